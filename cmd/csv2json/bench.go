@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+)
+
+// benchPhases are the measured stages of processing one file, in pipeline order
+var benchPhases = []string{"parse", "convert", "output", "total"}
+
+// benchCommand measures parse, convert and output throughput and latency on synthetic
+// files, using the parsing and broker settings of the environment it runs in
+func benchCommand(fs *flag.FlagSet) func(args []string) {
+	rows := fs.Int("rows", 10000, "Rows per synthetic file")
+	cols := fs.Int("cols", 10, "Columns per synthetic file")
+	files := fs.Int("files", 20, "Number of files to measure")
+	warmup := fs.Int("warmup", 2, "Unmeasured files processed first")
+	outputType := fs.String("output", "file", "Output to measure: file or queue")
+	queueName := fs.String("queue", "csv2json-bench", "Queue to publish to (must not exist yet; deleted afterwards)")
+	folder := fs.String("folder", "", "Folder for file output (default: a temporary folder)")
+	keep := fs.Bool("keep", false, "Keep the bench queue or output files instead of removing them")
+	return func(args []string) {
+		if *rows < 1 || *cols < 1 || *files < 1 || *warmup < 0 {
+			log.Fatal("bench needs at least one row, column and file")
+		}
+		if *outputType != "file" && *outputType != "queue" {
+			log.Fatalf("invalid --output: %s (must be file or queue)", *outputType)
+		}
+
+		// Queue credentials may come from a secrets provider
+		loadSecrets()
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		work, err := os.MkdirTemp("", "csv2json-bench-*")
+		if err != nil {
+			log.Fatalf("Failed to create work folder: %v", err)
+		}
+		defer os.RemoveAll(work)
+
+		samplePath := filepath.Join(work, "bench.csv")
+		size, err := writeBenchFile(samplePath, cfg, *rows, *cols)
+		if err != nil {
+			log.Fatalf("Failed to generate bench file: %v", err)
+		}
+
+		handler, cleanup, err := benchHandler(cfg, *outputType, *queueName, *folder, work, *keep)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+		defer cleanup()
+
+		fmt.Printf("Benchmarking %d file(s) of %d rows x %d columns (%.1f MB) with %s output\n",
+			*files, *rows, *cols, float64(size)/(1<<20), *outputType)
+		results, peakHeap, err := bench(cfg, handler, samplePath, *files, *warmup)
+		if err != nil {
+			log.Fatalf("Bench FAILED: %v", err)
+		}
+		printBenchResults(results, *files, *rows, size, peakHeap)
+	}
+}
+
+// benchHandler creates the output handler under test and a function that removes
+// what the bench wrote
+func benchHandler(cfg *config.Config, outputType, queueName, folder, work string, keep bool) (output.Handler, func(), error) {
+	opts := output.Options{ASCIISafe: cfg.ASCIISafeOutput}
+	if outputType == "file" {
+		outputFolder := folder
+		var err error
+		switch {
+		case folder != "":
+			err = os.MkdirAll(folder, 0755)
+		case keep:
+			// The work folder is removed, so kept output needs a folder of its own
+			outputFolder, err = os.MkdirTemp("", "csv2json-bench-output-*")
+		default:
+			outputFolder = filepath.Join(work, "output")
+			err = os.MkdirAll(outputFolder, 0755)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create output folder: %w", err)
+		}
+		handler, err := output.CreateHandlerWithOptions("file", outputFolder, "", "", 0, "", "", "", false, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		cleanup := func() {
+			handler.Close()
+			if keep {
+				log.Printf("Bench output kept in %s", outputFolder)
+				return
+			}
+			written, _ := filepath.Glob(filepath.Join(outputFolder, "bench-*.json"))
+			for _, path := range written {
+				os.Remove(path)
+			}
+		}
+		return handler, cleanup, nil
+	}
+
+	if cfg.QueueType != "rabbitmq" {
+		return nil, nil, fmt.Errorf("queue bench supports rabbitmq, got QUEUE_TYPE=%s", cfg.QueueType)
+	}
+	// Refusing existing queues means deleting the bench queue never drops real messages
+	exists, err := benchQueueExists(cfg, queueName)
+	if err != nil {
+		return nil, nil, err
+	}
+	if exists {
+		return nil, nil, fmt.Errorf("queue %s already exists; pass an unused --queue so the bench can delete it afterwards", queueName)
+	}
+	handler, err := output.CreateHandlerWithOptions("queue", "", cfg.QueueType, cfg.QueueHost, cfg.QueuePort, queueName, cfg.QueueUsername, cfg.QueuePassword, false, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to queue: %w", err)
+	}
+	cleanup := func() {
+		handler.Close()
+		if keep {
+			log.Printf("Bench messages kept on queue %s", queueName)
+			return
+		}
+		if err := deleteBenchQueue(cfg, queueName); err != nil {
+			log.Printf("Warning: failed to delete bench queue %s: %v", queueName, err)
+		}
+	}
+	return handler, cleanup, nil
+}
+
+// bench processes the sample file warmup+files times, timing each phase of every
+// measured file, and returns the phase durations and the peak heap in use
+func bench(cfg *config.Config, handler output.Handler, samplePath string, files, warmup int) (map[string][]time.Duration, uint64, error) {
+	p := parser.NewWithOptions(cfg.Delimiter, cfg.QuoteChar, cfg.HasHeader, parser.Options{
+		Encoding:          cfg.Encoding,
+		InvalidUTF8Policy: cfg.InvalidUTF8Policy,
+		Workers:           cfg.ParseWorkers,
+		ParallelMinSize:   cfg.ParallelParseMin,
+	})
+	conv := converter.NewWithOptions(converter.Options{ASCIISafe: cfg.ASCIISafeOutput})
+
+	results := make(map[string][]time.Duration, len(benchPhases))
+	var peakHeap uint64
+	var mem runtime.MemStats
+	for i := 0; i < warmup+files; i++ {
+		identifier := fmt.Sprintf("bench-%04d.csv", i)
+
+		start := time.Now()
+		result, err := p.ParseWithOrder(samplePath)
+		if err != nil {
+			return nil, 0, fmt.Errorf("parse failed: %w", err)
+		}
+		parsed := time.Now()
+		if _, err := conv.ToJSONOrdered(result); err != nil {
+			return nil, 0, fmt.Errorf("convert failed: %w", err)
+		}
+		converted := time.Now()
+		if err := handler.SendOrdered(result, identifier); err != nil {
+			return nil, 0, fmt.Errorf("output failed: %w", err)
+		}
+		sent := time.Now()
+
+		runtime.ReadMemStats(&mem)
+		peakHeap = max(peakHeap, mem.HeapInuse)
+		if i < warmup {
+			continue
+		}
+		results["parse"] = append(results["parse"], parsed.Sub(start))
+		results["convert"] = append(results["convert"], converted.Sub(parsed))
+		results["output"] = append(results["output"], sent.Sub(converted))
+		// The service parses and hands the result to the output, which renders it itself
+		results["total"] = append(results["total"], parsed.Sub(start)+sent.Sub(converted))
+	}
+	return results, peakHeap, nil
+}
+
+// printBenchResults prints throughput and latency percentiles per phase
+func printBenchResults(results map[string][]time.Duration, files, rows int, size int64, peakHeap uint64) {
+	fmt.Printf("\n%-8s %12s %10s %10s %10s %10s %10s\n", "phase", "rows/s", "MB/s", "p50", "p95", "p99", "max")
+	for _, phase := range benchPhases {
+		durations := results[phase]
+		var sum time.Duration
+		for _, d := range durations {
+			sum += d
+		}
+		seconds := sum.Seconds()
+		fmt.Printf("%-8s %12.0f %10.1f %10s %10s %10s %10s\n", phase,
+			float64(files*rows)/seconds, float64(int64(files)*size)/(1<<20)/seconds,
+			percentile(durations, 50), percentile(durations, 95), percentile(durations, 99), percentile(durations, 100))
+	}
+	fmt.Printf("\nconvert: rendering JSON alone; output: the output handler, including its own rendering;\n")
+	fmt.Printf("total: parse + output, as the service processes a file. Peak heap in use: %.1f MB\n", float64(peakHeap)/(1<<20))
+}
+
+// percentile returns the p-th percentile of durations (nearest rank), rounded for display
+func percentile(durations []time.Duration, p int) time.Duration {
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1].Round(10 * time.Microsecond)
+}
+
+// writeBenchFile writes a synthetic delimited file in the configured dialect, with a
+// mix of integer, decimal, date and text columns, returning its size
+func writeBenchFile(path string, cfg *config.Config, rows, cols int) (int64, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+	w := csv.NewWriter(file)
+	w.Comma = cfg.Delimiter
+	if cfg.HasHeader {
+		header := make([]string, cols)
+		for j := range header {
+			header[j] = fmt.Sprintf("col_%d", j)
+		}
+		w.Write(header)
+	}
+	record := make([]string, cols)
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < rows; i++ {
+		for j := range record {
+			switch j % 4 {
+			case 0:
+				record[j] = strconv.Itoa(i)
+			case 1:
+				record[j] = fmt.Sprintf("%d.%02d", i*7%10000, i%100)
+			case 2:
+				record[j] = day.AddDate(0, 0, i%365).Format("2006-01-02")
+			default:
+				record[j] = "value " + strings.Repeat(string(rune('a'+(i+j)%26)), 8+(i+j)%8)
+			}
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	return info.Size(), file.Close()
+}
+
+// benchChannel opens a channel on the configured broker; close the returned connection when done
+func benchChannel(cfg *config.Config) (*amqp.Connection, *amqp.Channel, error) {
+	amqpURL := fmt.Sprintf("amqp://%s:%d/%s", cfg.QueueHost, cfg.QueuePort, url.PathEscape(cfg.QueueVHost))
+	if cfg.QueueUsername != "" && cfg.QueuePassword != "" {
+		amqpURL = fmt.Sprintf("amqp://%s:%s@%s:%d/%s", cfg.QueueUsername, cfg.QueuePassword, cfg.QueueHost, cfg.QueuePort, url.PathEscape(cfg.QueueVHost))
+	}
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, fmt.Errorf("failed to open channel: %w", err)
+	}
+	return conn, ch, nil
+}
+
+// benchQueueExists reports whether a queue is already declared on the broker
+func benchQueueExists(cfg *config.Config, queueName string) (bool, error) {
+	conn, ch, err := benchChannel(cfg)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	// A passive declare of a missing queue fails with 404 and closes the channel
+	if _, err := ch.QueueDeclarePassive(queueName, true, false, false, false, nil); err != nil {
+		if amqpErr, ok := err.(*amqp.Error); ok && amqpErr.Code == amqp.NotFound {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check queue %s: %w", queueName, err)
+	}
+	return true, nil
+}
+
+// deleteBenchQueue deletes the queue the bench published to
+func deleteBenchQueue(cfg *config.Config, queueName string) error {
+	conn, ch, err := benchChannel(cfg)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = ch.QueueDelete(queueName, false, false, false)
+	return err
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// command is a csv2json subcommand
+type command struct {
+	name    string
+	usage   string // Arguments shown after the command name in its usage line
+	summary string // One line shown in the command list and shell completions
+	// setup registers the command's flags on fs and returns its action, which runs with
+	// the positional arguments once the flags are parsed
+	setup func(fs *flag.FlagSet) func(args []string)
+	words []string // Positional words completed after the command name (e.g. config show)
+}
+
+// defaultCommand runs when no command is given, so flag-only invocations of the
+// service keep working
+const defaultCommand = "run"
+
+// commands are the csv2json subcommands, in the order they are listed. They are
+// registered in init because help and completion refer back to the list.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{name: "run", usage: "[OPTIONS]", summary: "Run the conversion service (default)", setup: serviceRunCommand},
+		{name: "convert", usage: "input.csv [--route NAME] [-o output.json] [--force]", summary: "Convert one file to JSON with a route's settings", setup: convertCommand},
+		{name: "reverse", usage: "input.json [-o output.csv] [--delimiter ,] [--force]", summary: "Convert a JSON array back into CSV", setup: reverseCommand},
+		{name: "validate-routes", usage: "[--routes routes.json]", summary: "Check routes.json and the contracts it enforces", setup: validateRoutesCommand},
+		{name: "test-route", usage: "[--route NAME] --file sample.csv", summary: "Dry-run a file through a route and print its output", setup: testRouteCommand},
+		{name: "replay", usage: "[--route NAME] [--from failed|processed] [--match GLOB] [--dry-run]", summary: "Move archived files back into the input folder", setup: replayCommand},
+		{name: "release", usage: "[--route NAME] [--match GLOB] [--dry-run]", summary: "Release files held for a completed business date", setup: releaseCommand},
+		{name: "history", usage: "[--route NAME] [--days N] [--json]", summary: "Report throughput and error rates per route and day", setup: historyCommand},
+		{name: "rescan-ignored", usage: "[--route NAME] [--dry-run]", summary: "Requeue ignored files that now pass the filters", setup: rescanIgnoredCommand},
+		{name: "selftest", usage: "[--route NAME] [--rows N] [--columns a,b,c] [--filename NAME]", summary: "Round-trip a generated sample through a route", setup: selftestCommand},
+		{name: "bench", usage: "[--rows N] [--cols M] [--files N] [--output file|queue]", summary: "Measure throughput and latency", setup: benchCommand},
+		{name: "healthcheck", usage: "[--addr HOST:PORT] [--timeout 5s]", summary: "Check that a running service is up and its routes are running", setup: healthcheckCommand},
+		{name: "init", usage: "[--mode legacy|routes] [--watch-mode MODE] [--output TYPE] [...]", summary: "Generate a starter .env and routes.json", setup: initCommand},
+		{name: "config", usage: "show [--config FILE] [--set KEY=VALUE ...] [--json]", summary: "Show the effective configuration", setup: configCommand, words: []string{"show"}},
+		{name: "service", usage: "install|uninstall|start|stop [--name NAME] [--workdir DIR]", summary: "Manage the Windows service", setup: serviceCommand, words: []string{"install", "uninstall", "start", "stop"}},
+		{name: "completion", usage: "bash|zsh|fish", summary: "Print a shell completion script", setup: completionCommand, words: completionShells},
+		{name: "help", usage: "[COMMAND]", summary: "Show help for csv2json or a command", setup: helpCommand},
+	}
+	for _, cmd := range commands {
+		if cmd.name == "help" {
+			cmd.words = commandNames()
+		}
+	}
+}
+
+// runCommand runs the command named by the first argument, or the default command
+// when the arguments are empty or start with a flag
+func runCommand(args []string) {
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "Unknown command %q. Run 'csv2json help' for the list of commands.\n", name)
+		os.Exit(2)
+	}
+
+	fs := cmd.flagSet()
+	action := cmd.setup(fs)
+	action(parseArgs(fs, args))
+}
+
+// findCommand returns the command called name (nil if there is none)
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// commandNames returns the names of all commands
+func commandNames() []string {
+	names := make([]string, len(commands))
+	for i, cmd := range commands {
+		names[i] = cmd.name
+	}
+	return names
+}
+
+// flagSet returns an empty flag set for the command that prints its usage on errors
+func (c *command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: csv2json %s %s\n\n%s.\n", c.name, c.usage, c.summary)
+		if hasFlags(fs) {
+			fmt.Fprintln(out, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// hasFlags reports whether any flags are registered on fs
+func hasFlags(fs *flag.FlagSet) bool {
+	found := false
+	fs.VisitAll(func(*flag.Flag) { found = true })
+	return found
+}
+
+// parseArgs parses flags wherever they appear among the arguments (csv2json reverse
+// output.json -o partner.csv) and returns the positional arguments. Arguments after
+// "--" are positional.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...)
+		}
+		if len(rest) == 0 {
+			return positional
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// helpCommand prints the full help, or the usage and flags of one command
+func helpCommand(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		if len(args) == 0 {
+			printHelp()
+			return
+		}
+		cmd := findCommand(args[0])
+		if cmd == nil {
+			fmt.Fprintf(os.Stderr, "Unknown command %q. Commands: %s\n", args[0], strings.Join(commandNames(), ", "))
+			os.Exit(2)
+		}
+		if cmd.name == defaultCommand {
+			printHelp()
+			return
+		}
+		cmdFlags := cmd.flagSet()
+		cmdFlags.SetOutput(os.Stdout)
+		cmd.setup(cmdFlags)
+		cmdFlags.Usage()
+	}
+}
+
+// commandUsage returns the usage line of each command for the help text
+func commandUsage() string {
+	var usage strings.Builder
+	for _, cmd := range commands {
+		name := cmd.name
+		if name == defaultCommand {
+			name = "[" + name + "]"
+		}
+		fmt.Fprintf(&usage, "    csv2json %s %s\n", name, cmd.usage)
+	}
+	return usage.String()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// completionShells are the shells completion scripts are generated for
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionCommand prints a completion script for the commands, their flags and
+// their positional words, generated from the command list
+func completionCommand(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		if len(args) != 1 {
+			log.Fatalf("usage: csv2json completion %s", strings.Join(completionShells, "|"))
+		}
+		switch args[0] {
+		case "bash":
+			writeBashCompletion(os.Stdout)
+		case "zsh":
+			writeZshCompletion(os.Stdout)
+		case "fish":
+			writeFishCompletion(os.Stdout)
+		default:
+			log.Fatalf("unsupported shell: %s (supported: %s)", args[0], strings.Join(completionShells, ", "))
+		}
+	}
+}
+
+// completionFlag is a flag as completion scripts offer it
+type completionFlag struct {
+	name    string // Flag name without dashes
+	usage   string
+	boolean bool // The flag takes no value
+}
+
+// option returns the flag as written on the command line: -o, --route
+func (f completionFlag) option() string {
+	if len(f.name) == 1 {
+		return "-" + f.name
+	}
+	return "--" + f.name
+}
+
+// completionFlags returns the flags of cmd
+func (c *command) completionFlags() []completionFlag {
+	fs := c.flagSet()
+	c.setup(fs)
+	var flags []completionFlag
+	fs.VisitAll(func(f *flag.Flag) {
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		flags = append(flags, completionFlag{name: f.Name, usage: f.Usage, boolean: ok && boolFlag.IsBoolFlag()})
+	})
+	return flags
+}
+
+// options returns the command line options of flags, space-separated
+func options(flags []completionFlag) string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = f.option()
+	}
+	return strings.Join(names, " ")
+}
+
+// writeBashCompletion writes a bash completion script (source it, or install it in
+// bash-completion's completions folder as csv2json)
+func writeBashCompletion(w io.Writer) {
+	run := findCommand(defaultCommand)
+	fmt.Fprintf(w, `# bash completion for csv2json
+_csv2json() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" cmd="${COMP_WORDS[1]}" flags="" words=""
+    if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+        COMPREPLY=($(compgen -W %q -- "$cur"))
+        return
+    fi
+    [[ $cmd == -* ]] && cmd=%s
+    case "$cmd" in
+`, strings.Join(commandNames(), " "), run.name)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "        %s) flags=%q; words=%q ;;\n", cmd.name, options(cmd.completionFlags()), strings.Join(cmd.words, " "))
+	}
+	fmt.Fprint(w, `    esac
+    if [[ $cur == -* ]]; then
+        COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+    elif [[ $COMP_CWORD -eq 2 && -n $words ]]; then
+        COMPREPLY=($(compgen -W "$words" -- "$cur"))
+    fi
+}
+complete -o default -F _csv2json csv2json
+`)
+}
+
+// writeZshCompletion writes a zsh completion script (source it, or install it on
+// $fpath as _csv2json)
+func writeZshCompletion(w io.Writer) {
+	fmt.Fprint(w, `#compdef csv2json
+
+_csv2json() {
+    local -a commands
+    commands=(
+`)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "        %s\n", zshQuote(cmd.name+":"+cmd.summary))
+	}
+	fmt.Fprintf(w, `    )
+    if (( CURRENT == 2 )) && [[ $words[2] != -* ]]; then
+        _describe -t commands 'csv2json command' commands
+        return
+    fi
+    local cmd=$words[2]
+    if [[ $cmd == -* ]]; then
+        cmd=%s
+    else
+        shift words
+        (( CURRENT-- ))
+    fi
+    case $cmd in
+`, defaultCommand)
+	for _, cmd := range commands {
+		specs := []string{}
+		for _, f := range cmd.completionFlags() {
+			spec := f.option() + "[" + zshEscape(f.usage) + "]"
+			if !f.boolean {
+				spec += ":" + f.name + ":_files"
+			}
+			specs = append(specs, zshQuote(spec))
+		}
+		if len(cmd.words) > 0 {
+			specs = append(specs, zshQuote("1:"+cmd.name+":("+strings.Join(cmd.words, " ")+")"))
+		}
+		specs = append(specs, zshQuote("*:file:_files"))
+		fmt.Fprintf(w, "        %s) _arguments %s ;;\n", cmd.name, strings.Join(specs, " "))
+	}
+	fmt.Fprint(w, `    esac
+}
+
+if [[ $funcstack[1] == _csv2json ]]; then
+    _csv2json "$@"
+else
+    compdef _csv2json csv2json
+fi
+`)
+}
+
+// writeFishCompletion writes a fish completion script (install it in
+// ~/.config/fish/completions as csv2json.fish)
+func writeFishCompletion(w io.Writer) {
+	fmt.Fprintln(w, "# fish completion for csv2json")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "complete -c csv2json -n __fish_use_subcommand -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+	}
+	for _, cmd := range commands {
+		condition := "__fish_seen_subcommand_from " + cmd.name
+		if cmd.name == defaultCommand {
+			condition = "__fish_use_subcommand; or " + condition
+		}
+		for _, f := range cmd.completionFlags() {
+			option := "-l " + f.name
+			if len(f.name) == 1 {
+				option = "-s " + f.name
+			}
+			if !f.boolean {
+				option += " -r"
+			}
+			fmt.Fprintf(w, "complete -c csv2json -n %s %s -d %s\n", fishQuote(condition), option, fishQuote(f.usage))
+		}
+		if len(cmd.words) > 0 {
+			fmt.Fprintf(w, "complete -c csv2json -n %s -f -a %s\n", fishQuote(condition), fishQuote(strings.Join(cmd.words, " ")))
+		}
+	}
+}
+
+// zshEscape escapes the characters _arguments treats specially in descriptions
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
+
+// zshQuote single-quotes s for zsh
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote single-quotes s for fish
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"csv2json/internal/config"
+)
+
+// settingFlags are command-line flags for the main settings, each overriding one
+// environment variable. Other settings are overridden with --set KEY=VALUE.
+var settingFlags = []struct {
+	name  string
+	key   string
+	usage string
+}{
+	{"routes", "ROUTES_CONFIG", "Path to routes.json (multi-ingress routing mode)"},
+	{"input", "INPUT_FOLDER", "Folder monitored for input files"},
+	{"watch-mode", "WATCH_MODE", "File detection: event, poll or hybrid"},
+	{"poll-interval", "POLL_INTERVAL_SECONDS", "Polling interval, seconds or a duration like 500ms"},
+	{"delimiter", "DELIMITER", "Field delimiter"},
+	{"has-header", "HAS_HEADER", "Whether files start with a header row (true or false)"},
+	{"output-type", "OUTPUT_TYPE", "Output: file, queue, both, stdout or pipe"},
+	{"output", "OUTPUT_FOLDER", "Folder JSON output files are written to"},
+	{"output-pipe", "OUTPUT_PIPE", "Named pipe written by pipe output"},
+	{"queue-type", "QUEUE_TYPE", "Queue system: rabbitmq"},
+	{"queue-host", "QUEUE_HOST", "Queue server host"},
+	{"queue-port", "QUEUE_PORT", "Queue server port"},
+	{"queue-vhost", "QUEUE_VHOST", "RabbitMQ virtual host"},
+	{"queue-name", "QUEUE_NAME", "Queue messages are published to"},
+	{"archive-processed", "ARCHIVE_PROCESSED", "Archive folder for processed files"},
+	{"archive-ignored", "ARCHIVE_IGNORED", "Archive folder for ignored files"},
+	{"archive-failed", "ARCHIVE_FAILED", "Archive folder for failed files"},
+	{"work-dir", "WORK_DIR", "Working directory files are moved to while processed"},
+	{"log-level", "LOG_LEVEL", "Logging level"},
+	{"log-file", "LOG_FILE", "File log output is mirrored to"},
+	{"metrics-addr", "METRICS_ADDR", "Listen address for the Prometheus /metrics endpoint"},
+	{"admin-addr", "ADMIN_ADDR", "Listen address for the admin API (POST /trigger)"},
+}
+
+// layerFlags registers --config, --set and the setting flags on fs and returns a
+// function applying them as configuration layers once fs is parsed
+func layerFlags(fs *flag.FlagSet) func() error {
+	configFile := fs.String("config", "", "Configuration file: .env or flat YAML (default: CONFIG_FILE, then .env)")
+	overrides := make(map[string]string)
+	for _, setting := range settingFlags {
+		key := setting.key
+		fs.Func(setting.name, fmt.Sprintf("%s (overrides %s)", setting.usage, key), func(value string) error {
+			overrides[key] = value
+			return nil
+		})
+	}
+	fs.Func("set", "Override a setting as KEY=VALUE, taking precedence over the environment (repeatable)", func(setting string) error {
+		key, value, err := config.ParseOverride(setting)
+		if err != nil {
+			return err
+		}
+		overrides[key] = value
+		return nil
+	})
+	return func() error {
+		if *configFile != "" {
+			overrides["CONFIG_FILE"] = *configFile
+		}
+		return config.ApplyLayers(overrides)
+	}
+}
+
+// configCommand handles the config subcommand
+func configCommand(fs *flag.FlagSet) func(args []string) {
+	jsonOutput := fs.Bool("json", false, "Print the settings as JSON")
+	applyLayers := layerFlags(fs)
+	return func(args []string) {
+		if len(args) != 1 || args[0] != "show" {
+			fmt.Fprintln(os.Stderr, "Usage: csv2json config show [--config FILE] [--set KEY=VALUE ...] [--json]")
+			os.Exit(2)
+		}
+		if err := applyLayers(); err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		// Secrets are fetched as the service would, so their settings show their source
+		fetchSecrets()
+
+		settings, err := config.Effective()
+		if *jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if encodeErr := encoder.Encode(settings); encodeErr != nil {
+				log.Fatalf("Failed to write settings: %v", encodeErr)
+			}
+		} else {
+			fmt.Printf("Config file: %s\n", config.ConfigFile())
+			fmt.Println("Precedence: default < file < environment < secrets < flag")
+			fmt.Println()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SETTING\tVALUE\tSOURCE")
+			for _, setting := range settings {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", setting.Key, setting.Value, setting.Source)
+			}
+			w.Flush()
+		}
+
+		// Invalid configuration is reported after the settings, so they can be used to find the cause
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nConfiguration is invalid: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+	"csv2json/internal/processor"
+)
+
+// convertCommand converts one file to JSON with the parsing settings, transforms and
+// contract of the environment or a route, without archiving it or publishing
+func convertCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Route whose settings to convert with (multi-ingress mode)")
+	outputPath := fs.String("o", "", "Output JSON path (default: input path with .json extension, - for stdout)")
+	force := fs.Bool("force", false, "Overwrite an existing output file")
+	return func(args []string) {
+		if len(args) != 1 {
+			log.Fatal("usage: csv2json convert input.csv [--route NAME] [-o output.json] [--force]")
+		}
+		inputPath := args[0]
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		target := &selftestRoute{cfg: cfg}
+		if *routeName != "" {
+			if cfg.RoutesConfigPath == "" {
+				log.Fatal("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+			}
+			if target, err = selftestRouteConfig(cfg.RoutesConfigPath, *routeName); err != nil {
+				log.Fatalf("%v", err)
+			}
+		}
+
+		proc, err := processor.NewConverter(target.cfg)
+		if err != nil {
+			log.Fatalf("Failed to create route pipeline: %v", err)
+		}
+		if target.schema != nil {
+			proc.SetContract(target.schema)
+		}
+
+		result, err := proc.Convert(inputPath)
+		if err != nil {
+			log.Fatalf("Conversion failed: %v", err)
+		}
+		if result == nil {
+			return
+		}
+		jsonBytes, err := converter.NewWithOptions(target.cfg.ConverterOptions()).ToJSONOrdered(result)
+		if err != nil {
+			log.Fatalf("Conversion failed: %v", err)
+		}
+
+		if *outputPath == "" {
+			*outputPath = filepath.Join(filepath.Dir(inputPath), converter.GetOutputFilename(filepath.Base(inputPath)))
+		}
+		if err := writeConverted(*outputPath, jsonBytes, *force); err != nil {
+			log.Fatalf("Conversion failed: %v", err)
+		}
+		if *outputPath != "-" {
+			fmt.Printf("Wrote %d row(s) to %s\n", len(result.Rows), *outputPath)
+		}
+	}
+}
+
+// writeConverted writes JSON to outputPath ("-" for stdout)
+func writeConverted(outputPath string, jsonBytes []byte, force bool) error {
+	if outputPath == "-" {
+		_, err := fmt.Fprintf(os.Stdout, "%s\n", jsonBytes)
+		return err
+	}
+	if !force {
+		if _, err := os.Stat(outputPath); err == nil {
+			return fmt.Errorf("%s already exists (use --force to overwrite)", outputPath)
+		}
+	}
+	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+// routeUpMetric is the gauge the service reports each route's state in (1 = running)
+const routeUpMetric = "csv2json_route_up"
+
+// healthcheckCommand checks a running service through its metrics endpoint, for use as
+// a container health check. It fails if the endpoint does not answer or a route is down.
+func healthcheckCommand(fs *flag.FlagSet) func(args []string) {
+	addr := fs.String("addr", "", "Metrics address of the service (default: METRICS_ADDR)")
+	timeout := fs.Duration("timeout", 5*time.Second, "How long to wait for the metrics endpoint")
+	return func(args []string) {
+		if *addr == "" {
+			cfg, err := config.Load()
+			if err != nil {
+				log.Fatalf("Failed to load configuration: %v", err)
+			}
+			if cfg.MetricsAddr == "" {
+				log.Fatal("No metrics endpoint: pass --addr or set METRICS_ADDR")
+			}
+			*addr = cfg.MetricsAddr
+		}
+
+		up, down, err := checkHealth(metricsURL(*addr), *timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "UNHEALTHY: %v\n", err)
+			os.Exit(1)
+		}
+		if len(down) > 0 {
+			fmt.Fprintf(os.Stderr, "UNHEALTHY: route(s) down: %s\n", strings.Join(down, ", "))
+			os.Exit(1)
+		}
+		// Legacy single-input mode reports no route state; answering is all that is checked
+		if up == 0 {
+			fmt.Println("HEALTHY: metrics endpoint answered")
+			return
+		}
+		fmt.Printf("HEALTHY: %d route(s) up\n", up)
+	}
+}
+
+// metricsURL returns the /metrics URL of a listen address, connecting to localhost
+// when the service listens on all interfaces (":9090", "0.0.0.0:9090")
+func metricsURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr + "/metrics"
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/metrics"
+}
+
+// checkHealth reads the metrics at url and returns the number of routes up and the
+// names of the routes down
+func checkHealth(url string, timeout time.Duration) (int, []string, error) {
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, nil, fmt.Errorf("metrics endpoint unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, nil, fmt.Errorf("metrics endpoint returned %s", resp.Status)
+	}
+
+	up := 0
+	var down []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, routeUpMetric+"{") {
+			continue
+		}
+		// Route names may contain spaces, so the value follows the last one
+		i := strings.LastIndexByte(line, ' ')
+		if i < 0 {
+			continue
+		}
+		series, value := line[:i], line[i+1:]
+		if value == "1" {
+			up++
+		} else {
+			down = append(down, routeLabel(series))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, nil, fmt.Errorf("failed to read metrics: %w", err)
+	}
+	return up, down, nil
+}
+
+// routeLabel returns the route label of a series such as csv2json_route_up{route="orders"}
+func routeLabel(series string) string {
+	_, rest, ok := strings.Cut(series, `route="`)
+	if !ok {
+		return series
+	}
+	route, _, _ := strings.Cut(rest, `"`)
+	return route
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// historyCommand replays each route's archive folders and delivery receipt log into a
+// per-route, per-day report of throughput and error rates, for capacity and reliability
+// reviews without a separate analytics stack
+func historyCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Only report this route (default: every route)")
+	days := fs.Int("days", 7, "Days to report, ending today (0 = all history)")
+	jsonOutput := fs.Bool("json", false, "Print the report as JSON")
+	return func(args []string) {
+		if *days < 0 {
+			log.Fatalf("invalid --days: %d (must be >= 0)", *days)
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+		routes, err := historyRoutes(cfg, *routeName)
+		if err != nil {
+			log.Fatalf("%v", err)
+		}
+
+		var since time.Time
+		if *days > 0 {
+			year, month, day := time.Now().AddDate(0, 0, 1-*days).Date()
+			since = time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+		}
+		report, err := buildHistory(routes, since)
+		if err != nil {
+			log.Fatalf("History failed: %v", err)
+		}
+
+		if *jsonOutput {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				log.Fatalf("Failed to write report: %v", err)
+			}
+			return
+		}
+		if len(report) == 0 {
+			fmt.Println("No processing history in the reported period")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ROUTE\tDAY\tPROCESSED\tFAILED\tIGNORED\tERROR RATE\tDELIVERIES\tDELIVERY ERRORS\tROWS\tBYTES")
+		for _, day := range report {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%d\t%.1f%%\t%d\t%d\t%d\t%d\n", day.Route, day.Day, day.Processed, day.Failed,
+				day.Ignored, day.ErrorRate*100, day.Deliveries, day.DeliveryErrors, day.Rows, day.Bytes)
+		}
+		w.Flush()
+	}
+}
+
+// historyRoute is a route whose history is reported
+type historyRoute struct {
+	name string // "default" in legacy single-input mode
+	cfg  *config.Config
+}
+
+// historyRoutes returns the routes to report: every route (or the one named) in
+// multi-ingress mode, else the single legacy input
+func historyRoutes(cfg *config.Config, name string) ([]historyRoute, error) {
+	if cfg.RoutesConfigPath == "" {
+		if name != "" {
+			return nil, fmt.Errorf("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+		}
+		return []historyRoute{{name: "default", cfg: cfg}}, nil
+	}
+
+	routesConfig, err := config.LoadRoutes(cfg.RoutesConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routes configuration: %w", err)
+	}
+	var routes []historyRoute
+	for _, route := range routesConfig.Routes {
+		if name == "" || route.Name == name {
+			routes = append(routes, historyRoute{name: route.Name, cfg: route.ToLegacyConfig()})
+		}
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("route '%s' not found in %s", name, cfg.RoutesConfigPath)
+	}
+	return routes, nil
+}
+
+// historyDay is one route's activity on one day
+type historyDay struct {
+	Route          string         `json:"route"`
+	Day            string         `json:"day"` // Date in the route's TIMEZONE (else local), YYYY-MM-DD
+	Processed      int            `json:"processed"`
+	Failed         int            `json:"failed"`
+	Ignored        int            `json:"ignored"`
+	FailedBy       map[string]int `json:"failedByCategory,omitempty"` // Failed files by error category ("uncategorized" if none)
+	ErrorRate      float64        `json:"errorRate"`                  // Failed / (processed + failed)
+	Deliveries     int            `json:"deliveries"`                 // Messages published or files written
+	DeliveryErrors int            `json:"deliveryErrors"`             // Deliveries that failed, were nacked, timed out or were rejected downstream
+	Rows           int            `json:"rows"`                       // Rows delivered
+	Bytes          int64          `json:"bytes"`                      // Payload bytes delivered
+}
+
+// deliveryErrorStatuses are the receipt statuses of deliveries that did not succeed
+var deliveryErrorStatuses = map[string]bool{
+	output.ReceiptFailed:   true,
+	output.ReceiptNacked:   true,
+	output.ReceiptTimeout:  true,
+	output.ReceiptRejected: true,
+}
+
+// buildHistory counts each route's archived files and delivery receipts per day since
+// since (zero = all history), sorted by route and day
+func buildHistory(routes []historyRoute, since time.Time) ([]*historyDay, error) {
+	days := make(map[string]*historyDay)
+	locations := make(map[string]*time.Location) // Each route's days follow its TIMEZONE
+	dayOf := func(route string, at time.Time) *historyDay {
+		if at.Before(since) {
+			return nil
+		}
+		loc := locations[route]
+		if loc == nil {
+			loc = time.Local
+		}
+		day := at.In(loc).Format("2006-01-02")
+		key := route + "\x00" + day
+		if days[key] == nil {
+			days[key] = &historyDay{Route: route, Day: day}
+		}
+		return days[key]
+	}
+
+	selected := make(map[string]bool)
+	receiptLogs := make(map[string]bool)
+	for _, route := range routes {
+		selected[route.name] = true
+		locations[route.name] = route.cfg.Location()
+		if route.cfg.ReceiptLog != "" {
+			receiptLogs[route.cfg.ReceiptLog] = true
+		}
+
+		arch := archiver.New(route.cfg.ArchiveProcessed, route.cfg.ArchiveIgnored, route.cfg.ArchiveFailed, route.cfg.ArchiveTimestamp)
+		arch.SetLocation(route.cfg.Location())
+		for _, category := range []archiver.Category{archiver.CategoryProcessed, archiver.CategoryFailed, archiver.CategoryIgnored} {
+			files, err := arch.ArchivedFiles(category)
+			if err != nil {
+				return nil, err
+			}
+			for _, file := range files {
+				day := dayOf(route.name, file.ArchivedAt)
+				if day == nil {
+					continue
+				}
+				switch category {
+				case archiver.CategoryProcessed:
+					day.Processed++
+				case archiver.CategoryFailed:
+					day.Failed++
+					if day.FailedBy == nil {
+						day.FailedBy = make(map[string]int)
+					}
+					kind := file.Category
+					if kind == "" {
+						kind = "uncategorized"
+					}
+					day.FailedBy[kind]++
+				case archiver.CategoryIgnored:
+					day.Ignored++
+				}
+			}
+		}
+	}
+
+	// Routes may share a receipt log; each receipt names the route that delivered it
+	for path := range receiptLogs {
+		err := output.ReadReceipts(path, func(r output.Receipt) {
+			route := r.Route
+			if route == "" {
+				route = "default"
+			}
+			at, err := time.Parse(time.RFC3339Nano, r.Timestamp)
+			if !selected[route] || err != nil {
+				return
+			}
+			day := dayOf(route, at)
+			if day == nil {
+				return
+			}
+			if deliveryErrorStatuses[r.Status] {
+				day.DeliveryErrors++
+				return
+			}
+			day.Deliveries++
+			day.Rows += r.Rows
+			day.Bytes += int64(r.Bytes)
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read receipt log %s: %w", path, err)
+		}
+	}
+
+	report := make([]*historyDay, 0, len(days))
+	for _, day := range days {
+		if total := day.Processed + day.Failed; total > 0 {
+			day.ErrorRate = float64(day.Failed) / float64(total)
+		}
+		report = append(report, day)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Route != report[j].Route {
+			return report[i].Route < report[j].Route
+		}
+		return report[i].Day < report[j].Day
+	})
+	return report, nil
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+// Archive layouts offered by init for multi-ingress routes
+const (
+	archiveLayoutPerRoute = "per-route" // ./data/archive/<route>/{processed,failed,ignored}
+	archiveLayoutShared   = "shared"    // ./data/archive/{processed,failed,ignored} for all routes
+)
+
+// initOptions are the choices that shape the generated configuration
+type initOptions struct {
+	dir           string
+	mode          string // "legacy" or "routes"
+	routes        []string
+	watchMode     string
+	output        string
+	queueHost     string
+	queuePort     int
+	dataDir       string
+	archiveLayout string
+	force         bool
+}
+
+// initCommand generates a starter .env (and routes.json in routes mode) from flags,
+// prompting for each choice when run interactively
+func initCommand(fs *flag.FlagSet) func(args []string) {
+	opts := initOptions{}
+	fs.StringVar(&opts.dir, "dir", ".", "Directory to write the generated files to")
+	fs.StringVar(&opts.mode, "mode", "legacy", "Configuration mode: legacy (single input, .env only) or routes (routes.json)")
+	routes := fs.String("routes", "main", "Comma-separated route names (routes mode)")
+	fs.StringVar(&opts.watchMode, "watch-mode", "event", "File detection: event, poll, or hybrid")
+	fs.StringVar(&opts.output, "output", "file", "Output type: file, queue, or both")
+	fs.StringVar(&opts.queueHost, "queue-host", "localhost", "RabbitMQ host for queue output")
+	fs.IntVar(&opts.queuePort, "queue-port", 5672, "RabbitMQ port for queue output")
+	fs.StringVar(&opts.dataDir, "data-dir", "./data", "Base folder for input, output and archive folders")
+	fs.StringVar(&opts.archiveLayout, "archive-layout", archiveLayoutPerRoute, "Archive layout in routes mode: per-route or shared")
+	fs.BoolVar(&opts.force, "force", false, "Overwrite existing files")
+	interactive := fs.Bool("interactive", false, "Prompt for each setting (default when run in a terminal without flags)")
+	return func(args []string) {
+		opts.routes = splitNames(*routes)
+		if *interactive || (fs.NFlag() == 0 && isTerminal(os.Stdin)) {
+			if err := promptInitOptions(os.Stdin, os.Stdout, &opts); err != nil {
+				log.Fatalf("Failed to read answers: %v", err)
+			}
+		}
+
+		written, err := writeInitFiles(opts)
+		if err != nil {
+			log.Fatalf("Failed to generate configuration: %v", err)
+		}
+		for _, path := range written {
+			fmt.Printf("Wrote %s\n", path)
+		}
+		fmt.Println("Review the generated files, then start the service with: csv2json")
+	}
+}
+
+// promptInitOptions asks for each setting, offering the current value as the default
+func promptInitOptions(in io.Reader, out io.Writer, opts *initOptions) error {
+	reader := bufio.NewReader(in)
+	ask := func(question, current string, choices ...string) (string, error) {
+		for {
+			hint := current
+			if len(choices) > 0 {
+				hint = strings.Join(choices, "/") + ", default " + current
+			}
+			fmt.Fprintf(out, "%s [%s]: ", question, hint)
+			line, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return "", err
+			}
+			answer := strings.TrimSpace(line)
+			if answer == "" {
+				return current, nil
+			}
+			if len(choices) == 0 || contains(choices, answer) {
+				return answer, nil
+			}
+			fmt.Fprintf(out, "Please answer one of: %s\n", strings.Join(choices, ", "))
+		}
+	}
+
+	var err error
+	if opts.mode, err = ask("Configuration mode", opts.mode, "legacy", "routes"); err != nil {
+		return err
+	}
+	if opts.mode == "routes" {
+		names, err := ask("Route names (comma-separated)", strings.Join(opts.routes, ","))
+		if err != nil {
+			return err
+		}
+		opts.routes = splitNames(names)
+	}
+	if opts.watchMode, err = ask("Watch mode", opts.watchMode, "event", "poll", "hybrid"); err != nil {
+		return err
+	}
+	if opts.output, err = ask("Output type", opts.output, "file", "queue", "both"); err != nil {
+		return err
+	}
+	if opts.output != "file" {
+		if opts.queueHost, err = ask("RabbitMQ host", opts.queueHost); err != nil {
+			return err
+		}
+		port, err := ask("RabbitMQ port", strconv.Itoa(opts.queuePort))
+		if err != nil {
+			return err
+		}
+		if opts.queuePort, err = strconv.Atoi(port); err != nil {
+			return fmt.Errorf("invalid port %q", port)
+		}
+	}
+	if opts.dataDir, err = ask("Data folder", opts.dataDir); err != nil {
+		return err
+	}
+	if opts.mode == "routes" {
+		if opts.archiveLayout, err = ask("Archive layout", opts.archiveLayout, archiveLayoutPerRoute, archiveLayoutShared); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate checks the option combination before anything is written
+func (o initOptions) validate() error {
+	if o.mode != "legacy" && o.mode != "routes" {
+		return fmt.Errorf("invalid --mode: %s (must be legacy or routes)", o.mode)
+	}
+	if !contains([]string{"event", "poll", "hybrid"}, o.watchMode) {
+		return fmt.Errorf("invalid --watch-mode: %s (must be event, poll, or hybrid)", o.watchMode)
+	}
+	if !contains([]string{"file", "queue", "both"}, o.output) {
+		return fmt.Errorf("invalid --output: %s (must be file, queue, or both)", o.output)
+	}
+	if o.mode == "routes" {
+		if len(o.routes) == 0 {
+			return fmt.Errorf("--routes must name at least one route")
+		}
+		if o.archiveLayout != archiveLayoutPerRoute && o.archiveLayout != archiveLayoutShared {
+			return fmt.Errorf("invalid --archive-layout: %s (must be per-route or shared)", o.archiveLayout)
+		}
+	}
+	return nil
+}
+
+// writeInitFiles renders the configuration, writes it to opts.dir and creates the
+// data folders it refers to, refusing to overwrite existing files unless forced.
+// It returns the paths written.
+func writeInitFiles(opts initOptions) ([]string, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{".env": []byte(renderEnv(opts))}
+	order := []string{".env"}
+	if opts.mode == "routes" {
+		data, err := json.MarshalIndent(buildRoutes(opts), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files["routes.json"] = append(data, '\n')
+		order = append(order, "routes.json")
+	}
+
+	if !opts.force {
+		for _, name := range order {
+			path := filepath.Join(opts.dir, name)
+			if _, err := os.Stat(path); err == nil {
+				return nil, fmt.Errorf("%s already exists (use --force to overwrite)", path)
+			}
+		}
+	}
+	if err := os.MkdirAll(opts.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", opts.dir, err)
+	}
+
+	var written []string
+	for _, name := range order {
+		path := filepath.Join(opts.dir, name)
+		if err := os.WriteFile(path, files[name], 0644); err != nil {
+			return written, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	// Create the folders the configuration refers to; routes require existing input folders
+	for _, folder := range dataFolders(opts) {
+		if !filepath.IsAbs(folder) {
+			folder = filepath.Join(opts.dir, folder)
+		}
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return written, fmt.Errorf("failed to create %s: %w", folder, err)
+		}
+	}
+	return written, nil
+}
+
+// renderEnv renders the starter .env for the chosen options
+func renderEnv(opts initOptions) string {
+	var b strings.Builder
+	line := func(format string, args ...any) { fmt.Fprintf(&b, format+"\n", args...) }
+	data := filepath.ToSlash(opts.dataDir)
+
+	line("# Generated by csv2json init; see .env.example for all options")
+	line("")
+	if opts.mode == "routes" {
+		line("# Multi-ingress routing mode: inputs, outputs and archives are configured per route")
+		line("ROUTES_CONFIG=./routes.json")
+	} else {
+		line("# Input")
+		line("INPUT_FOLDER=%s/input", data)
+		line("FILE_SUFFIX_FILTER=.csv")
+		line("WATCH_MODE=%s", opts.watchMode)
+		line("POLL_INTERVAL_SECONDS=5")
+		if opts.watchMode == "hybrid" {
+			line("HYBRID_POLL_INTERVAL_SECONDS=60")
+		}
+		line("")
+		line("# Parsing")
+		line("HAS_HEADER=true")
+		line("DELIMITER=,")
+		line("")
+		line("# Output")
+		line("OUTPUT_TYPE=%s", opts.output)
+		if opts.output != "queue" {
+			line("OUTPUT_FOLDER=%s/output", data)
+		}
+	}
+
+	if opts.output != "file" {
+		line("")
+		line("# Queue connection (prefer QUEUE_PASSWORD_FILE over QUEUE_PASSWORD)")
+		line("QUEUE_TYPE=rabbitmq")
+		line("QUEUE_HOST=%s", opts.queueHost)
+		line("QUEUE_PORT=%d", opts.queuePort)
+		if opts.mode != "routes" {
+			line("QUEUE_NAME=csv2json_output")
+		}
+		line("QUEUE_USERNAME=")
+		line("QUEUE_PASSWORD_FILE=")
+	}
+
+	if opts.mode != "routes" {
+		line("")
+		line("# Archive")
+		line("ARCHIVE_PROCESSED=%s/archive/processed", data)
+		line("ARCHIVE_FAILED=%s/archive/failed", data)
+		line("ARCHIVE_IGNORED=%s/archive/ignored", data)
+		line("ARCHIVE_TIMESTAMP=true")
+	}
+
+	line("")
+	line("# Logging")
+	line("LOG_LEVEL=INFO")
+	line("LOG_FILE=./logs/csv2json.log")
+	return b.String()
+}
+
+// buildRoutes builds the starter routes.json for the chosen options
+func buildRoutes(opts initOptions) config.RoutesConfig {
+	data := filepath.ToSlash(opts.dataDir)
+	routes := config.RoutesConfig{Routes: make([]config.Route, 0, len(opts.routes))}
+	for _, name := range opts.routes {
+		archiveBase := data + "/archive/" + name
+		if opts.archiveLayout == archiveLayoutShared {
+			archiveBase = data + "/archive"
+		}
+
+		route := config.Route{
+			Name:              name,
+			IngestionContract: name + ".csv.v1",
+			Input: config.InputConfig{
+				Path:         data + "/input/" + name,
+				SuffixFilter: ".csv",
+				WatchMode:    opts.watchMode,
+			},
+			Parsing: config.ParsingConfig{HasHeader: true, Delimiter: ","},
+			Output:  config.OutputConfig{Type: opts.output, Destination: data + "/output/" + name},
+			Archive: config.ArchiveConfig{
+				ProcessedPath: archiveBase + "/processed",
+				FailedPath:    archiveBase + "/failed",
+				IgnoredPath:   archiveBase + "/ignored",
+			},
+		}
+		if opts.watchMode != "event" {
+			route.Input.PollInterval = config.Interval(5 * time.Second)
+		}
+		if opts.watchMode == "hybrid" {
+			route.Input.HybridPollInterval = config.Interval(60 * time.Second)
+		}
+		switch opts.output {
+		case "queue":
+			route.Output.Destination = name + "_queue"
+		case "both":
+			route.Output.Destination = ""
+			route.Output.Folder = data + "/output/" + name
+			route.Output.Queue = name + "_queue"
+		}
+		routes.Routes = append(routes.Routes, route)
+	}
+	return routes
+}
+
+// dataFolders lists the input, output and archive folders of the generated configuration
+func dataFolders(opts initOptions) []string {
+	data := filepath.ToSlash(opts.dataDir)
+	if opts.mode != "routes" {
+		folders := []string{data + "/input", data + "/archive/processed", data + "/archive/failed", data + "/archive/ignored"}
+		if opts.output != "queue" {
+			folders = append(folders, data+"/output")
+		}
+		return folders
+	}
+
+	var folders []string
+	for _, route := range buildRoutes(opts).Routes {
+		folders = append(folders, route.Input.Path, route.Archive.ProcessedPath, route.Archive.FailedPath, route.Archive.IgnoredPath)
+		if folder := route.Output.FileTarget(); folder != "" {
+			folders = append(folders, folder)
+		}
+	}
+	return folders
+}
+
+// splitNames splits a comma-separated list of names, dropping blanks
+func splitNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
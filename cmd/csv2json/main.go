@@ -8,30 +8,84 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
+	"csv2json/internal/admin"
+	"csv2json/internal/alert"
 	"csv2json/internal/config"
+	"csv2json/internal/contract"
+	"csv2json/internal/metrics"
 	"csv2json/internal/processor"
+	"csv2json/internal/secrets"
+	"csv2json/internal/systemd"
 	"csv2json/internal/version"
 )
 
 func main() {
-	// Parse command-line flags
-	versionFlag := flag.Bool("version", false, "Display version information")
-	helpFlag := flag.Bool("help", false, "Display usage information")
-	flag.Parse()
+	runCommand(os.Args[1:])
+}
 
-	// Handle help flag
-	if *helpFlag {
-		printHelp()
-		os.Exit(0)
-	}
+// serviceRunCommand runs the conversion service. It is the default command, so
+// csv2json --version and csv2json --set KEY=VALUE keep working without a command name.
+func serviceRunCommand(fs *flag.FlagSet) func(args []string) {
+	versionFlag := fs.Bool("version", false, "Display version information")
+	jsonFlag := fs.Bool("json", false, "With --version, print version information as JSON")
+	helpFlag := fs.Bool("help", false, "Display usage information")
+	applyLayers := layerFlags(fs)
+	fs.Usage = printHelp
+	return func(args []string) {
+		// Handle help flag
+		if *helpFlag {
+			printHelp()
+			os.Exit(0)
+		}
+
+		// Handle version flag
+		if *versionFlag {
+			if *jsonFlag {
+				fmt.Println(version.GetJSONVersionInfo())
+			} else {
+				fmt.Println(version.GetFullVersionInfo())
+			}
+			os.Exit(0)
+		}
+
+		if len(args) > 0 {
+			fmt.Fprintf(os.Stderr, "Unknown command %q. Run 'csv2json help' for the list of commands.\n", args[0])
+			os.Exit(2)
+		}
+
+		// Config file and --set overrides are merged into the environment before loading
+		if err := applyLayers(); err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
 
-	// Handle version flag
-	if *versionFlag {
-		fmt.Println(version.GetFullVersionInfo())
-		os.Exit(0)
+		serve(shutdownOnSignal())
 	}
+}
+
+// shutdownOnSignal returns a channel closed on Ctrl+C or SIGTERM. On Windows, SIGTERM
+// is also delivered for console close, logoff and system shutdown events.
+func shutdownOnSignal() <-chan struct{} {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	stop := make(chan struct{})
+	go func() {
+		<-sigChan
+		log.Println("Shutdown signal received, stopping gracefully...")
+		close(stop)
+	}()
+	return stop
+}
+
+// serve loads configuration and runs the service until stop is closed
+func serve(stop <-chan struct{}) {
+	// Fetch credentials from an external secrets provider before loading configuration
+	loadSecrets()
 
 	// Load configuration
 	cfg, err := config.Load()
@@ -48,34 +102,113 @@ func main() {
 		}
 
 		// Open log file
-		logFile, err := os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		logFile, err = os.OpenFile(cfg.LogFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
 		if err != nil {
 			log.Fatalf("Failed to open log file: %v", err)
 		}
 		defer logFile.Close()
 
 		// Write to both stdout and log file
-		multiWriter := io.MultiWriter(os.Stdout, logFile)
-		log.SetOutput(multiWriter)
+		mirrorLogFile(cfg.OutputType == "stdout")
 		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
 	}
 
+	// Expose metrics for all routes
+	if cfg.MetricsAddr != "" {
+		go func() {
+			if err := metrics.Serve(cfg.MetricsAddr); err != nil {
+				log.Printf("ERROR: Metrics endpoint failed: %v", err)
+			}
+		}()
+	}
+
+	// Alert operators about failures when an alert channel is configured
+	configureAlerts()
+
+	// Operators can force an immediate rescan with SIGUSR1 or the admin API
+	routes := &routeSet{}
+	startTriggers(routes, cfg.AdminAddr, stop)
+
 	// Check if using multi-ingress routing mode
 	if cfg.RoutesConfigPath != "" {
 		log.Printf("Starting in MULTI-INGRESS ROUTING mode with config: %s", cfg.RoutesConfigPath)
-		runMultiIngressMode(cfg.RoutesConfigPath)
+		runMultiIngressMode(cfg.RoutesConfigPath, routes, stop)
 	} else {
 		log.Println("Starting in LEGACY SINGLE-INPUT mode")
-		runLegacyMode(cfg)
+		runLegacyMode(cfg, routes, stop)
+	}
+	alert.Wait()
+}
+
+// logFile is the LOG_FILE log output is mirrored to (nil = console only)
+var logFile *os.File
+
+// mirrorLogFile writes log output to the log file and the console: stdout, or stderr
+// when converted output is written to stdout and must not be interleaved with logs
+func mirrorLogFile(stdoutOutput bool) {
+	console := io.Writer(os.Stdout)
+	if stdoutOutput {
+		console = os.Stderr
+	}
+	log.SetOutput(io.MultiWriter(console, logFile))
+}
+
+// configureAlerts sets up the process-wide alert dispatcher
+func configureAlerts() {
+	alertsCfg, err := config.LoadAlerts()
+	if err != nil {
+		log.Fatalf("Failed to load alerting configuration: %v", err)
+	}
+	alert.Default = alert.New(alertsCfg)
+	if alert.Default != nil {
+		log.Printf("Alerting enabled for events %v (rate limit %v per event and route)", alertsCfg.Events, alertsCfg.RateLimit)
+	}
+}
+
+// fatalStartup alerts operators that a route could not be started, then exits
+func fatalStartup(route, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	alert.Send(config.AlertEventRouteStartup, route, message)
+	alert.Wait()
+	log.Fatal(message)
+}
+
+// loadSecrets exports secrets from the configured provider (if any) to the
+// environment and keeps refreshing them in the background
+func loadSecrets() {
+	provider, secretsCfg := fetchSecrets()
+	if provider != nil {
+		go secrets.Watch(provider, secretsCfg.Refresh, secretsCfg.Timeout, nil)
+	}
+}
+
+// fetchSecrets exports secrets from the configured provider once, as the layer between
+// the environment and flag overrides. It returns a nil provider when none is configured.
+func fetchSecrets() (secrets.Provider, *config.SecretsConfig) {
+	secretsCfg, err := config.LoadSecrets()
+	if err != nil {
+		log.Fatalf("Failed to load secrets configuration: %v", err)
+	}
+	if secretsCfg.Provider == "" {
+		return nil, secretsCfg
+	}
+
+	provider, err := secrets.New(secretsCfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize secrets provider: %v", err)
+	}
+	if err := secrets.Load(provider, secretsCfg.Timeout); err != nil {
+		log.Fatalf("Failed to load secrets: %v", err)
 	}
+	return provider, secretsCfg
 }
 
 // runLegacyMode runs the service in single-input mode (original behavior)
-func runLegacyMode(cfg *config.Config) {
+func runLegacyMode(cfg *config.Config, routes *routeSet, stop <-chan struct{}) {
 	// Initialize processor
 	proc, err := processor.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize processor: %v", err)
+		fatalStartup("", "Failed to initialize processor: %v", err)
 	}
 
 	// Log startup configuration
@@ -85,6 +218,7 @@ func runLegacyMode(cfg *config.Config) {
 	log.Println("========================================")
 	log.Printf("INPUT_FOLDER: %s", cfg.InputFolder)
 	log.Printf("POLL_INTERVAL: %v", cfg.PollInterval)
+	log.Printf("FILE_STABILITY_WINDOW: %v", cfg.StabilityWindow)
 	log.Printf("MAX_FILES_PER_POLL: %d", cfg.MaxFilesPerPoll)
 	if len(cfg.FileSuffixFilter) > 0 {
 		log.Printf("FILE_SUFFIX_FILTER: %v", cfg.FileSuffixFilter)
@@ -92,22 +226,62 @@ func runLegacyMode(cfg *config.Config) {
 		log.Println("FILE_SUFFIX_FILTER: * (all files)")
 	}
 	log.Printf("FILENAME_PATTERN: %s", cfg.FilenamePattern.String())
+	if cfg.FilenameIgnoreCase {
+		log.Println("FILENAME_CASE_INSENSITIVE: true")
+	}
+	if cfg.ExpectedArrival != nil {
+		log.Printf("EXPECTED_ARRIVAL: %s", cfg.ExpectedArrival)
+	}
+	if cfg.ReportDestination != "" {
+		log.Printf("REPORT_DESTINATION: %s", cfg.ReportDestination)
+	}
+	if cfg.MemoryLimitMB > 0 {
+		log.Printf("MEMORY_LIMIT_MB: %d", cfg.MemoryLimitMB)
+	}
+	log.Printf("INPUT_FORMAT: %s", cfg.InputFormat)
+	if len(cfg.FixedWidthColumns) > 0 {
+		log.Printf("FIXED_WIDTH_COLUMNS: %v", cfg.FixedWidthColumns)
+	}
+	if cfg.ParseWorkers > 1 {
+		log.Printf("PARSE_WORKERS: %d (files >= %d MB)", cfg.ParseWorkers, cfg.ParallelParseMin>>20)
+	}
 	log.Printf("DELIMITER: %q", cfg.Delimiter)
 	log.Printf("QUOTECHAR: %q", cfg.QuoteChar)
 	log.Printf("ENCODING: %s", cfg.Encoding)
 	log.Printf("HAS_HEADER: %t", cfg.HasHeader)
 	log.Printf("OUTPUT_TYPE: %s", cfg.OutputType)
-	if cfg.OutputType == "file" {
+	if cfg.Tenant != "" {
+		log.Printf("TENANT: %s", cfg.Tenant)
+	}
+	if cfg.OutputType == "file" || cfg.OutputType == "both" {
 		log.Printf("OUTPUT_FOLDER: %s", cfg.OutputFolder)
-	} else {
+	}
+	if cfg.OutputType == "pipe" {
+		log.Printf("OUTPUT_PIPE: %s", cfg.OutputPipe)
+	}
+	if cfg.OutputType == "queue" || cfg.OutputType == "both" {
 		log.Printf("QUEUE_TYPE: %s", cfg.QueueType)
 		log.Printf("QUEUE_HOST: %s", cfg.QueueHost)
 		log.Printf("QUEUE_PORT: %d", cfg.QueuePort)
+		if cfg.QueueVHost != "" {
+			log.Printf("QUEUE_VHOST: %s", cfg.QueueVHost)
+		}
 		log.Printf("QUEUE_NAME: %s", cfg.QueueName)
+		if len(cfg.QueueShards) > 0 {
+			log.Printf("QUEUE_SHARDS: %s (%s)", strings.Join(cfg.QueueShards, ", "), cfg.ShardStrategy)
+		}
 		if cfg.QueueUsername != "" {
 			log.Printf("QUEUE_USERNAME: %s", cfg.QueueUsername)
 		}
+		log.Printf("QUEUE_LAZY_CONNECT: %t", cfg.QueueLazyConnect)
+		log.Printf("QUEUE_HEARTBEAT: %v", cfg.QueueHeartbeat)
 		log.Printf("LOG_QUEUE_MESSAGES: %t", cfg.LogQueueMessages)
+		if cfg.PayloadEncryptionKey != "" {
+			log.Printf("PAYLOAD_ENCRYPTION_KEY_ID: %s", cfg.PayloadEncryptionKeyID)
+		}
+		if cfg.SigningKey != "" {
+			log.Printf("MESSAGE_SIGNING: %s key %s", cfg.SigningAlgorithm, cfg.SigningKeyID)
+		}
 	}
 	log.Printf("ARCHIVE_PROCESSED: %s", cfg.ArchiveProcessed)
 	log.Printf("ARCHIVE_IGNORED: %s", cfg.ArchiveIgnored)
@@ -117,11 +291,8 @@ func runLegacyMode(cfg *config.Config) {
 	log.Printf("LOG_FILE: %s", cfg.LogFile)
 	log.Println("========================================")
 
-	// Setup graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
 	// Start processor in goroutine
+	routes.add("default", proc)
 	go func() {
 		if err := proc.Start(); err != nil {
 			log.Fatalf("Processor error: %v", err)
@@ -129,97 +300,151 @@ func runLegacyMode(cfg *config.Config) {
 	}()
 
 	log.Println("Service ready. Monitoring for new files. Press Ctrl+C to stop.")
+	go notifySystemd([]string{"default"}, []*processor.Processor{proc}, stop)
 
 	// Wait for shutdown signal
-	<-sigChan
-	log.Println("Shutdown signal received, stopping gracefully...")
+	<-stop
+	systemd.Notify(systemd.StateStopping)
 
+	routes.stop()
 	proc.Stop()
 	log.Println("Service stopped")
 }
 
 // runMultiIngressMode runs the service in multi-ingress routing mode (ADR-004)
-func runMultiIngressMode(routesConfigPath string) {
+func runMultiIngressMode(routesConfigPath string, routes *routeSet, stop <-chan struct{}) {
 	// Load routes configuration
 	routesConfig, err := config.LoadRoutes(routesConfigPath)
 	if err != nil {
-		log.Fatalf("Failed to load routes configuration: %v", err)
+		fatalStartup("", "Failed to load routes configuration: %v", err)
 	}
 
 	if len(routesConfig.Routes) == 0 {
 		log.Fatal("No routes configured in routes.json")
 	}
 
+	for _, route := range routesConfig.Routes {
+		if route.Output.Type == "stdout" && logFile != nil {
+			mirrorLogFile(true)
+			break
+		}
+	}
+
 	log.Printf("Loaded %d route(s) from configuration", len(routesConfig.Routes))
+	skipInvalid := routesConfig.StartupPolicy == config.StartupPolicySkipInvalid
+	for _, skipped := range routesConfig.Skipped {
+		log.Printf("ERROR: Skipping invalid route (startupPolicy=%s): %v", routesConfig.StartupPolicy, skipped.Err)
+		processor.MarkRouteDown(skipped.Name)
+		alert.Send(config.AlertEventRouteStartup, skipped.Name, fmt.Sprintf("route skipped as invalid: %v", skipped.Err))
+	}
+
+	// Contracts are enforced when a registry is configured
+	var registry *contract.Registry
+	if routesConfig.ContractRegistry != "" {
+		if registry, err = contract.NewRegistry(routesConfig.ContractRegistry, routesConfig.RegistryToken, routesConfig.RegistryTimeout); err != nil {
+			fatalStartup("", "Failed to open contract registry: %v", err)
+		}
+		log.Printf("Enforcing ingestion contracts from registry %s", routesConfig.ContractRegistry)
+	}
 
 	// Create a processor for each route
-	processors := make([]*processor.Processor, 0, len(routesConfig.Routes))
+	waiting := 0
+
+	// Routes share a processing budget when configured; priority decides who goes first
+	var scheduler *processor.Scheduler
+	if routesConfig.MaxConcurrentFiles > 0 {
+		scheduler = processor.NewScheduler(routesConfig.MaxConcurrentFiles)
+		log.Printf("Shared processing budget: %d concurrent file(s), served by route priority", routesConfig.MaxConcurrentFiles)
+	}
 
 	for i, route := range routesConfig.Routes {
 		log.Printf("Initializing route %d/%d: %s", i+1, len(routesConfig.Routes), route.Name)
 
 		// Convert route to legacy config
 		routeCfg := route.ToLegacyConfig()
+		routeCfg.QueueSharedConnection = *routesConfig.SharedConnection
+
+		// Resolve the route's contract before starting anything for it
+		var schema *contract.Schema
+		if registry != nil && route.Type != config.RouteTypeReverse && (route.EnforceContract == nil || *route.EnforceContract) {
+			if schema, err = registry.Fetch(route.IngestionContract); err != nil {
+				if !skipInvalid {
+					fatalStartup(route.Name, "Failed to resolve contract for route '%s': %v", route.Name, err)
+				}
+				log.Printf("ERROR: Skipping route '%s' (startupPolicy=%s): %v", route.Name, routesConfig.StartupPolicy, err)
+				processor.MarkRouteDown(route.Name)
+				alert.Send(config.AlertEventRouteStartup, route.Name, fmt.Sprintf("route skipped: failed to resolve contract: %v", err))
+				continue
+			}
+		}
 
-		// Initialize processor for this route
-		proc, err := processor.New(routeCfg)
-		if err != nil {
-			log.Fatalf("Failed to initialize processor for route '%s': %v", route.Name, err)
+		// Routes waiting for their input folder (e.g. a late mount) start once it appears
+		if !route.InputReady() {
+			log.Printf("WARNING: Route '%s' input path %s does not exist yet; route is degraded until it appears", route.Name, route.Input.Path)
+			processor.MarkRouteDown(route.Name)
+			processor.MarkRouteDegraded(route.Name, true)
+			alert.Send(config.AlertEventRouteStartup, route.Name, fmt.Sprintf("route degraded: waiting for input path %s", route.Input.Path))
+			go startWhenInputAppears(route, routeCfg, scheduler, schema, routes, stop)
+			logRoute(route, schema)
+			waiting++
+			continue
 		}
 
-		// Set envelope context for queue output (ADR-006)
-		if route.Output.Type == "queue" || route.Output.Type == "both" {
-			includeEnvelope := true // Default
-			if route.Output.IncludeEnvelope != nil {
-				includeEnvelope = *route.Output.IncludeEnvelope
+		// Initialize processor for this route
+		proc, err := newRouteProcessor(route, routeCfg, scheduler, schema)
+		if err != nil {
+			if !skipInvalid {
+				fatalStartup(route.Name, "Failed to initialize processor for route '%s': %v", route.Name, err)
 			}
-			proc.SetEnvelopeContext(route.Name, route.IngestionContract, includeEnvelope)
+			log.Printf("ERROR: Skipping route '%s' (startupPolicy=%s): failed to initialize processor: %v", route.Name, routesConfig.StartupPolicy, err)
+			processor.MarkRouteDown(route.Name)
+			alert.Send(config.AlertEventRouteStartup, route.Name, fmt.Sprintf("route skipped: failed to initialize processor: %v", err))
+			continue
 		}
 
-		processors = append(processors, proc)
+		routes.add(route.Name, proc)
+		logRoute(route, schema)
+	}
 
-		// Log route configuration
-		log.Println("----------------------------------------")
-		log.Printf("Route: %s", route.Name)
-		log.Printf("  Input: %s", route.Input.Path)
-		log.Printf("  Output: %s -> %s", route.Output.Type, route.Output.Destination)
-		if route.Input.FilenamePattern != "" {
-			log.Printf("  Pattern: %s", route.Input.FilenamePattern)
-		}
-		log.Printf("  PollInterval: %ds", route.Input.PollIntervalSec)
-		log.Println("----------------------------------------")
+	routeNames, processors := routes.list()
+	if len(processors) == 0 && waiting == 0 {
+		log.Fatal("No routes could be started")
 	}
 
 	// Log startup summary
 	log.Println("========================================")
 	log.Printf("%s", version.GetFullVersionInfo())
 	log.Printf("Multi-Ingress Routing Mode: %d active routes", len(processors))
+	if waiting > 0 {
+		log.Printf("WARNING: %d route(s) degraded, waiting for their input path", waiting)
+	}
+	if skipped := len(routesConfig.Skipped) + len(routesConfig.Routes) - len(processors) - waiting; skipped > 0 {
+		log.Printf("WARNING: %d route(s) skipped due to configuration errors", skipped)
+	}
 	log.Println("========================================")
 
-	// Setup graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-
-	// Start all processors in goroutines
+	// Start all processors in goroutines, each supervised so that a failure or
+	// panic in one route restarts that route with backoff instead of killing the service
 	for i, proc := range processors {
-		routeName := routesConfig.Routes[i].Name
+		routeName := routeNames[i]
 		go func(p *processor.Processor, name string) {
 			log.Printf("Starting route processor: %s", name)
-			if err := p.Start(); err != nil {
-				log.Printf("ERROR: Route '%s' processor failed: %v", name, err)
-			}
+			p.Run()
 		}(proc, routeName)
 	}
 
 	log.Println("All routes active. Monitoring for new files. Press Ctrl+C to stop.")
+	go notifySystemd(routeNames, processors, stop)
 
 	// Wait for shutdown signal
-	<-sigChan
-	log.Println("Shutdown signal received, stopping all routes gracefully...")
+	<-stop
+	systemd.Notify(systemd.StateStopping)
+	log.Println("Stopping all routes gracefully...")
 
-	// Stop all processors
+	// Stop all processors, including routes started late
+	routeNames, processors = routes.stop()
 	for i, proc := range processors {
-		routeName := routesConfig.Routes[i].Name
+		routeName := routeNames[i]
 		log.Printf("Stopping route: %s", routeName)
 		proc.Stop()
 	}
@@ -227,6 +452,219 @@ func runMultiIngressMode(routesConfigPath string) {
 	log.Println("All routes stopped. Service shutdown complete.")
 }
 
+// newRouteProcessor creates a route's processor and applies its route-level settings
+func newRouteProcessor(route config.Route, routeCfg *config.Config, scheduler *processor.Scheduler, schema *contract.Schema) (*processor.Processor, error) {
+	proc, err := processor.New(routeCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set envelope context for queue output (ADR-006); file routes record the
+	// route name in receipts, metrics and alerts
+	proc.SetEnvelopeContext(route.Name, route.IngestionContract, route.Output.MessageFormat())
+
+	if scheduler != nil {
+		proc.SetScheduler(scheduler, route.Priority)
+	}
+
+	if schema != nil {
+		proc.SetContract(schema)
+	}
+	return proc, nil
+}
+
+// logRoute logs a route's configuration at startup
+func logRoute(route config.Route, schema *contract.Schema) {
+	log.Println("----------------------------------------")
+	log.Printf("Route: %s", route.Name)
+	log.Printf("  Input: %s", route.Input.Path)
+	if route.Output.Type == "both" {
+		log.Printf("  Output: both -> %s, %s", route.Output.Folder, route.Output.Queue)
+	} else if route.Output.Type == "stdout" {
+		log.Println("  Output: stdout")
+	} else {
+		log.Printf("  Output: %s -> %s", route.Output.Type, route.Output.Destination)
+	}
+	if route.Output.Shards != nil {
+		log.Printf("  Shards: %s (%s)", strings.Join(route.Output.Shards.Queues, ", "), route.Output.Shards.Strategy)
+	}
+	if route.Output.Encryption != nil {
+		log.Printf("  Encryption key: %s", route.Output.Encryption.KeyID)
+	}
+	if route.Output.Signing != nil {
+		log.Printf("  Signing: %s key %s", route.Output.Signing.Algorithm, route.Output.Signing.KeyID)
+	}
+	if route.Output.Report != "" {
+		log.Printf("  Report: %s", route.Output.Report)
+	}
+	if route.Input.FilenamePattern != "" {
+		log.Printf("  Pattern: %s", route.Input.FilenamePattern)
+	}
+	if *route.Input.CaseInsensitive {
+		log.Println("  Filename matching: case-insensitive")
+	}
+	if route.Input.ExpectedArrival != "" {
+		log.Printf("  Expected arrival: %s", route.Input.ExpectedArrival)
+	}
+	log.Printf("  WatchMode: %s", route.Input.WatchMode)
+	log.Printf("  PollInterval: %s", route.Input.PollInterval.Duration())
+	if route.Input.WatchMode == "hybrid" {
+		log.Printf("  HybridPollInterval: %s", route.Input.HybridPollInterval.Duration())
+	}
+	log.Printf("  StabilityWindow: %s", route.Input.StabilityWindow.Duration())
+	if route.Priority != 0 {
+		log.Printf("  Priority: %d", route.Priority)
+	}
+	if tenant := route.ResolvedTenant(); tenant != "" {
+		log.Printf("  Tenant: %s", tenant)
+	}
+	if schema != nil {
+		log.Printf("  Contract: %s (version %s, enforced)", schema.ID, schema.ResolvedVersion)
+	}
+	log.Println("----------------------------------------")
+}
+
+// routeSet holds the running route processors. Routes waiting for their input
+// folder join it when they start, so shutdown stops them too.
+type routeSet struct {
+	mu      sync.Mutex
+	names   []string
+	procs   []*processor.Processor
+	stopped bool
+}
+
+// add records a started route; it returns false once shutdown has begun, in which
+// case the caller must stop the processor itself
+func (s *routeSet) add(name string, proc *processor.Processor) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return false
+	}
+	s.names = append(s.names, name)
+	s.procs = append(s.procs, proc)
+	return true
+}
+
+// list returns the routes started so far
+func (s *routeSet) list() ([]string, []*processor.Processor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.names...), append([]*processor.Processor(nil), s.procs...)
+}
+
+// trigger forces an immediate rescan of the named route's input folder, or of every
+// route when name is empty, and returns the routes triggered
+func (s *routeSet) trigger(name string) ([]string, error) {
+	names, procs := s.list()
+	var triggered []string
+	for i, proc := range procs {
+		if name == "" || names[i] == name {
+			proc.TriggerNow()
+			triggered = append(triggered, names[i])
+		}
+	}
+	if name != "" && len(triggered) == 0 {
+		return nil, fmt.Errorf("%w: %s", admin.ErrUnknownRoute, name)
+	}
+	return triggered, nil
+}
+
+// stop closes the set to late routes and returns every route to shut down
+func (s *routeSet) stop() ([]string, []*processor.Processor) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	return s.names, s.procs
+}
+
+// startWhenInputAppears waits for a route's input folder (e.g. a mount that comes up
+// after the service), checking every poll interval, then starts the route. The route
+// is reported as degraded until then.
+func startWhenInputAppears(route config.Route, routeCfg *config.Config, scheduler *processor.Scheduler, schema *contract.Schema, routes *routeSet, stop <-chan struct{}) {
+	ticker := time.NewTicker(route.Input.PollInterval.Duration())
+	defer ticker.Stop()
+	for !route.InputReady() {
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+
+	log.Printf("Route '%s' input path %s is available; starting route", route.Name, route.Input.Path)
+	processor.MarkRouteDegraded(route.Name, false)
+	proc, err := newRouteProcessor(route, routeCfg, scheduler, schema)
+	if err != nil {
+		log.Printf("ERROR: Route '%s' failed to start after its input path appeared: %v", route.Name, err)
+		alert.Send(config.AlertEventRouteStartup, route.Name, fmt.Sprintf("route failed to start: %v", err))
+		return
+	}
+	if !routes.add(route.Name, proc) {
+		proc.Stop()
+		return
+	}
+	log.Printf("Starting route processor: %s", route.Name)
+	proc.Run()
+}
+
+// notifySystemd reports readiness to systemd once every route is watching its folder,
+// then pings the watchdog (WatchdogSec=) at half its timeout for as long as no route's
+// monitor loop has stalled. Pings continue while a file is being processed, however
+// long it takes, and stop while a route is stalled, so systemd restarts the service. Does nothing unless running under systemd with Type=notify.
+func notifySystemd(names []string, procs []*processor.Processor, stop <-chan struct{}) {
+	for _, proc := range procs {
+		select {
+		case <-proc.Ready():
+		case <-stop:
+			return
+		}
+	}
+	sent, err := systemd.Notify(systemd.StateReady)
+	if err != nil {
+		log.Printf("Warning: failed to notify systemd: %v", err)
+		return
+	}
+	if !sent {
+		return
+	}
+	log.Printf("Notified systemd: all %d route(s) watching", len(procs))
+
+	interval, err := systemd.WatchdogInterval()
+	if err != nil {
+		log.Printf("Warning: systemd watchdog disabled: %v", err)
+		return
+	}
+	if interval == 0 {
+		return
+	}
+	log.Printf("systemd watchdog enabled (timeout %v)", interval)
+
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			stalled := ""
+			for i, proc := range procs {
+				if proc.Stalled(interval) {
+					stalled = names[i]
+					break
+				}
+			}
+			if stalled != "" {
+				log.Printf("ERROR: Route '%s' monitor has not run for over %v; withholding systemd watchdog ping", stalled, interval)
+				continue
+			}
+			if _, err := systemd.Notify(systemd.StateWatchdog); err != nil {
+				log.Printf("Warning: failed to ping systemd watchdog: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
 // printHelp displays comprehensive usage information
 func printHelp() {
 	fmt.Printf(`%s
@@ -238,11 +676,113 @@ DESCRIPTION:
     modes with event-driven or polling-based file detection.
 
 USAGE:
-    csv2json [OPTIONS]
-
+%s
 OPTIONS:
     --help              Display this help information
     --version           Display version information and exit
+    --version --json    Display version and build information as JSON
+    --config FILE       Configuration file: .env or flat YAML (.yaml/.yml)
+                        (default: CONFIG_FILE, then .env)
+    --set KEY=VALUE     Override a setting; repeatable. Precedence, lowest
+                        first: defaults < config file < environment <
+                        secrets provider < flags
+
+SETTING FLAGS (each overrides the environment variable in brackets):
+    --routes PATH               [ROUTES_CONFIG]
+    --input DIR                 [INPUT_FOLDER]
+    --watch-mode MODE           [WATCH_MODE]
+    --poll-interval DURATION    [POLL_INTERVAL_SECONDS]
+    --delimiter CHAR            [DELIMITER]
+    --has-header BOOL           [HAS_HEADER]
+    --output-type TYPE          [OUTPUT_TYPE]
+    --output DIR                [OUTPUT_FOLDER]
+    --output-pipe PATH          [OUTPUT_PIPE]
+    --queue-type TYPE           [QUEUE_TYPE]
+    --queue-host HOST           [QUEUE_HOST]
+    --queue-port PORT           [QUEUE_PORT]
+    --queue-vhost VHOST         [QUEUE_VHOST]
+    --queue-name NAME           [QUEUE_NAME]
+    --archive-processed DIR     [ARCHIVE_PROCESSED]
+    --archive-ignored DIR       [ARCHIVE_IGNORED]
+    --archive-failed DIR        [ARCHIVE_FAILED]
+    --work-dir DIR              [WORK_DIR]
+    --log-level LEVEL           [LOG_LEVEL]
+    --log-file PATH             [LOG_FILE]
+    --metrics-addr ADDR         [METRICS_ADDR]
+    --admin-addr ADDR           [ADMIN_ADDR]
+    Any other setting: --set KEY=VALUE
+
+COMMANDS:
+    run                 Run the conversion service. This is the default
+                        command: csv2json with only flags runs the service.
+    convert             Convert one file to JSON with the parsing settings,
+                        transforms and contract of the environment or of
+                        --route, writing it next to the input (-o to choose
+                        the path, - for stdout). Nothing is archived or
+                        published.
+    validate-routes     Load routes.json (--routes, default ROUTES_CONFIG)
+                        as the service would, resolve the contracts routes
+                        enforce, and list the routes. Exits non-zero if any
+                        route is invalid.
+    replay              Move files from the failed (default) or processed
+                        archive (--from) back into the route's input folder
+                        under their original names, to be processed again.
+                        --match GLOB selects files by original name;
+                        --dry-run only lists them.
+    release             Move files held for restating a completed business date
+                        (LATE_BUSINESS_DATE_POLICY=hold) back into the input
+                        folder, to be processed despite their date. --match
+                        GLOB selects files by name; --dry-run only lists them.
+    healthcheck         Check a running service through its metrics endpoint
+                        (--addr, default METRICS_ADDR): exits non-zero if the
+                        endpoint does not answer or a route is down. Suitable
+                        for Docker HEALTHCHECK and Kubernetes exec probes.
+    completion          Print a bash, zsh or fish completion script for the
+                        commands and their flags (see README).
+    help                Show this help, or a command's usage and flags
+                        (csv2json help COMMAND).
+    rescan-ignored      Re-evaluate files in the ignored archive against the
+                        current filters and move matches back to the input
+                        folder. --route NAME selects the route in multi-ingress
+                        mode; --dry-run only reports what would be requeued.
+    reverse             Flatten a JSON array of objects (e.g. csv2json output)
+                        back into CSV. Columns follow first appearance, nested
+                        objects become dotted columns (address.city) and arrays
+                        are kept as JSON text. -o - writes to stdout.
+    init                Generate a starter .env (and routes.json with
+                        --mode routes). Prompts for each setting when run in a
+                        terminal without flags; otherwise uses the flags
+                        --routes, --watch-mode, --output, --queue-host,
+                        --queue-port, --data-dir, --archive-layout, --dir and
+                        --force (see csv2json init --help).
+    service             Manage csv2json as a native Windows service (Windows
+                        only). install registers an automatic-start service
+                        that reads .env from --workdir (default: the folder
+                        holding the executable); start and stop control it.
+                        Stop and shutdown events stop the service gracefully.
+    selftest            Generate a sample CSV, run it through a route's full
+                        pipeline and read the result back from the output
+                        folder or RabbitMQ queue (--timeout, default 30s).
+                        Exits non-zero unless the round trip succeeds. Input
+                        and archives use a temporary folder; the sample's
+                        output file is removed unless --keep is given.
+    test-route          Run one file through a route's full pipeline in dry-run
+                        mode: print the JSON output or queue message envelopes
+                        it would produce, or the validation error it would be
+                        archived with. The file stays in place; nothing is
+                        written, archived or published. Exits non-zero if the
+                        file would fail or be ignored.
+    bench               Generate a synthetic CSV (--rows, --cols) and measure
+                        parse, convert and output throughput and p50/p95/p99
+                        latency over --files runs, with the parsing and broker
+                        settings of the environment. Queue output publishes to
+                        --queue (default csv2json-bench), which must not exist
+                        yet and is deleted afterwards unless --keep is given.
+    config show         Print the effective configuration: every setting
+                        with the value in effect and where it came from
+                        (default, file, environment or flag). Secrets are
+                        masked; --json prints the settings as JSON. Exits
+                        non-zero if the configuration is invalid.
 
 OPERATIONAL MODES:
     The service operates in one of two modes based on configuration:
@@ -260,18 +800,25 @@ OPERATIONAL MODES:
 
 CONFIGURATION:
     All configuration is managed through environment variables or routes.json.
+    Settings may also come from a config file (CONFIG_FILE or --config: .env
+    or flat YAML) below the environment, and from --set KEY=VALUE above it.
 
     Key Environment Variables (Legacy Mode):
         ROUTES_CONFIG              Path to routes.json (enables Multi-Ingress Mode)
         INPUT_FOLDER               Directory to monitor (default: ./input)
         WATCH_MODE                 File detection: event|poll|hybrid (default: event)
-        POLL_INTERVAL_SECONDS      Polling interval (default: 5)
-        OUTPUT_TYPE                Output: file|queue (default: file)
+        POLL_INTERVAL_SECONDS      Polling interval, seconds or duration like 500ms (default: 5)
+        FILE_STABILITY_WINDOW      Wait for a file's size to settle (default: 2s, 0 = off)
+        PROCESS_EXISTING           Process files already in the input folder at startup (default: false)
+        OUTPUT_TYPE                Output: file|queue|both|stdout|pipe (default: file)
         OUTPUT_FOLDER              JSON output directory (default: ./output)
+        OUTPUT_PIPE                Named pipe written by pipe output (created if missing)
         QUEUE_TYPE                 Queue system: rabbitmq (default)
         QUEUE_HOST                 Queue server host (default: localhost)
+        QUEUE_VHOST                RabbitMQ virtual host (default: /)
         QUEUE_PORT                 Queue server port (default: 5672)
         QUEUE_NAME                 Queue name (required for queue mode)
+        QUEUE_LAZY_CONNECT         Connect to the broker on the first publish (default: false)
         HAS_HEADER                 CSV has header row (default: true)
         DELIMITER                  Field delimiter (default: ,)
         ARCHIVE_PROCESSED          Archive for processed files
@@ -301,6 +848,7 @@ FILE DETECTION STRATEGIES (ADR-005):
 EXAMPLES:
     # Display version
     csv2json --version
+    csv2json --version --json
 
     # Run with default configuration (.env or environment variables)
     csv2json
@@ -309,6 +857,30 @@ EXAMPLES:
     export ROUTES_CONFIG=./routes.json
     csv2json
 
+    # Hand converted data back to a CSV-only partner
+    csv2json reverse output/products.json -o partner/products.csv --delimiter ';'
+
+    # Generate a starter routes.json with two hybrid-mode file routes
+    csv2json init --mode routes --routes products,orders --watch-mode hybrid
+
+    # Install and start as a Windows service (elevated prompt)
+    csv2json service install --workdir C:\csv2json
+    csv2json service start
+
+    # Smoke test a route after deploying
+    csv2json selftest --route products
+
+    # Size an instance: throughput of 100k-row files published to RabbitMQ
+    csv2json bench --rows 100000 --cols 20 --output queue
+
+    # Requeue files ignored by a route after fixing its filename pattern
+    csv2json rescan-ignored --route products --dry-run
+    csv2json rescan-ignored --route products
+
+    # Pick up a dropped file now instead of at the next poll
+    kill -USR1 $(pidof csv2json)
+    curl -X POST 'http://localhost:9091/trigger?route=products'   # ADMIN_ADDR=localhost:9091
+
     # Run with custom poll interval
     export POLL_INTERVAL_SECONDS=10
     csv2json
@@ -338,5 +910,5 @@ PROJECT:
     License:    [To be added]
     Version:    %s
 
-`, version.GetVersionInfo(), version.GetVersionInfo())
+`, version.GetVersionInfo(), commandUsage(), version.GetVersionInfo())
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"csv2json/internal/config"
+	"csv2json/internal/processor"
+)
+
+// releaseCommand moves files held for restating a completed business date
+// (LATE_BUSINESS_DATE_POLICY=hold) back into the input folder, so the running service
+// processes them
+func releaseCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Route to release held files of (required in multi-ingress mode)")
+	match := fs.String("match", "", "Only release files whose name matches this glob (e.g. orders_2024-05-*.csv)")
+	dryRun := fs.Bool("dry-run", false, "List the files that would be released without moving them")
+	return func(args []string) {
+		if *match != "" {
+			if _, err := filepath.Match(*match, ""); err != nil {
+				log.Fatalf("invalid --match pattern %q: %v", *match, err)
+			}
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		if cfg.RoutesConfigPath != "" {
+			if *routeName == "" {
+				log.Fatal("--route is required in multi-ingress routing mode")
+			}
+			cfg, err = routeConfig(cfg.RoutesConfigPath, *routeName)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		} else if *routeName != "" {
+			log.Fatal("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+		}
+
+		released, err := processor.ReleaseHeld(cfg.InputFolder, *match, *dryRun)
+		for _, name := range released {
+			if *dryRun {
+				log.Printf("Would release: %s", name)
+			} else {
+				log.Printf("Released: %s", name)
+			}
+		}
+		if err != nil {
+			log.Fatalf("Release failed: %v", err)
+		}
+
+		action := "Released"
+		if *dryRun {
+			action = "Would release"
+		}
+		fmt.Printf("%s %d held file(s) to %s\n", action, len(released), cfg.InputFolder)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+)
+
+// replayCommand moves files from the failed or processed archive back into the input
+// folder under their original names, so the running service processes them again
+func replayCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Route to replay (required in multi-ingress mode)")
+	from := fs.String("from", string(archiver.CategoryFailed), "Archive to replay: failed or processed")
+	match := fs.String("match", "", "Only replay files whose original name matches this glob (e.g. orders_2024-*.csv)")
+	dryRun := fs.Bool("dry-run", false, "List the files that would be replayed without moving them")
+	return func(args []string) {
+		category := archiver.Category(*from)
+		if category != archiver.CategoryFailed && category != archiver.CategoryProcessed {
+			log.Fatalf("invalid --from: %s (must be failed or processed)", *from)
+		}
+		if *match != "" {
+			if _, err := filepath.Match(*match, ""); err != nil {
+				log.Fatalf("invalid --match pattern %q: %v", *match, err)
+			}
+		}
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		if cfg.RoutesConfigPath != "" {
+			if *routeName == "" {
+				log.Fatal("--route is required in multi-ingress routing mode")
+			}
+			cfg, err = routeConfig(cfg.RoutesConfigPath, *routeName)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		} else if *routeName != "" {
+			log.Fatal("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+		}
+
+		replayed, failed, err := replayArchived(cfg, category, *match, *dryRun)
+		if err != nil {
+			log.Fatalf("Replay failed: %v", err)
+		}
+
+		action := "Replayed"
+		if *dryRun {
+			action = "Would replay"
+		}
+		fmt.Printf("%s %d %s file(s) to %s", action, replayed, category, cfg.InputFolder)
+		if failed > 0 {
+			fmt.Printf("; %d could not be moved", failed)
+		}
+		fmt.Println()
+	}
+}
+
+// replayArchived requeues the files in the archive for category whose original name
+// matches pattern ("" = all) and returns the number replayed and the number that
+// could not be moved
+func replayArchived(cfg *config.Config, category archiver.Category, pattern string, dryRun bool) (int, int, error) {
+	arch := archiver.New(cfg.ArchiveProcessed, cfg.ArchiveIgnored, cfg.ArchiveFailed, cfg.ArchiveTimestamp)
+	arch.SetLocation(cfg.Location())
+	files, err := arch.ArchivedFiles(category)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	replayed, failed := 0, 0
+	for _, file := range files {
+		if pattern != "" {
+			if ok, _ := filepath.Match(pattern, file.OriginalName); !ok {
+				continue
+			}
+		}
+
+		if dryRun {
+			if file.Reason != "" {
+				log.Printf("Would replay: %s (failed with: %s)", file.OriginalName, file.Reason)
+			} else {
+				log.Printf("Would replay: %s", file.OriginalName)
+			}
+			replayed++
+			continue
+		}
+		target, err := arch.Requeue(file, cfg.InputFolder)
+		if err != nil {
+			log.Printf("Failed to replay %s: %v", file.OriginalName, err)
+			failed++
+			continue
+		}
+		log.Printf("Replayed: %s -> %s", file.Path, target)
+		replayed++
+	}
+	return replayed, failed, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+)
+
+// rescanIgnoredCommand re-evaluates files in the ignored archive against the current
+// filters and moves files that now match back into the input folder
+func rescanIgnoredCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Route to rescan (required in multi-ingress mode)")
+	dryRun := fs.Bool("dry-run", false, "Report matching files without requeueing them")
+	return func(args []string) {
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		if cfg.RoutesConfigPath != "" {
+			if *routeName == "" {
+				log.Fatal("--route is required in multi-ingress routing mode")
+			}
+			cfg, err = routeConfig(cfg.RoutesConfigPath, *routeName)
+			if err != nil {
+				log.Fatalf("%v", err)
+			}
+		} else if *routeName != "" {
+			log.Fatal("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+		}
+
+		requeued, skipped, err := rescanIgnored(cfg, *dryRun)
+		if err != nil {
+			log.Fatalf("Rescan failed: %v", err)
+		}
+
+		action := "Requeued"
+		if *dryRun {
+			action = "Would requeue"
+		}
+		fmt.Printf("%s %d file(s) to %s; %d still ignored\n", action, requeued, cfg.InputFolder, skipped)
+	}
+}
+
+// routeConfig loads the routes configuration and returns the legacy config of one route
+func routeConfig(routesConfigPath, name string) (*config.Config, error) {
+	routesConfig, err := config.LoadRoutes(routesConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routes configuration: %w", err)
+	}
+	for _, route := range routesConfig.Routes {
+		if route.Name == name {
+			return route.ToLegacyConfig(), nil
+		}
+	}
+	return nil, fmt.Errorf("route '%s' not found in %s", name, routesConfigPath)
+}
+
+// rescanIgnored requeues ignored files whose original name now passes the input filters.
+// Files ignored as duplicates are left alone since no filter change affects them.
+func rescanIgnored(cfg *config.Config, dryRun bool) (int, int, error) {
+	arch := archiver.New(cfg.ArchiveProcessed, cfg.ArchiveIgnored, cfg.ArchiveFailed, cfg.ArchiveTimestamp)
+	arch.SetLocation(cfg.Location())
+	files, err := arch.IgnoredFiles()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	requeued, skipped := 0, 0
+	for _, file := range files {
+		if file.Reason == config.IgnoreReasonDuplicate {
+			skipped++
+			continue
+		}
+		if reason, _ := cfg.IgnoreReason(file.OriginalName); reason != "" {
+			log.Printf("Still ignored: %s (reason: %s)", file.OriginalName, reason)
+			skipped++
+			continue
+		}
+
+		if dryRun {
+			log.Printf("Would requeue: %s", file.OriginalName)
+			requeued++
+			continue
+		}
+		target, err := arch.Requeue(file, cfg.InputFolder)
+		if err != nil {
+			log.Printf("Failed to requeue %s: %v", file.OriginalName, err)
+			skipped++
+			continue
+		}
+		log.Printf("Requeued: %s -> %s", file.Path, target)
+		requeued++
+	}
+	return requeued, skipped, nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"csv2json/internal/converter"
+)
+
+// reverseCommand converts a JSON array of objects (e.g. csv2json output) back into CSV
+func reverseCommand(fs *flag.FlagSet) func(args []string) {
+	outputPath := fs.String("o", "", "Output CSV path (default: input path with .csv extension, - for stdout)")
+	delimiter := fs.String("delimiter", ",", "CSV field delimiter")
+	force := fs.Bool("force", false, "Overwrite an existing output file")
+	return func(args []string) {
+		if len(args) != 1 {
+			log.Fatal("usage: csv2json reverse input.json [-o output.csv] [--delimiter ,] [--force]")
+		}
+		comma, size := utf8.DecodeRuneInString(*delimiter)
+		if size == 0 || size != len(*delimiter) {
+			log.Fatalf("--delimiter must be a single character, got %q", *delimiter)
+		}
+
+		inputPath := args[0]
+		if *outputPath == "" {
+			*outputPath = filepath.Join(filepath.Dir(inputPath), converter.GetCSVFilename(filepath.Base(inputPath)))
+		}
+
+		rows, err := reverseFile(inputPath, *outputPath, comma, *force)
+		if err != nil {
+			log.Fatalf("Reverse conversion failed: %v", err)
+		}
+		if *outputPath != "-" {
+			fmt.Printf("Wrote %d row(s) to %s\n", rows, *outputPath)
+		}
+	}
+}
+
+// reverseFile converts inputPath to CSV at outputPath ("-" for stdout) and returns the row count
+func reverseFile(inputPath, outputPath string, delimiter rune, force bool) (int, error) {
+	file, err := os.Open(inputPath)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	result, err := converter.FromJSON(file)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", inputPath, err)
+	}
+
+	var buf bytes.Buffer
+	if err := converter.WriteCSV(&buf, result, delimiter); err != nil {
+		return 0, err
+	}
+
+	if outputPath == "-" {
+		_, err := os.Stdout.Write(buf.Bytes())
+		return len(result.Rows), err
+	}
+	if !force {
+		if _, err := os.Stat(outputPath); err == nil {
+			return 0, fmt.Errorf("%s already exists (use --force to overwrite)", outputPath)
+		}
+	}
+	if err := os.WriteFile(outputPath, buf.Bytes(), 0644); err != nil {
+		return 0, fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return len(result.Rows), nil
+}
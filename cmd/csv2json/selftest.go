@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/streadway/amqp"
+
+	"csv2json/internal/config"
+	"csv2json/internal/contract"
+	"csv2json/internal/output"
+	"csv2json/internal/processor"
+)
+
+// selftestRoute is the configuration a selftest runs against
+type selftestRoute struct {
+	cfg           *config.Config
+	name          string           // Route name ("" in legacy mode)
+	contract      string           // Ingestion contract identifier
+	payloadFormat string           // Queue message payload format (envelope, legacy or bare)
+	schema        *contract.Schema // Registry schema enforced on the route (nil = none)
+}
+
+// selftestCommand generates a sample CSV, runs it through a route's full pipeline and reads
+// the result back from the output folder or broker to verify the round trip
+func selftestCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Route to test (required in multi-ingress mode)")
+	rows := fs.Int("rows", 3, "Number of sample rows to generate")
+	columns := fs.String("columns", "id,name,amount", "Comma-separated sample columns (default: the route's contract properties, if enforced)")
+	filename := fs.String("filename", "", "Sample filename (default: csv2json-selftest-<id> with the route's first suffix)")
+	timeout := fs.Duration("timeout", 30*time.Second, "How long to wait for the message on the broker")
+	keep := fs.Bool("keep", false, "Leave the sample's output file in place")
+	return func(args []string) {
+		columnsSet := false
+		fs.Visit(func(f *flag.Flag) { columnsSet = columnsSet || f.Name == "columns" })
+
+		// Queue credentials may come from a secrets provider
+		loadSecrets()
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		target := &selftestRoute{cfg: cfg, payloadFormat: config.PayloadFormatEnvelope}
+		if cfg.RoutesConfigPath != "" {
+			if *routeName == "" {
+				log.Fatal("--route is required in multi-ingress routing mode")
+			}
+			if target, err = selftestRouteConfig(cfg.RoutesConfigPath, *routeName); err != nil {
+				log.Fatalf("%v", err)
+			}
+		} else if *routeName != "" {
+			log.Fatal("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+		}
+
+		sampleColumns := splitColumns(*columns)
+		if target.schema != nil && !columnsSet {
+			sampleColumns = contractColumns(target.schema)
+		}
+		if len(sampleColumns) == 0 || *rows < 1 {
+			log.Fatal("selftest needs at least one column and one row")
+		}
+
+		summary, err := selftest(target, sampleColumns, *rows, *filename, *timeout, *keep)
+		if err != nil {
+			log.Fatalf("Selftest FAILED: %v", err)
+		}
+		fmt.Printf("Selftest PASSED: %s\n", summary)
+	}
+}
+
+// selftestRouteConfig resolves a route's configuration, envelope settings and contract
+func selftestRouteConfig(routesConfigPath, name string) (*selftestRoute, error) {
+	routesConfig, err := config.LoadRoutes(routesConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load routes configuration: %w", err)
+	}
+	for _, route := range routesConfig.Routes {
+		if route.Name != name {
+			continue
+		}
+		target := &selftestRoute{
+			cfg:           route.ToLegacyConfig(),
+			name:          route.Name,
+			contract:      route.IngestionContract,
+			payloadFormat: route.Output.MessageFormat(),
+		}
+		if target.schema, err = routeContract(routesConfig, route); err != nil {
+			return nil, err
+		}
+		return target, nil
+	}
+	return nil, fmt.Errorf("route '%s' not found in %s", name, routesConfigPath)
+}
+
+// routeContract resolves the registry schema a route enforces (nil when the route's
+// contract is a label only)
+func routeContract(routesConfig *config.RoutesConfig, route config.Route) (*contract.Schema, error) {
+	if routesConfig.ContractRegistry == "" || route.Type == config.RouteTypeReverse || (route.EnforceContract != nil && !*route.EnforceContract) {
+		return nil, nil
+	}
+	registry, err := contract.NewRegistry(routesConfig.ContractRegistry, routesConfig.RegistryToken, routesConfig.RegistryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open contract registry: %w", err)
+	}
+	schema, err := registry.Fetch(route.IngestionContract)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve contract for route '%s': %w", route.Name, err)
+	}
+	return schema, nil
+}
+
+// selftest processes a generated sample with the route's parser, transforms, contract
+// and output. Input state and archives live in a temporary folder so a running service
+// watching the same input is not disturbed; only the output destination is shared.
+func selftest(target *selftestRoute, columns []string, rows int, filename string, timeout time.Duration, keep bool) (string, error) {
+	cfg := *target.cfg
+	switch {
+	case cfg.ReverseConversion:
+		return "", fmt.Errorf("reverse routes are not supported (selftest generates CSV input)")
+	case cfg.InputFormat != "" && cfg.InputFormat != "delimited":
+		return "", fmt.Errorf("input format %s is not supported (selftest generates delimited CSV)", cfg.InputFormat)
+	case cfg.PartitionBy != "":
+		return "", fmt.Errorf("partitioned output is not supported (selftest verifies a single output per file)")
+	}
+
+	id, err := selftestID()
+	if err != nil {
+		return "", err
+	}
+	if filename == "" {
+		suffix := ".csv"
+		if len(cfg.FileSuffixFilter) > 0 {
+			suffix = cfg.FileSuffixFilter[0]
+		}
+		filename = "csv2json-selftest-" + id + suffix
+	}
+	if reason, detail := cfg.IgnoreReason(filename); reason != "" {
+		return "", fmt.Errorf("sample %s would be ignored by the route (%s: %s); pass a matching --filename", filename, reason, detail)
+	}
+
+	work, err := os.MkdirTemp("", "csv2json-selftest-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(work)
+
+	cfg.InputFolder = filepath.Join(work, "input")
+	cfg.ArchiveProcessed = filepath.Join(work, "processed")
+	cfg.ArchiveIgnored = filepath.Join(work, "ignored")
+	cfg.ArchiveFailed = filepath.Join(work, "failed")
+	for _, dir := range []string{cfg.InputFolder, cfg.ArchiveProcessed, cfg.ArchiveIgnored, cfg.ArchiveFailed} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", err
+		}
+	}
+	// The sample is processed directly, once, and output immediately
+	cfg.WatchMode = "poll"
+	cfg.ClaimFiles = false
+	cfg.InstanceLock = false
+	cfg.SkipDuplicateFiles = false
+	cfg.MinFreeDiskMB = 0
+	cfg.BatchWindow = 0
+	cfg.BatchMaxFiles = 0
+	cfg.BatchManifests = false
+	cfg.ChecksumPolicy = config.ChecksumPolicyOff
+	cfg.WorkDir = ""
+	// Stream output is not read back (and a pipe blocks without a reader), so it is checked as files
+	stream := ""
+	if cfg.OutputType == "stdout" || cfg.OutputType == "pipe" {
+		stream = cfg.OutputType
+		cfg.OutputType = "file"
+		cfg.OutputFolder = filepath.Join(work, "output")
+		if err := os.MkdirAll(cfg.OutputFolder, 0755); err != nil {
+			return "", err
+		}
+	}
+
+	samplePath := filepath.Join(cfg.InputFolder, filename)
+	if err := writeSample(samplePath, &cfg, columns, rows, id, target.schema); err != nil {
+		return "", fmt.Errorf("failed to write sample: %w", err)
+	}
+
+	proc, err := processor.New(&cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize processor: %w", err)
+	}
+	if cfg.OutputType == "queue" || cfg.OutputType == "both" {
+		proc.SetEnvelopeContext(target.name, target.contract, target.payloadFormat)
+	}
+	if target.schema != nil {
+		proc.SetContract(target.schema)
+	}
+	err = proc.ProcessFile(samplePath)
+	proc.Stop()
+	if err != nil {
+		return "", err
+	}
+
+	// The processor archives rather than returns pipeline failures
+	if reason := archivedReason(cfg.ArchiveFailed, "Error: "); reason != "" {
+		return "", fmt.Errorf("sample archived as failed: %s", reason)
+	}
+	if reason := archivedReason(cfg.ArchiveIgnored, "Reason: "); reason != "" {
+		return "", fmt.Errorf("sample archived as ignored: %s", reason)
+	}
+
+	var checks []string
+	if cfg.OutputType == "file" || cfg.OutputType == "both" {
+		path := filepath.Join(cfg.OutputFolder, strings.TrimSuffix(filename, filepath.Ext(filename))+".json")
+		records, err := readOutputFile(path, keep)
+		if err != nil {
+			return "", err
+		}
+		if stream != "" {
+			checks = append(checks, fmt.Sprintf("%s output checked as a file (%d/%d records)", stream, records, rows))
+		} else {
+			checks = append(checks, fmt.Sprintf("file %s (%d/%d records)", path, records, rows))
+		}
+	}
+	if cfg.OutputType == "queue" || cfg.OutputType == "both" {
+		if cfg.QueueType != "rabbitmq" {
+			checks = append(checks, fmt.Sprintf("published to %s %s (read-back is only supported for rabbitmq)", cfg.QueueType, cfg.QueueName))
+		} else if cfg.MessageContentType != config.ContentTypeJSON {
+			checks = append(checks, fmt.Sprintf("published to queue %s as %s (read-back is only supported for JSON messages)", cfg.QueueName, cfg.MessageContentType))
+		} else {
+			records, err := consumeSelftestMessage(&cfg, filename, id, timeout)
+			if err != nil {
+				return "", err
+			}
+			checks = append(checks, fmt.Sprintf("queue %s (%d/%d records)", cfg.QueueName, records, rows))
+		}
+	}
+
+	route := target.name
+	if route == "" {
+		route = "default"
+	}
+	return fmt.Sprintf("route %s: %s -> %s", route, filename, strings.Join(checks, ", ")), nil
+}
+
+// selftestID returns a short random identifier that marks one selftest run
+func selftestID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// writeSample writes a delimited sample file using the route's delimiter and header setting
+func writeSample(path string, cfg *config.Config, columns []string, rows int, id string, schema *contract.Schema) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	w.Comma = cfg.Delimiter
+	if cfg.HasHeader {
+		w.Write(columns)
+	}
+	for i := 1; i <= rows; i++ {
+		record := make([]string, len(columns))
+		for j, column := range columns {
+			record[j] = sampleValue(column, i, id, schema)
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// sampleValue returns a value for one cell, satisfying the contract property if there is one
+func sampleValue(column string, row int, id string, schema *contract.Schema) string {
+	if schema != nil {
+		if property, ok := schema.Properties[column]; ok {
+			switch {
+			case len(property.Enum) > 0:
+				return property.Enum[0]
+			case property.Type == "integer":
+				return strconv.Itoa(row)
+			case property.Type == "number":
+				return fmt.Sprintf("%d.5", row)
+			case property.Type == "boolean":
+				return "true"
+			}
+		}
+	}
+	switch column {
+	case "id":
+		return strconv.Itoa(row)
+	case "amount":
+		return fmt.Sprintf("%d.50", row*10)
+	}
+	return fmt.Sprintf("selftest-%s-%d", id, row)
+}
+
+// contractColumns returns the columns a contract describes, required columns first
+func contractColumns(schema *contract.Schema) []string {
+	columns := append([]string(nil), schema.Required...)
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		seen[column] = true
+	}
+	var optional []string
+	for name := range schema.Properties {
+		if !seen[name] {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(optional)
+	return append(columns, optional...)
+}
+
+// splitColumns parses the --columns flag
+func splitColumns(spec string) []string {
+	var columns []string
+	for _, column := range strings.Split(spec, ",") {
+		if column = strings.TrimSpace(column); column != "" {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// archivedReason returns the field following prefix in the first sidecar archived in
+// folder (.error or .reason), or "" if nothing was archived there
+func archivedReason(folder, prefix string) string {
+	entries, err := os.ReadDir(folder)
+	if err != nil || len(entries) == 0 {
+		return ""
+	}
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(folder, entry.Name()))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if strings.HasPrefix(line, prefix) {
+				return strings.TrimPrefix(line, prefix)
+			}
+		}
+	}
+	return "file archived without a reason"
+}
+
+// readOutputFile counts the records in the sample's output file, removing it unless keep is set
+func readOutputFile(path string, keep bool) (int, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("output file not found: %w", err)
+	}
+	if !keep {
+		defer os.Remove(path)
+	}
+	var records []json.RawMessage
+	if err := json.Unmarshal(content, &records); err != nil {
+		return 0, fmt.Errorf("output file %s is not a JSON array: %w", path, err)
+	}
+	if len(records) == 0 {
+		return 0, fmt.Errorf("output file %s has no records", path)
+	}
+	return len(records), nil
+}
+
+// selftestMessage covers both the ADR-006 envelope and the legacy message format. Bare
+// messages are a JSON array only.
+type selftestMessage struct {
+	Identifier string `json:"identifier"`
+	Meta       struct {
+		Source struct {
+			Name string `json:"name"`
+		} `json:"source"`
+	} `json:"meta"`
+	Data []json.RawMessage `json:"data"`
+}
+
+// consumeSelftestMessage waits for the sample's message on the output queue and acks it.
+// Other messages received meanwhile are returned to the queue unacknowledged. Bare
+// messages carry no filename, so they are recognized by the run id in sample values.
+func consumeSelftestMessage(cfg *config.Config, filename, id string, timeout time.Duration) (int, error) {
+	amqpURL := fmt.Sprintf("amqp://%s:%d/%s", cfg.QueueHost, cfg.QueuePort, url.PathEscape(cfg.QueueVHost))
+	if cfg.QueueUsername != "" && cfg.QueuePassword != "" {
+		amqpURL = fmt.Sprintf("amqp://%s:%s@%s:%d/%s", cfg.QueueUsername, cfg.QueuePassword, cfg.QueueHost, cfg.QueuePort, url.PathEscape(cfg.QueueVHost))
+	}
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	defer conn.Close()
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open channel: %w", err)
+	}
+	defer ch.Close()
+
+	var payloads *output.PayloadCipher
+	if cfg.PayloadEncryptionKey != "" {
+		if payloads, err = output.NewPayloadCipher(cfg.PayloadEncryptionKey, cfg.PayloadEncryptionKeyID); err != nil {
+			return 0, err
+		}
+	}
+
+	var others []uint64
+	defer func() {
+		for _, tag := range others {
+			ch.Nack(tag, false, true)
+		}
+	}()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		delivery, ok, err := ch.Get(cfg.QueueName, false)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read from queue %s: %w", cfg.QueueName, err)
+		}
+		if !ok {
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+
+		body := delivery.Body
+		if payloads != nil && delivery.Headers[output.HeaderEncryptionKeyID] == payloads.KeyID() {
+			if body, err = payloads.Decrypt(body); err != nil {
+				body = nil // Not the sample; returned to the queue below
+			}
+		}
+
+		var message selftestMessage
+		matched := json.Unmarshal(body, &message) == nil && (message.Meta.Source.Name == filename || message.Identifier == filename)
+		if !matched && json.Unmarshal(body, &message.Data) == nil {
+			matched = bytes.Contains(body, []byte("selftest-"+id+"-"))
+		}
+		if matched {
+			if err := delivery.Ack(false); err != nil {
+				return 0, fmt.Errorf("failed to ack sample message: %w", err)
+			}
+			if len(message.Data) == 0 {
+				return 0, fmt.Errorf("sample message on %s has no records", cfg.QueueName)
+			}
+			return len(message.Data), nil
+		}
+		others = append(others, delivery.DeliveryTag)
+	}
+	return 0, fmt.Errorf("sample message not received on queue %s within %v (another consumer may have taken it)", cfg.QueueName, timeout)
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+// serviceCommand is only available on Windows; elsewhere the service runs under
+// a supervisor such as systemd, launchd or Docker, which stop it with SIGTERM
+func serviceCommand(fs *flag.FlagSet) func(args []string) {
+	return func(args []string) {
+		log.Fatal("csv2json service manages Windows services and is only available on Windows; " +
+			"on Linux and macOS run csv2json under systemd, launchd or Docker (SIGTERM stops it gracefully)")
+	}
+}
@@ -0,0 +1,197 @@
+//go:build windows
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const defaultServiceName = "csv2json"
+
+// serviceCommand manages csv2json as a native Windows service
+func serviceCommand(fs *flag.FlagSet) func(args []string) {
+	name := fs.String("name", defaultServiceName, "Windows service name")
+	workDir := fs.String("workdir", "", "Folder holding .env and routes.json (default: the executable's folder)")
+	return func(args []string) {
+		if len(args) != 1 {
+			log.Fatal("usage: csv2json service install|uninstall|start|stop|run [--name NAME] [--workdir DIR]")
+		}
+		command := args[0]
+
+		var err error
+		switch command {
+		case "install":
+			err = installService(*name, *workDir)
+		case "uninstall":
+			err = uninstallService(*name)
+		case "start":
+			err = startService(*name)
+		case "stop":
+			err = stopService(*name)
+		case "run":
+			err = runAsService(*name, *workDir)
+		default:
+			err = fmt.Errorf("unknown service command: %s (supported: install, uninstall, start, stop, run)", command)
+		}
+		if err != nil {
+			log.Fatalf("Service %s failed: %v", command, err)
+		}
+	}
+}
+
+// installService registers the executable as an automatic-start service that
+// restarts after failures
+func installService(name, workDir string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if workDir == "" {
+		workDir = filepath.Dir(exe)
+	}
+	if workDir, err = filepath.Abs(workDir); err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: "csv2json (" + name + ")",
+		Description: "Converts CSV files dropped into watched folders to JSON files or queue messages",
+		StartType:   mgr.StartAutomatic,
+	}, "service", "run", "--name", name, "--workdir", workDir)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	restart := mgr.RecoveryAction{Type: mgr.ServiceRestart, Delay: 10 * time.Second}
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{restart, restart, restart}, 24*60*60); err != nil {
+		log.Printf("Warning: failed to set recovery actions: %v", err)
+	}
+
+	fmt.Printf("Installed service %s (working directory %s)\n", name, workDir)
+	return nil
+}
+
+// uninstallService removes the service registration
+func uninstallService(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		if err := s.Delete(); err != nil {
+			return err
+		}
+		fmt.Printf("Removed service %s\n", name)
+		return nil
+	})
+}
+
+// startService asks the service manager to start the service
+func startService(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		if err := s.Start(); err != nil {
+			return err
+		}
+		fmt.Printf("Started service %s\n", name)
+		return nil
+	})
+}
+
+// stopService sends a stop request and waits for the service to finish its graceful shutdown
+func stopService(name string) error {
+	return withService(name, func(s *mgr.Service) error {
+		status, err := s.Control(svc.Stop)
+		if err != nil {
+			return err
+		}
+		deadline := time.Now().Add(60 * time.Second)
+		for status.State != svc.Stopped {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("service %s did not stop within 60s", name)
+			}
+			time.Sleep(500 * time.Millisecond)
+			if status, err = s.Query(); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Stopped service %s\n", name)
+		return nil
+	})
+}
+
+// withService opens the named service and calls fn with it
+func withService(name string, fn func(*mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to service manager (run as Administrator): %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", name, err)
+	}
+	defer s.Close()
+	return fn(s)
+}
+
+// runAsService runs the service under the Windows service manager. Services start in
+// the system folder, so the working directory is switched to where .env lives.
+func runAsService(name, workDir string) error {
+	if workDir != "" {
+		if err := os.Chdir(workDir); err != nil {
+			return err
+		}
+	}
+	return svc.Run(name, serviceHandler{})
+}
+
+// serviceHandler translates service control events into a graceful shutdown
+type serviceHandler struct{}
+
+func (serviceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		serve(stop)
+		close(done)
+	}()
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				changes <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				log.Println("Service stop requested, stopping gracefully...")
+				changes <- svc.Status{State: svc.StopPending}
+				close(stop)
+				<-done
+				return false, 0
+			}
+		case <-done:
+			// The service exited without being asked to; report a failure so recovery actions apply
+			return false, 1
+		}
+	}
+}
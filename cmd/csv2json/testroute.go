@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"csv2json/internal/config"
+	"csv2json/internal/processor"
+)
+
+// testRouteCommand runs one file through a route's full pipeline and prints the JSON files
+// or queue messages it would produce, without archiving the file, writing output or
+// connecting to the broker
+func testRouteCommand(fs *flag.FlagSet) func(args []string) {
+	routeName := fs.String("route", "", "Route to test (required in multi-ingress mode)")
+	file := fs.String("file", "", "Sample file to run through the route (required)")
+	return func(args []string) {
+		if *file == "" {
+			log.Fatal("--file is required")
+		}
+		if _, err := os.Stat(*file); err != nil {
+			log.Fatalf("Cannot read sample file: %v", err)
+		}
+
+		// Signing and encryption keys may come from a secrets provider
+		loadSecrets()
+
+		cfg, err := config.Load()
+		if err != nil {
+			log.Fatalf("Failed to load configuration: %v", err)
+		}
+
+		target := &selftestRoute{cfg: cfg, payloadFormat: config.PayloadFormatEnvelope}
+		if cfg.RoutesConfigPath != "" {
+			if *routeName == "" {
+				log.Fatal("--route is required in multi-ingress routing mode")
+			}
+			if target, err = selftestRouteConfig(cfg.RoutesConfigPath, *routeName); err != nil {
+				log.Fatalf("%v", err)
+			}
+		} else if *routeName != "" {
+			log.Fatal("--route requires ROUTES_CONFIG (multi-ingress routing mode)")
+		}
+
+		proc, err := processor.NewDryRun(target.cfg, os.Stdout)
+		if err != nil {
+			log.Fatalf("Failed to create route pipeline: %v", err)
+		}
+		proc.SetEnvelopeContext(target.name, target.contract, target.payloadFormat)
+		if target.schema != nil {
+			proc.SetContract(target.schema)
+		}
+
+		result, err := proc.DryRun(*file)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Dry run FAILED: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Dry run PASSED: %d rows from %s\n", len(result.Rows), *file)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"os"
+	"os/signal"
+
+	"csv2json/internal/admin"
+)
+
+// startTriggers lets operators force an immediate rescan of the running routes'
+// input folders: SIGUSR1 rescans every route (not on Windows), and the admin API
+// on adminAddr ("" = disabled) rescans every route or one selected by name
+func startTriggers(routes *routeSet, adminAddr string, stop <-chan struct{}) {
+	if sigs := triggerSignals(); len(sigs) > 0 {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, sigs...)
+		go func() {
+			defer signal.Stop(sigChan)
+			for {
+				select {
+				case sig := <-sigChan:
+					triggered, _ := routes.trigger("")
+					log.Printf("%v received: rescanning %d route(s)", sig, len(triggered))
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	if adminAddr != "" {
+		go func() {
+			if err := admin.Serve(adminAddr, routes.trigger); err != nil {
+				log.Printf("ERROR: Admin API failed: %v", err)
+			}
+		}()
+	}
+}
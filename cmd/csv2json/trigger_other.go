@@ -0,0 +1,13 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// triggerSignals are the signals that force an immediate rescan of every route
+func triggerSignals() []os.Signal {
+	return []os.Signal{syscall.SIGUSR1}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// triggerSignals is empty on Windows, which has no SIGUSR1; use the admin API
+// (ADMIN_ADDR) to force a rescan instead
+func triggerSignals() []os.Signal {
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"csv2json/internal/config"
+)
+
+// validateRoutesCommand loads a routes file as the service would at startup, resolves
+// the contracts its routes enforce and lists the routes
+func validateRoutesCommand(fs *flag.FlagSet) func(args []string) {
+	routesPath := fs.String("routes", "", "Routes file to validate (default: ROUTES_CONFIG)")
+	return func(args []string) {
+		if *routesPath == "" {
+			cfg, err := config.Load()
+			if err != nil {
+				log.Fatalf("Failed to load configuration: %v", err)
+			}
+			if cfg.RoutesConfigPath == "" {
+				log.Fatal("No routes file: pass --routes or set ROUTES_CONFIG")
+			}
+			*routesPath = cfg.RoutesConfigPath
+		}
+
+		routesConfig, err := config.LoadRoutes(*routesPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Routes INVALID: %v\n", err)
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ROUTE\tINPUT\tOUTPUT\tCONTRACT")
+		var invalid int
+		for _, route := range routesConfig.Routes {
+			contract := route.IngestionContract
+			schema, err := routeContract(routesConfig, route)
+			switch {
+			case err != nil:
+				contract = "ERROR: " + err.Error()
+				invalid++
+			case schema != nil:
+				contract += " (enforced, version " + schema.ResolvedVersion + ")"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", route.Name, route.Input.Path, route.Output.Type, contract)
+		}
+		w.Flush()
+
+		for _, skipped := range routesConfig.Skipped {
+			fmt.Fprintf(os.Stderr, "Route '%s' is invalid and would be skipped: %v\n", skipped.Name, skipped.Err)
+			invalid++
+		}
+		if invalid > 0 {
+			fmt.Fprintf(os.Stderr, "Routes INVALID: %d of %d route(s) failed validation\n", invalid, len(routesConfig.Routes)+len(routesConfig.Skipped))
+			os.Exit(1)
+		}
+		fmt.Printf("Routes OK: %d route(s) in %s\n", len(routesConfig.Routes), *routesPath)
+	}
+}
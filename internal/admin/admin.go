@@ -0,0 +1,47 @@
+// Package admin serves operator actions for a running service over HTTP (ADMIN_ADDR)
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// ErrUnknownRoute is returned by a TriggerFunc for a route that is not running
+var ErrUnknownRoute = errors.New("unknown route")
+
+// TriggerFunc forces an immediate rescan of a route's input folder ("" = every
+// route) and returns the names of the routes triggered
+type TriggerFunc func(route string) ([]string, error)
+
+// Handler serves POST /trigger[?route=NAME]
+func Handler(trigger TriggerFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/trigger", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		routes, err := trigger(r.URL.Query().Get("route"))
+		if errors.Is(err, ErrUnknownRoute) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string][]string{"triggered": routes})
+	})
+	return mux
+}
+
+// Serve exposes the admin API on addr (blocks)
+func Serve(addr string, trigger TriggerFunc) error {
+	log.Printf("Admin API available at http://%s", addr)
+	return http.ListenAndServe(addr, Handler(trigger))
+}
@@ -0,0 +1,52 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestTriggerHandler validates the trigger endpoint's routing, status codes and body
+func TestTriggerHandler(t *testing.T) {
+	routes := []string{"orders", "products"}
+	trigger := func(route string) ([]string, error) {
+		if route == "" {
+			return routes, nil
+		}
+		for _, name := range routes {
+			if name == route {
+				return []string{name}, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnknownRoute, route)
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "all routes", method: http.MethodPost, target: "/trigger", wantStatus: http.StatusAccepted, wantBody: `{"triggered":["orders","products"]}`},
+		{name: "one route", method: http.MethodPost, target: "/trigger?route=orders", wantStatus: http.StatusAccepted, wantBody: `{"triggered":["orders"]}`},
+		{name: "unknown route", method: http.MethodPost, target: "/trigger?route=missing", wantStatus: http.StatusNotFound, wantBody: "unknown route: missing"},
+		{name: "wrong method", method: http.MethodGet, target: "/trigger", wantStatus: http.StatusMethodNotAllowed, wantBody: "method not allowed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			Handler(trigger).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if body := strings.TrimSpace(rec.Body.String()); body != tt.wantBody {
+				t.Errorf("Expected body %q, got %q", tt.wantBody, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+// Alert describes one problem reported to operators
+type Alert struct {
+	Event      string    `json:"event"` // config.AlertEvent* value
+	Route      string    `json:"route"`
+	Message    string    `json:"message"`
+	Host       string    `json:"host"`
+	Time       time.Time `json:"time"`
+	Suppressed int       `json:"suppressed"` // Alerts for the same event and route dropped by rate limiting since the last one sent
+}
+
+// Summary returns a one-line description used as a title or email subject
+func (a Alert) Summary() string {
+	return fmt.Sprintf("[csv2json] %s on route %s (%s)", a.Event, a.Route, a.Host)
+}
+
+// Text renders the alert as plain text
+func (a Alert) Text() string {
+	text := fmt.Sprintf("%s\n%s\nTime: %s", a.Summary(), a.Message, a.Time.Format(time.RFC3339))
+	if a.Suppressed > 0 {
+		text += fmt.Sprintf("\n%d similar alert(s) suppressed by rate limiting", a.Suppressed)
+	}
+	return text
+}
+
+// Channel delivers alerts to one destination
+type Channel interface {
+	Name() string
+	Deliver(ctx context.Context, a Alert) error
+}
+
+// Dispatcher rate limits alerts per event and route and delivers them to every
+// configured channel in the background
+type Dispatcher struct {
+	channels  []Channel
+	events    map[string]bool
+	rateLimit time.Duration
+	timeout   time.Duration
+	host      string
+
+	mu         sync.Mutex
+	lastSent   map[string]time.Time // Event/route key -> time of the last alert sent
+	suppressed map[string]int       // Event/route key -> alerts dropped since then
+	inFlight   sync.WaitGroup
+}
+
+// New creates a dispatcher for the channels configured in cfg, or returns nil when
+// alerting is disabled
+func New(cfg *config.AlertsConfig) *Dispatcher {
+	if !cfg.Enabled() {
+		return nil
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	d := &Dispatcher{
+		events:     make(map[string]bool, len(cfg.Events)),
+		rateLimit:  cfg.RateLimit,
+		timeout:    cfg.Timeout,
+		lastSent:   make(map[string]time.Time),
+		suppressed: make(map[string]int),
+	}
+	d.host, _ = os.Hostname()
+	for _, event := range cfg.Events {
+		d.events[event] = true
+	}
+	if cfg.SlackWebhookURL != "" {
+		d.channels = append(d.channels, &slackChannel{url: cfg.SlackWebhookURL, client: client})
+	}
+	if cfg.WebhookURL != "" {
+		d.channels = append(d.channels, &webhookChannel{url: cfg.WebhookURL, client: client})
+	}
+	if cfg.SMTPHost != "" {
+		d.channels = append(d.channels, newEmailChannel(cfg))
+	}
+	return d
+}
+
+// Send reports an event unless it is not subscribed to or rate limited. Delivery
+// happens in the background; a nil dispatcher discards the alert.
+func (d *Dispatcher) Send(event, route, message string) {
+	if d == nil || !d.events[event] {
+		return
+	}
+	if route == "" {
+		route = "default"
+	}
+
+	a, ok := d.admit(Alert{Event: event, Route: route, Message: message, Host: d.host, Time: time.Now().UTC()})
+	if !ok {
+		return
+	}
+	for _, channel := range d.channels {
+		d.inFlight.Add(1)
+		go func(channel Channel) {
+			defer d.inFlight.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			defer cancel()
+			if err := channel.Deliver(ctx, a); err != nil {
+				log.Printf("Warning: failed to send %s alert via %s: %v", a.Event, channel.Name(), err)
+			}
+		}(channel)
+	}
+}
+
+// admit applies the rate limit, returning the alert with its suppressed count when
+// it may be sent
+func (d *Dispatcher) admit(a Alert) (Alert, bool) {
+	key := a.Event + "/" + a.Route
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if last, ok := d.lastSent[key]; ok && d.rateLimit > 0 && a.Time.Sub(last) < d.rateLimit {
+		d.suppressed[key]++
+		return a, false
+	}
+	a.Suppressed = d.suppressed[key]
+	d.lastSent[key] = a.Time
+	delete(d.suppressed, key)
+	return a, true
+}
+
+// Wait blocks until alerts being delivered have been sent or timed out
+func (d *Dispatcher) Wait() {
+	if d != nil {
+		d.inFlight.Wait()
+	}
+}
+
+// Default is the process-wide dispatcher used by Send (nil = alerting disabled)
+var Default *Dispatcher
+
+// Send reports an event through the default dispatcher
+func Send(event, route, message string) {
+	Default.Send(event, route, message)
+}
+
+// Wait waits for the default dispatcher's pending deliveries, e.g. before exiting
+func Wait() {
+	Default.Wait()
+}
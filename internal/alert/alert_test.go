@@ -0,0 +1,152 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+// recorder collects the request bodies posted to a test webhook
+type recorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *recorder) handler(status int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(body))
+		r.mu.Unlock()
+		w.WriteHeader(status)
+	}
+}
+
+func (r *recorder) received() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+func testConfig() *config.AlertsConfig {
+	return &config.AlertsConfig{
+		Events:    []string{config.AlertEventFileFailed, config.AlertEventBrokerOutage},
+		RateLimit: time.Hour,
+		Timeout:   5 * time.Second,
+	}
+}
+
+// TestNewDisabled validates no dispatcher is created without channels and a nil one is safe to use
+func TestNewDisabled(t *testing.T) {
+	d := New(testConfig())
+	if d != nil {
+		t.Fatal("Expected nil dispatcher without channels")
+	}
+	d.Send(config.AlertEventFileFailed, "products", "boom")
+	d.Wait()
+}
+
+// TestSendWebhookAndSlack validates payloads delivered to the webhook and Slack channels
+func TestSendWebhookAndSlack(t *testing.T) {
+	var webhook, slack recorder
+	webhookServer := httptest.NewServer(webhook.handler(http.StatusOK))
+	defer webhookServer.Close()
+	slackServer := httptest.NewServer(slack.handler(http.StatusOK))
+	defer slackServer.Close()
+
+	cfg := testConfig()
+	cfg.WebhookURL = webhookServer.URL
+	cfg.SlackWebhookURL = slackServer.URL
+	d := New(cfg)
+
+	d.Send(config.AlertEventFileFailed, "", "orders.csv archived as failed: bad row")
+	d.Wait()
+
+	bodies := webhook.received()
+	if len(bodies) != 1 {
+		t.Fatalf("Expected 1 webhook delivery, got %d", len(bodies))
+	}
+	var a Alert
+	if err := json.Unmarshal([]byte(bodies[0]), &a); err != nil {
+		t.Fatalf("Invalid webhook payload: %v", err)
+	}
+	if a.Event != config.AlertEventFileFailed || a.Route != "default" || !strings.Contains(a.Message, "orders.csv") {
+		t.Errorf("Unexpected alert: %+v", a)
+	}
+
+	bodies = slack.received()
+	if len(bodies) != 1 || !strings.Contains(bodies[0], `"text":"[csv2json] file_failed on route default`) {
+		t.Errorf("Unexpected Slack payload: %v", bodies)
+	}
+}
+
+// TestRateLimit validates alerts are limited per event and route and suppressed ones are counted
+func TestRateLimit(t *testing.T) {
+	var webhook recorder
+	server := httptest.NewServer(webhook.handler(http.StatusOK))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.WebhookURL = server.URL
+	d := New(cfg)
+
+	d.Send(config.AlertEventFileFailed, "products", "first")
+	d.Send(config.AlertEventFileFailed, "products", "second")
+	d.Send(config.AlertEventFileFailed, "products", "third")
+	d.Send(config.AlertEventFileFailed, "orders", "other route")
+	d.Send(config.AlertEventBrokerOutage, "products", "other event")
+	d.Send(config.AlertEventRouteStartup, "products", "not subscribed")
+	d.Wait()
+
+	if got := len(webhook.received()); got != 3 {
+		t.Fatalf("Expected 3 deliveries, got %d", got)
+	}
+
+	// Once the window has passed, the next alert reports what was suppressed
+	d.mu.Lock()
+	d.lastSent[config.AlertEventFileFailed+"/products"] = time.Now().Add(-2 * time.Hour)
+	d.mu.Unlock()
+	d.Send(config.AlertEventFileFailed, "products", "fourth")
+	d.Wait()
+
+	bodies := webhook.received()
+	var a Alert
+	if err := json.Unmarshal([]byte(bodies[len(bodies)-1]), &a); err != nil {
+		t.Fatalf("Invalid webhook payload: %v", err)
+	}
+	if a.Message != "fourth" || a.Suppressed != 2 {
+		t.Errorf("Expected fourth alert with 2 suppressed, got %+v", a)
+	}
+}
+
+// TestEmailMessage validates the rendered email headers and body
+func TestEmailMessage(t *testing.T) {
+	cfg := testConfig()
+	cfg.SMTPHost = "smtp.example.com"
+	cfg.SMTPPort = 587
+	cfg.EmailFrom = "csv2json@example.com"
+	cfg.EmailTo = []string{"ops@example.com", "data@example.com"}
+	c := newEmailChannel(cfg)
+
+	message := string(c.message(Alert{Event: config.AlertEventBrokerOutage, Route: "orders", Message: "connection refused", Host: "etl1", Suppressed: 4}))
+	for _, want := range []string{
+		"To: ops@example.com, data@example.com\r\n",
+		"Subject: [csv2json] broker_outage on route orders (etl1)\r\n",
+		"connection refused\r\n",
+		"4 similar alert(s) suppressed",
+	} {
+		if !strings.Contains(message, want) {
+			t.Errorf("Expected message to contain %q, got:\n%s", want, message)
+		}
+	}
+	if c.addr != "smtp.example.com:587" {
+		t.Errorf("Expected smtp.example.com:587, got %s", c.addr)
+	}
+}
@@ -0,0 +1,110 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+
+	"csv2json/internal/config"
+)
+
+// slackChannel posts alerts to a Slack incoming webhook
+type slackChannel struct {
+	url    string
+	client *http.Client
+}
+
+func (c *slackChannel) Name() string { return "slack" }
+
+func (c *slackChannel) Deliver(ctx context.Context, a Alert) error {
+	return postJSON(ctx, c.client, c.url, map[string]string{"text": a.Text()})
+}
+
+// webhookChannel posts the alert as JSON to a generic webhook
+type webhookChannel struct {
+	url    string
+	client *http.Client
+}
+
+func (c *webhookChannel) Name() string { return "webhook" }
+
+func (c *webhookChannel) Deliver(ctx context.Context, a Alert) error {
+	return postJSON(ctx, c.client, c.url, a)
+}
+
+// postJSON posts body as JSON and treats any non-2xx response as a failure
+func postJSON(ctx context.Context, client *http.Client, url string, body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// emailChannel sends alerts over SMTP
+type emailChannel struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+}
+
+func newEmailChannel(cfg *config.AlertsConfig) *emailChannel {
+	c := &emailChannel{
+		addr: cfg.SMTPHost + ":" + strconv.Itoa(cfg.SMTPPort),
+		from: cfg.EmailFrom,
+		to:   cfg.EmailTo,
+	}
+	if cfg.SMTPUsername != "" {
+		c.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return c
+}
+
+func (c *emailChannel) Name() string { return "email" }
+
+// Deliver sends the alert; net/smtp has no context support, so the dispatcher's
+// timeout bounds how long Wait blocks rather than the SMTP session itself
+func (c *emailChannel) Deliver(ctx context.Context, a Alert) error {
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(c.addr, c.auth, c.from, c.to, c.message(a)) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// message renders the alert as a plain text email
+func (c *emailChannel) message(a Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", c.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(c.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", a.Summary())
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(a.Text(), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -1,11 +1,15 @@
 package archiver
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
+
+	"csv2json/internal/failure"
 )
 
 type Category string
@@ -19,6 +23,16 @@ const (
 type Archiver struct {
 	archivePaths map[Category]string
 	addTimestamp bool
+	onArchived   func(filename string, category Category, reason string) // Called after each file is archived
+
+	location   *time.Location // Time zone of archive timestamps (nil = server local)
+	dateFolder string         // Business date subfolder the next file is archived under ("" = none)
+
+	companionDir      string   // Folder holding companion files ("" = none)
+	companionSuffixes []string // Companions (<file><suffix>) archived alongside each file
+
+	mu    sync.Mutex
+	stats map[string][]byte // Column statistics written next to files when they are archived, by filename
 }
 
 func New(processed, ignored, failed string, addTimestamp bool) *Archiver {
@@ -33,11 +47,146 @@ func New(processed, ignored, failed string, addTimestamp bool) *Archiver {
 }
 
 func (a *Archiver) Archive(filePath string, category Category, errorMsg string) error {
-	archiveDir := a.archivePaths[category]
+	return a.archive(filePath, category, errorMsg, "")
+}
+
+// ArchiveFailed archives a file as failed. The error sidecar records the error's
+// category, route, row and column when it carries them (see internal/failure).
+func (a *Archiver) ArchiveFailed(filePath string, cause error) error {
+	var context string
+	var e *failure.Error
+	if errors.As(cause, &e) {
+		context = e.Context()
+	}
+	return a.archive(filePath, CategoryFailed, cause.Error(), context)
+}
+
+func (a *Archiver) archive(filePath string, category Category, errorMsg, context string) error {
+	archivePath, err := a.move(filePath, category)
+	if err != nil {
+		return err
+	}
+	a.moveCompanions(filePath, archivePath)
+	a.writeStats(filePath, archivePath)
+
+	// Create error log if error message provided
+	if errorMsg != "" {
+		if err := a.logError(archivePath, errorMsg, context); err != nil {
+			// Log error but don't fail the archive operation
+			fmt.Printf("Warning: failed to create error log: %v\n", err)
+		}
+	}
+
+	if a.onArchived != nil {
+		a.onArchived(filepath.Base(filePath), category, errorMsg)
+	}
+	return nil
+}
+
+// OnArchived registers a callback invoked after each file is archived, with the
+// error message (failed files) or reason code (ignored files)
+func (a *Archiver) OnArchived(callback func(filename string, category Category, reason string)) {
+	a.onArchived = callback
+}
+
+// SetLocation sets the time zone of archive name timestamps and sidecar timestamps
+// (nil = server local)
+func (a *Archiver) SetLocation(loc *time.Location) {
+	a.location = loc
+}
+
+// Now returns the current time in the archiver's time zone
+func (a *Archiver) Now() time.Time {
+	if a.location == nil {
+		return time.Now()
+	}
+	return time.Now().In(a.location)
+}
+
+// SetDateFolder archives the files that follow under a subfolder of their category's
+// archive folder named after their business date (YYYY-MM-DD, "" = none)
+func (a *Archiver) SetDateFolder(date string) {
+	a.dateFolder = date
+}
+
+// SetCompanions archives companion files found in dir (e.g. orders.csv.sha256 next to
+// orders.csv) alongside each archived file, under the archived name plus their suffix
+func (a *Archiver) SetCompanions(dir string, suffixes []string) {
+	a.companionDir = dir
+	a.companionSuffixes = suffixes
+}
+
+// moveCompanions moves the companions of filePath next to its archived path
+func (a *Archiver) moveCompanions(filePath, archivePath string) {
+	if a.companionDir == "" {
+		return
+	}
+	for _, suffix := range a.companionSuffixes {
+		companion := filepath.Join(a.companionDir, filepath.Base(filePath)+suffix)
+		if _, err := os.Stat(companion); err != nil {
+			continue
+		}
+		if err := MoveFile(companion, archivePath+suffix); err != nil {
+			// Log error but don't fail the archive operation
+			fmt.Printf("Warning: failed to archive %s: %v\n", filepath.Base(companion), err)
+		}
+	}
+}
+
+// AttachStats records column statistics of a file, written to a .stats.json sidecar
+// next to the file when it is archived
+func (a *Archiver) AttachStats(filename string, content []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stats == nil {
+		a.stats = make(map[string][]byte)
+	}
+	a.stats[filename] = content
+}
+
+// writeStats writes the statistics attached to filePath next to its archived path
+func (a *Archiver) writeStats(filePath, archivePath string) {
+	a.mu.Lock()
+	content, ok := a.stats[filepath.Base(filePath)]
+	delete(a.stats, filepath.Base(filePath))
+	a.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := os.WriteFile(archivePath+statsSuffix, content, 0644); err != nil {
+		// Log error but don't fail the archive operation
+		fmt.Printf("Warning: failed to write column statistics: %v\n", err)
+	}
+}
+
+// ArchiveIgnored archives a file as ignored and writes a .reason sidecar with the reason code
+func (a *Archiver) ArchiveIgnored(filePath, reason, detail string) error {
+	archivePath, err := a.move(filePath, CategoryIgnored)
+	if err != nil {
+		return err
+	}
+	a.moveCompanions(filePath, archivePath)
+	a.writeStats(filePath, archivePath)
+
+	if err := a.logReason(archivePath, filepath.Base(filePath), reason, detail); err != nil {
+		// Log error but don't fail the archive operation
+		fmt.Printf("Warning: failed to create reason log: %v\n", err)
+	}
+
+	if a.onArchived != nil {
+		a.onArchived(filepath.Base(filePath), CategoryIgnored, reason)
+	}
+
+	return nil
+}
+
+// move moves a file into the archive folder for category and returns its archived path
+func (a *Archiver) move(filePath string, category Category) (string, error) {
+	archiveDir := filepath.Join(a.archivePaths[category], a.dateFolder)
 
 	// Ensure archive directory exists
 	if err := os.MkdirAll(archiveDir, 0755); err != nil {
-		return fmt.Errorf("failed to create archive directory: %w", err)
+		return "", &failure.Error{Kind: failure.ErrArchive, File: filepath.Base(filePath), Err: fmt.Errorf("failed to create archive directory: %w", err)}
 	}
 
 	// Generate archive filename
@@ -45,7 +194,7 @@ func (a *Archiver) Archive(filePath string, category Category, errorMsg string)
 	var archiveName string
 
 	if a.addTimestamp {
-		timestamp := time.Now().Format("20060102_150405")
+		timestamp := a.Now().Format("20060102_150405")
 		ext := filepath.Ext(filename)
 		base := filename[:len(filename)-len(ext)]
 		archiveName = fmt.Sprintf("%s_%s%s", base, timestamp, ext)
@@ -64,7 +213,7 @@ func (a *Archiver) Archive(filePath string, category Category, errorMsg string)
 		ext := filepath.Ext(filename)
 		base := filename[:len(filename)-len(ext)]
 		if a.addTimestamp {
-			timestamp := time.Now().Format("20060102_150405")
+			timestamp := a.Now().Format("20060102_150405")
 			archiveName = fmt.Sprintf("%s_%s_%d%s", base, timestamp, counter, ext)
 		} else {
 			archiveName = fmt.Sprintf("%s_%d%s", base, counter, ext)
@@ -73,41 +222,57 @@ func (a *Archiver) Archive(filePath string, category Category, errorMsg string)
 		counter++
 	}
 
-	// Move file (try rename first, fallback to copy+delete for cross-device links)
-	if err := os.Rename(filePath, archivePath); err != nil {
+	if err := MoveFile(filePath, archivePath); err != nil {
+		return "", &failure.Error{Kind: failure.ErrArchive, File: filename, Err: err}
+	}
+	return archivePath, nil
+}
+
+// MoveFile moves src to dst (try rename first, fallback to copy+delete for cross-device links)
+func MoveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err != nil {
 		// Rename failed (likely cross-device link in Docker volumes)
 		// Fallback to copy + delete
-		if err := copyFile(filePath, archivePath); err != nil {
-			return fmt.Errorf("failed to copy file to archive: %w", err)
+		if err := copyFile(src, dst); err != nil {
+			return fmt.Errorf("failed to copy file: %w", err)
 		}
-		if err := os.Remove(filePath); err != nil {
+		if err := os.Remove(src); err != nil {
 			return fmt.Errorf("failed to remove original file after copy: %w", err)
 		}
 	}
-
-	// Create error log if error message provided
-	if errorMsg != "" {
-		if err := a.logError(archivePath, errorMsg); err != nil {
-			// Log error but don't fail the archive operation
-			fmt.Printf("Warning: failed to create error log: %v\n", err)
-		}
-	}
-
 	return nil
 }
 
-func (a *Archiver) logError(archivePath, errorMsg string) error {
-	errorLogPath := archivePath + ".error"
+// logError writes the error sidecar next to the archived file, followed by the
+// error's context lines
+func (a *Archiver) logError(archivePath, errorMsg, context string) error {
+	errorLogPath := archivePath + errorSuffix
 
-	content := fmt.Sprintf("Timestamp: %s\nFile: %s\nError: %s\n",
-		time.Now().Format(time.RFC3339),
+	content := fmt.Sprintf("Timestamp: %s\nFile: %s\nError: %s\n%s",
+		a.Now().Format(time.RFC3339),
 		filepath.Base(archivePath),
 		errorMsg,
+		context,
 	)
 
 	return os.WriteFile(errorLogPath, []byte(content), 0644)
 }
 
+// logReason writes the ignore reason sidecar next to the archived file
+func (a *Archiver) logReason(archivePath, original, reason, detail string) error {
+	reasonLogPath := archivePath + reasonSuffix
+
+	content := fmt.Sprintf("Timestamp: %s\nFile: %s\nOriginal: %s\nReason: %s\nDetail: %s\n",
+		a.Now().Format(time.RFC3339),
+		filepath.Base(archivePath),
+		original,
+		reason,
+		detail,
+	)
+
+	return os.WriteFile(reasonLogPath, []byte(content), 0644)
+}
+
 // copyFile copies a file from src to dst
 func copyFile(src, dst string) error {
 	sourceFile, err := os.Open(src)
@@ -1,11 +1,14 @@
 package archiver
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
+
+	"csv2json/internal/failure"
 )
 
 func TestNew(t *testing.T) {
@@ -150,6 +153,42 @@ func TestArchive_WithTimestamp(t *testing.T) {
 	}
 }
 
+// TestArchive_Location validates archive name and sidecar timestamps follow the
+// configured time zone and read back as the same instant
+func TestArchive_Location(t *testing.T) {
+	tempDir := t.TempDir()
+	archiveDir := filepath.Join(tempDir, "archive")
+	testFile := filepath.Join(tempDir, "test.csv")
+	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// A fixed zone far from UTC, so the local and zoned names differ
+	loc := time.FixedZone("UTC+14", 14*60*60)
+	a := New(archiveDir, archiveDir, archiveDir, true)
+	a.SetLocation(loc)
+	before := time.Now().Truncate(time.Second)
+	if err := a.Archive(testFile, CategoryProcessed, "boom"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	files, err := a.ArchivedFiles(CategoryProcessed)
+	if err != nil || len(files) != 1 {
+		t.Fatalf("Expected one archived file, got %v (%v)", files, err)
+	}
+	name := filepath.Base(files[0].Path)
+	if want := "test_" + before.In(loc).Format("20060102") + "_"; !strings.HasPrefix(name, want) {
+		t.Errorf("Expected archived name starting %s, got %s", want, name)
+	}
+	sidecar, err := os.ReadFile(files[0].Path + errorSuffix)
+	if err != nil || !strings.Contains(string(sidecar), "+14:00") {
+		t.Errorf("Expected the sidecar timestamp in the configured zone, got %q (%v)", sidecar, err)
+	}
+	if files[0].ArchivedAt.Before(before) {
+		t.Errorf("Expected ArchivedAt after %v, got %v", before, files[0].ArchivedAt)
+	}
+}
+
 func TestArchive_DuplicateHandling(t *testing.T) {
 	tempDir := t.TempDir()
 	inputDir := filepath.Join(tempDir, "input")
@@ -260,6 +299,105 @@ func TestArchive_ErrorLog(t *testing.T) {
 	}
 }
 
+// TestArchiveFailed_Context validates the error sidecar records the category and
+// context of categorized errors
+func TestArchiveFailed_Context(t *testing.T) {
+	tempDir := t.TempDir()
+	testFile := filepath.Join(tempDir, "orders.csv")
+	if err := os.WriteFile(testFile, []byte("id,amount\n1,x\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	archiveDir := filepath.Join(tempDir, "failed")
+	a := New(archiveDir, archiveDir, archiveDir, false)
+
+	cause := &failure.Error{Kind: failure.ErrValidation, Route: "orders", File: "orders.csv", Row: 2, Column: "amount", Err: fmt.Errorf("not a number")}
+	if err := a.ArchiveFailed(testFile, cause); err != nil {
+		t.Fatalf("ArchiveFailed failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(archiveDir, "orders.csv.error"))
+	if err != nil {
+		t.Fatalf("Error log not found: %v", err)
+	}
+	for _, line := range []string{"Error: not a number", "Category: validation", "Route: orders", "Row: 2", "Column: amount"} {
+		if !strings.Contains(string(content), line+"\n") {
+			t.Errorf("Expected error log line %q, got:\n%s", line, content)
+		}
+	}
+
+	files, err := a.ArchivedFiles(CategoryFailed)
+	if err != nil || len(files) != 1 || files[0].Category != "validation" {
+		t.Errorf("Expected the failed file listed with category validation, got %+v (%v)", files, err)
+	}
+}
+
+// TestArchive_OnArchived validates the callback reports each archived file with its category and reason
+func TestArchive_OnArchived(t *testing.T) {
+	tempDir := t.TempDir()
+	a := New(filepath.Join(tempDir, "processed"), filepath.Join(tempDir, "ignored"), filepath.Join(tempDir, "failed"), false)
+
+	var archived []string
+	a.OnArchived(func(filename string, category Category, reason string) {
+		archived = append(archived, fmt.Sprintf("%s %s: %s", filename, category, reason))
+	})
+
+	for _, name := range []string{"good.csv", "bad.csv", "other.txt"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("a,b\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+	if err := a.Archive(filepath.Join(tempDir, "good.csv"), CategoryProcessed, ""); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if err := a.Archive(filepath.Join(tempDir, "bad.csv"), CategoryFailed, "broken"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+	if err := a.ArchiveIgnored(filepath.Join(tempDir, "other.txt"), "suffix_mismatch", "suffix filter: .csv"); err != nil {
+		t.Fatalf("ArchiveIgnored failed: %v", err)
+	}
+
+	want := "good.csv processed: |bad.csv failed: broken|other.txt ignored: suffix_mismatch"
+	if got := strings.Join(archived, "|"); got != want {
+		t.Errorf("Expected callbacks %q, got %q", want, got)
+	}
+}
+
+func TestArchiveIgnored_ReasonLog(t *testing.T) {
+	tempDir := t.TempDir()
+	ignoredDir := filepath.Join(tempDir, "ignored")
+
+	testFile := filepath.Join(tempDir, "report.txt")
+	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	a := New(filepath.Join(tempDir, "processed"), ignoredDir, filepath.Join(tempDir, "failed"), false)
+	if err := a.ArchiveIgnored(testFile, "suffix_mismatch", "suffix filter: .csv"); err != nil {
+		t.Fatalf("ArchiveIgnored failed: %v", err)
+	}
+
+	archivedFile := filepath.Join(ignoredDir, "report.txt")
+	if _, err := os.Stat(archivedFile); os.IsNotExist(err) {
+		t.Error("Archived file not found in ignored folder")
+	}
+
+	content, err := os.ReadFile(archivedFile + ".reason")
+	if err != nil {
+		t.Fatalf("Reason log not found: %v", err)
+	}
+
+	contentStr := string(content)
+	if !strings.Contains(contentStr, "Reason: suffix_mismatch") {
+		t.Error("Reason log missing reason code")
+	}
+	if !strings.Contains(contentStr, "Detail: suffix filter: .csv") {
+		t.Error("Reason log missing detail")
+	}
+	if _, err := os.Stat(archivedFile + ".error"); !os.IsNotExist(err) {
+		t.Error("Expected no .error log for ignored file")
+	}
+}
+
 func TestArchive_Categories(t *testing.T) {
 	tempDir := t.TempDir()
 	inputDir := filepath.Join(tempDir, "input")
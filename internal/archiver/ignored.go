@@ -0,0 +1,174 @@
+package archiver
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sidecar suffixes written next to archived files
+const (
+	errorSuffix  = ".error"
+	reasonSuffix = ".reason"
+	statsSuffix  = ".stats.json"
+)
+
+// archiveTimestampSuffix matches the "_20060102_150405" (optionally "_N") suffix added when archiving
+var archiveTimestampSuffix = regexp.MustCompile(`_\d{8}_\d{6}(_\d+)?$`)
+
+// dateFolderName matches the business date subfolders of archive folders (see SetDateFolder)
+var dateFolderName = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// ArchivedFile describes a file sitting in an archive folder
+type ArchivedFile struct {
+	Path         string    // Archived file path
+	BusinessDate string    // Business date subfolder the file is archived under ("" if none)
+	OriginalName string    // Filename before archiving
+	Reason       string    // Reason code of an ignored file or error of a failed file, from its sidecar ("" if none)
+	Category     string    // Error category of a failed file, from its sidecar ("" if uncategorized)
+	ArchivedAt   time.Time // When the file was archived, from its sidecar or archived name (else its modification time)
+}
+
+// IgnoredFiles lists the files in the ignored archive, sorted by archived path
+func (a *Archiver) IgnoredFiles() ([]ArchivedFile, error) {
+	return a.ArchivedFiles(CategoryIgnored)
+}
+
+// ArchivedFiles lists the files in the archive folder for category, including its
+// business date subfolders, sorted by archived path
+func (a *Archiver) ArchivedFiles(category Category) ([]ArchivedFile, error) {
+	files, err := a.listFolder(a.archivePaths[category], "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s archive: %w", category, err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// listFolder lists the archived files in dir and, at the top level, in its business
+// date subfolders
+func (a *Archiver) listFolder(dir, date string) ([]ArchivedFile, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ArchivedFile
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			if date == "" && dateFolderName.MatchString(name) {
+				dated, err := a.listFolder(filepath.Join(dir, name), name)
+				if err != nil {
+					return nil, err
+				}
+				files = append(files, dated...)
+			}
+			continue
+		}
+		if isSidecar(name) {
+			continue
+		}
+
+		file := ArchivedFile{Path: filepath.Join(dir, name), BusinessDate: date, OriginalName: a.originalName(name)}
+		var fields map[string]string
+		if fields, err = readSidecar(file.Path + reasonSuffix); err == nil {
+			file.Reason = fields["Reason"]
+			if fields["Original"] != "" {
+				file.OriginalName = fields["Original"]
+			}
+		} else if fields, err = readSidecar(file.Path + errorSuffix); err == nil {
+			file.Reason = fields["Error"]
+			file.Category = fields["Category"]
+		}
+		file.ArchivedAt = a.archivedAt(entry, fields["Timestamp"])
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// Requeue moves an archived file back into inputDir under its original name and
+// removes its sidecars. It refuses to overwrite a file already waiting in inputDir.
+func (a *Archiver) Requeue(file ArchivedFile, inputDir string) (string, error) {
+	target := filepath.Join(inputDir, file.OriginalName)
+	if _, err := os.Stat(target); err == nil {
+		return "", fmt.Errorf("input file already exists: %s", target)
+	}
+
+	if err := os.Rename(file.Path, target); err != nil {
+		// Fallback to copy + delete for cross-device links
+		if err := copyFile(file.Path, target); err != nil {
+			return "", fmt.Errorf("failed to copy file to input folder: %w", err)
+		}
+		if err := os.Remove(file.Path); err != nil {
+			return "", fmt.Errorf("failed to remove archived file after copy: %w", err)
+		}
+	}
+
+	for _, suffix := range []string{reasonSuffix, errorSuffix, statsSuffix} {
+		if err := os.Remove(file.Path + suffix); err != nil && !os.IsNotExist(err) {
+			fmt.Printf("Warning: failed to remove %s sidecar: %v\n", suffix, err)
+		}
+	}
+	return target, nil
+}
+
+// originalName strips the archive timestamp from an archived filename. Used for
+// files archived without a .reason sidecar recording the original name.
+func (a *Archiver) originalName(archived string) string {
+	if !a.addTimestamp {
+		return archived
+	}
+	ext := filepath.Ext(archived)
+	base := archived[:len(archived)-len(ext)]
+	return archiveTimestampSuffix.ReplaceAllString(base, "") + ext
+}
+
+// archivedAt returns when a file was archived: the sidecar timestamp, else the
+// timestamp in its archived name, else its modification time
+func (a *Archiver) archivedAt(entry os.DirEntry, sidecarTimestamp string) time.Time {
+	if t, err := time.Parse(time.RFC3339, sidecarTimestamp); err == nil {
+		return t
+	}
+	ext := filepath.Ext(entry.Name())
+	if match := archiveTimestampSuffix.FindString(strings.TrimSuffix(entry.Name(), ext)); match != "" {
+		if t, err := time.ParseInLocation("20060102_150405", match[1:16], a.Now().Location()); err == nil {
+			return t
+		}
+	}
+	if info, err := entry.Info(); err == nil {
+		return info.ModTime()
+	}
+	return time.Time{}
+}
+
+// isSidecar reports whether name is a sidecar written next to an archived file
+func isSidecar(name string) bool {
+	return strings.HasSuffix(name, reasonSuffix) || strings.HasSuffix(name, errorSuffix) || strings.HasSuffix(name, statsSuffix)
+}
+
+// readSidecar parses a "Key: value" sidecar file
+func readSidecar(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if key, value, ok := strings.Cut(scanner.Text(), ": "); ok {
+			fields[key] = value
+		}
+	}
+	return fields, scanner.Err()
+}
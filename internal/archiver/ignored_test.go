@@ -0,0 +1,122 @@
+package archiver
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIgnoredFiles_OriginalNameAndReason(t *testing.T) {
+	tempDir := t.TempDir()
+	inputDir := filepath.Join(tempDir, "input")
+	ignoredDir := filepath.Join(tempDir, "ignored")
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input dir: %v", err)
+	}
+
+	a := New(filepath.Join(tempDir, "processed"), ignoredDir, filepath.Join(tempDir, "failed"), true)
+
+	testFile := filepath.Join(inputDir, "orders_2024.CSV")
+	if err := os.WriteFile(testFile, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := a.ArchiveIgnored(testFile, "suffix_mismatch", "suffix filter: .csv"); err != nil {
+		t.Fatalf("ArchiveIgnored failed: %v", err)
+	}
+
+	// A file archived before reason sidecars existed
+	legacy := filepath.Join(ignoredDir, "legacy_20240101_120000_2.txt")
+	if err := os.WriteFile(legacy, []byte("id\n2\n"), 0644); err != nil {
+		t.Fatalf("Failed to create legacy file: %v", err)
+	}
+
+	files, err := a.IgnoredFiles()
+	if err != nil {
+		t.Fatalf("IgnoredFiles failed: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 ignored files (sidecars excluded), got %d: %+v", len(files), files)
+	}
+
+	byName := map[string]ArchivedFile{}
+	for _, f := range files {
+		byName[f.OriginalName] = f
+	}
+	if f, ok := byName["orders_2024.CSV"]; !ok || f.Reason != "suffix_mismatch" {
+		t.Errorf("Expected orders_2024.CSV with reason suffix_mismatch, got %+v", files)
+	}
+	if f, ok := byName["legacy.txt"]; !ok || f.Reason != "" {
+		t.Errorf("Expected legacy.txt with no reason, got %+v", files)
+	}
+	if want := time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local); !byName["legacy.txt"].ArchivedAt.Equal(want) {
+		t.Errorf("Expected legacy.txt archived at %v from its name, got %v", want, byName["legacy.txt"].ArchivedAt)
+	}
+	if time.Since(byName["orders_2024.CSV"].ArchivedAt) > time.Minute {
+		t.Errorf("Expected orders_2024.CSV archived now from its sidecar, got %v", byName["orders_2024.CSV"].ArchivedAt)
+	}
+
+	// Requeue restores the original name and removes the sidecar
+	f := byName["orders_2024.CSV"]
+	target, err := a.Requeue(f, inputDir)
+	if err != nil {
+		t.Fatalf("Requeue failed: %v", err)
+	}
+	if target != testFile {
+		t.Errorf("Expected requeue to %s, got %s", testFile, target)
+	}
+	if _, err := os.Stat(f.Path + ".reason"); !os.IsNotExist(err) {
+		t.Error("Expected .reason sidecar to be removed")
+	}
+
+	// Requeue never overwrites a file waiting in the input folder
+	if err := os.WriteFile(filepath.Join(inputDir, "legacy.txt"), []byte("new"), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+	if _, err := a.Requeue(byName["legacy.txt"], inputDir); err == nil {
+		t.Error("Expected error requeueing over an existing input file")
+	}
+}
+
+func TestIgnoredFiles_MissingDir(t *testing.T) {
+	a := New("", filepath.Join(t.TempDir(), "missing"), "", false)
+	files, err := a.IgnoredFiles()
+	if err != nil || len(files) != 0 {
+		t.Errorf("Expected no files and no error for missing archive, got %v, %v", files, err)
+	}
+}
+
+func TestArchivedFiles_FailedError(t *testing.T) {
+	tempDir := t.TempDir()
+	a := New(filepath.Join(tempDir, "processed"), filepath.Join(tempDir, "ignored"), filepath.Join(tempDir, "failed"), true)
+
+	testFile := filepath.Join(tempDir, "orders.csv")
+	if err := os.WriteFile(testFile, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	a.AttachStats("orders.csv", []byte(`{"file":"orders.csv"}`))
+	if err := a.Archive(testFile, CategoryFailed, "missing column id"); err != nil {
+		t.Fatalf("Archive failed: %v", err)
+	}
+
+	files, err := a.ArchivedFiles(CategoryFailed)
+	if err != nil {
+		t.Fatalf("ArchivedFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0].OriginalName != "orders.csv" || files[0].Reason != "missing column id" {
+		t.Fatalf("Expected orders.csv with its error, got %+v", files)
+	}
+
+	inputDir := filepath.Join(tempDir, "input")
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input dir: %v", err)
+	}
+	if _, err := a.Requeue(files[0], inputDir); err != nil {
+		t.Fatalf("Requeue failed: %v", err)
+	}
+	for _, suffix := range []string{".error", ".stats.json"} {
+		if _, err := os.Stat(files[0].Path + suffix); !os.IsNotExist(err) {
+			t.Errorf("Expected %s sidecar to be removed", suffix)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Package businessdate derives the business date a file belongs to (the day its data
+// describes, as opposed to the day it arrived) from the filename, a column of the
+// file, or its modification time. Downstream loading is organized by business date,
+// so it is carried in envelopes, output paths and archive folders.
+package businessdate
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Business date sources
+const (
+	FromFilename = "filename" // A regular expression capture group of the filename
+	FromColumn   = "column"   // The value of a column in the first data row
+	FromMtime    = "mtime"    // The file's modification date
+)
+
+// Layout is the form business dates are carried in
+const Layout = "2006-01-02"
+
+// Placeholder is replaced with the business date in output folders and message templates
+const Placeholder = "{businessDate}"
+
+// defaultPattern matches the first YYYYMMDD or YYYY-MM-DD date in a filename
+const defaultPattern = `(\d{4}-\d{2}-\d{2}|\d{8})`
+
+// defaultLayouts are tried in turn when no format is given
+var defaultLayouts = []string{Layout, "20060102"}
+
+// Spec configures how a business date is derived
+type Spec struct {
+	From    string // filename, column, or mtime
+	Pattern string // Filename regular expression; its first capture group (else the match) is the date (default: first YYYYMMDD or YYYY-MM-DD)
+	Column  string // Column holding the date (column source)
+	Format  string // Go layout of the extracted date, e.g. 02.01.2006 (default: YYYY-MM-DD or YYYYMMDD)
+}
+
+// Extractor derives business dates as configured by a Spec
+type Extractor struct {
+	spec    Spec
+	pattern *regexp.Regexp
+}
+
+// New validates spec and returns its extractor
+func New(spec Spec) (*Extractor, error) {
+	e := &Extractor{spec: spec}
+	switch spec.From {
+	case FromFilename:
+		pattern := spec.Pattern
+		if pattern == "" {
+			pattern = defaultPattern
+		}
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+		e.pattern = compiled
+	case FromColumn:
+		if spec.Column == "" {
+			return nil, fmt.Errorf("the column source requires a column")
+		}
+	case FromMtime:
+	default:
+		return nil, fmt.Errorf("unsupported business date source: %s (supported: filename, column, mtime)", spec.From)
+	}
+	if spec.Pattern != "" && spec.From != FromFilename {
+		return nil, fmt.Errorf("a pattern requires the filename source")
+	}
+	if spec.Column != "" && spec.From != FromColumn {
+		return nil, fmt.Errorf("a column requires the column source")
+	}
+	return e, nil
+}
+
+// From returns the configured source
+func (e *Extractor) From() string {
+	return e.spec.From
+}
+
+// Extract returns the business date (YYYY-MM-DD) of a file from its name, its first
+// data row, or its modification time in loc (nil = local)
+func (e *Extractor) Extract(filename string, firstRow map[string]string, modTime time.Time, loc *time.Location) (string, error) {
+	switch e.spec.From {
+	case FromFilename:
+		match := e.pattern.FindStringSubmatch(filename)
+		if match == nil {
+			return "", fmt.Errorf("filename %s does not match business date pattern %s", filename, e.pattern)
+		}
+		value := match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+		return e.parse(value, "filename "+filename)
+	case FromColumn:
+		value, ok := firstRow[e.spec.Column]
+		if !ok {
+			return "", fmt.Errorf("business date column %s not found", e.spec.Column)
+		}
+		return e.parse(value, "column "+e.spec.Column)
+	default:
+		if loc == nil {
+			loc = time.Local
+		}
+		return modTime.In(loc).Format(Layout), nil
+	}
+}
+
+// parse reads value with the configured format (else the default layouts) and
+// returns it in Layout
+func (e *Extractor) parse(value, source string) (string, error) {
+	layouts := defaultLayouts
+	if e.spec.Format != "" {
+		layouts = []string{e.spec.Format}
+	}
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date.Format(Layout), nil
+		}
+	}
+	return "", fmt.Errorf("%s: %q is not a valid business date", source, value)
+}
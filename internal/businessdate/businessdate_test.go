@@ -0,0 +1,60 @@
+package businessdate
+
+import (
+	"testing"
+	"time"
+)
+
+// TestExtract validates each source and the date formats accepted
+func TestExtract(t *testing.T) {
+	mtime := time.Date(2026, 3, 31, 23, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		spec     Spec
+		filename string
+		row      map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{"filename default compact", Spec{From: FromFilename}, "sales_20260115.csv", nil, "2026-01-15", false},
+		{"filename default dashed", Spec{From: FromFilename}, "sales_2026-01-15_v2.csv", nil, "2026-01-15", false},
+		{"filename capture group", Spec{From: FromFilename, Pattern: `_(\d{6})_`, Format: "020106"}, "gl_150126_1.csv", nil, "2026-01-15", false},
+		{"filename without date", Spec{From: FromFilename}, "sales.csv", nil, "", true},
+		{"filename invalid date", Spec{From: FromFilename}, "sales_20261345.csv", nil, "", true},
+		{"column", Spec{From: FromColumn, Column: "as_of"}, "sales.csv", map[string]string{"as_of": "2026-02-01"}, "2026-02-01", false},
+		{"column custom format", Spec{From: FromColumn, Column: "as_of", Format: "02.01.2006"}, "sales.csv", map[string]string{"as_of": "01.02.2026"}, "2026-02-01", false},
+		{"column missing", Spec{From: FromColumn, Column: "as_of"}, "sales.csv", map[string]string{"id": "1"}, "", true},
+		{"mtime", Spec{From: FromMtime}, "sales.csv", nil, "2026-04-01", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := New(tt.spec)
+			if err != nil {
+				t.Fatalf("New failed: %v", err)
+			}
+			// mtime dates follow the given zone: 23:30 UTC is the next day at UTC+2
+			got, err := e.Extract(tt.filename, tt.row, mtime, time.FixedZone("UTC+2", 2*60*60))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Extract error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Extract = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewValidation validates unusable specs are rejected
+func TestNewValidation(t *testing.T) {
+	for name, spec := range map[string]Spec{
+		"unknown source":                  {From: "header"},
+		"invalid pattern":                 {From: FromFilename, Pattern: "("},
+		"column without column":           {From: FromColumn},
+		"pattern without filename source": {From: FromMtime, Pattern: `(\d{8})`},
+		"column without column source":    {From: FromFilename, Column: "as_of"},
+	} {
+		if _, err := New(spec); err == nil {
+			t.Errorf("%s: expected error, got success", name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Alert events operators can subscribe to with ALERT_EVENTS
+const (
+	AlertEventFileFailed   = "file_failed"   // A file was archived as failed
+	AlertEventRouteStartup = "route_startup" // A route could not be started
+	AlertEventBrokerOutage = "broker_outage" // Publishing to the message broker failed
+	AlertEventSLAMissed    = "sla_missed"    // No file arrived before a route's expected arrival deadline
+)
+
+// AlertsConfig selects the channels failure alerts are sent to. Alerting is
+// disabled when no channel is configured.
+type AlertsConfig struct {
+	SlackWebhookURL string // Slack incoming webhook
+	WebhookURL      string // Generic webhook receiving the alert as JSON
+
+	// Email over SMTP (STARTTLS when the server offers it)
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	EmailFrom    string
+	EmailTo      []string
+
+	Events    []string      // Events that trigger alerts
+	RateLimit time.Duration // Minimum time between alerts for the same event and route (0 = unlimited)
+	Timeout   time.Duration // Timeout for each delivery
+}
+
+// Enabled reports whether any alert channel is configured
+func (c *AlertsConfig) Enabled() bool {
+	return c.SlackWebhookURL != "" || c.WebhookURL != "" || c.SMTPHost != ""
+}
+
+// LoadAlerts loads the alerting settings from environment variables
+func LoadAlerts() (*AlertsConfig, error) {
+	// Load the config file (CONFIG_FILE, default .env) below the environment
+	if err := loadConfigFile(); err != nil {
+		return nil, err
+	}
+
+	cfg := &AlertsConfig{
+		SMTPHost:  getEnv("ALERT_SMTP_HOST", ""),
+		SMTPPort:  getIntEnv("ALERT_SMTP_PORT", 587),
+		EmailFrom: getEnv("ALERT_EMAIL_FROM", ""),
+		EmailTo:   splitList(getEnv("ALERT_EMAIL_TO", "")),
+		Events:    splitList(getEnv("ALERT_EVENTS", strings.Join([]string{AlertEventFileFailed, AlertEventRouteStartup, AlertEventBrokerOutage, AlertEventSLAMissed}, ","))),
+		RateLimit: getDurationEnv("ALERT_RATE_LIMIT_SECONDS", 300) * time.Second,
+		Timeout:   getDurationEnv("ALERT_TIMEOUT_SECONDS", 10) * time.Second,
+	}
+
+	// Webhook URLs embed credentials, so they may be mounted as secret files
+	var err error
+	for key, dest := range map[string]*string{
+		"ALERT_SLACK_WEBHOOK_URL": &cfg.SlackWebhookURL,
+		"ALERT_WEBHOOK_URL":       &cfg.WebhookURL,
+		"ALERT_SMTP_USERNAME":     &cfg.SMTPUsername,
+		"ALERT_SMTP_PASSWORD":     &cfg.SMTPPassword,
+	} {
+		if *dest, err = getSecretEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate checks the event list, limits and email settings
+func (c *AlertsConfig) validate() error {
+	for _, event := range c.Events {
+		switch event {
+		case AlertEventFileFailed, AlertEventRouteStartup, AlertEventBrokerOutage, AlertEventSLAMissed:
+		default:
+			return fmt.Errorf("invalid ALERT_EVENTS entry: %s (must be %s, %s, %s, or %s)", event, AlertEventFileFailed, AlertEventRouteStartup, AlertEventBrokerOutage, AlertEventSLAMissed)
+		}
+	}
+	if c.RateLimit < 0 || c.Timeout <= 0 {
+		return fmt.Errorf("ALERT_RATE_LIMIT_SECONDS must be >= 0 and ALERT_TIMEOUT_SECONDS must be > 0")
+	}
+	if c.SMTPHost != "" {
+		if c.EmailFrom == "" || len(c.EmailTo) == 0 {
+			return fmt.Errorf("ALERT_EMAIL_FROM and ALERT_EMAIL_TO are required when ALERT_SMTP_HOST is set")
+		}
+		if c.SMTPPort <= 0 || c.SMTPPort > 65535 {
+			return fmt.Errorf("ALERT_SMTP_PORT must be between 1 and 65535, got: %d", c.SMTPPort)
+		}
+	}
+	return nil
+}
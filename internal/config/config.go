@@ -1,7 +1,9 @@
 package config
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -9,105 +11,561 @@ import (
 	"strings"
 	"time"
 
-	"github.com/joho/godotenv"
+	"csv2json/internal/businessdate"
+	"csv2json/internal/converter"
+	"csv2json/internal/parser"
+	"csv2json/internal/quality"
+	"csv2json/internal/sla"
+)
+
+// Empty file policies for files that are empty or contain only a header row
+const (
+	EmptyFilePolicyFail           = "fail"           // Archive as failed (default)
+	EmptyFilePolicyEmitEmptyArray = "emitEmptyArray" // Emit an empty JSON array and archive as processed
+	EmptyFilePolicyIgnore         = "ignore"         // Archive as processed without emitting output
+)
+
+// Schema drift policies for files whose columns differ from the route's established schema
+const (
+	SchemaDriftPolicyOff  = "off"  // Do not track columns (default)
+	SchemaDriftPolicyWarn = "warn" // Log and count the drift, then process the file
+	SchemaDriftPolicyFail = "fail" // Archive the file as failed
+)
+
+// Data quality policies for files that do not meet their expectations
+const (
+	QualityPolicyFail = "fail" // Archive the file as failed (default)
+	QualityPolicyWarn = "warn" // Log and count the unmet expectations, then process the file
+)
+
+// Output schema policies for columns a file has beyond the declared output schema
+const (
+	ExtraColumnsDrop = "drop" // Drop them silently (default)
+	ExtraColumnsFlag = "flag" // Drop them and log a warning naming them
+)
+
+// MinPollInterval is the shortest poll interval accepted, to keep a misconfigured
+// poll loop from spinning
+const MinPollInterval = 10 * time.Millisecond
+
+// Message signing algorithms
+const (
+	SigningHMACSHA256 = "hmac-sha256" // Shared secret (default)
+	SigningEd25519    = "ed25519"     // Private key; consumers verify with the public key
+)
+
+// Queue message payload formats
+const (
+	PayloadFormatEnvelope = "envelope" // ADR-006 envelope with provenance metadata (default)
+	PayloadFormatLegacy   = "legacy"   // {"identifier": ..., "data": [...]}
+	PayloadFormatBare     = "bare"     // The converted JSON array alone
+)
+
+// Queue message content types
+const (
+	ContentTypeJSON   = "application/json"     // JSON document (default)
+	ContentTypeNDJSON = "application/x-ndjson" // One JSON record per line
+	ContentTypeAvro   = "application/avro"     // Avro object container file
+)
+
+// Sources a tenant name is derived from when none is set explicitly
+const (
+	TenantFromRoute  = "route"  // The route name (routes mode only)
+	TenantFromFolder = "folder" // The base name of the input folder
+)
+
+// tenantPattern restricts tenant names to characters safe in queue names and paths
+var tenantPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// Strategies for distributing messages across sharded queues
+const (
+	ShardStrategyRoundRobin = "roundRobin" // Each message goes to the next queue in turn (default)
+	ShardStrategyHash       = "hash"       // Messages go to the queue chosen by hashing a column value
+)
+
+// Policies for files that do not match the input filters
+const (
+	UnmatchedFileArchive = "archive" // Moved to the ignored archive with a reason sidecar (default)
+	UnmatchedFileSkip    = "skip"    // Left untouched in the input folder, e.g. for other consumers of a shared folder
+)
+
+// Policies for files whose output was delivered but which could not be archived as processed
+const (
+	ArchiveFailureReprocess  = "reprocess"  // Leave the file in the input folder; it is processed and sent again (default)
+	ArchiveFailureRetry      = "retry"      // Retry archiving with a doubling delay before leaving the file
+	ArchiveFailureQuarantine = "quarantine" // Move the file to the quarantine folder, out of the monitor's reach
+	ArchiveFailureMark       = "mark"       // Record the file as published, so the next scan archives it without sending it again
+)
+
+// Policies for files arriving for a business date the route has already completed, i.e.
+// processed a file for that date or a later one
+const (
+	LateBusinessDateAccept      = "accept"      // Process the file like any other (default)
+	LateBusinessDateCorrections = "corrections" // Publish the file to CORRECTIONS_QUEUE instead of the output queue
+	LateBusinessDateHold        = "hold"        // Move the file to the held folder until it is released with csv2json release
+)
+
+// Policies for checksum sidecars (file.csv.md5, file.csv.sha256) delivered with data files
+const (
+	ChecksumPolicyOff     = "off"     // Sidecars are treated as ordinary files (default)
+	ChecksumPolicyVerify  = "verify"  // Files are verified against a sidecar when one is present
+	ChecksumPolicyRequire = "require" // Files wait in the input folder until their sidecar arrives
+)
+
+// Orders the files already in an input folder at startup are processed in (PROCESS_EXISTING_ORDER)
+const (
+	ProcessExistingOldest = "oldest" // Oldest modification time first (default)
+	ProcessExistingNewest = "newest" // Newest modification time first
+	ProcessExistingName   = "name"   // Filename order
+)
+
+// Reasons a file is archived as ignored, recorded in the ignored archive sidecar
+const (
+	IgnoreReasonSuffixMismatch  = "suffix_mismatch"  // Filename does not end with any FILE_SUFFIX_FILTER suffix
+	IgnoreReasonPatternMismatch = "pattern_mismatch" // Filename does not match FILENAME_PATTERN
+	IgnoreReasonExcluded        = "excluded"         // Filename matches FILENAME_EXCLUDE_PATTERN
+	IgnoreReasonDuplicate       = "duplicate"        // Identical content was already processed under the same name, or under any name with EXACTLY_ONCE
 )
 
 type Config struct {
 	// Routing settings
-	RoutesConfigPath string // Path to routes.json (if using multi-ingress mode)
+	RoutesConfigPath string                  // Path to routes.json (if using multi-ingress mode)
+	Tenant           string                  // Tenant destinations are scoped to ("" = none), applied by applyTenant
+	Timezone         string                  // IANA time zone of envelope and archive timestamps ("" = UTC envelopes, server-local archives)
+	BusinessDate     *businessdate.Extractor // Derives the business date of each file (nil = none)
+	LateDatePolicy   string                  // "accept", "corrections" or "hold" for files of an already completed business date
+	CorrectionsQueue string                  // Queue restated business dates are published to (corrections policy)
 
 	// Input settings
-	InputFolder        string
-	PollInterval       time.Duration
-	MaxFilesPerPoll    int
-	FileSuffixFilter   []string
-	FilenamePattern    *regexp.Regexp
-	WatchMode          string // "event", "poll", or "hybrid"
-	HybridPollInterval time.Duration
+	InputFolder          string
+	PollInterval         time.Duration // Accepts Go durations ("500ms", "2m") or whole seconds
+	MaxFilesPerPoll      int
+	FileSuffixFilter     []string
+	FilenamePattern      *regexp.Regexp
+	FilenameExclude      *regexp.Regexp // Files matching this pattern are ignored (nil = none)
+	FilenameIgnoreCase   bool           // Suffixes and filename patterns match regardless of case (DATA.CSV matches .csv)
+	UnmatchedPolicy      string         // "archive" or "skip" files that do not match the filters
+	ChecksumPolicy       string         // "off", "verify" or "require" checksum sidecars
+	SkipDuplicateFiles   bool           // Ignore files whose name and content match an already processed file
+	ExactlyOnce          bool           // Publish a file only if its content hash has not been recorded as delivered
+	ExactlyOnceRetention time.Duration  // Delivered content hashes are forgotten after this long (0 = kept forever)
+	BatchManifests       bool           // Process files only as members of *.manifest transactional batches
+	ClaimFiles           bool           // Claim files before processing so instances can share an input folder
+	InstanceID           string         // Identifies this instance in claim folders (default: hostname)
+	ClaimTTL             time.Duration  // Claims not refreshed within this time are released to other instances
+	InstanceLock         bool           // Lock the input folder so a second instance on this host refuses to watch it
+	WorkDir              string         // Files are moved here while processed, with temp files in tmp/ ("" = process in place)
+	MinFreeDiskMB        int            // Pause intake while output/archive filesystems have less free space (0 = disabled)
+	DiskCheckInterval    time.Duration  // How often free space is rechecked while intake is paused
+	MemoryLimitMB        int            // Files whose parsed payload would exceed this are converted through a spill file (0 = disabled)
+	BytesPerInterval     int64          // Intake is throttled to this many bytes of input files per ThrottleInterval (0 = unlimited)
+	ThrottleInterval     time.Duration  // Interval BytesPerInterval applies to
+	ExpectedArrival      *sla.Schedule  // A file must arrive before each deadline of this schedule (nil = not monitored)
+	WatchMode            string         // "event", "poll", or "hybrid"
+	HybridPollInterval   time.Duration
+	StabilityWindow      time.Duration // A detected file's size must hold steady this long before processing (0 = disabled)
+	ProcessExisting      bool          // Process the files already in the input folder at startup before new arrivals
+	ProcessExistingOrder string        // "oldest", "newest" or "name": order of the startup backlog
 
 	// Parsing settings
-	Delimiter rune
-	QuoteChar rune
-	Encoding  string
-	HasHeader bool
+	InputFormat       string                    // "delimited", "tsv", "whitespace", "fixed-width", or "multi-record"
+	FixedWidthColumns []parser.FixedWidthColumn // Field layout for the fixed-width format
+	RecordLayouts     []parser.RecordLayout     // Record type layouts for the multi-record format
+	Delimiter         rune
+	QuoteChar         rune
+	Encoding          string
+	HasHeader         bool
+	InvalidUTF8Policy string // "fail", "replace", or "strip"
+	EmptyFilePolicy   string // "fail", "emitEmptyArray", or "ignore"
+	SchemaDriftPolicy string // "off", "warn", or "fail"
+	ParseWorkers      int    // Parse large files with this many goroutines (1 = sequential)
+	ParallelParseMin  int64  // Smallest file in bytes parsed in parallel
+	RawLineField      string // Field holding each record's raw source line ("" = not included)
+	ReassembleLines   bool   // Join delimited records split by unquoted line breaks until they have the header's column count
+	RaggedRowPolicy   string // "fail", "skip", or "pad" data rows whose column count differs from the header's
+	ColumnSampleRows  int    // Headerless files take the most common column count of this many leading records (0 = the first record's)
+
+	// Data quality settings
+	Quality       *quality.Suite // Expectations evaluated against each file's parsed rows (nil = none)
+	QualityPolicy string         // "fail" or "warn" when an expectation is not met
+
+	// Transform settings
+	SampleRows       int            // Emit at most this many rows per file (0 = disabled)
+	SampleMode       string         // "head" or "random"
+	DedupKeys        []string       // Key columns for within-file row deduplication
+	DedupKeep        string         // "first" or "last" occurrence retained
+	Lookups          []LookupConfig // Reference CSVs joined into each record
+	OutputSchema     []SchemaField  // Fields every record is emitted with, in order (nil = as parsed)
+	ExtraColumns     string         // "drop" or "flag" columns not in OutputSchema
+	StrictColumns    bool           // Fail files containing columns not in OutputSchema
+	SanitizeFormulas bool           // Neutralize values that spreadsheets would evaluate as formulas
+	SanitizePrefix   string         // Prefix prepended to neutralized values
+	MaskRules        []MaskRule
+	HashColumns      []string // Columns replaced with HMAC-SHA256 hashes keyed by HashSalt
+	HashSalt         string   // Salt for column hashing (from HASH_SALT or HASH_SALT_FILE)
+	SortBy           []SortKey
+	SortMemoryRows   int      // Rows in each sorted run an external sort spills to disk
+	GroupBy          []string // Key columns for nesting child rows under a parent object
+	GroupParentCols  []string // Columns kept on the parent object (defaults to GroupBy)
+	GroupChildKey    string   // Field name of the nested child array
 
 	// Output settings
-	OutputType   string // "file" or "queue"
-	OutputFolder string
+	OutputType        string // "file", "queue", "both", "stdout" or "pipe"
+	OutputFolder      string
+	OutputPipe        string             // Named pipe written by pipe output
+	ReverseConversion bool               // Convert JSON array input back to CSV files (reverse routes)
+	ASCIISafeOutput   bool               // Escape non-ASCII characters in output JSON as \uXXXX
+	OutputShape       string             // "array" (default) or "object" for files of exactly one data row
+	FileWrapper       *FileWrapperConfig // Top-level object output files are wrapped in (nil = bare JSON)
+	TypeInference     bool               // Render numbers, booleans and nulls as native JSON values instead of strings (ADR-003)
+	ColumnTypes       []ColumnType       // Types of these columns' values, overriding inference
+	PartitionBy       string             // Column whose values split each file into separate outputs
+	BatchWindow       time.Duration      // Merge files arriving within this window into one output (0 = disabled)
+	BatchMaxFiles     int                // Flush a batch once it holds this many files (0 = no limit)
 
 	// Queue settings
 	QueueType     string
 	QueueHost     string
 	QueuePort     int
+	QueueVHost    string // RabbitMQ virtual host ("" = the broker's default vhost "/")
 	QueueName     string
 	QueueUsername string
 	QueuePassword string
+	QueueShards   []string // Queues messages are distributed across ("" = QueueName only)
+	ShardStrategy string   // "roundRobin" or "hash"
+	ShardColumn   string   // Column hashed by the hash strategy
+
+	// Message headers
+	MessageContentType string // "application/json", "application/x-ndjson" or "application/avro"
+	MessageSchema      string // Schema or contract identifier sent in the x-schema header ("" = none)
+
+	// Broker connection
+	QueueLazyConnect       bool          // Connect on the first publish instead of at startup
+	QueueSharedConnection  bool          // Share the broker connection with other routes (multi-ingress sharedConnection)
+	QueueHeartbeat         time.Duration // AMQP heartbeat interval keeping idle connections alive (0 = the broker's)
+	QueueConnectRetries    int           // Reconnect attempts before a publish fails
+	QueueConnectRetryDelay time.Duration // Delay before the first reconnect attempt, doubled after each
+
+	// Payload encryption (queue messages only)
+	PayloadEncryptionKey   string // Base64 AES-GCM key (from PAYLOAD_ENCRYPTION_KEY or _FILE, "" = plaintext)
+	PayloadEncryptionKeyID string // Key identifier sent with every encrypted message
+
+	// Message signing (queue messages only)
+	SigningAlgorithm string // "hmac-sha256" or "ed25519"
+	SigningKey       string // Base64 key (from MESSAGE_SIGNING_KEY or _FILE, "" = unsigned)
+	SigningKeyID     string // Key identifier sent with every signed message
+
+	// Message key settings (templates, see README "Message Templates")
+	KafkaMessageKey  string
+	KafkaPartition   string
+	LegacyIdentifier string // Identifier of legacy format messages ("" = the source filename)
+
+	// RabbitMQ exchange settings
+	RabbitMQExchange     string
+	RabbitMQExchangeType string // "topic", "direct", "fanout", or "headers"
+	RabbitMQRoutingKey   string // Routing key template
+	RabbitMQBindingKey   string
+
+	// Delivery receipts
+	ReceiptLog            string        // NDJSON file recording one receipt per message/file delivered
+	ReportDestination     string        // Processing report queue (rabbitmq://name) or folder ("" = disabled)
+	PublisherConfirms     bool          // Wait for broker confirmation of each published message
+	PublishConfirmTimeout time.Duration // How long to wait for a publisher confirm
+	DownstreamAck         bool          // Wait for a downstream consumer to reply before archiving files as processed
+	DownstreamAckQueue    string        // Queue downstream replies are sent to ("" = exclusive server-named queue)
+	DownstreamAckTimeout  time.Duration // How long to wait for a downstream reply
 
 	// Archive settings
 	ArchiveProcessed string
 	ArchiveIgnored   string
 	ArchiveFailed    string
 	ArchiveTimestamp bool
+	ArchiveByDate    bool // Archive files under a YYYY-MM-DD folder named after their business date
+	ColumnStats      bool // Write per-column statistics of each converted file to a .stats.json sidecar next to its archived copy
+
+	ArchiveFailurePolicy string        // "reprocess", "retry", "quarantine" or "mark" when a sent file cannot be archived
+	ArchiveRetries       int           // Archive attempts after the first fails (retry policy)
+	ArchiveRetryDelay    time.Duration // Delay before the first archive retry, doubled after each
+	ArchiveQuarantine    string        // Folder quarantined files are moved to ("" = .quarantine in the input folder)
 
 	// Logging settings
 	LogLevel         string
 	LogFile          string
+	MetricsAddr      string // Listen address for the Prometheus /metrics endpoint ("" = disabled)
+	AdminAddr        string // Listen address for the admin API (POST /trigger) ("" = disabled)
 	LogQueueMessages bool
 }
 
 func Load() (*Config, error) {
-	// Load .env file if it exists (ignore error if not present)
-	_ = godotenv.Load()
+	// Load the config file (CONFIG_FILE, default .env) below the environment
+	if err := loadConfigFile(); err != nil {
+		return nil, err
+	}
+
+	// TSV input defaults to the tab delimiter and .tsv/.txt files
+	inputFormat := getEnv("INPUT_FORMAT", parser.FormatDelimited)
+	delimiter, suffixFilter := ",", ""
+	if inputFormat == parser.FormatTSV {
+		delimiter, suffixFilter = "\t", tsvSuffixFilter
+	}
 
 	cfg := &Config{
-		RoutesConfigPath:   getEnv("ROUTES_CONFIG", ""), // Empty = legacy single-input mode
-		InputFolder:        getEnv("INPUT_FOLDER", "./input"),
-		PollInterval:       getDurationEnv("POLL_INTERVAL_SECONDS", 5) * time.Second,
-		HybridPollInterval: getDurationEnv("HYBRID_POLL_INTERVAL_SECONDS", 60) * time.Second,
-		MaxFilesPerPoll:    getIntEnv("MAX_FILES_PER_POLL", 0), // 0 = no limit
-		WatchMode:          getEnv("WATCH_MODE", "event"),
-		Delimiter:          rune(getEnv("DELIMITER", ",")[0]),
-		QuoteChar:          rune(getEnv("QUOTECHAR", "\"")[0]),
-		Encoding:           getEnv("ENCODING", "utf-8"),
-		HasHeader:          getBoolEnv("HAS_HEADER", true),
-		OutputType:         getEnv("OUTPUT_TYPE", "file"),
-		OutputFolder:       getEnv("OUTPUT_FOLDER", "./output"),
-		QueueType:          getEnv("QUEUE_TYPE", "rabbitmq"),
-		QueueHost:          getEnv("QUEUE_HOST", "localhost"),
-		QueuePort:          getIntEnv("QUEUE_PORT", 5672),
-		QueueName:          getEnv("QUEUE_NAME", ""),
-		QueueUsername:      getEnv("QUEUE_USERNAME", ""),
-		QueuePassword:      getEnv("QUEUE_PASSWORD", ""),
-		ArchiveProcessed:   getEnv("ARCHIVE_PROCESSED", "./archive/processed"),
-		ArchiveIgnored:     getEnv("ARCHIVE_IGNORED", "./archive/ignored"),
-		ArchiveFailed:      getEnv("ARCHIVE_FAILED", "./archive/failed"),
-		ArchiveTimestamp:   getBoolEnv("ARCHIVE_TIMESTAMP", true),
-		LogLevel:           getEnv("LOG_LEVEL", "INFO"),
-		LogFile:            getEnv("LOG_FILE", "./logs/csv2json.log"),
-		LogQueueMessages:   getBoolEnv("LOG_QUEUE_MESSAGES", false),
+		RoutesConfigPath:       getEnv("ROUTES_CONFIG", ""), // Empty = legacy single-input mode
+		InputFolder:            getEnv("INPUT_FOLDER", "./input"),
+		PollInterval:           getIntervalEnv("POLL_INTERVAL_SECONDS", 5*time.Second),
+		HybridPollInterval:     getIntervalEnv("HYBRID_POLL_INTERVAL_SECONDS", 60*time.Second),
+		StabilityWindow:        getIntervalEnv("FILE_STABILITY_WINDOW", 2*time.Second),
+		MaxFilesPerPoll:        getIntEnv("MAX_FILES_PER_POLL", 0), // 0 = no limit
+		WatchMode:              getEnv("WATCH_MODE", "event"),
+		SkipDuplicateFiles:     getBoolEnv("SKIP_DUPLICATE_FILES", false),
+		ExactlyOnce:            getBoolEnv("EXACTLY_ONCE", false),
+		ExactlyOnceRetention:   getIntervalEnv("EXACTLY_ONCE_RETENTION", 30*24*time.Hour),
+		BatchManifests:         getBoolEnv("BATCH_MANIFESTS", false),
+		ClaimFiles:             getBoolEnv("CLAIM_FILES", false),
+		InstanceID:             getEnv("INSTANCE_ID", defaultInstanceID()),
+		ClaimTTL:               getDurationEnv("CLAIM_TTL_SECONDS", 300) * time.Second,
+		InstanceLock:           getBoolEnv("INSTANCE_LOCK", false),
+		WorkDir:                getEnv("WORK_DIR", ""),
+		MinFreeDiskMB:          getIntEnv("MIN_FREE_DISK_MB", 0),
+		DiskCheckInterval:      getDurationEnv("DISK_CHECK_INTERVAL_SECONDS", 30) * time.Second,
+		MemoryLimitMB:          getIntEnv("MEMORY_LIMIT_MB", 0),
+		BytesPerInterval:       getByteSizeEnv("BYTES_PER_INTERVAL", 0),
+		ThrottleInterval:       getIntervalEnv("THROTTLE_INTERVAL", time.Minute),
+		Delimiter:              rune(getEnv("DELIMITER", delimiter)[0]),
+		QuoteChar:              rune(getEnv("QUOTECHAR", "\"")[0]),
+		InputFormat:            inputFormat,
+		Encoding:               getEnv("ENCODING", "utf-8"),
+		HasHeader:              getBoolEnv("HAS_HEADER", true),
+		InvalidUTF8Policy:      getEnv("INVALID_UTF8_POLICY", "replace"),
+		EmptyFilePolicy:        getEnv("EMPTY_FILE_POLICY", EmptyFilePolicyFail),
+		SchemaDriftPolicy:      getEnv("SCHEMA_DRIFT_POLICY", SchemaDriftPolicyOff),
+		RawLineField:           getEnv("RAW_LINE_FIELD", ""),
+		ReassembleLines:        getBoolEnv("REASSEMBLE_LINES", false),
+		RaggedRowPolicy:        getEnv("RAGGED_ROW_POLICY", parser.RaggedRowFail),
+		ColumnSampleRows:       getIntEnv("COLUMN_SAMPLE_ROWS", 0),
+		QualityPolicy:          getEnv("QUALITY_POLICY", QualityPolicyFail),
+		ParseWorkers:           getIntEnv("PARSE_WORKERS", 1),
+		ParallelParseMin:       int64(getIntEnv("PARALLEL_PARSE_MIN_MB", 64)) << 20,
+		SampleRows:             getIntEnv("SAMPLE_ROWS", 0),
+		SampleMode:             getEnv("SAMPLE_MODE", "head"),
+		DedupKeep:              getEnv("DEDUP_KEEP", "first"),
+		SanitizeFormulas:       getBoolEnv("SANITIZE_FORMULAS", false),
+		SanitizePrefix:         getEnv("SANITIZE_FORMULA_PREFIX", "'"),
+		SortMemoryRows:         getIntEnv("SORT_MEMORY_ROWS", 100000),
+		GroupChildKey:          getEnv("GROUP_CHILD_KEY", "items"),
+		OutputType:             getEnv("OUTPUT_TYPE", "file"),
+		OutputFolder:           getEnv("OUTPUT_FOLDER", "./output"),
+		OutputPipe:             getEnv("OUTPUT_PIPE", ""),
+		ASCIISafeOutput:        getBoolEnv("ASCII_SAFE_OUTPUT", false),
+		OutputShape:            getEnv("OUTPUT_SHAPE", converter.ShapeArray),
+		TypeInference:          getBoolEnv("TYPE_INFERENCE", false),
+		PartitionBy:            getEnv("PARTITION_BY", ""),
+		BatchWindow:            getDurationEnv("BATCH_WINDOW_SECONDS", 0) * time.Second,
+		BatchMaxFiles:          getIntEnv("BATCH_MAX_FILES", 0),
+		QueueType:              getEnv("QUEUE_TYPE", "rabbitmq"),
+		QueueHost:              getEnv("QUEUE_HOST", "localhost"),
+		QueuePort:              getIntEnv("QUEUE_PORT", 5672),
+		QueueVHost:             getEnv("QUEUE_VHOST", ""),
+		QueueName:              getEnv("QUEUE_NAME", ""),
+		QueueShards:            splitList(getEnv("QUEUE_SHARDS", "")),
+		ShardStrategy:          getEnv("SHARD_STRATEGY", ShardStrategyRoundRobin),
+		ShardColumn:            getEnv("SHARD_COLUMN", ""),
+		MessageContentType:     getEnv("MESSAGE_CONTENT_TYPE", ContentTypeJSON),
+		MessageSchema:          getEnv("MESSAGE_SCHEMA", ""),
+		QueueLazyConnect:       getBoolEnv("QUEUE_LAZY_CONNECT", false),
+		QueueHeartbeat:         getIntervalEnv("QUEUE_HEARTBEAT", 10*time.Second),
+		QueueConnectRetries:    getIntEnv("QUEUE_CONNECT_RETRIES", 3),
+		QueueConnectRetryDelay: getIntervalEnv("QUEUE_CONNECT_RETRY_DELAY", time.Second),
+		KafkaMessageKey:        getEnv("KAFKA_MESSAGE_KEY", ""),
+		KafkaPartition:         getEnv("KAFKA_PARTITION", ""),
+		LegacyIdentifier:       getEnv("LEGACY_IDENTIFIER", ""),
+		RabbitMQExchange:       getEnv("RABBITMQ_EXCHANGE", ""),
+		RabbitMQExchangeType:   getEnv("RABBITMQ_EXCHANGE_TYPE", "topic"),
+		RabbitMQRoutingKey:     getEnv("RABBITMQ_ROUTING_KEY", ""),
+		RabbitMQBindingKey:     getEnv("RABBITMQ_BINDING_KEY", ""),
+		ReceiptLog:             getEnv("RECEIPT_LOG", ""),
+		ReportDestination:      getEnv("REPORT_DESTINATION", ""),
+		PublisherConfirms:      getBoolEnv("PUBLISHER_CONFIRMS", false),
+		PublishConfirmTimeout:  getDurationEnv("PUBLISH_CONFIRM_TIMEOUT_SECONDS", 30) * time.Second,
+		DownstreamAck:          getBoolEnv("DOWNSTREAM_ACK", false),
+		DownstreamAckQueue:     getEnv("DOWNSTREAM_ACK_QUEUE", ""),
+		DownstreamAckTimeout:   getDurationEnv("DOWNSTREAM_ACK_TIMEOUT_SECONDS", 300) * time.Second,
+		ArchiveProcessed:       getEnv("ARCHIVE_PROCESSED", "./archive/processed"),
+		ArchiveIgnored:         getEnv("ARCHIVE_IGNORED", "./archive/ignored"),
+		ArchiveFailed:          getEnv("ARCHIVE_FAILED", "./archive/failed"),
+		ArchiveTimestamp:       getBoolEnv("ARCHIVE_TIMESTAMP", true),
+		ColumnStats:            getBoolEnv("COLUMN_STATS", false),
+		ArchiveFailurePolicy:   getEnv("ARCHIVE_FAILURE_POLICY", ArchiveFailureReprocess),
+		ArchiveRetries:         getIntEnv("ARCHIVE_RETRIES", 3),
+		ArchiveRetryDelay:      getIntervalEnv("ARCHIVE_RETRY_DELAY", time.Second),
+		ArchiveQuarantine:      getEnv("ARCHIVE_QUARANTINE", ""),
+		LogLevel:               getEnv("LOG_LEVEL", "INFO"),
+		LogFile:                getEnv("LOG_FILE", "./logs/csv2json.log"),
+		LogQueueMessages:       getBoolEnv("LOG_QUEUE_MESSAGES", false),
+		MetricsAddr:            getEnv("METRICS_ADDR", ""),
+		AdminAddr:              getEnv("ADMIN_ADDR", ""),
+	}
+
+	// Sharded output is described by its first queue wherever a single name is shown
+	if cfg.QueueName == "" && len(cfg.QueueShards) > 0 {
+		cfg.QueueName = cfg.QueueShards[0]
 	}
 
 	// Parse file suffix filter
-	suffixFilter := getEnv("FILE_SUFFIX_FILTER", "")
-	if suffixFilter != "" && suffixFilter != "*" {
-		suffixes := strings.Split(suffixFilter, ",")
-		for _, s := range suffixes {
-			s = strings.TrimSpace(s)
-			if !strings.HasPrefix(s, ".") {
-				s = "." + s
-			}
-			cfg.FileSuffixFilter = append(cfg.FileSuffixFilter, s)
+	cfg.FileSuffixFilter = parseSuffixFilter(getEnv("FILE_SUFFIX_FILTER", suffixFilter))
+	cfg.FilenameIgnoreCase = getBoolEnv("FILENAME_CASE_INSENSITIVE", false)
+	cfg.UnmatchedPolicy = getEnv("UNMATCHED_FILE_POLICY", UnmatchedFileArchive)
+	cfg.ChecksumPolicy = getEnv("CHECKSUM_POLICY", ChecksumPolicyOff)
+	cfg.ProcessExisting = getBoolEnv("PROCESS_EXISTING", false)
+	cfg.ProcessExistingOrder = getEnv("PROCESS_EXISTING_ORDER", ProcessExistingOldest)
+
+	// Parse deduplication keys
+	cfg.DedupKeys = splitList(getEnv("DEDUP_KEYS", ""))
+
+	// Parse lookup tables
+	lookups, err := parseLookupTables(getEnv("LOOKUP_TABLES", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOOKUP_TABLES: %w", err)
+	}
+	cfg.Lookups = lookups
+
+	// Parse the declared output schema
+	if cfg.OutputSchema, err = parseOutputSchema(getEnv("OUTPUT_SCHEMA", "")); err != nil {
+		return nil, fmt.Errorf("invalid OUTPUT_SCHEMA: %w", err)
+	}
+	cfg.ExtraColumns = getEnv("OUTPUT_SCHEMA_EXTRA_COLUMNS", ExtraColumnsDrop)
+	cfg.StrictColumns = getBoolEnv("OUTPUT_SCHEMA_STRICT_COLUMNS", false)
+
+	// Parse column value types
+	if cfg.ColumnTypes, err = parseColumnTypes(getEnv("COLUMN_TYPES", "")); err != nil {
+		return nil, fmt.Errorf("invalid COLUMN_TYPES: %w", err)
+	}
+
+	// Parse the output file wrapper
+	if cfg.FileWrapper, err = fileWrapperFromEnv(); err != nil {
+		return nil, fmt.Errorf("invalid FILE_WRAPPER_FIELDS: %w", err)
+	}
+
+	// Parse expected arrival schedule
+	if spec := getEnv("EXPECTED_ARRIVAL", ""); spec != "" {
+		if cfg.ExpectedArrival, err = sla.Parse(spec); err != nil {
+			return nil, fmt.Errorf("invalid EXPECTED_ARRIVAL: %w", err)
+		}
+	}
+
+	// Parse data quality expectations
+	if spec := getEnv("QUALITY_EXPECTATIONS", ""); spec != "" {
+		expectations, err := parseQualityExpectations(spec)
+		if err == nil {
+			cfg.Quality, err = quality.NewSuite(expectations)
 		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid QUALITY_EXPECTATIONS: %w", err)
+		}
+	}
+
+	// Parse fixed-width layout
+	cfg.FixedWidthColumns, err = parser.ParseFixedWidthColumns(getEnv("FIXED_WIDTH_COLUMNS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid FIXED_WIDTH_COLUMNS: %w", err)
+	}
+	cfg.RecordLayouts, err = parser.ParseRecordLayouts(getEnv("RECORD_LAYOUTS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RECORD_LAYOUTS: %w", err)
+	}
+
+	// Resolve queue credentials, preferring mounted secret files
+	cfg.QueueUsername, err = getSecretEnv("QUEUE_USERNAME")
+	if err != nil {
+		return nil, err
+	}
+	cfg.QueuePassword, err = getSecretEnv("QUEUE_PASSWORD")
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve the payload encryption key, preferring a mounted secret file
+	cfg.PayloadEncryptionKey, err = getSecretEnv("PAYLOAD_ENCRYPTION_KEY")
+	if err != nil {
+		return nil, err
+	}
+	cfg.PayloadEncryptionKeyID = getEnv("PAYLOAD_ENCRYPTION_KEY_ID", "")
+
+	// Resolve the message signing key, preferring a mounted secret file
+	cfg.SigningKey, err = getSecretEnv("MESSAGE_SIGNING_KEY")
+	if err != nil {
+		return nil, err
+	}
+	cfg.SigningAlgorithm = getEnv("MESSAGE_SIGNING_ALGORITHM", SigningHMACSHA256)
+	cfg.SigningKeyID = getEnv("MESSAGE_SIGNING_KEY_ID", "")
+
+	// Parse column masking rules
+	cfg.MaskRules, err = parseMaskRules(getEnv("MASK_COLUMNS", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MASK_COLUMNS: %w", err)
+	}
+
+	// Parse column hashing settings
+	cfg.HashColumns = splitList(getEnv("HASH_COLUMNS", ""))
+	cfg.HashSalt, err = getSecretEnv("HASH_SALT")
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.HashColumns) > 0 && cfg.HashSalt == "" {
+		return nil, fmt.Errorf("invalid HASH_SALT: a salt is required with HASH_COLUMNS")
 	}
 
+	// Parse sort specification
+	cfg.SortBy, err = parseSortKeys(getEnv("SORT_BY", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SORT_BY: %w", err)
+	}
+
+	// Parse group-by nesting settings
+	cfg.GroupBy = splitList(getEnv("GROUP_BY", ""))
+	cfg.GroupParentCols = splitList(getEnv("GROUP_PARENT_COLUMNS", ""))
+
 	// Parse filename pattern
 	pattern := getEnv("FILENAME_PATTERN", ".*")
-	re, err := regexp.Compile(pattern)
+	re, err := compileFilenamePattern(pattern, cfg.FilenameIgnoreCase)
 	if err != nil {
 		return nil, fmt.Errorf("invalid FILENAME_PATTERN: %w", err)
 	}
 	cfg.FilenamePattern = re
 
+	// Parse filename exclude pattern
+	if exclude := getEnv("FILENAME_EXCLUDE_PATTERN", ""); exclude != "" {
+		cfg.FilenameExclude, err = compileFilenamePattern(exclude, cfg.FilenameIgnoreCase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FILENAME_EXCLUDE_PATTERN: %w", err)
+		}
+	}
+
+	cfg.Timezone = getEnv("TIMEZONE", "")
+	cfg.ArchiveByDate = getBoolEnv("ARCHIVE_BY_BUSINESS_DATE", false)
+
+	// Parse business date derivation
+	if from := getEnv("BUSINESS_DATE_FROM", ""); from != "" {
+		cfg.BusinessDate, err = businessdate.New(businessdate.Spec{
+			From:    from,
+			Pattern: getEnv("BUSINESS_DATE_PATTERN", ""),
+			Column:  getEnv("BUSINESS_DATE_COLUMN", ""),
+			Format:  getEnv("BUSINESS_DATE_FORMAT", ""),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid BUSINESS_DATE_FROM: %w", err)
+		}
+	}
+	cfg.LateDatePolicy = getEnv("LATE_BUSINESS_DATE_POLICY", LateBusinessDateAccept)
+	cfg.CorrectionsQueue = getEnv("CORRECTIONS_QUEUE", "")
+
+	// Scope destinations to the tenant before any are created
+	tenant, err := resolveTenant(getEnv("TENANT", ""), getEnv("TENANT_FROM", ""), "", cfg.InputFolder)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TENANT/TENANT_FROM: %w", err)
+	}
+	cfg.applyTenant(tenant)
+
 	// Create required directories
 	dirs := []string{
 		cfg.InputFolder,
@@ -118,6 +576,9 @@ func Load() (*Config, error) {
 		filepath.Dir(cfg.LogFile),
 	}
 	for _, dir := range dirs {
+		if strings.Contains(dir, partitionPlaceholder) || strings.Contains(dir, businessdate.Placeholder) {
+			continue // Partition and business date folders are created on first write
+		}
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
@@ -132,8 +593,18 @@ func Load() (*Config, error) {
 }
 
 func (c *Config) validate() error {
-	if c.OutputType != "file" && c.OutputType != "queue" && c.OutputType != "both" {
-		return fmt.Errorf("OUTPUT_TYPE must be 'file', 'queue', or 'both', got: %s", c.OutputType)
+	if err := validateTimezone(c.Timezone); err != nil {
+		return fmt.Errorf("invalid TIMEZONE: %w", err)
+	}
+
+	switch c.OutputType {
+	case "file", "queue", "both", "stdout":
+	case "pipe":
+		if c.OutputPipe == "" {
+			return fmt.Errorf("OUTPUT_PIPE must be set when OUTPUT_TYPE=pipe")
+		}
+	default:
+		return fmt.Errorf("OUTPUT_TYPE must be 'file', 'queue', 'both', 'stdout', or 'pipe', got: %s", c.OutputType)
 	}
 
 	if c.OutputType == "queue" || c.OutputType == "both" {
@@ -156,36 +627,1017 @@ func (c *Config) validate() error {
 		}
 	}
 
-	if c.PollInterval < time.Second {
-		return fmt.Errorf("POLL_INTERVAL_SECONDS must be >= 1")
+	// Kafka templates are resolved by the Kafka producer, so they would be ignored until it lands
+	if c.KafkaMessageKey != "" || c.KafkaPartition != "" {
+		return fmt.Errorf("KAFKA_MESSAGE_KEY and KAFKA_PARTITION cannot be used: Kafka output not supported yet")
+	}
+
+	if err := validateExchangeType(c.RabbitMQExchangeType); err != nil {
+		return fmt.Errorf("invalid RABBITMQ_EXCHANGE_TYPE: %w", err)
+	}
+
+	if err := validateEncryptionKey(c.PayloadEncryptionKey, c.PayloadEncryptionKeyID); err != nil {
+		return fmt.Errorf("invalid PAYLOAD_ENCRYPTION_KEY: %w", err)
+	}
+
+	if err := validateSigningKey(c.SigningAlgorithm, c.SigningKey, c.SigningKeyID); err != nil {
+		return fmt.Errorf("invalid MESSAGE_SIGNING_KEY: %w", err)
 	}
 
+	if err := validateSharding(c.QueueShards, c.ShardStrategy, c.ShardColumn, c.RabbitMQRoutingKey != "" || c.RabbitMQBindingKey != ""); err != nil {
+		return fmt.Errorf("invalid QUEUE_SHARDS: %w", err)
+	}
+
+	if err := validateContentType(c.MessageContentType, len(c.GroupBy) > 0 || c.InputFormat == parser.FormatMulti); err != nil {
+		return fmt.Errorf("invalid MESSAGE_CONTENT_TYPE: %w", err)
+	}
+
+	if err := validateOutputShape(c.OutputShape, c.BatchWindow > 0 || c.BatchMaxFiles > 0 || c.BatchManifests, c.EmptyFilePolicy, c.MessageContentType); err != nil {
+		return fmt.Errorf("invalid OUTPUT_SHAPE: %w", err)
+	}
+
+	if err := validateTypeInference(c.TypeInference, c.ColumnTypes, c.MessageContentType); err != nil {
+		return fmt.Errorf("invalid TYPE_INFERENCE or COLUMN_TYPES: %w", err)
+	}
+
+	if c.LegacyIdentifier != "" && c.OutputType != "queue" && c.OutputType != "both" {
+		return fmt.Errorf("LEGACY_IDENTIFIER requires OUTPUT_TYPE=queue or both")
+	}
+
+	if err := validateFileWrapper(c.FileWrapper, c.OutputType == "file" || c.OutputType == "both"); err != nil {
+		return fmt.Errorf("invalid FILE_WRAPPER: %w", err)
+	}
+
+	if err := validateInputFormat(c.InputFormat, c.FixedWidthColumns); err != nil {
+		return fmt.Errorf("invalid INPUT_FORMAT/FIXED_WIDTH_COLUMNS: %w", err)
+	}
+	if err := validateRecordLayouts(c.InputFormat, c.RecordLayouts, len(c.GroupBy) > 0); err != nil {
+		return fmt.Errorf("invalid INPUT_FORMAT/RECORD_LAYOUTS: %w", err)
+	}
+	if c.ReassembleLines && c.InputFormat != parser.FormatDelimited && c.InputFormat != parser.FormatTSV {
+		return fmt.Errorf("REASSEMBLE_LINES requires INPUT_FORMAT=delimited or tsv")
+	}
+	if c.InputFormat == parser.FormatTSV && c.Delimiter != '\t' {
+		return fmt.Errorf("INPUT_FORMAT=tsv uses the tab DELIMITER, got %q", c.Delimiter)
+	}
+
+	if _, err := parser.NormalizeEncoding(c.Encoding); err != nil {
+		return fmt.Errorf("invalid ENCODING: %w", err)
+	}
+
+	if err := parser.ValidateInvalidUTF8Policy(c.InvalidUTF8Policy); err != nil {
+		return fmt.Errorf("invalid INVALID_UTF8_POLICY: %w", err)
+	}
+
+	if err := parser.ValidateRaggedRowPolicy(c.RaggedRowPolicy); err != nil {
+		return fmt.Errorf("invalid RAGGED_ROW_POLICY: %w", err)
+	}
+	if c.ColumnSampleRows < 0 {
+		return fmt.Errorf("COLUMN_SAMPLE_ROWS must not be negative")
+	}
+
+	if c.ParseWorkers < 1 || c.ParallelParseMin < 0 {
+		return fmt.Errorf("PARSE_WORKERS must be >= 1 and PARALLEL_PARSE_MIN_MB must not be negative")
+	}
+
+	if err := validateEmptyFilePolicy(c.EmptyFilePolicy); err != nil {
+		return fmt.Errorf("invalid EMPTY_FILE_POLICY: %w", err)
+	}
+
+	if err := validateSchemaDriftPolicy(c.SchemaDriftPolicy); err != nil {
+		return fmt.Errorf("invalid SCHEMA_DRIFT_POLICY: %w", err)
+	}
+
+	if err := validateQualityPolicy(c.QualityPolicy); err != nil {
+		return fmt.Errorf("invalid QUALITY_POLICY: %w", err)
+	}
+
+	if err := validateArchiveFailurePolicy(c.ArchiveFailurePolicy); err != nil {
+		return fmt.Errorf("invalid ARCHIVE_FAILURE_POLICY: %w", err)
+	}
+	if c.ArchiveRetries < 0 || c.ArchiveRetryDelay < 0 {
+		return fmt.Errorf("ARCHIVE_RETRIES and ARCHIVE_RETRY_DELAY must not be negative")
+	}
+	if c.ExactlyOnceRetention < 0 {
+		return fmt.Errorf("EXACTLY_ONCE_RETENTION must not be negative")
+	}
+
+	if err := validateOutputSchema(c.OutputSchema, c.ExtraColumns); err != nil {
+		return fmt.Errorf("invalid OUTPUT_SCHEMA: %w", err)
+	}
+	if c.StrictColumns && len(c.OutputSchema) == 0 {
+		return fmt.Errorf("OUTPUT_SCHEMA_STRICT_COLUMNS requires OUTPUT_SCHEMA")
+	}
+
+	if c.PartitionBy == "" && (strings.Contains(c.OutputFolder, partitionPlaceholder) || strings.Contains(c.QueueName, partitionPlaceholder)) {
+		return fmt.Errorf("PARTITION_BY must be set when OUTPUT_FOLDER or QUEUE_NAME contains %s", partitionPlaceholder)
+	}
+
+	if err := validateBatching(c.BatchWindow, c.BatchMaxFiles, c.PartitionBy); err != nil {
+		return err
+	}
+	if c.BatchManifests && (c.BatchWindow > 0 || c.BatchMaxFiles > 0) {
+		return fmt.Errorf("BATCH_MANIFESTS cannot be combined with BATCH_WINDOW_SECONDS or BATCH_MAX_FILES")
+	}
+	if c.ExactlyOnce && c.BatchManifests {
+		return fmt.Errorf("EXACTLY_ONCE cannot be combined with BATCH_MANIFESTS")
+	}
+	if err := validateBusinessDate(c.BusinessDate != nil, c.ArchiveByDate, c.OutputFolder, c.BatchWindow > 0 || c.BatchMaxFiles > 0 || c.BatchManifests); err != nil {
+		return fmt.Errorf("invalid BUSINESS_DATE_FROM: %w", err)
+	}
+	if err := validateLateDatePolicy(c.LateDatePolicy, c.CorrectionsQueue, c.BusinessDate != nil, c.OutputType); err != nil {
+		return fmt.Errorf("invalid LATE_BUSINESS_DATE_POLICY: %w", err)
+	}
+
+	if err := validateReportDestination(c.ReportDestination); err != nil {
+		return fmt.Errorf("invalid REPORT_DESTINATION: %w", err)
+	}
+
+	if err := validateClaims(c.ClaimFiles, c.InstanceID, c.ClaimTTL); err != nil {
+		return err
+	}
+	if err := validateInstanceLock(c.InstanceLock, c.ClaimFiles); err != nil {
+		return err
+	}
+	if err := validateWorkDir(c.WorkDir, c.InputFolder, c.ClaimFiles); err != nil {
+		return fmt.Errorf("invalid WORK_DIR: %w", err)
+	}
+
+	if c.MinFreeDiskMB < 0 {
+		return fmt.Errorf("MIN_FREE_DISK_MB must not be negative, got: %d", c.MinFreeDiskMB)
+	}
+	if c.MinFreeDiskMB > 0 && c.DiskCheckInterval < time.Second {
+		return fmt.Errorf("DISK_CHECK_INTERVAL_SECONDS must be >= 1")
+	}
+
+	if c.DownstreamAck && c.DownstreamAckTimeout < time.Second {
+		return fmt.Errorf("DOWNSTREAM_ACK_TIMEOUT_SECONDS must be >= 1")
+	}
+
+	if err := validateBrokerConnection(c.QueueHeartbeat, c.QueueConnectRetries, c.QueueConnectRetryDelay); err != nil {
+		return err
+	}
+
+	if c.MemoryLimitMB < 0 {
+		return fmt.Errorf("MEMORY_LIMIT_MB must not be negative, got: %d", c.MemoryLimitMB)
+	}
+
+	if err := validateThrottle(c.BytesPerInterval, c.ThrottleInterval); err != nil {
+		return err
+	}
+
+	if err := validateUnmatchedPolicy(c.UnmatchedPolicy); err != nil {
+		return fmt.Errorf("invalid UNMATCHED_FILE_POLICY: %w", err)
+	}
+
+	if err := validateChecksumPolicy(c.ChecksumPolicy); err != nil {
+		return fmt.Errorf("invalid CHECKSUM_POLICY: %w", err)
+	}
+
+	if err := validateProcessExistingOrder(c.ProcessExistingOrder); err != nil {
+		return fmt.Errorf("invalid PROCESS_EXISTING_ORDER: %w", err)
+	}
+
+	if err := validateWatchMode(c.WatchMode); err != nil {
+		return fmt.Errorf("invalid WATCH_MODE: %w", err)
+	}
+
+	if c.PollInterval < MinPollInterval {
+		return fmt.Errorf("POLL_INTERVAL_SECONDS must be at least %s, got: %s", MinPollInterval, c.PollInterval)
+	}
+
+	if c.HybridPollInterval < MinPollInterval {
+		return fmt.Errorf("HYBRID_POLL_INTERVAL_SECONDS must be at least %s, got: %s", MinPollInterval, c.HybridPollInterval)
+	}
+
+	if c.StabilityWindow < 0 {
+		return fmt.Errorf("FILE_STABILITY_WINDOW must not be negative, got: %s", c.StabilityWindow)
+	}
+
+	return nil
+}
+
+// validateBrokerConnection checks the broker heartbeat and reconnect settings
+func validateBrokerConnection(heartbeat time.Duration, retries int, retryDelay time.Duration) error {
+	if heartbeat < 0 {
+		return fmt.Errorf("QUEUE_HEARTBEAT must not be negative, got: %s", heartbeat)
+	}
+	if retries < 0 {
+		return fmt.Errorf("QUEUE_CONNECT_RETRIES must not be negative, got: %d", retries)
+	}
+	if retryDelay < 0 {
+		return fmt.Errorf("QUEUE_CONNECT_RETRY_DELAY must not be negative, got: %s", retryDelay)
+	}
 	return nil
 }
 
+// validateReportDestination checks that a report queue names a supported broker and a queue
+func validateReportDestination(destination string) error {
+	queueType, queueName, isQueue := strings.Cut(destination, "://")
+	if !isQueue {
+		return nil
+	}
+	if queueType != "rabbitmq" {
+		return fmt.Errorf("report queues support rabbitmq, got: %s", queueType)
+	}
+	if queueName == "" {
+		return fmt.Errorf("missing queue name in %s", destination)
+	}
+	return nil
+}
+
+// validateClaims checks the multi-instance claim settings when claiming is enabled
+func validateClaims(enabled bool, instanceID string, ttl time.Duration) error {
+	if !enabled {
+		return nil
+	}
+	if instanceID == "" || instanceID == "." || instanceID == ".." || strings.ContainsAny(instanceID, `/\`) {
+		return fmt.Errorf("INSTANCE_ID must be a non-empty name without path separators, got: %q", instanceID)
+	}
+	if ttl < 3*time.Second {
+		return fmt.Errorf("CLAIM_TTL_SECONDS must be >= 3")
+	}
+	return nil
+}
+
+// validateWorkDir checks the working directory is separate from the input folder and
+// not combined with claims, which already take files out of the input folder
+func validateWorkDir(workDir, inputFolder string, claimFiles bool) error {
+	if workDir == "" {
+		return nil
+	}
+	if filepath.Clean(workDir) == filepath.Clean(inputFolder) {
+		return fmt.Errorf("working directory must differ from the input folder")
+	}
+	if claimFiles {
+		return fmt.Errorf("working directory cannot be combined with CLAIM_FILES: both take files out of the input folder before processing")
+	}
+	return nil
+}
+
+// validateInstanceLock rejects locking a folder that instances are meant to share via claims
+func validateInstanceLock(lock, claimFiles bool) error {
+	if lock && claimFiles {
+		return fmt.Errorf("INSTANCE_LOCK cannot be combined with CLAIM_FILES: claims let instances share an input folder, the lock prevents it")
+	}
+	return nil
+}
+
+// validateWatchMode returns an error if mode is not a supported file detection strategy
+func validateWatchMode(mode string) error {
+	switch mode {
+	case "event", "poll", "hybrid":
+		return nil
+	default:
+		return fmt.Errorf("unsupported watch mode: %s (supported: event, poll, hybrid)", mode)
+	}
+}
+
+// validateExchangeType returns an error if exchangeType is not a RabbitMQ exchange type (empty means topic)
+func validateExchangeType(exchangeType string) error {
+	switch exchangeType {
+	case "", "topic", "direct", "fanout", "headers":
+		return nil
+	default:
+		return fmt.Errorf("unsupported exchange type: %s (supported: topic, direct, fanout, headers)", exchangeType)
+	}
+}
+
+// validatePayloadFormat returns an error if format is not a supported queue message payload format
+func validatePayloadFormat(format string) error {
+	switch format {
+	case PayloadFormatEnvelope, PayloadFormatLegacy, PayloadFormatBare:
+		return nil
+	default:
+		return fmt.Errorf("unsupported payload format: %s (supported: envelope, legacy, bare)", format)
+	}
+}
+
+// validateContentType returns an error if contentType is not a supported message
+// content type, or is Avro for grouped (nested) output
+func validateContentType(contentType string, grouped bool) error {
+	switch contentType {
+	case ContentTypeJSON, ContentTypeNDJSON:
+		return nil
+	case ContentTypeAvro:
+		if grouped {
+			return fmt.Errorf("%s cannot encode grouped (nested) records", ContentTypeAvro)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported content type: %s (supported: %s, %s, %s)", contentType, ContentTypeJSON, ContentTypeNDJSON, ContentTypeAvro)
+	}
+}
+
+// validateEncryptionKey checks a payload encryption key is a base64 AES key with an ID ("" = disabled)
+func validateEncryptionKey(key, keyID string) error {
+	if key == "" {
+		return nil
+	}
+	if keyID == "" {
+		return fmt.Errorf("a key ID must be set so consumers can select the decryption key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("key is not valid base64: %w", err)
+	}
+	if len(raw) != 16 && len(raw) != 24 && len(raw) != 32 {
+		return fmt.Errorf("key must decode to 16, 24 or 32 bytes, got %d", len(raw))
+	}
+	return nil
+}
+
+// validateSigningKey checks a base64 message signing key suits the algorithm and has an ID ("" = disabled)
+func validateSigningKey(algorithm, key, keyID string) error {
+	if key == "" {
+		return nil
+	}
+	if keyID == "" {
+		return fmt.Errorf("a key ID must be set so consumers can select the verification key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return fmt.Errorf("key is not valid base64: %w", err)
+	}
+	switch algorithm {
+	case SigningHMACSHA256:
+		if len(raw) < 16 {
+			return fmt.Errorf("HMAC key must decode to at least 16 bytes, got %d", len(raw))
+		}
+	case SigningEd25519:
+		if len(raw) != 32 && len(raw) != 64 {
+			return fmt.Errorf("Ed25519 key must decode to a 32-byte seed or 64-byte private key, got %d bytes", len(raw))
+		}
+	default:
+		return fmt.Errorf("unsupported algorithm: %s (supported: hmac-sha256, ed25519)", algorithm)
+	}
+	return nil
+}
+
+// resolveTenant returns tenant, or the name derived from the route or input folder per from
+func resolveTenant(tenant, from, routeName, inputFolder string) (string, error) {
+	if tenant == "" {
+		switch from {
+		case "":
+			return "", nil
+		case TenantFromRoute:
+			if routeName == "" {
+				return "", fmt.Errorf("tenant cannot be derived from the route name outside routes mode")
+			}
+			tenant = routeName
+		case TenantFromFolder:
+			tenant = filepath.Base(filepath.Clean(inputFolder))
+		default:
+			return "", fmt.Errorf("unsupported tenant source: %s (supported: route, folder)", from)
+		}
+	}
+	if !tenantPattern.MatchString(tenant) {
+		return "", fmt.Errorf("tenant %q must start with a letter or digit and contain only letters, digits, '-' and '_'", tenant)
+	}
+	return tenant, nil
+}
+
+// applyTenant scopes destinations to tenant: queue names get a "tenant." prefix and
+// output and report folders a tenant subfolder
+func (c *Config) applyTenant(tenant string) {
+	c.Tenant = tenant
+	if tenant == "" {
+		return
+	}
+	prefixQueue := func(name string) string {
+		if name == "" {
+			return ""
+		}
+		return tenant + "." + name
+	}
+
+	c.QueueName = prefixQueue(c.QueueName)
+	for i, queue := range c.QueueShards {
+		c.QueueShards[i] = prefixQueue(queue)
+	}
+	c.DownstreamAckQueue = prefixQueue(c.DownstreamAckQueue)
+	c.CorrectionsQueue = prefixQueue(c.CorrectionsQueue)
+	if c.OutputFolder != "" {
+		c.OutputFolder = filepath.Join(c.OutputFolder, tenant)
+	}
+	if scheme, queue, ok := strings.Cut(c.ReportDestination, "://"); ok {
+		c.ReportDestination = scheme + "://" + prefixQueue(queue)
+	} else if c.ReportDestination != "" {
+		c.ReportDestination = filepath.Join(c.ReportDestination, tenant)
+	}
+}
+
+// validateSharding checks sharded queue output settings
+func validateSharding(queues []string, strategy, column string, customRouting bool) error {
+	if len(queues) == 0 {
+		return nil
+	}
+	switch strategy {
+	case ShardStrategyRoundRobin:
+	case ShardStrategyHash:
+		if column == "" {
+			return fmt.Errorf("the hash strategy requires a shard column")
+		}
+	default:
+		return fmt.Errorf("unsupported shard strategy: %s (supported: roundRobin, hash)", strategy)
+	}
+	for _, queue := range queues {
+		if strings.Contains(queue, partitionPlaceholder) {
+			return fmt.Errorf("sharded queue names cannot contain %s", partitionPlaceholder)
+		}
+	}
+	// Each shard is bound by its own name, so routing keys cannot be templated
+	if customRouting {
+		return fmt.Errorf("sharding cannot be combined with a RabbitMQ routing or binding key")
+	}
+	return nil
+}
+
+// validateBatching checks merge window batching settings
+func validateBatching(window time.Duration, maxFiles int, partitionBy string) error {
+	if window < 0 || maxFiles < 0 {
+		return fmt.Errorf("batch window and max files must be >= 0")
+	}
+	if (window > 0 || maxFiles > 0) && partitionBy != "" {
+		return fmt.Errorf("batching cannot be combined with partitioned output")
+	}
+	return nil
+}
+
+// partitionPlaceholder marks where the partition value is substituted into output destinations
+const partitionPlaceholder = "{partition}"
+
+// validateRecordLayouts checks record layouts are given exactly for multi-record input,
+// whose nested output cannot be grouped again
+func validateRecordLayouts(format string, layouts []parser.RecordLayout, grouped bool) error {
+	if format != parser.FormatMulti {
+		if len(layouts) > 0 {
+			return fmt.Errorf("record layouts require the multi-record format")
+		}
+		return nil
+	}
+	if grouped {
+		return fmt.Errorf("multi-record input is already nested and cannot be grouped")
+	}
+	return parser.ValidateRecordLayouts(layouts)
+}
+
+// validateBusinessDate checks business date archiving and output folders have a business
+// date to use, and that files are not merged into outputs of several business dates
+func validateBusinessDate(derived, archiveByDate bool, outputFolder string, batched bool) error {
+	if !derived {
+		if archiveByDate {
+			return fmt.Errorf("archiving by business date requires a business date source")
+		}
+		if strings.Contains(outputFolder, businessdate.Placeholder) {
+			return fmt.Errorf("output folders containing %s require a business date source", businessdate.Placeholder)
+		}
+		return nil
+	}
+	if batched {
+		return fmt.Errorf("business dates cannot be combined with batching, which merges files of different business dates")
+	}
+	return nil
+}
+
+// validateOutputShape checks the output shape, and that object output is not combined
+// with settings that emit several rows or an empty array per output
+func validateOutputShape(shape string, batched bool, emptyFilePolicy, contentType string) error {
+	if err := converter.ValidateShape(shape); err != nil {
+		return err
+	}
+	if shape != converter.ShapeObject {
+		return nil
+	}
+	switch {
+	case batched:
+		return fmt.Errorf("object output cannot be combined with batching, which merges the rows of several files")
+	case emptyFilePolicy == EmptyFilePolicyEmitEmptyArray:
+		return fmt.Errorf("object output cannot be combined with the emitEmptyArray empty file policy")
+	case contentType != ContentTypeJSON:
+		return fmt.Errorf("object output requires the %s content type", ContentTypeJSON)
+	}
+	return nil
+}
+
+// parseColumnTypes parses a comma-separated list of column:type value types
+// Example: "age:integer,price:number,account_id:string"
+func parseColumnTypes(spec string) ([]ColumnType, error) {
+	var columnTypes []ColumnType
+	for _, entry := range splitList(spec) {
+		column, typ, ok := strings.Cut(entry, ":")
+		column, typ = strings.TrimSpace(column), strings.TrimSpace(typ)
+		if !ok || column == "" {
+			return nil, fmt.Errorf("expected column:type, got %q", entry)
+		}
+		columnTypes = append(columnTypes, ColumnType{Column: column, Type: typ})
+	}
+	return columnTypes, nil
+}
+
+// validateTypeInference checks column types are supported and declared once, and that
+// typed values are not combined with Avro messages, whose fields are all strings
+func validateTypeInference(inference bool, columnTypes []ColumnType, contentType string) error {
+	seen := make(map[string]bool, len(columnTypes))
+	typed := inference
+	for _, columnType := range columnTypes {
+		if columnType.Column == "" {
+			return fmt.Errorf("column name must not be empty")
+		}
+		if seen[columnType.Column] {
+			return fmt.Errorf("column '%s' is declared twice", columnType.Column)
+		}
+		seen[columnType.Column] = true
+		if err := converter.ValidateType(columnType.Type); err != nil {
+			return fmt.Errorf("column '%s': %w", columnType.Column, err)
+		}
+		typed = typed || columnType.Type != converter.TypeString
+	}
+	if typed && contentType == ContentTypeAvro {
+		return fmt.Errorf("typed values cannot be encoded as %s, whose fields are strings", ContentTypeAvro)
+	}
+	return nil
+}
+
+// Default field names of the output file wrapper
+const (
+	defaultWrapperFile        = "file"
+	defaultWrapperGeneratedAt = "generatedAt"
+	defaultWrapperRecords     = "records"
+)
+
+// fileWrapperFromEnv returns the output file wrapper enabled by FILE_WRAPPER, with field
+// names renamed by FILE_WRAPPER_FIELDS (nil when disabled)
+func fileWrapperFromEnv() (*FileWrapperConfig, error) {
+	fields := getEnv("FILE_WRAPPER_FIELDS", "")
+	if !getBoolEnv("FILE_WRAPPER", false) {
+		if fields != "" {
+			return nil, fmt.Errorf("FILE_WRAPPER_FIELDS requires FILE_WRAPPER=true")
+		}
+		return nil, nil
+	}
+	return parseFileWrapperFields(fields)
+}
+
+// parseFileWrapperFields parses a comma-separated list of field=name renames of the
+// output file wrapper's fields
+// Example: "file=source,generatedAt=createdAt,records=rows"
+func parseFileWrapperFields(spec string) (*FileWrapperConfig, error) {
+	wrapper := &FileWrapperConfig{}
+	for _, entry := range splitList(spec) {
+		field, name, ok := strings.Cut(entry, "=")
+		field, name = strings.TrimSpace(field), strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected field=name, got %q", entry)
+		}
+		switch field {
+		case defaultWrapperFile:
+			wrapper.File = name
+		case defaultWrapperGeneratedAt:
+			wrapper.GeneratedAt = name
+		case defaultWrapperRecords:
+			wrapper.Records = name
+		default:
+			return nil, fmt.Errorf("unsupported wrapper field: %s (supported: file, generatedAt, records)", field)
+		}
+	}
+	wrapper.applyDefaults()
+	return wrapper, nil
+}
+
+// validateFileWrapper checks the output file wrapper is only set for file output and
+// that its field names are distinct
+func validateFileWrapper(wrapper *FileWrapperConfig, fileOutput bool) error {
+	if wrapper == nil {
+		return nil
+	}
+	if !fileOutput {
+		return fmt.Errorf("the file wrapper requires file or both output")
+	}
+	if wrapper.File == wrapper.GeneratedAt || wrapper.File == wrapper.Records || wrapper.GeneratedAt == wrapper.Records {
+		return fmt.Errorf("wrapper field names must be distinct, got %s, %s and %s", wrapper.File, wrapper.GeneratedAt, wrapper.Records)
+	}
+	return nil
+}
+
+// tsvSuffixFilter is the suffix filter of TSV input unless one is configured
+const tsvSuffixFilter = ".tsv,.txt"
+
+// validateEmptyFilePolicy returns an error if policy is not a supported empty file policy
+
+// validateInputFormat checks the input format and that a column layout is given exactly for fixed-width input
+func validateInputFormat(format string, columns []parser.FixedWidthColumn) error {
+	if err := parser.ValidateFormat(format); err != nil {
+		return err
+	}
+	if format == parser.FormatFixedWidth {
+		return parser.ValidateFixedWidthColumns(columns)
+	}
+	if len(columns) > 0 {
+		return fmt.Errorf("fixed-width columns require the fixed-width format")
+	}
+	return nil
+}
+
+func validateEmptyFilePolicy(policy string) error {
+	switch policy {
+	case EmptyFilePolicyFail, EmptyFilePolicyEmitEmptyArray, EmptyFilePolicyIgnore:
+		return nil
+	default:
+		return fmt.Errorf("unsupported empty file policy: %s (supported: fail, emitEmptyArray, ignore)", policy)
+	}
+}
+
+// validateSchemaDriftPolicy returns an error if policy is not a supported schema drift policy
+func validateSchemaDriftPolicy(policy string) error {
+	switch policy {
+	case SchemaDriftPolicyOff, SchemaDriftPolicyWarn, SchemaDriftPolicyFail:
+		return nil
+	default:
+		return fmt.Errorf("unsupported schema drift policy: %s (supported: off, warn, fail)", policy)
+	}
+}
+
+// validateQualityPolicy returns an error if policy is not a supported data quality policy
+func validateQualityPolicy(policy string) error {
+	switch policy {
+	case QualityPolicyFail, QualityPolicyWarn:
+		return nil
+	default:
+		return fmt.Errorf("unsupported data quality policy: %s (supported: fail, warn)", policy)
+	}
+}
+
+// validateLateDatePolicy returns an error if policy is not a supported late business
+// date policy or lacks what it needs: a business date source, and for corrections a
+// corrections queue and queue output
+func validateLateDatePolicy(policy, correctionsQueue string, derived bool, outputType string) error {
+	switch policy {
+	case "", LateBusinessDateAccept:
+		if correctionsQueue != "" {
+			return fmt.Errorf("a corrections queue requires the corrections policy")
+		}
+		return nil
+	case LateBusinessDateCorrections, LateBusinessDateHold:
+	default:
+		return fmt.Errorf("unsupported late business date policy: %s (supported: accept, corrections, hold)", policy)
+	}
+	if !derived {
+		return fmt.Errorf("the %s policy requires a business date source", policy)
+	}
+	if policy == LateBusinessDateHold {
+		if correctionsQueue != "" {
+			return fmt.Errorf("a corrections queue requires the corrections policy")
+		}
+		return nil
+	}
+	if correctionsQueue == "" {
+		return fmt.Errorf("the corrections policy requires a corrections queue")
+	}
+	if outputType != "queue" && outputType != "both" {
+		return fmt.Errorf("the corrections policy requires queue output, got %s", outputType)
+	}
+	return nil
+}
+
+// validateArchiveFailurePolicy returns an error if policy is not a supported archive failure policy
+func validateArchiveFailurePolicy(policy string) error {
+	switch policy {
+	case ArchiveFailureReprocess, ArchiveFailureRetry, ArchiveFailureQuarantine, ArchiveFailureMark:
+		return nil
+	default:
+		return fmt.Errorf("unsupported archive failure policy: %s (supported: reprocess, retry, quarantine, mark)", policy)
+	}
+}
+
+// validateTimezone returns an error if name is not a known IANA time zone ("" = default)
+func validateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("unknown time zone: %s", name)
+	}
+	return nil
+}
+
+// Location returns the TIMEZONE location, or nil when unset so each timestamp keeps
+// its default zone
+func (c *Config) Location() *time.Location {
+	if c.Timezone == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return nil
+	}
+	return loc
+}
+
+// ConverterOptions returns the JSON rendering options of the output: ASCII escaping,
+// shape and value types
+func (c *Config) ConverterOptions() converter.Options {
+	opts := converter.Options{ASCIISafe: c.ASCIISafeOutput, Shape: c.OutputShape, InferTypes: c.TypeInference}
+	for _, columnType := range c.ColumnTypes {
+		if opts.ColumnTypes == nil {
+			opts.ColumnTypes = make(map[string]string, len(c.ColumnTypes))
+		}
+		opts.ColumnTypes[columnType.Column] = columnType.Type
+	}
+	return opts
+}
+
+// defaultInstanceID returns the hostname, which is stable across restarts so an
+// instance can release its own claims after a crash
+func defaultInstanceID() string {
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	return fmt.Sprintf("pid-%d", os.Getpid())
+}
+
 func (c *Config) ShouldProcessFile(filename string) bool {
+	reason, _ := c.IgnoreReason(filename)
+	return reason == ""
+}
+
+// IgnoreReason returns the reason code and a human-readable detail for a filename
+// rejected by the input filters, or an empty reason if the file should be processed
+func (c *Config) IgnoreReason(filename string) (string, string) {
 	// Check suffix filter
 	if len(c.FileSuffixFilter) > 0 {
 		match := false
 		for _, suffix := range c.FileSuffixFilter {
-			if strings.HasSuffix(filename, suffix) {
+			if hasSuffix(filename, suffix, c.FilenameIgnoreCase) {
 				match = true
 				break
 			}
 		}
 		if !match {
-			return false
+			return IgnoreReasonSuffixMismatch, fmt.Sprintf("suffix filter: %s", strings.Join(c.FileSuffixFilter, ","))
 		}
 	}
 
 	// Check filename pattern
-	return c.FilenamePattern.MatchString(filename)
+	if c.FilenamePattern != nil && !c.FilenamePattern.MatchString(filename) {
+		return IgnoreReasonPatternMismatch, fmt.Sprintf("filename pattern: %s", c.FilenamePattern)
+	}
+
+	// Check exclude pattern
+	if c.FilenameExclude != nil && c.FilenameExclude.MatchString(filename) {
+		return IgnoreReasonExcluded, fmt.Sprintf("exclude pattern: %s", c.FilenameExclude)
+	}
+
+	return "", ""
+}
+
+// validateUnmatchedPolicy checks the handling of files that do not match the filters
+func validateUnmatchedPolicy(policy string) error {
+	if policy != UnmatchedFileArchive && policy != UnmatchedFileSkip {
+		return fmt.Errorf("unsupported policy: %s (supported: archive, skip)", policy)
+	}
+	return nil
+}
+
+// validateChecksumPolicy checks the handling of checksum sidecars
+func validateChecksumPolicy(policy string) error {
+	switch policy {
+	case ChecksumPolicyOff, ChecksumPolicyVerify, ChecksumPolicyRequire:
+		return nil
+	}
+	return fmt.Errorf("unsupported policy: %s (supported: off, verify, require)", policy)
+}
+
+// validateThrottle checks the intake throttle settings
+func validateThrottle(bytesPerInterval int64, interval time.Duration) error {
+	if bytesPerInterval < 0 {
+		return fmt.Errorf("BYTES_PER_INTERVAL must not be negative, got: %d", bytesPerInterval)
+	}
+	if bytesPerInterval > 0 && interval < MinPollInterval {
+		return fmt.Errorf("THROTTLE_INTERVAL must be at least %s, got: %s", MinPollInterval, interval)
+	}
+	return nil
+}
+
+// validateProcessExistingOrder checks the order of the startup backlog
+func validateProcessExistingOrder(order string) error {
+	switch order {
+	case ProcessExistingOldest, ProcessExistingNewest, ProcessExistingName:
+		return nil
+	}
+	return fmt.Errorf("unsupported order: %s (supported: oldest, newest, name)", order)
+}
+
+// hasSuffix reports whether filename ends with suffix, optionally ignoring case
+func hasSuffix(filename, suffix string, ignoreCase bool) bool {
+	if !ignoreCase {
+		return strings.HasSuffix(filename, suffix)
+	}
+	return len(filename) >= len(suffix) && strings.EqualFold(filename[len(filename)-len(suffix):], suffix)
+}
+
+// parseSuffixFilter parses a comma-separated suffix list, trimming spaces and adding
+// the leading dot where it was left off ("csv" = ".csv"). "" or "*" means all files.
+func parseSuffixFilter(filter string) []string {
+	if strings.TrimSpace(filter) == "*" {
+		return nil
+	}
+	var suffixes []string
+	for _, suffix := range strings.Split(filter, ",") {
+		suffix = strings.TrimSpace(suffix)
+		if suffix == "" {
+			continue
+		}
+		if !strings.HasPrefix(suffix, ".") {
+			suffix = "." + suffix
+		}
+		suffixes = append(suffixes, suffix)
+	}
+	return suffixes
+}
+
+// compileFilenamePattern compiles a filename regex, matching regardless of case when asked
+func compileFilenamePattern(pattern string, ignoreCase bool) (*regexp.Regexp, error) {
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// parseLookupTables parses a comma-separated list of key=path lookup tables
+// Example: "store_id=./reference/stores.csv,sku=./reference/products.csv"
+func parseLookupTables(spec string) ([]LookupConfig, error) {
+	var lookups []LookupConfig
+	for _, entry := range splitList(spec) {
+		key, path, ok := strings.Cut(entry, "=")
+		key, path = strings.TrimSpace(key), strings.TrimSpace(path)
+		if !ok || key == "" || path == "" {
+			return nil, fmt.Errorf("expected key=path, got %q", entry)
+		}
+		lookups = append(lookups, LookupConfig{Key: key, Path: path})
+	}
+	return lookups, nil
+}
+
+// parseOutputSchema parses a comma-separated list of name[=default] output fields
+// Example: "id,country=US,amount=0"
+func parseOutputSchema(spec string) ([]SchemaField, error) {
+	var fields []SchemaField
+	for _, entry := range splitList(spec) {
+		name, value, _ := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("expected name[=default], got %q", entry)
+		}
+		fields = append(fields, SchemaField{Name: name, Default: value})
+	}
+	return fields, nil
+}
+
+// validateOutputSchema checks field names are set and unique, and the extra columns policy
+func validateOutputSchema(fields []SchemaField, extraColumns string) error {
+	if extraColumns != ExtraColumnsDrop && extraColumns != ExtraColumnsFlag {
+		return fmt.Errorf("extra columns policy must be %s or %s, got: %s", ExtraColumnsDrop, ExtraColumnsFlag, extraColumns)
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field.Name == "" {
+			return fmt.Errorf("field name must not be empty")
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("field '%s' is declared twice", field.Name)
+		}
+		seen[field.Name] = true
+	}
+	return nil
+}
+
+// parseQualityExpectations parses a comma-separated list of column:type[:minPercent[:pattern]]
+// data quality expectations
+// Example: "email:notEmpty:99,id:unique,sku:pattern:100:^[A-Z]{3}-[0-9]+$"
+func parseQualityExpectations(spec string) ([]quality.Expectation, error) {
+	var expectations []quality.Expectation
+	for _, entry := range splitList(spec) {
+		parts := strings.SplitN(entry, ":", 4)
+		if len(parts) < 2 || parts[0] == "" {
+			return nil, fmt.Errorf("expected column:type[:minPercent[:pattern]], got %q", entry)
+		}
+		e := quality.Expectation{Column: parts[0], Type: parts[1]}
+		if len(parts) > 2 && parts[2] != "" {
+			minPercent, err := strconv.ParseFloat(parts[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid minPercent in %q: %w", entry, err)
+			}
+			e.MinPercent = minPercent
+		}
+		if len(parts) > 3 {
+			e.Pattern = parts[3]
+		}
+		expectations = append(expectations, e)
+	}
+	return expectations, nil
+}
+
+// parseMaskRules parses a comma-separated list of column:mode[:keep] masking rules
+// Example: "ssn:format:4,card_number:partial:4,notes:drop"
+func parseMaskRules(spec string) ([]MaskRule, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	var rules []MaskRule
+	for _, entry := range strings.Split(spec, ",") {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("expected column:mode[:keep], got %q", entry)
+		}
+		rule := MaskRule{Column: parts[0], Mode: parts[1]}
+		if len(parts) == 3 {
+			keep, err := strconv.Atoi(parts[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid keep count in %q: %w", entry, err)
+			}
+			rule.Keep = keep
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseSortKeys parses SORT_BY entries of the form column[:asc|desc[:string|number]]
+func parseSortKeys(spec string) ([]SortKey, error) {
+	var keys []SortKey
+	for _, entry := range splitList(spec) {
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("expected column[:asc|desc[:string|number]], got %q", entry)
+		}
+		key := SortKey{Column: parts[0]}
+		if len(parts) > 1 {
+			key.Order = parts[1]
+		}
+		if len(parts) > 2 {
+			key.Type = parts[2]
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// resolveSecret returns value, or the trimmed contents of file when a file path is given
+func resolveSecret(value, file string) (string, error) {
+	if file == "" {
+		return value, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
+
+// getSecretEnv returns the secret in key, or the contents of the file named by key_FILE
+// (e.g. a Docker or Kubernetes secret mount), which takes precedence
+// QueueCredentials reads the current queue username and password, which a secrets
+// provider may have rotated since the configuration was loaded
+func QueueCredentials() (string, string, error) {
+	username, err := getSecretEnv("QUEUE_USERNAME")
+	if err != nil {
+		return "", "", err
+	}
+	password, err := getSecretEnv("QUEUE_PASSWORD")
+	if err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+func getSecretEnv(key string) (string, error) {
+	value := os.Getenv(key)
+	recordSetting(key, value, value != "", true)
+	value, err := resolveSecret(value, getEnv(key+"_FILE", ""))
+	if err != nil {
+		return "", fmt.Errorf("invalid %s_FILE: %w", key, err)
+	}
+	return value, nil
 }
 
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
+		recordSetting(key, value, true, false)
 		return value
 	}
+	recordSetting(key, defaultValue, false, false)
 	return defaultValue
 }
 
@@ -193,9 +1645,11 @@ func getBoolEnv(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		parsed, err := strconv.ParseBool(value)
 		if err == nil {
+			recordSetting(key, value, true, false)
 			return parsed
 		}
 	}
+	recordSetting(key, strconv.FormatBool(defaultValue), false, false)
 	return defaultValue
 }
 
@@ -203,18 +1657,90 @@ func getDurationEnv(key string, defaultValue int) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		parsed, err := strconv.Atoi(value)
 		if err == nil {
+			recordSetting(key, value, true, false)
 			return time.Duration(parsed)
 		}
 	}
+	recordSetting(key, strconv.Itoa(defaultValue), false, false)
 	return time.Duration(defaultValue)
 }
 
+// getIntervalEnv reads a Go duration string such as "500ms" or "2m"; a bare number
+// is taken as whole seconds so existing *_SECONDS values keep their meaning
+func getIntervalEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := parseInterval(value)
+		if err == nil {
+			recordSetting(key, value, true, false)
+			return parsed
+		}
+	}
+	recordSetting(key, defaultValue.String(), false, false)
+	return defaultValue
+}
+
+// parseInterval parses a Go duration string, or a bare number of whole seconds
+func parseInterval(value string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// getByteSizeEnv reads a size in bytes, with an optional KB, MB, GB or TB suffix
+func getByteSizeEnv(key string, defaultValue int64) int64 {
+	if value := os.Getenv(key); value != "" {
+		parsed, err := parseByteSize(value)
+		if err == nil {
+			recordSetting(key, value, true, false)
+			return parsed
+		}
+	}
+	recordSetting(key, strconv.FormatInt(defaultValue, 10), false, false)
+	return defaultValue
+}
+
+// byteSizeUnits are the size suffixes parseByteSize accepts, longest first
+var byteSizeUnits = []struct {
+	suffix     string
+	multiplier int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseByteSize parses a whole number of bytes, optionally followed by a binary unit
+// such as 512KB or 2GB (case-insensitive)
+func parseByteSize(value string) (int64, error) {
+	number, multiplier := strings.TrimSpace(value), int64(1)
+	upper := strings.ToUpper(number)
+	for _, unit := range byteSizeUnits {
+		if strings.HasSuffix(upper, unit.suffix) {
+			number, multiplier = strings.TrimSpace(number[:len(number)-len(unit.suffix)]), unit.multiplier
+			break
+		}
+	}
+	parsed, err := strconv.ParseInt(number, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q (use bytes or a KB, MB, GB or TB suffix)", value)
+	}
+	if parsed > math.MaxInt64/multiplier || parsed < math.MinInt64/multiplier {
+		return 0, fmt.Errorf("size %q is too large", value)
+	}
+	return parsed * multiplier, nil
+}
+
 func getIntEnv(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
 		parsed, err := strconv.Atoi(value)
 		if err == nil {
+			recordSetting(key, value, true, false)
 			return parsed
 		}
 	}
+	recordSetting(key, strconv.Itoa(defaultValue), false, false)
 	return defaultValue
 }
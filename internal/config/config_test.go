@@ -1,9 +1,17 @@
 package config
 
 import (
+	"encoding/base64"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"csv2json/internal/parser"
+	"csv2json/internal/quality"
 )
 
 // TestLoadDefaultConfig validates default configuration values
@@ -171,6 +179,8 @@ func TestValidateFileSuffixFilter(t *testing.T) {
 		{"single suffix", ".csv", []string{".csv"}},
 		{"multiple suffixes", ".csv,.txt,.dat", []string{".csv", ".txt", ".dat"}},
 		{"with spaces", ".csv, .txt, .dat", []string{".csv", ".txt", ".dat"}},
+		{"missing dots", "csv,txt", []string{".csv", ".txt"}},
+		{"empty entries", ".csv,,", []string{".csv"}},
 	}
 
 	for _, tc := range testCases {
@@ -232,23 +242,28 @@ func TestValidateFilenamePattern(t *testing.T) {
 }
 
 // TestValidateOutputType validates output type enum
-// ADR-003: Only "file" or "queue" allowed
+// ADR-003: "file" or "queue", plus the "stdout" and "pipe" stream outputs
 func TestValidateOutputType(t *testing.T) {
 	testCases := []struct {
 		name        string
 		outputType  string
+		pipe        string
 		shouldError bool
 	}{
-		{"file", "file", false},
-		{"queue", "queue", false},
-		{"invalid", "invalid", true},
-		{"whitespace", "   ", true},
+		{"file", "file", "", false},
+		{"queue", "queue", "", false},
+		{"stdout", "stdout", "", false},
+		{"pipe", "pipe", "/run/csv2json/out.fifo", false},
+		{"pipe without path", "pipe", "", true},
+		{"invalid", "invalid", "", true},
+		{"whitespace", "   ", "", true},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			os.Clearenv()
 			os.Setenv("OUTPUT_TYPE", tc.outputType)
+			os.Setenv("OUTPUT_PIPE", tc.pipe)
 
 			// For queue type, need valid queue config
 			if tc.outputType == "queue" {
@@ -386,3 +401,2611 @@ func TestConfigFailFast(t *testing.T) {
 		t.Error("Expected clear error message, got empty string")
 	}
 }
+
+// TestValidateEncoding validates ENCODING values including auto-detection
+func TestValidateEncoding(t *testing.T) {
+	testCases := []struct {
+		name        string
+		encoding    string
+		shouldError bool
+	}{
+		{"utf-8", "utf-8", false},
+		{"auto", "auto", false},
+		{"utf-16", "utf-16", false},
+		{"windows-1252", "windows-1252", false},
+		{"unsupported", "ebcdic", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("ENCODING", tc.encoding)
+
+			_, err := Load()
+
+			if tc.shouldError && err == nil {
+				t.Errorf("Expected error for encoding %s, got success", tc.encoding)
+			}
+
+			if !tc.shouldError && err != nil {
+				t.Errorf("Expected success for encoding %s, got error: %v", tc.encoding, err)
+			}
+		})
+	}
+}
+
+// TestValidateEmptyFilePolicy validates EMPTY_FILE_POLICY values
+func TestValidateEmptyFilePolicy(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      string
+		shouldError bool
+	}{
+		{"fail", "fail", false},
+		{"emitEmptyArray", "emitEmptyArray", false},
+		{"ignore", "ignore", false},
+		{"unsupported", "skip", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("EMPTY_FILE_POLICY", tc.policy)
+
+			cfg, err := Load()
+
+			if tc.shouldError && err == nil {
+				t.Errorf("Expected error for policy %s, got success", tc.policy)
+			}
+
+			if !tc.shouldError && err != nil {
+				t.Errorf("Expected success for policy %s, got error: %v", tc.policy, err)
+			}
+
+			if !tc.shouldError && cfg.EmptyFilePolicy != tc.policy {
+				t.Errorf("Expected EmptyFilePolicy '%s', got '%s'", tc.policy, cfg.EmptyFilePolicy)
+			}
+		})
+	}
+}
+
+// TestParseMaskColumns validates MASK_COLUMNS parsing
+func TestParseMaskColumns(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("MASK_COLUMNS", "ssn:format:4, card:partial, notes:drop")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	expected := []MaskRule{
+		{Column: "ssn", Mode: "format", Keep: 4},
+		{Column: "card", Mode: "partial"},
+		{Column: "notes", Mode: "drop"},
+	}
+	if len(cfg.MaskRules) != len(expected) {
+		t.Fatalf("Expected %d mask rules, got %d", len(expected), len(cfg.MaskRules))
+	}
+	for i, rule := range expected {
+		if cfg.MaskRules[i] != rule {
+			t.Errorf("Expected rule[%d] %+v, got %+v", i, rule, cfg.MaskRules[i])
+		}
+	}
+
+	os.Setenv("MASK_COLUMNS", "ssn")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for rule without mode, got success")
+	}
+}
+
+// TestHashSaltFromFile validates HASH_SALT_FILE takes precedence over HASH_SALT
+func TestHashSaltFromFile(t *testing.T) {
+	saltFile := filepath.Join(t.TempDir(), "salt")
+	if err := os.WriteFile(saltFile, []byte("file-salt\n"), 0600); err != nil {
+		t.Fatalf("Failed to write salt file: %v", err)
+	}
+
+	os.Clearenv()
+	os.Setenv("HASH_COLUMNS", "customer_id, email")
+	os.Setenv("HASH_SALT", "env-salt")
+	os.Setenv("HASH_SALT_FILE", saltFile)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	if len(cfg.HashColumns) != 2 || cfg.HashColumns[1] != "email" {
+		t.Errorf("Expected HashColumns [customer_id email], got %v", cfg.HashColumns)
+	}
+	if cfg.HashSalt != "file-salt" {
+		t.Errorf("Expected salt 'file-salt' from file, got '%s'", cfg.HashSalt)
+	}
+
+	os.Setenv("HASH_SALT_FILE", filepath.Join(t.TempDir(), "missing"))
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for missing salt file, got success")
+	}
+
+	os.Unsetenv("HASH_SALT_FILE")
+	os.Unsetenv("HASH_SALT")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for HASH_COLUMNS without a salt, got success")
+	}
+}
+
+// TestQueueCredentialsFromFile validates QUEUE_USERNAME_FILE and QUEUE_PASSWORD_FILE take precedence over the plain variables
+func TestQueueCredentialsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "queue_password")
+	if err := os.WriteFile(passwordFile, []byte("s3cret\r\n"), 0600); err != nil {
+		t.Fatalf("Failed to write password file: %v", err)
+	}
+
+	os.Clearenv()
+	os.Setenv("QUEUE_USERNAME", "csv2json")
+	os.Setenv("QUEUE_PASSWORD", "env-password")
+	os.Setenv("QUEUE_PASSWORD_FILE", passwordFile)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.QueueUsername != "csv2json" {
+		t.Errorf("Expected username 'csv2json' from env, got '%s'", cfg.QueueUsername)
+	}
+	if cfg.QueuePassword != "s3cret" {
+		t.Errorf("Expected password 's3cret' from file, got '%s'", cfg.QueuePassword)
+	}
+
+	os.Setenv("QUEUE_USERNAME_FILE", filepath.Join(dir, "missing"))
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "QUEUE_USERNAME_FILE") {
+		t.Errorf("Expected QUEUE_USERNAME_FILE error for missing file, got: %v", err)
+	}
+}
+
+// TestLoadSecrets validates secrets provider settings are required per provider
+func TestLoadSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{"disabled", map[string]string{}, ""},
+		{"vault", map[string]string{"SECRETS_PROVIDER": "vault", "SECRETS_PATH": "secret/data/app", "VAULT_ADDR": "http://vault:8200", "VAULT_TOKEN": "t"}, ""},
+		{"vault without token", map[string]string{"SECRETS_PROVIDER": "vault", "SECRETS_PATH": "secret/data/app", "VAULT_ADDR": "http://vault:8200"}, "VAULT_TOKEN"},
+		{"missing path", map[string]string{"SECRETS_PROVIDER": "aws"}, "SECRETS_PATH"},
+		{"aws without region", map[string]string{"SECRETS_PROVIDER": "aws", "SECRETS_PATH": "app", "AWS_ACCESS_KEY_ID": "a", "AWS_SECRET_ACCESS_KEY": "b"}, "AWS_REGION"},
+		{"azure managed identity", map[string]string{"SECRETS_PROVIDER": "azure", "SECRETS_PATH": "app", "AZURE_KEYVAULT_URL": "https://kv.vault.azure.net"}, ""},
+		{"azure partial client credentials", map[string]string{"SECRETS_PROVIDER": "azure", "SECRETS_PATH": "app", "AZURE_KEYVAULT_URL": "https://kv.vault.azure.net", "AZURE_TENANT_ID": "t"}, "AZURE_CLIENT_SECRET"},
+		{"unknown provider", map[string]string{"SECRETS_PROVIDER": "gcp", "SECRETS_PATH": "app"}, "invalid SECRETS_PROVIDER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.env {
+				os.Setenv(key, value)
+			}
+			_, err := LoadSecrets()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Expected success, got error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestLoadExpectedArrival validates EXPECTED_ARRIVAL is parsed into a schedule
+func TestLoadExpectedArrival(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    bool
+		wantErr bool
+	}{
+		{"unset", "", false, false},
+		{"daily", "daily by 06:00 UTC", true, false},
+		{"invalid", "every morning", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("EXPECTED_ARRIVAL", tt.spec)
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %t, got: %v", tt.wantErr, err)
+			}
+			if err == nil && (cfg.ExpectedArrival != nil) != tt.want {
+				t.Errorf("Expected schedule %t, got %v", tt.want, cfg.ExpectedArrival)
+			}
+		})
+	}
+}
+
+// TestValidateReportDestination validates report folders and queue destinations
+func TestValidateReportDestination(t *testing.T) {
+	tests := []struct {
+		destination string
+		wantErr     bool
+	}{
+		{"/data/reports", false},
+		{"rabbitmq://ingest_reports", false},
+		{"kafka://ingest_reports", true},
+		{"rabbitmq://", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.destination, func(t *testing.T) {
+			if err := validateReportDestination(tt.destination); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %t, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestLoadParseWorkers validates the parallel parsing settings
+func TestLoadParseWorkers(t *testing.T) {
+	tests := []struct {
+		name        string
+		workers     string
+		minMB       string
+		wantWorkers int
+		wantMin     int64
+		wantErr     bool
+	}{
+		{"defaults", "", "", 1, 64 << 20, false},
+		{"parallel", "8", "16", 8, 16 << 20, false},
+		{"zero workers", "0", "", 0, 0, true},
+		{"negative minimum", "4", "-1", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("PARSE_WORKERS", tt.workers)
+			os.Setenv("PARALLEL_PARSE_MIN_MB", tt.minMB)
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %t, got: %v", tt.wantErr, err)
+			}
+			if err == nil && (cfg.ParseWorkers != tt.wantWorkers || cfg.ParallelParseMin != tt.wantMin) {
+				t.Errorf("Expected %d workers from %d bytes, got %d from %d", tt.wantWorkers, tt.wantMin, cfg.ParseWorkers, cfg.ParallelParseMin)
+			}
+		})
+	}
+}
+
+// TestLoadAlerts validates alert events, limits and email settings
+func TestLoadAlerts(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		enabled bool
+		wantErr string
+	}{
+		{"disabled", map[string]string{}, false, ""},
+		{"slack", map[string]string{"ALERT_SLACK_WEBHOOK_URL": "https://hooks.slack.com/services/x"}, true, ""},
+		{"email", map[string]string{"ALERT_SMTP_HOST": "smtp.example.com", "ALERT_EMAIL_FROM": "csv2json@example.com", "ALERT_EMAIL_TO": "ops@example.com"}, true, ""},
+		{"email without recipients", map[string]string{"ALERT_SMTP_HOST": "smtp.example.com", "ALERT_EMAIL_FROM": "csv2json@example.com"}, false, "ALERT_EMAIL_TO"},
+		{"unknown event", map[string]string{"ALERT_WEBHOOK_URL": "https://alerts.example.com", "ALERT_EVENTS": "file_failed,disk_full"}, false, "ALERT_EVENTS"},
+		{"negative rate limit", map[string]string{"ALERT_WEBHOOK_URL": "https://alerts.example.com", "ALERT_RATE_LIMIT_SECONDS": "-1"}, false, "ALERT_RATE_LIMIT_SECONDS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for key, value := range tt.env {
+				os.Setenv(key, value)
+			}
+			cfg, err := LoadAlerts()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected success, got error: %v", err)
+			}
+			if cfg.Enabled() != tt.enabled {
+				t.Errorf("Expected enabled=%t, got %t", tt.enabled, cfg.Enabled())
+			}
+			if len(cfg.Events) != 4 || cfg.RateLimit != 5*time.Minute {
+				t.Errorf("Expected all events and a 5m rate limit by default, got %v and %v", cfg.Events, cfg.RateLimit)
+			}
+		})
+	}
+}
+
+// TestLoadInputFormat validates INPUT_FORMAT and FIXED_WIDTH_COLUMNS are parsed and checked together
+func TestLoadInputFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		layout  string
+		wantErr bool
+	}{
+		{"default delimited", "", "", false},
+		{"whitespace", "whitespace", "", false},
+		{"fixed-width", "fixed-width", "id:6,name:20", false},
+		{"fixed-width without layout", "fixed-width", "", true},
+		{"layout without fixed-width", "delimited", "6,20", true},
+		{"bad width", "fixed-width", "id:0", true},
+		{"unsupported format", "xml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("INPUT_FORMAT", tt.format)
+			os.Setenv("FIXED_WIDTH_COLUMNS", tt.layout)
+
+			cfg, err := Load()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error=%t, got: %v", tt.wantErr, err)
+			}
+			if err == nil && tt.layout != "" && cfg.FixedWidthColumns[1].Width != 20 {
+				t.Errorf("Expected second column width 20, got %+v", cfg.FixedWidthColumns)
+			}
+		})
+	}
+}
+
+// TestParseLookupTables validates LOOKUP_TABLES parsing
+func TestParseLookupTables(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LOOKUP_TABLES", "store_id=./reference/stores.csv, sku=./reference/products.csv")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	if len(cfg.Lookups) != 2 {
+		t.Fatalf("Expected 2 lookup tables, got %d", len(cfg.Lookups))
+	}
+	if cfg.Lookups[1].Key != "sku" || cfg.Lookups[1].Path != "./reference/products.csv" {
+		t.Errorf("Unexpected lookup table: %+v", cfg.Lookups[1])
+	}
+
+	os.Setenv("LOOKUP_TABLES", "./reference/stores.csv")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for lookup without key, got success")
+	}
+}
+
+// TestParseGroupBy validates group-by nesting settings
+func TestParseGroupBy(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("GROUP_BY", "order_id")
+	os.Setenv("GROUP_PARENT_COLUMNS", "order_id, customer")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	if len(cfg.GroupBy) != 1 || cfg.GroupBy[0] != "order_id" {
+		t.Errorf("Expected GroupBy [order_id], got %v", cfg.GroupBy)
+	}
+	if len(cfg.GroupParentCols) != 2 || cfg.GroupParentCols[1] != "customer" {
+		t.Errorf("Expected GroupParentCols [order_id customer], got %v", cfg.GroupParentCols)
+	}
+	if cfg.GroupChildKey != "items" {
+		t.Errorf("Expected default GroupChildKey 'items', got '%s'", cfg.GroupChildKey)
+	}
+}
+
+// TestValidatePartitionPlaceholder validates that destination templates require PARTITION_BY
+func TestValidatePartitionPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	os.Clearenv()
+	os.Setenv("OUTPUT_FOLDER", filepath.Join(dir, "{partition}"))
+
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for partition placeholder without PARTITION_BY, got success")
+	}
+
+	os.Setenv("PARTITION_BY", "country")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.PartitionBy != "country" {
+		t.Errorf("Expected PartitionBy 'country', got '%s'", cfg.PartitionBy)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "{partition}")); !os.IsNotExist(err) {
+		t.Error("Expected templated output folder not to be created at startup")
+	}
+}
+
+// TestParseSortBy validates SORT_BY column, order and type parsing
+func TestParseSortBy(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SORT_BY", "region, amount:desc:number")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	if len(cfg.SortBy) != 2 {
+		t.Fatalf("Expected 2 sort keys, got %d", len(cfg.SortBy))
+	}
+	if cfg.SortBy[0].Column != "region" || cfg.SortBy[0].Order != "" {
+		t.Errorf("Unexpected first sort key: %+v", cfg.SortBy[0])
+	}
+	expected := SortKey{Column: "amount", Order: "desc", Type: "number"}
+	if cfg.SortBy[1] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cfg.SortBy[1])
+	}
+	if cfg.SortMemoryRows != 100000 {
+		t.Errorf("Expected default SortMemoryRows 100000, got %d", cfg.SortMemoryRows)
+	}
+}
+
+// TestParseSampling validates sampling settings from environment
+func TestParseSampling(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("SAMPLE_ROWS", "50")
+	os.Setenv("SAMPLE_MODE", "random")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.SampleRows != 50 || cfg.SampleMode != "random" {
+		t.Errorf("Expected 50 random sample rows, got %d %s", cfg.SampleRows, cfg.SampleMode)
+	}
+}
+
+// TestValidateKafkaSettings validates Kafka key and partition templates are rejected
+// until Kafka output is supported, rather than silently ignored
+func TestValidateKafkaSettings(t *testing.T) {
+	for _, key := range []string{"KAFKA_MESSAGE_KEY", "KAFKA_PARTITION"} {
+		os.Clearenv()
+		os.Setenv(key, "{col:customer_id}")
+		_, err := Load()
+		if err == nil || !strings.Contains(err.Error(), "Kafka output not supported yet") {
+			t.Errorf("Expected %s to be rejected as unsupported, got: %v", key, err)
+		}
+	}
+}
+
+// TestLoadRoutesKafka validates route Kafka templates are rejected until Kafka output
+// is supported
+func TestLoadRoutesKafka(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatalf("Failed to create input dir: %v", err)
+	}
+	path := filepath.Join(dir, "routes.json")
+	content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+		"input": {"path": "` + filepath.ToSlash(input) + `"},
+		"output": {"type": "queue", "destination": "rabbitmq://orders", "kafka": {"messageKey": "{col:customer_id}"}},
+		"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write routes config: %v", err)
+	}
+
+	os.Clearenv()
+	_, err := LoadRoutes(path)
+	if err == nil || !strings.Contains(err.Error(), "Kafka output not supported yet") {
+		t.Errorf("Expected output.kafka to be rejected as unsupported, got: %v", err)
+	}
+}
+
+// TestValidateExchangeType validates RabbitMQ exchange types
+func TestValidateExchangeType(t *testing.T) {
+	for _, exchangeType := range []string{"topic", "direct", "fanout", "headers"} {
+		os.Clearenv()
+		os.Setenv("RABBITMQ_EXCHANGE_TYPE", exchangeType)
+		if _, err := Load(); err != nil {
+			t.Errorf("Expected exchange type %s to be valid, got error: %v", exchangeType, err)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("RABBITMQ_EXCHANGE_TYPE", "x-delayed")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unsupported exchange type, got success")
+	}
+}
+
+// TestValidateSharding validates sharded queue output settings
+func TestValidateSharding(t *testing.T) {
+	testCases := []struct {
+		name        string
+		env         map[string]string
+		expectError bool
+	}{
+		{"round-robin", map[string]string{"QUEUE_SHARDS": "orders.0, orders.1"}, false},
+		{"hash", map[string]string{"QUEUE_SHARDS": "orders.0,orders.1", "SHARD_STRATEGY": "hash", "SHARD_COLUMN": "account_id"}, false},
+		{"hash without column", map[string]string{"QUEUE_SHARDS": "orders.0,orders.1", "SHARD_STRATEGY": "hash"}, true},
+		{"unknown strategy", map[string]string{"QUEUE_SHARDS": "orders.0,orders.1", "SHARD_STRATEGY": "random"}, true},
+		{"partitioned shard", map[string]string{"QUEUE_SHARDS": "orders.{partition}", "PARTITION_BY": "region"}, true},
+		{"routing key", map[string]string{"QUEUE_SHARDS": "orders.0,orders.1", "RABBITMQ_EXCHANGE": "ingest", "RABBITMQ_ROUTING_KEY": "{route}"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("OUTPUT_TYPE", "queue")
+			for key, value := range tc.env {
+				os.Setenv(key, value)
+			}
+			cfg, err := Load()
+			if (err != nil) != tc.expectError {
+				t.Fatalf("Expected error=%v, got %v", tc.expectError, err)
+			}
+			if err == nil && (cfg.QueueName != "orders.0" || len(cfg.QueueShards) != 2) {
+				t.Errorf("Expected queue name orders.0 and 2 shards, got %q %v", cfg.QueueName, cfg.QueueShards)
+			}
+		})
+	}
+}
+
+// TestValidatePayloadEncryption validates payload encryption key settings
+func TestValidatePayloadEncryption(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	keyFile := filepath.Join(t.TempDir(), "payload.key")
+	if err := os.WriteFile(keyFile, []byte(key+"\n"), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	testCases := []struct {
+		name        string
+		env         map[string]string
+		expectError bool
+	}{
+		{"disabled", map[string]string{}, false},
+		{"key and ID", map[string]string{"PAYLOAD_ENCRYPTION_KEY": key, "PAYLOAD_ENCRYPTION_KEY_ID": "2024-01"}, false},
+		{"key file", map[string]string{"PAYLOAD_ENCRYPTION_KEY_FILE": keyFile, "PAYLOAD_ENCRYPTION_KEY_ID": "2024-01"}, false},
+		{"missing key ID", map[string]string{"PAYLOAD_ENCRYPTION_KEY": key}, true},
+		{"short key", map[string]string{"PAYLOAD_ENCRYPTION_KEY": base64.StdEncoding.EncodeToString(make([]byte, 10)), "PAYLOAD_ENCRYPTION_KEY_ID": "k"}, true},
+		{"not base64", map[string]string{"PAYLOAD_ENCRYPTION_KEY": "secret!", "PAYLOAD_ENCRYPTION_KEY_ID": "k"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tc.env {
+				os.Setenv(k, v)
+			}
+			cfg, err := Load()
+			if (err != nil) != tc.expectError {
+				t.Fatalf("Expected error=%v, got %v", tc.expectError, err)
+			}
+			if err == nil && len(tc.env) > 0 && cfg.PayloadEncryptionKey != key {
+				t.Errorf("Expected key to be resolved, got %q", cfg.PayloadEncryptionKey)
+			}
+		})
+	}
+}
+
+// TestValidateMessageSigning validates message signing settings
+func TestValidateMessageSigning(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	testCases := []struct {
+		name        string
+		env         map[string]string
+		expectError bool
+	}{
+		{"disabled", map[string]string{}, false},
+		{"hmac default", map[string]string{"MESSAGE_SIGNING_KEY": key, "MESSAGE_SIGNING_KEY_ID": "k1"}, false},
+		{"ed25519", map[string]string{"MESSAGE_SIGNING_KEY": key, "MESSAGE_SIGNING_KEY_ID": "k1", "MESSAGE_SIGNING_ALGORITHM": "ed25519"}, false},
+		{"missing key ID", map[string]string{"MESSAGE_SIGNING_KEY": key}, true},
+		{"unknown algorithm", map[string]string{"MESSAGE_SIGNING_KEY": key, "MESSAGE_SIGNING_KEY_ID": "k1", "MESSAGE_SIGNING_ALGORITHM": "rsa"}, true},
+		{"short HMAC key", map[string]string{"MESSAGE_SIGNING_KEY": base64.StdEncoding.EncodeToString(make([]byte, 8)), "MESSAGE_SIGNING_KEY_ID": "k1"}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tc.env {
+				os.Setenv(k, v)
+			}
+			if _, err := Load(); (err != nil) != tc.expectError {
+				t.Errorf("Expected error=%v, got %v", tc.expectError, err)
+			}
+		})
+	}
+}
+
+// TestApplyTenant validates tenant scoping of queue names, output folders and report destinations
+func TestApplyTenant(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		name           string
+		env            map[string]string
+		expectError    bool
+		expectedTenant string
+	}{
+		{"none", map[string]string{}, false, ""},
+		{"explicit", map[string]string{"TENANT": "acme"}, false, "acme"},
+		{"from folder", map[string]string{"TENANT_FROM": "folder"}, false, "globex"},
+		{"from route outside routes mode", map[string]string{"TENANT_FROM": "route"}, true, ""},
+		{"unsafe name", map[string]string{"TENANT": "../acme"}, true, ""},
+		{"unknown source", map[string]string{"TENANT_FROM": "header"}, true, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("INPUT_FOLDER", filepath.Join(dir, "input", "globex"))
+			os.Setenv("OUTPUT_FOLDER", filepath.Join(dir, "output"))
+			os.Setenv("OUTPUT_TYPE", "both")
+			os.Setenv("QUEUE_NAME", "orders")
+			os.Setenv("REPORT_DESTINATION", "rabbitmq://reports")
+			for k, v := range tc.env {
+				os.Setenv(k, v)
+			}
+			cfg, err := Load()
+			if (err != nil) != tc.expectError {
+				t.Fatalf("Expected error=%v, got %v", tc.expectError, err)
+			}
+			if err != nil {
+				return
+			}
+
+			queue, folder, report := "orders", filepath.Join(dir, "output"), "rabbitmq://reports"
+			if tc.expectedTenant != "" {
+				queue = tc.expectedTenant + ".orders"
+				folder = filepath.Join(folder, tc.expectedTenant)
+				report = "rabbitmq://" + tc.expectedTenant + ".reports"
+			}
+			if cfg.Tenant != tc.expectedTenant || cfg.QueueName != queue || cfg.OutputFolder != folder || cfg.ReportDestination != report {
+				t.Errorf("Unexpected scoping: tenant=%q queue=%q folder=%q report=%q", cfg.Tenant, cfg.QueueName, cfg.OutputFolder, cfg.ReportDestination)
+			}
+			if _, err := os.Stat(folder); err != nil {
+				t.Errorf("Expected output folder %s to be created: %v", folder, err)
+			}
+		})
+	}
+}
+
+// TestLoadReceiptSettings validates delivery receipt and publisher confirm settings
+func TestLoadReceiptSettings(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.ReceiptLog != "" || cfg.PublisherConfirms || cfg.PublishConfirmTimeout != 30*time.Second {
+		t.Errorf("Unexpected receipt defaults: log=%q confirms=%v timeout=%v", cfg.ReceiptLog, cfg.PublisherConfirms, cfg.PublishConfirmTimeout)
+	}
+
+	os.Clearenv()
+	os.Setenv("RECEIPT_LOG", "/var/log/csv2json/receipts.ndjson")
+	os.Setenv("PUBLISHER_CONFIRMS", "true")
+	os.Setenv("PUBLISH_CONFIRM_TIMEOUT_SECONDS", "5")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.ReceiptLog != "/var/log/csv2json/receipts.ndjson" || !cfg.PublisherConfirms || cfg.PublishConfirmTimeout != 5*time.Second {
+		t.Errorf("Unexpected receipt settings: log=%q confirms=%v timeout=%v", cfg.ReceiptLog, cfg.PublisherConfirms, cfg.PublishConfirmTimeout)
+	}
+}
+
+// TestLoadBrokerConnection validates lazy connection, heartbeat and reconnect settings
+func TestLoadBrokerConnection(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.QueueLazyConnect || cfg.QueueHeartbeat != 10*time.Second || cfg.QueueConnectRetries != 3 || cfg.QueueConnectRetryDelay != time.Second {
+		t.Errorf("Unexpected connection defaults: lazy=%v heartbeat=%v retries=%d delay=%v", cfg.QueueLazyConnect, cfg.QueueHeartbeat, cfg.QueueConnectRetries, cfg.QueueConnectRetryDelay)
+	}
+
+	os.Setenv("QUEUE_LAZY_CONNECT", "true")
+	os.Setenv("QUEUE_HEARTBEAT", "30s")
+	os.Setenv("QUEUE_CONNECT_RETRIES", "0")
+	os.Setenv("QUEUE_CONNECT_RETRY_DELAY", "250ms")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !cfg.QueueLazyConnect || cfg.QueueHeartbeat != 30*time.Second || cfg.QueueConnectRetries != 0 || cfg.QueueConnectRetryDelay != 250*time.Millisecond {
+		t.Errorf("Unexpected connection settings: lazy=%v heartbeat=%v retries=%d delay=%v", cfg.QueueLazyConnect, cfg.QueueHeartbeat, cfg.QueueConnectRetries, cfg.QueueConnectRetryDelay)
+	}
+
+	for key, value := range map[string]string{"QUEUE_HEARTBEAT": "-1s", "QUEUE_CONNECT_RETRIES": "-1", "QUEUE_CONNECT_RETRY_DELAY": "-5s"} {
+		os.Clearenv()
+		os.Setenv(key, value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for %s=%s, got success", key, value)
+		}
+	}
+
+	// Routes inherit the environment unless they override lazyConnect
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+		"input": {"path": "` + filepath.ToSlash(dir) + `"},
+		"output": {"type": "queue", "destination": "orders", "lazyConnect": false},
+		"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+	if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write routes config: %v", err)
+	}
+	os.Clearenv()
+	os.Setenv("QUEUE_LAZY_CONNECT", "true")
+	os.Setenv("QUEUE_HEARTBEAT", "5s")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.QueueLazyConnect || cfg.QueueHeartbeat != 5*time.Second {
+		t.Errorf("Expected route override and inherited heartbeat, got lazy=%v heartbeat=%v", cfg.QueueLazyConnect, cfg.QueueHeartbeat)
+	}
+}
+
+// TestRoutePayloadFormat validates output.payloadFormat, its includeEnvelope and contentType fallbacks and conflicts
+func TestRoutePayloadFormat(t *testing.T) {
+	tests := []struct {
+		output  string
+		want    string
+		wantErr bool
+	}{
+		{output: ``, want: PayloadFormatEnvelope},
+		{output: `, "includeEnvelope": false`, want: PayloadFormatLegacy},
+		{output: `, "payloadFormat": "bare"`, want: PayloadFormatBare},
+		{output: `, "payloadFormat": "legacy"`, want: PayloadFormatLegacy},
+		{output: `, "payloadFormat": "raw"`, wantErr: true},
+		{output: `, "payloadFormat": "bare", "includeEnvelope": true`, wantErr: true},
+		{output: `, "contentType": "application/x-ndjson"`, want: PayloadFormatBare},
+		{output: `, "contentType": "application/avro", "payloadFormat": "envelope"`, wantErr: true},
+		{output: `, "contentType": "text/csv"`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		dir := t.TempDir()
+		routesPath := filepath.Join(dir, "routes.json")
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"output": {"type": "queue", "destination": "orders"` + tt.output + `},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+		os.Clearenv()
+		routes, err := LoadRoutes(routesPath)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("Expected error for output%s, got success", tt.output)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Expected successful load for output%s, got error: %v", tt.output, err)
+		}
+		if got := routes.Routes[0].Output.MessageFormat(); got != tt.want {
+			t.Errorf("output%s: expected payload format %s, got %s", tt.output, tt.want, got)
+		}
+	}
+}
+
+// TestLoadDownstreamAck validates downstream acknowledgment settings and timeout validation
+func TestLoadDownstreamAck(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.DownstreamAck || cfg.DownstreamAckQueue != "" || cfg.DownstreamAckTimeout != 300*time.Second {
+		t.Errorf("Unexpected defaults: ack=%v queue=%q timeout=%v", cfg.DownstreamAck, cfg.DownstreamAckQueue, cfg.DownstreamAckTimeout)
+	}
+
+	os.Clearenv()
+	os.Setenv("DOWNSTREAM_ACK", "true")
+	os.Setenv("DOWNSTREAM_ACK_QUEUE", "csv2json.replies")
+	os.Setenv("DOWNSTREAM_ACK_TIMEOUT_SECONDS", "60")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !cfg.DownstreamAck || cfg.DownstreamAckQueue != "csv2json.replies" || cfg.DownstreamAckTimeout != time.Minute {
+		t.Errorf("Unexpected settings: ack=%v queue=%q timeout=%v", cfg.DownstreamAck, cfg.DownstreamAckQueue, cfg.DownstreamAckTimeout)
+	}
+
+	os.Setenv("DOWNSTREAM_ACK_TIMEOUT_SECONDS", "0")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for zero DOWNSTREAM_ACK_TIMEOUT_SECONDS")
+	}
+}
+
+// TestIgnoreReason validates the reason codes reported for files rejected by input filters
+func TestIgnoreReason(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("FILE_SUFFIX_FILTER", ".csv")
+	os.Setenv("FILENAME_PATTERN", "^orders_")
+	os.Setenv("FILENAME_EXCLUDE_PATTERN", "_partial\\.csv$")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	testCases := []struct {
+		filename string
+		expected string
+	}{
+		{"orders_2024.csv", ""},
+		{"orders_2024.txt", IgnoreReasonSuffixMismatch},
+		{"customers_2024.csv", IgnoreReasonPatternMismatch},
+		{"orders_2024_partial.csv", IgnoreReasonExcluded},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			reason, detail := cfg.IgnoreReason(tc.filename)
+			if reason != tc.expected {
+				t.Errorf("Expected reason %q, got %q (%s)", tc.expected, reason, detail)
+			}
+			if cfg.ShouldProcessFile(tc.filename) != (tc.expected == "") {
+				t.Errorf("ShouldProcessFile disagrees with reason %q", reason)
+			}
+		})
+	}
+
+	os.Clearenv()
+	os.Setenv("FILENAME_EXCLUDE_PATTERN", "[")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for invalid FILENAME_EXCLUDE_PATTERN, got success")
+	}
+
+	os.Clearenv()
+	os.Setenv("UNMATCHED_FILE_POLICY", "delete")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unsupported UNMATCHED_FILE_POLICY, got success")
+	}
+	os.Setenv("UNMATCHED_FILE_POLICY", UnmatchedFileSkip)
+	if cfg, err := Load(); err != nil || cfg.UnmatchedPolicy != UnmatchedFileSkip {
+		t.Errorf("Expected UNMATCHED_FILE_POLICY=skip to load, got %v", err)
+	}
+
+	os.Clearenv()
+	if cfg, err := Load(); err != nil || cfg.ChecksumPolicy != ChecksumPolicyOff {
+		t.Errorf("Expected CHECKSUM_POLICY to default to off, got %v", err)
+	}
+	os.Setenv("CHECKSUM_POLICY", "sha1")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unsupported CHECKSUM_POLICY, got success")
+	}
+	os.Setenv("CHECKSUM_POLICY", ChecksumPolicyRequire)
+	if cfg, err := Load(); err != nil || cfg.ChecksumPolicy != ChecksumPolicyRequire {
+		t.Errorf("Expected CHECKSUM_POLICY=require to load, got %v", err)
+	}
+}
+
+// TestProcessExisting validates the startup backfill settings, in env and routes mode
+func TestProcessExisting(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.ProcessExisting || cfg.ProcessExistingOrder != ProcessExistingOldest {
+		t.Errorf("Expected backfill to default to off, oldest first, got %t, %s", cfg.ProcessExisting, cfg.ProcessExistingOrder)
+	}
+	os.Setenv("PROCESS_EXISTING_ORDER", "random")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unsupported PROCESS_EXISTING_ORDER, got success")
+	}
+
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	route := func(name, input string) string {
+		return `{"name": "` + name + `", "ingestionContract": "` + name + `.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(filepath.Join(dir, name)) + `"` + input + `},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, name, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, name, "failed")) + `"}}`
+	}
+	content := `{"routes": [` + route("orders", "") + `, ` + route("products", `, "processExisting": false, "processExistingOrder": "name"`) + `]}`
+	if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write routes config: %v", err)
+	}
+	for _, name := range []string{"orders", "products"} {
+		if err := os.MkdirAll(filepath.Join(dir, name), 0755); err != nil {
+			t.Fatalf("Failed to create input folder: %v", err)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("PROCESS_EXISTING", "true")
+	os.Setenv("PROCESS_EXISTING_ORDER", ProcessExistingNewest)
+	routesConfig, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+
+	tests := []struct {
+		route         string
+		expectEnabled bool
+		expectOrder   string
+	}{
+		{route: "orders", expectEnabled: true, expectOrder: ProcessExistingNewest},
+		{route: "products", expectEnabled: false, expectOrder: ProcessExistingName},
+	}
+	for i, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			routeCfg := routesConfig.Routes[i].ToLegacyConfig()
+			if routeCfg.ProcessExisting != tt.expectEnabled || routeCfg.ProcessExistingOrder != tt.expectOrder {
+				t.Errorf("Expected %t, %s, got %t, %s", tt.expectEnabled, tt.expectOrder, routeCfg.ProcessExisting, routeCfg.ProcessExistingOrder)
+			}
+		})
+	}
+}
+
+// TestIgnoreReasonCaseInsensitive validates case-insensitive suffix and pattern matching, in env and routes mode
+func TestIgnoreReasonCaseInsensitive(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("FILE_SUFFIX_FILTER", "csv")
+	os.Setenv("FILENAME_PATTERN", "^orders_")
+	os.Setenv("FILENAME_EXCLUDE_PATTERN", "_partial")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if reason, _ := cfg.IgnoreReason("ORDERS_2024.CSV"); reason != IgnoreReasonSuffixMismatch {
+		t.Errorf("Expected case-sensitive suffix mismatch by default, got %q", reason)
+	}
+
+	os.Setenv("FILENAME_CASE_INSENSITIVE", "true")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	testCases := []struct {
+		filename string
+		expected string
+	}{
+		{"ORDERS_2024.CSV", ""},
+		{"Orders_2024.Csv", ""},
+		{"orders_2024.txt", IgnoreReasonSuffixMismatch},
+		{"CUSTOMERS.CSV", IgnoreReasonPatternMismatch},
+		{"ORDERS_2024_PARTIAL.CSV", IgnoreReasonExcluded},
+		{"CSV", IgnoreReasonSuffixMismatch},
+	}
+	for _, tc := range testCases {
+		if reason, detail := cfg.IgnoreReason(tc.filename); reason != tc.expected {
+			t.Errorf("%s: expected reason %q, got %q (%s)", tc.filename, tc.expected, reason, detail)
+		}
+	}
+
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+		"input": {"path": "` + filepath.ToSlash(dir) + `", "suffixFilter": "csv", "filenamePattern": "^orders_", "caseInsensitive": true},
+		"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+		"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+	if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write routes config: %v", err)
+	}
+	os.Clearenv()
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if reason, detail := routes.Routes[0].ToLegacyConfig().IgnoreReason("ORDERS_2024.CSV"); reason != "" {
+		t.Errorf("Expected route to accept ORDERS_2024.CSV, got %q (%s)", reason, detail)
+	}
+}
+
+// TestValidateClaims validates multi-instance claim settings
+func TestValidateClaims(t *testing.T) {
+	testCases := []struct {
+		name        string
+		instanceID  string
+		ttl         string
+		shouldError bool
+	}{
+		{"defaults", "", "", false},
+		{"explicit", "node-a", "60", false},
+		{"path separator", "node/a", "60", true},
+		{"ttl too short", "node-a", "1", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Clearenv()
+			os.Setenv("CLAIM_FILES", "true")
+			if tc.instanceID != "" {
+				os.Setenv("INSTANCE_ID", tc.instanceID)
+			}
+			if tc.ttl != "" {
+				os.Setenv("CLAIM_TTL_SECONDS", tc.ttl)
+			}
+			cfg, err := Load()
+			if tc.shouldError && err == nil {
+				t.Error("Expected error, got success")
+			}
+			if !tc.shouldError && err != nil {
+				t.Errorf("Expected success, got error: %v", err)
+			}
+			if !tc.shouldError && (cfg.InstanceID == "" || !cfg.ClaimFiles) {
+				t.Errorf("Expected claiming enabled with an instance ID, got %+v", cfg.InstanceID)
+			}
+		})
+	}
+}
+
+// TestValidateInstanceLock validates the instance lock cannot be combined with file claims
+func TestValidateInstanceLock(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INSTANCE_LOCK", "true")
+	cfg, err := Load()
+	if err != nil || !cfg.InstanceLock {
+		t.Fatalf("Expected instance lock enabled, got %v (%v)", cfg, err)
+	}
+
+	os.Setenv("CLAIM_FILES", "true")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for INSTANCE_LOCK with CLAIM_FILES, got success")
+	}
+}
+
+// TestLoadRoutesStartupPolicy validates failFast and skipInvalid handling of a route with a missing input path
+func TestLoadRoutesStartupPolicy(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatalf("Failed to create input dir: %v", err)
+	}
+
+	route := func(name, inputPath string) string {
+		return `{"name": "` + name + `", "ingestionContract": "` + name + `.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(inputPath) + `"},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}`
+	}
+	writeRoutes := func(policy string, routes ...string) string {
+		path := filepath.Join(dir, policy+"routes.json")
+		content := `{"startupPolicy": "` + policy + `", "routes": [` + strings.Join(routes, ",") + `]}`
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+		return path
+	}
+	good, bad := route("orders", input), route("missing", filepath.Join(dir, "nope"))
+
+	os.Clearenv()
+	if _, err := LoadRoutes(writeRoutes("failFast", good, bad)); err == nil {
+		t.Error("Expected failFast to reject the invalid route, got success")
+	}
+
+	cfg, err := LoadRoutes(writeRoutes("skipInvalid", good, bad))
+	if err != nil {
+		t.Fatalf("Expected skipInvalid to start the valid route, got error: %v", err)
+	}
+	if len(cfg.Routes) != 1 || cfg.Routes[0].Name != "orders" {
+		t.Errorf("Expected only route 'orders', got %+v", cfg.Routes)
+	}
+	if len(cfg.Skipped) != 1 || cfg.Skipped[0].Name != "missing" {
+		t.Errorf("Expected route 'missing' to be skipped, got %+v", cfg.Skipped)
+	}
+
+	if _, err := LoadRoutes(writeRoutes("skipInvalid", bad)); err == nil {
+		t.Error("Expected error when every route is invalid, got success")
+	}
+	if _, err := LoadRoutes(writeRoutes("bestEffort", good)); err == nil {
+		t.Error("Expected error for unsupported startup policy, got success")
+	}
+}
+
+// TestLoadRoutesTenant validates route tenants derived from the route name scope its destinations
+func TestLoadRoutesTenant(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatalf("Failed to create input dir: %v", err)
+	}
+	path := filepath.Join(dir, "routes.json")
+	content := `{"routes": [{"name": "acme", "ingestionContract": "orders.csv.v1", "tenantFrom": "route",
+		"input": {"path": "` + filepath.ToSlash(input) + `"},
+		"output": {"type": "queue", "destination": "rabbitmq://orders"},
+		"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write routes config: %v", err)
+	}
+
+	os.Clearenv()
+	routes, err := LoadRoutes(path)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	cfg := routes.Routes[0].ToLegacyConfig()
+	if cfg.Tenant != "acme" || cfg.QueueName != "acme.orders" {
+		t.Errorf("Expected tenant acme and queue acme.orders, got %q %q", cfg.Tenant, cfg.QueueName)
+	}
+}
+
+// TestLoadRoutesWatchMode validates per-route watch modes, their environment fallback and validation
+func TestLoadRoutesWatchMode(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	if err := os.MkdirAll(input, 0755); err != nil {
+		t.Fatalf("Failed to create input dir: %v", err)
+	}
+	route := func(name, input string) string {
+		return `{"name": "` + name + `", "ingestionContract": "` + name + `.csv.v1", "input": ` + input + `,
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}`
+	}
+	inputPath := `"path": "` + filepath.ToSlash(input) + `"`
+	writeRoutes := func(routes ...string) string {
+		path := filepath.Join(dir, "routes.json")
+		if err := os.WriteFile(path, []byte(`{"routes": [`+strings.Join(routes, ",")+`]}`), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+		return path
+	}
+
+	os.Clearenv()
+	os.Setenv("WATCH_MODE", "poll")
+	os.Setenv("POLL_INTERVAL_SECONDS", "30")
+	routes, err := LoadRoutes(writeRoutes(
+		route("inherited", "{"+inputPath+"}"),
+		route("hybrid", "{"+inputPath+`, "watchMode": "hybrid", "hybridPollIntervalSeconds": 120}`),
+	))
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	inherited, hybrid := routes.Routes[0].ToLegacyConfig(), routes.Routes[1].ToLegacyConfig()
+	if inherited.WatchMode != "poll" || inherited.PollInterval != 30*time.Second {
+		t.Errorf("Expected inherited poll mode every 30s, got %s every %v", inherited.WatchMode, inherited.PollInterval)
+	}
+	if hybrid.WatchMode != "hybrid" || hybrid.HybridPollInterval != 2*time.Minute {
+		t.Errorf("Expected hybrid mode with 2m backup polling, got %s every %v", hybrid.WatchMode, hybrid.HybridPollInterval)
+	}
+
+	if _, err := LoadRoutes(writeRoutes(route("typo", "{"+inputPath+`, "watchMode": "inotify"}`))); err == nil {
+		t.Error("Expected error for unsupported watch mode, got success")
+	}
+	if _, err := LoadRoutes(writeRoutes(route("negative", "{"+inputPath+`, "pollIntervalSeconds": -1}`))); err == nil {
+		t.Error("Expected error for negative poll interval, got success")
+	}
+
+	routes, err = LoadRoutes(writeRoutes(route("fast", "{"+inputPath+`, "pollIntervalSeconds": "250ms", "stabilityWindow": "0s"}`)))
+	if err != nil {
+		t.Fatalf("Expected successful load with duration strings, got error: %v", err)
+	}
+	if fast := routes.Routes[0].ToLegacyConfig(); fast.PollInterval != 250*time.Millisecond || fast.StabilityWindow != 0 {
+		t.Errorf("Expected 250ms polling with no stability wait, got %v and %v", fast.PollInterval, fast.StabilityWindow)
+	}
+	if inherited.StabilityWindow != 2*time.Second {
+		t.Errorf("Expected default stability window of 2s, got %v", inherited.StabilityWindow)
+	}
+
+	os.Clearenv()
+	os.Setenv("WATCH_MODE", "inotify")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unsupported WATCH_MODE, got success")
+	}
+}
+
+// TestLoadRoutesMissingInput validates the fail, create and wait policies for a missing input path
+func TestLoadRoutesMissingInput(t *testing.T) {
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(input, policy string) {
+		content := `{"routes": [{"name": "late", "ingestionContract": "late.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(input) + `", "missingPolicy": "` + policy + `"},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	os.Clearenv()
+
+	writeRoute(filepath.Join(dir, "absent"), "")
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for missing input path under the default policy, got success")
+	}
+
+	writeRoute(filepath.Join(dir, "absent"), "retry")
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for unsupported missingPolicy, got success")
+	}
+
+	created := filepath.Join(dir, "created")
+	writeRoute(created, MissingInputCreate)
+	if _, err := LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected create policy to load, got error: %v", err)
+	}
+	if _, err := os.Stat(created); err != nil {
+		t.Errorf("Expected input path to be created: %v", err)
+	}
+
+	mount := filepath.Join(dir, "mount")
+	writeRoute(mount, MissingInputWait)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected wait policy to load, got error: %v", err)
+	}
+	if routes.Routes[0].InputReady() {
+		t.Error("Expected route waiting for its input path not to be ready")
+	}
+	if _, err := os.Stat(mount); !os.IsNotExist(err) {
+		t.Error("Expected wait policy not to create the input path")
+	}
+	if err := os.MkdirAll(mount, 0755); err != nil {
+		t.Fatalf("Failed to create input path: %v", err)
+	}
+	if !routes.Routes[0].InputReady() {
+		t.Error("Expected route to be ready once its input path exists")
+	}
+
+	os.Setenv("MISSING_INPUT_POLICY", MissingInputWait)
+	writeRoute(filepath.Join(dir, "inherited"), "")
+	if _, err := LoadRoutes(routesPath); err != nil {
+		t.Errorf("Expected MISSING_INPUT_POLICY=wait to apply to routes, got error: %v", err)
+	}
+}
+
+// TestLoadRoutesBothOutput validates independent folder and queue destinations for "both" output
+func TestLoadRoutesBothOutput(t *testing.T) {
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	out := filepath.ToSlash(filepath.Join(dir, "out"))
+	writeRoute := func(output string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"output": ` + output + `,
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	os.Clearenv()
+
+	writeRoute(`{"type": "both", "folder": "` + out + `", "queue": "rabbitmq://sales/orders"}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	cfg := routes.Routes[0].ToLegacyConfig()
+	if cfg.OutputType != "both" || cfg.OutputFolder != out || cfg.QueueName != "orders" || cfg.QueueVHost != "sales" {
+		t.Errorf("Unexpected both output settings: type=%s folder=%s queue=%s vhost=%s", cfg.OutputType, cfg.OutputFolder, cfg.QueueName, cfg.QueueVHost)
+	}
+
+	writeRoute(`{"type": "both", "folder": "` + out + `", "shards": {"queues": ["orders_a", "orders_b"]}}`)
+	routes, err = LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected sharded both output to load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.QueueName != "orders_a" || cfg.OutputFolder != out {
+		t.Errorf("Expected queue to default to the first shard, got queue=%s folder=%s", cfg.QueueName, cfg.OutputFolder)
+	}
+
+	invalid := map[string]string{
+		"unknown type":        `{"type": "s3", "destination": "bucket"}`,
+		"missing folder":      `{"type": "both", "queue": "orders"}`,
+		"missing queue":       `{"type": "both", "folder": "` + out + `"}`,
+		"destination":         `{"type": "both", "destination": "` + out + `", "folder": "` + out + `", "queue": "orders"}`,
+		"invalid queue":       `{"type": "both", "folder": "` + out + `", "queue": "amqp://orders"}`,
+		"missing file folder": `{"type": "file"}`,
+		"missing pipe":        `{"type": "pipe"}`,
+		"stdout destination":  `{"type": "stdout", "destination": "` + out + `"}`,
+	}
+	for name, output := range invalid {
+		writeRoute(output)
+		if _, err := LoadRoutes(routesPath); err == nil {
+			t.Errorf("%s: expected load error, got success", name)
+		}
+	}
+}
+
+// TestLoadOutputSchema validates OUTPUT_SCHEMA parsing and the route outputSchema transform
+func TestLoadOutputSchema(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("OUTPUT_SCHEMA", "id, country=US ,note=")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	expected := []SchemaField{{Name: "id"}, {Name: "country", Default: "US"}, {Name: "note"}}
+	if !reflect.DeepEqual(cfg.OutputSchema, expected) || cfg.ExtraColumns != ExtraColumnsDrop {
+		t.Errorf("Unexpected output schema: %+v extra=%s", cfg.OutputSchema, cfg.ExtraColumns)
+	}
+
+	invalid := map[string]string{"OUTPUT_SCHEMA": "id,id", "OUTPUT_SCHEMA_EXTRA_COLUMNS": "keep"}
+	for key, value := range invalid {
+		os.Clearenv()
+		os.Setenv("OUTPUT_SCHEMA", "id")
+		os.Setenv(key, value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for %s=%s, got success", key, value)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("OUTPUT_SCHEMA_STRICT_COLUMNS", "true")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for OUTPUT_SCHEMA_STRICT_COLUMNS without OUTPUT_SCHEMA, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(schema string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"transform": {"outputSchema": ` + schema + `},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`{"fields": [{"name": "id"}, {"name": "country", "default": "US"}], "extraColumns": "flag", "strictColumns": true}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	cfg = routes.Routes[0].ToLegacyConfig()
+	if len(cfg.OutputSchema) != 2 || cfg.OutputSchema[1].Default != "US" || cfg.ExtraColumns != ExtraColumnsFlag || !cfg.StrictColumns {
+		t.Errorf("Unexpected route output schema: %+v extra=%s strict=%v", cfg.OutputSchema, cfg.ExtraColumns, cfg.StrictColumns)
+	}
+
+	for _, schema := range []string{`{"fields": []}`, `{"fields": [{"name": "id"}, {"name": "id"}]}`, `{"fields": [{"name": "id"}], "extraColumns": "keep"}`} {
+		writeRoute(schema)
+		if _, err := LoadRoutes(routesPath); err == nil {
+			t.Errorf("Expected load error for %s, got success", schema)
+		}
+	}
+}
+
+// TestLoadQualityExpectations validates data quality expectations from the environment and routes
+func TestLoadQualityExpectations(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("QUALITY_EXPECTATIONS", "email:notEmpty:99.5, id:unique,sku:pattern::^[A-Z]{3}:[0-9]+$")
+	os.Setenv("QUALITY_POLICY", "warn")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.Quality == nil || cfg.QualityPolicy != QualityPolicyWarn {
+		t.Fatalf("Expected expectations with the warn policy, got %v %s", cfg.Quality, cfg.QualityPolicy)
+	}
+	expectations, _ := parseQualityExpectations("email:notEmpty:99.5, id:unique,sku:pattern::^[A-Z]{3}:[0-9]+$")
+	expected := []quality.Expectation{
+		{Column: "email", Type: quality.NotEmpty, MinPercent: 99.5},
+		{Column: "id", Type: quality.Unique},
+		{Column: "sku", Type: quality.Pattern, Pattern: "^[A-Z]{3}:[0-9]+$"},
+	}
+	if !reflect.DeepEqual(expectations, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, expectations)
+	}
+
+	invalid := map[string]string{"QUALITY_EXPECTATIONS": "email", "QUALITY_POLICY": "ignore"}
+	for key, value := range invalid {
+		os.Clearenv()
+		os.Setenv("QUALITY_EXPECTATIONS", "email:notEmpty")
+		os.Setenv(key, value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for %s=%s, got success", key, value)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("QUALITY_EXPECTATIONS", "email:notEmpty")
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(section string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			` + section + `
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	// Routes without their own expectations inherit the environment's
+	writeRoute("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.Quality == nil || cfg.QualityPolicy != QualityPolicyFail {
+		t.Errorf("Expected inherited expectations with the fail policy, got %v %s", cfg.Quality, cfg.QualityPolicy)
+	}
+
+	writeRoute(`"quality": {"policy": "warn", "expectations": [{"column": "id", "type": "unique", "minPercent": 99}]},`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.Quality == nil || cfg.QualityPolicy != QualityPolicyWarn {
+		t.Errorf("Expected route expectations with the warn policy, got %v %s", cfg.Quality, cfg.QualityPolicy)
+	}
+
+	for _, section := range []string{
+		`"quality": {"policy": "drop", "expectations": [{"column": "id", "type": "unique"}]},`,
+		`"quality": {"expectations": [{"column": "id", "type": "pattern"}]},`,
+		`"quality": {"expectations": [{"column": "id", "type": "unique", "minPercent": 120}]},`,
+	} {
+		writeRoute(section)
+		if _, err := LoadRoutes(routesPath); err == nil {
+			t.Errorf("Expected load error for %s, got success", section)
+		}
+	}
+}
+
+// TestLoadArchiveFailurePolicy validates the archive failure policy from the environment and routes
+func TestLoadArchiveFailurePolicy(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.ArchiveFailurePolicy != ArchiveFailureReprocess || cfg.ArchiveRetries != 3 {
+		t.Errorf("Expected the reprocess policy with 3 retries by default, got %s %d", cfg.ArchiveFailurePolicy, cfg.ArchiveRetries)
+	}
+
+	for key, value := range map[string]string{"ARCHIVE_FAILURE_POLICY": "delete", "ARCHIVE_RETRIES": "-1"} {
+		os.Clearenv()
+		os.Setenv(key, value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for %s=%s, got success", key, value)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("ARCHIVE_FAILURE_POLICY", "retry")
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(archive string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f"` + archive + `}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.ArchiveFailurePolicy != ArchiveFailureRetry {
+		t.Errorf("Expected the inherited retry policy, got %s", cfg.ArchiveFailurePolicy)
+	}
+
+	writeRoute(`, "failurePolicy": "quarantine", "quarantinePath": "q"`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.ArchiveFailurePolicy != ArchiveFailureQuarantine || cfg.ArchiveQuarantine != "q" {
+		t.Errorf("Expected the route's quarantine policy and folder, got %s %q", cfg.ArchiveFailurePolicy, cfg.ArchiveQuarantine)
+	}
+
+	writeRoute(`, "failurePolicy": "delete"`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected load error for an unsupported archive.failurePolicy, got success")
+	}
+}
+
+// TestLoadBatchManifests validates that manifest batches exclude merge window batching
+func TestLoadBatchManifests(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("BATCH_MANIFESTS", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !cfg.BatchManifests {
+		t.Error("Expected BatchManifests to be enabled")
+	}
+
+	os.Setenv("BATCH_MAX_FILES", "5")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error combining BATCH_MANIFESTS with BATCH_MAX_FILES, got success")
+	}
+}
+
+// TestLoadWorkDir validates working directory settings in env and routes mode
+func TestLoadWorkDir(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INPUT_FOLDER", "./data/input")
+	os.Setenv("WORK_DIR", "./data/work")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.WorkDir != "./data/work" {
+		t.Errorf("Expected WORK_DIR ./data/work, got %s", cfg.WorkDir)
+	}
+
+	os.Setenv("WORK_DIR", "./data/input/")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for WORK_DIR equal to INPUT_FOLDER, got success")
+	}
+	os.Setenv("WORK_DIR", "./data/work")
+	os.Setenv("CLAIM_FILES", "true")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error combining WORK_DIR with CLAIM_FILES, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	route := func(name, workDir string) string {
+		input := filepath.ToSlash(filepath.Join(dir, name))
+		if err := os.MkdirAll(input, 0755); err != nil {
+			t.Fatalf("Failed to create input folder: %v", err)
+		}
+		return `{"name": "` + name + `", "ingestionContract": "` + name + `.csv.v1",
+			"input": {"path": "` + input + `", "workDir": "` + workDir + `"},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}`
+	}
+	work := filepath.ToSlash(filepath.Join(dir, "work"))
+	write := func(routes ...string) {
+		if err := os.WriteFile(routesPath, []byte(`{"routes": [`+strings.Join(routes, ",")+`]}`), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	write(route("orders", work+"/orders"), route("stock", work+"/stock"))
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.WorkDir != work+"/orders" {
+		t.Errorf("Expected route workDir %s/orders, got %s", work, cfg.WorkDir)
+	}
+
+	write(route("orders", work), route("stock", work))
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for routes sharing a workDir, got success")
+	}
+}
+
+// TestLoadIntervals validates that poll and stability intervals accept duration strings and whole seconds
+func TestLoadIntervals(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		wantPoll      time.Duration
+		wantStability time.Duration
+		expectError   bool
+	}{
+		{name: "defaults", wantPoll: 5 * time.Second, wantStability: 2 * time.Second},
+		{name: "whole seconds", env: map[string]string{"POLL_INTERVAL_SECONDS": "10"}, wantPoll: 10 * time.Second, wantStability: 2 * time.Second},
+		{name: "sub-second", env: map[string]string{"POLL_INTERVAL_SECONDS": "500ms", "FILE_STABILITY_WINDOW": "250ms"}, wantPoll: 500 * time.Millisecond, wantStability: 250 * time.Millisecond},
+		{name: "minutes", env: map[string]string{"POLL_INTERVAL_SECONDS": "2m"}, wantPoll: 2 * time.Minute, wantStability: 2 * time.Second},
+		{name: "stability disabled", env: map[string]string{"FILE_STABILITY_WINDOW": "0"}, wantPoll: 5 * time.Second, wantStability: 0},
+		{name: "below minimum", env: map[string]string{"POLL_INTERVAL_SECONDS": "1ms"}, expectError: true},
+		{name: "zero poll", env: map[string]string{"POLL_INTERVAL_SECONDS": "0"}, expectError: true},
+		{name: "hybrid below minimum", env: map[string]string{"HYBRID_POLL_INTERVAL_SECONDS": "5ms"}, expectError: true},
+		{name: "negative stability", env: map[string]string{"FILE_STABILITY_WINDOW": "-1s"}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+			}
+
+			cfg, err := Load()
+			if tt.expectError {
+				if err == nil {
+					t.Error("Expected error, got success")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected successful load, got error: %v", err)
+			}
+			if cfg.PollInterval != tt.wantPoll {
+				t.Errorf("Expected poll interval %v, got %v", tt.wantPoll, cfg.PollInterval)
+			}
+			if cfg.StabilityWindow != tt.wantStability {
+				t.Errorf("Expected stability window %v, got %v", tt.wantStability, cfg.StabilityWindow)
+			}
+		})
+	}
+}
+
+// TestIntervalJSON validates that route intervals read and write duration strings and whole seconds
+func TestIntervalJSON(t *testing.T) {
+	tests := []struct {
+		json        string
+		want        time.Duration
+		marshaled   string
+		expectError bool
+	}{
+		{json: `30`, want: 30 * time.Second, marshaled: `30`},
+		{json: `"45"`, want: 45 * time.Second, marshaled: `45`},
+		{json: `"500ms"`, want: 500 * time.Millisecond, marshaled: `"500ms"`},
+		{json: `"2m"`, want: 2 * time.Minute, marshaled: `120`},
+		{json: `"soon"`, expectError: true},
+		{json: `true`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var i Interval
+			err := json.Unmarshal([]byte(tt.json), &i)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %s, got %v", tt.json, i.Duration())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if i.Duration() != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, i.Duration())
+			}
+			data, err := json.Marshal(i)
+			if err != nil {
+				t.Fatalf("Unexpected marshal error: %v", err)
+			}
+			if string(data) != tt.marshaled {
+				t.Errorf("Expected marshaled %s, got %s", tt.marshaled, data)
+			}
+		})
+	}
+}
+
+// TestByteSizeJSON validates that route sizes read byte counts and unit strings
+func TestByteSizeJSON(t *testing.T) {
+	tests := []struct {
+		json        string
+		want        int64
+		expectError bool
+	}{
+		{json: `1048576`, want: 1 << 20},
+		{json: `"2048"`, want: 2048},
+		{json: `"512KB"`, want: 512 << 10},
+		{json: `"500 mb"`, want: 500 << 20},
+		{json: `"2GB"`, want: 2 << 30},
+		{json: `"1.5GB"`, expectError: true},
+		{json: `"9999999TB"`, expectError: true},
+		{json: `"lots"`, expectError: true},
+		{json: `true`, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var b ByteSize
+			err := json.Unmarshal([]byte(tt.json), &b)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %s, got %d", tt.json, b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if int64(b) != tt.want {
+				t.Errorf("Expected %d, got %d", tt.want, b)
+			}
+		})
+	}
+}
+
+// TestReadConfigFileYAML validates flat YAML config files and rejects nested ones
+func TestReadConfigFileYAML(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write config file: %v", err)
+		}
+		return path
+	}
+
+	values, err := ReadConfigFile(write("config.yaml", "---\n# csv2json\nINPUT_FOLDER: ./in # inbox\nDELIMITER: \";\"\nQUEUE_NAME: 'it''s'\nLOG_FILE:\n"))
+	if err != nil {
+		t.Fatalf("ReadConfigFile failed: %v", err)
+	}
+	want := map[string]string{"INPUT_FOLDER": "./in", "DELIMITER": ";", "QUEUE_NAME": "it's", "LOG_FILE": ""}
+	if !reflect.DeepEqual(values, want) {
+		t.Errorf("Expected %v, got %v", want, values)
+	}
+
+	for name, content := range map[string]string{
+		"nested.yaml": "output:\n  type: queue\n",
+		"list.yml":    "FILE_SUFFIX_FILTER: [.csv, .txt]\n",
+		"quoted.yaml": "QUEUE_NAME: \"orders\" extra\n",
+	} {
+		if _, err := ReadConfigFile(write(name, content)); err == nil {
+			t.Errorf("Expected error for %s", name)
+		}
+	}
+}
+
+// TestApplyLayers validates the precedence defaults < config file < environment <
+// secrets < flags and the sources reported for the effective configuration
+func TestApplyLayers(t *testing.T) {
+	os.Clearenv()
+	layers.sources = make(map[string]string)
+	defer func() { layers.sources = make(map[string]string) }()
+
+	path := filepath.Join(t.TempDir(), "csv2json.env")
+	if err := os.WriteFile(path, []byte("INPUT_FOLDER=./from-file\nWATCH_MODE=poll\nLOG_LEVEL=WARN\nQUEUE_PASSWORD=hunter2\n"), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+	os.Setenv("WATCH_MODE", "hybrid")
+	if err := ApplyLayers(map[string]string{"CONFIG_FILE": path, "LOG_LEVEL": "DEBUG"}); err != nil {
+		t.Fatalf("ApplyLayers failed: %v", err)
+	}
+	// Secrets fetched afterwards override the file but not flags
+	changed, err := ApplySecrets(map[string]string{"QUEUE_PASSWORD": "rotated", "LOG_LEVEL": "ERROR"})
+	if err != nil || len(changed) != 1 || changed[0] != "QUEUE_PASSWORD" {
+		t.Fatalf("Expected only QUEUE_PASSWORD to change, got %v (%v)", changed, err)
+	}
+	if os.Getenv("QUEUE_PASSWORD") != "rotated" {
+		t.Errorf("Expected the secret to override the config file, got %q", os.Getenv("QUEUE_PASSWORD"))
+	}
+
+	settings, err := Effective()
+	if err != nil {
+		t.Fatalf("Effective failed: %v", err)
+	}
+	got := make(map[string]Setting, len(settings))
+	for _, setting := range settings {
+		got[setting.Key] = setting
+	}
+	for key, want := range map[string]Setting{
+		"INPUT_FOLDER":   {Key: "INPUT_FOLDER", Value: "./from-file", Source: SourceFile},
+		"WATCH_MODE":     {Key: "WATCH_MODE", Value: "hybrid", Source: SourceEnvironment},
+		"LOG_LEVEL":      {Key: "LOG_LEVEL", Value: "DEBUG", Source: SourceFlag},
+		"OUTPUT_TYPE":    {Key: "OUTPUT_TYPE", Value: "file", Source: SourceDefault},
+		"QUEUE_PASSWORD": {Key: "QUEUE_PASSWORD", Value: maskedValue, Source: SourceSecrets},
+	} {
+		if got[key] != want {
+			t.Errorf("Expected %+v, got %+v", want, got[key])
+		}
+	}
+
+	// An explicitly configured file must exist
+	os.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a missing CONFIG_FILE")
+	}
+}
+
+// TestLoadExactlyOnce validates exactly-once mode settings and that it is rejected with
+// transactional batch manifests
+func TestLoadExactlyOnce(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.ExactlyOnce || cfg.ExactlyOnceRetention != 30*24*time.Hour {
+		t.Errorf("Expected exactly-once off with 30 day retention by default, got %v %v", cfg.ExactlyOnce, cfg.ExactlyOnceRetention)
+	}
+
+	os.Setenv("EXACTLY_ONCE", "true")
+	os.Setenv("BATCH_MANIFESTS", "true")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for EXACTLY_ONCE with BATCH_MANIFESTS, got success")
+	}
+
+	os.Clearenv()
+	os.Setenv("EXACTLY_ONCE", "true")
+	os.Setenv("EXACTLY_ONCE_RETENTION", "168h")
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(input string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"` + input + `},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); !cfg.ExactlyOnce || cfg.ExactlyOnceRetention != 168*time.Hour {
+		t.Errorf("Expected the inherited exactly-once mode with 168h retention, got %v %v", cfg.ExactlyOnce, cfg.ExactlyOnceRetention)
+	}
+
+	writeRoute(`, "exactlyOnce": false`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if routes.Routes[0].ToLegacyConfig().ExactlyOnce {
+		t.Error("Expected the route to turn exactly-once mode off")
+	}
+
+	writeRoute(`, "batchManifests": true`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for exactlyOnce with batchManifests, got success")
+	}
+}
+
+// TestLoadReassembleLines validates line reassembly is inherited by routes and only
+// accepted for delimited input
+func TestLoadReassembleLines(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("REASSEMBLE_LINES", "true")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !cfg.ReassembleLines {
+		t.Error("Expected REASSEMBLE_LINES to be loaded")
+	}
+	os.Setenv("INPUT_FORMAT", "whitespace")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for REASSEMBLE_LINES with whitespace input, got success")
+	}
+
+	os.Unsetenv("INPUT_FORMAT")
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(parsing string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","` + parsing + `},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !routes.Routes[0].ToLegacyConfig().ReassembleLines {
+		t.Error("Expected the route to inherit REASSEMBLE_LINES")
+	}
+
+	writeRoute(`, "format": "fixed-width", "fixedWidthColumns": [{"width": 3}]`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for reassembleLines with fixed-width input, got success")
+	}
+}
+
+// TestLoadRaggedRowPolicy validates the ragged row policy and column sample settings
+func TestLoadRaggedRowPolicy(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.RaggedRowPolicy != parser.RaggedRowFail || cfg.ColumnSampleRows != 0 {
+		t.Errorf("Expected the fail policy without sampling by default, got %s %d", cfg.RaggedRowPolicy, cfg.ColumnSampleRows)
+	}
+
+	for key, value := range map[string]string{"RAGGED_ROW_POLICY": "truncate", "COLUMN_SAMPLE_ROWS": "-1"} {
+		os.Clearenv()
+		os.Setenv(key, value)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for %s=%s, got success", key, value)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("RAGGED_ROW_POLICY", "skip")
+	os.Setenv("COLUMN_SAMPLE_ROWS", "100")
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	content := `{"routes": [{"name": "feed", "ingestionContract": "feed.csv.v1",
+		"input": {"path": "` + filepath.ToSlash(dir) + `"},
+		"parsing": {"hasHeader": false, "delimiter": ",", "raggedRowPolicy": "pad"},
+		"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+		"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+	if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write routes config: %v", err)
+	}
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.RaggedRowPolicy != parser.RaggedRowPad || cfg.ColumnSampleRows != 100 {
+		t.Errorf("Expected the route's pad policy with the inherited 100-row sample, got %s %d", cfg.RaggedRowPolicy, cfg.ColumnSampleRows)
+	}
+}
+
+// TestLoadTSVFormat validates the TSV profile defaults the tab delimiter and the
+// .tsv/.txt suffix filter, globally and per route
+func TestLoadTSVFormat(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INPUT_FORMAT", "tsv")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.Delimiter != '\t' {
+		t.Errorf("Expected tab delimiter, got %q", cfg.Delimiter)
+	}
+	if len(cfg.FileSuffixFilter) != 2 || cfg.FileSuffixFilter[0] != ".tsv" || cfg.FileSuffixFilter[1] != ".txt" {
+		t.Errorf("Expected suffix filter [.tsv .txt], got %v", cfg.FileSuffixFilter)
+	}
+	os.Setenv("DELIMITER", ";")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for tsv input with a non-tab delimiter, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(parsing string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.tsv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "format": "tsv"` + parsing + `},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`, "reassembleLines": true`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	legacy := routes.Routes[0].ToLegacyConfig()
+	if legacy.Delimiter != '\t' || legacy.InputFormat != "tsv" {
+		t.Errorf("Expected tsv input with tab delimiter, got %q %q", legacy.InputFormat, legacy.Delimiter)
+	}
+	if len(legacy.FileSuffixFilter) != 2 || legacy.FileSuffixFilter[0] != ".tsv" {
+		t.Errorf("Expected suffix filter [.tsv .txt], got %v", legacy.FileSuffixFilter)
+	}
+
+	writeRoute(`, "delimiter": ","`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for tsv route with a comma delimiter, got success")
+	}
+}
+
+// TestLoadRecordLayouts validates record layouts are loaded for multi-record input and
+// rejected for other formats or with grouping
+func TestLoadRecordLayouts(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("INPUT_FORMAT", "multi-record")
+	os.Setenv("RECORD_LAYOUTS", "H:header=doc:6,date:8;D:detail:items=sku:6,qty:4;T:trailer=count:3")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if len(cfg.RecordLayouts) != 3 || cfg.RecordLayouts[1].Key != "items" || cfg.RecordLayouts[2].Role != parser.RecordTrailer {
+		t.Errorf("Unexpected record layouts: %+v", cfg.RecordLayouts)
+	}
+	os.Setenv("GROUP_BY", "doc")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for grouped multi-record input, got success")
+	}
+	os.Unsetenv("GROUP_BY")
+	os.Unsetenv("INPUT_FORMAT")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for RECORD_LAYOUTS with delimited input, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(layouts string) {
+		content := `{"routes": [{"name": "idocs", "ingestionContract": "idocs.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"format": "multi-record", "recordLayouts": ` + layouts + `},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`[{"prefix": "H", "role": "header", "columns": [{"name": "doc", "width": 6}]},
+		{"prefix": "D", "role": "detail", "columns": [{"name": "sku", "width": 6}]}]`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if layouts := routes.Routes[0].ToLegacyConfig().RecordLayouts; len(layouts) != 2 || layouts[0].Prefix != "H" {
+		t.Errorf("Unexpected route record layouts: %+v", layouts)
+	}
+
+	writeRoute(`[{"prefix": "D", "role": "detail", "columns": [{"name": "sku", "width": 6}]}]`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for record layouts without a header type, got success")
+	}
+}
+
+// TestLoadTimezone validates TIMEZONE is validated and inherited by routes without
+// their own timezone
+func TestLoadTimezone(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.Location() != nil {
+		t.Error("Expected no location without TIMEZONE")
+	}
+	os.Setenv("TIMEZONE", "Europe/London")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if loc := cfg.Location(); loc == nil || loc.String() != "Europe/London" {
+		t.Errorf("Expected Europe/London, got %v", loc)
+	}
+	os.Setenv("TIMEZONE", "Mars/Olympus_Mons")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown TIMEZONE, got success")
+	}
+
+	os.Setenv("TIMEZONE", "Europe/London")
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(timezone string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1"` + timezone + `,
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if tz := routes.Routes[0].ToLegacyConfig().Timezone; tz != "Europe/London" {
+		t.Errorf("Expected the route to inherit TIMEZONE, got %q", tz)
+	}
+
+	writeRoute(`, "timezone": "Asia/Tokyo"`)
+	routes, err = LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if tz := routes.Routes[0].ToLegacyConfig().Timezone; tz != "Asia/Tokyo" {
+		t.Errorf("Expected the route timezone, got %q", tz)
+	}
+
+	writeRoute(`, "timezone": "Nowhere/Special"`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for unknown route timezone, got success")
+	}
+}
+
+// TestLoadBusinessDate validates business date sources and the settings that depend on them
+func TestLoadBusinessDate(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.BusinessDate != nil {
+		t.Error("Expected no business date without BUSINESS_DATE_FROM")
+	}
+
+	os.Setenv("ARCHIVE_BY_BUSINESS_DATE", "true")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for ARCHIVE_BY_BUSINESS_DATE without a business date source, got success")
+	}
+	os.Setenv("ARCHIVE_BY_BUSINESS_DATE", "false")
+	os.Setenv("OUTPUT_FOLDER", filepath.Join(dir, "out", "{businessDate}"))
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for a business date output folder without a business date source, got success")
+	}
+
+	os.Setenv("BUSINESS_DATE_FROM", "column")
+	os.Setenv("BUSINESS_DATE_COLUMN", "as_of")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.BusinessDate == nil || cfg.BusinessDate.From() != "column" {
+		t.Errorf("Expected the column business date source, got %v", cfg.BusinessDate)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "out", "{businessDate}")); !os.IsNotExist(err) {
+		t.Error("Expected the business date output folder to be created on first write")
+	}
+
+	os.Setenv("BATCH_MAX_FILES", "10")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for business dates with batching, got success")
+	}
+	os.Unsetenv("BATCH_MAX_FILES")
+	os.Setenv("BUSINESS_DATE_FROM", "mtime")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for BUSINESS_DATE_COLUMN with the mtime source, got success")
+	}
+	os.Unsetenv("BUSINESS_DATE_COLUMN")
+	os.Setenv("BUSINESS_DATE_FROM", "header")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown BUSINESS_DATE_FROM, got success")
+	}
+
+	os.Clearenv()
+	os.Setenv("BUSINESS_DATE_FROM", "mtime")
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(extra string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1"` + extra + `,
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": {"type": "file", "destination": "` + filepath.ToSlash(filepath.Join(dir, "out")) + `"},
+			"archive": {"processedPath": "p", "failedPath": "f", "byBusinessDate": true}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	legacy := routes.Routes[0].ToLegacyConfig()
+	if legacy.BusinessDate == nil || legacy.BusinessDate.From() != "mtime" || !legacy.ArchiveByDate {
+		t.Errorf("Expected the route to inherit BUSINESS_DATE_FROM and archive by date, got %v %v", legacy.BusinessDate, legacy.ArchiveByDate)
+	}
+
+	writeRoute(`, "businessDate": {"from": "filename", "pattern": "_(\\d{6})\\.", "format": "020106"}`)
+	routes, err = LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if from := routes.Routes[0].ToLegacyConfig().BusinessDate.From(); from != "filename" {
+		t.Errorf("Expected the route business date source, got %q", from)
+	}
+
+	writeRoute(`, "businessDate": {"from": "filename", "pattern": "("}`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for invalid route business date pattern, got success")
+	}
+
+	os.Unsetenv("BUSINESS_DATE_FROM")
+	writeRoute("")
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for archive.byBusinessDate without a business date source, got success")
+	}
+}
+
+// TestLoadLateDatePolicy validates the late business date policy and corrections queue
+func TestLoadLateDatePolicy(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.LateDatePolicy != LateBusinessDateAccept {
+		t.Errorf("Expected default policy accept, got %q", cfg.LateDatePolicy)
+	}
+
+	os.Setenv("LATE_BUSINESS_DATE_POLICY", "hold")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for the hold policy without a business date source, got success")
+	}
+	os.Setenv("BUSINESS_DATE_FROM", "filename")
+	if _, err := Load(); err != nil {
+		t.Errorf("Expected successful load, got error: %v", err)
+	}
+
+	os.Setenv("LATE_BUSINESS_DATE_POLICY", "corrections")
+	os.Setenv("OUTPUT_TYPE", "queue")
+	os.Setenv("QUEUE_TYPE", "rabbitmq")
+	os.Setenv("QUEUE_HOST", "localhost")
+	os.Setenv("QUEUE_NAME", "orders")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for the corrections policy without CORRECTIONS_QUEUE, got success")
+	}
+	os.Setenv("CORRECTIONS_QUEUE", "orders.corrections")
+	os.Setenv("TENANT", "acme")
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.CorrectionsQueue != "acme.orders.corrections" {
+		t.Errorf("Expected the tenant-scoped corrections queue, got %q", cfg.CorrectionsQueue)
+	}
+	os.Unsetenv("TENANT")
+	os.Setenv("OUTPUT_TYPE", "file")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for the corrections policy with file output, got success")
+	}
+	os.Setenv("LATE_BUSINESS_DATE_POLICY", "discard")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown LATE_BUSINESS_DATE_POLICY, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(businessDate string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1", "businessDate": ` + businessDate + `,
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": {"type": "queue", "destination": "orders"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`{"from": "filename", "latePolicy": "corrections", "correctionsQueue": "orders.corrections"}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.LateDatePolicy != LateBusinessDateCorrections || legacy.CorrectionsQueue != "orders.corrections" {
+		t.Errorf("Expected the route corrections policy, got %q %q", legacy.LateDatePolicy, legacy.CorrectionsQueue)
+	}
+
+	os.Setenv("LATE_BUSINESS_DATE_POLICY", "hold")
+	writeRoute(`{"from": "filename"}`)
+	routes, err = LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if policy := routes.Routes[0].ToLegacyConfig().LateDatePolicy; policy != LateBusinessDateHold {
+		t.Errorf("Expected the route to inherit LATE_BUSINESS_DATE_POLICY, got %q", policy)
+	}
+
+	writeRoute(`{"from": "filename", "latePolicy": "corrections"}`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for the corrections policy without a corrections queue, got success")
+	}
+}
+
+// TestLoadOutputShape validates OUTPUT_SHAPE and the route outputShape field
+func TestLoadOutputShape(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.OutputShape != "array" {
+		t.Errorf("Expected default shape array, got %q", cfg.OutputShape)
+	}
+
+	os.Setenv("OUTPUT_SHAPE", "object")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.OutputShape != "object" {
+		t.Errorf("Expected shape object, got %q", cfg.OutputShape)
+	}
+	os.Setenv("BATCH_MAX_FILES", "10")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for object output with batching, got success")
+	}
+	os.Unsetenv("BATCH_MAX_FILES")
+	os.Setenv("EMPTY_FILE_POLICY", "emitEmptyArray")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for object output with EMPTY_FILE_POLICY=emitEmptyArray, got success")
+	}
+	os.Unsetenv("EMPTY_FILE_POLICY")
+	os.Setenv("MESSAGE_CONTENT_TYPE", "application/x-ndjson")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for object output with NDJSON messages, got success")
+	}
+	os.Unsetenv("MESSAGE_CONTENT_TYPE")
+	os.Setenv("OUTPUT_SHAPE", "scalar")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for unknown OUTPUT_SHAPE, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(output string) {
+		content := `{"routes": [{"name": "status", "ingestionContract": "status.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": ` + output + `,
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`{"type": "file", "destination": "out", "outputShape": "object"}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.OutputShape != "object" {
+		t.Errorf("Expected the route object shape, got %q", legacy.OutputShape)
+	}
+
+	os.Setenv("OUTPUT_SHAPE", "object")
+	writeRoute(`{"type": "file", "destination": "out"}`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.OutputShape != "object" {
+		t.Errorf("Expected the OUTPUT_SHAPE default, got %q", legacy.OutputShape)
+	}
+
+	writeRoute(`{"type": "file", "destination": "out", "outputShape": "object", "batch": {"maxFiles": 5}}`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for a batched route with object output, got success")
+	}
+}
+
+// TestLoadFileWrapper validates FILE_WRAPPER, FILE_WRAPPER_FIELDS and the route wrapper
+func TestLoadFileWrapper(t *testing.T) {
+	os.Clearenv()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.FileWrapper != nil {
+		t.Errorf("Expected no wrapper by default, got %+v", cfg.FileWrapper)
+	}
+
+	os.Setenv("FILE_WRAPPER_FIELDS", "records=rows")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for FILE_WRAPPER_FIELDS without FILE_WRAPPER, got success")
+	}
+	os.Setenv("FILE_WRAPPER", "true")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if w := cfg.FileWrapper; w == nil || w.File != "file" || w.GeneratedAt != "generatedAt" || w.Records != "rows" {
+		t.Errorf("Expected the default fields with records renamed, got %+v", w)
+	}
+	for _, fields := range []string{"records=file", "rows=data", "records"} {
+		os.Setenv("FILE_WRAPPER_FIELDS", fields)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for FILE_WRAPPER_FIELDS=%s, got success", fields)
+		}
+	}
+	os.Unsetenv("FILE_WRAPPER_FIELDS")
+	os.Setenv("OUTPUT_TYPE", "stdout")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for FILE_WRAPPER with stdout output, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(output string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": ` + output + `,
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`{"type": "file", "destination": "out", "wrapper": {"file": "source"}}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if w := routes.Routes[0].ToLegacyConfig().FileWrapper; w == nil || w.File != "source" || w.Records != "records" {
+		t.Errorf("Expected the route wrapper with defaults, got %+v", w)
+	}
+
+	writeRoute(`{"type": "queue", "destination": "orders", "wrapper": {}}`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for a wrapper on queue output, got success")
+	}
+
+	// Only routes writing files inherit FILE_WRAPPER
+	os.Setenv("FILE_WRAPPER", "true")
+	writeRoute(`{"type": "queue", "destination": "orders"}`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if w := routes.Routes[0].ToLegacyConfig().FileWrapper; w != nil {
+		t.Errorf("Expected no wrapper for queue output, got %+v", w)
+	}
+	writeRoute(`{"type": "both", "folder": "out", "queue": "orders"}`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if w := routes.Routes[0].ToLegacyConfig().FileWrapper; w == nil || w.File != "file" {
+		t.Errorf("Expected the FILE_WRAPPER default, got %+v", w)
+	}
+}
+
+// TestLoadLegacyIdentifier validates LEGACY_IDENTIFIER and the route identifier template
+func TestLoadLegacyIdentifier(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("LEGACY_IDENTIFIER", "{path}")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for LEGACY_IDENTIFIER with file output, got success")
+	}
+	os.Setenv("OUTPUT_TYPE", "queue")
+	os.Setenv("QUEUE_TYPE", "rabbitmq")
+	os.Setenv("QUEUE_HOST", "localhost")
+	os.Setenv("QUEUE_NAME", "orders")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg.LegacyIdentifier != "{path}" {
+		t.Errorf("Expected identifier template {path}, got %q", cfg.LegacyIdentifier)
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(output string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": ` + output + `,
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoute(`{"type": "queue", "destination": "orders", "payloadFormat": "legacy", "identifier": "{route}/{filename}"}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.LegacyIdentifier != "{route}/{filename}" {
+		t.Errorf("Expected the route identifier template, got %q", legacy.LegacyIdentifier)
+	}
+
+	writeRoute(`{"type": "queue", "destination": "orders", "identifier": "{route}/{filename}"}`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for an identifier template with envelope messages, got success")
+	}
+
+	// Only legacy format routes inherit LEGACY_IDENTIFIER
+	os.Setenv("LEGACY_IDENTIFIER", "{fileHash}")
+	writeRoute(`{"type": "queue", "destination": "orders"}`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.LegacyIdentifier != "" {
+		t.Errorf("Expected no identifier template for envelope messages, got %q", legacy.LegacyIdentifier)
+	}
+	writeRoute(`{"type": "queue", "destination": "orders", "includeEnvelope": false}`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.LegacyIdentifier != "{fileHash}" {
+		t.Errorf("Expected the LEGACY_IDENTIFIER default, got %q", legacy.LegacyIdentifier)
+	}
+}
+
+func TestLoadRoutesSharedConnection(t *testing.T) {
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoutes := func(settings string) {
+		content := `{` + settings + `"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": {"type": "queue", "destination": "orders"},
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	writeRoutes("")
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !*routes.SharedConnection {
+		t.Error("Expected routes to share a connection by default")
+	}
+
+	os.Setenv("QUEUE_SHARED_CONNECTION", "false")
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if *routes.SharedConnection {
+		t.Error("Expected QUEUE_SHARED_CONNECTION=false to give each route its own connection")
+	}
+
+	writeRoutes(`"sharedConnection": true, `)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !*routes.SharedConnection {
+		t.Error("Expected sharedConnection to take precedence over QUEUE_SHARED_CONNECTION")
+	}
+}
+
+func TestLoadTypeInference(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("TYPE_INFERENCE", "true")
+	os.Setenv("COLUMN_TYPES", "account_id:string, age:integer")
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if !cfg.TypeInference || len(cfg.ColumnTypes) != 2 || cfg.ColumnTypes[1] != (ColumnType{Column: "age", Type: "integer"}) {
+		t.Errorf("Expected type inference with 2 column types, got %t %v", cfg.TypeInference, cfg.ColumnTypes)
+	}
+	if opts := cfg.ConverterOptions(); !opts.InferTypes || opts.ColumnTypes["account_id"] != "string" {
+		t.Errorf("Expected converter options with the column types, got %+v", opts)
+	}
+
+	for _, spec := range []string{"age", "age:date", "age:integer,age:number"} {
+		os.Setenv("COLUMN_TYPES", spec)
+		if _, err := Load(); err == nil {
+			t.Errorf("Expected error for COLUMN_TYPES=%s, got success", spec)
+		}
+	}
+	os.Setenv("COLUMN_TYPES", "")
+	os.Setenv("OUTPUT_TYPE", "queue")
+	os.Setenv("QUEUE_TYPE", "rabbitmq")
+	os.Setenv("QUEUE_HOST", "localhost")
+	os.Setenv("QUEUE_NAME", "orders")
+	os.Setenv("MESSAGE_CONTENT_TYPE", ContentTypeAvro)
+	if _, err := Load(); err == nil {
+		t.Error("Expected error for type inference with Avro messages, got success")
+	}
+
+	os.Clearenv()
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(output string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"parsing": {"hasHeader": true, "delimiter": ","},
+			"output": ` + output + `,
+			"archive": {"processedPath": "p", "failedPath": "f"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	// Routes inherit the global settings unless they set their own
+	os.Setenv("TYPE_INFERENCE", "true")
+	os.Setenv("COLUMN_TYPES", "account_id:string")
+	writeRoute(`{"type": "file", "destination": "out"}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); !legacy.TypeInference || len(legacy.ColumnTypes) != 1 {
+		t.Errorf("Expected the global type settings, got %t %v", legacy.TypeInference, legacy.ColumnTypes)
+	}
+	writeRoute(`{"type": "file", "destination": "out", "typeInference": false, "columnTypes": [{"column": "age", "type": "integer"}]}`)
+	if routes, err = LoadRoutes(routesPath); err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if legacy := routes.Routes[0].ToLegacyConfig(); legacy.TypeInference || legacy.ColumnTypes[0].Column != "age" {
+		t.Errorf("Expected the route's type settings, got %t %v", legacy.TypeInference, legacy.ColumnTypes)
+	}
+
+	writeRoute(`{"type": "file", "destination": "out", "columnTypes": [{"column": "age", "type": "date"}]}`)
+	if _, err := LoadRoutes(routesPath); err == nil {
+		t.Error("Expected error for an unsupported column type, got success")
+	}
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// QueueDestination is a route's parsed queue destination
+type QueueDestination struct {
+	Scheme   string // Broker type, e.g. "rabbitmq" ("" = not given, the default broker)
+	Host     string // Broker host override ("" = QUEUE_HOST)
+	Port     int    // Broker port override (0 = QUEUE_PORT)
+	VHost    string // RabbitMQ virtual host ("" = QUEUE_VHOST)
+	Exchange string // Exchange to publish through ("" = output.rabbitmq.exchange, else the default exchange)
+	Queue    string // Queue name
+}
+
+// ParseQueueDestination parses a queue destination. A plain name is the queue itself;
+// URIs name the vhost and exchange as path segments before the queue:
+//
+//	products_queue
+//	rabbitmq://products_queue
+//	rabbitmq://vhost/products_queue
+//	rabbitmq://vhost/exchange/products_queue
+//	rabbitmq://vhost/exchange/products_queue?host=broker.internal:5673
+//
+// Segments are percent-decoded, so a "/" inside a vhost is written %2F. An empty vhost
+// segment ("rabbitmq:///exchange/queue") keeps the default vhost.
+func ParseQueueDestination(dest string) (QueueDestination, error) {
+	scheme, rest, isURI := strings.Cut(dest, "://")
+	if !isURI {
+		if dest == "" {
+			return QueueDestination{}, fmt.Errorf("queue destination is empty")
+		}
+		return QueueDestination{Queue: dest}, nil
+	}
+
+	d := QueueDestination{Scheme: scheme}
+	if scheme != "rabbitmq" {
+		return d, fmt.Errorf("unsupported queue destination scheme: %q (supported: rabbitmq)", scheme)
+	}
+
+	path, rawQuery, _ := strings.Cut(rest, "?")
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		decoded, err := url.PathUnescape(segment)
+		if err != nil {
+			return d, fmt.Errorf("invalid queue destination %q: %w", dest, err)
+		}
+		segments[i] = decoded
+	}
+	switch len(segments) {
+	case 1:
+		d.Queue = segments[0]
+	case 2:
+		d.VHost, d.Queue = segments[0], segments[1]
+	case 3:
+		d.VHost, d.Exchange, d.Queue = segments[0], segments[1], segments[2]
+		if d.Exchange == "" {
+			return d, fmt.Errorf("invalid queue destination %q: exchange segment is empty", dest)
+		}
+	default:
+		return d, fmt.Errorf("invalid queue destination %q: expected rabbitmq://[vhost/][exchange/]queue", dest)
+	}
+	if d.Queue == "" {
+		return d, fmt.Errorf("invalid queue destination %q: missing queue name", dest)
+	}
+
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return d, fmt.Errorf("invalid queue destination %q: %w", dest, err)
+	}
+	for key, values := range query {
+		if key != "host" || len(values) != 1 {
+			return d, fmt.Errorf("invalid queue destination %q: unsupported option %q (supported: host)", dest, key)
+		}
+		if d.Host, d.Port, err = parseBrokerAddress(values[0]); err != nil {
+			return d, fmt.Errorf("invalid queue destination %q: %w", dest, err)
+		}
+	}
+	return d, nil
+}
+
+// parseBrokerAddress parses "host" or "host:port"
+func parseBrokerAddress(address string) (string, int, error) {
+	if !strings.Contains(address, ":") {
+		if address == "" {
+			return "", 0, fmt.Errorf("host is empty")
+		}
+		return address, 0, nil
+	}
+	host, portValue, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid host %q: %w", address, err)
+	}
+	port, err := strconv.Atoi(portValue)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port in host %q", address)
+	}
+	if host == "" {
+		return "", 0, fmt.Errorf("host is empty in %q", address)
+	}
+	return host, port, nil
+}
+
+// sameBroker reports whether two destinations publish through the same connection and exchange
+func (d QueueDestination) sameBroker(other QueueDestination) bool {
+	return d.Host == other.Host && d.Port == other.Port && d.VHost == other.VHost && d.Exchange == other.Exchange
+}
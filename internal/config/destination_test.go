@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestParseQueueDestination validates queue destination URIs and their load-time errors
+func TestParseQueueDestination(t *testing.T) {
+	tests := []struct {
+		dest        string
+		want        QueueDestination
+		expectError bool
+	}{
+		{dest: "products_queue", want: QueueDestination{Queue: "products_queue"}},
+		{dest: `ingest\products`, want: QueueDestination{Queue: `ingest\products`}},
+		{dest: "rabbitmq://products_queue", want: QueueDestination{Scheme: "rabbitmq", Queue: "products_queue"}},
+		{dest: "rabbitmq://sales/orders", want: QueueDestination{Scheme: "rabbitmq", VHost: "sales", Queue: "orders"}},
+		{dest: "rabbitmq://sales/ingest/orders", want: QueueDestination{Scheme: "rabbitmq", VHost: "sales", Exchange: "ingest", Queue: "orders"}},
+		{dest: "rabbitmq:///ingest/orders", want: QueueDestination{Scheme: "rabbitmq", Exchange: "ingest", Queue: "orders"}},
+		{dest: "rabbitmq://eu%2Fsales/orders", want: QueueDestination{Scheme: "rabbitmq", VHost: "eu/sales", Queue: "orders"}},
+		{dest: "rabbitmq://sales/orders?host=broker.internal:5673", want: QueueDestination{Scheme: "rabbitmq", Host: "broker.internal", Port: 5673, VHost: "sales", Queue: "orders"}},
+		{dest: "rabbitmq://orders?host=broker.internal", want: QueueDestination{Scheme: "rabbitmq", Host: "broker.internal", Queue: "orders"}},
+		{dest: "", expectError: true},
+		{dest: "kafka://orders", expectError: true},
+		{dest: "rabbitmq://", expectError: true},
+		{dest: "rabbitmq://sales/", expectError: true},
+		{dest: "rabbitmq://sales//orders", expectError: true},
+		{dest: "rabbitmq://a/b/c/d", expectError: true},
+		{dest: "rabbitmq://sales/bad%zz", expectError: true},
+		{dest: "rabbitmq://orders?port=5673", expectError: true},
+		{dest: "rabbitmq://orders?host=broker:0", expectError: true},
+		{dest: "rabbitmq://orders?host=broker:http", expectError: true},
+		{dest: "rabbitmq://orders?host=", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dest, func(t *testing.T) {
+			got, err := ParseQueueDestination(tt.dest)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestLoadRoutesQueueDestination validates that route destination URIs set the queue connection and are checked at load time
+func TestLoadRoutesQueueDestination(t *testing.T) {
+	dir := t.TempDir()
+	routesPath := filepath.Join(dir, "routes.json")
+	writeRoute := func(output string) {
+		content := `{"routes": [{"name": "orders", "ingestionContract": "orders.csv.v1",
+			"input": {"path": "` + filepath.ToSlash(dir) + `"},
+			"output": ` + output + `,
+			"archive": {"processedPath": "` + filepath.ToSlash(filepath.Join(dir, "processed")) + `", "failedPath": "` + filepath.ToSlash(filepath.Join(dir, "failed")) + `"}}]}`
+		if err := os.WriteFile(routesPath, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write routes config: %v", err)
+		}
+	}
+
+	os.Clearenv()
+	os.Setenv("QUEUE_HOST", "rabbit")
+	os.Setenv("QUEUE_VHOST", "default")
+
+	writeRoute(`{"type": "queue", "destination": "rabbitmq://sales/ingest/orders?host=broker:5673",
+		"shards": {"queues": ["orders_a", "rabbitmq://sales/ingest/orders_b?host=broker:5673"]}}`)
+	routes, err := LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	cfg := routes.Routes[0].ToLegacyConfig()
+	if cfg.QueueName != "orders" || cfg.QueueVHost != "sales" || cfg.RabbitMQExchange != "ingest" || cfg.QueueHost != "broker" || cfg.QueuePort != 5673 {
+		t.Errorf("Unexpected queue settings: name=%s vhost=%s exchange=%s host=%s port=%d", cfg.QueueName, cfg.QueueVHost, cfg.RabbitMQExchange, cfg.QueueHost, cfg.QueuePort)
+	}
+	if len(cfg.QueueShards) != 2 || cfg.QueueShards[0] != "orders_a" || cfg.QueueShards[1] != "orders_b" {
+		t.Errorf("Expected shard queue names, got %v", cfg.QueueShards)
+	}
+
+	writeRoute(`{"type": "queue", "destination": "rabbitmq://orders"}`)
+	routes, err = LoadRoutes(routesPath)
+	if err != nil {
+		t.Fatalf("Expected successful load, got error: %v", err)
+	}
+	if cfg := routes.Routes[0].ToLegacyConfig(); cfg.QueueName != "orders" || cfg.QueueVHost != "default" || cfg.QueueHost != "rabbit" {
+		t.Errorf("Expected environment connection settings, got vhost=%s host=%s", cfg.QueueVHost, cfg.QueueHost)
+	}
+
+	invalid := map[string]string{
+		"bad scheme":        `{"type": "queue", "destination": "amqp://orders"}`,
+		"too many segments": `{"type": "queue", "destination": "rabbitmq://a/b/c/d"}`,
+		"exchange conflict": `{"type": "queue", "destination": "rabbitmq://sales/ingest/orders", "rabbitmq": {"exchange": "other"}}`,
+		"shard vhost":       `{"type": "queue", "destination": "rabbitmq://sales/orders", "shards": {"queues": ["rabbitmq://other/orders_b"]}}`,
+	}
+	for name, output := range invalid {
+		writeRoute(output)
+		if _, err := LoadRoutes(routesPath); err == nil {
+			t.Errorf("%s: expected load error, got success", name)
+		}
+	}
+}
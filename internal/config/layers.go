@@ -0,0 +1,288 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+// Configuration layers, lowest precedence first: defaults < config file < environment <
+// secrets provider < flags
+const (
+	SourceDefault     = "default"
+	SourceFile        = "file"
+	SourceEnvironment = "environment"
+	SourceSecrets     = "secrets"
+	SourceFlag        = "flag"
+)
+
+// defaultConfigFile is read when CONFIG_FILE is not set; it may be absent
+const defaultConfigFile = ".env"
+
+// maskedValue replaces secrets in the effective configuration
+const maskedValue = "********"
+
+// Setting is one configuration value in effect and the layer it came from
+type Setting struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// layers records where values applied by ApplyLayers came from, and collects the
+// settings read while Effective loads the configuration
+var layers = struct {
+	mu       sync.Mutex
+	sources  map[string]string
+	settings map[string]Setting // nil unless Effective is loading
+}{sources: make(map[string]string)}
+
+// ConfigFile returns the configuration file read below the environment (CONFIG_FILE,
+// default .env in the working directory)
+func ConfigFile() string {
+	if file := os.Getenv("CONFIG_FILE"); file != "" {
+		return file
+	}
+	return defaultConfigFile
+}
+
+// ReadConfigFile reads the settings in a config file: a .env file, or a flat YAML
+// mapping of the same keys (.yaml/.yml)
+func ReadConfigFile(path string) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return readYAMLConfig(path)
+	default:
+		return godotenv.Read(path)
+	}
+}
+
+// readYAMLConfig reads a flat YAML mapping of KEY: value pairs. Values may be quoted;
+// nested mappings and lists are not supported.
+func readYAMLConfig(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || text[0] == ' ' || text[0] == '\t' || strings.HasPrefix(key, "-") {
+			return nil, fmt.Errorf("line %d: expected a top-level KEY: value pair", line)
+		}
+		value, err := yamlScalar(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", line, key, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// yamlScalar unquotes a YAML scalar and strips trailing comments from plain ones
+func yamlScalar(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	switch value[0] {
+	case '"', '\'':
+		end := strings.LastIndexByte(value, value[0])
+		if end == 0 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		if rest := strings.TrimSpace(value[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after quoted value", rest)
+		}
+		if value[0] == '\'' {
+			return strings.ReplaceAll(value[1:end], "''", "'"), nil
+		}
+		return strings.NewReplacer(`\"`, `"`, `\\`, `\`, `\n`, "\n", `\t`, "\t").Replace(value[1:end]), nil
+	case '{', '[', '|', '>':
+		return "", fmt.Errorf("only plain and quoted values are supported")
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// loadConfigFile sets variables from the config file that are not already set in the
+// environment. A missing default .env is not an error.
+func loadConfigFile() error {
+	_, err := applyConfigFile()
+	return err
+}
+
+// applyConfigFile sets variables from the config file that are not already set in the
+// environment and returns their keys
+func applyConfigFile() ([]string, error) {
+	path := ConfigFile()
+	values, err := ReadConfigFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && os.Getenv("CONFIG_FILE") == "" {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	var applied []string
+	for key, value := range values {
+		if _, set := os.LookupEnv(key); !set {
+			os.Setenv(key, value)
+			applied = append(applied, key)
+		}
+	}
+	return applied, nil
+}
+
+// ApplyLayers merges the config file and flag overrides (KEY=VALUE settings given on
+// the command line) into the process environment and records the source of each
+// value. Variables already set in the environment take precedence over the file;
+// overrides take precedence over both.
+func ApplyLayers(overrides map[string]string) error {
+	// An overridden CONFIG_FILE selects the file to read
+	if file, ok := overrides["CONFIG_FILE"]; ok {
+		os.Setenv("CONFIG_FILE", file)
+	}
+	fileKeys, err := applyConfigFile()
+	if err != nil {
+		return err
+	}
+
+	layers.mu.Lock()
+	defer layers.mu.Unlock()
+	for _, key := range fileKeys {
+		layers.sources[key] = SourceFile
+	}
+	for key, value := range overrides {
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("invalid override %s: %w", key, err)
+		}
+		layers.sources[key] = SourceFlag
+	}
+	return nil
+}
+
+// ApplySecrets sets values fetched from a secrets provider in the process environment
+// and returns the keys that changed. Secrets override the environment and the config
+// file, but not flag overrides, so keys given on the command line are left alone.
+func ApplySecrets(values map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	layers.mu.Lock()
+	defer layers.mu.Unlock()
+	var changed []string
+	var errs []error
+	for _, key := range keys {
+		if layers.sources[key] == SourceFlag {
+			continue
+		}
+		if os.Getenv(key) != values[key] {
+			if err := os.Setenv(key, values[key]); err != nil {
+				errs = append(errs, fmt.Errorf("invalid secret %s: %w", key, err))
+				continue
+			}
+			changed = append(changed, key)
+		}
+		layers.sources[key] = SourceSecrets
+	}
+	return changed, errors.Join(errs...)
+}
+
+// ParseOverride splits a KEY=VALUE command-line override
+func ParseOverride(setting string) (string, string, error) {
+	key, value, ok := strings.Cut(setting, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return "", "", fmt.Errorf("invalid setting %q (expected KEY=VALUE)", setting)
+	}
+	return strings.TrimSpace(key), value, nil
+}
+
+// source returns the layer the value of key came from
+func source(key string) string {
+	if src, ok := layers.sources[key]; ok {
+		return src
+	}
+	if _, set := os.LookupEnv(key); set {
+		return SourceEnvironment
+	}
+	return SourceDefault
+}
+
+// recordSetting notes the value in effect for key while Effective is loading. fromEnv
+// is false when the default applies because key is unset or not a valid value.
+func recordSetting(key, value string, fromEnv, secret bool) {
+	layers.mu.Lock()
+	defer layers.mu.Unlock()
+	if layers.settings == nil {
+		return
+	}
+	src := SourceDefault
+	if fromEnv {
+		src = source(key)
+	}
+	if secret && value != "" {
+		value = maskedValue
+	}
+	// A setting read twice (e.g. by several loaders) keeps its first reading
+	if _, seen := layers.settings[key]; !seen {
+		layers.settings[key] = Setting{Key: key, Value: value, Source: src}
+	}
+}
+
+// Effective loads the configuration as the service would and returns every setting
+// read, sorted by key, with the value in effect and its source. Secrets are masked.
+// The settings are returned even when the configuration is invalid.
+func Effective() ([]Setting, error) {
+	layers.mu.Lock()
+	layers.settings = make(map[string]Setting)
+	layers.mu.Unlock()
+	defer func() {
+		layers.mu.Lock()
+		layers.settings = nil
+		layers.mu.Unlock()
+	}()
+
+	var errs []error
+	if _, err := LoadSecrets(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := LoadAlerts(); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := Load(); err != nil {
+		errs = append(errs, err)
+	}
+
+	layers.mu.Lock()
+	settings := make([]Setting, 0, len(layers.settings))
+	for _, setting := range layers.settings {
+		settings = append(settings, setting)
+	}
+	layers.mu.Unlock()
+	sort.Slice(settings, func(i, j int) bool { return settings[i].Key < settings[j].Key })
+
+	return settings, errors.Join(errs...)
+}
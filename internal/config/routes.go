@@ -6,57 +6,443 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
+
+	"csv2json/internal/businessdate"
+	"csv2json/internal/converter"
+	"csv2json/internal/parser"
+	"csv2json/internal/quality"
+	"csv2json/internal/sla"
 )
 
 // Route represents a single ingestion route configuration
 type Route struct {
-	Name              string        `json:"name"`
-	IngestionContract string        `json:"ingestionContract"` // Schema/contract identifier (e.g., products.csv.v1)
-	Input             InputConfig   `json:"input"`
-	Parsing           ParsingConfig `json:"parsing"`
-	Output            OutputConfig  `json:"output"`
-	Archive           ArchiveConfig `json:"archive"`
+	Name              string              `json:"name"`
+	IngestionContract string              `json:"ingestionContract"`         // Schema/contract identifier (e.g., products.csv.v1)
+	EnforceContract   *bool               `json:"enforceContract,omitempty"` // Validate output against the registry schema (default: true when contractRegistry is set)
+	Type              string              `json:"type,omitempty"`            // "forward" (CSV to JSON, default) or "reverse" (JSON to CSV)
+	Priority          int                 `json:"priority,omitempty"`        // Higher-priority routes get processing slots first (default 0)
+	Tenant            string              `json:"tenant,omitempty"`          // Scope destinations to this tenant (default: TENANT)
+	TenantFrom        string              `json:"tenantFrom,omitempty"`      // Derive the tenant from the "route" name or input "folder" (default: TENANT_FROM)
+	Timezone          string              `json:"timezone,omitempty"`        // IANA time zone of envelope and archive timestamps (default: TIMEZONE)
+	BusinessDate      *BusinessDateConfig `json:"businessDate,omitempty"`    // Derive each file's business date (default: BUSINESS_DATE_FROM)
+	Input             InputConfig         `json:"input"`
+	Parsing           ParsingConfig       `json:"parsing"`
+	Quality           *QualityConfig      `json:"quality,omitempty"` // Data quality expectations (default: QUALITY_EXPECTATIONS)
+	Transform         TransformConfig     `json:"transform,omitzero"`
+	Output            OutputConfig        `json:"output"`
+	Archive           ArchiveConfig       `json:"archive"`
+	tenant            string              // Resolved from Tenant/TenantFrom or the environment
+	businessDate      *businessdate.Extractor
+}
+
+// BusinessDateConfig defines how a route derives the business date of each file
+type BusinessDateConfig struct {
+	From    string `json:"from"`              // "filename", "column", or "mtime"
+	Pattern string `json:"pattern,omitempty"` // Filename regular expression whose first capture group is the date (default: first YYYYMMDD or YYYY-MM-DD)
+	Column  string `json:"column,omitempty"`  // Column holding the date (column source)
+	Format  string `json:"format,omitempty"`  // Go layout of the date, e.g. 02.01.2006 (default: YYYY-MM-DD or YYYYMMDD)
+
+	LatePolicy       string `json:"latePolicy,omitempty"`       // "accept", "corrections" or "hold" for already completed dates (default: LATE_BUSINESS_DATE_POLICY)
+	CorrectionsQueue string `json:"correctionsQueue,omitempty"` // Queue restated dates are published to (default: CORRECTIONS_QUEUE)
+}
+
+// Interval is a route duration written either as a Go duration string ("500ms",
+// "2m") or as a number of whole seconds, the form older routes files use
+type Interval time.Duration
+
+// Duration returns the interval as a time.Duration
+func (i Interval) Duration() time.Duration {
+	return time.Duration(i)
+}
+
+// UnmarshalJSON accepts a duration string or a number of whole seconds
+func (i *Interval) UnmarshalJSON(data []byte) error {
+	var seconds int
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*i = Interval(time.Duration(seconds) * time.Second)
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("interval must be a duration string or whole seconds, got: %s", data)
+	}
+	parsed, err := parseInterval(value)
+	if err != nil {
+		return fmt.Errorf("invalid interval %q: %w", value, err)
+	}
+	*i = Interval(parsed)
+	return nil
+}
+
+// MarshalJSON writes whole seconds as a number and anything finer as a duration string
+func (i Interval) MarshalJSON() ([]byte, error) {
+	d := time.Duration(i)
+	if d%time.Second == 0 {
+		return json.Marshal(int(d / time.Second))
+	}
+	return json.Marshal(d.String())
+}
+
+// ByteSize is a size in bytes, given in routes.json as a number of bytes or a
+// string with a unit such as "500MB"
+type ByteSize int64
+
+// UnmarshalJSON accepts a number of bytes or a size string
+func (b *ByteSize) UnmarshalJSON(data []byte) error {
+	var n int64
+	if err := json.Unmarshal(data, &n); err == nil {
+		*b = ByteSize(n)
+		return nil
+	}
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("size must be a number of bytes or a string such as \"500MB\", got: %s", data)
+	}
+	parsed, err := parseByteSize(value)
+	if err != nil {
+		return err
+	}
+	*b = ByteSize(parsed)
+	return nil
 }
 
 // InputConfig defines input folder and filtering
 type InputConfig struct {
-	Path                  string `json:"path"`
-	FilenamePattern       string `json:"filenamePattern,omitempty"`
-	SuffixFilter          string `json:"suffixFilter,omitempty"`
-	WatchMode             string `json:"watchMode,omitempty"`                 // "event", "poll", or "hybrid"
-	PollIntervalSec       int    `json:"pollIntervalSeconds,omitempty"`       // Used in poll/hybrid modes
-	HybridPollIntervalSec int    `json:"hybridPollIntervalSeconds,omitempty"` // Backup polling in hybrid mode
-	MaxFilesPerPoll       int    `json:"maxFilesPerPoll,omitempty"`
-	compiledPattern       *regexp.Regexp
-	compiledSuffixList    []string
+	Path                 string    `json:"path"`
+	FilenamePattern      string    `json:"filenamePattern,omitempty"`
+	SuffixFilter         string    `json:"suffixFilter,omitempty"`
+	CaseInsensitive      *bool     `json:"caseInsensitive,omitempty"`           // Suffixes and patterns ignore case (default: FILENAME_CASE_INSENSITIVE)
+	UnmatchedPolicy      string    `json:"unmatchedPolicy,omitempty"`           // "archive" or "skip" files that do not match the filters (default: UNMATCHED_FILE_POLICY)
+	ExcludePattern       string    `json:"excludePattern,omitempty"`            // Files matching this regex are ignored
+	ChecksumPolicy       string    `json:"checksumPolicy,omitempty"`            // "off", "verify" or "require" checksum sidecars (default: CHECKSUM_POLICY)
+	SkipDuplicates       bool      `json:"skipDuplicates,omitempty"`            // Ignore re-deliveries with identical name and content
+	ExactlyOnce          *bool     `json:"exactlyOnce,omitempty"`               // Publish content only once, whatever its filename (default: EXACTLY_ONCE)
+	BatchManifests       bool      `json:"batchManifests,omitempty"`            // Process files only as members of *.manifest transactional batches
+	ClaimFiles           *bool     `json:"claimFiles,omitempty"`                // Claim files before processing (default: CLAIM_FILES)
+	InstanceLock         *bool     `json:"instanceLock,omitempty"`              // Lock the input folder against other instances (default: INSTANCE_LOCK)
+	WorkDir              string    `json:"workDir,omitempty"`                   // Files are moved here while processed ("" = process in place)
+	WatchMode            string    `json:"watchMode,omitempty"`                 // "event", "poll", or "hybrid"
+	PollInterval         Interval  `json:"pollIntervalSeconds,omitempty"`       // Used in poll/hybrid modes ("500ms", "2m" or whole seconds)
+	HybridPollInterval   Interval  `json:"hybridPollIntervalSeconds,omitempty"` // Backup polling in hybrid mode
+	StabilityWindow      *Interval `json:"stabilityWindow,omitempty"`           // How long a file's size must hold steady (default: FILE_STABILITY_WINDOW)
+	MissingPolicy        string    `json:"missingPolicy,omitempty"`             // "fail", "create" or "wait" when the path does not exist (default: MISSING_INPUT_POLICY)
+	MaxFilesPerPoll      int       `json:"maxFilesPerPoll,omitempty"`
+	BytesPerInterval     *ByteSize `json:"bytesPerInterval,omitempty"`     // Intake throttle, e.g. "500MB" (default: BYTES_PER_INTERVAL)
+	ThrottleInterval     Interval  `json:"throttleInterval,omitempty"`     // Interval bytesPerInterval applies to (default: THROTTLE_INTERVAL)
+	ProcessExisting      *bool     `json:"processExisting,omitempty"`      // Process files already in the folder at startup (default: PROCESS_EXISTING)
+	ProcessExistingOrder string    `json:"processExistingOrder,omitempty"` // "oldest", "newest" or "name" (default: PROCESS_EXISTING_ORDER)
+	ExpectedArrival      string    `json:"expectedArrival,omitempty"`      // Arrival deadline schedule, e.g. "daily by 06:00"
+	compiledPattern      *regexp.Regexp
+	compiledSuffixList   []string
+	compiledExclude      *regexp.Regexp
+	compiledArrival      *sla.Schedule
 }
 
 // ParsingConfig defines CSV parsing semantics
 type ParsingConfig struct {
-	HasHeader bool   `json:"hasHeader"`
-	Delimiter string `json:"delimiter"`
-	QuoteChar string `json:"quoteChar,omitempty"`
-	Encoding  string `json:"encoding,omitempty"`
+	Format            string                    `json:"format,omitempty"`            // "delimited" (default), "tsv", "whitespace", "fixed-width", or "multi-record"
+	FixedWidthColumns []parser.FixedWidthColumn `json:"fixedWidthColumns,omitempty"` // Field layout for fixed-width input
+	RecordLayouts     []parser.RecordLayout     `json:"recordLayouts,omitempty"`     // Record type layouts for multi-record input
+	HasHeader         bool                      `json:"hasHeader"`
+	Delimiter         string                    `json:"delimiter"`
+	QuoteChar         string                    `json:"quoteChar,omitempty"`
+	Encoding          string                    `json:"encoding,omitempty"`
+	InvalidUTF8Policy string                    `json:"invalidUtf8Policy,omitempty"` // "fail", "replace" (default), or "strip"
+	EmptyFilePolicy   string                    `json:"emptyFilePolicy,omitempty"`   // "fail" (default), "emitEmptyArray", or "ignore"
+	SchemaDriftPolicy string                    `json:"schemaDriftPolicy,omitempty"` // "off" (default), "warn", or "fail"
+	Workers           int                       `json:"workers,omitempty"`           // Parse large files with this many goroutines (default: PARSE_WORKERS)
+	ParallelMinMB     *int                      `json:"parallelMinMb,omitempty"`     // Smallest file parsed in parallel (default: PARALLEL_PARSE_MIN_MB)
+	RawLineField      string                    `json:"rawLineField,omitempty"`      // Field holding each record's raw source line (default: RAW_LINE_FIELD)
+	ReassembleLines   *bool                     `json:"reassembleLines,omitempty"`   // Join records split by unquoted line breaks (default: REASSEMBLE_LINES)
+	RaggedRowPolicy   string                    `json:"raggedRowPolicy,omitempty"`   // "fail", "skip", or "pad" rows with another column count (default: RAGGED_ROW_POLICY)
+	ColumnSampleRows  *int                      `json:"columnSampleRows,omitempty"`  // Sample a headerless file's column count from this many records (default: COLUMN_SAMPLE_ROWS)
+}
+
+// QualityConfig declares data quality expectations evaluated against each file
+type QualityConfig struct {
+	Policy       string               `json:"policy,omitempty"` // "fail" or "warn" when an expectation is not met (default: QUALITY_POLICY, else fail)
+	Expectations []QualityExpectation `json:"expectations"`
+	suite        *quality.Suite
+}
+
+// QualityExpectation is one data quality check on a column
+type QualityExpectation struct {
+	Column     string  `json:"column"`
+	Type       string  `json:"type"`                 // "notEmpty", "unique" or "pattern"
+	Pattern    string  `json:"pattern,omitempty"`    // Regular expression values must match (pattern type)
+	MinPercent float64 `json:"minPercent,omitempty"` // Share of rows that must pass (default: 100)
+}
+
+// TransformConfig defines row/value transforms applied between parsing and output
+type TransformConfig struct {
+	Sample           *SampleConfig  `json:"sample,omitempty"`           // Emit only a sample of rows (feed onboarding)
+	DedupKeys        []string       `json:"dedupKeys,omitempty"`        // Collapse rows with the same key combination
+	DedupKeep        string         `json:"dedupKeep,omitempty"`        // "first" (default) or "last"
+	Lookups          []LookupConfig `json:"lookups,omitempty"`          // Reference CSV enrichment
+	OutputSchema     *OutputSchema  `json:"outputSchema,omitempty"`     // Emit exactly these fields, with defaults for missing columns
+	SanitizeFormulas bool           `json:"sanitizeFormulas,omitempty"` // Neutralize leading =, +, -, @ (CSV injection)
+	SanitizePrefix   string         `json:"sanitizePrefix,omitempty"`   // Prefix for neutralized values (default: ')
+	Mask             []MaskRule     `json:"mask,omitempty"`             // Column-level PII masking rules
+	Hash             *HashConfig    `json:"hash,omitempty"`             // HMAC-SHA256 column hashing
+	SortBy           []SortKey      `json:"sortBy,omitempty"`           // Order rows before output
+	SortMemoryRows   int            `json:"sortMemoryRows,omitempty"`   // Rows in each sorted run spilled to disk (default: 100000)
+	GroupBy          *GroupConfig   `json:"groupBy,omitempty"`          // Nest child rows under parent objects
+}
+
+// SortKey defines one column of a sort specification
+type SortKey struct {
+	Column string `json:"column"`
+	Order  string `json:"order,omitempty"` // "asc" (default) or "desc"
+	Type   string `json:"type,omitempty"`  // "string" (default) or "number"
+}
+
+// GroupConfig defines how flat rows are nested into parent objects with child arrays
+type GroupConfig struct {
+	By            []string `json:"by"`                      // Columns identifying a parent (e.g. order_id)
+	ParentColumns []string `json:"parentColumns,omitempty"` // Columns kept on the parent (default: by)
+	ChildKey      string   `json:"childKey,omitempty"`      // Nested array field name (default: items)
+}
+
+// SampleConfig defines sampling of rows for onboarding new feeds
+type SampleConfig struct {
+	Rows int    `json:"rows"`
+	Mode string `json:"mode,omitempty"` // "head" (default) or "random"
+}
+
+// HashConfig defines columns replaced with HMAC-SHA256 hashes keyed by a salt
+type HashConfig struct {
+	Columns  []string `json:"columns"`
+	Salt     string   `json:"salt,omitempty"`
+	SaltFile string   `json:"saltFile,omitempty"` // Read salt from a secret file (takes precedence over salt)
+}
+
+// OutputSchema declares the fields every record is emitted with, in order
+type OutputSchema struct {
+	Fields        []SchemaField `json:"fields"`
+	ExtraColumns  string        `json:"extraColumns,omitempty"`  // "drop" (default) or "flag" columns not in fields
+	StrictColumns bool          `json:"strictColumns,omitempty"` // Fail files containing columns not in fields
+}
+
+// SchemaField is one output field and the value emitted when a file lacks the column
+type SchemaField struct {
+	Name    string `json:"name"`
+	Default string `json:"default,omitempty"` // Default: "" (ADR-003)
+}
+
+// LookupConfig defines a reference CSV joined into each record by key
+type LookupConfig struct {
+	Path      string   `json:"path"`
+	Key       string   `json:"key"`                 // Input column used for the join
+	LookupKey string   `json:"lookupKey,omitempty"` // Reference column matched against key (default: same as key)
+	Columns   []string `json:"columns,omitempty"`   // Reference columns to add (default: all except lookupKey)
+	Delimiter string   `json:"delimiter,omitempty"` // Reference CSV delimiter (default: ,)
+}
+
+// MaskRule defines masking for a single column
+type MaskRule struct {
+	Column   string `json:"column"`
+	Mode     string `json:"mode"`               // "full", "partial", "format", or "drop"
+	Keep     int    `json:"keep,omitempty"`     // Trailing characters left visible (partial: default 4)
+	MaskChar string `json:"maskChar,omitempty"` // Mask character (default: *)
 }
 
 // OutputConfig defines destination and type
 type OutputConfig struct {
-	Type            string `json:"type"` // "file" or "queue"
-	Destination     string `json:"destination"`
-	IncludeEnvelope *bool  `json:"includeEnvelope,omitempty"` // Include full message envelope with provenance (ADR-006)
+	Type               string             `json:"type"`                         // "file", "queue", "both", "stdout" or "pipe"
+	Destination        string             `json:"destination,omitempty"`        // Output folder (file), queue (queue) or named pipe (pipe)
+	Folder             string             `json:"folder,omitempty"`             // Output folder of "both" output
+	Queue              string             `json:"queue,omitempty"`              // Queue of "both" output (name or rabbitmq:// URI)
+	IncludeEnvelope    *bool              `json:"includeEnvelope,omitempty"`    // Include full message envelope with provenance (ADR-006)
+	PayloadFormat      string             `json:"payloadFormat,omitempty"`      // "envelope", "legacy" or "bare" (replaces includeEnvelope)
+	ContentType        string             `json:"contentType,omitempty"`        // Queue message content type (default: MESSAGE_CONTENT_TYPE)
+	Schema             string             `json:"schema,omitempty"`             // Sent in the x-schema header (default: MESSAGE_SCHEMA)
+	Identifier         string             `json:"identifier,omitempty"`         // Legacy message identifier template, e.g. "{route}/{filename}" (default: LEGACY_IDENTIFIER)
+	ASCIISafe          bool               `json:"asciiSafe,omitempty"`          // Escape non-ASCII characters in output JSON as \uXXXX
+	OutputShape        string             `json:"outputShape,omitempty"`        // "array" or "object" for files of exactly one data row (default: OUTPUT_SHAPE)
+	Wrapper            *FileWrapperConfig `json:"wrapper,omitempty"`            // Wrap output files in a top-level object (default: FILE_WRAPPER)
+	TypeInference      *bool              `json:"typeInference,omitempty"`      // Render numbers, booleans and nulls as native JSON values (default: TYPE_INFERENCE)
+	ColumnTypes        []ColumnType       `json:"columnTypes,omitempty"`        // Types of these columns' values, overriding inference (default: COLUMN_TYPES)
+	PartitionBy        string             `json:"partitionBy,omitempty"`        // Split each file into one output per distinct value of this column
+	Batch              *BatchConfig       `json:"batch,omitempty"`              // Merge small files into combined outputs
+	Kafka              *KafkaConfig       `json:"kafka,omitempty"`              // Kafka message key/partition derivation
+	RabbitMQ           *RabbitMQConfig    `json:"rabbitmq,omitempty"`           // Exchange and templated routing keys
+	Shards             *ShardConfig       `json:"shards,omitempty"`             // Distribute queue output across several queues
+	Encryption         *EncryptionConfig  `json:"encryption,omitempty"`         // Encrypt queue message bodies (default: PAYLOAD_ENCRYPTION_KEY)
+	Signing            *SigningConfig     `json:"signing,omitempty"`            // Sign queue message bodies (default: MESSAGE_SIGNING_KEY)
+	ReceiptLog         string             `json:"receiptLog,omitempty"`         // NDJSON delivery receipt log (default: RECEIPT_LOG)
+	PublisherConfirms  *bool              `json:"publisherConfirms,omitempty"`  // Wait for broker confirms (default: PUBLISHER_CONFIRMS)
+	LazyConnect        *bool              `json:"lazyConnect,omitempty"`        // Connect to the broker on the first publish (default: QUEUE_LAZY_CONNECT)
+	DownstreamAck      *bool              `json:"downstreamAck,omitempty"`      // Wait for a downstream reply before archiving (default: DOWNSTREAM_ACK)
+	DownstreamAckQueue string             `json:"downstreamAckQueue,omitempty"` // Reply queue (default: DOWNSTREAM_ACK_QUEUE)
+	Report             string             `json:"report,omitempty"`             // Processing report queue (rabbitmq://name) or folder (default: REPORT_DESTINATION)
+	queueDest          QueueDestination   // Parsed Destination for queue output
+}
+
+// MessageFormat returns the payload format of queue messages: payloadFormat, bare for
+// content types other than JSON, legacy when includeEnvelope is false, else the
+// ADR-006 envelope
+func (o OutputConfig) MessageFormat() string {
+	switch {
+	case o.PayloadFormat != "":
+		return o.PayloadFormat
+	case o.ContentType != "" && o.ContentType != ContentTypeJSON:
+		return PayloadFormatBare
+	case o.IncludeEnvelope != nil && !*o.IncludeEnvelope:
+		return PayloadFormatLegacy
+	default:
+		return PayloadFormatEnvelope
+	}
+}
+
+// FileTarget returns the folder output files are written to ("" without file output)
+func (o *OutputConfig) FileTarget() string {
+	switch o.Type {
+	case "file":
+		return o.Destination
+	case "both":
+		return o.Folder
+	}
+	return ""
+}
+
+// QueueTarget returns the queue destination messages are published to ("" without queue output)
+func (o *OutputConfig) QueueTarget() string {
+	switch o.Type {
+	case "queue":
+		return o.Destination
+	case "both":
+		return o.Queue
+	}
+	return ""
+}
+
+// writesFiles reports whether the route writes output files
+func (o *OutputConfig) writesFiles() bool {
+	return o.Type == "file" || o.Type == "both"
+}
+
+// publishes reports whether the route publishes to a queue
+func (o *OutputConfig) publishes() bool {
+	return o.Type == "queue" || o.Type == "both"
+}
+
+// RabbitMQConfig defines exchange publishing with templated routing keys
+type RabbitMQConfig struct {
+	Exchange     string `json:"exchange,omitempty"`
+	ExchangeType string `json:"exchangeType,omitempty"` // "topic" (default), "direct", "fanout", "headers"
+	RoutingKey   string `json:"routingKey,omitempty"`   // Template, e.g. "ingest.{route}.{filenamePrefix}"
+	BindingKey   string `json:"bindingKey,omitempty"`   // Binding for the destination queue (default "#" for topic)
+}
+
+// EncryptionConfig defines AES-GCM encryption of queue message bodies
+type EncryptionConfig struct {
+	KeyID   string `json:"keyId"`   // Key identifier sent with every message
+	KeyFile string `json:"keyFile"` // Secret file holding the base64 key
+	Key     string `json:"-"`       // Resolved from keyFile
+}
+
+// SigningConfig defines signing of queue message bodies
+type SigningConfig struct {
+	Algorithm string `json:"algorithm,omitempty"` // "hmac-sha256" (default) or "ed25519"
+	KeyID     string `json:"keyId"`               // Key identifier sent with every message
+	KeyFile   string `json:"keyFile"`             // Secret file holding the base64 key
+	Key       string `json:"-"`                   // Resolved from keyFile
+}
+
+// ShardConfig distributes queue output across several queues
+type ShardConfig struct {
+	Queues   []string `json:"queues"`             // Queue destinations, e.g. ["rabbitmq://orders.0", "rabbitmq://orders.1"]
+	Strategy string   `json:"strategy,omitempty"` // "roundRobin" (default) or "hash"
+	Column   string   `json:"column,omitempty"`   // Column hashed by the hash strategy
+}
+
+// KafkaConfig defines how Kafka message keys and partitions are derived per message
+type KafkaConfig struct {
+	MessageKey string `json:"messageKey,omitempty"` // Key template, e.g. "{col:customer_id}"
+	Partition  string `json:"partition,omitempty"`  // Explicit partition template (must resolve to an integer)
+}
+
+// BatchConfig defines merge window batching of multiple files into one output
+type BatchConfig struct {
+	WindowSec int `json:"windowSec,omitempty"` // Flush files accumulated over this many seconds
+	MaxFiles  int `json:"maxFiles,omitempty"`  // Flush once this many files are queued
+}
+
+// FileWrapperConfig names the fields of the top-level object output files are wrapped
+// in: the source filename, when the output was generated, and the converted records
+type FileWrapperConfig struct {
+	File        string `json:"file,omitempty"`        // Source filename field (default: file)
+	GeneratedAt string `json:"generatedAt,omitempty"` // Generation timestamp field (default: generatedAt)
+	Records     string `json:"records,omitempty"`     // Records field (default: records)
+}
+
+// applyDefaults names the fields left unset after their defaults
+func (w *FileWrapperConfig) applyDefaults() {
+	if w.File == "" {
+		w.File = defaultWrapperFile
+	}
+	if w.GeneratedAt == "" {
+		w.GeneratedAt = defaultWrapperGeneratedAt
+	}
+	if w.Records == "" {
+		w.Records = defaultWrapperRecords
+	}
+}
+
+// ColumnType declares the JSON type of a column's values
+type ColumnType struct {
+	Column string `json:"column"`
+	Type   string `json:"type"` // "string", "number", "integer" or "boolean"
 }
 
 // ArchiveConfig defines archive paths
 type ArchiveConfig struct {
-	ProcessedPath string `json:"processedPath"`
-	FailedPath    string `json:"failedPath"`
-	IgnoredPath   string `json:"ignoredPath,omitempty"`
+	ProcessedPath  string `json:"processedPath"`
+	FailedPath     string `json:"failedPath"`
+	IgnoredPath    string `json:"ignoredPath,omitempty"`
+	ColumnStats    *bool  `json:"columnStats,omitempty"`    // Write a .stats.json column statistics sidecar per file (default: COLUMN_STATS)
+	FailurePolicy  string `json:"failurePolicy,omitempty"`  // "reprocess", "retry", "quarantine" or "mark" when a sent file cannot be archived (default: ARCHIVE_FAILURE_POLICY)
+	QuarantinePath string `json:"quarantinePath,omitempty"` // Quarantine folder (default: ARCHIVE_QUARANTINE, else .quarantine in the input folder)
+	ByBusinessDate *bool  `json:"byBusinessDate,omitempty"` // Archive files under a YYYY-MM-DD folder of their business date (default: ARCHIVE_BY_BUSINESS_DATE)
 }
 
 // RoutesConfig represents the complete routes.json structure
 type RoutesConfig struct {
-	Routes []Route `json:"routes"`
+	MaxConcurrentFiles int            `json:"maxConcurrentFiles,omitempty"` // Processing budget shared by all routes (default: MAX_CONCURRENT_FILES, 0 = unlimited)
+	StartupPolicy      string         `json:"startupPolicy,omitempty"`      // "failFast" or "skipInvalid" (default: ROUTE_STARTUP_POLICY)
+	SharedConnection   *bool          `json:"sharedConnection,omitempty"`   // Routes publishing to the same broker share one connection (default: QUEUE_SHARED_CONNECTION)
+	ContractRegistry   string         `json:"contractRegistry,omitempty"`   // Schema registry enforcing ingestionContract (default: CONTRACT_REGISTRY, "" = label only)
+	RegistryToken      string         `json:"-"`                            // Bearer token for HTTP registries (CONTRACT_REGISTRY_TOKEN)
+	RegistryTimeout    time.Duration  `json:"-"`                            // Timeout for fetching each contract (CONTRACT_REGISTRY_TIMEOUT_SECONDS)
+	Routes             []Route        `json:"routes"`
+	Skipped            []SkippedRoute `json:"-"` // Invalid routes skipped under the skipInvalid policy
+}
+
+// Route types
+const (
+	RouteTypeForward = "forward" // CSV input converted to JSON (default)
+	RouteTypeReverse = "reverse" // JSON array input flattened back to CSV files
+)
+
+// Startup policies for routes that fail validation
+const (
+	StartupPolicyFailFast    = "failFast"    // Any invalid route stops the service (default)
+	StartupPolicySkipInvalid = "skipInvalid" // Invalid routes are skipped; the remaining routes start
+)
+
+// Policies for a route whose input path does not exist at startup
+const (
+	MissingInputFail   = "fail"   // The route is invalid (default)
+	MissingInputCreate = "create" // The folder is created
+	MissingInputWait   = "wait"   // The route starts once the folder appears, e.g. a late mount
+)
+
+// SkippedRoute records a route skipped at startup and why
+type SkippedRoute struct {
+	Name string
+	Err  error
 }
 
 // LoadRoutes loads routes from the JSON configuration file
@@ -77,86 +463,679 @@ func LoadRoutes(configPath string) (*RoutesConfig, error) {
 		return nil, fmt.Errorf("failed to parse routes JSON: %w", err)
 	}
 
-	// Validate and compile patterns
+	// Shared processing budget falls back to the global setting
+	if routesConfig.MaxConcurrentFiles == 0 {
+		routesConfig.MaxConcurrentFiles = getIntEnv("MAX_CONCURRENT_FILES", 0)
+	}
+	if routesConfig.MaxConcurrentFiles < 0 {
+		return nil, fmt.Errorf("invalid maxConcurrentFiles: must not be negative")
+	}
+
+	// Routes publishing to the same broker share a connection unless disabled
+	if routesConfig.SharedConnection == nil {
+		shared := getBoolEnv("QUEUE_SHARED_CONNECTION", true)
+		routesConfig.SharedConnection = &shared
+	}
+
+	// Contracts are enforced against a registry when one is configured
+	if routesConfig.ContractRegistry == "" {
+		routesConfig.ContractRegistry = getEnv("CONTRACT_REGISTRY", "")
+	}
+	if routesConfig.RegistryToken, err = getSecretEnv("CONTRACT_REGISTRY_TOKEN"); err != nil {
+		return nil, err
+	}
+	routesConfig.RegistryTimeout = getDurationEnv("CONTRACT_REGISTRY_TIMEOUT_SECONDS", 30) * time.Second
+	if routesConfig.RegistryTimeout <= 0 {
+		return nil, fmt.Errorf("CONTRACT_REGISTRY_TIMEOUT_SECONDS must be >= 1")
+	}
+
+	// Validate and compile each route, applying the startup policy to invalid routes
+	if routesConfig.StartupPolicy == "" {
+		routesConfig.StartupPolicy = getEnv("ROUTE_STARTUP_POLICY", StartupPolicyFailFast)
+	}
+	if routesConfig.StartupPolicy != StartupPolicyFailFast && routesConfig.StartupPolicy != StartupPolicySkipInvalid {
+		return nil, fmt.Errorf("unsupported startupPolicy: %s (supported: failFast, skipInvalid)", routesConfig.StartupPolicy)
+	}
+
+	valid := routesConfig.Routes[:0]
 	for i := range routesConfig.Routes {
-		route := &routesConfig.Routes[i]
+		route := routesConfig.Routes[i]
+		if err := route.prepare(i); err != nil {
+			if routesConfig.StartupPolicy == StartupPolicyFailFast {
+				return nil, err
+			}
+			routesConfig.Skipped = append(routesConfig.Skipped, SkippedRoute{Name: route.Name, Err: err})
+			continue
+		}
+		valid = append(valid, route)
+	}
+	routesConfig.Routes = valid
+
+	// A working directory's orphans are returned to its route's input folder, so routes cannot share one
+	workDirs := make(map[string]string)
+	for _, route := range routesConfig.Routes {
+		if route.Input.WorkDir == "" {
+			continue
+		}
+		dir := filepath.Clean(route.Input.WorkDir)
+		if other, ok := workDirs[dir]; ok {
+			return nil, fmt.Errorf("routes '%s' and '%s' share input.workDir %s", other, route.Name, route.Input.WorkDir)
+		}
+		workDirs[dir] = route.Name
+	}
 
-		// Validate required fields
-		if route.Name == "" {
-			return nil, fmt.Errorf("route at index %d missing required field 'name'", i)
+	if len(routesConfig.Routes) == 0 && len(routesConfig.Skipped) > 0 {
+		return nil, fmt.Errorf("all %d route(s) are invalid; first error: %w", len(routesConfig.Skipped), routesConfig.Skipped[0].Err)
+	}
+
+	return &routesConfig, nil
+}
+
+// prepare validates a route, applies defaults and compiles its filters.
+// index is the route's position in routes.json, used when it has no name.
+func (r *Route) prepare(index int) error {
+	// Validate required fields
+	if r.Name == "" {
+		return fmt.Errorf("route at index %d missing required field 'name'", index)
+	}
+	if r.IngestionContract == "" {
+		return fmt.Errorf("route '%s': missing required field 'ingestionContract' (e.g., products.csv.v1)", r.Name)
+	}
+	if r.Input.Path == "" {
+		return fmt.Errorf("route '%s': missing required field 'input.path'", r.Name)
+	}
+	if r.Output.Shards != nil && len(r.Output.Shards.Queues) > 0 {
+		// Shown wherever a single destination is reported
+		if r.Output.Type == "both" && r.Output.Queue == "" {
+			r.Output.Queue = r.Output.Shards.Queues[0]
+		} else if r.Output.Type != "both" && r.Output.Destination == "" {
+			r.Output.Destination = r.Output.Shards.Queues[0]
 		}
-		if route.IngestionContract == "" {
-			return nil, fmt.Errorf("route '%s': missing required field 'ingestionContract' (e.g., products.csv.v1)", route.Name)
+	}
+	switch r.Output.Type {
+	case "":
+		return fmt.Errorf("route '%s': missing required output configuration", r.Name)
+	case "file", "queue", "pipe":
+		if r.Output.Destination == "" {
+			return fmt.Errorf("route '%s': missing required output configuration", r.Name)
 		}
-		if route.Input.Path == "" {
-			return nil, fmt.Errorf("route '%s': missing required field 'input.path'", route.Name)
+	case "stdout":
+		if r.Output.Destination != "" {
+			return fmt.Errorf("route '%s': output type 'stdout' takes no output.destination", r.Name)
 		}
-		if route.Output.Type == "" || route.Output.Destination == "" {
-			return nil, fmt.Errorf("route '%s': missing required output configuration", route.Name)
+	case "both":
+		if r.Output.Folder == "" || r.Output.Queue == "" {
+			return fmt.Errorf("route '%s': output type 'both' requires output.folder and output.queue", r.Name)
 		}
-		if route.Archive.ProcessedPath == "" || route.Archive.FailedPath == "" {
-			return nil, fmt.Errorf("route '%s': missing required archive paths", route.Name)
+		if r.Output.Destination != "" {
+			return fmt.Errorf("route '%s': output type 'both' uses output.folder and output.queue instead of output.destination", r.Name)
 		}
+	default:
+		return fmt.Errorf("route '%s': unsupported output.type: %s (supported: file, queue, both, stdout, pipe)", r.Name, r.Output.Type)
+	}
+	if r.Archive.ProcessedPath == "" || r.Archive.FailedPath == "" {
+		return fmt.Errorf("route '%s': missing required archive paths", r.Name)
+	}
+	if r.Archive.FailurePolicy == "" {
+		r.Archive.FailurePolicy = getEnv("ARCHIVE_FAILURE_POLICY", ArchiveFailureReprocess)
+	}
+	if err := validateArchiveFailurePolicy(r.Archive.FailurePolicy); err != nil {
+		return fmt.Errorf("route '%s': invalid archive.failurePolicy: %w", r.Name, err)
+	}
 
-		// Verify paths exist
-		if _, err := os.Stat(route.Input.Path); os.IsNotExist(err) {
-			return nil, fmt.Errorf("route '%s': input path does not exist: %s", route.Name, route.Input.Path)
+	if r.Type == "" {
+		r.Type = RouteTypeForward
+	}
+	if r.Type != RouteTypeForward && r.Type != RouteTypeReverse {
+		return fmt.Errorf("route '%s': unsupported type: %s (supported: forward, reverse)", r.Name, r.Type)
+	}
+	if r.Type == RouteTypeReverse && (r.Output.Type != "file" || r.Output.PartitionBy != "" || r.Output.Batch != nil) {
+		return fmt.Errorf("route '%s': reverse routes require file output without partitionBy or batch", r.Name)
+	}
+	if r.Input.BatchManifests && (r.Type == RouteTypeReverse || r.Output.Batch != nil) {
+		return fmt.Errorf("route '%s': input.batchManifests cannot be combined with reverse routes or output.batch", r.Name)
+	}
+	if r.Input.ExactlyOnce == nil {
+		exactlyOnce := getBoolEnv("EXACTLY_ONCE", false)
+		r.Input.ExactlyOnce = &exactlyOnce
+	}
+	if *r.Input.ExactlyOnce && r.Input.BatchManifests {
+		return fmt.Errorf("route '%s': input.exactlyOnce cannot be combined with input.batchManifests", r.Name)
+	}
+
+	// Verify paths exist, or create or wait for them as the route asks
+	if r.Input.MissingPolicy == "" {
+		r.Input.MissingPolicy = getEnv("MISSING_INPUT_POLICY", MissingInputFail)
+	}
+	switch r.Input.MissingPolicy {
+	case MissingInputFail, MissingInputCreate, MissingInputWait:
+	default:
+		return fmt.Errorf("route '%s': unsupported input.missingPolicy: %s (supported: fail, create, wait)", r.Name, r.Input.MissingPolicy)
+	}
+	if _, err := os.Stat(r.Input.Path); os.IsNotExist(err) {
+		switch r.Input.MissingPolicy {
+		case MissingInputCreate:
+			if err := os.MkdirAll(r.Input.Path, 0755); err != nil {
+				return fmt.Errorf("route '%s': failed to create input path: %w", r.Name, err)
+			}
+		case MissingInputWait:
+			// Started later by the caller; see InputReady
+		default:
+			return fmt.Errorf("route '%s': input path does not exist: %s", r.Name, r.Input.Path)
 		}
+	}
 
-		// Set defaults
-		if route.Input.WatchMode == "" {
-			route.Input.WatchMode = "event" // Default to event-driven
+	// Set defaults
+	// Detection settings fall back to the global settings
+	if r.Input.WatchMode == "" {
+		r.Input.WatchMode = getEnv("WATCH_MODE", "event")
+	}
+	if err := validateWatchMode(r.Input.WatchMode); err != nil {
+		return fmt.Errorf("route '%s': invalid input.watchMode: %w", r.Name, err)
+	}
+	if r.Input.PollInterval == 0 {
+		r.Input.PollInterval = Interval(getIntervalEnv("POLL_INTERVAL_SECONDS", 5*time.Second)) // Poll mode, and polling fallback
+	}
+	if r.Input.HybridPollInterval == 0 {
+		r.Input.HybridPollInterval = Interval(getIntervalEnv("HYBRID_POLL_INTERVAL_SECONDS", 60*time.Second)) // Backup polling in hybrid mode
+	}
+	if r.Input.PollInterval.Duration() < MinPollInterval || r.Input.HybridPollInterval.Duration() < MinPollInterval {
+		return fmt.Errorf("route '%s': input.pollIntervalSeconds and input.hybridPollIntervalSeconds must be at least %s", r.Name, MinPollInterval)
+	}
+	if r.Input.StabilityWindow == nil {
+		window := Interval(getIntervalEnv("FILE_STABILITY_WINDOW", 2*time.Second))
+		r.Input.StabilityWindow = &window
+	}
+	if *r.Input.StabilityWindow < 0 {
+		return fmt.Errorf("route '%s': input.stabilityWindow must not be negative", r.Name)
+	}
+	if r.Parsing.Format == "" {
+		r.Parsing.Format = parser.FormatDelimited
+	}
+	if err := validateInputFormat(r.Parsing.Format, r.Parsing.FixedWidthColumns); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.format: %w", r.Name, err)
+	}
+	if err := validateRecordLayouts(r.Parsing.Format, r.Parsing.RecordLayouts, r.Transform.GroupBy != nil); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.recordLayouts: %w", r.Name, err)
+	}
+	if r.Parsing.ReassembleLines == nil {
+		reassemble := getBoolEnv("REASSEMBLE_LINES", false)
+		r.Parsing.ReassembleLines = &reassemble
+	}
+	if *r.Parsing.ReassembleLines && r.Parsing.Format != parser.FormatDelimited && r.Parsing.Format != parser.FormatTSV {
+		return fmt.Errorf("route '%s': parsing.reassembleLines requires the delimited or tsv format", r.Name)
+	}
+	if r.Parsing.Format == parser.FormatTSV {
+		// The TSV profile implies the tab delimiter and .tsv/.txt files
+		if r.Parsing.Delimiter == "" {
+			r.Parsing.Delimiter = "\t"
 		}
-		if route.Input.PollIntervalSec == 0 {
-			route.Input.PollIntervalSec = 5 // Default poll interval for poll/fallback modes
+		if r.Parsing.Delimiter != "\t" {
+			return fmt.Errorf("route '%s': parsing.format tsv uses the tab delimiter, got %q", r.Name, r.Parsing.Delimiter)
 		}
-		if route.Input.HybridPollIntervalSec == 0 {
-			route.Input.HybridPollIntervalSec = 60 // Default backup polling in hybrid mode
+		if r.Input.SuffixFilter == "" {
+			r.Input.SuffixFilter = tsvSuffixFilter
 		}
-		if route.Parsing.Delimiter == "" {
-			route.Parsing.Delimiter = ","
+	}
+	if r.Parsing.Delimiter == "" {
+		r.Parsing.Delimiter = ","
+	}
+	if r.Parsing.QuoteChar == "" {
+		r.Parsing.QuoteChar = "\""
+	}
+	if r.Parsing.Encoding == "" {
+		r.Parsing.Encoding = "utf-8"
+	}
+	if _, err := parser.NormalizeEncoding(r.Parsing.Encoding); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.encoding: %w", r.Name, err)
+	}
+	if r.Parsing.InvalidUTF8Policy == "" {
+		r.Parsing.InvalidUTF8Policy = "replace"
+	}
+	if err := parser.ValidateInvalidUTF8Policy(r.Parsing.InvalidUTF8Policy); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.invalidUtf8Policy: %w", r.Name, err)
+	}
+	if r.Parsing.RaggedRowPolicy == "" {
+		r.Parsing.RaggedRowPolicy = getEnv("RAGGED_ROW_POLICY", parser.RaggedRowFail)
+	}
+	if err := parser.ValidateRaggedRowPolicy(r.Parsing.RaggedRowPolicy); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.raggedRowPolicy: %w", r.Name, err)
+	}
+	if r.Parsing.ColumnSampleRows == nil {
+		sampleRows := getIntEnv("COLUMN_SAMPLE_ROWS", 0)
+		r.Parsing.ColumnSampleRows = &sampleRows
+	}
+	if *r.Parsing.ColumnSampleRows < 0 {
+		return fmt.Errorf("route '%s': parsing.columnSampleRows must not be negative", r.Name)
+	}
+	if r.Parsing.Workers < 0 || (r.Parsing.ParallelMinMB != nil && *r.Parsing.ParallelMinMB < 0) {
+		return fmt.Errorf("route '%s': parsing.workers and parsing.parallelMinMb must not be negative", r.Name)
+	}
+	if r.Parsing.EmptyFilePolicy == "" {
+		r.Parsing.EmptyFilePolicy = EmptyFilePolicyFail
+	}
+	if err := validateEmptyFilePolicy(r.Parsing.EmptyFilePolicy); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.emptyFilePolicy: %w", r.Name, err)
+	}
+	if r.Parsing.SchemaDriftPolicy == "" {
+		r.Parsing.SchemaDriftPolicy = SchemaDriftPolicyOff
+	}
+	if err := validateSchemaDriftPolicy(r.Parsing.SchemaDriftPolicy); err != nil {
+		return fmt.Errorf("route '%s': invalid parsing.schemaDriftPolicy: %w", r.Name, err)
+	}
+	if r.Quality == nil {
+		r.Quality = &QualityConfig{Policy: getEnv("QUALITY_POLICY", QualityPolicyFail)}
+		expectations, err := parseQualityExpectations(getEnv("QUALITY_EXPECTATIONS", ""))
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid QUALITY_EXPECTATIONS: %w", r.Name, err)
+		}
+		for _, e := range expectations {
+			r.Quality.Expectations = append(r.Quality.Expectations, QualityExpectation(e))
+		}
+	}
+	if r.Quality.Policy == "" {
+		r.Quality.Policy = getEnv("QUALITY_POLICY", QualityPolicyFail)
+	}
+	if err := validateQualityPolicy(r.Quality.Policy); err != nil {
+		return fmt.Errorf("route '%s': invalid quality.policy: %w", r.Name, err)
+	}
+	if len(r.Quality.Expectations) > 0 {
+		expectations := make([]quality.Expectation, len(r.Quality.Expectations))
+		for i, e := range r.Quality.Expectations {
+			expectations[i] = quality.Expectation(e)
 		}
-		if route.Parsing.QuoteChar == "" {
-			route.Parsing.QuoteChar = "\""
+		suite, err := quality.NewSuite(expectations)
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid quality.expectations: %w", r.Name, err)
 		}
-		if route.Parsing.Encoding == "" {
-			route.Parsing.Encoding = "utf-8"
+		r.Quality.suite = suite
+	}
+	if schema := r.Transform.OutputSchema; schema != nil {
+		if schema.ExtraColumns == "" {
+			schema.ExtraColumns = ExtraColumnsDrop
 		}
-		// Default includeEnvelope to true for queue output (nil = not explicitly set)
-		if route.Output.Type == "queue" && route.Output.IncludeEnvelope == nil {
-			defaultTrue := true
-			route.Output.IncludeEnvelope = &defaultTrue
+		if len(schema.Fields) == 0 {
+			return fmt.Errorf("route '%s': transform.outputSchema.fields must not be empty", r.Name)
 		}
-
-		// Compile filename pattern if specified
-		if route.Input.FilenamePattern != "" {
-			compiled, err := regexp.Compile(route.Input.FilenamePattern)
+		if err := validateOutputSchema(schema.Fields, schema.ExtraColumns); err != nil {
+			return fmt.Errorf("route '%s': invalid transform.outputSchema: %w", r.Name, err)
+		}
+	}
+	if r.Output.PartitionBy == "" && (strings.Contains(r.Output.FileTarget(), partitionPlaceholder) || strings.Contains(r.Output.QueueTarget(), partitionPlaceholder)) {
+		return fmt.Errorf("route '%s': output.partitionBy must be set when the output destination contains %s", r.Name, partitionPlaceholder)
+	}
+	if r.BusinessDate == nil {
+		if from := getEnv("BUSINESS_DATE_FROM", ""); from != "" {
+			r.BusinessDate = &BusinessDateConfig{
+				From:    from,
+				Pattern: getEnv("BUSINESS_DATE_PATTERN", ""),
+				Column:  getEnv("BUSINESS_DATE_COLUMN", ""),
+				Format:  getEnv("BUSINESS_DATE_FORMAT", ""),
+			}
+		}
+	}
+	if r.BusinessDate != nil {
+		bd := r.BusinessDate
+		extractor, err := businessdate.New(businessdate.Spec{From: bd.From, Pattern: bd.Pattern, Column: bd.Column, Format: bd.Format})
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid businessDate: %w", r.Name, err)
+		}
+		r.businessDate = extractor
+		if bd.LatePolicy == "" {
+			bd.LatePolicy = getEnv("LATE_BUSINESS_DATE_POLICY", LateBusinessDateAccept)
+		}
+		if bd.CorrectionsQueue == "" && bd.LatePolicy == LateBusinessDateCorrections {
+			bd.CorrectionsQueue = getEnv("CORRECTIONS_QUEUE", "")
+		}
+		if err := validateLateDatePolicy(bd.LatePolicy, bd.CorrectionsQueue, true, r.Output.Type); err != nil {
+			return fmt.Errorf("route '%s': invalid businessDate.latePolicy: %w", r.Name, err)
+		}
+	}
+	if r.Archive.ByBusinessDate == nil {
+		byDate := getBoolEnv("ARCHIVE_BY_BUSINESS_DATE", false)
+		r.Archive.ByBusinessDate = &byDate
+	}
+	if err := validateBusinessDate(r.businessDate != nil, *r.Archive.ByBusinessDate, r.Output.FileTarget(), r.Output.Batch != nil || r.Input.BatchManifests); err != nil {
+		return fmt.Errorf("route '%s': invalid businessDate: %w", r.Name, err)
+	}
+	if r.Output.Batch != nil {
+		batch := r.Output.Batch
+		if err := validateBatching(time.Duration(batch.WindowSec)*time.Second, batch.MaxFiles, r.Output.PartitionBy); err != nil {
+			return fmt.Errorf("route '%s': invalid output.batch: %w", r.Name, err)
+		}
+	}
+	if err := validateReportDestination(r.Output.Report); err != nil {
+		return fmt.Errorf("route '%s': invalid output.report: %w", r.Name, err)
+	}
+	if r.Output.PayloadFormat != "" {
+		if r.Output.IncludeEnvelope != nil {
+			return fmt.Errorf("route '%s': output.payloadFormat replaces output.includeEnvelope, set only one", r.Name)
+		}
+		if err := validatePayloadFormat(r.Output.PayloadFormat); err != nil {
+			return fmt.Errorf("route '%s': invalid output.payloadFormat: %w", r.Name, err)
+		}
+	}
+	if r.Output.ContentType == "" {
+		r.Output.ContentType = getEnv("MESSAGE_CONTENT_TYPE", ContentTypeJSON)
+	}
+	if err := validateContentType(r.Output.ContentType, r.Transform.GroupBy != nil || r.Parsing.Format == parser.FormatMulti); err != nil {
+		return fmt.Errorf("route '%s': invalid output.contentType: %w", r.Name, err)
+	}
+	// Messages other than JSON carry the records alone
+	if r.Output.ContentType != ContentTypeJSON && (r.Output.IncludeEnvelope != nil || (r.Output.PayloadFormat != "" && r.Output.PayloadFormat != PayloadFormatBare)) {
+		return fmt.Errorf("route '%s': output.contentType %s requires the bare payload format", r.Name, r.Output.ContentType)
+	}
+	if r.Output.OutputShape == "" {
+		r.Output.OutputShape = getEnv("OUTPUT_SHAPE", converter.ShapeArray)
+	}
+	if err := validateOutputShape(r.Output.OutputShape, r.Output.Batch != nil || r.Input.BatchManifests, r.Parsing.EmptyFilePolicy, r.Output.ContentType); err != nil {
+		return fmt.Errorf("route '%s': invalid output.outputShape: %w", r.Name, err)
+	}
+	if r.Type == RouteTypeReverse && (r.Output.TypeInference != nil || r.Output.ColumnTypes != nil) {
+		return fmt.Errorf("route '%s': output.typeInference and output.columnTypes are not supported by reverse routes, which write CSV files", r.Name)
+	}
+	if r.Type == RouteTypeForward {
+		// Typing falls back to the global settings
+		if r.Output.TypeInference == nil {
+			inference := getBoolEnv("TYPE_INFERENCE", false)
+			r.Output.TypeInference = &inference
+		}
+		if r.Output.ColumnTypes == nil {
+			columnTypes, err := parseColumnTypes(getEnv("COLUMN_TYPES", ""))
 			if err != nil {
-				return nil, fmt.Errorf("route '%s': invalid filename pattern: %w", route.Name, err)
+				return fmt.Errorf("route '%s': invalid COLUMN_TYPES: %w", r.Name, err)
 			}
-			route.Input.compiledPattern = compiled
+			r.Output.ColumnTypes = columnTypes
 		}
-
-		// Parse suffix filter if specified
-		if route.Input.SuffixFilter != "" {
-			route.Input.compiledSuffixList = parseSuffixFilter(route.Input.SuffixFilter)
+		if err := validateTypeInference(*r.Output.TypeInference, r.Output.ColumnTypes, r.Output.ContentType); err != nil {
+			return fmt.Errorf("route '%s': invalid output.typeInference or output.columnTypes: %w", r.Name, err)
 		}
+	}
+	if r.Output.Wrapper != nil {
+		if r.Type == RouteTypeReverse {
+			return fmt.Errorf("route '%s': output.wrapper is not supported by reverse routes, which write CSV files", r.Name)
+		}
+		r.Output.Wrapper.applyDefaults()
+	} else if r.Type == RouteTypeForward && r.Output.FileTarget() != "" {
+		// Routes writing files inherit FILE_WRAPPER
+		wrapper, err := fileWrapperFromEnv()
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid FILE_WRAPPER_FIELDS: %w", r.Name, err)
+		}
+		r.Output.Wrapper = wrapper
+	}
+	if err := validateFileWrapper(r.Output.Wrapper, r.Output.FileTarget() != ""); err != nil {
+		return fmt.Errorf("route '%s': invalid output.wrapper: %w", r.Name, err)
+	}
+	if r.Output.Identifier == "" && r.Output.publishes() && r.Output.MessageFormat() == PayloadFormatLegacy {
+		r.Output.Identifier = getEnv("LEGACY_IDENTIFIER", "")
+	}
+	if r.Output.Identifier != "" && (!r.Output.publishes() || r.Output.MessageFormat() != PayloadFormatLegacy) {
+		return fmt.Errorf("route '%s': output.identifier requires queue output in the legacy payload format", r.Name)
+	}
+	if r.Output.Shards != nil {
+		if !r.Output.publishes() || len(r.Output.Shards.Queues) == 0 {
+			return fmt.Errorf("route '%s': output.shards requires queue output and at least one queue", r.Name)
+		}
+		if r.Output.Shards.Strategy == "" {
+			r.Output.Shards.Strategy = ShardStrategyRoundRobin
+		}
+		customRouting := r.Output.RabbitMQ != nil && (r.Output.RabbitMQ.RoutingKey != "" || r.Output.RabbitMQ.BindingKey != "")
+		if err := validateSharding(r.Output.Shards.Queues, r.Output.Shards.Strategy, r.Output.Shards.Column, customRouting); err != nil {
+			return fmt.Errorf("route '%s': invalid output.shards: %w", r.Name, err)
+		}
+	}
+	if r.Output.RabbitMQ != nil {
+		if err := validateExchangeType(r.Output.RabbitMQ.ExchangeType); err != nil {
+			return fmt.Errorf("route '%s': invalid output.rabbitmq.exchangeType: %w", r.Name, err)
+		}
+	}
+	// Kafka templates are resolved by the Kafka producer, so they would be ignored until it lands
+	if r.Output.Kafka != nil {
+		return fmt.Errorf("route '%s': output.kafka is not usable: Kafka output not supported yet", r.Name)
+	}
 
-		// Create archive directories
-		for _, archivePath := range []string{
-			route.Archive.ProcessedPath,
-			route.Archive.FailedPath,
-			route.Archive.IgnoredPath,
-		} {
-			if archivePath != "" {
-				if err := os.MkdirAll(archivePath, 0755); err != nil {
-					return nil, fmt.Errorf("route '%s': failed to create archive directory %s: %w", route.Name, archivePath, err)
+	// Parse the queue destination URI; shard queues share its broker, vhost and exchange
+	if r.Output.publishes() {
+		field := "output.destination"
+		if r.Output.Type == "both" {
+			field = "output.queue"
+		}
+		dest, err := ParseQueueDestination(r.Output.QueueTarget())
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid %s: %w", r.Name, field, err)
+		}
+		if dest.Exchange != "" && r.Output.RabbitMQ != nil && r.Output.RabbitMQ.Exchange != "" && r.Output.RabbitMQ.Exchange != dest.Exchange {
+			return fmt.Errorf("route '%s': %s exchange %q conflicts with output.rabbitmq.exchange %q", r.Name, field, dest.Exchange, r.Output.RabbitMQ.Exchange)
+		}
+		if r.Output.Shards != nil {
+			for i, queue := range r.Output.Shards.Queues {
+				shard, err := ParseQueueDestination(queue)
+				if err != nil {
+					return fmt.Errorf("route '%s': invalid output.shards.queues[%d]: %w", r.Name, i, err)
+				}
+				if shard.Scheme != "" && !shard.sameBroker(dest) {
+					return fmt.Errorf("route '%s': output.shards.queues[%d] must use the broker, vhost and exchange of %s", r.Name, i, field)
 				}
 			}
 		}
+		r.Output.queueDest = dest
 	}
 
-	return &routesConfig, nil
+	// Queue credentials and connection settings come from the environment; fail early on unreadable secret files
+	if r.Output.publishes() {
+		for _, key := range []string{"QUEUE_USERNAME", "QUEUE_PASSWORD"} {
+			if _, err := getSecretEnv(key); err != nil {
+				return fmt.Errorf("route '%s': %w", r.Name, err)
+			}
+		}
+		if err := validateBrokerConnection(getIntervalEnv("QUEUE_HEARTBEAT", 10*time.Second), getIntEnv("QUEUE_CONNECT_RETRIES", 3), getIntervalEnv("QUEUE_CONNECT_RETRY_DELAY", time.Second)); err != nil {
+			return fmt.Errorf("route '%s': %w", r.Name, err)
+		}
+	}
+
+	// Resolve the payload encryption key; routes without their own key use the environment's
+	if r.Output.Encryption != nil {
+		if r.Output.Encryption.KeyFile == "" {
+			return fmt.Errorf("route '%s': output.encryption.keyFile must be set", r.Name)
+		}
+		key, err := resolveSecret("", r.Output.Encryption.KeyFile)
+		if err == nil && key == "" {
+			err = fmt.Errorf("key file is empty")
+		}
+		if err == nil {
+			err = validateEncryptionKey(key, r.Output.Encryption.KeyID)
+		}
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid output.encryption: %w", r.Name, err)
+		}
+		r.Output.Encryption.Key = key
+	} else if r.Output.publishes() {
+		key, err := getSecretEnv("PAYLOAD_ENCRYPTION_KEY")
+		if err == nil {
+			err = validateEncryptionKey(key, getEnv("PAYLOAD_ENCRYPTION_KEY_ID", ""))
+		}
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid PAYLOAD_ENCRYPTION_KEY: %w", r.Name, err)
+		}
+	}
+
+	// Resolve the message signing key; routes without their own key use the environment's
+	if r.Output.Signing != nil {
+		if r.Output.Signing.Algorithm == "" {
+			r.Output.Signing.Algorithm = SigningHMACSHA256
+		}
+		if r.Output.Signing.KeyFile == "" {
+			return fmt.Errorf("route '%s': output.signing.keyFile must be set", r.Name)
+		}
+		key, err := resolveSecret("", r.Output.Signing.KeyFile)
+		if err == nil && key == "" {
+			err = fmt.Errorf("key file is empty")
+		}
+		if err == nil {
+			err = validateSigningKey(r.Output.Signing.Algorithm, key, r.Output.Signing.KeyID)
+		}
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid output.signing: %w", r.Name, err)
+		}
+		r.Output.Signing.Key = key
+	} else if r.Output.publishes() {
+		key, err := getSecretEnv("MESSAGE_SIGNING_KEY")
+		if err == nil {
+			err = validateSigningKey(getEnv("MESSAGE_SIGNING_ALGORITHM", SigningHMACSHA256), key, getEnv("MESSAGE_SIGNING_KEY_ID", ""))
+		}
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid MESSAGE_SIGNING_KEY: %w", r.Name, err)
+		}
+	}
+
+	// Resolve hashing salt from secret file if specified
+	if r.Transform.Hash != nil {
+		salt, err := resolveSecret(r.Transform.Hash.Salt, r.Transform.Hash.SaltFile)
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid transform.hash.saltFile: %w", r.Name, err)
+		}
+		if salt == "" {
+			return fmt.Errorf("route '%s': invalid transform.hash: a salt or saltFile is required", r.Name)
+		}
+		r.Transform.Hash.Salt = salt
+	}
+
+	if r.Input.UnmatchedPolicy == "" {
+		r.Input.UnmatchedPolicy = getEnv("UNMATCHED_FILE_POLICY", UnmatchedFileArchive)
+	}
+	if err := validateUnmatchedPolicy(r.Input.UnmatchedPolicy); err != nil {
+		return fmt.Errorf("route '%s': invalid input.unmatchedPolicy: %w", r.Name, err)
+	}
+	if r.Input.ChecksumPolicy == "" {
+		r.Input.ChecksumPolicy = getEnv("CHECKSUM_POLICY", ChecksumPolicyOff)
+	}
+	if err := validateChecksumPolicy(r.Input.ChecksumPolicy); err != nil {
+		return fmt.Errorf("route '%s': invalid input.checksumPolicy: %w", r.Name, err)
+	}
+	if r.Input.BytesPerInterval == nil {
+		bytesPerInterval := ByteSize(getByteSizeEnv("BYTES_PER_INTERVAL", 0))
+		r.Input.BytesPerInterval = &bytesPerInterval
+	}
+	if r.Input.ThrottleInterval == 0 {
+		r.Input.ThrottleInterval = Interval(getIntervalEnv("THROTTLE_INTERVAL", time.Minute))
+	}
+	if err := validateThrottle(int64(*r.Input.BytesPerInterval), r.Input.ThrottleInterval.Duration()); err != nil {
+		return fmt.Errorf("route '%s': invalid input.bytesPerInterval: %w", r.Name, err)
+	}
+	if r.Input.ProcessExisting == nil {
+		processExisting := getBoolEnv("PROCESS_EXISTING", false)
+		r.Input.ProcessExisting = &processExisting
+	}
+	if r.Input.ProcessExistingOrder == "" {
+		r.Input.ProcessExistingOrder = getEnv("PROCESS_EXISTING_ORDER", ProcessExistingOldest)
+	}
+	if err := validateProcessExistingOrder(r.Input.ProcessExistingOrder); err != nil {
+		return fmt.Errorf("route '%s': invalid input.processExistingOrder: %w", r.Name, err)
+	}
+
+	// Compile filename pattern if specified
+	if r.Input.CaseInsensitive == nil {
+		caseInsensitive := getBoolEnv("FILENAME_CASE_INSENSITIVE", false)
+		r.Input.CaseInsensitive = &caseInsensitive
+	}
+	if r.Input.FilenamePattern != "" {
+		compiled, err := compileFilenamePattern(r.Input.FilenamePattern, *r.Input.CaseInsensitive)
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid filename pattern: %w", r.Name, err)
+		}
+		r.Input.compiledPattern = compiled
+	}
+
+	claimFiles := getBoolEnv("CLAIM_FILES", false)
+	if r.Input.ClaimFiles != nil {
+		claimFiles = *r.Input.ClaimFiles
+	}
+	if err := validateClaims(claimFiles, getEnv("INSTANCE_ID", defaultInstanceID()), getDurationEnv("CLAIM_TTL_SECONDS", 300)*time.Second); err != nil {
+		return fmt.Errorf("route '%s': %w", r.Name, err)
+	}
+	instanceLock := getBoolEnv("INSTANCE_LOCK", false)
+	if r.Input.InstanceLock != nil {
+		instanceLock = *r.Input.InstanceLock
+	}
+	if err := validateInstanceLock(instanceLock, claimFiles); err != nil {
+		return fmt.Errorf("route '%s': %w", r.Name, err)
+	}
+	if err := validateWorkDir(r.Input.WorkDir, r.Input.Path, claimFiles); err != nil {
+		return fmt.Errorf("route '%s': invalid input.workDir: %w", r.Name, err)
+	}
+
+	// Compile exclude pattern if specified
+	if r.Input.ExcludePattern != "" {
+		compiled, err := compileFilenamePattern(r.Input.ExcludePattern, *r.Input.CaseInsensitive)
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid exclude pattern: %w", r.Name, err)
+		}
+		r.Input.compiledExclude = compiled
+	}
+
+	// Parse expected arrival schedule if specified
+	if r.Input.ExpectedArrival != "" {
+		schedule, err := sla.Parse(r.Input.ExpectedArrival)
+		if err != nil {
+			return fmt.Errorf("route '%s': invalid expectedArrival: %w", r.Name, err)
+		}
+		r.Input.compiledArrival = schedule
+	}
+
+	// Parse suffix filter if specified
+	if r.Input.SuffixFilter != "" {
+		r.Input.compiledSuffixList = parseSuffixFilter(r.Input.SuffixFilter)
+	}
+
+	if r.Timezone == "" {
+		r.Timezone = getEnv("TIMEZONE", "")
+	}
+	if err := validateTimezone(r.Timezone); err != nil {
+		return fmt.Errorf("route '%s': invalid timezone: %w", r.Name, err)
+	}
+
+	// Resolve the tenant; routes without their own setting use the environment's
+	tenant, tenantFrom := r.Tenant, r.TenantFrom
+	if tenant == "" && tenantFrom == "" {
+		tenant, tenantFrom = getEnv("TENANT", ""), getEnv("TENANT_FROM", "")
+	}
+	var err error
+	if r.tenant, err = resolveTenant(tenant, tenantFrom, r.Name, r.Input.Path); err != nil {
+		return fmt.Errorf("route '%s': invalid tenant: %w", r.Name, err)
+	}
+	if r.tenant != "" && r.Output.writesFiles() {
+		if err := os.MkdirAll(filepath.Join(r.Output.FileTarget(), r.tenant), 0755); err != nil {
+			return fmt.Errorf("route '%s': failed to create tenant output directory: %w", r.Name, err)
+		}
+	}
+
+	// Create archive directories
+	for _, archivePath := range []string{
+		r.Archive.ProcessedPath,
+		r.Archive.FailedPath,
+		r.Archive.IgnoredPath,
+	} {
+		if archivePath != "" {
+			if err := os.MkdirAll(archivePath, 0755); err != nil {
+				return fmt.Errorf("route '%s': failed to create archive directory %s: %w", r.Name, archivePath, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// InputReady reports whether the route's input path exists. Routes with the wait
+// policy load without it and must not be started until it does.
+func (r *Route) InputReady() bool {
+	_, err := os.Stat(r.Input.Path)
+	return err == nil
+}
+
+// ResolvedTenant returns the tenant the route's destinations are scoped to ("" = none)
+func (r *Route) ResolvedTenant() string {
+	return r.tenant
 }
 
 // ToLegacyConfig converts a Route to the legacy Config structure for compatibility
@@ -172,20 +1151,103 @@ func (r *Route) ToLegacyConfig() *Config {
 	}
 
 	cfg := &Config{
-		InputFolder:        r.Input.Path,
-		PollInterval:       time.Duration(r.Input.PollIntervalSec) * time.Second,
-		HybridPollInterval: time.Duration(r.Input.HybridPollIntervalSec) * time.Second,
-		MaxFilesPerPoll:    r.Input.MaxFilesPerPoll,
-		WatchMode:          r.Input.WatchMode,
-		FilenamePattern:    r.Input.compiledPattern,
-		Delimiter:          delimiter,
-		QuoteChar:          quoteChar,
-		Encoding:           r.Parsing.Encoding,
-		HasHeader:          r.Parsing.HasHeader,
-		ArchiveProcessed:   r.Archive.ProcessedPath,
-		ArchiveIgnored:     r.Archive.IgnoredPath,
-		ArchiveFailed:      r.Archive.FailedPath,
-		ArchiveTimestamp:   true, // Always timestamp in routing mode
+		InputFolder:          r.Input.Path,
+		PollInterval:         r.Input.PollInterval.Duration(),
+		HybridPollInterval:   r.Input.HybridPollInterval.Duration(),
+		StabilityWindow:      r.Input.StabilityWindow.Duration(),
+		MaxFilesPerPoll:      r.Input.MaxFilesPerPoll,
+		ProcessExisting:      *r.Input.ProcessExisting,
+		ProcessExistingOrder: r.Input.ProcessExistingOrder,
+		WatchMode:            r.Input.WatchMode,
+		FilenamePattern:      r.Input.compiledPattern,
+		FilenameExclude:      r.Input.compiledExclude,
+		FilenameIgnoreCase:   *r.Input.CaseInsensitive,
+		UnmatchedPolicy:      r.Input.UnmatchedPolicy,
+		ChecksumPolicy:       r.Input.ChecksumPolicy,
+		ExpectedArrival:      r.Input.compiledArrival,
+		SkipDuplicateFiles:   r.Input.SkipDuplicates,
+		BatchManifests:       r.Input.BatchManifests,
+		ClaimFiles:           getBoolEnv("CLAIM_FILES", false),
+		InstanceID:           getEnv("INSTANCE_ID", defaultInstanceID()),
+		ClaimTTL:             getDurationEnv("CLAIM_TTL_SECONDS", 300) * time.Second,
+		InstanceLock:         getBoolEnv("INSTANCE_LOCK", false),
+		MinFreeDiskMB:        getIntEnv("MIN_FREE_DISK_MB", 0),
+		DiskCheckInterval:    getDurationEnv("DISK_CHECK_INTERVAL_SECONDS", 30) * time.Second,
+		MemoryLimitMB:        getIntEnv("MEMORY_LIMIT_MB", 0),
+		BytesPerInterval:     int64(*r.Input.BytesPerInterval),
+		ThrottleInterval:     r.Input.ThrottleInterval.Duration(),
+		InputFormat:          r.Parsing.Format,
+		FixedWidthColumns:    r.Parsing.FixedWidthColumns,
+		RecordLayouts:        r.Parsing.RecordLayouts,
+		Delimiter:            delimiter,
+		QuoteChar:            quoteChar,
+		Encoding:             r.Parsing.Encoding,
+		HasHeader:            r.Parsing.HasHeader,
+		InvalidUTF8Policy:    r.Parsing.InvalidUTF8Policy,
+		EmptyFilePolicy:      r.Parsing.EmptyFilePolicy,
+		SchemaDriftPolicy:    r.Parsing.SchemaDriftPolicy,
+		ArchiveProcessed:     r.Archive.ProcessedPath,
+		ArchiveIgnored:       r.Archive.IgnoredPath,
+		ArchiveFailed:        r.Archive.FailedPath,
+		ArchiveTimestamp:     true, // Always timestamp in routing mode
+		SanitizeFormulas:     r.Transform.SanitizeFormulas,
+		SanitizePrefix:       r.Transform.SanitizePrefix,
+		DedupKeys:            r.Transform.DedupKeys,
+		DedupKeep:            r.Transform.DedupKeep,
+		Lookups:              r.Transform.Lookups,
+		ExtraColumns:         ExtraColumnsDrop,
+		MaskRules:            r.Transform.Mask,
+		SortBy:               r.Transform.SortBy,
+		SortMemoryRows:       r.Transform.SortMemoryRows,
+	}
+
+	if r.Transform.OutputSchema != nil {
+		cfg.OutputSchema = r.Transform.OutputSchema.Fields
+		cfg.ExtraColumns = r.Transform.OutputSchema.ExtraColumns
+		cfg.StrictColumns = r.Transform.OutputSchema.StrictColumns
+	}
+
+	if r.Quality != nil {
+		cfg.Quality = r.Quality.suite
+		cfg.QualityPolicy = r.Quality.Policy
+	}
+
+	if r.Transform.Hash != nil {
+		cfg.HashColumns = r.Transform.Hash.Columns
+		cfg.HashSalt = r.Transform.Hash.Salt
+	}
+
+	if r.Transform.Sample != nil {
+		cfg.SampleRows = r.Transform.Sample.Rows
+		cfg.SampleMode = r.Transform.Sample.Mode
+	}
+
+	if r.Transform.GroupBy != nil {
+		cfg.GroupBy = r.Transform.GroupBy.By
+		cfg.GroupParentCols = r.Transform.GroupBy.ParentColumns
+		cfg.GroupChildKey = r.Transform.GroupBy.ChildKey
+	}
+
+	cfg.ExactlyOnce = *r.Input.ExactlyOnce
+	cfg.ExactlyOnceRetention = getIntervalEnv("EXACTLY_ONCE_RETENTION", 30*24*time.Hour)
+
+	if r.Input.ClaimFiles != nil {
+		cfg.ClaimFiles = *r.Input.ClaimFiles
+	}
+	if r.Input.InstanceLock != nil {
+		cfg.InstanceLock = *r.Input.InstanceLock
+	}
+	cfg.WorkDir = r.Input.WorkDir
+	cfg.ColumnStats = getBoolEnv("COLUMN_STATS", false)
+	if r.Archive.ColumnStats != nil {
+		cfg.ColumnStats = *r.Archive.ColumnStats
+	}
+	cfg.ArchiveFailurePolicy = r.Archive.FailurePolicy
+	cfg.ArchiveRetries = getIntEnv("ARCHIVE_RETRIES", 3)
+	cfg.ArchiveRetryDelay = getIntervalEnv("ARCHIVE_RETRY_DELAY", time.Second)
+	cfg.ArchiveQuarantine = getEnv("ARCHIVE_QUARANTINE", "")
+	if r.Archive.QuarantinePath != "" {
+		cfg.ArchiveQuarantine = r.Archive.QuarantinePath
 	}
 
 	// Parse suffix filter
@@ -195,44 +1257,157 @@ func (r *Route) ToLegacyConfig() *Config {
 
 	// Parse output configuration
 	cfg.OutputType = r.Output.Type
-	if r.Output.Type == "file" {
-		cfg.OutputFolder = r.Output.Destination
-	} else if r.Output.Type == "queue" {
-		// Parse queue destination (e.g., "rabbitmq://products_queue")
-		cfg.QueueName = parseQueueDestination(r.Output.Destination)
+	cfg.ASCIISafeOutput = r.Output.ASCIISafe
+	cfg.OutputShape = r.Output.OutputShape
+	cfg.FileWrapper = r.Output.Wrapper
+	cfg.TypeInference = r.Output.TypeInference != nil && *r.Output.TypeInference
+	cfg.ColumnTypes = r.Output.ColumnTypes
+	cfg.PartitionBy = r.Output.PartitionBy
+	if r.Output.Kafka != nil {
+		cfg.KafkaMessageKey = r.Output.Kafka.MessageKey
+		cfg.KafkaPartition = r.Output.Kafka.Partition
+	}
+	cfg.MessageContentType = r.Output.ContentType
+	cfg.LegacyIdentifier = r.Output.Identifier
+	if cfg.MessageContentType == "" {
+		cfg.MessageContentType = getEnv("MESSAGE_CONTENT_TYPE", ContentTypeJSON)
+	}
+	cfg.MessageSchema = r.Output.Schema
+	if cfg.MessageSchema == "" {
+		cfg.MessageSchema = getEnv("MESSAGE_SCHEMA", "")
+	}
+	// Delivery receipts fall back to the global settings
+	cfg.ReceiptLog = r.Output.ReceiptLog
+	if cfg.ReceiptLog == "" {
+		cfg.ReceiptLog = getEnv("RECEIPT_LOG", "")
+	}
+	cfg.PublisherConfirms = getBoolEnv("PUBLISHER_CONFIRMS", false)
+	if r.Output.PublisherConfirms != nil {
+		cfg.PublisherConfirms = *r.Output.PublisherConfirms
+	}
+	cfg.PublishConfirmTimeout = getDurationEnv("PUBLISH_CONFIRM_TIMEOUT_SECONDS", 30) * time.Second
+	cfg.DownstreamAck = getBoolEnv("DOWNSTREAM_ACK", false)
+	if r.Output.DownstreamAck != nil {
+		cfg.DownstreamAck = *r.Output.DownstreamAck
+	}
+	cfg.DownstreamAckQueue = r.Output.DownstreamAckQueue
+	if cfg.DownstreamAckQueue == "" {
+		cfg.DownstreamAckQueue = getEnv("DOWNSTREAM_ACK_QUEUE", "")
+	}
+	cfg.DownstreamAckTimeout = getDurationEnv("DOWNSTREAM_ACK_TIMEOUT_SECONDS", 300) * time.Second
+
+	if r.Output.RabbitMQ != nil {
+		cfg.RabbitMQExchange = r.Output.RabbitMQ.Exchange
+		cfg.RabbitMQExchangeType = r.Output.RabbitMQ.ExchangeType
+		cfg.RabbitMQRoutingKey = r.Output.RabbitMQ.RoutingKey
+		cfg.RabbitMQBindingKey = r.Output.RabbitMQ.BindingKey
+	}
+	if r.Output.Batch != nil {
+		cfg.BatchWindow = time.Duration(r.Output.Batch.WindowSec) * time.Second
+		cfg.BatchMaxFiles = r.Output.Batch.MaxFiles
+	}
+	cfg.ReverseConversion = r.Type == RouteTypeReverse
+	if r.Output.writesFiles() {
+		cfg.OutputFolder = r.Output.FileTarget()
+	}
+	if r.Output.Type == "pipe" {
+		cfg.OutputPipe = r.Output.Destination
+	}
+	if r.Output.publishes() {
+		// Destination parsed in LoadRoutes (e.g., "rabbitmq://vhost/exchange/products_queue")
+		dest := r.Output.queueDest
+		cfg.QueueName = dest.Queue
+		if dest.Exchange != "" {
+			cfg.RabbitMQExchange = dest.Exchange
+		}
+		if r.Output.Shards != nil {
+			for _, queue := range r.Output.Shards.Queues {
+				shard, _ := ParseQueueDestination(queue)
+				cfg.QueueShards = append(cfg.QueueShards, shard.Queue)
+			}
+			cfg.ShardStrategy = r.Output.Shards.Strategy
+			cfg.ShardColumn = r.Output.Shards.Column
+		}
+		if r.Output.Encryption != nil {
+			cfg.PayloadEncryptionKey = r.Output.Encryption.Key
+			cfg.PayloadEncryptionKeyID = r.Output.Encryption.KeyID
+		} else {
+			// Checked in LoadRoutes, so errors cannot occur here
+			cfg.PayloadEncryptionKey, _ = getSecretEnv("PAYLOAD_ENCRYPTION_KEY")
+			cfg.PayloadEncryptionKeyID = getEnv("PAYLOAD_ENCRYPTION_KEY_ID", "")
+		}
+		if r.Output.Signing != nil {
+			cfg.SigningAlgorithm = r.Output.Signing.Algorithm
+			cfg.SigningKey = r.Output.Signing.Key
+			cfg.SigningKeyID = r.Output.Signing.KeyID
+		} else {
+			cfg.SigningAlgorithm = getEnv("MESSAGE_SIGNING_ALGORITHM", SigningHMACSHA256)
+			cfg.SigningKey, _ = getSecretEnv("MESSAGE_SIGNING_KEY")
+			cfg.SigningKeyID = getEnv("MESSAGE_SIGNING_KEY_ID", "")
+		}
 		cfg.QueueType = "rabbitmq" // Default to RabbitMQ
-		// Use global queue connection settings from environment
+		// Use global queue connection settings from environment unless the destination overrides them
 		cfg.QueueHost = getEnv("QUEUE_HOST", "localhost")
 		cfg.QueuePort = getIntEnv("QUEUE_PORT", 5672)
-		cfg.QueueUsername = getEnv("QUEUE_USERNAME", "")
-		cfg.QueuePassword = getEnv("QUEUE_PASSWORD", "")
+		cfg.QueueVHost = getEnv("QUEUE_VHOST", "")
+		if dest.Host != "" {
+			cfg.QueueHost = dest.Host
+		}
+		if dest.Port != 0 {
+			cfg.QueuePort = dest.Port
+		}
+		if dest.VHost != "" {
+			cfg.QueueVHost = dest.VHost
+		}
+		// Secret files were checked in LoadRoutes, so errors cannot occur here
+		cfg.QueueUsername, _ = getSecretEnv("QUEUE_USERNAME")
+		cfg.QueuePassword, _ = getSecretEnv("QUEUE_PASSWORD")
+		cfg.QueueLazyConnect = getBoolEnv("QUEUE_LAZY_CONNECT", false)
+		if r.Output.LazyConnect != nil {
+			cfg.QueueLazyConnect = *r.Output.LazyConnect
+		}
+		cfg.QueueHeartbeat = getIntervalEnv("QUEUE_HEARTBEAT", 10*time.Second)
+		cfg.QueueConnectRetries = getIntEnv("QUEUE_CONNECT_RETRIES", 3)
+		cfg.QueueConnectRetryDelay = getIntervalEnv("QUEUE_CONNECT_RETRY_DELAY", time.Second)
 	}
 
-	return cfg
-}
-
-// parseQueueDestination extracts queue name from destination string
-// Examples:
-//   - "rabbitmq://products_queue" -> "products_queue"
-//   - "products_queue" -> "products_queue"
-func parseQueueDestination(dest string) string {
-	// Simple parsing: remove protocol prefix if present
-	if idx := filepath.Base(dest); idx != "" {
-		return idx
+	cfg.ParseWorkers = r.Parsing.Workers
+	if cfg.ParseWorkers == 0 {
+		cfg.ParseWorkers = getIntEnv("PARSE_WORKERS", 1)
 	}
-	return dest
-}
+	parallelMinMB := getIntEnv("PARALLEL_PARSE_MIN_MB", 64)
+	if r.Parsing.ParallelMinMB != nil {
+		parallelMinMB = *r.Parsing.ParallelMinMB
+	}
+	cfg.ParallelParseMin = int64(parallelMinMB) << 20
+	cfg.RawLineField = r.Parsing.RawLineField
+	if cfg.RawLineField == "" {
+		cfg.RawLineField = getEnv("RAW_LINE_FIELD", "")
+	}
+	cfg.ReassembleLines = *r.Parsing.ReassembleLines
+	cfg.RaggedRowPolicy = r.Parsing.RaggedRowPolicy
+	cfg.ColumnSampleRows = *r.Parsing.ColumnSampleRows
 
-// parseSuffixFilter parses comma-separated suffix filter
-func parseSuffixFilter(filter string) []string {
-	if filter == "" {
-		return nil
+	// Report queues use the global broker connection, also on file-output routes
+	cfg.ReportDestination = r.Output.Report
+	if cfg.ReportDestination == "" {
+		cfg.ReportDestination = getEnv("REPORT_DESTINATION", "")
 	}
-	suffixes := []string{}
-	for _, suffix := range regexp.MustCompile(`\s*,\s*`).Split(filter, -1) {
-		if suffix != "" {
-			suffixes = append(suffixes, suffix)
-		}
+	if strings.Contains(cfg.ReportDestination, "://") && cfg.QueueHost == "" {
+		cfg.QueueHost = getEnv("QUEUE_HOST", "localhost")
+		cfg.QueuePort = getIntEnv("QUEUE_PORT", 5672)
+		cfg.QueueVHost = getEnv("QUEUE_VHOST", "")
+		cfg.QueueUsername, _ = getSecretEnv("QUEUE_USERNAME")
+		cfg.QueuePassword, _ = getSecretEnv("QUEUE_PASSWORD")
 	}
-	return suffixes
+
+	cfg.Timezone = r.Timezone
+	cfg.BusinessDate = r.businessDate
+	if r.BusinessDate != nil {
+		cfg.LateDatePolicy = r.BusinessDate.LatePolicy
+		cfg.CorrectionsQueue = r.BusinessDate.CorrectionsQueue
+	}
+	cfg.ArchiveByDate = *r.Archive.ByBusinessDate
+	cfg.applyTenant(r.tenant)
+	return cfg
 }
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Secrets providers
+const (
+	SecretsProviderVault = "vault" // HashiCorp Vault KV v2
+	SecretsProviderAWS   = "aws"   // AWS Secrets Manager
+	SecretsProviderAzure = "azure" // Azure Key Vault
+)
+
+// SecretsConfig selects an external secrets provider. The secret at Path holds
+// key/value pairs named after environment variables (e.g. QUEUE_PASSWORD,
+// HASH_SALT); they are fetched before the rest of the configuration is loaded.
+type SecretsConfig struct {
+	Provider string        // vault, aws, azure ("" = disabled)
+	Path     string        // Vault KV path, AWS secret ID, or Azure secret name
+	Refresh  time.Duration // Re-fetch interval (0 = fetch once at startup)
+	Timeout  time.Duration // Timeout for each provider request
+
+	// HashiCorp Vault
+	VaultAddr  string
+	VaultToken string
+
+	// AWS Secrets Manager
+	AWSRegion          string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+	AWSEndpoint        string // Override for VPC endpoints ("" = regional endpoint)
+
+	// Azure Key Vault (client credentials when set, otherwise managed identity)
+	AzureVaultURL     string
+	AzureTenantID     string
+	AzureClientID     string
+	AzureClientSecret string
+}
+
+// LoadSecrets loads the secrets provider settings from environment variables
+func LoadSecrets() (*SecretsConfig, error) {
+	// Load the config file (CONFIG_FILE, default .env) below the environment
+	if err := loadConfigFile(); err != nil {
+		return nil, err
+	}
+
+	cfg := &SecretsConfig{
+		Provider:      getEnv("SECRETS_PROVIDER", ""),
+		Path:          getEnv("SECRETS_PATH", ""),
+		Refresh:       getDurationEnv("SECRETS_REFRESH_SECONDS", 300) * time.Second,
+		Timeout:       getDurationEnv("SECRETS_TIMEOUT_SECONDS", 10) * time.Second,
+		VaultAddr:     getEnv("VAULT_ADDR", ""),
+		AWSRegion:     getEnv("AWS_REGION", getEnv("AWS_DEFAULT_REGION", "")),
+		AWSEndpoint:   getEnv("AWS_SECRETSMANAGER_ENDPOINT", ""),
+		AzureVaultURL: getEnv("AZURE_KEYVAULT_URL", ""),
+		AzureTenantID: getEnv("AZURE_TENANT_ID", ""),
+		AzureClientID: getEnv("AZURE_CLIENT_ID", ""),
+	}
+	if cfg.Provider == "" {
+		return cfg, nil
+	}
+
+	// Provider credentials may themselves be mounted as secret files
+	var err error
+	for key, dest := range map[string]*string{
+		"VAULT_TOKEN":           &cfg.VaultToken,
+		"AWS_ACCESS_KEY_ID":     &cfg.AWSAccessKeyID,
+		"AWS_SECRET_ACCESS_KEY": &cfg.AWSSecretAccessKey,
+		"AWS_SESSION_TOKEN":     &cfg.AWSSessionToken,
+		"AZURE_CLIENT_SECRET":   &cfg.AzureClientSecret,
+	} {
+		if *dest, err = getSecretEnv(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// validate checks the settings required by the selected provider
+func (c *SecretsConfig) validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("SECRETS_PATH is required when SECRETS_PROVIDER is set")
+	}
+	if c.Refresh < 0 || c.Timeout <= 0 {
+		return fmt.Errorf("SECRETS_REFRESH_SECONDS must be >= 0 and SECRETS_TIMEOUT_SECONDS must be > 0")
+	}
+
+	switch c.Provider {
+	case SecretsProviderVault:
+		if c.VaultAddr == "" || c.VaultToken == "" {
+			return fmt.Errorf("VAULT_ADDR and VAULT_TOKEN are required for the vault secrets provider")
+		}
+	case SecretsProviderAWS:
+		if c.AWSRegion == "" {
+			return fmt.Errorf("AWS_REGION is required for the aws secrets provider")
+		}
+		if c.AWSAccessKeyID == "" || c.AWSSecretAccessKey == "" {
+			return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required for the aws secrets provider")
+		}
+	case SecretsProviderAzure:
+		if c.AzureVaultURL == "" {
+			return fmt.Errorf("AZURE_KEYVAULT_URL is required for the azure secrets provider")
+		}
+		clientCreds := c.AzureTenantID != "" || c.AzureClientSecret != ""
+		if clientCreds && (c.AzureTenantID == "" || c.AzureClientID == "" || c.AzureClientSecret == "") {
+			return fmt.Errorf("AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_SECRET must be set together")
+		}
+	default:
+		return fmt.Errorf("invalid SECRETS_PROVIDER: %s (must be vault, aws, or azure)", c.Provider)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package contract
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"csv2json/internal/parser"
+)
+
+const productsSchema = `{
+	"$id": "products.csv.v1",
+	"version": "1.2.0",
+	"type": "object",
+	"required": ["sku"],
+	"properties": {
+		"sku": {"type": "string", "pattern": "^[A-Z]{3}-[0-9]+$"},
+		"price": {"type": "number"},
+		"qty": {"type": "integer"},
+		"status": {"enum": ["active", "retired"]}
+	},
+	"additionalProperties": false
+}`
+
+// result builds a parse result from header and row values
+func result(headers []string, rows ...[]string) *parser.ParseResult {
+	r := &parser.ParseResult{Headers: headers}
+	for _, values := range rows {
+		row := parser.OrderedMap{Keys: headers, Values: map[string]string{}}
+		for i, header := range headers {
+			row.Values[header] = values[i]
+		}
+		r.Rows = append(r.Rows, row)
+	}
+	return r
+}
+
+// TestValidate validates required columns, types, enums, patterns and additional columns
+func TestValidate(t *testing.T) {
+	schema, err := Parse([]byte(productsSchema))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		result  *parser.ParseResult
+		wantErr string // "" = valid
+	}{
+		{"valid", result([]string{"sku", "price", "qty"}, []string{"ABC-1", "9.99", "3"}, []string{"DEF-2", "", ""}), ""},
+		{"missing required column", result([]string{"price"}, []string{"1"}), `missing required column "sku"`},
+		{"empty required value", result([]string{"sku"}, []string{""}), `row 1: required column "sku" is empty`},
+		{"wrong type", result([]string{"sku", "qty"}, []string{"ABC-1", "1.5"}), `"1.5" is not an integer`},
+		{"enum", result([]string{"sku", "status"}, []string{"ABC-1", "draft"}), `"draft" is not one of active, retired`},
+		{"pattern", result([]string{"sku"}, []string{"abc"}), `does not match`},
+		{"additional column", result([]string{"sku", "colour"}, []string{"ABC-1", "red"}), `column "colour" is not in the contract`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.Validate(tt.result)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected valid output, got: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// TestValidateReportsLimitedViolations validates long violation lists are truncated
+func TestValidateReportsLimitedViolations(t *testing.T) {
+	schema, _ := Parse([]byte(productsSchema))
+	var rows [][]string
+	for i := 0; i < 8; i++ {
+		rows = append(rows, []string{"ABC-1", "x"})
+	}
+	err := schema.Validate(result([]string{"sku", "price"}, rows...))
+	if err == nil || !strings.Contains(err.Error(), "(and 3 more)") {
+		t.Errorf("Expected truncated violation list, got: %v", err)
+	}
+}
+
+// TestParseResolvedVersion validates the version falls back to a content digest
+func TestParseResolvedVersion(t *testing.T) {
+	schema, err := Parse([]byte(`{"properties": {"id": {"type": "integer"}}}`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if !strings.HasPrefix(schema.ResolvedVersion, "sha256:") {
+		t.Errorf("Expected digest version, got %q", schema.ResolvedVersion)
+	}
+	if _, err := Parse([]byte(`{"properties": {"id": {"type": "date"}}}`)); err == nil {
+		t.Error("Expected error for unsupported property type, got success")
+	}
+}
+
+// TestRegistryFetch validates HTTP and directory registries
+func TestRegistryFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/contracts/products.csv.v1.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(productsSchema))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "products.csv.v1.json"), []byte(productsSchema), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+
+	for _, location := range []string{server.URL + "/contracts/", dir, "file://" + dir} {
+		registry, err := NewRegistry(location, "s3cret", 5*time.Second)
+		if err != nil {
+			t.Fatalf("Failed to create registry %s: %v", location, err)
+		}
+		schema, err := registry.Fetch("products.csv.v1")
+		if err != nil || schema.ResolvedVersion != "1.2.0" {
+			t.Errorf("%s: expected version 1.2.0, got %v (%v)", location, schema, err)
+		}
+		if _, err := registry.Fetch("orders.csv.v1"); err == nil {
+			t.Errorf("%s: expected error for unknown contract", location)
+		}
+		if _, err := registry.Fetch("../secrets"); err == nil {
+			t.Errorf("%s: expected error for path traversal", location)
+		}
+	}
+}
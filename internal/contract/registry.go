@@ -0,0 +1,124 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Registry resolves ingestion contract identifiers (e.g. products.csv.v1) to schemas
+// stored as <contract>.json at an HTTP(S) base URL, in a git repository, or in a
+// local directory
+type Registry struct {
+	location string // Base URL or directory
+	token    string // Bearer token for HTTP registries
+	client   *http.Client
+	timeout  time.Duration
+}
+
+// NewRegistry creates a registry for location:
+//   - https://schemas.example.com/contracts (GET <location>/<contract>.json)
+//   - git+https://git.example.com/contracts.git#main (shallow clone of the ref, then read files)
+//   - /etc/csv2json/contracts or file:///etc/csv2json/contracts (local directory)
+func NewRegistry(location, token string, timeout time.Duration) (*Registry, error) {
+	r := &Registry{
+		location: strings.TrimSuffix(location, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: timeout},
+		timeout:  timeout,
+	}
+	switch {
+	case strings.HasPrefix(location, "git+"):
+		dir, err := r.clone(strings.TrimPrefix(location, "git+"))
+		if err != nil {
+			return nil, err
+		}
+		r.location = dir
+	case strings.HasPrefix(location, "file://"):
+		r.location = strings.TrimPrefix(r.location, "file://")
+	}
+	return r, nil
+}
+
+// Fetch retrieves and parses the schema of a contract
+func (r *Registry) Fetch(contract string) (*Schema, error) {
+	if contract == "" || strings.ContainsAny(contract, `/\`) || strings.Contains(contract, "..") {
+		return nil, fmt.Errorf("invalid contract identifier %q", contract)
+	}
+
+	var data []byte
+	var err error
+	if r.isHTTP() {
+		data, err = r.fetchHTTP(contract)
+	} else {
+		data, err = os.ReadFile(filepath.Join(r.location, contract+".json"))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch contract %s: %w", contract, err)
+	}
+
+	schema, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("contract %s: %w", contract, err)
+	}
+	if schema.ID == "" {
+		schema.ID = contract
+	}
+	return schema, nil
+}
+
+func (r *Registry) isHTTP() bool {
+	return strings.HasPrefix(r.location, "http://") || strings.HasPrefix(r.location, "https://")
+}
+
+func (r *Registry) fetchHTTP(contract string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.location+"/"+contract+".json", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("registry returned %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+}
+
+// clone shallow-clones a git registry ("<url>#<ref>") into a temporary directory
+func (r *Registry) clone(spec string) (string, error) {
+	url, ref, _ := strings.Cut(spec, "#")
+	dir, err := os.MkdirTemp("", "csv2json-contracts-*")
+	if err != nil {
+		return "", err
+	}
+
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "git", append(args, url, dir)...).CombinedOutput()
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to clone contract registry %s: %v: %s", url, err, strings.TrimSpace(string(output)))
+	}
+	return dir, nil
+}
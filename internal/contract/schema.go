@@ -0,0 +1,175 @@
+package contract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"csv2json/internal/parser"
+)
+
+// maxReportedViolations caps how many violations are listed in a validation error
+const maxReportedViolations = 5
+
+// Schema is an ingestion contract: a JSON Schema subset describing one output record.
+// Supported keywords are type "object", required, properties (type, enum, pattern)
+// and additionalProperties. Values are CSV text, so a property type checks that the
+// value parses as that type; empty values are only rejected for required columns.
+type Schema struct {
+	ID                   string              `json:"$id"`
+	Version              string              `json:"version"`
+	Type                 string              `json:"type"`
+	Required             []string            `json:"required"`
+	Properties           map[string]Property `json:"properties"`
+	AdditionalProperties *bool               `json:"additionalProperties"`
+
+	// ResolvedVersion is Version, or a content digest when the schema has none
+	ResolvedVersion string `json:"-"`
+}
+
+// Property constrains one column
+type Property struct {
+	Type    string   `json:"type"` // "string", "number", "integer", or "boolean" ("" = any)
+	Enum    []string `json:"enum"`
+	Pattern string   `json:"pattern"`
+	pattern *regexp.Regexp
+}
+
+// Parse decodes and checks a schema document
+func Parse(data []byte) (*Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema JSON: %w", err)
+	}
+	if schema.Type != "" && schema.Type != "object" {
+		return nil, fmt.Errorf("unsupported schema type %q (records are objects)", schema.Type)
+	}
+	for name, property := range schema.Properties {
+		switch property.Type {
+		case "", "string", "number", "integer", "boolean":
+		default:
+			return nil, fmt.Errorf("property %q: unsupported type %q (supported: string, number, integer, boolean)", name, property.Type)
+		}
+		if property.Pattern != "" {
+			compiled, err := regexp.Compile(property.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: invalid pattern: %w", name, err)
+			}
+			property.pattern = compiled
+			schema.Properties[name] = property
+		}
+	}
+
+	schema.ResolvedVersion = schema.Version
+	if schema.ResolvedVersion == "" {
+		sum := sha256.Sum256(data)
+		schema.ResolvedVersion = "sha256:" + hex.EncodeToString(sum[:])[:12]
+	}
+	return &schema, nil
+}
+
+// Validate checks every row of result against the schema and returns an error listing
+// the first violations, or nil if the output satisfies the contract
+func (s *Schema) Validate(result *parser.ParseResult) error {
+	var violations []string
+	total := 0
+	report := func(format string, args ...any) {
+		total++
+		if len(violations) < maxReportedViolations {
+			violations = append(violations, fmt.Sprintf(format, args...))
+		}
+	}
+
+	// Column-level checks apply to the whole file
+	present := make(map[string]bool, len(result.Headers))
+	for _, header := range result.Headers {
+		present[header] = true
+	}
+	for _, column := range s.Required {
+		if !present[column] {
+			report("missing required column %q", column)
+		}
+	}
+	if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+		for _, header := range result.Headers {
+			if _, ok := s.Properties[header]; !ok {
+				report("column %q is not in the contract", header)
+			}
+		}
+	}
+
+	for i, row := range result.Rows {
+		for _, column := range s.Required {
+			if present[column] && row.Values[column] == "" {
+				report("row %d: required column %q is empty", i+1, column)
+			}
+		}
+		for _, name := range sortedKeys(s.Properties) {
+			value, ok := row.Values[name]
+			if !ok || value == "" {
+				continue
+			}
+			if problem := s.Properties[name].check(value); problem != "" {
+				report("row %d: column %q: %s", i+1, name, problem)
+			}
+		}
+	}
+
+	if total == 0 {
+		return nil
+	}
+	message := strings.Join(violations, "; ")
+	if total > len(violations) {
+		message += fmt.Sprintf(" (and %d more)", total-len(violations))
+	}
+	return fmt.Errorf("output violates contract %s (version %s): %s", s.ID, s.ResolvedVersion, message)
+}
+
+// check returns a description of why value violates the property, or ""
+func (p Property) check(value string) string {
+	switch p.Type {
+	case "number":
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Sprintf("%q is not a number", value)
+		}
+	case "integer":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Sprintf("%q is not an integer", value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Sprintf("%q is not a boolean", value)
+		}
+	}
+	if len(p.Enum) > 0 {
+		allowed := false
+		for _, option := range p.Enum {
+			if value == option {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Sprintf("%q is not one of %s", value, strings.Join(p.Enum, ", "))
+		}
+	}
+	if p.pattern != nil && !p.pattern.MatchString(value) {
+		return fmt.Sprintf("%q does not match %s", value, p.Pattern)
+	}
+	return ""
+}
+
+// sortedKeys returns property names in a stable order for reproducible error messages
+func sortedKeys(properties map[string]Property) []string {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
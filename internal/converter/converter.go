@@ -5,81 +5,371 @@ import (
 	"csv2json/internal/parser"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
 )
 
+// Output shapes
+const (
+	ShapeArray  = "array"  // A JSON array of row objects (default)
+	ShapeObject = "object" // The single row object of a file with exactly one data row
+)
+
+// ValidateShape returns an error if shape is not a supported output shape ("" = array)
+func ValidateShape(shape string) error {
+	switch shape {
+	case "", ShapeArray, ShapeObject:
+		return nil
+	default:
+		return fmt.Errorf("unsupported output shape: %s (supported: array, object)", shape)
+	}
+}
+
+// Options holds optional JSON rendering behaviour
+type Options struct {
+	ASCIISafe   bool              // Escape all non-ASCII characters as \uXXXX for legacy consumers
+	Shape       string            // ShapeArray or ShapeObject ("" = array)
+	InferTypes  bool              // Render numbers, booleans and nulls as native JSON values instead of strings
+	ColumnTypes map[string]string // Type of these columns' values, overriding inference (see TypeString)
+}
+
 type Converter struct {
-	indent string
+	indent      string
+	asciiSafe   bool
+	object      bool // Render the single row as an object instead of an array
+	inferTypes  bool
+	columnTypes map[string]string
 }
 
 func New() *Converter {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates a converter with optional rendering behaviour
+func NewWithOptions(opts Options) *Converter {
 	return &Converter{
-		indent: "  ",
+		indent:      "  ",
+		asciiSafe:   opts.ASCIISafe,
+		object:      opts.Shape == ShapeObject,
+		inferTypes:  opts.InferTypes,
+		columnTypes: opts.ColumnTypes,
 	}
 }
 
+// Object reports whether the converter renders single-row objects instead of arrays
+func (c *Converter) Object() bool {
+	return c.object
+}
+
+// singleRow returns an error unless a file with rows rows can be rendered as an object
+func singleRow(rows int) error {
+	if rows != 1 {
+		return fmt.Errorf("object output requires exactly one data row, got %d", rows)
+	}
+	return nil
+}
+
 // ToJSON converts unordered maps to JSON (field order not preserved)
 func (c *Converter) ToJSON(data []map[string]string) ([]byte, error) {
-	jsonBytes, err := json.MarshalIndent(data, "", c.indent)
+	var v any = data
+	if c.object {
+		if err := singleRow(len(data)); err != nil {
+			return nil, err
+		}
+		v = data[0]
+	}
+	if c.typed() && data != nil {
+		var err error
+		if v, err = c.typedRows(data); err != nil {
+			return nil, err
+		}
+	}
+	jsonBytes, err := json.MarshalIndent(v, "", c.indent)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal JSON: %w", err)
 	}
-	return jsonBytes, nil
+	return c.finalize(jsonBytes), nil
 }
 
 // ToJSONOrdered converts ParseResult to JSON preserving CSV column order per ADR-003
 func (c *Converter) ToJSONOrdered(result *parser.ParseResult) ([]byte, error) {
 	var buf bytes.Buffer
-	buf.WriteString("[\n")
+	if c.object {
+		if err := singleRow(len(result.Rows)); err != nil {
+			return nil, err
+		}
+		if err := c.writeObject(&buf, result.Rows[0], 0); err != nil {
+			return nil, err
+		}
+		return c.finalize(buf.Bytes()), nil
+	}
+	if err := c.writeArray(&buf, result.Rows, 0); err != nil {
+		return nil, err
+	}
+	return c.finalize(buf.Bytes()), nil
+}
+
+// writerChunkRows is how many rows the io.Writer variants render per write
+const writerChunkRows = 256
+
+// ToJSONWriter streams unordered maps to w, rendered exactly like ToJSON, and returns
+// the number of bytes written. Only one chunk of rows is buffered at a time.
+func (c *Converter) ToJSONWriter(data []map[string]string, w io.Writer) (int64, error) {
+	if c.object {
+		jsonBytes, err := c.ToJSON(data)
+		if err != nil {
+			return 0, err
+		}
+		n, err := w.Write(jsonBytes)
+		return int64(n), err
+	}
+	if len(data) == 0 {
+		// Matches json.MarshalIndent: a nil slice is null, an empty one []
+		empty := "[]"
+		if data == nil {
+			empty = "null"
+		}
+		n, err := io.WriteString(w, empty)
+		return int64(n), err
+	}
 
-	for i, row := range result.Rows {
+	var written int64
+	var buf bytes.Buffer
+	flush := func() error {
+		n, err := w.Write(c.finalize(buf.Bytes()))
+		written += int64(n)
+		buf.Reset()
+		return err
+	}
+	for i, row := range data {
+		if i == 0 {
+			buf.WriteString("[\n")
+		} else {
+			buf.WriteString(",\n")
+		}
+		var v any = row
+		if c.typed() {
+			var err error
+			if v, err = c.typedRow(row); err != nil {
+				return written, err
+			}
+		}
+		rowJSON, err := json.MarshalIndent(v, c.indent, c.indent)
+		if err != nil {
+			return written, fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		buf.WriteString(c.indent)
+		buf.Write(rowJSON)
+		if (i+1)%writerChunkRows == 0 {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	buf.WriteString("\n]")
+	return written, flush()
+}
+
+// ToJSONOrderedWriter streams result to w, rendered exactly like ToJSONOrdered (column
+// order per ADR-003), and returns the number of bytes written. Only one chunk of rows is
+// buffered at a time, so handlers can stream large payloads without double-buffering.
+func (c *Converter) ToJSONOrderedWriter(result *parser.ParseResult, w io.Writer) (int64, error) {
+	if c.object {
+		jsonBytes, err := c.ToJSONOrdered(result)
+		if err != nil {
+			return 0, err
+		}
+		n, err := w.Write(jsonBytes)
+		return int64(n), err
+	}
+	array := c.NewArrayWriter(w)
+	for start := 0; start < len(result.Rows); start += writerChunkRows {
+		end := min(start+writerChunkRows, len(result.Rows))
+		if err := array.Write(result.Rows[start:end]); err != nil {
+			return array.Bytes(), err
+		}
+	}
+	err := array.Close()
+	return array.Bytes(), err
+}
+
+// ArrayWriter streams rows to w as one JSON array, rendered exactly like ToJSONOrdered,
+// so a file can be converted chunk by chunk without holding every row in memory. It
+// always renders an array, whatever the converter's shape.
+type ArrayWriter struct {
+	c     *Converter
+	w     io.Writer
+	rows  int
+	bytes int64
+}
+
+// NewArrayWriter starts a JSON array written to w
+func (c *Converter) NewArrayWriter(w io.Writer) *ArrayWriter {
+	return &ArrayWriter{c: c, w: w}
+}
+
+// Write renders rows and appends them to the array
+func (a *ArrayWriter) Write(rows []parser.OrderedMap) error {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		if a.rows == 0 {
+			buf.WriteString("[\n")
+		} else {
+			buf.WriteString(",\n")
+		}
+		buf.WriteString(a.c.indent)
+		if err := a.c.writeObject(&buf, row, 1); err != nil {
+			return err
+		}
+		a.rows++
+	}
+	return a.write(a.c.finalize(buf.Bytes()))
+}
+
+// Close ends the array; it does not close the underlying writer
+func (a *ArrayWriter) Close() error {
+	if a.rows == 0 {
+		return a.write([]byte("[]"))
+	}
+	return a.write([]byte("\n]"))
+}
+
+// Rows returns the number of rows written
+func (a *ArrayWriter) Rows() int {
+	return a.rows
+}
+
+// Bytes returns the number of bytes written
+func (a *ArrayWriter) Bytes() int64 {
+	return a.bytes
+}
+
+func (a *ArrayWriter) write(p []byte) error {
+	n, err := a.w.Write(p)
+	a.bytes += int64(n)
+	return err
+}
+
+// writeArray renders rows as a JSON array at the given nesting level
+func (c *Converter) writeArray(buf *bytes.Buffer, rows []parser.OrderedMap, level int) error {
+	if len(rows) == 0 {
+		buf.WriteString("[]")
+		return nil
+	}
+
+	buf.WriteString("[\n")
+	for i, row := range rows {
 		if i > 0 {
 			buf.WriteString(",\n")
 		}
-		buf.WriteString(c.indent + "{\n")
+		buf.WriteString(strings.Repeat(c.indent, level+1))
+		if err := c.writeObject(buf, row, level+1); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("\n" + strings.Repeat(c.indent, level) + "]")
+	return nil
+}
 
-		for j, key := range row.Keys {
-			value := row.Values[key]
-			// Escape JSON string
-			valueJSON, err := json.Marshal(value)
-			if err != nil {
-				return nil, fmt.Errorf("failed to marshal value: %w", err)
+// writeObject renders a row as a JSON object, fields in key order, nested arrays inline
+func (c *Converter) writeObject(buf *bytes.Buffer, row parser.OrderedMap, level int) error {
+	buf.WriteString("{\n")
+	fieldIndent := strings.Repeat(c.indent, level+1)
+
+	for j, key := range row.Keys {
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return fmt.Errorf("failed to marshal key: %w", err)
+		}
+
+		buf.WriteString(fieldIndent)
+		buf.Write(keyJSON)
+		buf.WriteString(": ")
+
+		if children, nested := row.Nested[key]; nested {
+			if err := c.writeArray(buf, children, level+1); err != nil {
+				return err
 			}
-			keyJSON, err := json.Marshal(key)
+		} else {
+			valueJSON, err := c.valueJSON(key, row.Values[key])
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal key: %w", err)
+				return err
 			}
-
-			buf.WriteString(c.indent + c.indent)
-			buf.Write(keyJSON)
-			buf.WriteString(": ")
 			buf.Write(valueJSON)
-			if j < len(row.Keys)-1 {
-				buf.WriteString(",")
-			}
-			buf.WriteString("\n")
 		}
 
-		buf.WriteString(c.indent + "}")
+		if j < len(row.Keys)-1 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
 	}
 
-	buf.WriteString("\n]")
-	return buf.Bytes(), nil
+	buf.WriteString(strings.Repeat(c.indent, level) + "}")
+	return nil
 }
 
-func (c *Converter) ToJSONFile(data []map[string]string, outputPath string) error {
-	jsonBytes, err := c.ToJSON(data)
-	if err != nil {
-		return err
+// finalize applies output-wide options to rendered JSON
+func (c *Converter) finalize(jsonBytes []byte) []byte {
+	if c.asciiSafe {
+		return EscapeNonASCII(jsonBytes)
+	}
+	return jsonBytes
+}
+
+// EscapeNonASCII rewrites every non-ASCII character in rendered JSON as a \uXXXX escape
+// (surrogate pairs above the BMP). Raw non-ASCII bytes can only occur inside JSON strings,
+// so the result is equivalent JSON that is pure 7-bit ASCII.
+func EscapeNonASCII(jsonBytes []byte) []byte {
+	firstNonASCII := -1
+	for i, b := range jsonBytes {
+		if b >= utf8.RuneSelf {
+			firstNonASCII = i
+			break
+		}
+	}
+	if firstNonASCII < 0 {
+		return jsonBytes
 	}
 
+	var buf bytes.Buffer
+	buf.Grow(len(jsonBytes) + len(jsonBytes)/2)
+	buf.Write(jsonBytes[:firstNonASCII])
+	for rest := jsonBytes[firstNonASCII:]; len(rest) > 0; {
+		r, size := utf8.DecodeRune(rest)
+		rest = rest[size:]
+		switch {
+		case r < utf8.RuneSelf:
+			buf.WriteByte(byte(r))
+		case r > 0xFFFF:
+			high, low := utf16.EncodeRune(r)
+			fmt.Fprintf(&buf, "\\u%04x\\u%04x", high, low)
+		default:
+			fmt.Fprintf(&buf, "\\u%04x", r)
+		}
+	}
+	return buf.Bytes()
+}
+
+func (c *Converter) ToJSONFile(data []map[string]string, outputPath string) error {
 	// Ensure output directory exists
 	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
 		return fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to write JSON file: %w", err)
+	}
+	_, err = c.ToJSONWriter(data, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(outputPath)
 		return fmt.Errorf("failed to write JSON file: %w", err)
 	}
 
@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"bytes"
 	"csv2json/internal/parser"
 	"encoding/json"
 	"os"
@@ -410,3 +411,228 @@ func TestToJSONOrdered_FieldOrderPreservation(t *testing.T) {
 		t.Errorf("Row 1 values incorrect: %v", decoded[0])
 	}
 }
+
+// TestToJSONOrderedASCIISafe validates non-ASCII escaping for legacy consumers
+func TestToJSONOrderedASCIISafe(t *testing.T) {
+	result := &parser.ParseResult{
+		Headers: []string{"name", "note"},
+		Rows: []parser.OrderedMap{
+			{Keys: []string{"name", "note"}, Values: map[string]string{"name": "José", "note": "€5 🎉"}},
+		},
+	}
+
+	jsonBytes, err := NewWithOptions(Options{ASCIISafe: true}).ToJSONOrdered(result)
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+
+	for _, b := range jsonBytes {
+		if b >= 0x80 {
+			t.Fatalf("Expected pure ASCII output, found byte 0x%x in %s", b, jsonBytes)
+		}
+	}
+	if !strings.Contains(string(jsonBytes), `"Jos\u00e9"`) {
+		t.Errorf("Expected escaped name, got %s", jsonBytes)
+	}
+	if !strings.Contains(string(jsonBytes), `"\u20ac5 \ud83c\udf89"`) {
+		t.Errorf("Expected escaped note with surrogate pair, got %s", jsonBytes)
+	}
+
+	// Escaped output must decode to the original values
+	var decoded []map[string]string
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		t.Fatalf("Generated JSON is invalid: %v", err)
+	}
+	if decoded[0]["note"] != "€5 🎉" {
+		t.Errorf("Expected note '€5 🎉' after decoding, got %q", decoded[0]["note"])
+	}
+
+	// Default behaviour keeps raw UTF-8
+	jsonBytes, err = New().ToJSONOrdered(result)
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+	if !strings.Contains(string(jsonBytes), "José") {
+		t.Errorf("Expected raw UTF-8 by default, got %s", jsonBytes)
+	}
+}
+
+// TestToJSONOrderedEmptyRows validates an empty result renders as an empty JSON array
+func TestToJSONOrderedEmptyRows(t *testing.T) {
+	jsonBytes, err := New().ToJSONOrdered(&parser.ParseResult{})
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+	if string(jsonBytes) != "[]" {
+		t.Errorf("Expected '[]', got %q", string(jsonBytes))
+	}
+}
+
+// TestArrayWriterMatchesToJSONOrdered validates chunked rendering produces the same JSON as a single pass
+func TestArrayWriterMatchesToJSONOrdered(t *testing.T) {
+	row := func(id, name string) parser.OrderedMap {
+		return parser.OrderedMap{Keys: []string{"id", "name"}, Values: map[string]string{"id": id, "name": name}}
+	}
+	rows := []parser.OrderedMap{row("1", "Zoë"), row("2", "b"), row("3", "c")}
+
+	for _, c := range []*Converter{New(), NewWithOptions(Options{ASCIISafe: true})} {
+		want, err := c.ToJSONOrdered(&parser.ParseResult{Rows: rows})
+		if err != nil {
+			t.Fatalf("ToJSONOrdered failed: %v", err)
+		}
+
+		var buf bytes.Buffer
+		writer := c.NewArrayWriter(&buf)
+		for _, chunk := range [][]parser.OrderedMap{rows[:2], nil, rows[2:]} {
+			if err := writer.Write(chunk); err != nil {
+				t.Fatalf("Write failed: %v", err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("Close failed: %v", err)
+		}
+		if buf.String() != string(want) || writer.Rows() != 3 || writer.Bytes() != int64(len(want)) {
+			t.Errorf("Expected %q (3 rows, %d bytes), got %q (%d rows, %d bytes)", want, len(want), buf.String(), writer.Rows(), writer.Bytes())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := New().NewArrayWriter(&buf).Close(); err != nil || buf.String() != "[]" {
+		t.Errorf("Expected an empty array, got %q (%v)", buf.String(), err)
+	}
+}
+
+// TestWriterVariantsMatchByteSlices validates that the io.Writer variants stream the same JSON as ToJSON and ToJSONOrdered
+func TestWriterVariantsMatchByteSlices(t *testing.T) {
+	var rows []parser.OrderedMap
+	var maps []map[string]string
+	for i := 0; i < writerChunkRows*2+3; i++ {
+		values := map[string]string{"id": strings.Repeat("9", i%5+1), "name": "Zoë <" + string(rune('a'+i%26)) + ">"}
+		rows = append(rows, parser.OrderedMap{Keys: []string{"name", "id"}, Values: values})
+		maps = append(maps, values)
+	}
+	rows[1].Keys = append(rows[1].Keys, "items")
+	rows[1].Nested = map[string][]parser.OrderedMap{"items": {{Keys: []string{"sku"}, Values: map[string]string{"sku": "A-1"}}}}
+
+	for _, c := range []*Converter{New(), NewWithOptions(Options{ASCIISafe: true})} {
+		for _, tc := range []struct {
+			name string
+			rows []parser.OrderedMap
+		}{{"empty", nil}, {"single", rows[:1]}, {"chunked", rows}} {
+			want, err := c.ToJSONOrdered(&parser.ParseResult{Rows: tc.rows})
+			if err != nil {
+				t.Fatalf("ToJSONOrdered failed: %v", err)
+			}
+			var buf bytes.Buffer
+			written, err := c.ToJSONOrderedWriter(&parser.ParseResult{Rows: tc.rows}, &buf)
+			if err != nil {
+				t.Fatalf("ToJSONOrderedWriter failed: %v", err)
+			}
+			if buf.String() != string(want) || written != int64(len(want)) {
+				t.Errorf("%s: ordered writer output differs (%d bytes written, %d expected)", tc.name, written, len(want))
+			}
+		}
+
+		for _, tc := range []struct {
+			name string
+			data []map[string]string
+		}{{"nil", nil}, {"empty", []map[string]string{}}, {"empty row", []map[string]string{{}}}, {"chunked", maps}} {
+			want, err := c.ToJSON(tc.data)
+			if err != nil {
+				t.Fatalf("ToJSON failed: %v", err)
+			}
+			var buf bytes.Buffer
+			written, err := c.ToJSONWriter(tc.data, &buf)
+			if err != nil {
+				t.Fatalf("ToJSONWriter failed: %v", err)
+			}
+			if buf.String() != string(want) || written != int64(len(want)) {
+				t.Errorf("%s: writer output %q differs from %q", tc.name, buf.String(), want)
+			}
+		}
+	}
+}
+
+// TestObjectShape validates single-row files render as one object and other row
+// counts are rejected
+func TestObjectShape(t *testing.T) {
+	c := NewWithOptions(Options{Shape: ShapeObject})
+	row := parser.OrderedMap{Keys: []string{"name", "id"}, Values: map[string]string{"id": "1", "name": "Zoë"}}
+
+	got, err := c.ToJSONOrdered(&parser.ParseResult{Rows: []parser.OrderedMap{row}})
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+	want := "{\n  \"name\": \"Zoë\",\n  \"id\": \"1\"\n}"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	var buf bytes.Buffer
+	if _, err := c.ToJSONOrderedWriter(&parser.ParseResult{Rows: []parser.OrderedMap{row}}, &buf); err != nil || buf.String() != want {
+		t.Errorf("Expected the writer to render %q, got %q (%v)", want, buf.String(), err)
+	}
+	if got, err := c.ToJSON([]map[string]string{row.Values}); err != nil || !strings.HasPrefix(string(got), "{") {
+		t.Errorf("Expected ToJSON to render an object, got %q (%v)", got, err)
+	}
+
+	for _, rows := range [][]parser.OrderedMap{nil, {row, row}} {
+		if _, err := c.ToJSONOrdered(&parser.ParseResult{Rows: rows}); err == nil {
+			t.Errorf("Expected error for %d rows, got success", len(rows))
+		}
+	}
+	if err := ValidateShape("table"); err == nil {
+		t.Error("Expected error for unknown shape, got success")
+	}
+}
+
+// TestTypeInference validates native JSON values under type inference, with column
+// types overriding it
+func TestTypeInference(t *testing.T) {
+	keys := []string{"age", "price", "active", "note", "zip", "account", "big", "label"}
+	row := parser.OrderedMap{Keys: keys, Values: map[string]string{
+		"age": "30", "price": "9.50", "active": "TRUE", "note": "", "zip": "007",
+		"account": "12345", "big": "123456789012345678901234", "label": "1e3x",
+	}}
+	c := NewWithOptions(Options{InferTypes: true, ColumnTypes: map[string]string{"account": TypeString}})
+
+	got, err := c.ToJSONOrdered(&parser.ParseResult{Rows: []parser.OrderedMap{row}})
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, got); err != nil {
+		t.Fatalf("Rendered invalid JSON: %v", err)
+	}
+	want := `[{"age":30,"price":9.50,"active":true,"note":null,"zip":"007","account":"12345","big":"123456789012345678901234","label":"1e3x"}]`
+	if compact.String() != want {
+		t.Errorf("Expected %s, got %s", want, compact.String())
+	}
+
+	// Unordered rendering types values the same way
+	unordered, err := c.ToJSON([]map[string]string{row.Values})
+	if err != nil {
+		t.Fatalf("ToJSON failed: %v", err)
+	}
+	var decoded []map[string]any
+	if err := json.Unmarshal(unordered, &decoded); err != nil {
+		t.Fatalf("ToJSON rendered invalid JSON: %v", err)
+	}
+	if decoded[0]["age"] != float64(30) || decoded[0]["account"] != "12345" || decoded[0]["note"] != nil {
+		t.Errorf("Expected typed values from ToJSON, got %v", decoded[0])
+	}
+
+	// Declared types apply without inference, and reject values of other types
+	declared := NewWithOptions(Options{ColumnTypes: map[string]string{"age": TypeInteger}})
+	got, err = declared.ToJSONOrdered(&parser.ParseResult{Rows: []parser.OrderedMap{row}})
+	if err != nil || !strings.Contains(string(got), `"age": 30,`) || !strings.Contains(string(got), `"price": "9.50"`) {
+		t.Errorf("Expected only the declared column to be typed, got %s (%v)", got, err)
+	}
+	declared = NewWithOptions(Options{ColumnTypes: map[string]string{"price": TypeInteger}})
+	if _, err := declared.ToJSONOrdered(&parser.ParseResult{Rows: []parser.OrderedMap{row}}); err == nil {
+		t.Error("Expected error for a value that is not of the column's type, got success")
+	}
+
+	if err := ValidateType("date"); err == nil {
+		t.Error("Expected error for unknown column type, got success")
+	}
+}
@@ -0,0 +1,165 @@
+package converter
+
+import (
+	"csv2json/internal/parser"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// FromJSON flattens a JSON array of objects into rows for CSV output. Columns are
+// ordered by first appearance across the objects, so output is stable for a given
+// input. Nested objects become dotted columns (address.city); arrays are kept as
+// compact JSON text; null becomes an empty string. Numbers keep their literal form.
+func FromJSON(r io.Reader) (*parser.ParseResult, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of objects: %w", err)
+	}
+
+	result := &parser.ParseResult{Encoding: "utf-8"}
+	seen := make(map[string]bool)
+	for dec.More() {
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, fmt.Errorf("row %d: expected a JSON object: %w", len(result.Rows)+1, err)
+		}
+		row := parser.OrderedMap{Values: make(map[string]string)}
+		if err := flattenObject(dec, "", &row); err != nil {
+			return nil, fmt.Errorf("row %d: %w", len(result.Rows)+1, err)
+		}
+		for _, key := range row.Keys {
+			if !seen[key] {
+				seen[key] = true
+				result.Headers = append(result.Headers, key)
+			}
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON array")
+	}
+	return result, nil
+}
+
+// WriteCSV writes result as CSV with a header row; missing fields are written empty
+func WriteCSV(w io.Writer, result *parser.ParseResult, delimiter rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	if err := writer.Write(result.Headers); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	record := make([]string, len(result.Headers))
+	for _, row := range result.Rows {
+		for i, header := range result.Headers {
+			record[i] = row.Values[header]
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV row: %w", err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// GetCSVFilename returns the CSV output filename for a JSON input filename
+func GetCSVFilename(inputFilename string) string {
+	ext := filepath.Ext(inputFilename)
+	base := inputFilename[:len(inputFilename)-len(ext)]
+	return base + ".csv"
+}
+
+// flattenObject reads object members after the opening brace into row, prefixing nested keys
+func flattenObject(dec *json.Decoder, prefix string, row *parser.OrderedMap) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := prefix + tok.(string)
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			if err := flattenObject(dec, key+".", row); err != nil {
+				return err
+			}
+			continue
+		case json.Delim('['):
+			value, err := readArray(dec)
+			if err != nil {
+				return err
+			}
+			var text strings.Builder
+			enc := json.NewEncoder(&text)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(value); err != nil {
+				return err
+			}
+			setField(row, key, strings.TrimSuffix(text.String(), "\n"))
+		default:
+			setField(row, key, scalarString(tok))
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+// readArray reads array elements after the opening bracket as generic JSON values
+func readArray(dec *json.Decoder) ([]any, error) {
+	values := []any{}
+	for dec.More() {
+		var value any
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, expectDelim(dec, ']')
+}
+
+// setField sets a row value, keeping the key's first position if it repeats
+func setField(row *parser.OrderedMap, key, value string) {
+	if _, exists := row.Values[key]; !exists {
+		row.Keys = append(row.Keys, key)
+	}
+	row.Values[key] = value
+}
+
+// scalarString renders a JSON scalar token as CSV text
+func scalarString(tok json.Token) string {
+	switch v := tok.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	default: // null
+		return ""
+	}
+}
+
+// expectDelim reads the next token and checks it is the given delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("expected %q, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package converter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestFromJSONToCSV validates flattening to CSV with stable first-seen column order
+func TestFromJSONToCSV(t *testing.T) {
+	input := `[
+		{"id": 1, "name": "Widget", "address": {"city": "Leeds", "zip": null}, "tags": ["a", "<b>"]},
+		{"name": "Gadget, large", "id": "2", "active": true}
+	]`
+
+	result, err := FromJSON(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("FromJSON failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, result, ','); err != nil {
+		t.Fatalf("WriteCSV failed: %v", err)
+	}
+
+	want := "id,name,address.city,address.zip,tags,active\n" +
+		"1,Widget,Leeds,,\"[\"\"a\"\",\"\"<b>\"\"]\",\n" +
+		"2,\"Gadget, large\",,,,true\n"
+	if buf.String() != want {
+		t.Errorf("Unexpected CSV:\n got: %q\nwant: %q", buf.String(), want)
+	}
+}
+
+// TestFromJSONErrors validates inputs that are not an array of objects are rejected
+func TestFromJSONErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"object instead of array", `{"id": "1"}`},
+		{"array of scalars", `["a", "b"]`},
+		{"truncated", `[{"id": "1"}`},
+		{"trailing data", `[{"id": "1"}] []`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromJSON(strings.NewReader(tt.input)); err == nil {
+				t.Errorf("Expected error for %s, got success", tt.input)
+			}
+		})
+	}
+}
+
+// TestGetCSVFilename validates the output filename mirrors GetOutputFilename
+func TestGetCSVFilename(t *testing.T) {
+	if got := GetCSVFilename("orders_2024.json"); got != "orders_2024.csv" {
+		t.Errorf("Expected orders_2024.csv, got %s", got)
+	}
+}
@@ -0,0 +1,163 @@
+package converter
+
+import (
+	"csv2json/internal/parser"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Column value types. Values are strings (ADR-003) unless type inference is enabled or
+// a column declares another type.
+const (
+	TypeString  = "string"  // Always a JSON string
+	TypeNumber  = "number"  // A JSON number, integer or not
+	TypeInteger = "integer" // A JSON number without fraction or exponent
+	TypeBoolean = "boolean" // true or false, in any case
+)
+
+// ValidateType returns an error if typ is not a supported column type
+func ValidateType(typ string) error {
+	switch typ {
+	case TypeString, TypeNumber, TypeInteger, TypeBoolean:
+		return nil
+	default:
+		return fmt.Errorf("unsupported column type: %s (supported: string, number, integer, boolean)", typ)
+	}
+}
+
+// numberPattern matches JSON number literals, so a matching value is rendered as written.
+// Leading zeros ("007") and signs other than "-" do not match and stay strings.
+var numberPattern = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// isInteger reports whether value is a JSON integer that fits in 64 bits; longer
+// digit strings (e.g. account numbers) would lose precision in most consumers
+func isInteger(value string) bool {
+	if !numberPattern.MatchString(value) || strings.ContainsAny(value, ".eE") {
+		return false
+	}
+	_, err := strconv.ParseInt(value, 10, 64)
+	return err == nil
+}
+
+// isNumber reports whether value is a JSON number within float64 range
+func isNumber(value string) bool {
+	if !numberPattern.MatchString(value) {
+		return false
+	}
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// isNull reports whether value stands for a missing value: empty, or null in any case
+func isNull(value string) bool {
+	return value == "" || strings.EqualFold(value, "null")
+}
+
+// isBoolean reports whether value is true or false in any case
+func isBoolean(value string) bool {
+	return strings.EqualFold(value, "true") || strings.EqualFold(value, "false")
+}
+
+// typed reports whether any values may be rendered as other JSON types than strings
+func (c *Converter) typed() bool {
+	return c.inferTypes || len(c.columnTypes) > 0
+}
+
+// valueJSON renders the value of column key: with its declared type, the inferred type,
+// or as a string
+func (c *Converter) valueJSON(key, value string) ([]byte, error) {
+	typ, declared := c.columnTypes[key]
+	if !declared {
+		if !c.inferTypes {
+			return json.Marshal(value)
+		}
+		typ = inferType(value)
+	}
+	if typ == TypeString {
+		return json.Marshal(value)
+	}
+
+	switch {
+	case isNull(value):
+		return []byte("null"), nil
+	case typ == TypeBoolean && isBoolean(value):
+		return []byte(strings.ToLower(value)), nil
+	case typ == TypeInteger && isInteger(value), typ == TypeNumber && isNumber(value):
+		return []byte(value), nil
+	}
+	article := "a"
+	if typ == TypeInteger {
+		article = "an"
+	}
+	return nil, fmt.Errorf("column %s: %q is not %s %s", key, value, article, typ)
+}
+
+// inferType returns the type a value is rendered with under type inference
+func inferType(value string) string {
+	switch {
+	case isNull(value):
+		return TypeNumber // Any type other than string renders null
+	case isBoolean(value):
+		return TypeBoolean
+	case isInteger(value):
+		return TypeInteger
+	case isNumber(value) && strings.ContainsAny(value, ".eE"):
+		return TypeNumber
+	default:
+		return TypeString
+	}
+}
+
+// CheckTypes returns an error for the first value that does not match its column's
+// declared type, so a file can be rejected before it is rendered
+func (c *Converter) CheckTypes(rows []parser.OrderedMap) error {
+	for i, row := range rows {
+		for _, key := range row.Keys {
+			if children, nested := row.Nested[key]; nested {
+				if err := c.CheckTypes(children); err != nil {
+					return fmt.Errorf("row %d, %s: %w", i+1, key, err)
+				}
+				continue
+			}
+			if _, declared := c.columnTypes[key]; !declared {
+				continue
+			}
+			if _, err := c.valueJSON(key, row.Values[key]); err != nil {
+				return fmt.Errorf("row %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// typedRows renders the values of the rows of a file, or of its single row in object
+// output, as JSON
+func (c *Converter) typedRows(data []map[string]string) (any, error) {
+	if c.object {
+		return c.typedRow(data[0])
+	}
+	rows := make([]map[string]json.RawMessage, len(data))
+	for i, row := range data {
+		var err error
+		if rows[i], err = c.typedRow(row); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// typedRow returns row with its values rendered as JSON, for marshaling unordered maps
+func (c *Converter) typedRow(row map[string]string) (map[string]json.RawMessage, error) {
+	typed := make(map[string]json.RawMessage, len(row))
+	for key, value := range row {
+		valueJSON, err := c.valueJSON(key, value)
+		if err != nil {
+			return nil, err
+		}
+		typed[key] = valueJSON
+	}
+	return typed, nil
+}
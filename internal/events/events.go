@@ -0,0 +1,94 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies a pipeline event
+type Type string
+
+// Pipeline events, in the order a file passes through them
+const (
+	FileDetected     Type = "file.detected"     // A file was picked up for processing
+	ParseStarted     Type = "parse.started"     // Parsing of the file began
+	FileParsed       Type = "file.parsed"       // The file was parsed; Rows holds the rows read
+	RowsDeduplicated Type = "rows.deduplicated" // Duplicate rows were removed (DEDUP_KEYS); Rows holds their count
+	RowsRejected     Type = "rows.rejected"     // Parsed rows were rejected (schema, transform or contract failure); Rows holds their count
+	PublishConfirmed Type = "publish.confirmed" // The file's rows were delivered to the output; Rows holds their count
+	FileArchived     Type = "file.archived"     // The file was archived; Status holds the archive category
+)
+
+// Event describes one step of a file through a route's pipeline
+type Event struct {
+	Type   Type
+	Route  string // Route name ("default" in single-route mode)
+	File   string // Source filename
+	Rows   int    // Rows parsed, deduplicated, rejected or published
+	Status string // Archive category (FileArchived)
+	Reason string // Why the file was archived as failed or ignored, or its rows rejected
+	Detail string // Event-specific context, e.g. the detected encoding (FileParsed)
+	Time   time.Time
+}
+
+// Handler receives published events. Handlers run synchronously on the publishing
+// goroutine, so they must not block.
+type Handler func(Event)
+
+type subscription struct {
+	types   map[Type]bool // nil = every event
+	handler Handler
+}
+
+// Bus delivers events to its subscribers in the order they subscribed
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+}
+
+// NewBus creates a bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Default is the process-wide bus every route forwards its events to
+var Default = NewBus()
+
+// Subscribe registers handler for the given event types (none = every event)
+func (b *Bus) Subscribe(handler Handler, types ...Type) {
+	sub := subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, sub)
+}
+
+// Publish delivers event to every subscriber of its type, stamping the time if unset.
+// Publishing on a nil bus is a no-op.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+	for _, sub := range subscriptions {
+		if sub.types == nil || sub.types[event.Type] {
+			sub.handler(event)
+		}
+	}
+}
+
+// Subscribe registers handler on the default bus
+func Subscribe(handler Handler, types ...Type) { Default.Subscribe(handler, types...) }
+
+// Publish delivers event on the default bus
+func Publish(event Event) { Default.Publish(event) }
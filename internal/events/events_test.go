@@ -0,0 +1,33 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestBusPublish validates events reach subscribers of their type in subscription order
+func TestBusPublish(t *testing.T) {
+	bus := NewBus()
+	var got []string
+	bus.Subscribe(func(e Event) { got = append(got, "all:"+string(e.Type)) })
+	bus.Subscribe(func(e Event) { got = append(got, "archived:"+e.File) }, FileArchived)
+	bus.Subscribe(func(e Event) {
+		if e.Time.IsZero() {
+			t.Error("Expected published events to be timestamped")
+		}
+	}, FileDetected)
+
+	bus.Publish(Event{Type: FileDetected, File: "orders.csv"})
+	bus.Publish(Event{Type: FileArchived, File: "orders.csv", Status: "processed"})
+
+	want := []string{"all:file.detected", "all:file.archived", "archived:orders.csv"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// TestBusPublishNil validates publishing on a nil bus is a no-op
+func TestBusPublishNil(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: FileDetected})
+}
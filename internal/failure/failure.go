@@ -0,0 +1,130 @@
+// Package failure defines the categories of errors that fail a file: parsing,
+// validation, publishing and archiving. Errors carry the route, file, row and column
+// they occurred at, so processing policies and failed-file sidecars can branch on the
+// category instead of matching message text.
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Error categories, matched with errors.Is
+var (
+	ErrParse      = errors.New("parse error")      // The file could not be read or parsed
+	ErrValidation = errors.New("validation error") // Parsed rows were rejected (schema, quality, transforms, contract)
+	ErrPublish    = errors.New("publish error")    // Output could not be written or published
+	ErrArchive    = errors.New("archive error")    // The file could not be moved to its archive
+)
+
+// categories names each category in failed-file sidecars
+var categories = map[error]string{
+	ErrParse:      "parse",
+	ErrValidation: "validation",
+	ErrPublish:    "publish",
+	ErrArchive:    "archive",
+}
+
+// Error is an error of one category with the context it occurred in. Its message is
+// the wrapped error's, so logs and sidecars read as before.
+type Error struct {
+	Kind   error  // ErrParse, ErrValidation, ErrPublish or ErrArchive (nil = uncategorized)
+	Route  string // Route name ("" = unknown or single-route mode)
+	File   string // Source file name
+	Row    int    // Record number, header line included (1-based, 0 = not row-specific)
+	Column string // Column name or index ("" = not column-specific)
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the error's category
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+// Context renders the error's category and context as "Key: value" lines
+func (e *Error) Context() string {
+	var b strings.Builder
+	for _, field := range []struct{ key, value string }{
+		{"Category", categories[e.Kind]},
+		{"Route", e.Route},
+		{"Source", e.File},
+		{"Row", rowString(e.Row)},
+		{"Column", e.Column},
+	} {
+		if field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.key, field.value)
+		}
+	}
+	return b.String()
+}
+
+func rowString(row int) string {
+	if row == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", row)
+}
+
+// Parse categorizes err as a parse error unless it is already categorized
+func Parse(err error) error { return categorize(ErrParse, err) }
+
+// Validation categorizes err as a validation error unless it is already categorized
+func Validation(err error) error { return categorize(ErrValidation, err) }
+
+// Publish categorizes err as a publish error unless it is already categorized
+func Publish(err error) error { return categorize(ErrPublish, err) }
+
+// Archive categorizes err as an archive error unless it is already categorized
+func Archive(err error) error { return categorize(ErrArchive, err) }
+
+func categorize(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if errors.As(err, &e) && e.Kind != nil {
+		return err
+	}
+	if e != nil {
+		e.Kind = kind
+		return err
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// WithContext records the route and file err occurred in, keeping any context it
+// already carries. Uncategorized errors are wrapped without a category.
+func WithContext(err error, route, file string) error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if !errors.As(err, &e) {
+		return &Error{Route: route, File: file, Err: err}
+	}
+	if e.Route == "" {
+		e.Route = route
+	}
+	if e.File == "" {
+		e.File = file
+	}
+	return err
+}
+
+// Category returns the name of err's category ("" if uncategorized)
+func Category(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return categories[e.Kind]
+	}
+	return ""
+}
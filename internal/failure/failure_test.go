@@ -0,0 +1,50 @@
+package failure
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestCategorize validates errors keep their first category and message, and are
+// matched by category through wrapping
+func TestCategorize(t *testing.T) {
+	parseErr := &Error{Kind: ErrParse, Row: 3, Column: "amount", Err: errors.New("bad value")}
+	err := Publish(fmt.Errorf("partition EU: %w", parseErr))
+	if !errors.Is(err, ErrParse) || errors.Is(err, ErrPublish) {
+		t.Errorf("Expected the parse category to be kept, got %q", Category(err))
+	}
+	if err.Error() != "partition EU: bad value" {
+		t.Errorf("Expected the message to be unchanged, got %q", err.Error())
+	}
+
+	err = Validation(errors.New("schema drift"))
+	if !errors.Is(err, ErrValidation) || Category(err) != "validation" {
+		t.Errorf("Expected a validation error, got %q", Category(err))
+	}
+	if Parse(nil) != nil {
+		t.Error("Expected nil to stay nil")
+	}
+	if Category(errors.New("plain")) != "" {
+		t.Error("Expected plain errors to have no category")
+	}
+}
+
+// TestWithContext validates the route and file are added without overwriting context
+// the error already carries, and rendered with it
+func TestWithContext(t *testing.T) {
+	err := WithContext(Parse(&Error{File: "inner.csv", Row: 7, Err: errors.New("row 6 has 3 columns, expected 2")}), "orders", "orders.csv")
+	var e *Error
+	if !errors.As(err, &e) {
+		t.Fatal("Expected a categorized error")
+	}
+	want := "Category: parse\nRoute: orders\nSource: inner.csv\nRow: 7\n"
+	if got := e.Context(); got != want {
+		t.Errorf("Expected context %q, got %q", want, got)
+	}
+
+	err = WithContext(errors.New("disk full"), "orders", "orders.csv")
+	if !errors.As(err, &e) || e.Context() != "Route: orders\nSource: orders.csv\n" {
+		t.Errorf("Expected uncategorized errors to carry the context, got %q", e.Context())
+	}
+}
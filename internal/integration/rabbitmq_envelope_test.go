@@ -35,9 +35,9 @@ func TestRabbitMQIntegration_EnvelopeStructure(t *testing.T) {
 	handler.SetEnvelopeContext(
 		"integration-test-route",
 		"integration.csv.v1",
-		"/data/input/integration-test.csv",
-		true,
+		output.PayloadEnvelope,
 	)
+	handler.SetSourceFile("/data/input/integration-test.csv")
 
 	// Test data
 	data := []map[string]string{
@@ -217,9 +217,9 @@ func TestRabbitMQIntegration_MultipleMessages(t *testing.T) {
 		handler.SetEnvelopeContext(
 			"multi-test-route",
 			contract,
-			"/data/input/test"+string(rune(i))+".csv",
-			true,
+			output.PayloadEnvelope,
 		)
+		handler.SetSourceFile("/data/input/test" + string(rune(i)) + ".csv")
 
 		data := []map[string]string{
 			{"message": string(rune(i)), "contract": contract},
@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Metric types (Prometheus text exposition format)
+const (
+	Counter = "counter"
+	Gauge   = "gauge"
+)
+
+// Labels identifies one series within a metric family
+type Labels map[string]string
+
+// Registry holds metric families and renders them in Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+type family struct {
+	kind   string
+	help   string
+	series map[string]float64 // Rendered label set -> value
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Default is the process-wide registry served by Serve
+var Default = NewRegistry()
+
+// Register declares a metric family; registering the same name again is a no-op
+func (r *Registry) Register(name, kind, help string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[name]; !ok {
+		r.families[name] = &family{kind: kind, help: help, series: make(map[string]float64)}
+	}
+}
+
+// Add increments a series (counters)
+func (r *Registry) Add(name string, labels Labels, delta float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name).series[renderLabels(labels)] += delta
+}
+
+// Set sets a series to value (gauges)
+func (r *Registry) Set(name string, labels Labels, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.family(name).series[renderLabels(labels)] = value
+}
+
+// Value returns the current value of a series (0 if unset)
+func (r *Registry) Value(name string, labels Labels) float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		return f.series[renderLabels(labels)]
+	}
+	return 0
+}
+
+// family returns the named family, creating an untyped one if it was never registered
+func (r *Registry) family(name string) *family {
+	f, ok := r.families[name]
+	if !ok {
+		f = &family{kind: "untyped", series: make(map[string]float64)}
+		r.families[name] = f
+	}
+	return f
+}
+
+// Write renders all families in Prometheus text exposition format, sorted by name
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		f := r.families[name]
+		if f.help != "" {
+			fmt.Fprintf(&b, "# HELP %s %s\n", name, f.help)
+		}
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.kind)
+
+		series := make([]string, 0, len(f.series))
+		for labels := range f.series {
+			series = append(series, labels)
+		}
+		sort.Strings(series)
+		for _, labels := range series {
+			fmt.Fprintf(&b, "%s%s %g\n", name, labels, f.series[labels])
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Handler serves the registry over HTTP
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		if err := r.Write(w); err != nil {
+			log.Printf("Failed to write metrics: %v", err)
+		}
+	})
+}
+
+// Register declares a metric family on the default registry
+func Register(name, kind, help string) { Default.Register(name, kind, help) }
+
+// Add increments a series on the default registry
+func Add(name string, labels Labels, delta float64) { Default.Add(name, labels, delta) }
+
+// Set sets a series on the default registry
+func Set(name string, labels Labels, value float64) { Default.Set(name, labels, value) }
+
+// Serve exposes the default registry at /metrics on addr (blocks)
+func Serve(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Default.Handler())
+	log.Printf("Metrics available at http://%s/metrics", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// renderLabels formats labels as {k="v",...} sorted by key
+func renderLabels(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, k, labelEscaper.Replace(labels[k]))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper escapes label values as required by the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRegistryWrite validates the Prometheus text exposition output
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	r.Register("csv2json_route_up", Gauge, "Whether the route is running")
+	r.Register("csv2json_files_ignored_total", Counter, "Ignored files")
+
+	r.Set("csv2json_route_up", Labels{"route": "orders"}, 1)
+	r.Add("csv2json_files_ignored_total", Labels{"route": "orders", "reason": "excluded"}, 1)
+	r.Add("csv2json_files_ignored_total", Labels{"route": "orders", "reason": "excluded"}, 2)
+	r.Set("csv2json_route_up", Labels{"route": `we"ird\`}, 0)
+
+	var b strings.Builder
+	if err := r.Write(&b); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := `# HELP csv2json_files_ignored_total Ignored files
+# TYPE csv2json_files_ignored_total counter
+csv2json_files_ignored_total{reason="excluded",route="orders"} 3
+# HELP csv2json_route_up Whether the route is running
+# TYPE csv2json_route_up gauge
+csv2json_route_up{route="orders"} 1
+csv2json_route_up{route="we\"ird\\"} 0
+`
+	if b.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nexpected:\n%s", b.String(), expected)
+	}
+
+	if v := r.Value("csv2json_files_ignored_total", Labels{"reason": "excluded", "route": "orders"}); v != 3 {
+		t.Errorf("Expected value 3, got %v", v)
+	}
+}
+
+// TestRegistryHandler validates the registry is served over HTTP
+func TestRegistryHandler(t *testing.T) {
+	r := NewRegistry()
+	r.Add("csv2json_unregistered", nil, 1)
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if !strings.Contains(rec.Body.String(), "# TYPE csv2json_unregistered untyped\ncsv2json_unregistered 1\n") {
+		t.Errorf("Unexpected body: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// BackfillOrder is the order files already in the watch folder at startup are processed in
+type BackfillOrder string
+
+const (
+	BackfillOldest BackfillOrder = "oldest" // Oldest modification time first (default)
+	BackfillNewest BackfillOrder = "newest" // Newest modification time first
+	BackfillName   BackfillOrder = "name"   // Filename order
+)
+
+// backfillOrderNames describe the orders in log messages
+var backfillOrderNames = map[BackfillOrder]string{
+	BackfillOldest: "oldest first",
+	BackfillNewest: "newest first",
+	BackfillName:   "by name",
+}
+
+// closedChan is always ready; it paces backfill batches through a monitor's select loop
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// backfill is embedded by the monitors to process the files already in the watch
+// folder when they start, in order and in batches of maxFilesPerPoll
+type backfill struct {
+	order   BackfillOrder // "" = leave existing files to the usual detection
+	pending []string      // Backlog filenames still to process, in order
+	queued  map[string]bool
+}
+
+// SetBackfill makes the monitor process the files already in its folder when it
+// starts, in the given order; "" leaves them to the usual detection
+func (b *backfill) SetBackfill(order BackfillOrder) {
+	b.order = order
+}
+
+// startBackfill records the files in folder as the backlog
+func (b *backfill) startBackfill(folder string) error {
+	if b.order == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return err
+	}
+
+	type existing struct {
+		name    string
+		modTime time.Time
+	}
+	var files []existing
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed since the listing
+		}
+		files = append(files, existing{name: entry.Name(), modTime: info.ModTime()})
+	}
+
+	switch b.order {
+	case BackfillOldest, BackfillNewest:
+		sort.SliceStable(files, func(i, j int) bool {
+			if files[i].modTime.Equal(files[j].modTime) {
+				return files[i].name < files[j].name
+			}
+			if b.order == BackfillNewest {
+				return files[i].modTime.After(files[j].modTime)
+			}
+			return files[i].modTime.Before(files[j].modTime)
+		})
+	case BackfillName:
+		// ReadDir already lists files by name
+	default:
+		return fmt.Errorf("unsupported backfill order: %s", b.order)
+	}
+
+	b.queued = make(map[string]bool, len(files))
+	for _, f := range files {
+		b.pending = append(b.pending, f.name)
+		b.queued[f.name] = true
+	}
+	log.Printf("Backfill: %d existing file(s) in %s, processing %s", len(files), folder, backfillOrderNames[b.order])
+	return nil
+}
+
+// backfillReady is ready while backlog files remain, so a monitor's select loop
+// processes one batch per iteration and still serves stop and trigger requests
+func (b *backfill) backfillReady() <-chan struct{} {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	return closedChan
+}
+
+// inBackfill reports whether filename is waiting in the backlog, so that regular
+// scans leave it to be processed in order
+func (b *backfill) inBackfill(filename string) bool {
+	return b.queued[filename]
+}
+
+// backfillBatch hands the next limit (0 = all) backlog files to callback, skipping
+// files already handled or removed, marks them in processed and returns how many
+// were handed over
+func (b *backfill) backfillBatch(folder string, limit int, processed map[string]bool, ready func(string) bool, callback FileCallback) int {
+	count := 0
+	for len(b.pending) > 0 && (limit <= 0 || count < limit) {
+		filename := b.pending[0]
+		b.pending = b.pending[1:]
+		delete(b.queued, filename)
+
+		if processed[filename] {
+			continue
+		}
+		filePath := filepath.Join(folder, filename)
+		if !ready(filePath) {
+			continue // Removed, or still changing: left to the usual detection
+		}
+
+		log.Printf("Backfilling existing file: %s", filename)
+		if err := callback(filePath); err != nil {
+			log.Printf("Error processing %s: %v", filename, err)
+		}
+		markHandled(processed, filePath)
+		count++
+	}
+	if len(b.pending) == 0 {
+		log.Printf("Backfill of %s complete", folder)
+	}
+	return count
+}
@@ -9,18 +9,46 @@ import (
 	"github.com/fsnotify/fsnotify"
 )
 
-// EventMonitor uses fsnotify for event-driven file detection
+// eventDebounce is how long a file's events must pause before its readiness is
+// checked, so the burst of Write events reported while a file is written coalesces
+// into one check
+const eventDebounce = 100 * time.Millisecond
+
+// readiness is the outcome of a file's readiness check
+type readiness struct {
+	filename string
+	ready    bool
+}
+
+// EventMonitor uses fsnotify for event-driven file detection. Events are coalesced
+// per file, and readiness checks run concurrently so one file's stability window
+// does not hold up detection of the others.
 type EventMonitor struct {
 	watchFolder     string
+	pollInterval    time.Duration // Length of the cycles maxFilesPerPoll applies to
 	maxFilesPerPoll int
 	processedFiles  map[string]bool
+	cycleCount      int      // Files handled in the current cycle
+	queued          []string // Files held over for later cycles, in arrival order
+	queuedFiles     map[string]bool
+	settling        map[string]time.Time // Time of the last event of files waiting for their events to pause
+	checking        map[string]bool      // Files whose readiness check is running
+	settled         chan string          // Files whose debounce timer fired
+	checked         chan readiness       // Results of readiness checks
 	running         bool
 	stopChan        chan struct{}
 	watcher         *fsnotify.Watcher
+	*liveness
+	stability
+	trigger
+	backfill
+	watchStats
 }
 
-// NewEventMonitor creates an event-driven file monitor using fsnotify
-func NewEventMonitor(watchFolder string, maxFilesPerPoll int) (*EventMonitor, error) {
+// NewEventMonitor creates an event-driven file monitor using fsnotify. At most
+// maxFilesPerPoll files (0 = unlimited) are handled per pollInterval; files detected
+// beyond that are queued for the following intervals.
+func NewEventMonitor(watchFolder string, pollInterval time.Duration, maxFilesPerPoll int) (*EventMonitor, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
@@ -28,9 +56,18 @@ func NewEventMonitor(watchFolder string, maxFilesPerPoll int) (*EventMonitor, er
 
 	return &EventMonitor{
 		watchFolder:     watchFolder,
+		pollInterval:    pollInterval,
 		maxFilesPerPoll: maxFilesPerPoll,
 		processedFiles:  make(map[string]bool),
+		queuedFiles:     make(map[string]bool),
+		settling:        make(map[string]time.Time),
+		checking:        make(map[string]bool),
+		settled:         make(chan string),
+		checked:         make(chan readiness),
 		stopChan:        make(chan struct{}),
+		liveness:        newLiveness(),
+		stability:       newStability(),
+		trigger:         newTrigger(),
 		watcher:         watcher,
 	}, nil
 }
@@ -47,6 +84,24 @@ func (m *EventMonitor) Start(callback FileCallback) error {
 
 	log.Printf("Event-driven file monitor started on %s", m.watchFolder)
 
+	// Heartbeat ticker lets watchdogs tell an idle loop from a stalled one
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	m.markReady()
+
+	// Existing files are processed first when backfill is enabled
+	if err := m.startBackfill(m.watchFolder); err != nil {
+		log.Printf("Backfill failed: %v", err)
+	}
+
+	// With a per-cycle limit, each tick starts a cycle and releases queued files
+	var cycle <-chan time.Time
+	if m.maxFilesPerPoll > 0 {
+		ticker := time.NewTicker(m.pollInterval)
+		defer ticker.Stop()
+		cycle = ticker.C
+	}
+
 	// Process events
 	for {
 		select {
@@ -57,14 +112,44 @@ func (m *EventMonitor) Start(callback FileCallback) error {
 
 			// Only care about Create and Write events
 			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
-				m.handleFileEvent(event.Name, callback)
+				m.record(WatchEventReceived)
+				m.coalesce(event.Name)
+			}
+
+		case filename := <-m.settled:
+			m.settle(filename)
+
+		case result := <-m.checked:
+			delete(m.checking, result.filename)
+			// Events since the check started mean the file changed; it is checked again
+			if _, changed := m.settling[result.filename]; result.ready && !changed {
+				m.admit(filepath.Join(m.watchFolder, result.filename), callback)
 			}
 
 		case err, ok := <-m.watcher.Errors:
 			if !ok {
 				return nil
 			}
-			log.Printf("Watcher error: %v", err)
+			if m.watchError(err) {
+				if err := m.rescan(callback); err != nil {
+					log.Printf("Error during overflow rescan: %v", err)
+				}
+			}
+
+		case <-cycle:
+			m.nextCycle(callback)
+
+		case <-m.backfillDue():
+			m.cycleCount += m.backfillBatch(m.watchFolder, m.cycleRoom(), m.processedFiles, m.isFileReady, callback)
+
+		case <-m.triggered():
+			log.Printf("Manual trigger: rescanning %s", m.watchFolder)
+			if err := m.rescan(callback); err != nil {
+				log.Printf("Error during triggered scan: %v", err)
+			}
+
+		case <-heartbeat.C:
+			m.beat()
 
 		case <-m.stopChan:
 			log.Println("Event-driven file monitor stopped")
@@ -82,50 +167,164 @@ func (m *EventMonitor) Stop() {
 	}
 }
 
-func (m *EventMonitor) handleFileEvent(filePath string, callback FileCallback) {
-	// Extract filename
-	filename := filepath.Base(filePath)
+// rescan treats every file already in the watch folder as if it had an event,
+// picking up files whose events were missed
+func (m *EventMonitor) rescan(callback FileCallback) error {
+	entries, err := os.ReadDir(m.watchFolder)
+	if err != nil {
+		return err
+	}
 
-	// Skip directories
-	info, err := os.Stat(filePath)
-	if err != nil || info.IsDir() {
-		return
+	for _, entry := range entries {
+		if entry.IsDir() || m.processedFiles[entry.Name()] || m.inBackfill(entry.Name()) {
+			continue
+		}
+		// A file not already on its way through detection had no event delivered
+		if _, settling := m.settling[entry.Name()]; !settling && !m.checking[entry.Name()] && !m.queuedFiles[entry.Name()] {
+			m.record(WatchEventMissed)
+		}
+		m.coalesce(filepath.Join(m.watchFolder, entry.Name()))
 	}
 
-	// Skip already processed files
-	if m.processedFiles[filename] {
+	return nil
+}
+
+// coalesce records an event for a file; its readiness is checked once its events
+// pause for eventDebounce
+func (m *EventMonitor) coalesce(filePath string) {
+	filename := filepath.Base(filePath)
+	_, waiting := m.settling[filename]
+	m.settling[filename] = time.Now()
+	if waiting {
+		m.record(WatchEventCoalesced)
 		return
 	}
+	m.settleAfter(filename, eventDebounce)
+}
 
-	// Wait for file to be ready (not being written)
-	if !m.isFileReady(filePath) {
+// settleAfter reports filename on the settled channel after delay
+func (m *EventMonitor) settleAfter(filename string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		select {
+		case m.settled <- filename:
+		case <-m.stopChan:
+		}
+	})
+}
+
+// settle starts the readiness check of a file whose events have paused, in the
+// background; the result arrives on the checked channel
+func (m *EventMonitor) settle(filename string) {
+	last, ok := m.settling[filename]
+	if !ok {
+		return
+	}
+	// Events arrived since the timer was set: wait for them to pause
+	if wait := eventDebounce - time.Since(last); wait > 0 {
+		m.settleAfter(filename, wait)
+		return
+	}
+	// One check per file at a time; a file changed during its check is checked again after
+	if m.checking[filename] {
+		m.settleAfter(filename, eventDebounce)
 		return
 	}
+	delete(m.settling, filename)
 
-	log.Printf("Detected new file: %s", filename)
+	m.checking[filename] = true
+	filePath := filepath.Join(m.watchFolder, filename)
+	go func() {
+		result := readiness{filename: filename, ready: m.isFileReady(filePath)}
+		select {
+		case m.checked <- result:
+		case <-m.stopChan:
+		}
+	}()
+}
 
-	// Process file
-	if err := callback(filePath); err != nil {
-		log.Printf("Error processing %s: %v", filename, err)
+// cycleFull reports whether the current cycle has handled maxFilesPerPoll files
+func (m *EventMonitor) cycleFull() bool {
+	return m.maxFilesPerPoll > 0 && m.cycleCount >= m.maxFilesPerPoll
+}
+
+// cycleRoom returns how many more files the current cycle takes (0 = no limit)
+func (m *EventMonitor) cycleRoom() int {
+	if m.maxFilesPerPoll <= 0 {
+		return 0
 	}
+	return m.maxFilesPerPoll - m.cycleCount
+}
 
-	// Mark as processed
-	m.processedFiles[filename] = true
+// backfillDue paces backlog batches by the per-cycle limit
+func (m *EventMonitor) backfillDue() <-chan struct{} {
+	if m.cycleFull() {
+		return nil
+	}
+	return m.backfillReady()
 }
 
-func (m *EventMonitor) isFileReady(filePath string) bool {
-	info1, err := os.Stat(filePath)
-	if err != nil {
-		return false
+// admit handles a detected file, or queues it for a later cycle once the current
+// cycle has reached maxFilesPerPoll
+func (m *EventMonitor) admit(filePath string, callback FileCallback) {
+	if !m.cycleFull() {
+		if m.handleFileEvent(filePath, callback) {
+			m.cycleCount++
+		}
+		return
 	}
 
-	time.Sleep(2 * time.Second)
+	filename := filepath.Base(filePath)
+	if m.queuedFiles[filename] || m.processedFiles[filename] || m.inBackfill(filename) {
+		return
+	}
+	if len(m.queued) == 0 {
+		log.Printf("Reached max files per poll limit (%d), queuing further files for the next cycle", m.maxFilesPerPoll)
+	}
+	m.queued = append(m.queued, filename)
+	m.queuedFiles[filename] = true
+}
 
-	info2, err := os.Stat(filePath)
-	if err != nil {
+// nextCycle starts a cycle and releases queued files up to maxFilesPerPoll
+func (m *EventMonitor) nextCycle(callback FileCallback) {
+	m.cycleCount = 0
+	for len(m.queued) > 0 && !m.cycleFull() {
+		filename := m.queued[0]
+		m.queued = m.queued[1:]
+		delete(m.queuedFiles, filename)
+		if m.handleFileEvent(filepath.Join(m.watchFolder, filename), callback) {
+			m.cycleCount++
+		}
+	}
+	if len(m.queued) > 0 {
+		log.Printf("%d file(s) still queued, max files per poll limit (%d) reached", len(m.queued), m.maxFilesPerPoll)
+	}
+}
+
+// handleFileEvent hands a detected file that passed its readiness check to callback,
+// and reports whether it did
+func (m *EventMonitor) handleFileEvent(filePath string, callback FileCallback) bool {
+	// Extract filename
+	filename := filepath.Base(filePath)
+
+	// Skip directories
+	info, err := os.Stat(filePath)
+	if err != nil || info.IsDir() {
 		return false
 	}
 
-	// If size hasn't changed, file is probably ready
-	return info1.Size() == info2.Size()
+	// Skip already processed files, and backlog files awaiting their turn
+	if m.processedFiles[filename] || m.inBackfill(filename) {
+		return false
+	}
+
+	log.Printf("Detected new file: %s", filename)
+
+	// Process file
+	if err := callback(filePath); err != nil {
+		log.Printf("Error processing %s: %v", filename, err)
+	}
+
+	// Mark as processed
+	markHandled(m.processedFiles, filePath)
+	return true
 }
@@ -0,0 +1,166 @@
+package monitor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// TestEventMonitorMaxFilesPerPoll validates a burst of events is handled maxFilesPerPoll files per cycle, in arrival order
+func TestEventMonitorMaxFilesPerPoll(t *testing.T) {
+	tempDir := t.TempDir()
+	m, err := NewEventMonitor(tempDir, time.Hour, 2)
+	if err != nil {
+		t.Fatalf("Failed to create event monitor: %v", err)
+	}
+	defer m.watcher.Close()
+	m.SetStabilityWindow(0)
+
+	var handled []string
+	callback := func(path string) error {
+		handled = append(handled, filepath.Base(path))
+		return nil
+	}
+
+	// A burst of five files, with a repeated event for a queued file
+	burst := []string{"e.csv", "d.csv", "c.csv", "b.csv", "a.csv"}
+	for _, name := range burst {
+		path := filepath.Join(tempDir, name)
+		if err := os.WriteFile(path, []byte("a,b\n"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		m.admit(path, callback)
+	}
+	m.admit(filepath.Join(tempDir, "c.csv"), callback)
+
+	tests := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "first cycle", expected: []string{"e.csv", "d.csv"}},
+		{name: "second cycle", expected: []string{"e.csv", "d.csv", "c.csv", "b.csv"}},
+		{name: "third cycle", expected: []string{"e.csv", "d.csv", "c.csv", "b.csv", "a.csv"}},
+		{name: "queue drained", expected: []string{"e.csv", "d.csv", "c.csv", "b.csv", "a.csv"}},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if i > 0 {
+				m.nextCycle(callback)
+			}
+			if !reflect.DeepEqual(handled, tt.expected) {
+				t.Errorf("Expected %v handled, got %v", tt.expected, handled)
+			}
+		})
+	}
+}
+
+// TestEventMonitorCoalescesEvents validates repeated events for a file produce one callback, and stability windows overlap across files
+func TestEventMonitorCoalescesEvents(t *testing.T) {
+	tempDir := t.TempDir()
+	m, err := NewEventMonitor(tempDir, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Failed to create event monitor: %v", err)
+	}
+	window := 500 * time.Millisecond
+	m.SetStabilityWindow(window)
+
+	detected := make(chan string, 20)
+	go m.Start(func(path string) error {
+		detected <- filepath.Base(path)
+		return nil
+	})
+	defer m.Stop()
+	<-m.Ready()
+
+	// Each file is written in several chunks, producing several Write events
+	files := []string{"a.csv", "b.csv", "c.csv", "d.csv", "e.csv"}
+	for _, name := range files {
+		f, err := os.Create(filepath.Join(tempDir, name))
+		if err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			f.WriteString("a,b\n")
+			f.Sync()
+		}
+		f.Close()
+	}
+	start := time.Now()
+
+	seen := make(map[string]int)
+	for range files {
+		select {
+		case name := <-detected:
+			seen[name]++
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Only %d of %d files detected", len(seen), len(files))
+		}
+	}
+	// Checked one after another, the windows would take len(files) * window
+	if elapsed := time.Since(start); elapsed > time.Duration(len(files)-2)*window {
+		t.Errorf("Expected readiness checks to overlap, detection took %v", elapsed)
+	}
+
+	// No further callbacks for the coalesced events
+	select {
+	case name := <-detected:
+		seen[name]++
+	case <-time.After(2 * eventDebounce):
+	}
+	for _, name := range files {
+		if seen[name] != 1 {
+			t.Errorf("Expected %s to be detected once, got %d", name, seen[name])
+		}
+	}
+}
+
+// TestEventMonitorWatchStats validates watcher events are counted and an overflow rescan finds the files whose events were dropped
+func TestEventMonitorWatchStats(t *testing.T) {
+	tempDir := t.TempDir()
+	m, err := NewEventMonitor(tempDir, time.Hour, 0)
+	if err != nil {
+		t.Fatalf("Failed to create event monitor: %v", err)
+	}
+	defer m.watcher.Close()
+	defer close(m.stopChan)
+
+	counts := make(map[WatchEvent]int)
+	m.SetWatchReporter(func(event WatchEvent) { counts[event]++ })
+
+	for _, name := range []string{"seen.csv", "dropped.csv"} {
+		if err := os.WriteFile(filepath.Join(tempDir, name), []byte("a,b\n"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+	}
+	// Only seen.csv had its event delivered before the queue overflowed
+	m.coalesce(filepath.Join(tempDir, "seen.csv"))
+
+	if m.watchError(errors.New("inotify read failed")) {
+		t.Error("Expected no rescan for an ordinary watcher error")
+	}
+	if !m.watchError(fsnotify.ErrEventOverflow) {
+		t.Fatal("Expected a rescan after an event queue overflow")
+	}
+	if err := m.rescan(func(string) error { return nil }); err != nil {
+		t.Fatalf("Rescan failed: %v", err)
+	}
+
+	expected := map[WatchEvent]int{
+		WatchEventError:     1,
+		WatchEventOverflow:  1,
+		WatchEventMissed:    1,
+		WatchEventCoalesced: 1,
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("Expected counts %v, got %v", expected, counts)
+	}
+	if _, ok := m.settling["dropped.csv"]; !ok {
+		t.Error("Expected the rescan to start detection of dropped.csv")
+	}
+}
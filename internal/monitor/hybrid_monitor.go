@@ -18,6 +18,11 @@ type HybridMonitor struct {
 	running         bool
 	stopChan        chan struct{}
 	watcher         *fsnotify.Watcher
+	*liveness
+	stability
+	trigger
+	backfill
+	watchStats
 }
 
 // NewHybridMonitor creates a hybrid monitor with event-driven primary and polling backup
@@ -33,6 +38,9 @@ func NewHybridMonitor(watchFolder string, pollInterval time.Duration, maxFilesPe
 		maxFilesPerPoll: maxFilesPerPoll,
 		processedFiles:  make(map[string]bool),
 		stopChan:        make(chan struct{}),
+		liveness:        newLiveness(),
+		stability:       newStability(),
+		trigger:         newTrigger(),
 		watcher:         watcher,
 	}, nil
 }
@@ -53,6 +61,16 @@ func (m *HybridMonitor) Start(callback FileCallback) error {
 	ticker := time.NewTicker(m.pollInterval)
 	defer ticker.Stop()
 
+	// Heartbeat ticker lets watchdogs tell an idle loop from a stalled one
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	m.markReady()
+
+	// Existing files are processed first when backfill is enabled
+	if err := m.startBackfill(m.watchFolder); err != nil {
+		log.Printf("Backfill failed: %v", err)
+	}
+
 	// Process events and periodic polls
 	for {
 		select {
@@ -63,6 +81,7 @@ func (m *HybridMonitor) Start(callback FileCallback) error {
 
 			// Only care about Create and Write events
 			if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) {
+				m.record(WatchEventReceived)
 				m.handleFileEvent(event.Name, callback)
 			}
 
@@ -70,7 +89,11 @@ func (m *HybridMonitor) Start(callback FileCallback) error {
 			if !ok {
 				return nil
 			}
-			log.Printf("Watcher error: %v", err)
+			if m.watchError(err) {
+				if err := m.scanForNew(callback); err != nil {
+					log.Printf("Error during overflow rescan: %v", err)
+				}
+			}
 
 		case <-ticker.C:
 			// Backup polling to catch any missed events
@@ -78,6 +101,18 @@ func (m *HybridMonitor) Start(callback FileCallback) error {
 				log.Printf("Error during backup scan: %v", err)
 			}
 
+		case <-m.backfillReady():
+			m.backfillBatch(m.watchFolder, m.maxFilesPerPoll, m.processedFiles, m.isFileReady, callback)
+
+		case <-m.triggered():
+			log.Printf("Manual trigger: rescanning %s", m.watchFolder)
+			if err := m.scanForNew(callback); err != nil {
+				log.Printf("Error during triggered scan: %v", err)
+			}
+
+		case <-heartbeat.C:
+			m.beat()
+
 		case <-m.stopChan:
 			log.Println("Hybrid file monitor stopped")
 			m.watcher.Close()
@@ -104,8 +139,8 @@ func (m *HybridMonitor) handleFileEvent(filePath string, callback FileCallback)
 		return
 	}
 
-	// Skip already processed files
-	if m.processedFiles[filename] {
+	// Skip already processed files, and backlog files awaiting their turn
+	if m.processedFiles[filename] || m.inBackfill(filename) {
 		return
 	}
 
@@ -122,7 +157,7 @@ func (m *HybridMonitor) handleFileEvent(filePath string, callback FileCallback)
 	}
 
 	// Mark as processed
-	m.processedFiles[filename] = true
+	markHandled(m.processedFiles, filePath)
 }
 
 func (m *HybridMonitor) scanForNew(callback FileCallback) error {
@@ -145,7 +180,7 @@ func (m *HybridMonitor) scanForNew(callback FileCallback) error {
 		}
 
 		filename := entry.Name()
-		if m.processedFiles[filename] {
+		if m.processedFiles[filename] || m.inBackfill(filename) {
 			continue
 		}
 
@@ -157,6 +192,7 @@ func (m *HybridMonitor) scanForNew(callback FileCallback) error {
 		}
 
 		log.Printf("Detected new file (backup poll): %s", filename)
+		m.record(WatchEventMissed)
 
 		// Process file
 		if err := callback(filePath); err != nil {
@@ -164,26 +200,9 @@ func (m *HybridMonitor) scanForNew(callback FileCallback) error {
 		}
 
 		// Mark as processed
-		m.processedFiles[filename] = true
+		markHandled(m.processedFiles, filePath)
 		processedCount++
 	}
 
 	return nil
 }
-
-func (m *HybridMonitor) isFileReady(filePath string) bool {
-	info1, err := os.Stat(filePath)
-	if err != nil {
-		return false
-	}
-
-	time.Sleep(2 * time.Second)
-
-	info2, err := os.Stat(filePath)
-	if err != nil {
-		return false
-	}
-
-	// If size hasn't changed, file is probably ready
-	return info1.Size() == info2.Size()
-}
@@ -0,0 +1,55 @@
+package monitor
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// HeartbeatInterval is how often an idle monitor loop records that it is still running
+const HeartbeatInterval = time.Second
+
+// Liveness is implemented by monitors that report when they are watching and
+// whether their event loop is still turning
+type Liveness interface {
+	// Ready is closed once the monitor is watching its folder
+	Ready() <-chan struct{}
+	// LastBeat returns when the event loop last completed an iteration (zero before start)
+	LastBeat() time.Time
+}
+
+// liveness is embedded by the monitors to implement Liveness
+type liveness struct {
+	readyOnce sync.Once
+	ready     chan struct{}
+	lastBeat  atomic.Int64
+}
+
+func newLiveness() *liveness {
+	return &liveness{ready: make(chan struct{})}
+}
+
+// Ready is closed once the monitor is watching its folder
+func (l *liveness) Ready() <-chan struct{} {
+	return l.ready
+}
+
+// LastBeat returns when the event loop last completed an iteration
+func (l *liveness) LastBeat() time.Time {
+	n := l.lastBeat.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// markReady records that the monitor is watching and beats once
+func (l *liveness) markReady() {
+	l.beat()
+	l.readyOnce.Do(func() { close(l.ready) })
+}
+
+// beat records that the event loop is still running
+func (l *liveness) beat() {
+	l.lastBeat.Store(time.Now().UnixNano())
+}
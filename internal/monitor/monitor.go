@@ -3,6 +3,8 @@ package monitor
 import (
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"time"
 )
 
@@ -19,14 +21,32 @@ const (
 type FileMonitor interface {
 	Start(callback FileCallback) error
 	Stop()
+	// TriggerNow forces an immediate rescan of the watched folder
+	TriggerNow()
 }
 
-// NewMonitor creates the appropriate monitor based on watch mode
-func NewMonitor(mode WatchMode, watchFolder string, pollInterval time.Duration, hybridPollInterval time.Duration, maxFilesPerPoll int) (FileMonitor, error) {
+// NewMonitor creates the appropriate monitor based on watch mode. stabilityWindow is
+// how long a detected file's size must hold steady before it is handed to the callback;
+// backfillOrder, when set, processes the files already in the folder at startup first.
+func NewMonitor(mode WatchMode, watchFolder string, pollInterval time.Duration, hybridPollInterval time.Duration, stabilityWindow time.Duration, maxFilesPerPoll int, backfillOrder BackfillOrder) (FileMonitor, error) {
+	monitor, err := newMonitor(mode, watchFolder, pollInterval, hybridPollInterval, maxFilesPerPoll)
+	if err != nil {
+		return nil, err
+	}
+	if s, ok := monitor.(interface{ SetStabilityWindow(time.Duration) }); ok {
+		s.SetStabilityWindow(stabilityWindow)
+	}
+	if b, ok := monitor.(interface{ SetBackfill(BackfillOrder) }); ok {
+		b.SetBackfill(backfillOrder)
+	}
+	return monitor, nil
+}
+
+func newMonitor(mode WatchMode, watchFolder string, pollInterval time.Duration, hybridPollInterval time.Duration, maxFilesPerPoll int) (FileMonitor, error) {
 	switch mode {
 	case WatchModeEvent:
 		// Try event-driven, fallback to polling if it fails
-		monitor, err := NewEventMonitor(watchFolder, maxFilesPerPoll)
+		monitor, err := NewEventMonitor(watchFolder, pollInterval, maxFilesPerPoll)
 		if err != nil {
 			log.Printf("Warning: Failed to create event monitor (%v), falling back to polling", err)
 			return NewPollingMonitor(watchFolder, pollInterval, maxFilesPerPoll), nil
@@ -49,3 +69,15 @@ func NewMonitor(mode WatchMode, watchFolder string, pollInterval time.Duration,
 		return nil, fmt.Errorf("unsupported watch mode: %s (supported: event, poll, hybrid)", mode)
 	}
 }
+
+// markHandled remembers a handled file only while it is still in the watch folder.
+// Files that were archived or claimed elsewhere are forgotten, so a file of the same
+// name that arrives later (requeued, or released by another instance) is picked up.
+func markHandled(processed map[string]bool, filePath string) {
+	filename := filepath.Base(filePath)
+	if _, err := os.Stat(filePath); err != nil {
+		delete(processed, filename)
+		return
+	}
+	processed[filename] = true
+}
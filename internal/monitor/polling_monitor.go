@@ -17,6 +17,10 @@ type PollingMonitor struct {
 	processedFiles  map[string]bool
 	running         bool
 	stopChan        chan struct{}
+	*liveness
+	stability
+	trigger
+	backfill
 }
 
 // NewPollingMonitor creates a polling-based file monitor
@@ -27,6 +31,9 @@ func NewPollingMonitor(watchFolder string, pollInterval time.Duration, maxFilesP
 		maxFilesPerPoll: maxFilesPerPoll,
 		processedFiles:  make(map[string]bool),
 		stopChan:        make(chan struct{}),
+		liveness:        newLiveness(),
+		stability:       newStability(),
+		trigger:         newTrigger(),
 	}
 }
 
@@ -39,12 +46,31 @@ func (m *PollingMonitor) Start(callback FileCallback) error {
 	ticker := time.NewTicker(m.pollInterval)
 	defer ticker.Stop()
 
+	// Heartbeat ticker lets watchdogs tell an idle loop from a stalled one
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+	m.markReady()
+
+	// Existing files are processed first when backfill is enabled
+	if err := m.startBackfill(m.watchFolder); err != nil {
+		log.Printf("Backfill failed: %v", err)
+	}
+
 	for {
 		select {
 		case <-ticker.C:
 			if err := m.scan(callback); err != nil {
 				log.Printf("Error during scan: %v", err)
 			}
+		case <-m.backfillReady():
+			m.backfillBatch(m.watchFolder, m.maxFilesPerPoll, m.processedFiles, m.isFileReady, callback)
+		case <-m.triggered():
+			log.Printf("Manual trigger: rescanning %s", m.watchFolder)
+			if err := m.scan(callback); err != nil {
+				log.Printf("Error during scan: %v", err)
+			}
+		case <-heartbeat.C:
+			m.beat()
 		case <-m.stopChan:
 			log.Println("Polling-based file monitor stopped")
 			return nil
@@ -80,7 +106,7 @@ func (m *PollingMonitor) scan(callback FileCallback) error {
 		}
 
 		filename := entry.Name()
-		if m.processedFiles[filename] {
+		if m.processedFiles[filename] || m.inBackfill(filename) {
 			continue
 		}
 
@@ -100,26 +126,9 @@ func (m *PollingMonitor) scan(callback FileCallback) error {
 
 		// Mark as processed even if there was an error
 		// (archiver will have moved it anyway)
-		m.processedFiles[filename] = true
+		markHandled(m.processedFiles, filePath)
 		processedCount++
 	}
 
 	return nil
 }
-
-func (m *PollingMonitor) isFileReady(filePath string) bool {
-	info1, err := os.Stat(filePath)
-	if err != nil {
-		return false
-	}
-
-	time.Sleep(2 * time.Second)
-
-	info2, err := os.Stat(filePath)
-	if err != nil {
-		return false
-	}
-
-	// If size hasn't changed, file is probably ready
-	return info1.Size() == info2.Size()
-}
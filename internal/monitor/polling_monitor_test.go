@@ -160,6 +160,34 @@ func TestScan_SkipsProcessedFiles(t *testing.T) {
 	}
 }
 
+func TestScan_ReprocessesReturnedFiles(t *testing.T) {
+	tempDir := t.TempDir()
+
+	m := NewPollingMonitor(tempDir, 1*time.Second, 0)
+	m.running = true
+
+	calls := 0
+	callback := func(path string) error {
+		calls++
+		return os.Remove(path) // Simulate archiving (or another instance claiming the file)
+	}
+
+	file := filepath.Join(tempDir, "requeued.csv")
+	for i := 0; i < 2; i++ {
+		if err := os.WriteFile(file, []byte("test"), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		if err := m.scan(callback); err != nil {
+			t.Fatalf("Scan failed: %v", err)
+		}
+	}
+
+	// A file that left the folder is forgotten, so the same name arriving again is processed
+	if calls != 2 {
+		t.Errorf("Expected returned file to be processed again, got %d calls", calls)
+	}
+}
+
 func TestScan_ProcessesNewFiles(t *testing.T) {
 	tempDir := t.TempDir()
 
@@ -318,6 +346,137 @@ func TestStop(t *testing.T) {
 	}
 }
 
+// TestStabilityWindow validates that the size check waits for the configured window, or not at all
+func TestStabilityWindow(t *testing.T) {
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "ready.csv")
+	if err := os.WriteFile(filePath, []byte("a,b\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		window time.Duration
+	}{
+		{name: "disabled", window: 0},
+		{name: "sub-second", window: 50 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewPollingMonitor(tempDir, time.Second, 10)
+			m.SetStabilityWindow(tt.window)
+
+			start := time.Now()
+			if !m.isFileReady(filePath) {
+				t.Fatal("Expected unchanged file to be ready")
+			}
+			if elapsed := time.Since(start); elapsed < tt.window || elapsed > tt.window+time.Second {
+				t.Errorf("Expected readiness check to take about %v, took %v", tt.window, elapsed)
+			}
+		})
+	}
+
+	m := NewPollingMonitor(tempDir, time.Second, 10)
+	m.SetStabilityWindow(0)
+	if m.isFileReady(filepath.Join(tempDir, "missing.csv")) {
+		t.Error("Expected missing file not to be ready")
+	}
+}
+
+// TestTriggerNow validates a manual trigger scans the folder without waiting for the poll interval
+func TestTriggerNow(t *testing.T) {
+	tempDir := t.TempDir()
+	m := NewPollingMonitor(tempDir, time.Hour, 10)
+	m.SetStabilityWindow(0)
+
+	detected := make(chan string, 1)
+	go m.Start(func(path string) error {
+		detected <- filepath.Base(path)
+		return nil
+	})
+	defer m.Stop()
+	<-m.Ready()
+
+	if err := os.WriteFile(filepath.Join(tempDir, "orders.csv"), []byte("a,b\n"), 0644); err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+
+	// Repeated requests coalesce rather than block
+	m.TriggerNow()
+	m.TriggerNow()
+
+	select {
+	case name := <-detected:
+		if name != "orders.csv" {
+			t.Errorf("Expected orders.csv to be detected, got %s", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("TriggerNow did not scan the folder")
+	}
+}
+
+// TestBackfill validates files present at startup are processed in order, in batches, without waiting for a poll
+func TestBackfill(t *testing.T) {
+	// Modification times make the oldest-first order differ from name order
+	existing := []struct {
+		name string
+		age  time.Duration
+	}{
+		{name: "a.csv", age: 2 * time.Hour},
+		{name: "b.csv", age: 3 * time.Hour},
+		{name: "c.csv", age: time.Hour},
+	}
+
+	tests := []struct {
+		order    BackfillOrder
+		expected []string
+	}{
+		{order: BackfillOldest, expected: []string{"b.csv", "a.csv", "c.csv"}},
+		{order: BackfillNewest, expected: []string{"c.csv", "a.csv", "b.csv"}},
+		{order: BackfillName, expected: []string{"a.csv", "b.csv", "c.csv"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.order), func(t *testing.T) {
+			tempDir := t.TempDir()
+			for _, f := range existing {
+				path := filepath.Join(tempDir, f.name)
+				if err := os.WriteFile(path, []byte("a,b\n"), 0644); err != nil {
+					t.Fatalf("Failed to create file: %v", err)
+				}
+				modTime := time.Now().Add(-f.age)
+				if err := os.Chtimes(path, modTime, modTime); err != nil {
+					t.Fatalf("Failed to set modification time: %v", err)
+				}
+			}
+
+			// One file per batch; the hour-long poll interval never fires
+			m := NewPollingMonitor(tempDir, time.Hour, 1)
+			m.SetStabilityWindow(0)
+			m.SetBackfill(tt.order)
+
+			detected := make(chan string, len(existing))
+			go m.Start(func(path string) error {
+				detected <- filepath.Base(path)
+				return nil
+			})
+			defer m.Stop()
+
+			for i, want := range tt.expected {
+				select {
+				case got := <-detected:
+					if got != want {
+						t.Errorf("File %d: expected %s, got %s", i, want, got)
+					}
+				case <-time.After(2 * time.Second):
+					t.Fatalf("Backfill stopped after %d file(s)", i)
+				}
+			}
+		})
+	}
+}
+
 // Benchmark tests
 func BenchmarkScan_SmallFiles(b *testing.B) {
 	tempDir := b.TempDir()
@@ -365,3 +524,25 @@ func BenchmarkScan_WithLimit(b *testing.B) {
 		m.scan(callback)
 	}
 }
+
+// TestPollingMonitorLiveness validates the monitor reports readiness and heartbeats once started
+func TestPollingMonitorLiveness(t *testing.T) {
+	m := NewPollingMonitor(t.TempDir(), time.Hour, 0)
+	if !m.LastBeat().IsZero() {
+		t.Fatal("Expected no heartbeat before start")
+	}
+
+	go m.Start(func(string) error { return nil })
+	defer m.Stop()
+
+	select {
+	case <-m.Ready():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Monitor did not report ready")
+	}
+	first := m.LastBeat()
+	time.Sleep(HeartbeatInterval + 500*time.Millisecond)
+	if !m.LastBeat().After(first) {
+		t.Error("Expected heartbeat while idle")
+	}
+}
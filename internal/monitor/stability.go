@@ -0,0 +1,44 @@
+package monitor
+
+import (
+	"os"
+	"time"
+)
+
+// DefaultStabilityWindow is how long a file's size must hold steady before it is processed
+const DefaultStabilityWindow = 2 * time.Second
+
+// stability is embedded by the monitors to decide when a detected file has finished being written
+type stability struct {
+	window time.Duration
+}
+
+func newStability() stability {
+	return stability{window: DefaultStabilityWindow}
+}
+
+// SetStabilityWindow sets how long a file's size must hold steady before it is
+// processed; zero skips the check and hands files over as soon as they are seen
+func (s *stability) SetStabilityWindow(window time.Duration) {
+	s.window = window
+}
+
+func (s *stability) isFileReady(filePath string) bool {
+	info1, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+	if s.window <= 0 {
+		return true
+	}
+
+	time.Sleep(s.window)
+
+	info2, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+
+	// If size hasn't changed, file is probably ready
+	return info1.Size() == info2.Size()
+}
@@ -0,0 +1,25 @@
+package monitor
+
+// trigger is embedded by the monitors to implement TriggerNow. Requests made while
+// a rescan is already pending coalesce into that one rescan.
+type trigger struct {
+	requests chan struct{}
+}
+
+func newTrigger() trigger {
+	return trigger{requests: make(chan struct{}, 1)}
+}
+
+// TriggerNow asks the monitor to rescan its folder immediately instead of waiting
+// for the next poll. It never blocks.
+func (t trigger) TriggerNow() {
+	select {
+	case t.requests <- struct{}{}:
+	default:
+	}
+}
+
+// triggered receives a value for each pending rescan request
+func (t trigger) triggered() <-chan struct{} {
+	return t.requests
+}
@@ -0,0 +1,56 @@
+package monitor
+
+import (
+	"errors"
+	"log"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchEvent is something that happened to a monitor's file system watcher,
+// reported for watcher statistics
+type WatchEvent string
+
+const (
+	WatchEventReceived  WatchEvent = "received"  // A Create or Write event arrived
+	WatchEventCoalesced WatchEvent = "coalesced" // An event merged into a file's pending readiness check
+	WatchEventError     WatchEvent = "error"     // The watcher reported an error
+	WatchEventOverflow  WatchEvent = "overflow"  // The event queue overflowed and events were dropped
+	WatchEventMissed    WatchEvent = "missed"    // A scan found a file no event had delivered
+)
+
+// WatchReporter is implemented by monitors that watch file system events
+type WatchReporter interface {
+	// SetWatchReporter sets the function watcher events are reported to
+	SetWatchReporter(report func(WatchEvent))
+}
+
+// watchStats is embedded by the event-driven monitors to report watcher statistics
+type watchStats struct {
+	report func(WatchEvent)
+}
+
+// SetWatchReporter sets the function watcher events are reported to
+func (w *watchStats) SetWatchReporter(report func(WatchEvent)) {
+	w.report = report
+}
+
+// record reports a watcher event
+func (w *watchStats) record(event WatchEvent) {
+	if w.report != nil {
+		w.report(event)
+	}
+}
+
+// watchError records a watcher error and reports whether events were dropped, in
+// which case the caller rescans its folder to pick up the files they announced
+func (w *watchStats) watchError(err error) bool {
+	if errors.Is(err, fsnotify.ErrEventOverflow) {
+		log.Printf("WARNING: Watcher event queue overflowed, events were dropped; rescanning folder")
+		w.record(WatchEventOverflow)
+		return true
+	}
+	log.Printf("Watcher error: %v", err)
+	w.record(WatchEventError)
+	return false
+}
@@ -0,0 +1,105 @@
+package output
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// connectionPool shares AMQP connections between queue handlers publishing to the same
+// broker, so routes open a channel each instead of a connection each
+type connectionPool struct {
+	mu          sync.Mutex
+	connections map[connectionKey]*sharedConnection
+}
+
+// connectionKey identifies connections handlers can share: same broker, vhost,
+// credentials and heartbeat
+type connectionKey struct {
+	url       string
+	heartbeat time.Duration
+}
+
+// sharedConnections is the pool used by handlers created with Options.SharedConnection
+var sharedConnections = &connectionPool{connections: make(map[connectionKey]*sharedConnection)}
+
+// sharedConnection is an AMQP connection used by several queue handlers, each on its
+// own channel. When it is lost, the first handler to publish re-dials it for all.
+type sharedConnection struct {
+	key       connectionKey
+	brokerURI string // Redacted, for logs
+
+	mu    sync.Mutex
+	conn  *amqp.Connection
+	users int // Handlers holding the connection; it is closed when the last releases it
+}
+
+// acquire returns the pool's connection for url and heartbeat, registering a new user
+func (p *connectionPool) acquire(url, brokerURI string, heartbeat time.Duration) *sharedConnection {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := connectionKey{url: url, heartbeat: heartbeat}
+	shared := p.connections[key]
+	if shared == nil {
+		shared = &sharedConnection{key: key, brokerURI: brokerURI}
+		p.connections[key] = shared
+	}
+	shared.users++
+	return shared
+}
+
+// release unregisters a user, closing the connection when none are left
+func (p *connectionPool) release(shared *sharedConnection) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	shared.users--
+	if shared.users > 0 {
+		return nil
+	}
+	delete(p.connections, shared.key)
+	return shared.close()
+}
+
+// connect dials the broker unless the connection is already open
+func (c *sharedConnection) connect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != nil && !c.conn.IsClosed() {
+		return nil
+	}
+	conn, err := dialAMQP(c.key.url, amqp.Config{Heartbeat: c.key.heartbeat, Locale: "en_US"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+	}
+	c.conn = conn
+	log.Printf("Opened shared RabbitMQ connection to %s", c.brokerURI)
+	return nil
+}
+
+// channel opens a channel on the connection
+func (c *sharedConnection) channel() (*amqp.Channel, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil, amqp.ErrClosed
+	}
+	return c.conn.Channel()
+}
+
+// close closes the connection
+func (c *sharedConnection) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	if err == amqp.ErrClosed {
+		return nil // Already closed by the broker or a network failure
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package output
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConnectionPoolSharesByBroker(t *testing.T) {
+	pool := &connectionPool{connections: make(map[connectionKey]*sharedConnection)}
+
+	orders := pool.acquire("amqp://broker:5672/", "rabbitmq://broker:5672/", 10*time.Second)
+	trades := pool.acquire("amqp://broker:5672/", "rabbitmq://broker:5672/", 10*time.Second)
+	if orders != trades {
+		t.Error("Expected handlers for the same broker to share a connection")
+	}
+	if other := pool.acquire("amqp://broker:5672/other", "rabbitmq://broker:5672/other", 10*time.Second); other == orders {
+		t.Error("Expected a separate connection for another vhost")
+	}
+	if other := pool.acquire("amqp://broker:5672/", "rabbitmq://broker:5672/", time.Minute); other == orders {
+		t.Error("Expected a separate connection for another heartbeat")
+	}
+
+	if err := pool.release(orders); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if pool.connections[orders.key] != orders {
+		t.Error("Expected the connection to stay pooled while a handler still uses it")
+	}
+	if err := pool.release(trades); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if _, ok := pool.connections[orders.key]; ok {
+		t.Error("Expected the connection to leave the pool when the last handler releases it")
+	}
+}
+
+func TestSharedConnectionChannelBeforeConnect(t *testing.T) {
+	shared := &sharedConnection{}
+	if _, err := shared.channel(); err == nil {
+		t.Error("Expected an error opening a channel before the connection is dialed")
+	}
+}
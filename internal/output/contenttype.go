@@ -0,0 +1,213 @@
+package output
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Message content types. Messages other than JSON carry the records alone, as with
+// the bare payload format.
+const (
+	ContentTypeJSON   = "application/json"     // JSON document (default)
+	ContentTypeNDJSON = "application/x-ndjson" // One JSON record per line
+	ContentTypeAvro   = "application/avro"     // Avro object container file with the schema embedded
+)
+
+// HeaderSchema carries the schema or contract identifier of a message, so consumers
+// can dispatch on headers instead of inspecting the payload
+const HeaderSchema = "x-schema"
+
+// avroMagic starts every Avro object container file
+var avroMagic = []byte{'O', 'b', 'j', 1}
+
+// encodeRecords re-encodes a rendered JSON array of records in contentType
+func encodeRecords(contentType string, array []byte) ([]byte, error) {
+	switch contentType {
+	case ContentTypeNDJSON:
+		return encodeNDJSON(array)
+	case ContentTypeAvro:
+		return encodeAvro(array)
+	default:
+		return array, nil
+	}
+}
+
+// encodeNDJSON writes each record of a JSON array on its own line
+func encodeNDJSON(array []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(array))
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	for dec.More() {
+		var record json.RawMessage
+		if err := dec.Decode(&record); err != nil {
+			return nil, err
+		}
+		if err := json.Compact(&buf, record); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeAvro writes a JSON array of flat records as an Avro object container file
+// with a single block. Every field is an Avro string (values are strings, ADR-003);
+// the record schema follows the first record's fields, with names made valid for
+// Avro. Nested values (group-by) cannot be encoded.
+func encodeAvro(array []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(array))
+	if err := expectDelim(dec, '['); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	var block bytes.Buffer
+	count := 0
+	for dec.More() {
+		keys, values, err := decodeFlatRecord(dec)
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", count+1, err)
+		}
+		if columns == nil {
+			columns = keys
+		} else if strings.Join(keys, "\x00") != strings.Join(columns, "\x00") {
+			return nil, fmt.Errorf("record %d: fields differ from the first record's", count+1)
+		}
+		for _, value := range values {
+			writeAvroString(&block, value)
+		}
+		count++
+	}
+
+	schema, err := avroSchema(columns)
+	if err != nil {
+		return nil, err
+	}
+	sync := make([]byte, 16)
+	if _, err := rand.Read(sync); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(avroMagic)
+	writeAvroLong(&buf, 2) // File metadata map: one block of two entries
+	writeAvroString(&buf, "avro.schema")
+	writeAvroString(&buf, string(schema))
+	writeAvroString(&buf, "avro.codec")
+	writeAvroString(&buf, "null")
+	writeAvroLong(&buf, 0)
+	buf.Write(sync)
+	if count > 0 {
+		writeAvroLong(&buf, int64(count))
+		writeAvroLong(&buf, int64(block.Len()))
+		buf.Write(block.Bytes())
+		buf.Write(sync)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeFlatRecord reads one JSON object whose values are all strings, in field order
+func decodeFlatRecord(dec *json.Decoder) ([]string, []string, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, nil, err
+	}
+	var keys, values []string
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key, _ := token.(string)
+		if token, err = dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		value, ok := token.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("field %q is not a string (nested values cannot be encoded as Avro)", key)
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+	}
+	return keys, values, expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token and checks it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %s in rendered records, got %v", delim, token)
+	}
+	return nil
+}
+
+// avroSchema returns the record schema of columns, each an Avro string field
+func avroSchema(columns []string) ([]byte, error) {
+	type field struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	}
+	fields := make([]field, 0, len(columns))
+	seen := make(map[string]string, len(columns))
+	for _, column := range columns {
+		name := avroName(column)
+		if other, ok := seen[name]; ok {
+			return nil, fmt.Errorf("columns %q and %q both map to Avro field %q", other, column, name)
+		}
+		seen[name] = column
+		fields = append(fields, field{Name: name, Type: "string"})
+	}
+	return json.Marshal(struct {
+		Type      string  `json:"type"`
+		Name      string  `json:"name"`
+		Namespace string  `json:"namespace"`
+		Fields    []field `json:"fields"`
+	}{"record", "Record", "csv2json", fields})
+}
+
+// avroName makes a column name a valid Avro name: letters, digits and underscores,
+// not starting with a digit
+func avroName(column string) string {
+	var b strings.Builder
+	for i, r := range column {
+		switch {
+		case r == '_' || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// writeAvroLong writes n in Avro's zig-zag variable-length encoding
+func writeAvroLong(w io.ByteWriter, n int64) {
+	var buf [binary.MaxVarintLen64]byte
+	size := binary.PutVarint(buf[:], n) // PutVarint zig-zag encodes, as Avro does
+	for _, b := range buf[:size] {
+		w.WriteByte(b)
+	}
+}
+
+// writeAvroString writes s as an Avro string (or bytes): its length, then its bytes
+func writeAvroString(buf *bytes.Buffer, s string) {
+	writeAvroLong(buf, int64(len(s)))
+	buf.WriteString(s)
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestEncodeNDJSON validates records are written one per line, compacted
+func TestEncodeNDJSON(t *testing.T) {
+	got, err := encodeRecords(ContentTypeNDJSON, []byte(`[{"id": "1", "name": "a"}, {"id": "2", "name": "b"}]`))
+	if err != nil {
+		t.Fatalf("encodeRecords failed: %v", err)
+	}
+	want := "{\"id\":\"1\",\"name\":\"a\"}\n{\"id\":\"2\",\"name\":\"b\"}\n"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// TestEncodeAvro validates flat records are written as an Avro object container file
+// whose embedded schema and block decode back to the records
+func TestEncodeAvro(t *testing.T) {
+	got, err := encodeRecords(ContentTypeAvro, []byte(`[{"order id": "1", "city": "Zürich"}, {"order id": "2", "city": ""}]`))
+	if err != nil {
+		t.Fatalf("encodeRecords failed: %v", err)
+	}
+	if !bytes.HasPrefix(got, avroMagic) {
+		t.Fatalf("Expected the Avro magic, got %q", got[:4])
+	}
+	r := bytes.NewReader(got[len(avroMagic):])
+	readLong := func() int64 {
+		n, err := binary.ReadVarint(r)
+		if err != nil {
+			t.Fatalf("Failed to read long: %v", err)
+		}
+		return n
+	}
+	readString := func() string {
+		b := make([]byte, readLong())
+		r.Read(b)
+		return string(b)
+	}
+
+	metadata := map[string]string{}
+	for entries := readLong(); entries > 0; entries-- {
+		key := readString()
+		metadata[key] = readString()
+	}
+	if readLong() != 0 || metadata["avro.codec"] != "null" {
+		t.Fatalf("Unexpected metadata: %v", metadata)
+	}
+	var schema struct {
+		Fields []struct{ Name, Type string }
+	}
+	if err := json.Unmarshal([]byte(metadata["avro.schema"]), &schema); err != nil {
+		t.Fatalf("Invalid schema: %v", err)
+	}
+	if len(schema.Fields) != 2 || schema.Fields[0].Name != "order_id" || schema.Fields[1].Type != "string" {
+		t.Errorf("Unexpected schema fields: %+v", schema.Fields)
+	}
+
+	sync := make([]byte, 16)
+	r.Read(sync)
+	if count := readLong(); count != 2 {
+		t.Fatalf("Expected a block of 2 records, got %d", count)
+	}
+	readLong() // Block size
+	var values []string
+	for i := 0; i < 4; i++ {
+		values = append(values, readString())
+	}
+	if strings.Join(values, "|") != "1|Zürich|2|" {
+		t.Errorf("Unexpected values: %q", values)
+	}
+	trailer := make([]byte, 16)
+	r.Read(trailer)
+	if !bytes.Equal(trailer, sync) || r.Len() != 0 {
+		t.Error("Expected the block to end with the sync marker")
+	}
+
+	// Nested values cannot be encoded
+	if _, err := encodeRecords(ContentTypeAvro, []byte(`[{"id": "1", "lines": [{"sku": "A"}]}]`)); err == nil {
+		t.Error("Expected an error for nested records")
+	}
+}
+
+// TestContentTypeMessages validates content types other than JSON publish the records
+// alone, whatever the payload format
+func TestContentTypeMessages(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope}
+	handler.applyOptions(Options{ContentType: ContentTypeNDJSON, Schema: "orders.v2"})
+	if handler.schema != "orders.v2" {
+		t.Errorf("Expected schema orders.v2, got %q", handler.schema)
+	}
+
+	message, err := handler.buildMessageEnvelope([]map[string]string{{"id": "1"}, {"id": "2"}}, "orders.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+	if string(message) != "{\"id\":\"1\"}\n{\"id\":\"2\"}\n" {
+		t.Errorf("Expected NDJSON records, got %q", message)
+	}
+}
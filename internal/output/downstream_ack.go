@@ -0,0 +1,139 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// DownstreamReplyOK is the reply status acknowledging that a message was ingested
+const DownstreamReplyOK = "ok"
+
+// DownstreamReply is what a downstream consumer publishes to a message's ReplyTo
+// queue, with the message's correlation ID, once it has ingested the message (or
+// failed to)
+type DownstreamReply struct {
+	Status string `json:"status"`          // "ok" acknowledges ingestion; anything else rejects the message
+	Error  string `json:"error,omitempty"` // Why ingestion failed
+}
+
+// downstreamAcks correlates replies from downstream consumers with published messages
+type downstreamAcks struct {
+	channel *amqp.Channel // Consumes the reply queue, separately from publishing
+	queue   string
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[string]chan DownstreamReply // Reply channel by correlation ID
+}
+
+// enableDownstreamAck declares the reply queue and starts dispatching replies. An
+// empty queue name declares an exclusive, server-named queue that lives as long as
+// the connection.
+func (h *QueueHandler) enableDownstreamAck(queue string, timeout time.Duration) error {
+	ch, err := h.openChannel()
+	if err != nil {
+		return fmt.Errorf("failed to open reply channel: %w", err)
+	}
+	exclusive := queue == ""
+	q, err := ch.QueueDeclare(
+		queue,
+		!exclusive, // durable
+		exclusive,  // auto-delete
+		exclusive,  // exclusive
+		false,      // no-wait
+		nil,        // arguments
+	)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to declare reply queue: %w", err)
+	}
+	deliveries, err := ch.Consume(q.Name, "", true, exclusive, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to consume reply queue %s: %w", q.Name, err)
+	}
+
+	if timeout <= 0 {
+		timeout = 5 * time.Minute
+	}
+	h.acks = &downstreamAcks{channel: ch, queue: q.Name, timeout: timeout, pending: make(map[string]chan DownstreamReply)}
+	go h.acks.dispatch(deliveries)
+	return nil
+}
+
+// expect registers a message before it is published, so an early reply is not missed
+func (a *downstreamAcks) expect(correlationID string) chan DownstreamReply {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	reply := make(chan DownstreamReply, 1)
+	a.pending[correlationID] = reply
+	return reply
+}
+
+// cancel stops waiting for a message's reply
+func (a *downstreamAcks) cancel(correlationID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.pending, correlationID)
+}
+
+// dispatch hands each reply to the publish waiting for it until the reply queue closes
+func (a *downstreamAcks) dispatch(deliveries <-chan amqp.Delivery) {
+	for delivery := range deliveries {
+		var reply DownstreamReply
+		if err := json.Unmarshal(delivery.Body, &reply); err != nil {
+			reply = DownstreamReply{Error: fmt.Sprintf("invalid reply: %v", err)}
+		}
+
+		a.mu.Lock()
+		waiter, ok := a.pending[delivery.CorrelationId]
+		delete(a.pending, delivery.CorrelationId)
+		a.mu.Unlock()
+		if !ok {
+			log.Printf("Discarding downstream reply for unknown or timed-out message %q", delivery.CorrelationId)
+			continue
+		}
+		waiter <- reply
+	}
+
+	// Fail publishes still waiting rather than letting them time out
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, waiter := range a.pending {
+		close(waiter)
+		delete(a.pending, id)
+	}
+}
+
+// wait waits for the reply to a published message, returning the receipt status
+func (a *downstreamAcks) wait(correlationID string, replies chan DownstreamReply) (string, error) {
+	select {
+	case reply, ok := <-replies:
+		if !ok {
+			return ReceiptFailed, fmt.Errorf("reply queue closed while awaiting downstream acknowledgment")
+		}
+		if reply.Status != DownstreamReplyOK {
+			reason := reply.Error
+			if reason == "" {
+				reason = fmt.Sprintf("status %q", reply.Status)
+			}
+			return ReceiptRejected, fmt.Errorf("downstream rejected message: %s", reason)
+		}
+		return ReceiptAcked, nil
+	case <-time.After(a.timeout):
+		a.cancel(correlationID)
+		return ReceiptTimeout, fmt.Errorf("no downstream acknowledgment within %v", a.timeout)
+	}
+}
+
+// close stops consuming replies
+func (a *downstreamAcks) close() {
+	if a != nil {
+		a.channel.Close()
+	}
+}
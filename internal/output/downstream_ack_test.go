@@ -0,0 +1,62 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/streadway/amqp"
+)
+
+// TestDownstreamAckWait validates replies are matched to publishes by correlation ID
+func TestDownstreamAckWait(t *testing.T) {
+	testCases := []struct {
+		name           string
+		reply          *amqp.Delivery
+		expectedStatus string
+		expectError    bool
+	}{
+		{"acknowledged", &amqp.Delivery{CorrelationId: "msg-1", Body: []byte(`{"status":"ok"}`)}, ReceiptAcked, false},
+		{"rejected", &amqp.Delivery{CorrelationId: "msg-1", Body: []byte(`{"status":"error","error":"schema mismatch"}`)}, ReceiptRejected, true},
+		{"invalid reply", &amqp.Delivery{CorrelationId: "msg-1", Body: []byte(`not json`)}, ReceiptRejected, true},
+		{"other message only", &amqp.Delivery{CorrelationId: "msg-0", Body: []byte(`{"status":"ok"}`)}, ReceiptTimeout, true},
+		{"no reply", nil, ReceiptTimeout, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			acks := &downstreamAcks{timeout: 50 * time.Millisecond, pending: make(map[string]chan DownstreamReply)}
+			deliveries := make(chan amqp.Delivery, 1)
+			go acks.dispatch(deliveries)
+			defer close(deliveries)
+
+			replies := acks.expect("msg-1")
+			if tc.reply != nil {
+				deliveries <- *tc.reply
+			}
+			status, err := acks.wait("msg-1", replies)
+			if status != tc.expectedStatus {
+				t.Errorf("Expected status %q, got %q", tc.expectedStatus, status)
+			}
+			if (err != nil) != tc.expectError {
+				t.Errorf("Expected error=%v, got %v", tc.expectError, err)
+			}
+			if len(acks.pending) != 0 {
+				t.Errorf("Expected no pending replies, got %d", len(acks.pending))
+			}
+		})
+	}
+}
+
+// TestDownstreamAckReplyQueueClosed validates waiting publishes fail when the reply queue closes
+func TestDownstreamAckReplyQueueClosed(t *testing.T) {
+	acks := &downstreamAcks{timeout: time.Minute, pending: make(map[string]chan DownstreamReply)}
+	deliveries := make(chan amqp.Delivery)
+	replies := acks.expect("msg-1")
+	go acks.dispatch(deliveries)
+	close(deliveries)
+
+	status, err := acks.wait("msg-1", replies)
+	if status != ReceiptFailed || err == nil {
+		t.Errorf("Expected failed status with error, got %q, %v", status, err)
+	}
+}
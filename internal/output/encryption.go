@@ -0,0 +1,73 @@
+package output
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// EncryptionAlgorithm identifies the payload cipher in message headers
+const EncryptionAlgorithm = "AES-GCM"
+
+// Message headers describing an encrypted payload
+const (
+	HeaderEncryption      = "x-encryption"        // Payload cipher (EncryptionAlgorithm)
+	HeaderEncryptionKeyID = "x-encryption-key-id" // Key the payload was encrypted with, so keys can be rotated
+)
+
+// PayloadCipher encrypts message bodies with AES-GCM. An encrypted body is the random
+// nonce followed by the ciphertext and tag; the key ID is authenticated as additional
+// data, so a payload only decrypts under the key ID it was published with.
+type PayloadCipher struct {
+	keyID string
+	aead  cipher.AEAD
+}
+
+// NewPayloadCipher creates a cipher from a base64-encoded 16, 24 or 32-byte AES key
+func NewPayloadCipher(key, keyID string) (*PayloadCipher, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("encryption key ID must be set")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key is not valid base64: %w", err)
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be 16, 24 or 32 bytes, got %d", len(raw))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &PayloadCipher{keyID: keyID, aead: aead}, nil
+}
+
+// KeyID returns the identifier of the cipher's key
+func (c *PayloadCipher) KeyID() string {
+	return c.keyID
+}
+
+// Encrypt seals plaintext under a fresh random nonce
+func (c *PayloadCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, []byte(c.keyID)), nil
+}
+
+// Decrypt opens a payload produced by Encrypt
+func (c *PayloadCipher) Decrypt(payload []byte) ([]byte, error) {
+	if len(payload) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted payload too short")
+	}
+	nonce, ciphertext := payload[:c.aead.NonceSize()], payload[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, []byte(c.keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt payload with key %s: %w", c.keyID, err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+)
+
+// testEncryptionKey is a base64 AES-256 key for tests
+var testEncryptionKey = base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x42}, 32))
+
+// TestPayloadCipherRoundTrip validates payloads decrypt to the original and use fresh nonces
+func TestPayloadCipherRoundTrip(t *testing.T) {
+	c, err := NewPayloadCipher(testEncryptionKey, "2024-01")
+	if err != nil {
+		t.Fatalf("Failed to create cipher: %v", err)
+	}
+	plaintext := []byte(`{"data":[{"ssn":"123-45-6789"}]}`)
+
+	first, err := c.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("Failed to encrypt: %v", err)
+	}
+	second, _ := c.Encrypt(plaintext)
+	if bytes.Contains(first, []byte("123-45-6789")) {
+		t.Error("Expected ciphertext not to contain the plaintext")
+	}
+	if bytes.Equal(first, second) {
+		t.Error("Expected different ciphertexts for repeated payloads")
+	}
+
+	decrypted, err := c.Decrypt(first)
+	if err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Errorf("Expected %s, got %s", plaintext, decrypted)
+	}
+
+	// The key ID is authenticated: a payload relabelled with another ID does not open
+	other, _ := NewPayloadCipher(testEncryptionKey, "2024-02")
+	if _, err := other.Decrypt(first); err == nil {
+		t.Error("Expected decryption under a different key ID to fail")
+	}
+	tampered := append([]byte{}, first...)
+	tampered[len(tampered)-1] ^= 0xFF
+	if _, err := c.Decrypt(tampered); err == nil {
+		t.Error("Expected decryption of a tampered payload to fail")
+	}
+}
+
+// TestNewPayloadCipherInvalid validates key and key ID checks
+func TestNewPayloadCipherInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		key   string
+		keyID string
+	}{
+		{"missing key ID", testEncryptionKey, ""},
+		{"not base64", "not-a-key!", "k1"},
+		{"wrong length", base64.StdEncoding.EncodeToString([]byte("short")), "k1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewPayloadCipher(tc.key, tc.keyID); err == nil {
+				t.Error("Expected error, got success")
+			}
+		})
+	}
+}
@@ -0,0 +1,10 @@
+//go:build windows
+
+package output
+
+import "fmt"
+
+// makeFIFO is unsupported: Windows named pipes live in \\.\pipe\ and are created by their server
+func makeFIFO(path string) error {
+	return fmt.Errorf("named pipes cannot be created on Windows; create %s before starting", path)
+}
@@ -1,17 +1,27 @@
 package output
 
 import (
+	"bufio"
 	"csv2json/internal/converter"
 	"csv2json/internal/parser"
-	"encoding/json"
 	"fmt"
+	"io"
+	"log"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 type FileHandler struct {
 	outputFolder string
 	converter    *converter.Converter
+	routeName    string         // Route name recorded in delivery receipts
+	businessDate string         // Business date of the file sent next, substituted into the output folder
+	wrapper      *FileWrapper   // Optional top-level object output files are wrapped in
+	asciiSafe    bool           // Escape non-ASCII characters of the wrapper fields
+	location     *time.Location // Time zone of the wrapper's generatedAt (nil = UTC)
+	receipts     *ReceiptLog    // Optional delivery receipt log
+	meter        *outputMeter   // Delivery metrics (set by MeteredHandler)
 }
 
 func NewFileHandler(outputFolder string) *FileHandler {
@@ -21,48 +31,154 @@ func NewFileHandler(outputFolder string) *FileHandler {
 	}
 }
 
+// applyOptions configures optional output behaviour
+func (h *FileHandler) applyOptions(opts Options) {
+	h.converter = converter.NewWithOptions(opts.converterOptions())
+	h.wrapper = opts.FileWrapper
+	h.asciiSafe = opts.ASCIISafe
+	h.location = opts.Location
+}
+
+// now returns the current time in the configured time zone (UTC by default)
+func (h *FileHandler) now() time.Time {
+	if h.location == nil {
+		return time.Now().UTC()
+	}
+	return time.Now().In(h.location)
+}
+
+// SetEnvelopeContext sets the route name recorded in delivery receipts. Output files
+// carry no envelope, so the contract and payload format are not used.
+func (h *FileHandler) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
+	h.routeName = routeName
+}
+
+// SetSourceFile is a no-op: receipts identify output files by their source filename
+func (h *FileHandler) SetSourceFile(sourceFilePath string) {}
+
+// SetBusinessDate sets the business date substituted into the output folder of the
+// file sent next
+func (h *FileHandler) SetBusinessDate(date string) {
+	h.businessDate = date
+}
+
 func (h *FileHandler) Send(data []map[string]string, identifier string) error {
-	// Generate output filename
-	ext := filepath.Ext(identifier)
-	base := identifier[:len(identifier)-len(ext)]
-	outputFilename := base + ".json"
-	outputPath := filepath.Join(h.outputFolder, outputFilename)
+	folder, err := h.folder()
+	if err != nil {
+		return err
+	}
+	return h.write(outputPath(folder, identifier, ""), identifier, len(data), func(w io.Writer) (int64, error) {
+		return h.converter.ToJSONWriter(data, w)
+	})
+}
 
-	// Marshal to JSON
-	jsonBytes, err := json.MarshalIndent(data, "", "  ")
+func (h *FileHandler) SendOrdered(result *parser.ParseResult, identifier string) error {
+	folder, err := h.folder()
 	if err != nil {
-		return fmt.Errorf("failed to marshal JSON: %w", err)
+		return err
 	}
+	return h.writeOrdered(result, outputPath(folder, identifier, ""), identifier)
+}
 
-	// Write to file
-	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+// SendPartition writes one partition of a file. If the output folder contains the
+// partition placeholder each partition gets its own folder; otherwise the partition
+// value is appended to the output filename.
+func (h *FileHandler) SendPartition(result *parser.ParseResult, identifier, partition string) error {
+	dated, datedTemplated := resolveBusinessDate(h.outputFolder, h.businessDate)
+	folder, templated := resolvePartition(dated, partition)
+	if templated || datedTemplated {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return fmt.Errorf("failed to create partition folder: %w", err)
+		}
 	}
+	if templated {
+		return h.writeOrdered(result, outputPath(folder, identifier, ""), identifier)
+	}
+	return h.writeOrdered(result, outputPath(folder, identifier, partitionSegment(partition)), identifier)
+}
 
-	return nil
+// folder returns the output folder of the file sent next, creating it when it is
+// named after the business date
+func (h *FileHandler) folder() (string, error) {
+	folder, templated := resolveBusinessDate(h.outputFolder, h.businessDate)
+	if templated {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return "", fmt.Errorf("failed to create business date folder: %w", err)
+		}
+	}
+	return folder, nil
 }
 
-func (h *FileHandler) SendOrdered(result *parser.ParseResult, identifier string) error {
-	// Generate output filename
-	ext := filepath.Ext(identifier)
-	base := identifier[:len(identifier)-len(ext)]
-	outputFilename := base + ".json"
-	outputPath := filepath.Join(h.outputFolder, outputFilename)
+// writeOrdered streams result as ordered JSON (preserves CSV column order per ADR-003) to outputPath
+func (h *FileHandler) writeOrdered(result *parser.ParseResult, outputPath, identifier string) error {
+	return h.write(outputPath, identifier, len(result.Rows), func(w io.Writer) (int64, error) {
+		return h.converter.ToJSONOrderedWriter(result, w)
+	})
+}
 
-	// Convert to ordered JSON (preserves CSV column order per ADR-003)
-	jsonBytes, err := h.converter.ToJSONOrdered(result)
-	if err != nil {
-		return fmt.Errorf("failed to marshal ordered JSON: %w", err)
+// write streams JSON rendered by render to outputPath, inside the wrapper object if one
+// is configured, and records a delivery receipt. A partially written file is removed, so
+// a failed write leaves no truncated output.
+func (h *FileHandler) write(outputPath, identifier string, rows int, render func(io.Writer) (int64, error)) error {
+	if h.wrapper != nil {
+		records := render
+		render = func(w io.Writer) (int64, error) {
+			return h.wrapper.write(w, identifier, h.now(), h.asciiSafe, records)
+		}
 	}
+	file, writeErr := os.Create(outputPath)
+	var written int64
+	if writeErr == nil {
+		buffered := bufio.NewWriter(file)
+		written, writeErr = render(buffered)
+		if writeErr == nil {
+			writeErr = buffered.Flush()
+		}
+		if closeErr := file.Close(); writeErr == nil {
+			writeErr = closeErr
+		}
+		if writeErr != nil {
+			os.Remove(outputPath)
+		}
+	}
+	return h.recordWrite(outputPath, identifier, rows, int(written), writeErr)
+}
 
-	// Write to file
-	if err := os.WriteFile(outputPath, jsonBytes, 0644); err != nil {
-		return fmt.Errorf("failed to write output file: %w", err)
+// recordWrite records the delivery receipt of an output file write
+func (h *FileHandler) recordWrite(outputPath, identifier string, rows, bytes int, writeErr error) error {
+	receipt := Receipt{
+		Route:       h.routeName,
+		SourceFile:  identifier,
+		Output:      "file",
+		Destination: outputPath,
+		Rows:        rows,
+		Bytes:       bytes,
+		Status:      ReceiptWritten,
+	}
+	if writeErr != nil {
+		receipt.Status, receipt.Error = ReceiptFailed, writeErr.Error()
+	}
+	h.meter.delivered(receipt)
+	if err := h.receipts.Record(receipt); err != nil {
+		log.Printf("Failed to record delivery receipt: %v", err)
 	}
 
+	if writeErr != nil {
+		return fmt.Errorf("failed to write output file: %w", writeErr)
+	}
 	return nil
 }
 
+// outputPath builds the JSON output path for an input filename, with an optional suffix
+func outputPath(folder, identifier, suffix string) string {
+	ext := filepath.Ext(identifier)
+	base := identifier[:len(identifier)-len(ext)]
+	if suffix != "" {
+		base += "_" + suffix
+	}
+	return filepath.Join(folder, base+".json")
+}
+
 func (h *FileHandler) Close() error {
-	return nil
+	return h.receipts.Close()
 }
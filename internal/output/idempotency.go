@@ -0,0 +1,43 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// HeaderIdempotencyKey carries the message's idempotency key, so consumers can drop
+// re-deliveries without parsing the body
+const HeaderIdempotencyKey = "x-idempotency-key"
+
+// hashSourceFile returns the hex SHA-256 of a source file's content
+func hashSourceFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// IdempotencyKey derives a deterministic key from the route, the source file's content
+// hash and the partition, so the same file delivered again through the same route (a
+// retry or a replay) produces messages with the same keys. Returns "" when the content
+// hash is unknown (e.g. time-window batches, which have no single source file).
+func IdempotencyKey(route, contentHash, partition string) string {
+	if contentHash == "" {
+		return ""
+	}
+	digest := sha256.New()
+	for _, part := range []string{route, contentHash, partition} {
+		digest.Write([]byte(part))
+		digest.Write([]byte{0})
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
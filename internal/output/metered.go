@@ -0,0 +1,169 @@
+package output
+
+import (
+	"csv2json/internal/failure"
+	"csv2json/internal/metrics"
+	"csv2json/internal/parser"
+	"time"
+)
+
+// Output metrics, labelled by route and destination ("file", the queue type, "stdout" or "pipe")
+const (
+	metricOutputSends      = "csv2json_output_sends_total"
+	metricOutputErrors     = "csv2json_output_errors_total"
+	metricOutputSeconds    = "csv2json_output_send_seconds_total"
+	metricOutputDeliveries = "csv2json_output_deliveries_total"
+	metricOutputBytes      = "csv2json_output_bytes_total"
+	metricOutputRetries    = "csv2json_output_retries_total"
+)
+
+func init() {
+	metrics.Register(metricOutputSends, metrics.Counter, "Sends to an output destination (files, partitions and spilled files)")
+	metrics.Register(metricOutputErrors, metrics.Counter, "Sends to an output destination that failed")
+	metrics.Register(metricOutputSeconds, metrics.Counter, "Time spent sending to an output destination; divide by sends for the mean latency")
+	metrics.Register(metricOutputDeliveries, metrics.Counter, "Messages published or files written, whether or not they succeeded")
+	metrics.Register(metricOutputBytes, metrics.Counter, "Payload bytes of the messages published or files written")
+	metrics.Register(metricOutputRetries, metrics.Counter, "Broker reconnect attempts made before a publish")
+}
+
+// outputMeter records the metrics of one output destination. A nil meter records nothing.
+type outputMeter struct {
+	route       string
+	destination string
+}
+
+// meteredHandler is implemented by handlers that report their deliveries and retries
+type meteredHandler interface {
+	setMeter(meter *outputMeter)
+}
+
+func (m *outputMeter) labels() metrics.Labels {
+	route := m.route
+	if route == "" {
+		route = "default"
+	}
+	return metrics.Labels{"route": route, "destination": m.destination}
+}
+
+// sent records one send and how long it took
+func (m *outputMeter) sent(start time.Time, err error) {
+	if m == nil {
+		return
+	}
+	labels := m.labels()
+	metrics.Add(metricOutputSends, labels, 1)
+	metrics.Add(metricOutputSeconds, labels, time.Since(start).Seconds())
+	if err != nil {
+		metrics.Add(metricOutputErrors, labels, 1)
+	}
+}
+
+// delivered records the payload size of one message or file, from its delivery receipt
+func (m *outputMeter) delivered(receipt Receipt) {
+	if m == nil {
+		return
+	}
+	labels := m.labels()
+	metrics.Add(metricOutputDeliveries, labels, 1)
+	metrics.Add(metricOutputBytes, labels, float64(receipt.Bytes))
+}
+
+// retried records one reconnect attempt
+func (m *outputMeter) retried() {
+	if m == nil {
+		return
+	}
+	metrics.Add(metricOutputRetries, m.labels(), 1)
+}
+
+func (h *FileHandler) setMeter(meter *outputMeter)   { h.meter = meter }
+func (h *QueueHandler) setMeter(meter *outputMeter)  { h.meter = meter }
+func (h *StreamHandler) setMeter(meter *outputMeter) { h.meter = meter }
+
+// MeteredHandler decorates an output handler with per-destination metrics: send
+// latency, payload sizes, errors and broker reconnect retries. Send errors are
+// categorized as failure.ErrPublish. Optional handler interfaces are passed through
+// to the wrapped handler.
+type MeteredHandler struct {
+	handler Handler
+	meter   *outputMeter
+}
+
+// NewMeteredHandler wraps handler, recording its metrics under destination
+func NewMeteredHandler(handler Handler, destination string) *MeteredHandler {
+	meter := &outputMeter{destination: destination}
+	if mh, ok := handler.(meteredHandler); ok {
+		mh.setMeter(meter)
+	}
+	return &MeteredHandler{handler: handler, meter: meter}
+}
+
+func (h *MeteredHandler) Send(data []map[string]string, identifier string) error {
+	start := time.Now()
+	err := h.handler.Send(data, identifier)
+	h.meter.sent(start, err)
+	return failure.Publish(err)
+}
+
+func (h *MeteredHandler) SendOrdered(result *parser.ParseResult, identifier string) error {
+	start := time.Now()
+	err := h.handler.SendOrdered(result, identifier)
+	h.meter.sent(start, err)
+	return failure.Publish(err)
+}
+
+// SendPartition sends one partition if the wrapped handler supports partitioned output
+func (h *MeteredHandler) SendPartition(result *parser.ParseResult, identifier, partition string) error {
+	start := time.Now()
+	err := sendPartition(h.handler, result, identifier, partition)
+	h.meter.sent(start, err)
+	return failure.Publish(err)
+}
+
+// SendSpill sends a spilled file if the wrapped handler supports spilled output
+func (h *MeteredHandler) SendSpill(spill *Spill, identifier string) error {
+	start := time.Now()
+	err := sendSpill(h.handler, spill, identifier)
+	h.meter.sent(start, err)
+	return failure.Publish(err)
+}
+
+func (h *MeteredHandler) Close() error {
+	return h.handler.Close()
+}
+
+// SetEnvelopeContext labels the metrics with the route and passes the context on
+func (h *MeteredHandler) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
+	h.meter.route = routeName
+	if rc, ok := h.handler.(RouteContextSetter); ok {
+		rc.SetEnvelopeContext(routeName, ingestionContract, payloadFormat)
+	}
+}
+
+// SetSourceFile passes the source file path on to the wrapped handler
+func (h *MeteredHandler) SetSourceFile(sourceFilePath string) {
+	if rc, ok := h.handler.(RouteContextSetter); ok {
+		rc.SetSourceFile(sourceFilePath)
+	}
+}
+
+// SetContractVersion passes the registry schema version on to the wrapped handler
+func (h *MeteredHandler) SetContractVersion(version string) {
+	if cv, ok := h.handler.(ContractVersionSetter); ok {
+		cv.SetContractVersion(version)
+	}
+}
+
+// SetBusinessDate passes the business date on to the wrapped handler
+func (h *MeteredHandler) SetBusinessDate(date string) {
+	if bd, ok := h.handler.(BusinessDateSetter); ok {
+		bd.SetBusinessDate(date)
+	}
+}
+
+// SetCorrection passes the correction flag on to the wrapped handler
+func (h *MeteredHandler) SetCorrection(correction bool) {
+	if cs, ok := h.handler.(CorrectionSetter); ok {
+		cs.SetCorrection(correction)
+	}
+}
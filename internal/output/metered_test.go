@@ -0,0 +1,63 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/metrics"
+	"csv2json/internal/parser"
+)
+
+// TestMeteredHandler validates sends, payload bytes and errors are recorded per route
+// and destination, and optional handler interfaces are passed through
+func TestMeteredHandler(t *testing.T) {
+	folder := t.TempDir()
+	handler, err := CreateHandlerWithOptions("file", folder, "", "", 0, "", "", "", false, Options{})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	rc, ok := handler.(RouteContextSetter)
+	if !ok {
+		t.Fatal("Expected the metered handler to set the route context")
+	}
+	rc.SetEnvelopeContext("metered-test", "", "")
+	labels := metrics.Labels{"route": "metered-test", "destination": "file"}
+
+	result := &parser.ParseResult{Headers: []string{"id"}, Rows: []parser.OrderedMap{{Keys: []string{"id"}, Values: map[string]string{"id": "1"}}}}
+	if err := handler.SendOrdered(result, "orders.csv"); err != nil {
+		t.Fatalf("SendOrdered failed: %v", err)
+	}
+	if err := handler.(PartitionSender).SendPartition(result, "orders.csv", "EU"); err != nil {
+		t.Fatalf("SendPartition failed: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(folder, "orders.json"))
+	if err != nil {
+		t.Fatalf("Expected an output file: %v", err)
+	}
+
+	if got := metrics.Default.Value(metricOutputSends, labels); got != 2 {
+		t.Errorf("Expected 2 sends, got %v", got)
+	}
+	if got := metrics.Default.Value(metricOutputDeliveries, labels); got != 2 {
+		t.Errorf("Expected 2 deliveries, got %v", got)
+	}
+	if got := metrics.Default.Value(metricOutputBytes, labels); got <= float64(info.Size()) {
+		t.Errorf("Expected more than %d payload bytes, got %v", info.Size(), got)
+	}
+	if got := metrics.Default.Value(metricOutputErrors, labels); got != 0 {
+		t.Errorf("Expected no errors, got %v", got)
+	}
+
+	// A folder that cannot be created fails the write
+	blocker := filepath.Join(folder, "blocker")
+	os.WriteFile(blocker, nil, 0644)
+	failing := NewMeteredHandler(NewFileHandler(filepath.Join(blocker, "out")), "file")
+	failing.SetEnvelopeContext("metered-test", "", "")
+	if err := failing.Send([]map[string]string{{"id": "1"}}, "orders.csv"); err == nil {
+		t.Fatal("Expected the write to fail")
+	}
+	if got := metrics.Default.Value(metricOutputErrors, labels); got != 1 {
+		t.Errorf("Expected 1 error, got %v", got)
+	}
+}
@@ -1,9 +1,12 @@
 package output
 
 import (
+	"csv2json/internal/converter"
 	"csv2json/internal/parser"
 	"encoding/json"
 	"fmt"
+	"log"
+	"time"
 )
 
 type Handler interface {
@@ -12,29 +15,184 @@ type Handler interface {
 	Close() error
 }
 
+// RouteContextSetter is implemented by handlers that record which route and source
+// file produced their output, in ADR-006 message envelopes and delivery receipts
+type RouteContextSetter interface {
+	// SetEnvelopeContext sets the route-level context, once when the route starts
+	SetEnvelopeContext(routeName, ingestionContract, payloadFormat string)
+	// SetSourceFile sets the source file path of the output sent next ("" for batches)
+	SetSourceFile(sourceFilePath string)
+}
+
+// ContractVersionSetter is implemented by handlers that record the registry schema
+// version output was validated against
+type ContractVersionSetter interface {
+	SetContractVersion(version string)
+}
+
+// BusinessDateSetter is implemented by handlers that carry the business date of the
+// file sent next in envelopes, output folders or message templates ("" = none)
+type BusinessDateSetter interface {
+	SetBusinessDate(date string)
+}
+
+// CorrectionSetter is implemented by handlers that publish the file sent next to the
+// corrections queue, bypassing shards and the exchange, when it restates a completed
+// business date
+type CorrectionSetter interface {
+	SetCorrection(correction bool)
+}
+
+// Message is the legacy message format: the records under an identifier, without
+// provenance metadata. Data holds the records as the converter rendered them.
 type Message struct {
-	Identifier string              `json:"identifier"`
-	Data       []map[string]string `json:"data"`
+	Identifier string          `json:"identifier"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Options holds optional output behaviour shared by all handler types
+type Options struct {
+	ASCIISafe       bool           // Escape all non-ASCII characters in output JSON as \uXXXX
+	Shape           string         // converter.ShapeArray or converter.ShapeObject ("" = array)
+	Tenant          string         // Tenant recorded in message envelope metadata
+	Location        *time.Location // Time zone of envelope and file wrapper timestamps (nil = UTC)
+	FileWrapper     *FileWrapper   // Top-level object output files are wrapped in (nil = bare JSON)
+	PipePath        string         // Named pipe written by pipe output
+	ContentType     string         // Queue message content type (default application/json)
+	Schema          string         // Schema or contract identifier sent in the x-schema header ("" = none)
+	KafkaMessageKey string         // Message key template for Kafka (see MessageTemplate)
+	KafkaPartition  string         // Explicit partition template for Kafka; must resolve to an integer
+
+	InferTypes  bool              // Render numbers, booleans and nulls as native JSON values instead of strings
+	ColumnTypes map[string]string // Type of these columns' values (converter.TypeString etc.), overriding inference
+
+	LegacyIdentifier string // Identifier template of legacy format messages ("" = the source filename)
+
+	Credentials CredentialsFunc // Reads the current username and password before each dial, so rotated secrets reach reconnects (nil = those the handler was created with)
+
+	VHost                string        // RabbitMQ virtual host ("" = the broker's default vhost)
+	LazyConnect          bool          // Connect on the first publish instead of when the handler is created
+	SharedConnection     bool          // Open a channel on a connection shared with other handlers for the same broker
+	Heartbeat            time.Duration // AMQP heartbeat interval keeping idle connections alive (0 = the broker's)
+	ConnectRetries       int           // Reconnect attempts before a publish fails
+	ConnectRetryDelay    time.Duration // Delay before the first reconnect attempt, doubled after each
+	RabbitMQExchange     string        // Publish to this exchange instead of the default exchange
+	RabbitMQExchangeType string        // Exchange type: topic (default), direct, fanout, headers
+	RabbitMQRoutingKey   string        // Routing key template, e.g. "ingest.{route}.{filenamePrefix}"
+	RabbitMQBindingKey   string        // Binding key for the output queue (default "#" for topic, else the queue name)
+
+	ReceiptLog        string        // Path of the NDJSON delivery receipt log ("" = disabled)
+	PublisherConfirms bool          // Wait for broker confirmation of every published message
+	ConfirmTimeout    time.Duration // Publisher confirm timeout (default 30s)
+
+	DownstreamAck        bool          // Wait for a downstream reply correlated to every published message
+	DownstreamAckQueue   string        // Reply queue ("" = exclusive server-named queue)
+	DownstreamAckTimeout time.Duration // Downstream reply timeout (default 5m)
+
+	CorrectionsQueue string // Queue messages of restated business dates are published to (see CorrectionSetter)
+
+	QueueShards []string // Distribute messages across these queues instead of the queue name
+	ShardColumn string   // Column hashed to choose a shard ("" = round-robin)
+
+	EncryptionKey   string // Base64 AES key encrypting queue message bodies ("" = plaintext)
+	EncryptionKeyID string // Identifies the key to consumers (sent in the x-encryption-key-id header)
+
+	SigningAlgorithm string // hmac-sha256 or ed25519
+	SigningKey       string // Base64 signing key ("" = unsigned)
+	SigningKeyID     string // Identifies the key to consumers (sent in the x-signature-key-id header)
+}
+
+// CredentialsFunc returns the current broker username and password
+type CredentialsFunc func() (username, password string, err error)
+
+// converterOptions returns the JSON rendering options of opts
+func (opts Options) converterOptions() converter.Options {
+	return converter.Options{ASCIISafe: opts.ASCIISafe, Shape: opts.Shape, InferTypes: opts.InferTypes, ColumnTypes: opts.ColumnTypes}
 }
 
 func CreateHandler(outputType, outputFolder, queueType, queueHost string, queuePort int, queueName, queueUsername, queuePassword string, logMessages bool) (Handler, error) {
+	return CreateHandlerWithOptions(outputType, outputFolder, queueType, queueHost, queuePort, queueName, queueUsername, queuePassword, logMessages, Options{})
+}
+
+// CreateHandlerWithOptions creates an output handler with optional output behaviour applied
+func CreateHandlerWithOptions(outputType, outputFolder, queueType, queueHost string, queuePort int, queueName, queueUsername, queuePassword string, logMessages bool, opts Options) (Handler, error) {
+	var receipts *ReceiptLog
+	if opts.ReceiptLog != "" {
+		var err error
+		if receipts, err = NewReceiptLog(opts.ReceiptLog); err != nil {
+			return nil, err
+		}
+	}
+
 	switch outputType {
 	case "file":
-		return NewFileHandler(outputFolder), nil
+		fileHandler := NewFileHandler(outputFolder)
+		fileHandler.applyOptions(opts)
+		fileHandler.receipts = receipts
+		return NewMeteredHandler(fileHandler, "file"), nil
 	case "queue":
-		return NewQueueHandler(queueType, queueHost, queuePort, queueName, queueUsername, queuePassword, logMessages)
+		queueHandler, err := createQueueHandler(queueType, queueHost, queuePort, queueName, queueUsername, queuePassword, logMessages, opts)
+		if err != nil {
+			receipts.Close()
+			return nil, err
+		}
+		queueHandler.receipts = receipts
+		return NewMeteredHandler(queueHandler, queueType), nil
+	case "stdout":
+		streamHandler := NewStdoutHandler()
+		streamHandler.applyOptions(opts)
+		streamHandler.receipts = receipts
+		return NewMeteredHandler(streamHandler, streamHandler.outputType()), nil
+	case "pipe":
+		streamHandler, err := NewPipeHandler(opts.PipePath)
+		if err != nil {
+			receipts.Close()
+			return nil, err
+		}
+		streamHandler.applyOptions(opts)
+		streamHandler.receipts = receipts
+		return NewMeteredHandler(streamHandler, streamHandler.outputType()), nil
 	case "both":
 		fileHandler := NewFileHandler(outputFolder)
-		queueHandler, err := NewQueueHandler(queueType, queueHost, queuePort, queueName, queueUsername, queuePassword, logMessages)
+		fileHandler.applyOptions(opts)
+		fileHandler.receipts = receipts
+		queueHandler, err := createQueueHandler(queueType, queueHost, queuePort, queueName, queueUsername, queuePassword, logMessages, opts)
 		if err != nil {
+			receipts.Close()
 			return nil, fmt.Errorf("failed to create queue handler: %w", err)
 		}
-		return NewBothHandler(fileHandler, queueHandler), nil
+		queueHandler.receipts = receipts
+		return NewBothHandler(NewMeteredHandler(fileHandler, "file"), NewMeteredHandler(queueHandler, queueType)), nil
 	default:
-		return nil, fmt.Errorf("invalid output type: %s (valid: file, queue, both)", outputType)
+		receipts.Close()
+		return nil, fmt.Errorf("invalid output type: %s (valid: file, queue, both, stdout, pipe)", outputType)
 	}
 }
 
+// createQueueHandler creates a queue handler with opts applied, connecting now or, with
+// LazyConnect, on the first publish so the service can start before the broker
+func createQueueHandler(queueType, queueHost string, queuePort int, queueName, queueUsername, queuePassword string, logMessages bool, opts Options) (*QueueHandler, error) {
+	handler, err := buildQueueHandler(queueType, queueHost, queuePort, opts.VHost, queueName, queueUsername, queuePassword, logMessages)
+	if err != nil {
+		return nil, err
+	}
+	if err := handler.applyOptions(opts); err != nil {
+		return nil, err
+	}
+	if opts.SharedConnection {
+		handler.shared = sharedConnections.acquire(handler.connStr, handler.brokerURI, handler.heartbeat)
+	}
+	if opts.LazyConnect {
+		log.Printf("Deferring connection to %s until the first publish", handler.brokerURI)
+		return handler, nil
+	}
+	if err := handler.connect(); err != nil {
+		handler.Close()
+		return nil, err
+	}
+	return handler, nil
+}
+
 // BothHandler sends output to both file and queue
 type BothHandler struct {
 	fileHandler  Handler
@@ -76,6 +234,19 @@ func (h *BothHandler) SendOrdered(result *parser.ParseResult, identifier string)
 	return nil
 }
 
+// SendPartition writes one partition to file, then publishes it to the queue
+func (h *BothHandler) SendPartition(result *parser.ParseResult, identifier, partition string) error {
+	if err := sendPartition(h.fileHandler, result, identifier, partition); err != nil {
+		return fmt.Errorf("file output failed: %w", err)
+	}
+
+	if err := sendPartition(h.queueHandler, result, identifier, partition); err != nil {
+		return fmt.Errorf("queue output failed: %w", err)
+	}
+
+	return nil
+}
+
 func (h *BothHandler) Close() error {
 	// Close both handlers (ignore file handler close errors as it's a no-op)
 	h.fileHandler.Close()
@@ -83,16 +254,57 @@ func (h *BothHandler) Close() error {
 }
 
 // SetEnvelopeContext configures envelope metadata for the queue handler (ADR-006)
-func (h *BothHandler) SetEnvelopeContext(routeName, ingestionContract, sourceFilePath string, includeEnvelope bool) {
-	if qh, ok := h.queueHandler.(*QueueHandler); ok {
-		qh.SetEnvelopeContext(routeName, ingestionContract, sourceFilePath, includeEnvelope)
+// and the route name recorded in file delivery receipts
+func (h *BothHandler) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
+	for _, handler := range []Handler{h.fileHandler, h.queueHandler} {
+		if rc, ok := handler.(RouteContextSetter); ok {
+			rc.SetEnvelopeContext(routeName, ingestionContract, payloadFormat)
+		}
 	}
 }
 
-func marshalMessage(data []map[string]string, identifier string) ([]byte, error) {
-	msg := Message{
-		Identifier: identifier,
-		Data:       data,
+// SetSourceFile sets the source file path recorded in queue message envelopes
+func (h *BothHandler) SetSourceFile(sourceFilePath string) {
+	for _, handler := range []Handler{h.fileHandler, h.queueHandler} {
+		if rc, ok := handler.(RouteContextSetter); ok {
+			rc.SetSourceFile(sourceFilePath)
+		}
+	}
+}
+
+// SetContractVersion records the registry schema version in queue message envelopes
+func (h *BothHandler) SetContractVersion(version string) {
+	if cv, ok := h.queueHandler.(ContractVersionSetter); ok {
+		cv.SetContractVersion(version)
+	}
+}
+
+// SetBusinessDate passes the business date on to both handlers
+func (h *BothHandler) SetBusinessDate(date string) {
+	for _, handler := range []Handler{h.fileHandler, h.queueHandler} {
+		if bd, ok := handler.(BusinessDateSetter); ok {
+			bd.SetBusinessDate(date)
+		}
 	}
-	return json.Marshal(msg)
+}
+
+// SetCorrection passes the correction flag on to the queue handler
+func (h *BothHandler) SetCorrection(correction bool) {
+	if cs, ok := h.queueHandler.(CorrectionSetter); ok {
+		cs.SetCorrection(correction)
+	}
+}
+
+// sendPartition sends a partition through handler if it supports partitioned output
+func sendPartition(handler Handler, result *parser.ParseResult, identifier, partition string) error {
+	ps, ok := handler.(PartitionSender)
+	if !ok {
+		return fmt.Errorf("output handler does not support partitioned output")
+	}
+	return ps.SendPartition(result, identifier, partition)
+}
+
+// marshalMessage wraps rendered records in the legacy message format
+func marshalMessage(dataJSON json.RawMessage, identifier string) ([]byte, error) {
+	return json.Marshal(Message{Identifier: identifier, Data: dataJSON})
 }
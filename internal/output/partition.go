@@ -0,0 +1,64 @@
+package output
+
+import (
+	"strings"
+
+	"csv2json/internal/businessdate"
+	"csv2json/internal/parser"
+)
+
+// PartitionPlaceholder is replaced with the partition value in output folders and queue names
+const PartitionPlaceholder = "{partition}"
+
+// emptyPartition names the partition of rows whose partition column is empty
+const emptyPartition = "_empty"
+
+// undatedFolder replaces the business date placeholder of output without a business date
+const undatedFolder = "_undated"
+
+// PartitionSender is implemented by handlers that can route output per partition value
+type PartitionSender interface {
+	SendPartition(result *parser.ParseResult, identifier, partition string) error
+}
+
+// partitionSegment converts a partition value into a string safe for file paths and queue names.
+// Characters other than letters, digits, '.', '-' and '_' are replaced with '_'.
+func partitionSegment(value string) string {
+	if value == "" {
+		return emptyPartition
+	}
+	segment := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+	if strings.Trim(segment, ".") == "" {
+		return strings.Repeat("_", len(segment))
+	}
+	return segment
+}
+
+// resolveBusinessDate substitutes the business date placeholder in template, with
+// "_undated" when no business date is known. Returns the template unchanged and false
+// if it has no placeholder.
+func resolveBusinessDate(template, date string) (string, bool) {
+	if !strings.Contains(template, businessdate.Placeholder) {
+		return template, false
+	}
+	if date == "" {
+		date = undatedFolder
+	}
+	return strings.ReplaceAll(template, businessdate.Placeholder, date), true
+}
+
+// resolvePartition substitutes the partition placeholder in template.
+// Returns the template unchanged and false if it has no placeholder.
+func resolvePartition(template, partition string) (string, bool) {
+	if !strings.Contains(template, PartitionPlaceholder) {
+		return template, false
+	}
+	return strings.ReplaceAll(template, PartitionPlaceholder, partitionSegment(partition)), true
+}
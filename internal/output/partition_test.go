@@ -0,0 +1,61 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/parser"
+)
+
+// TestPartitionSegment validates partition values are made safe for paths and queue names
+func TestPartitionSegment(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"DE", "DE"},
+		{"en-GB_v1.2", "en-GB_v1.2"},
+		{"North America", "North_America"},
+		{"../etc", ".._etc"},
+		{"..", "__"},
+		{"", "_empty"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			if got := partitionSegment(tc.value); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestFileHandlerSendPartition validates partition output paths with and without a folder template
+func TestFileHandlerSendPartition(t *testing.T) {
+	result := &parser.ParseResult{
+		Headers: []string{"country"},
+		Rows:    []parser.OrderedMap{{Keys: []string{"country"}, Values: map[string]string{"country": "DE"}}},
+	}
+
+	t.Run("filename suffix", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := NewFileHandler(dir).SendPartition(result, "sales.csv", "DE"); err != nil {
+			t.Fatalf("SendPartition failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "sales_DE.json")); err != nil {
+			t.Errorf("Expected sales_DE.json to be written: %v", err)
+		}
+	})
+
+	t.Run("folder template", func(t *testing.T) {
+		dir := t.TempDir()
+		handler := NewFileHandler(filepath.Join(dir, "country="+PartitionPlaceholder))
+		if err := handler.SendPartition(result, "sales.csv", "DE"); err != nil {
+			t.Fatalf("SendPartition failed: %v", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "country=DE", "sales.json")); err != nil {
+			t.Errorf("Expected country=DE/sales.json to be written: %v", err)
+		}
+	})
+}
@@ -0,0 +1,212 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"csv2json/internal/converter"
+	"csv2json/internal/parser"
+)
+
+// PreviewHandler writes output as it would be written or published to a writer, without
+// touching output folders or connecting to a broker (csv2json test-route)
+type PreviewHandler struct {
+	w            io.Writer
+	outputType   string
+	outputFolder string
+	pipePath     string
+	businessDate string
+	wrapper      *FileWrapper         // Top-level object previewed file outputs are wrapped in
+	asciiSafe    bool                 // Escape non-ASCII characters of the wrapper fields
+	location     *time.Location       // Time zone of the wrapper's generatedAt (nil = UTC)
+	file         *converter.Converter // Non-nil when the route writes JSON files or streams
+	queue        *QueueHandler        // Never connected; non-nil when the route publishes to a queue
+}
+
+// NewPreviewHandler creates a preview of the given output type with opts applied
+func NewPreviewHandler(w io.Writer, outputType, outputFolder, queueType, queueHost string, queuePort int, queueName string, opts Options) (*PreviewHandler, error) {
+	h := &PreviewHandler{w: w, outputType: outputType, outputFolder: outputFolder, pipePath: opts.PipePath}
+	if outputType == "file" || outputType == "both" {
+		h.wrapper, h.asciiSafe, h.location = opts.FileWrapper, opts.ASCIISafe, opts.Location
+	}
+	if outputType != "queue" {
+		h.file = converter.NewWithOptions(opts.converterOptions())
+	}
+	switch outputType {
+	case "file", "stdout", "pipe":
+	case "queue", "both":
+		queue, err := buildQueueHandler(queueType, queueHost, queuePort, opts.VHost, queueName, "", "", false)
+		if err != nil {
+			return nil, err
+		}
+		if err := queue.applyOptions(opts); err != nil {
+			return nil, err
+		}
+		h.queue = queue
+	default:
+		return nil, fmt.Errorf("invalid output type: %s (valid: file, queue, both, stdout, pipe)", outputType)
+	}
+	return h, nil
+}
+
+// SetEnvelopeContext sets the route context recorded in previewed queue envelopes
+func (h *PreviewHandler) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
+	if h.queue != nil {
+		h.queue.SetEnvelopeContext(routeName, ingestionContract, payloadFormat)
+	}
+}
+
+// SetSourceFile sets the source file recorded in previewed queue envelopes
+func (h *PreviewHandler) SetSourceFile(sourceFilePath string) {
+	if h.queue != nil {
+		h.queue.SetSourceFile(sourceFilePath)
+	}
+}
+
+// SetContractVersion records the registry schema version in previewed queue envelopes
+func (h *PreviewHandler) SetContractVersion(version string) {
+	if h.queue != nil {
+		h.queue.SetContractVersion(version)
+	}
+}
+
+// SetBusinessDate records the business date in previewed envelopes and file destinations
+func (h *PreviewHandler) SetBusinessDate(date string) {
+	h.businessDate = date
+	if h.queue != nil {
+		h.queue.SetBusinessDate(date)
+	}
+}
+
+// SetCorrection previews messages as published to the corrections queue
+func (h *PreviewHandler) SetCorrection(correction bool) {
+	if h.queue != nil {
+		h.queue.SetCorrection(correction)
+	}
+}
+
+func (h *PreviewHandler) Send(data []map[string]string, identifier string) error {
+	rows := make([]parser.OrderedMap, 0, len(data))
+	for _, values := range data {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		rows = append(rows, parser.OrderedMap{Keys: keys, Values: values})
+	}
+	return h.SendOrdered(&parser.ParseResult{Rows: rows}, identifier)
+}
+
+func (h *PreviewHandler) SendOrdered(result *parser.ParseResult, identifier string) error {
+	return h.preview(result, identifier, "", false)
+}
+
+// SendPartition previews one partition of a file
+func (h *PreviewHandler) SendPartition(result *parser.ParseResult, identifier, partition string) error {
+	return h.preview(result, identifier, partition, true)
+}
+
+// preview writes the file output and queue message result would be sent as
+func (h *PreviewHandler) preview(result *parser.ParseResult, identifier, partition string, partitioned bool) error {
+	if h.file != nil {
+		jsonBytes, err := h.file.ToJSONOrdered(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal ordered JSON: %w", err)
+		}
+		if h.wrapper != nil {
+			if jsonBytes, err = h.wrap(jsonBytes, identifier); err != nil {
+				return err
+			}
+		}
+		if err := h.write(h.fileDestination(identifier, partition, partitioned), jsonBytes); err != nil {
+			return err
+		}
+	}
+	if h.queue != nil {
+		queueName, message, err := h.queue.preview(result, identifier, partition, partitioned)
+		if err != nil {
+			return err
+		}
+		if err := h.write("queue "+queueName, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// wrap returns rendered records inside the wrapper object of file outputs
+func (h *PreviewHandler) wrap(records []byte, identifier string) ([]byte, error) {
+	generatedAt := time.Now().UTC()
+	if h.location != nil {
+		generatedAt = generatedAt.In(h.location)
+	}
+	var buf bytes.Buffer
+	_, err := h.wrapper.write(&buf, identifier, generatedAt, h.asciiSafe, func(w io.Writer) (int64, error) {
+		n, err := w.Write(records)
+		return int64(n), err
+	})
+	return buf.Bytes(), err
+}
+
+// fileDestination describes where a file or stream output would be written
+func (h *PreviewHandler) fileDestination(identifier, partition string, partitioned bool) string {
+	switch h.outputType {
+	case "stdout":
+		return StdoutDestination
+	case "pipe":
+		return "pipe " + h.pipePath
+	}
+	dated, _ := resolveBusinessDate(h.outputFolder, h.businessDate)
+	if !partitioned {
+		return "file " + outputPath(dated, identifier, "")
+	}
+	folder, templated := resolvePartition(dated, partition)
+	if templated {
+		return "file " + outputPath(folder, identifier, "")
+	}
+	return "file " + outputPath(folder, identifier, partitionSegment(partition))
+}
+
+// write prints one previewed output under a header naming its destination
+func (h *PreviewHandler) write(destination string, content []byte) error {
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, content, "", "  "); err != nil {
+		indented.Reset()
+		indented.Write(content)
+	}
+	_, err := fmt.Fprintf(h.w, "--- %s\n%s\n", filepath.ToSlash(destination), indented.Bytes())
+	return err
+}
+
+// Close is a no-op: previews hold no files or connections
+func (h *PreviewHandler) Close() error {
+	return nil
+}
+
+// preview renders the message a partition of a file would be published as, and the
+// queue it would be published to
+func (h *QueueHandler) preview(result *parser.ParseResult, identifier, partition string, partitioned bool) (string, []byte, error) {
+	if partitioned {
+		h.partition = partition
+		defer func() { h.partition = "" }()
+
+		template := h.queueName
+		h.queueName, _ = resolvePartition(template, partition)
+		defer func() { h.queueName = template }()
+	}
+
+	var firstRow map[string]string
+	if len(result.Rows) > 0 {
+		firstRow = result.Rows[0].Values
+	}
+	defer h.selectShard(firstRow)()
+
+	message, err := h.Render(result, identifier)
+	return h.queueName, message, err
+}
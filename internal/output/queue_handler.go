@@ -7,20 +7,38 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/streadway/amqp"
 )
 
-// MessageEnvelope represents the ADR-006 message envelope with full provenance
+// Message payload formats
+const (
+	PayloadEnvelope = "envelope" // ADR-006 envelope: {"meta": {...}, "data": [...]} (default)
+	PayloadLegacy   = "legacy"   // {"identifier": "...", "data": [...]}
+	PayloadBare     = "bare"     // The converter's JSON array alone, without a wrapper
+)
+
+// MessageEnvelope represents the ADR-006 message envelope with full provenance. Data
+// holds the records as the converter rendered them, so their field order (ADR-003) and
+// nesting survive.
 type MessageEnvelope struct {
-	Meta MessageMeta         `json:"meta"`
-	Data []map[string]string `json:"data"`
+	Meta MessageMeta     `json:"meta"`
+	Data json.RawMessage `json:"data"`
 }
 
 // MessageMeta contains provenance and ingestion metadata
 type MessageMeta struct {
 	IngestionContract string            `json:"ingestionContract"`
+	ContractVersion   string            `json:"contractVersion,omitempty"` // Registry schema version the data was validated against
+	BusinessDate      string            `json:"businessDate,omitempty"`    // Business date of the source file (YYYY-MM-DD)
+	Correction        bool              `json:"correction,omitempty"`      // Restates an already completed business date
+	Tenant            string            `json:"tenant,omitempty"`          // Tenant the destination is scoped to
+	IdempotencyKey    string            `json:"idempotencyKey,omitempty"`  // Same for re-deliveries of the same file through the same route
 	Source            SourceMetadata    `json:"source"`
 	Ingestion         IngestionMetadata `json:"ingestion"`
 }
@@ -39,47 +57,110 @@ type SourceMetadata struct {
 type IngestionMetadata struct {
 	Service   string `json:"service"`   // Service name (csv2json)
 	Version   string `json:"version"`   // Service semantic version
-	Timestamp string `json:"timestamp"` // ISO8601 ingestion timestamp (UTC)
+	Timestamp string `json:"timestamp"` // ISO8601 ingestion timestamp (UTC unless TIMEZONE is set)
 }
 
 type QueueHandler struct {
 	queueType         string
-	conn              *amqp.Connection
+	conn              *amqp.Connection  // The handler's own connection (nil when shared)
+	shared            *sharedConnection // Connection shared with other handlers (nil = own connection)
 	channel           *amqp.Channel
+	connStr           string        // AMQP URL, kept for reconnects
+	heartbeat         time.Duration // AMQP heartbeat interval (0 = the broker's)
+	connectRetries    int           // Reconnect attempts before a publish fails
+	retryDelay        time.Duration // Delay before the first reconnect attempt, doubled after each
+	lost              *atomic.Bool  // Set when the current connection or channel closes
 	queueName         string
+	host              string
+	port              int
+	vhost             string
 	converter         *converter.Converter
 	logMessages       bool
-	routeName         string // Route name for context in messages
-	ingestionContract string // Schema/contract identifier
-	includeEnvelope   bool   // Whether to include full envelope (ADR-006)
-	sourceFilePath    string // Full source file path
-	brokerURI         string // Broker connection string
-	serviceVersion    string // csv2json version
+	routeName         string          // Route name for context in messages
+	ingestionContract string          // Schema/contract identifier
+	contractVersion   string          // Resolved registry schema version ("" = contract not enforced)
+	businessDate      string          // Business date of the file being sent ("" = not derived)
+	correctionsQueue  string          // Queue restated business dates are published to ("" = none)
+	correction        bool            // The file being sent restates a completed business date
+	payloadFormat     string          // PayloadEnvelope, PayloadLegacy or PayloadBare ("" = legacy)
+	contentType       string          // Message content type; other than JSON, messages carry the records alone
+	schema            string          // Sent in the x-schema header ("" = no header)
+	sourceFilePath    string          // Full source file path
+	sourceHash        string          // SHA-256 of the source file content ("" when unknown)
+	brokerURI         string          // Broker connection string
+	serviceVersion    string          // csv2json version
+	tenant            string          // Tenant recorded in envelope metadata
+	location          *time.Location  // Time zone of envelope timestamps (nil = UTC)
+	asciiSafe         bool            // Escape non-ASCII characters in message bodies
+	declaredQueues    map[string]bool // Partition queues declared so far
+	partition         string          // Partition value of the message being sent
+	kafkaKey          *MessageTemplate
+	kafkaPartition    *MessageTemplate
+	identifier        *MessageTemplate // Legacy message identifier template (nil = the source filename)
+	exchange          string           // RabbitMQ exchange ("" = default exchange, routing key is the queue name)
+	routingKey        *MessageTemplate // RabbitMQ routing key template (defaults to the queue name)
+	bindingKey        string           // Binding key for the output queue when an exchange is used ("" = queue name)
+	credentials       CredentialsFunc  // Reads the current credentials before each dial (nil = fixed credentials)
+	receipts          *ReceiptLog      // Optional delivery receipt log
+	meter             *outputMeter     // Delivery and retry metrics (set by MeteredHandler)
+	exchangeType      string           // RabbitMQ exchange type (default topic)
+	publisherConfirms bool             // Put channels into confirm mode
+	confirms          chan amqp.Confirmation
+	confirmTimeout    time.Duration
+	publishSeq        uint64          // Delivery tag of the last publish in confirm mode
+	downstreamAck     bool            // Publishes wait for a downstream reply
+	ackQueue          string          // Downstream reply queue ("" = exclusive server-named queue)
+	ackTimeout        time.Duration   // Downstream reply timeout
+	acks              *downstreamAcks // Non-nil when publishes wait for a downstream reply
+	shards            *shardSelector  // Non-nil when messages are distributed across several queues
+	cipher            *PayloadCipher  // Non-nil when message bodies are encrypted
+	signer            *PayloadSigner  // Non-nil when message bodies are signed
+}
+
+// messageAttributes are broker-specific attributes derived per message
+type messageAttributes struct {
+	Key            string // Message key (Kafka); related records share a key and therefore a partition
+	Partition      int32  // Explicit partition (Kafka), or -1 to let the producer hash the key
+	RoutingKey     string // RabbitMQ routing key (empty = queue name)
+	IdempotencyKey string // Deterministic per file, route and partition ("" when unknown)
+	SourceFile     string // Recorded in delivery receipts
+	Rows           int    // Recorded in delivery receipts
 }
 
 func NewQueueHandler(queueType, host string, port int, queueName, username, password string, logMessages bool) (*QueueHandler, error) {
-	// Build broker URI
-	var brokerURI string
-	if username != "" && password != "" {
-		brokerURI = fmt.Sprintf("%s://%s:%s@%s:%d/", queueType, username, "***", host, port) // Redacted password in URI
-	} else {
-		brokerURI = fmt.Sprintf("%s://%s:%d/", queueType, host, port)
+	return newQueueHandler(queueType, host, port, "", queueName, username, password, logMessages)
+}
+
+// newQueueHandler creates a queue handler connected to vhost ("" = the broker's default vhost)
+func newQueueHandler(queueType, host string, port int, vhost, queueName, username, password string, logMessages bool) (*QueueHandler, error) {
+	handler, err := buildQueueHandler(queueType, host, port, vhost, queueName, username, password, logMessages)
+	if err != nil {
+		return nil, err
 	}
+	return handler, handler.connect()
+}
 
+// buildQueueHandler creates a queue handler without connecting to the broker
+func buildQueueHandler(queueType, host string, port int, vhost, queueName, username, password string, logMessages bool) (*QueueHandler, error) {
 	handler := &QueueHandler{
-		queueType:       queueType,
-		queueName:       queueName,
-		converter:       converter.New(),
-		logMessages:     logMessages,
-		includeEnvelope: true, // Default: include envelope with provenance (ADR-006)
-		brokerURI:       brokerURI,
-		serviceVersion:  version.GetVersion(), // Read from VERSION file (ADR-006)
+		queueType:      queueType,
+		queueName:      queueName,
+		host:           host,
+		port:           port,
+		vhost:          vhost,
+		converter:      converter.New(),
+		logMessages:    logMessages,
+		payloadFormat:  PayloadEnvelope, // Default: include envelope with provenance (ADR-006)
+		contentType:    ContentTypeJSON,
+		serviceVersion: version.GetVersion(), // Embedded VERSION file (ADR-006)
+		heartbeat:      10 * time.Second,     // AMQP client default
 	}
+	handler.setCredentials(username, password)
 
 	// Route to appropriate queue implementation
 	switch queueType {
 	case "rabbitmq":
-		return handler, handler.initRabbitMQ(host, port, username, password)
+		return handler, nil
 	case "kafka":
 		return nil, fmt.Errorf("Kafka not yet implemented")
 	case "sqs":
@@ -91,35 +172,193 @@ func NewQueueHandler(queueType, host string, port int, queueName, username, pass
 	}
 }
 
-func (h *QueueHandler) initRabbitMQ(host string, port int, username, password string) error {
-	// Build AMQP connection string
-	var connStr string
+// setCredentials builds the broker URI and the AMQP connection string for username and password
+func (h *QueueHandler) setCredentials(username, password string) {
+	if username != "" && password != "" {
+		h.brokerURI = fmt.Sprintf("%s://%s:%s@%s:%d/%s", h.queueType, username, "***", h.host, h.port, url.PathEscape(h.vhost)) // Redacted password in URI
+	} else {
+		h.brokerURI = fmt.Sprintf("%s://%s:%d/%s", h.queueType, h.host, h.port, url.PathEscape(h.vhost))
+	}
+
+	// An empty path selects the default vhost "/"
 	if username != "" && password != "" {
 		// With authentication
-		connStr = fmt.Sprintf("amqp://%s:%s@%s:%d/", username, password, host, port)
+		h.connStr = fmt.Sprintf("amqp://%s:%s@%s:%d/%s", username, password, h.host, h.port, url.PathEscape(h.vhost))
 	} else {
 		// Without authentication (guest:guest default)
-		connStr = fmt.Sprintf("amqp://%s:%d/", host, port)
+		h.connStr = fmt.Sprintf("amqp://%s:%d/%s", h.host, h.port, url.PathEscape(h.vhost))
 	}
+}
 
-	// Connect to RabbitMQ
-	conn, err := amqp.Dial(connStr)
+// refreshCredentials rebuilds the connection string from the current credentials, so a
+// dial after a secret rotation authenticates with the rotated values. A handler on a
+// shared connection moves to the pool's connection for the new credentials.
+func (h *QueueHandler) refreshCredentials() error {
+	if h.credentials == nil {
+		return nil
+	}
+	username, password, err := h.credentials()
 	if err != nil {
-		return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		return fmt.Errorf("failed to read broker credentials: %w", err)
+	}
+	connStr := h.connStr
+	h.setCredentials(username, password)
+	if h.connStr == connStr {
+		return nil
+	}
+	log.Printf("Broker credentials for %s changed; dialing with the new credentials", h.brokerURI)
+	if h.shared != nil {
+		if err := sharedConnections.release(h.shared); err != nil {
+			log.Printf("Warning: failed to close shared connection: %v", err)
+		}
+		h.shared = sharedConnections.acquire(h.connStr, h.brokerURI, h.heartbeat)
+	}
+	return nil
+}
+
+// dialAMQP opens an AMQP connection; replaced in tests
+var dialAMQP = amqp.DialConfig
+
+// connect dials RabbitMQ and declares the output topology
+func (h *QueueHandler) connect() error {
+	if err := h.refreshCredentials(); err != nil {
+		return err
+	}
+	if h.shared != nil {
+		if err := h.shared.connect(); err != nil {
+			return err
+		}
+	} else {
+		conn, err := dialAMQP(h.connStr, amqp.Config{Heartbeat: h.heartbeat, Locale: "en_US"})
+		if err != nil {
+			return fmt.Errorf("failed to connect to RabbitMQ: %w", err)
+		}
+		h.conn = conn
 	}
-	h.conn = conn
 
 	// Create channel
-	ch, err := conn.Channel()
+	ch, err := h.openChannel()
 	if err != nil {
-		conn.Close()
+		h.disconnect()
 		return fmt.Errorf("failed to open channel: %w", err)
 	}
 	h.channel = ch
+	h.declaredQueues = nil
+	h.publishSeq = 0
 
-	// Declare queue
-	_, err = ch.QueueDeclare(
-		h.queueName,
+	if err := h.declareTopology(); err != nil {
+		h.disconnect()
+		return err
+	}
+
+	// Heartbeats keep the connection alive while idle; a closed channel means the next publish reconnects
+	lost := &atomic.Bool{}
+	h.lost = lost
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		if err := <-closed; err != nil {
+			log.Printf("RabbitMQ connection to %s lost: %v", h.brokerURI, err)
+		}
+		lost.Store(true)
+	}()
+	return nil
+}
+
+// openChannel opens a channel on the handler's own or shared connection
+func (h *QueueHandler) openChannel() (*amqp.Channel, error) {
+	if h.shared != nil {
+		return h.shared.channel()
+	}
+	return h.conn.Channel()
+}
+
+// declareTopology declares the queues, exchange and reply queue, and enables confirms
+func (h *QueueHandler) declareTopology() error {
+	// Partitioned queue names are declared on first use
+	if !strings.Contains(h.queueName, PartitionPlaceholder) {
+		if err := h.declareQueue(h.queueName); err != nil {
+			return err
+		}
+	}
+
+	if h.publisherConfirms {
+		if err := h.channel.Confirm(false); err != nil {
+			return fmt.Errorf("failed to enable publisher confirms: %w", err)
+		}
+		h.confirms = h.channel.NotifyPublish(make(chan amqp.Confirmation, 16))
+	}
+	if h.shards != nil {
+		if err := h.declareShards(); err != nil {
+			return err
+		}
+	}
+	if h.correctionsQueue != "" {
+		if err := h.declareQueue(h.correctionsQueue); err != nil {
+			return err
+		}
+	}
+	if h.downstreamAck {
+		if err := h.enableDownstreamAck(h.ackQueue, h.ackTimeout); err != nil {
+			return err
+		}
+	}
+	if h.exchange != "" {
+		return h.declareExchange(h.exchangeType)
+	}
+	return nil
+}
+
+// ensureConnected connects on first use, or reconnects after the connection was lost,
+// retrying with a doubling delay
+func (h *QueueHandler) ensureConnected() error {
+	if h.channel != nil && (h.lost == nil || !h.lost.Load()) {
+		return nil
+	}
+	if h.channel != nil {
+		h.disconnect()
+	}
+
+	delay := h.retryDelay
+	err := h.connect()
+	for attempt := 1; err != nil && attempt <= h.connectRetries; attempt++ {
+		log.Printf("Connecting to RabbitMQ at %s failed (%v), retrying in %v (%d/%d)", h.brokerURI, err, delay, attempt, h.connectRetries)
+		h.meter.retried()
+		time.Sleep(delay)
+		delay *= 2
+		err = h.connect()
+	}
+	if err != nil {
+		return err
+	}
+	log.Printf("Connected to RabbitMQ at %s", h.brokerURI)
+	return nil
+}
+
+// disconnect closes the channel and the handler's own connection, leaving the handler to
+// reconnect on the next publish. A shared connection stays open for the other handlers.
+func (h *QueueHandler) disconnect() error {
+	h.acks.close()
+	h.acks = nil
+	h.confirms = nil
+	if h.channel != nil {
+		h.channel.Close()
+		h.channel = nil
+	}
+	if h.conn == nil {
+		return nil
+	}
+	err := h.conn.Close()
+	h.conn = nil
+	if err == amqp.ErrClosed {
+		return nil // Already closed by the broker or a network failure
+	}
+	return err
+}
+
+// declareQueue declares a durable queue on the channel
+func (h *QueueHandler) declareQueue(queueName string) error {
+	_, err := h.channel.QueueDeclare(
+		queueName,
 		true,  // durable
 		false, // auto-delete
 		false, // exclusive
@@ -127,123 +366,575 @@ func (h *QueueHandler) initRabbitMQ(host string, port int, username, password st
 		nil,   // arguments
 	)
 	if err != nil {
-		ch.Close()
-		conn.Close()
 		return fmt.Errorf("failed to declare queue: %w", err)
 	}
+	return nil
+}
+
+// applyOptions configures optional output behaviour
+func (h *QueueHandler) applyOptions(opts Options) error {
+	h.asciiSafe = opts.ASCIISafe
+	h.tenant = opts.Tenant
+	h.location = opts.Location
+	if opts.ContentType != "" {
+		h.contentType = opts.ContentType
+	}
+	h.schema = opts.Schema
+	h.converter = converter.NewWithOptions(opts.converterOptions())
+
+	var err error
+	if h.kafkaKey, err = ParseMessageTemplate(opts.KafkaMessageKey); err != nil {
+		return fmt.Errorf("invalid Kafka message key: %w", err)
+	}
+	if h.kafkaPartition, err = ParseMessageTemplate(opts.KafkaPartition); err != nil {
+		return fmt.Errorf("invalid Kafka partition: %w", err)
+	}
+	if h.identifier, err = ParseMessageTemplate(opts.LegacyIdentifier); err != nil {
+		return fmt.Errorf("invalid legacy message identifier: %w", err)
+	}
+	if h.routingKey, err = ParseMessageTemplate(opts.RabbitMQRoutingKey); err != nil {
+		return fmt.Errorf("invalid RabbitMQ routing key: %w", err)
+	}
+
+	h.credentials = opts.Credentials
+	h.heartbeat = opts.Heartbeat
+	h.connectRetries = opts.ConnectRetries
+	h.retryDelay = opts.ConnectRetryDelay
+
+	if opts.PublisherConfirms {
+		h.publisherConfirms = true
+		h.confirmTimeout = opts.ConfirmTimeout
+		if h.confirmTimeout <= 0 {
+			h.confirmTimeout = 30 * time.Second
+		}
+	}
+
+	if opts.EncryptionKey != "" {
+		if h.cipher, err = NewPayloadCipher(opts.EncryptionKey, opts.EncryptionKeyID); err != nil {
+			return fmt.Errorf("invalid payload encryption settings: %w", err)
+		}
+	}
+
+	if opts.SigningKey != "" {
+		if h.signer, err = NewPayloadSigner(opts.SigningAlgorithm, opts.SigningKey, opts.SigningKeyID); err != nil {
+			return fmt.Errorf("invalid message signing settings: %w", err)
+		}
+		if publicKey := h.signer.PublicKey(); publicKey != "" {
+			log.Printf("Signing messages with Ed25519 key %s (public key %s)", h.signer.KeyID(), publicKey)
+		}
+	}
 
+	h.correctionsQueue = opts.CorrectionsQueue
+	if len(opts.QueueShards) > 0 {
+		h.shards = &shardSelector{queues: opts.QueueShards, column: opts.ShardColumn}
+	}
+
+	if opts.DownstreamAck {
+		h.downstreamAck = true
+		h.ackQueue = opts.DownstreamAckQueue
+		h.ackTimeout = opts.DownstreamAckTimeout
+	}
+
+	if opts.RabbitMQExchange != "" {
+		h.exchange = opts.RabbitMQExchange
+		h.exchangeType = opts.RabbitMQExchangeType
+		h.bindingKey = opts.RabbitMQBindingKey
+		if h.bindingKey == "" && h.shards == nil && (opts.RabbitMQExchangeType == "" || opts.RabbitMQExchangeType == amqp.ExchangeTopic) {
+			h.bindingKey = "#" // Output queue receives every message published to the topic exchange
+		}
+	}
 	return nil
 }
 
-// SetEnvelopeContext configures message envelope metadata (ADR-006)
-func (h *QueueHandler) SetEnvelopeContext(routeName, ingestionContract, sourceFilePath string, includeEnvelope bool) {
+// declareExchange declares the output exchange and binds the output queue to it
+func (h *QueueHandler) declareExchange(exchangeType string) error {
+	if exchangeType == "" {
+		exchangeType = amqp.ExchangeTopic
+	}
+	err := h.channel.ExchangeDeclare(
+		h.exchange,
+		exchangeType,
+		true,  // durable
+		false, // auto-delete
+		false, // internal
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		return fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	// Partitioned queues are bound when first declared
+	if strings.Contains(h.queueName, PartitionPlaceholder) {
+		return nil
+	}
+	// Shards are bound by name so each message reaches only the queue it was routed to
+	if h.shards != nil {
+		for _, queue := range h.shards.queues {
+			if err := h.bindQueue(queue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return h.bindQueue(h.queueName)
+}
+
+// bindQueue binds queueName to the output exchange with the binding key (or the queue name)
+func (h *QueueHandler) bindQueue(queueName string) error {
+	key := h.bindingKey
+	if key == "" {
+		key = queueName
+	}
+	if err := h.channel.QueueBind(queueName, key, h.exchange, false, nil); err != nil {
+		return fmt.Errorf("failed to bind queue %s to exchange %s: %w", queueName, h.exchange, err)
+	}
+	return nil
+}
+
+// needsData reports whether any attribute template hashes the message data
+func (h *QueueHandler) needsData() bool {
+	for _, t := range []*MessageTemplate{h.kafkaKey, h.kafkaPartition, h.routingKey} {
+		if t.references(templateDataHash) {
+			return true
+		}
+	}
+	return false
+}
+
+// messageContext returns the template values for a message whose first row is row
+func (h *QueueHandler) messageContext(identifier string, row map[string]string, data []byte) MessageContext {
+	return MessageContext{
+		Route:        h.routeName,
+		Contract:     h.ingestionContract,
+		Filename:     identifier,
+		Path:         h.sourceFilePath,
+		FileHash:     h.sourceHash,
+		Partition:    h.partition,
+		BusinessDate: h.businessDate,
+		Row:          row,
+		Data:         data,
+	}
+}
+
+// messageAttributes resolves the configured attribute templates for a message
+func (h *QueueHandler) messageAttributes(identifier string, row map[string]string, data []byte) (messageAttributes, error) {
+	attrs := messageAttributes{Partition: -1, SourceFile: identifier, IdempotencyKey: h.idempotencyKey()}
+	ctx := h.messageContext(identifier, row, data)
+	if h.kafkaKey != nil {
+		attrs.Key = h.kafkaKey.Resolve(ctx)
+	}
+	if h.kafkaPartition != nil {
+		value := h.kafkaPartition.Resolve(ctx)
+		partition, err := strconv.ParseInt(value, 10, 32)
+		if err != nil || partition < 0 {
+			return attrs, fmt.Errorf("Kafka partition template %q resolved to invalid partition %q", h.kafkaPartition, value)
+		}
+		attrs.Partition = int32(partition)
+	}
+	if h.routingKey != nil {
+		attrs.RoutingKey = h.routingKey.Resolve(ctx)
+	}
+	return attrs, nil
+}
+
+// SetEnvelopeContext configures the route-level message envelope metadata (ADR-006)
+func (h *QueueHandler) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
 	h.routeName = routeName
 	h.ingestionContract = ingestionContract
+	h.payloadFormat = payloadFormat
+}
+
+// SetSourceFile sets the source file path recorded in envelopes of the messages that follow
+// and hashes its content for their idempotency keys
+func (h *QueueHandler) SetSourceFile(sourceFilePath string) {
 	h.sourceFilePath = sourceFilePath
-	h.includeEnvelope = includeEnvelope
+	h.sourceHash = ""
+	if sourceFilePath == "" {
+		return
+	}
+	hash, err := hashSourceFile(sourceFilePath)
+	if err != nil {
+		log.Printf("Warning: failed to hash %s, messages will carry no idempotency key: %v", sourceFilePath, err)
+		return
+	}
+	h.sourceHash = hash
+}
+
+// idempotencyKey returns the idempotency key of the message being sent
+func (h *QueueHandler) idempotencyKey() string {
+	return IdempotencyKey(h.routeName, h.sourceHash, h.partition)
+}
+
+// SetContractVersion records the registry schema version messages were validated against
+func (h *QueueHandler) SetContractVersion(version string) {
+	h.contractVersion = version
+}
+
+// SetBusinessDate records the business date of the messages that follow in their
+// envelopes and templates
+func (h *QueueHandler) SetBusinessDate(date string) {
+	h.businessDate = date
 }
 
-// buildMessageEnvelope creates ADR-006 compliant message envelope with full provenance
+// buildMessageEnvelope renders unordered rows and wraps them in the configured message format
 func (h *QueueHandler) buildMessageEnvelope(data []map[string]string, identifier string) ([]byte, error) {
-	if !h.includeEnvelope {
-		// Legacy format without envelope
-		return marshalMessage(data, identifier)
-	}
-
-	// Build full message envelope with provenance metadata (ADR-006)
-	envelope := MessageEnvelope{
-		Meta: MessageMeta{
-			IngestionContract: h.ingestionContract,
-			Source: SourceMetadata{
-				Type:   "file",
-				Name:   identifier,
-				Path:   h.sourceFilePath,
-				Queue:  h.queueName,
-				Broker: h.brokerURI,
-				Route:  h.routeName,
-			},
-			Ingestion: IngestionMetadata{
-				Service:   "csv2json",
-				Version:   h.serviceVersion,
-				Timestamp: time.Now().UTC().Format(time.RFC3339),
-			},
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var firstRow map[string]string
+	if len(data) > 0 {
+		firstRow = data[0]
+	}
+	return h.buildMessage(dataJSON, identifier, firstRow)
+}
+
+// recordsOnly reports whether messages carry the records alone: the bare payload
+// format, and every content type other than JSON
+func (h *QueueHandler) recordsOnly() bool {
+	return h.payloadFormat == PayloadBare || (h.contentType != "" && h.contentType != ContentTypeJSON)
+}
+
+// legacyIdentifier returns the identifier of a legacy format message: the source
+// filename, or the configured identifier template resolved for the message
+func (h *QueueHandler) legacyIdentifier(identifier string, row map[string]string, data []byte) string {
+	if h.identifier == nil {
+		return identifier
+	}
+	return h.identifier.Resolve(h.messageContext(identifier, row, data))
+}
+
+// now returns the current time in the handler's time zone
+func (h *QueueHandler) now() time.Time {
+	if h.location == nil {
+		return time.Now().UTC()
+	}
+	return time.Now().In(h.location)
+}
+
+// buildMessageMeta creates the ADR-006 provenance metadata for a message
+func (h *QueueHandler) buildMessageMeta(identifier string) MessageMeta {
+	return MessageMeta{
+		IngestionContract: h.ingestionContract,
+		ContractVersion:   h.contractVersion,
+		BusinessDate:      h.businessDate,
+		Correction:        h.correction,
+		Tenant:            h.tenant,
+		IdempotencyKey:    h.idempotencyKey(),
+		Source: SourceMetadata{
+			Type:   "file",
+			Name:   identifier,
+			Path:   h.sourceFilePath,
+			Queue:  h.queueName,
+			Broker: h.brokerURI,
+			Route:  h.routeName,
+		},
+		Ingestion: IngestionMetadata{
+			Service:   "csv2json",
+			Version:   h.serviceVersion,
+			Timestamp: h.now().Format(time.RFC3339),
 		},
-		Data: data,
 	}
+}
 
-	return json.Marshal(envelope)
+// buildMessage wraps rendered JSON data whose first row is row in the envelope or legacy
+// message format. The data is embedded as rendered, never decoded into maps, so field
+// order and nesting (group-by, multi-record) are kept. Bare messages are the data alone.
+func (h *QueueHandler) buildMessage(dataJSON []byte, identifier string, row map[string]string) ([]byte, error) {
+	var message []byte
+	var err error
+	switch {
+	case h.recordsOnly():
+		message = dataJSON
+	case h.payloadFormat == PayloadEnvelope:
+		// Full message envelope with provenance metadata (ADR-006)
+		message, err = json.Marshal(MessageEnvelope{Meta: h.buildMessageMeta(identifier), Data: dataJSON})
+	default:
+		// Legacy format without envelope
+		message, err = marshalMessage(dataJSON, h.legacyIdentifier(identifier, row, dataJSON))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.asciiSafe {
+		message = converter.EscapeNonASCII(message)
+	}
+	return encodeRecords(h.contentType, message)
 }
 
 func (h *QueueHandler) Send(data []map[string]string, identifier string) error {
+	var firstRow map[string]string
+	if len(data) > 0 {
+		firstRow = data[0]
+	}
+	defer h.selectShard(firstRow)()
+
 	message, err := h.buildMessageEnvelope(data, identifier)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
-
-	switch h.queueType {
-	case "rabbitmq":
-		return h.sendToRabbitMQ(message)
-	default:
-		return fmt.Errorf("unsupported queue type: %s", h.queueType)
+	var dataJSON []byte
+	if h.needsData() {
+		if dataJSON, err = json.Marshal(data); err != nil {
+			return fmt.Errorf("failed to marshal message data: %w", err)
+		}
+	}
+	attrs, err := h.messageAttributes(identifier, firstRow, dataJSON)
+	if err != nil {
+		return err
 	}
+	attrs.Rows = len(data)
+
+	return h.publish(message, attrs)
 }
 
 func (h *QueueHandler) SendOrdered(result *parser.ParseResult, identifier string) error {
-	// Convert to ordered JSON
-	jsonBytes, err := h.converter.ToJSONOrdered(result)
+	var firstRow map[string]string
+	if len(result.Rows) > 0 {
+		firstRow = result.Rows[0].Values
+	}
+	defer h.selectShard(firstRow)()
+
+	message, jsonBytes, err := h.renderOrdered(result, identifier)
+	if err != nil {
+		return err
+	}
+	attrs, err := h.messageAttributes(identifier, firstRow, jsonBytes)
 	if err != nil {
-		return fmt.Errorf("failed to marshal ordered JSON: %w", err)
+		return err
 	}
+	attrs.Rows = len(result.Rows)
 
-	// Parse JSON bytes back to []map[string]string for envelope
-	var data []map[string]string
-	if err := json.Unmarshal(jsonBytes, &data); err != nil {
-		return fmt.Errorf("failed to unmarshal JSON for envelope: %w", err)
+	return h.publish(message, attrs)
+}
+
+// Render returns the message body result would be published as, before encryption
+func (h *QueueHandler) Render(result *parser.ParseResult, identifier string) ([]byte, error) {
+	message, _, err := h.renderOrdered(result, identifier)
+	return message, err
+}
+
+// renderOrdered renders result as a message with ordered fields (ADR-003), returning the
+// message and the rendered data. The converter output is embedded in the message as is.
+func (h *QueueHandler) renderOrdered(result *parser.ParseResult, identifier string) ([]byte, []byte, error) {
+	jsonBytes, err := h.converter.ToJSONOrdered(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal ordered JSON: %w", err)
 	}
 
-	// Build envelope with provenance metadata
-	message, err := h.buildMessageEnvelope(data, identifier)
+	var firstRow map[string]string
+	if len(result.Rows) > 0 {
+		firstRow = result.Rows[0].Values
+	}
+	message, err := h.buildMessage(jsonBytes, identifier, firstRow)
 	if err != nil {
-		return fmt.Errorf("failed to build message envelope: %w", err)
+		return nil, nil, fmt.Errorf("failed to build message envelope: %w", err)
+	}
+	return message, jsonBytes, nil
+}
+
+// SendPartition publishes one partition of a file. If the queue name contains the
+// partition placeholder, each partition is published to its own (lazily declared) queue.
+func (h *QueueHandler) SendPartition(result *parser.ParseResult, identifier, partition string) error {
+	h.partition = partition
+	defer func() { h.partition = "" }()
+
+	queueName, templated := resolvePartition(h.queueName, partition)
+	if !templated {
+		return h.SendOrdered(result, identifier)
+	}
+
+	if !h.declaredQueues[queueName] && h.queueType == "rabbitmq" {
+		if err := h.ensureConnected(); err != nil {
+			return err
+		}
+		if err := h.declareQueue(queueName); err != nil {
+			return err
+		}
+		if h.exchange != "" {
+			if err := h.bindQueue(queueName); err != nil {
+				return err
+			}
+		}
+		if h.declaredQueues == nil {
+			h.declaredQueues = make(map[string]bool)
+		}
+		h.declaredQueues[queueName] = true
+	}
+
+	// Publish (and record in envelope metadata) under the resolved queue name
+	template := h.queueName
+	h.queueName = queueName
+	defer func() { h.queueName = template }()
+
+	return h.SendOrdered(result, identifier)
+}
+
+// publish sends a rendered message to the configured queue system
+func (h *QueueHandler) publish(message []byte, attrs messageAttributes) error {
+	if h.logMessages && attrs.Key != "" {
+		log.Printf("Message key: %s", attrs.Key)
 	}
 
 	switch h.queueType {
 	case "rabbitmq":
-		return h.sendToRabbitMQ(message)
+		if err := h.ensureConnected(); err != nil {
+			return err
+		}
+		return h.sendToRabbitMQ(message, attrs)
 	default:
 		return fmt.Errorf("unsupported queue type: %s", h.queueType)
 	}
 }
 
-func (h *QueueHandler) sendToRabbitMQ(message []byte) error {
+func (h *QueueHandler) sendToRabbitMQ(message []byte, attrs messageAttributes) error {
+	// The default exchange routes by queue name; templates apply to named exchanges.
+	// Corrections go straight to the corrections queue.
+	exchange, routingKey := h.exchange, h.queueName
+	if h.correction {
+		exchange = ""
+	} else if h.exchange != "" && attrs.RoutingKey != "" {
+		routingKey = attrs.RoutingKey
+	}
+
 	if h.logMessages {
-		log.Printf("Queuing message to %s: %s", h.queueName, string(message))
+		body := string(message)
+		if h.cipher != nil {
+			body = fmt.Sprintf("<%d bytes, encrypted with key %s>", len(message), h.cipher.KeyID())
+		}
+		if exchange != "" {
+			log.Printf("Publishing message to exchange %s with routing key %s: %s", exchange, routingKey, body)
+		} else {
+			log.Printf("Queuing message to %s: %s", routingKey, body)
+		}
 	}
 
+	messageID := newMessageID()
+	publishing := amqp.Publishing{
+		DeliveryMode: amqp.Persistent,
+		ContentType:  h.contentType,
+		MessageId:    messageID,
+		Timestamp:    time.Now().UTC(),
+		Body:         message,
+	}
+	if h.cipher != nil {
+		// Brokers only ever see ciphertext; the headers tell consumers which key opens it
+		encrypted, err := h.cipher.Encrypt(message)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt message: %w", err)
+		}
+		publishing.Body = encrypted
+		publishing.ContentType = "application/octet-stream"
+		publishing.Headers = amqp.Table{
+			HeaderEncryption:      EncryptionAlgorithm,
+			HeaderEncryptionKeyID: h.cipher.KeyID(),
+		}
+	}
+	if attrs.IdempotencyKey != "" {
+		if publishing.Headers == nil {
+			publishing.Headers = amqp.Table{}
+		}
+		publishing.Headers[HeaderIdempotencyKey] = attrs.IdempotencyKey
+	}
+	if h.schema != "" {
+		if publishing.Headers == nil {
+			publishing.Headers = amqp.Table{}
+		}
+		publishing.Headers[HeaderSchema] = h.schema
+	}
+	if h.signer != nil {
+		// Signed as sent, so consumers verify before decrypting or parsing
+		if publishing.Headers == nil {
+			publishing.Headers = amqp.Table{}
+		}
+		publishing.Headers[HeaderSignature] = h.signer.Sign(publishing.Body)
+		publishing.Headers[HeaderSignatureAlgorithm] = h.signer.Algorithm()
+		publishing.Headers[HeaderSignatureKeyID] = h.signer.KeyID()
+	}
+	var replies chan DownstreamReply
+	if h.acks != nil {
+		// Downstream consumers reply to the reply queue, quoting the message ID
+		publishing.ReplyTo, publishing.CorrelationId = h.acks.queue, messageID
+		replies = h.acks.expect(messageID)
+	}
 	err := h.channel.Publish(
-		"",          // exchange
-		h.queueName, // routing key
-		false,       // mandatory
-		false,       // immediate
-		amqp.Publishing{
-			DeliveryMode: amqp.Persistent,
-			ContentType:  "application/json",
-			Body:         message,
-		},
+		exchange,   // exchange
+		routingKey, // routing key
+		false,      // mandatory
+		false,      // immediate
+		publishing,
 	)
+
+	status := ReceiptPublished
 	if err != nil {
-		return fmt.Errorf("failed to publish message: %w", err)
+		status = ReceiptFailed
+		err = fmt.Errorf("failed to publish message: %w", err)
+	} else if h.confirms != nil {
+		h.publishSeq++
+		status, err = h.waitForConfirm(h.publishSeq)
+	}
+	if replies != nil {
+		if err == nil {
+			status, err = h.acks.wait(messageID, replies)
+		} else {
+			h.acks.cancel(messageID)
+		}
 	}
 
-	return nil
+	receipt := Receipt{
+		MessageID:   messageID,
+		Route:       h.routeName,
+		SourceFile:  attrs.SourceFile,
+		Output:      h.queueType,
+		Destination: h.queueName,
+		Exchange:    exchange,
+		RoutingKey:  routingKey,
+		Rows:        attrs.Rows,
+		Bytes:       len(publishing.Body),
+		Status:      status,
+	}
+	if err != nil {
+		receipt.Error = err.Error()
+	}
+	h.meter.delivered(receipt)
+	if recordErr := h.receipts.Record(receipt); recordErr != nil {
+		log.Printf("Failed to record delivery receipt: %v", recordErr)
+	}
+
+	return err
 }
 
-func (h *QueueHandler) Close() error {
-	if h.channel != nil {
-		h.channel.Close()
+// waitForConfirm waits for the broker to confirm the publish with deliveryTag
+func (h *QueueHandler) waitForConfirm(deliveryTag uint64) (string, error) {
+	timeout := time.After(h.confirmTimeout)
+	for {
+		select {
+		case confirm, ok := <-h.confirms:
+			if !ok {
+				return ReceiptFailed, fmt.Errorf("channel closed while awaiting publisher confirm")
+			}
+			if confirm.DeliveryTag < deliveryTag {
+				continue // Late confirm for an earlier, timed-out publish
+			}
+			if !confirm.Ack {
+				return ReceiptNacked, fmt.Errorf("broker rejected message (nack)")
+			}
+			return ReceiptConfirmed, nil
+		case <-timeout:
+			return ReceiptTimeout, fmt.Errorf("no publisher confirm within %v", h.confirmTimeout)
+		}
 	}
-	if h.conn != nil {
-		return h.conn.Close()
+}
+
+func (h *QueueHandler) Close() error {
+	h.receipts.Close()
+	err := h.disconnect()
+	if h.shared != nil {
+		if releaseErr := sharedConnections.release(h.shared); err == nil {
+			err = releaseErr
+		}
+		h.shared = nil
 	}
-	return nil
+	return err
 }
@@ -2,16 +2,32 @@ package output
 
 import (
 	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"csv2json/internal/converter"
+	"csv2json/internal/parser"
 )
 
+// decodeRecords decodes the rendered records a message carries
+func decodeRecords(t *testing.T, data json.RawMessage) []map[string]string {
+	t.Helper()
+	var records []map[string]string
+	if err := json.Unmarshal(data, &records); err != nil {
+		t.Fatalf("Failed to unmarshal message data %s: %v", data, err)
+	}
+	return records
+}
+
 // TestBuildMessageEnvelope_Structure validates the ADR-006 envelope structure
 func TestBuildMessageEnvelope_Structure(t *testing.T) {
 	handler := &QueueHandler{
 		routeName:         "test-route",
 		ingestionContract: "products.csv.v1",
-		includeEnvelope:   true,
+		payloadFormat:     PayloadEnvelope,
 		sourceFilePath:    "/data/input/products.csv",
 		queueName:         "products.inbound",
 		brokerURI:         "amqp://rabbitmq:5672/",
@@ -74,11 +90,12 @@ func TestBuildMessageEnvelope_Structure(t *testing.T) {
 	}
 
 	// Validate data payload
-	if len(envelope.Data) != 2 {
-		t.Errorf("Expected 2 data records, got %d", len(envelope.Data))
+	records := decodeRecords(t, envelope.Data)
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 data records, got %d", len(records))
 	}
-	if envelope.Data[0]["name"] != "Alice" {
-		t.Errorf("Expected first record name 'Alice', got '%s'", envelope.Data[0]["name"])
+	if records[0]["name"] != "Alice" {
+		t.Errorf("Expected first record name 'Alice', got '%s'", records[0]["name"])
 	}
 }
 
@@ -87,7 +104,7 @@ func TestBuildMessageEnvelope_EmptyData(t *testing.T) {
 	handler := &QueueHandler{
 		routeName:         "empty-route",
 		ingestionContract: "test.csv.v1",
-		includeEnvelope:   true,
+		payloadFormat:     PayloadEnvelope,
 		sourceFilePath:    "/data/input/empty.csv",
 		queueName:         "test.queue",
 		brokerURI:         "amqp://localhost:5672/",
@@ -107,11 +124,8 @@ func TestBuildMessageEnvelope_EmptyData(t *testing.T) {
 	}
 
 	// Data should be empty array, not null
-	if envelope.Data == nil {
-		t.Error("Data should be empty array, not null")
-	}
-	if len(envelope.Data) != 0 {
-		t.Errorf("Expected empty data array, got %d records", len(envelope.Data))
+	if string(envelope.Data) != "[]" {
+		t.Errorf("Expected empty data array, got %s", envelope.Data)
 	}
 
 	// Metadata should still be complete
@@ -128,7 +142,7 @@ func TestBuildMessageEnvelope_StringValues(t *testing.T) {
 	handler := &QueueHandler{
 		routeName:         "test-route",
 		ingestionContract: "types.csv.v1",
-		includeEnvelope:   true,
+		payloadFormat:     PayloadEnvelope,
 		sourceFilePath:    "/data/input/types.csv",
 		queueName:         "test.queue",
 		brokerURI:         "amqp://localhost:5672/",
@@ -150,7 +164,7 @@ func TestBuildMessageEnvelope_StringValues(t *testing.T) {
 	}
 
 	// Verify all values remain strings (no type coercion)
-	record := envelope.Data[0]
+	record := decodeRecords(t, envelope.Data)[0]
 	if record["number"] != "123" {
 		t.Errorf("Number should be string '123', got '%s'", record["number"])
 	}
@@ -181,7 +195,7 @@ func TestBuildMessageEnvelope_SourceFilename(t *testing.T) {
 			handler := &QueueHandler{
 				routeName:         "test-route",
 				ingestionContract: "test.csv.v1",
-				includeEnvelope:   true,
+				payloadFormat:     PayloadEnvelope,
 				sourceFilePath:    tc.fullPath,
 				queueName:         "test.queue",
 				brokerURI:         "amqp://localhost:5672/",
@@ -210,7 +224,7 @@ func TestBuildMessageEnvelope_TimestampRecent(t *testing.T) {
 	handler := &QueueHandler{
 		routeName:         "test-route",
 		ingestionContract: "test.csv.v1",
-		includeEnvelope:   true,
+		payloadFormat:     PayloadEnvelope,
 		sourceFilePath:    "/data/input/test.csv",
 		queueName:         "test.queue",
 		brokerURI:         "amqp://localhost:5672/",
@@ -248,9 +262,9 @@ func TestSetEnvelopeContext(t *testing.T) {
 	handler.SetEnvelopeContext(
 		"test-route",
 		"products.csv.v2",
-		"/data/input/products.csv",
-		true,
+		PayloadEnvelope,
 	)
+	handler.SetSourceFile("/data/input/products.csv")
 
 	if handler.routeName != "test-route" {
 		t.Errorf("Expected routeName 'test-route', got '%s'", handler.routeName)
@@ -261,8 +275,79 @@ func TestSetEnvelopeContext(t *testing.T) {
 	if handler.sourceFilePath != "/data/input/products.csv" {
 		t.Errorf("Expected sourceFilePath '/data/input/products.csv', got '%s'", handler.sourceFilePath)
 	}
-	if !handler.includeEnvelope {
-		t.Error("Expected includeEnvelope true")
+	if handler.payloadFormat != PayloadEnvelope {
+		t.Errorf("Expected payloadFormat '%s', got '%s'", PayloadEnvelope, handler.payloadFormat)
+	}
+}
+
+// TestSetSourceFileKeepsLegacyFormat validates per-file source updates do not re-enable the envelope
+func TestSetSourceFileKeepsLegacyFormat(t *testing.T) {
+	handler := &QueueHandler{}
+	handler.SetEnvelopeContext("legacy-route", "products.csv.v1", PayloadLegacy)
+	handler.SetSourceFile("/data/input/products.csv")
+
+	message, err := handler.buildMessageEnvelope([]map[string]string{{"sku": "ABC-1"}}, "products.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+	if strings.Contains(string(message), `"meta"`) {
+		t.Errorf("Expected legacy format without envelope, got %s", message)
+	}
+}
+
+// TestMessageEnvelope_IdempotencyKey validates keys are deterministic per file content, route and partition
+func TestMessageEnvelope_IdempotencyKey(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+	original := write("orders.csv", "id\n1\n")
+	replayed := write("orders-replay.csv", "id\n1\n")
+	changed := write("orders-changed.csv", "id\n2\n")
+
+	keyFor := func(route, path, partition string) string {
+		handler := &QueueHandler{routeName: route, payloadFormat: PayloadEnvelope, partition: partition}
+		handler.SetSourceFile(path)
+		message, err := handler.buildMessageEnvelope([]map[string]string{{"id": "1"}}, filepath.Base(path))
+		if err != nil {
+			t.Fatalf("buildMessageEnvelope failed: %v", err)
+		}
+		var envelope MessageEnvelope
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			t.Fatalf("Failed to unmarshal envelope: %v", err)
+		}
+		attrs, err := handler.messageAttributes(filepath.Base(path), nil, nil)
+		if err != nil {
+			t.Fatalf("messageAttributes failed: %v", err)
+		}
+		if attrs.IdempotencyKey != envelope.Meta.IdempotencyKey {
+			t.Errorf("Expected header key %q to match envelope key %q", attrs.IdempotencyKey, envelope.Meta.IdempotencyKey)
+		}
+		return envelope.Meta.IdempotencyKey
+	}
+
+	key := keyFor("orders", original, "")
+	if key == "" {
+		t.Fatal("Expected an idempotency key for a message with a source file")
+	}
+	if got := keyFor("orders", replayed, ""); got != key {
+		t.Errorf("Expected a replay of the same content to keep key %q, got %q", key, got)
+	}
+	for name, got := range map[string]string{
+		"changed content": keyFor("orders", changed, ""),
+		"other route":     keyFor("archive", original, ""),
+		"partition":       keyFor("orders", original, "EU"),
+	} {
+		if got == key {
+			t.Errorf("Expected %s to change the idempotency key", name)
+		}
+	}
+	if got := keyFor("orders", "", ""); got != "" {
+		t.Errorf("Expected no idempotency key without a source file, got %q", got)
 	}
 }
 
@@ -271,7 +356,7 @@ func BenchmarkBuildMessageEnvelope(b *testing.B) {
 	handler := &QueueHandler{
 		routeName:         "benchmark-route",
 		ingestionContract: "products.csv.v1",
-		includeEnvelope:   true,
+		payloadFormat:     PayloadEnvelope,
 		sourceFilePath:    "/data/input/products.csv",
 		queueName:         "products.inbound",
 		brokerURI:         "amqp://rabbitmq:5672/",
@@ -298,7 +383,7 @@ func BenchmarkBuildMessageEnvelope_LargePayload(b *testing.B) {
 	handler := &QueueHandler{
 		routeName:         "benchmark-route",
 		ingestionContract: "products.csv.v1",
-		includeEnvelope:   true,
+		payloadFormat:     PayloadEnvelope,
 		sourceFilePath:    "/data/input/products.csv",
 		queueName:         "products.inbound",
 		brokerURI:         "amqp://rabbitmq:5672/",
@@ -324,3 +409,304 @@ func BenchmarkBuildMessageEnvelope_LargePayload(b *testing.B) {
 		}
 	}
 }
+
+// TestBuildMessageEnvelope_ASCIISafe validates non-ASCII escaping of message bodies
+func TestBuildMessageEnvelope_ASCIISafe(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope}
+	handler.applyOptions(Options{ASCIISafe: true})
+
+	data := []map[string]string{{"city": "Zürich"}}
+
+	message, err := handler.buildMessageEnvelope(data, "cities.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+
+	for _, b := range message {
+		if b >= 0x80 {
+			t.Fatalf("Expected pure ASCII message, found byte 0x%x", b)
+		}
+	}
+
+	var envelope MessageEnvelope
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+	if city := decodeRecords(t, envelope.Data)[0]["city"]; city != "Zürich" {
+		t.Errorf("Expected city 'Zürich' after decoding, got %q", city)
+	}
+}
+
+// TestBuildMessageEnvelope_ContractVersion validates the enforced contract version is recorded only when set
+func TestBuildMessageEnvelope_ContractVersion(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope, ingestionContract: "products.csv.v1"}
+	data := []map[string]string{{"sku": "ABC-1"}}
+
+	message, err := handler.buildMessageEnvelope(data, "products.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+	if strings.Contains(string(message), "contractVersion") {
+		t.Errorf("Expected no contractVersion for an unenforced contract, got %s", message)
+	}
+
+	handler.SetContractVersion("1.2.0")
+	message, err = handler.buildMessageEnvelope(data, "products.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+	var envelope MessageEnvelope
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+	if envelope.Meta.ContractVersion != "1.2.0" {
+		t.Errorf("Expected contractVersion '1.2.0', got %q", envelope.Meta.ContractVersion)
+	}
+}
+
+// TestBuildMessageEnvelope_Tenant validates the tenant is recorded only for tenant-scoped routes
+func TestBuildMessageEnvelope_Tenant(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope, ingestionContract: "orders.csv.v1"}
+	data := []map[string]string{{"order_id": "1"}}
+
+	message, err := handler.buildMessageEnvelope(data, "orders.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+	if strings.Contains(string(message), "tenant") {
+		t.Errorf("Expected no tenant without a tenant-scoped route, got %s", message)
+	}
+
+	if err := handler.applyOptions(Options{Tenant: "acme"}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	message, err = handler.buildMessageEnvelope(data, "orders.csv")
+	if err != nil {
+		t.Fatalf("buildMessageEnvelope failed: %v", err)
+	}
+	var envelope MessageEnvelope
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+	if envelope.Meta.Tenant != "acme" {
+		t.Errorf("Expected tenant acme, got %q", envelope.Meta.Tenant)
+	}
+}
+
+// TestBuildMessageEnvelope_Location validates the ingestion timestamp is UTC by default
+// and in the configured time zone when one is set
+func TestBuildMessageEnvelope_Location(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope, ingestionContract: "orders.csv.v1"}
+	data := []map[string]string{{"order_id": "1"}}
+
+	for _, tc := range []struct {
+		loc    *time.Location
+		suffix string
+	}{
+		{nil, "Z"},
+		{time.FixedZone("UTC-5", -5*60*60), "-05:00"},
+	} {
+		if err := handler.applyOptions(Options{Location: tc.loc}); err != nil {
+			t.Fatalf("applyOptions failed: %v", err)
+		}
+		message, err := handler.buildMessageEnvelope(data, "orders.csv")
+		if err != nil {
+			t.Fatalf("buildMessageEnvelope failed: %v", err)
+		}
+		var envelope MessageEnvelope
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			t.Fatalf("Failed to unmarshal envelope: %v", err)
+		}
+		if !strings.HasSuffix(envelope.Meta.Ingestion.Timestamp, tc.suffix) {
+			t.Errorf("Expected timestamp ending %s, got %s", tc.suffix, envelope.Meta.Ingestion.Timestamp)
+		}
+	}
+}
+
+// TestBuildMessageEnvelope_BusinessDate validates the business date is carried in the
+// envelope metadata only once it is set
+func TestBuildMessageEnvelope_BusinessDate(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope, ingestionContract: "orders.csv.v1"}
+	data := []map[string]string{{"order_id": "1"}}
+
+	for _, date := range []string{"", "2026-03-31"} {
+		handler.SetBusinessDate(date)
+		message, err := handler.buildMessageEnvelope(data, "orders.csv")
+		if err != nil {
+			t.Fatalf("buildMessageEnvelope failed: %v", err)
+		}
+		var envelope MessageEnvelope
+		if err := json.Unmarshal(message, &envelope); err != nil {
+			t.Fatalf("Failed to unmarshal envelope: %v", err)
+		}
+		if envelope.Meta.BusinessDate != date {
+			t.Errorf("Expected business date %q, got %q", date, envelope.Meta.BusinessDate)
+		}
+		if date == "" && strings.Contains(string(message), "businessDate") {
+			t.Error("Expected no businessDate field without a business date")
+		}
+	}
+}
+
+// TestBuildNestedMessage validates grouped (nested) data is embedded in the envelope in order
+func TestBuildNestedMessage(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope, ingestionContract: "orders.v1"}
+
+	dataJSON := []byte(`[{"order_id": "O1", "items": [{"sku": "A"}, {"sku": "B"}]}]`)
+
+	message, err := handler.buildMessage(dataJSON, "orders.csv", nil)
+	if err != nil {
+		t.Fatalf("buildNestedMessage failed: %v", err)
+	}
+
+	expectedData := `"data":[{"order_id":"O1","items":[{"sku":"A"},{"sku":"B"}]}]`
+	if !strings.Contains(string(message), expectedData) {
+		t.Errorf("Expected message to contain %s, got %s", expectedData, message)
+	}
+
+	var envelope struct {
+		Meta MessageMeta `json:"meta"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		t.Fatalf("Failed to unmarshal envelope: %v", err)
+	}
+	if envelope.Meta.IngestionContract != "orders.v1" {
+		t.Errorf("Expected ingestionContract 'orders.v1', got %q", envelope.Meta.IngestionContract)
+	}
+}
+
+// TestRenderBare validates bare messages are exactly the converter output, without a wrapper
+func TestRenderBare(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadBare, converter: converter.New()}
+	handler.SetEnvelopeContext("orders", "orders.v1", PayloadBare)
+
+	result := &parser.ParseResult{
+		Headers: []string{"sku", "qty"},
+		Rows:    []parser.OrderedMap{{Keys: []string{"sku", "qty"}, Values: map[string]string{"sku": "A", "qty": "2"}}},
+	}
+	want, err := converter.New().ToJSONOrdered(result)
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+	message, err := handler.Render(result, "orders.csv")
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if string(message) != string(want) {
+		t.Errorf("Expected the converter output %s, got %s", want, message)
+	}
+
+	// Unordered and nested data are published as the array alone too
+	message, err = handler.buildMessageEnvelope([]map[string]string{{"sku": "A"}}, "orders.csv")
+	if err != nil || string(message) != `[{"sku":"A"}]` {
+		t.Errorf("Expected a bare array, got %s (%v)", message, err)
+	}
+	dataJSON := []byte(`[{"order_id": "O1", "items": [{"sku": "A"}]}]`)
+	message, err = handler.buildMessage(dataJSON, "orders.csv", nil)
+	if err != nil || string(message) != string(dataJSON) {
+		t.Errorf("Expected the nested data as rendered, got %s (%v)", message, err)
+	}
+}
+
+// TestRenderOrderedObject validates single-row object output is carried as the envelope's data
+func TestRenderOrderedObject(t *testing.T) {
+	handler := &QueueHandler{payloadFormat: PayloadEnvelope, ingestionContract: "status.v1"}
+	if err := handler.applyOptions(Options{Shape: converter.ShapeObject}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	result := &parser.ParseResult{
+		Headers: []string{"id", "state"},
+		Rows:    []parser.OrderedMap{{Keys: []string{"id", "state"}, Values: map[string]string{"id": "1", "state": "ready"}}},
+	}
+
+	message, _, err := handler.renderOrdered(result, "status.csv")
+	if err != nil {
+		t.Fatalf("renderOrdered failed: %v", err)
+	}
+	if expected := `"data":{"id":"1","state":"ready"}`; !strings.Contains(string(message), expected) {
+		t.Errorf("Expected message to contain %s, got %s", expected, message)
+	}
+}
+
+// TestLegacyIdentifierTemplate validates legacy messages carry the templated identifier
+// in flat and nested messages, and the bare filename without a template
+func TestLegacyIdentifierTemplate(t *testing.T) {
+	handler := &QueueHandler{}
+	if err := handler.applyOptions(Options{}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	handler.SetEnvelopeContext("orders-route", "orders.csv.v1", PayloadLegacy)
+	result := &parser.ParseResult{
+		Headers: []string{"id"},
+		Rows:    []parser.OrderedMap{{Keys: []string{"id"}, Values: map[string]string{"id": "1"}}},
+	}
+
+	identifier := func(message []byte) string {
+		var msg struct {
+			Identifier string `json:"identifier"`
+		}
+		if err := json.Unmarshal(message, &msg); err != nil {
+			t.Fatalf("Failed to unmarshal message: %v", err)
+		}
+		return msg.Identifier
+	}
+
+	message, _, err := handler.renderOrdered(result, "orders.csv")
+	if err != nil {
+		t.Fatalf("renderOrdered failed: %v", err)
+	}
+	if got := identifier(message); got != "orders.csv" {
+		t.Errorf("Expected the filename by default, got %q", got)
+	}
+
+	if err := handler.applyOptions(Options{LegacyIdentifier: "{route}/{filename}#{col:id}"}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	if message, _, err = handler.renderOrdered(result, "orders.csv"); err != nil {
+		t.Fatalf("renderOrdered failed: %v", err)
+	}
+	if got := identifier(message); got != "orders-route/orders.csv#1" {
+		t.Errorf("Expected the templated identifier, got %q", got)
+	}
+	nested, err := handler.buildMessage([]byte(`[{"id":"1"}]`), "orders.csv", result.Rows[0].Values)
+	if err != nil {
+		t.Fatalf("buildNestedMessage failed: %v", err)
+	}
+	if got := identifier(nested); got != "orders-route/orders.csv#1" {
+		t.Errorf("Expected the templated identifier in nested messages, got %q", got)
+	}
+
+	if err := handler.applyOptions(Options{LegacyIdentifier: "{dataHash}"}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	if message, _, err = handler.renderOrdered(result, "orders.csv"); err != nil {
+		t.Fatalf("renderOrdered failed: %v", err)
+	}
+	if got := identifier(message); len(got) != 64 {
+		t.Errorf("Expected a SHA-256 identifier, got %q", got)
+	}
+}
+
+// TestRenderOrderedFieldOrder validates envelope and legacy messages keep the CSV column
+// order of the rendered records instead of sorting their fields
+func TestRenderOrderedFieldOrder(t *testing.T) {
+	result := &parser.ParseResult{
+		Headers: []string{"zone", "amount", "id"},
+		Rows:    []parser.OrderedMap{{Keys: []string{"zone", "amount", "id"}, Values: map[string]string{"zone": "EU", "amount": "9.99", "id": "1"}}},
+	}
+	for _, format := range []string{PayloadEnvelope, PayloadLegacy} {
+		handler := &QueueHandler{}
+		if err := handler.applyOptions(Options{}); err != nil {
+			t.Fatalf("applyOptions failed: %v", err)
+		}
+		handler.SetEnvelopeContext("orders", "orders.csv.v1", format)
+
+		message, _, err := handler.renderOrdered(result, "orders.csv")
+		if err != nil {
+			t.Fatalf("%s: renderOrdered failed: %v", format, err)
+		}
+		if expected := `"data":[{"zone":"EU","amount":"9.99","id":"1"}]`; !strings.Contains(string(message), expected) {
+			t.Errorf("%s: expected message to contain %s, got %s", format, expected, message)
+		}
+	}
+}
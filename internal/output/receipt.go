@@ -0,0 +1,126 @@
+package output
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Delivery receipt statuses
+const (
+	ReceiptWritten   = "written"   // File output written
+	ReceiptPublished = "published" // Accepted by the client library (publisher confirms disabled)
+	ReceiptConfirmed = "confirmed" // Broker confirmed the message (publisher confirms enabled)
+	ReceiptNacked    = "nacked"    // Broker rejected the message
+	ReceiptTimeout   = "timeout"   // No broker confirmation within the timeout
+	ReceiptFailed    = "failed"    // Write or publish failed
+	ReceiptAcked     = "acked"     // Downstream consumer acknowledged ingestion (DOWNSTREAM_ACK)
+	ReceiptRejected  = "rejected"  // Downstream consumer reported an ingestion error
+)
+
+// Receipt records the delivery of one output message or file
+type Receipt struct {
+	Timestamp   string `json:"timestamp"`
+	MessageID   string `json:"messageId,omitempty"`
+	Route       string `json:"route,omitempty"`
+	SourceFile  string `json:"sourceFile"`
+	Output      string `json:"output"`      // "file" or queue type
+	Destination string `json:"destination"` // Output path or queue name
+	Exchange    string `json:"exchange,omitempty"`
+	RoutingKey  string `json:"routingKey,omitempty"`
+	Rows        int    `json:"rows"`
+	Bytes       int    `json:"bytes"`
+	Status      string `json:"status"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ReceiptLog appends delivery receipts to a newline-delimited JSON file
+type ReceiptLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewReceiptLog opens (or creates) the receipt log at path for appending
+func NewReceiptLog(path string) (*ReceiptLog, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create receipt log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open receipt log: %w", err)
+	}
+	return &ReceiptLog{file: file}, nil
+}
+
+// Record appends a receipt as a single JSON line. A nil log records nothing.
+func (l *ReceiptLog) Record(r Receipt) error {
+	if l == nil {
+		return nil
+	}
+	if r.Timestamp == "" {
+		r.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return fmt.Errorf("receipt log is closed")
+	}
+	_, err = l.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the log; it is safe to call more than once
+func (l *ReceiptLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// ReadReceipts calls fn with each receipt in the receipt log at path, in the order they
+// were recorded. Lines that are not valid receipts (e.g. torn by a crash) are skipped.
+func ReadReceipts(path string, fn func(Receipt)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var r Receipt
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue
+		}
+		fn(r)
+	}
+	return scanner.Err()
+}
+
+// newMessageID returns a random 128-bit identifier in hex
+func newMessageID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
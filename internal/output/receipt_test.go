@@ -0,0 +1,137 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/parser"
+)
+
+// readReceipts reads every receipt line from an NDJSON receipt log
+func readReceipts(t *testing.T, path string) []Receipt {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open receipt log: %v", err)
+	}
+	defer file.Close()
+
+	var receipts []Receipt
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r Receipt
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("Receipt line is not valid JSON: %v", err)
+		}
+		receipts = append(receipts, r)
+	}
+	return receipts
+}
+
+// TestReceiptLogRecord validates receipts are appended as JSON lines and Close is idempotent
+func TestReceiptLogRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "receipts.ndjson")
+	log, err := NewReceiptLog(path)
+	if err != nil {
+		t.Fatalf("Failed to create receipt log: %v", err)
+	}
+
+	for _, status := range []string{ReceiptPublished, ReceiptConfirmed} {
+		if err := log.Record(Receipt{MessageID: newMessageID(), SourceFile: "a.csv", Status: status}); err != nil {
+			t.Fatalf("Failed to record receipt: %v", err)
+		}
+	}
+	if err := log.Close(); err != nil {
+		t.Fatalf("Failed to close receipt log: %v", err)
+	}
+	if err := log.Close(); err != nil {
+		t.Errorf("Expected second Close to succeed, got: %v", err)
+	}
+	if err := log.Record(Receipt{}); err == nil {
+		t.Error("Expected error recording to a closed log, got success")
+	}
+
+	receipts := readReceipts(t, path)
+	if len(receipts) != 2 {
+		t.Fatalf("Expected 2 receipts, got %d", len(receipts))
+	}
+	if receipts[1].Status != ReceiptConfirmed {
+		t.Errorf("Expected status %q, got %q", ReceiptConfirmed, receipts[1].Status)
+	}
+	if receipts[0].Timestamp == "" || len(receipts[0].MessageID) != 32 {
+		t.Errorf("Expected timestamp and 32-char message ID, got %+v", receipts[0])
+	}
+
+	var nilLog *ReceiptLog
+	if err := nilLog.Record(Receipt{}); err != nil {
+		t.Errorf("Expected nil log to ignore receipts, got: %v", err)
+	}
+}
+
+// TestReadReceipts validates receipts are read back in order, skipping torn lines
+func TestReadReceipts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "receipts.ndjson")
+	content := `{"sourceFile":"a.csv","rows":2,"status":"written"}
+{"sourceFile":"b.csv","rows":1,"status":"failed"}
+{"sourceFile":"c.cs`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write receipt log: %v", err)
+	}
+
+	var files []string
+	if err := ReadReceipts(path, func(r Receipt) { files = append(files, r.SourceFile+":"+r.Status) }); err != nil {
+		t.Fatalf("ReadReceipts failed: %v", err)
+	}
+	if len(files) != 2 || files[0] != "a.csv:written" || files[1] != "b.csv:failed" {
+		t.Errorf("Unexpected receipts: %v", files)
+	}
+	if err := ReadReceipts(filepath.Join(t.TempDir(), "missing.ndjson"), func(Receipt) {}); !os.IsNotExist(err) {
+		t.Errorf("Expected a not-exist error for a missing log, got: %v", err)
+	}
+}
+
+// TestFileHandlerReceipts validates the file handler records a receipt per written file
+func TestFileHandlerReceipts(t *testing.T) {
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "receipts.ndjson")
+	receipts, err := NewReceiptLog(logPath)
+	if err != nil {
+		t.Fatalf("Failed to create receipt log: %v", err)
+	}
+
+	h := NewFileHandler(dir)
+	h.receipts = receipts
+	h.SetEnvelopeContext("orders", "orders.csv.v1", PayloadLegacy)
+
+	result := &parser.ParseResult{
+		Headers: []string{"id"},
+		Rows: []parser.OrderedMap{
+			{Keys: []string{"id"}, Values: map[string]string{"id": "1"}},
+			{Keys: []string{"id"}, Values: map[string]string{"id": "2"}},
+		},
+	}
+	if err := h.SendOrdered(result, "orders.csv"); err != nil {
+		t.Fatalf("SendOrdered failed: %v", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	got := readReceipts(t, logPath)
+	if len(got) != 1 {
+		t.Fatalf("Expected 1 receipt, got %d", len(got))
+	}
+	r := got[0]
+	if r.Route != "orders" || r.SourceFile != "orders.csv" || r.Output != "file" {
+		t.Errorf("Unexpected receipt identity: %+v", r)
+	}
+	if r.Rows != 2 || r.Bytes == 0 || r.Status != ReceiptWritten {
+		t.Errorf("Unexpected receipt delivery fields: %+v", r)
+	}
+	if r.Destination != filepath.Join(dir, "orders.json") {
+		t.Errorf("Expected destination %q, got %q", filepath.Join(dir, "orders.json"), r.Destination)
+	}
+}
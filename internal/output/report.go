@@ -0,0 +1,79 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Report summarises the processing of one file for ingestion dashboards
+type Report struct {
+	Timestamp   string `json:"timestamp"`
+	Route       string `json:"route"`
+	File        string `json:"file"`
+	Status      string `json:"status"`      // Archive category: "processed", "failed", or "ignored"
+	Rows        int    `json:"rows"`        // Records delivered to the output
+	Rejects     int    `json:"rejects"`     // Parsed rows of a failed file (files fail as a whole)
+	Duplicates  int    `json:"duplicates"`  // Duplicate rows removed by deduplication (DEDUP_KEYS)
+	DurationMs  int64  `json:"durationMs"`  // From pickup to archiving
+	Destination string `json:"destination"` // Where the data was sent (output folder and/or queue)
+	Error       string `json:"error,omitempty"`
+}
+
+// Reporter publishes processing reports to a queue or writes them to a folder
+type Reporter struct {
+	mu     sync.Mutex
+	folder string        // Report folder ("" when publishing to a queue)
+	queue  *QueueHandler // Report queue (nil when writing to a folder)
+}
+
+// NewReporter creates a reporter for destination. "<queueType>://<queue>" (e.g.
+// rabbitmq://ingest_reports) publishes to a queue on the given broker and vhost ("" =
+// default); any other value is a folder that receives one <file>_<timestamp>.report.json per file.
+func NewReporter(destination, host string, port int, vhost, username, password string) (*Reporter, error) {
+	queueType, queueName, isQueue := strings.Cut(destination, "://")
+	if !isQueue {
+		if err := os.MkdirAll(destination, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create report folder: %w", err)
+		}
+		return &Reporter{folder: destination}, nil
+	}
+
+	queue, err := newQueueHandler(queueType, host, port, vhost, queueName, username, password, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect report queue: %w", err)
+	}
+	return &Reporter{queue: queue}, nil
+}
+
+// Send publishes or writes one report
+func (r *Reporter) Send(report Report) error {
+	if report.Timestamp == "" {
+		report.Timestamp = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.queue != nil {
+		return r.queue.publish(data, messageAttributes{SourceFile: report.File})
+	}
+	base := strings.TrimSuffix(report.File, filepath.Ext(report.File))
+	name := fmt.Sprintf("%s_%s.report.json", base, time.Now().UTC().Format("20060102T150405.000Z"))
+	return os.WriteFile(filepath.Join(r.folder, name), data, 0644)
+}
+
+// Close closes the report queue connection
+func (r *Reporter) Close() error {
+	if r == nil || r.queue == nil {
+		return nil
+	}
+	return r.queue.Close()
+}
@@ -0,0 +1,57 @@
+package output
+
+import (
+	"hash/fnv"
+)
+
+// shardSelector distributes messages across several queues
+type shardSelector struct {
+	queues []string
+	column string // Column hashed to choose the queue ("" = round-robin)
+	next   int    // Round-robin position
+}
+
+// pick returns the queue for a message whose first row is row. Hashing keeps every
+// message with the same column value on the same queue, so per-key ordering holds.
+func (s *shardSelector) pick(row map[string]string) string {
+	if s.column != "" {
+		h := fnv.New32a()
+		h.Write([]byte(row[s.column]))
+		return s.queues[h.Sum32()%uint32(len(s.queues))]
+	}
+	queue := s.queues[s.next]
+	s.next = (s.next + 1) % len(s.queues)
+	return queue
+}
+
+// selectShard points the handler at the shard queue for a message whose first row is
+// row, or at the corrections queue for a correction, returning a function that restores
+// the configured queue name
+func (h *QueueHandler) selectShard(row map[string]string) func() {
+	queueName := h.queueName
+	switch {
+	case h.correction:
+		h.queueName = h.correctionsQueue
+	case h.shards != nil:
+		h.queueName = h.shards.pick(row)
+	default:
+		return func() {}
+	}
+	return func() { h.queueName = queueName }
+}
+
+// SetCorrection publishes the messages that follow to the corrections queue, marked as
+// corrections in their envelopes, until it is cleared
+func (h *QueueHandler) SetCorrection(correction bool) {
+	h.correction = correction && h.correctionsQueue != ""
+}
+
+// declareShards declares every shard queue
+func (h *QueueHandler) declareShards() error {
+	for _, queue := range h.shards.queues {
+		if err := h.declareQueue(queue); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"testing"
+)
+
+// TestShardSelectorRoundRobin validates messages cycle through the shard queues in order
+func TestShardSelectorRoundRobin(t *testing.T) {
+	s := &shardSelector{queues: []string{"orders.0", "orders.1", "orders.2"}}
+	expected := []string{"orders.0", "orders.1", "orders.2", "orders.0"}
+	for i, queue := range expected {
+		if got := s.pick(nil); got != queue {
+			t.Errorf("Message %d: expected %s, got %s", i, queue, got)
+		}
+	}
+}
+
+// TestShardSelectorHash validates equal column values always map to the same shard
+func TestShardSelectorHash(t *testing.T) {
+	s := &shardSelector{queues: []string{"orders.0", "orders.1", "orders.2"}, column: "account_id"}
+	used := make(map[string]bool)
+	for _, account := range []string{"A1", "B2", "C3", "D4", "E5", "F6", "G7", "H8"} {
+		row := map[string]string{"account_id": account}
+		queue := s.pick(row)
+		if again := s.pick(row); again != queue {
+			t.Errorf("Account %s: expected stable shard %s, got %s", account, queue, again)
+		}
+		used[queue] = true
+	}
+	if len(used) < 2 {
+		t.Errorf("Expected accounts to spread over several shards, got %v", used)
+	}
+}
+
+// TestSelectShardRestoresQueueName validates the configured queue name is restored after a message
+func TestSelectShardRestoresQueueName(t *testing.T) {
+	h := &QueueHandler{queueName: "orders.0", shards: &shardSelector{queues: []string{"orders.0", "orders.1"}}}
+
+	restore := h.selectShard(nil)
+	restore()
+	restore = h.selectShard(nil)
+	if h.queueName != "orders.1" {
+		t.Errorf("Expected second message on orders.1, got %s", h.queueName)
+	}
+	restore()
+	if h.queueName != "orders.0" {
+		t.Errorf("Expected queue name restored to orders.0, got %s", h.queueName)
+	}
+}
+
+// TestSelectShardCorrection validates corrections go to the corrections queue, bypassing
+// shards, and are marked in the envelope
+func TestSelectShardCorrection(t *testing.T) {
+	h := &QueueHandler{queueName: "orders.0", shards: &shardSelector{queues: []string{"orders.0", "orders.1"}}}
+
+	h.SetCorrection(true)
+	if h.correction {
+		t.Error("Expected no correction without a corrections queue")
+	}
+
+	h.correctionsQueue = "orders.corrections"
+	h.SetCorrection(true)
+	restore := h.selectShard(nil)
+	if h.queueName != "orders.corrections" {
+		t.Errorf("Expected the correction on orders.corrections, got %s", h.queueName)
+	}
+	if meta := h.buildMessageMeta("orders.csv"); !meta.Correction || meta.Source.Queue != "orders.corrections" {
+		t.Errorf("Expected correction metadata for orders.corrections, got %+v", meta)
+	}
+	restore()
+
+	h.SetCorrection(false)
+	restore = h.selectShard(nil)
+	if h.queueName != "orders.0" {
+		t.Errorf("Expected the next message on orders.0, got %s", h.queueName)
+	}
+	restore()
+}
@@ -0,0 +1,109 @@
+package output
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// Signing algorithms
+const (
+	SigningHMACSHA256 = "hmac-sha256" // Shared secret; consumers verify with the same key
+	SigningEd25519    = "ed25519"     // Private key signs; consumers verify with the public key
+)
+
+// Message headers carrying a payload signature
+const (
+	HeaderSignature          = "x-signature"           // Base64 signature of the published body
+	HeaderSignatureAlgorithm = "x-signature-algorithm" // SigningHMACSHA256 or SigningEd25519
+	HeaderSignatureKeyID     = "x-signature-key-id"    // Key the body was signed with, so keys can be rotated
+)
+
+// minHMACKeySize is the shortest HMAC key accepted, in bytes
+const minHMACKeySize = 16
+
+// PayloadSigner signs published message bodies exactly as they are sent (after
+// encryption, if enabled), so consumers verify before decrypting or parsing.
+type PayloadSigner struct {
+	algorithm  string
+	keyID      string
+	hmacKey    []byte
+	privateKey ed25519.PrivateKey
+}
+
+// NewPayloadSigner creates a signer from a base64 key: an HMAC secret of at least 16
+// bytes, or an Ed25519 32-byte seed or 64-byte private key
+func NewPayloadSigner(algorithm, key, keyID string) (*PayloadSigner, error) {
+	if keyID == "" {
+		return nil, fmt.Errorf("signing key ID must be set")
+	}
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, fmt.Errorf("signing key is not valid base64: %w", err)
+	}
+
+	signer := &PayloadSigner{algorithm: algorithm, keyID: keyID}
+	switch algorithm {
+	case SigningHMACSHA256:
+		if len(raw) < minHMACKeySize {
+			return nil, fmt.Errorf("HMAC signing key must be at least %d bytes, got %d", minHMACKeySize, len(raw))
+		}
+		signer.hmacKey = raw
+	case SigningEd25519:
+		switch len(raw) {
+		case ed25519.SeedSize:
+			signer.privateKey = ed25519.NewKeyFromSeed(raw)
+		case ed25519.PrivateKeySize:
+			signer.privateKey = ed25519.PrivateKey(raw)
+		default:
+			return nil, fmt.Errorf("Ed25519 signing key must be a %d-byte seed or %d-byte private key, got %d bytes", ed25519.SeedSize, ed25519.PrivateKeySize, len(raw))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm: %s (supported: %s, %s)", algorithm, SigningHMACSHA256, SigningEd25519)
+	}
+	return signer, nil
+}
+
+// Algorithm returns the signing algorithm
+func (s *PayloadSigner) Algorithm() string {
+	return s.algorithm
+}
+
+// KeyID returns the identifier of the signing key
+func (s *PayloadSigner) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the base64 Ed25519 public key consumers verify with ("" for HMAC)
+func (s *PayloadSigner) PublicKey() string {
+	if s.privateKey == nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(s.privateKey.Public().(ed25519.PublicKey))
+}
+
+// Sign returns the base64 signature of body
+func (s *PayloadSigner) Sign(body []byte) string {
+	if s.privateKey != nil {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(s.privateKey, body))
+	}
+	mac := hmac.New(sha256.New, s.hmacKey)
+	mac.Write(body)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is a valid base64 signature of body under this key
+func (s *PayloadSigner) Verify(body []byte, signature string) bool {
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	if s.privateKey != nil {
+		return ed25519.Verify(s.privateKey.Public().(ed25519.PublicKey), body, raw)
+	}
+	mac := hmac.New(sha256.New, s.hmacKey)
+	mac.Write(body)
+	return hmac.Equal(raw, mac.Sum(nil))
+}
@@ -0,0 +1,85 @@
+package output
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+)
+
+// TestPayloadSigner validates signatures verify for the signed body only
+func TestPayloadSigner(t *testing.T) {
+	testCases := []struct {
+		algorithm string
+		key       []byte
+	}{
+		{SigningHMACSHA256, bytes.Repeat([]byte{0x01}, 32)},
+		{SigningEd25519, bytes.Repeat([]byte{0x02}, ed25519.SeedSize)},
+		{SigningEd25519, ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x03}, ed25519.SeedSize))},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.algorithm, func(t *testing.T) {
+			signer, err := NewPayloadSigner(tc.algorithm, base64.StdEncoding.EncodeToString(tc.key), "2024-01")
+			if err != nil {
+				t.Fatalf("Failed to create signer: %v", err)
+			}
+			body := []byte(`{"data":[{"amount":"100.00"}]}`)
+			signature := signer.Sign(body)
+
+			if !signer.Verify(body, signature) {
+				t.Error("Expected signature to verify")
+			}
+			if signer.Verify([]byte(`{"data":[{"amount":"999.00"}]}`), signature) {
+				t.Error("Expected signature not to verify a tampered body")
+			}
+			if signer.Verify(body, "not base64!") {
+				t.Error("Expected malformed signature not to verify")
+			}
+		})
+	}
+}
+
+// TestPayloadSignerPublicKey validates consumers can verify Ed25519 signatures with the public key alone
+func TestPayloadSignerPublicKey(t *testing.T) {
+	signer, err := NewPayloadSigner(SigningEd25519, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x04}, ed25519.SeedSize)), "k1")
+	if err != nil {
+		t.Fatalf("Failed to create signer: %v", err)
+	}
+	body := []byte("payload")
+	publicKey, _ := base64.StdEncoding.DecodeString(signer.PublicKey())
+	signature, _ := base64.StdEncoding.DecodeString(signer.Sign(body))
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), body, signature) {
+		t.Error("Expected signature to verify with the public key")
+	}
+
+	hmacSigner, _ := NewPayloadSigner(SigningHMACSHA256, base64.StdEncoding.EncodeToString(make([]byte, 16)), "k1")
+	if hmacSigner.PublicKey() != "" {
+		t.Error("Expected no public key for HMAC signing")
+	}
+}
+
+// TestNewPayloadSignerInvalid validates algorithm, key and key ID checks
+func TestNewPayloadSignerInvalid(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+	testCases := []struct {
+		name      string
+		algorithm string
+		key       string
+		keyID     string
+	}{
+		{"missing key ID", SigningHMACSHA256, key, ""},
+		{"unknown algorithm", "rsa", key, "k1"},
+		{"short HMAC key", SigningHMACSHA256, base64.StdEncoding.EncodeToString(make([]byte, 8)), "k1"},
+		{"wrong Ed25519 size", SigningEd25519, base64.StdEncoding.EncodeToString(make([]byte, 48)), "k1"},
+		{"not base64", SigningHMACSHA256, "secret!", "k1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewPayloadSigner(tc.algorithm, tc.key, tc.keyID); err == nil {
+				t.Error("Expected error, got success")
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Spill is a file's output rendered chunk by chunk into a temporary file when the
+// file is too large to convert in memory (MEMORY_LIMIT_MB)
+type Spill struct {
+	Path     string            // Temporary file holding the rendered JSON array
+	Rows     int               // Rows in the array
+	Bytes    int64             // Size of the rendered array
+	FirstRow map[string]string // First row, for message attribute templates
+}
+
+// SpillSender is implemented by handlers that can send a spilled file without
+// loading its rows back into memory
+type SpillSender interface {
+	SendSpill(spill *Spill, identifier string) error
+}
+
+// SendSpill copies the spilled JSON to the output file
+func (h *FileHandler) SendSpill(spill *Spill, identifier string) error {
+	folder, err := h.folder()
+	if err != nil {
+		return err
+	}
+	return h.write(outputPath(folder, identifier, ""), identifier, spill.Rows, func(w io.Writer) (int64, error) {
+		in, err := os.Open(spill.Path)
+		if err != nil {
+			return 0, err
+		}
+		defer in.Close()
+		return io.Copy(w, in)
+	})
+}
+
+// SendSpill publishes the spilled JSON as one message. Only the rendered array is
+// read back, never the parsed rows.
+func (h *QueueHandler) SendSpill(spill *Spill, identifier string) error {
+	dataJSON, err := os.ReadFile(spill.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read spilled output: %w", err)
+	}
+
+	defer h.selectShard(spill.FirstRow)()
+
+	attrs, err := h.messageAttributes(identifier, spill.FirstRow, dataJSON)
+	if err != nil {
+		return err
+	}
+	attrs.Rows = spill.Rows
+
+	message, err := h.buildMessage(dataJSON, identifier, spill.FirstRow)
+	if err != nil {
+		return fmt.Errorf("failed to build message envelope: %w", err)
+	}
+	return h.publish(message, attrs)
+}
+
+// SendSpill writes the spilled JSON to file, then publishes it to the queue
+func (h *BothHandler) SendSpill(spill *Spill, identifier string) error {
+	if err := sendSpill(h.fileHandler, spill, identifier); err != nil {
+		return fmt.Errorf("file output failed: %w", err)
+	}
+
+	if err := sendSpill(h.queueHandler, spill, identifier); err != nil {
+		return fmt.Errorf("queue output failed: %w", err)
+	}
+
+	return nil
+}
+
+// SendSpill writes the spilled JSON as one stream record. The record is a single
+// line, so the rendered array is read back whole.
+func (h *StreamHandler) SendSpill(spill *Spill, identifier string) error {
+	dataJSON, err := os.ReadFile(spill.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read spilled output: %w", err)
+	}
+	return h.write(dataJSON, identifier, "", spill.Rows)
+}
+
+// sendSpill sends a spilled file through handler if it supports spilled output
+func sendSpill(handler Handler, spill *Spill, identifier string) error {
+	ss, ok := handler.(SpillSender)
+	if !ok {
+		return fmt.Errorf("output handler does not support spilled output")
+	}
+	return ss.SendSpill(spill, identifier)
+}
@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bytes"
+	"csv2json/internal/converter"
+	"csv2json/internal/parser"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// StdoutDestination is the destination recorded for stdout output
+const StdoutDestination = "stdout"
+
+// stdoutMu serializes lines written to stdout, which routes share
+var stdoutMu sync.Mutex
+
+// StreamRecord is one converted file written to a stream, one JSON document per line
+type StreamRecord struct {
+	Identifier string          `json:"identifier"`
+	Partition  string          `json:"partition,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// StreamHandler writes converted files to stdout or a named pipe as newline-delimited
+// JSON, so the stream can be piped straight into another local process
+type StreamHandler struct {
+	path      string // Named pipe path ("" = stdout)
+	pipe      *os.File
+	converter *converter.Converter
+	routeName string       // Route name recorded in delivery receipts
+	receipts  *ReceiptLog  // Optional delivery receipt log
+	meter     *outputMeter // Delivery metrics (set by MeteredHandler)
+}
+
+// NewStdoutHandler creates a handler writing to standard output
+func NewStdoutHandler() *StreamHandler {
+	return &StreamHandler{converter: converter.New()}
+}
+
+// NewPipeHandler creates a handler writing to the named pipe at path, creating it
+// where the platform supports it. The pipe is opened on the first write, which blocks
+// until a reader has it open.
+func NewPipeHandler(path string) (*StreamHandler, error) {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if err := makeFIFO(path); err != nil {
+			return nil, fmt.Errorf("failed to create named pipe %s: %w", path, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to check named pipe %s: %w", path, err)
+	} else if info.Mode()&os.ModeNamedPipe == 0 {
+		return nil, fmt.Errorf("%s is not a named pipe", path)
+	}
+	return &StreamHandler{path: path, converter: converter.New()}, nil
+}
+
+// applyOptions configures optional output behaviour
+func (h *StreamHandler) applyOptions(opts Options) {
+	h.converter = converter.NewWithOptions(opts.converterOptions())
+}
+
+// SetEnvelopeContext sets the route name recorded in delivery receipts. Stream records
+// carry no envelope, so the contract and payload format are not used.
+func (h *StreamHandler) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
+	h.routeName = routeName
+}
+
+// SetSourceFile is a no-op: stream records identify their source by filename
+func (h *StreamHandler) SetSourceFile(sourceFilePath string) {}
+
+func (h *StreamHandler) Send(data []map[string]string, identifier string) error {
+	jsonBytes, err := h.converter.ToJSON(data)
+	if err != nil {
+		return err
+	}
+	return h.write(jsonBytes, identifier, "", len(data))
+}
+
+func (h *StreamHandler) SendOrdered(result *parser.ParseResult, identifier string) error {
+	return h.SendPartition(result, identifier, "")
+}
+
+// SendPartition writes one partition of a file as its own record
+func (h *StreamHandler) SendPartition(result *parser.ParseResult, identifier, partition string) error {
+	jsonBytes, err := h.converter.ToJSONOrdered(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ordered JSON: %w", err)
+	}
+	return h.write(jsonBytes, identifier, partition, len(result.Rows))
+}
+
+// write writes one record line and records a delivery receipt
+func (h *StreamHandler) write(jsonBytes []byte, identifier, partition string, rows int) error {
+	// Records must fit on one line
+	var data bytes.Buffer
+	if err := json.Compact(&data, jsonBytes); err != nil {
+		return fmt.Errorf("failed to compact output JSON: %w", err)
+	}
+	line, err := json.Marshal(StreamRecord{Identifier: identifier, Partition: partition, Data: data.Bytes()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stream record: %w", err)
+	}
+	line = append(line, '\n')
+
+	writeErr := h.writeLine(line)
+	receipt := Receipt{
+		Route:       h.routeName,
+		SourceFile:  identifier,
+		Output:      h.outputType(),
+		Destination: h.destination(),
+		Rows:        rows,
+		Bytes:       len(line),
+		Status:      ReceiptWritten,
+	}
+	if writeErr != nil {
+		receipt.Status, receipt.Error = ReceiptFailed, writeErr.Error()
+	}
+	h.meter.delivered(receipt)
+	if err := h.receipts.Record(receipt); err != nil {
+		log.Printf("Failed to record delivery receipt: %v", err)
+	}
+
+	if writeErr != nil {
+		return fmt.Errorf("failed to write to %s: %w", h.destination(), writeErr)
+	}
+	return nil
+}
+
+// writeLine writes a record to stdout, or to the pipe, reopening it after its reader went away
+func (h *StreamHandler) writeLine(line []byte) error {
+	if h.path == "" {
+		stdoutMu.Lock()
+		defer stdoutMu.Unlock()
+		return writeAll(os.Stdout, line)
+	}
+
+	if h.pipe == nil {
+		pipe, err := os.OpenFile(h.path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		h.pipe = pipe
+	}
+	if err := writeAll(h.pipe, line); err != nil {
+		h.pipe.Close()
+		h.pipe = nil
+		return err
+	}
+	return nil
+}
+
+// destination names the stream in receipts and errors
+func (h *StreamHandler) destination() string {
+	if h.path == "" {
+		return StdoutDestination
+	}
+	return h.path
+}
+
+// outputType is the output type recorded in receipts: "stdout" or "pipe"
+func (h *StreamHandler) outputType() string {
+	if h.path == "" {
+		return "stdout"
+	}
+	return "pipe"
+}
+
+// writeAll writes line to w in a single call, failing on a short write
+func writeAll(w io.Writer, line []byte) error {
+	n, err := w.Write(line)
+	if err == nil && n < len(line) {
+		err = io.ErrShortWrite
+	}
+	return err
+}
+
+func (h *StreamHandler) Close() error {
+	if h.pipe != nil {
+		h.pipe.Close()
+		h.pipe = nil
+	}
+	return h.receipts.Close()
+}
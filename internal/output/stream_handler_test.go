@@ -0,0 +1,100 @@
+package output
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"csv2json/internal/parser"
+)
+
+// TestStdoutHandler validates that each file is written to stdout as one NDJSON record
+func TestStdoutHandler(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("Failed to create pipe: %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = writer
+	defer func() { os.Stdout = stdout }()
+
+	result := &parser.ParseResult{
+		Headers: []string{"country", "city"},
+		Rows:    []parser.OrderedMap{{Keys: []string{"country", "city"}, Values: map[string]string{"country": "DE", "city": "Berlin"}}},
+	}
+	handler := NewStdoutHandler()
+	if err := handler.SendOrdered(result, "cities.csv"); err != nil {
+		t.Fatalf("SendOrdered failed: %v", err)
+	}
+	if err := handler.SendPartition(result, "cities.csv", "DE"); err != nil {
+		t.Fatalf("SendPartition failed: %v", err)
+	}
+	writer.Close()
+
+	scanner := bufio.NewScanner(reader)
+	var records []StreamRecord
+	for scanner.Scan() {
+		var record StreamRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("Line %q is not a JSON record: %v", scanner.Text(), err)
+		}
+		records = append(records, record)
+	}
+	if len(records) != 2 {
+		t.Fatalf("Expected 2 records, got %d", len(records))
+	}
+	if records[0].Identifier != "cities.csv" || records[0].Partition != "" || string(records[0].Data) != `[{"country":"DE","city":"Berlin"}]` {
+		t.Errorf("Unexpected record: %+v (data %s)", records[0], records[0].Data)
+	}
+	if records[1].Partition != "DE" {
+		t.Errorf("Expected partition DE, got %q", records[1].Partition)
+	}
+}
+
+// TestPipeHandler validates writing to a named pipe, creating it when missing
+func TestPipeHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("named pipes are not created on Windows")
+	}
+	dir := t.TempDir()
+
+	regular := filepath.Join(dir, "regular")
+	if err := os.WriteFile(regular, nil, 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if _, err := NewPipeHandler(regular); err == nil {
+		t.Error("Expected error for a path that is not a named pipe")
+	}
+
+	path := filepath.Join(dir, "out.fifo")
+	handler, err := NewPipeHandler(path)
+	if err != nil {
+		t.Fatalf("NewPipeHandler failed: %v", err)
+	}
+	defer handler.Close()
+	if info, err := os.Stat(path); err != nil || info.Mode()&os.ModeNamedPipe == 0 {
+		t.Fatalf("Expected a named pipe at %s", path)
+	}
+
+	lines := make(chan string, 1)
+	go func() {
+		pipe, err := os.Open(path)
+		if err != nil {
+			lines <- ""
+			return
+		}
+		defer pipe.Close()
+		line, _ := bufio.NewReader(pipe).ReadString('\n')
+		lines <- line
+	}()
+
+	if err := handler.Send([]map[string]string{{"id": "1"}}, "orders.csv"); err != nil {
+		t.Fatalf("Send failed: %v", err)
+	}
+	if line := <-lines; line != `{"identifier":"orders.csv","data":[{"id":"1"}]}`+"\n" {
+		t.Errorf("Unexpected pipe line: %q", line)
+	}
+}
@@ -0,0 +1,153 @@
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Template fields resolved per message
+const (
+	templateRoute          = "route"          // Route name (empty in legacy mode)
+	templateContract       = "contract"       // Ingestion contract
+	templateFilename       = "filename"       // Source filename, e.g. sales_2024.csv
+	templateFilenameBase   = "filenameBase"   // Filename without extension, e.g. sales_2024
+	templateFilenamePrefix = "filenamePrefix" // Filename up to the first '_', '-' or '.', e.g. sales
+	templatePath           = "path"           // Full source file path (empty for batches)
+	templateFileHash       = "fileHash"       // SHA-256 (hex) of the source file content (empty for batches)
+	templatePartition      = "partition"      // Partition value (PARTITION_BY)
+	templateDataHash       = "dataHash"       // SHA-256 (hex) of the message data, stable across re-sends
+	templateBusinessDate   = "businessDate"   // Business date of the source file, YYYY-MM-DD (BUSINESS_DATE_FROM)
+	templateColumnPrefix   = "col:"           // Column value from the first row, e.g. {col:country}
+)
+
+// MessageContext holds the values a message template can reference
+type MessageContext struct {
+	Route        string
+	Contract     string
+	Filename     string
+	Path         string // Full source file path ("" for batches)
+	FileHash     string // SHA-256 (hex) of the source file ("" when unknown)
+	Partition    string
+	BusinessDate string
+	Row          map[string]string // First row of the message
+	Data         []byte            // Rendered message data (without envelope metadata)
+}
+
+// MessageTemplate is a per-message string template such as "{route}.{col:country}",
+// used to derive message keys and broker routing attributes
+type MessageTemplate struct {
+	raw   string
+	parts []templatePart
+}
+
+type templatePart struct {
+	literal string
+	field   string // Empty for literal text
+}
+
+// ParseMessageTemplate parses and validates a template. An empty template returns nil.
+func ParseMessageTemplate(template string) (*MessageTemplate, error) {
+	if template == "" {
+		return nil, nil
+	}
+
+	t := &MessageTemplate{raw: template}
+	rest := template
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed placeholder in template %q", template)
+		}
+		field := rest[open+1 : open+end]
+		if err := validateTemplateField(field); err != nil {
+			return nil, fmt.Errorf("template %q: %w", template, err)
+		}
+		t.parts = append(t.parts, templatePart{field: field})
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+func validateTemplateField(field string) error {
+	switch field {
+	case templateRoute, templateContract, templateFilename, templateFilenameBase, templateFilenamePrefix, templatePath, templateFileHash, templatePartition, templateDataHash, templateBusinessDate:
+		return nil
+	}
+	if strings.HasPrefix(field, templateColumnPrefix) && len(field) > len(templateColumnPrefix) {
+		return nil
+	}
+	return fmt.Errorf("unknown placeholder {%s} (supported: route, contract, filename, filenameBase, filenamePrefix, path, fileHash, partition, dataHash, businessDate, col:NAME)", field)
+}
+
+// references reports whether the template uses field
+func (t *MessageTemplate) references(field string) bool {
+	if t == nil {
+		return false
+	}
+	for _, part := range t.parts {
+		if part.field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// String returns the template source
+func (t *MessageTemplate) String() string {
+	return t.raw
+}
+
+// Resolve substitutes placeholders with values from ctx; missing values resolve to ""
+func (t *MessageTemplate) Resolve(ctx MessageContext) string {
+	var sb strings.Builder
+	for _, part := range t.parts {
+		if part.field == "" {
+			sb.WriteString(part.literal)
+			continue
+		}
+		sb.WriteString(ctx.value(part.field))
+	}
+	return sb.String()
+}
+
+func (ctx MessageContext) value(field string) string {
+	switch field {
+	case templateRoute:
+		return ctx.Route
+	case templateContract:
+		return ctx.Contract
+	case templateFilename:
+		return ctx.Filename
+	case templateFilenameBase:
+		return strings.TrimSuffix(ctx.Filename, filepath.Ext(ctx.Filename))
+	case templateFilenamePrefix:
+		if i := strings.IndexAny(ctx.Filename, "_-."); i >= 0 {
+			return ctx.Filename[:i]
+		}
+		return ctx.Filename
+	case templatePath:
+		return ctx.Path
+	case templateFileHash:
+		return ctx.FileHash
+	case templatePartition:
+		return ctx.Partition
+	case templateBusinessDate:
+		return ctx.BusinessDate
+	case templateDataHash:
+		sum := sha256.Sum256(ctx.Data)
+		return hex.EncodeToString(sum[:])
+	default:
+		return ctx.Row[strings.TrimPrefix(field, templateColumnPrefix)]
+	}
+}
@@ -0,0 +1,114 @@
+package output
+
+import (
+	"testing"
+)
+
+// TestMessageTemplateResolve validates placeholder substitution from message context
+func TestMessageTemplateResolve(t *testing.T) {
+	ctx := MessageContext{
+		Route:     "sales-route",
+		Contract:  "sales.csv.v1",
+		Filename:  "sales_2024-01.csv",
+		Path:      "/data/in/sales_2024-01.csv",
+		FileHash:  "abc123",
+		Partition: "DE",
+		Row:       map[string]string{"customer_id": "C42"},
+	}
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{"{col:customer_id}", "C42"},
+		{"ingest.{route}.{filenamePrefix}", "ingest.sales-route.sales"},
+		{"{contract}/{filenameBase}/{partition}", "sales.csv.v1/sales_2024-01/DE"},
+		{"{filename}", "sales_2024-01.csv"},
+		{"{path}@{fileHash}", "/data/in/sales_2024-01.csv@abc123"},
+		{"{col:missing}-x", "-x"},
+		{"static", "static"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			tmpl, err := ParseMessageTemplate(tc.template)
+			if err != nil {
+				t.Fatalf("ParseMessageTemplate failed: %v", err)
+			}
+			if got := tmpl.Resolve(ctx); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestParseMessageTemplateErrors validates unknown and malformed placeholders are rejected
+func TestParseMessageTemplateErrors(t *testing.T) {
+	for _, template := range []string{"{unknown}", "{route", "{col:}"} {
+		if _, err := ParseMessageTemplate(template); err == nil {
+			t.Errorf("Expected error for template %q, got success", template)
+		}
+	}
+
+	tmpl, err := ParseMessageTemplate("")
+	if err != nil || tmpl != nil {
+		t.Errorf("Expected nil template for empty string, got %v (err: %v)", tmpl, err)
+	}
+}
+
+// TestMessageAttributes validates Kafka key and partition derivation per message
+func TestMessageAttributes(t *testing.T) {
+	handler := &QueueHandler{routeName: "orders"}
+	if err := handler.applyOptions(Options{KafkaMessageKey: "{route}:{col:customer_id}", KafkaPartition: "{col:region_id}"}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+
+	attrs, err := handler.messageAttributes("orders.csv", map[string]string{"customer_id": "C1", "region_id": "3"}, nil)
+	if err != nil {
+		t.Fatalf("messageAttributes failed: %v", err)
+	}
+	if attrs.Key != "orders:C1" || attrs.Partition != 3 {
+		t.Errorf("Expected key 'orders:C1' partition 3, got %+v", attrs)
+	}
+
+	if _, err := handler.messageAttributes("orders.csv", map[string]string{"region_id": "north"}, nil); err == nil {
+		t.Error("Expected error for non-numeric partition, got success")
+	}
+
+	unset := &QueueHandler{}
+	attrs, _ = unset.messageAttributes("orders.csv", nil, nil)
+	if attrs.Key != "" || attrs.Partition != -1 {
+		t.Errorf("Expected no key and partition -1 when unset, got %+v", attrs)
+	}
+}
+
+// TestMessageAttributesRoutingKey validates RabbitMQ routing key templates per message
+func TestMessageAttributesRoutingKey(t *testing.T) {
+	handler := &QueueHandler{routeName: "invoices"}
+	err := handler.applyOptions(Options{
+		RabbitMQExchange:   "ingest",
+		RabbitMQRoutingKey: "ingest.{route}.{filenamePrefix}",
+	})
+	if err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	if handler.bindingKey != "#" {
+		t.Errorf("Expected default topic binding key '#', got %q", handler.bindingKey)
+	}
+
+	attrs, err := handler.messageAttributes("acme_2024.csv", nil, nil)
+	if err != nil {
+		t.Fatalf("messageAttributes failed: %v", err)
+	}
+	if attrs.RoutingKey != "ingest.invoices.acme" {
+		t.Errorf("Expected routing key 'ingest.invoices.acme', got %q", attrs.RoutingKey)
+	}
+
+	direct := &QueueHandler{}
+	if err := direct.applyOptions(Options{RabbitMQExchange: "ingest", RabbitMQExchangeType: "direct"}); err != nil {
+		t.Fatalf("applyOptions failed: %v", err)
+	}
+	if direct.bindingKey != "" {
+		t.Errorf("Expected direct exchange to bind by queue name, got binding key %q", direct.bindingKey)
+	}
+}
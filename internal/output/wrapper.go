@@ -0,0 +1,87 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"time"
+
+	"csv2json/internal/converter"
+)
+
+// FileWrapper names the fields of the top-level object output files are wrapped in,
+// so file consumers get the source filename and generation time next to the records
+// queue envelopes carry (FILE_WRAPPER)
+type FileWrapper struct {
+	File        string // Field holding the source filename
+	GeneratedAt string // Field holding when the output was written (RFC 3339)
+	Records     string // Field holding the converted records, written last so they can be streamed
+}
+
+// write streams the wrapper object to w with the records rendered by render nested under
+// the records field, and returns the number of bytes written
+func (f *FileWrapper) write(w io.Writer, identifier string, generatedAt time.Time, asciiSafe bool, render func(io.Writer) (int64, error)) (int64, error) {
+	var header bytes.Buffer
+	header.WriteString("{\n")
+	for _, field := range [][2]string{{f.File, identifier}, {f.GeneratedAt, generatedAt.Format(time.RFC3339)}} {
+		if err := writeField(&header, field[0]); err != nil {
+			return 0, err
+		}
+		value, err := json.Marshal(field[1])
+		if err != nil {
+			return 0, err
+		}
+		header.Write(value)
+		header.WriteString(",\n")
+	}
+	if err := writeField(&header, f.Records); err != nil {
+		return 0, err
+	}
+	headerBytes := header.Bytes()
+	if asciiSafe {
+		headerBytes = converter.EscapeNonASCII(headerBytes)
+	}
+
+	n, err := w.Write(headerBytes)
+	written := int64(n)
+	if err != nil {
+		return written, err
+	}
+	records := &indentWriter{w: w}
+	_, err = render(records)
+	written += records.written
+	if err != nil {
+		return written, err
+	}
+	n, err = io.WriteString(w, "\n}")
+	return written + int64(n), err
+}
+
+// writeField writes an indented object key and its separator to buf
+func writeField(buf *bytes.Buffer, name string) error {
+	key, err := json.Marshal(name)
+	if err != nil {
+		return err
+	}
+	buf.WriteString("  ")
+	buf.Write(key)
+	buf.WriteString(": ")
+	return nil
+}
+
+// indentWriter indents rendered JSON one level by following every line break with the
+// converter's indent. Line breaks only occur between tokens, as JSON strings escape them.
+type indentWriter struct {
+	w       io.Writer
+	written int64 // Bytes written to w, including indentation
+}
+
+func (i *indentWriter) Write(p []byte) (int, error) {
+	indented := bytes.ReplaceAll(p, []byte("\n"), []byte("\n  "))
+	n, err := i.w.Write(indented)
+	i.written += int64(n)
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
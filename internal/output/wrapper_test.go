@@ -0,0 +1,66 @@
+package output
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"csv2json/internal/parser"
+)
+
+// TestFileHandlerWrapper validates output files are wrapped in an object with the
+// configured field names, for converted and spilled output alike
+func TestFileHandlerWrapper(t *testing.T) {
+	dir := t.TempDir()
+	handler := NewFileHandler(dir)
+	handler.applyOptions(Options{
+		FileWrapper: &FileWrapper{File: "source", GeneratedAt: "createdAt", Records: "rows"},
+		Location:    time.FixedZone("UTC+2", 2*60*60),
+	})
+	result := &parser.ParseResult{
+		Headers: []string{"id", "note"},
+		Rows: []parser.OrderedMap{
+			{Keys: []string{"id", "note"}, Values: map[string]string{"id": "1", "note": "a\nb"}},
+			{Keys: []string{"id", "note"}, Values: map[string]string{"id": "2", "note": ""}},
+		},
+	}
+	if err := handler.SendOrdered(result, "orders.csv"); err != nil {
+		t.Fatalf("SendOrdered failed: %v", err)
+	}
+
+	spillPath := filepath.Join(dir, "spill.tmp")
+	if err := os.WriteFile(spillPath, []byte("[\n  {\n    \"id\": \"3\"\n  }\n]"), 0644); err != nil {
+		t.Fatalf("Failed to write spill: %v", err)
+	}
+	if err := handler.SendSpill(&Spill{Path: spillPath, Rows: 1}, "large.csv"); err != nil {
+		t.Fatalf("SendSpill failed: %v", err)
+	}
+
+	for name, rows := range map[string]int{"orders": 2, "large": 1} {
+		content, err := os.ReadFile(filepath.Join(dir, name+".json"))
+		if err != nil {
+			t.Fatalf("Failed to read output: %v", err)
+		}
+		var wrapped struct {
+			Source    string              `json:"source"`
+			CreatedAt string              `json:"createdAt"`
+			Rows      []map[string]string `json:"rows"`
+		}
+		if err := json.Unmarshal(content, &wrapped); err != nil {
+			t.Fatalf("%s: invalid wrapped output: %v\n%s", name, err, content)
+		}
+		if wrapped.Source != name+".csv" || len(wrapped.Rows) != rows {
+			t.Errorf("%s: unexpected wrapper %+v", name, wrapped)
+		}
+		if !strings.HasSuffix(wrapped.CreatedAt, "+02:00") {
+			t.Errorf("%s: expected createdAt in the configured zone, got %q", name, wrapped.CreatedAt)
+		}
+		// Records are indented one level below the wrapper's fields
+		if !strings.Contains(string(content), "\n  \"rows\": [\n    {\n      \"id\"") {
+			t.Errorf("%s: expected the records nested one level, got %s", name, content)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Supported encoding names (normalized form)
+const (
+	EncodingAuto        = "auto"
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingLatin1      = "iso-8859-1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// Invalid UTF-8 policies applied to decoded field values
+const (
+	InvalidUTF8Fail    = "fail"    // Reject the file, pinpointing the offending row and column
+	InvalidUTF8Replace = "replace" // Replace each invalid sequence with U+FFFD (default)
+	InvalidUTF8Strip   = "strip"   // Drop invalid sequences entirely
+)
+
+// detectionSampleSize is the number of bytes inspected when ENCODING=auto
+const detectionSampleSize = 4096
+
+// encodingAliases maps accepted configuration values to their normalized names
+var encodingAliases = map[string]string{
+	"auto":         EncodingAuto,
+	"utf-8":        EncodingUTF8,
+	"utf8":         EncodingUTF8,
+	"utf-16":       EncodingUTF16LE, // BOM takes precedence when present
+	"utf-16le":     EncodingUTF16LE,
+	"utf-16be":     EncodingUTF16BE,
+	"iso-8859-1":   EncodingLatin1,
+	"latin1":       EncodingLatin1,
+	"latin-1":      EncodingLatin1,
+	"windows-1252": EncodingWindows1252,
+	"cp1252":       EncodingWindows1252,
+}
+
+// NormalizeEncoding returns the canonical encoding name, or an error if unsupported
+func NormalizeEncoding(encoding string) (string, error) {
+	if encoding == "" {
+		return EncodingUTF8, nil
+	}
+	normalized, ok := encodingAliases[strings.ToLower(strings.TrimSpace(encoding))]
+	if !ok {
+		return "", fmt.Errorf("unsupported encoding: %s (supported: auto, utf-8, utf-16, utf-16le, utf-16be, iso-8859-1, windows-1252)", encoding)
+	}
+	return normalized, nil
+}
+
+// ValidateInvalidUTF8Policy returns an error if policy is not a supported invalid UTF-8 policy.
+// An empty policy is valid and means the default (replace).
+func ValidateInvalidUTF8Policy(policy string) error {
+	switch policy {
+	case "", InvalidUTF8Fail, InvalidUTF8Replace, InvalidUTF8Strip:
+		return nil
+	default:
+		return fmt.Errorf("unsupported invalid UTF-8 policy: %s (supported: fail, replace, strip)", policy)
+	}
+}
+
+// sanitizeUTF8 applies the invalid UTF-8 policy to a single value.
+// For the fail policy it returns the byte offset of the first invalid sequence.
+func sanitizeUTF8(value, policy string) (string, int, bool) {
+	if utf8.ValidString(value) {
+		return value, 0, true
+	}
+	switch policy {
+	case InvalidUTF8Fail:
+		for offset, r := range value {
+			if r == utf8.RuneError {
+				if _, size := utf8.DecodeRuneInString(value[offset:]); size == 1 {
+					return value, offset, false
+				}
+			}
+		}
+		return value, 0, false
+	case InvalidUTF8Strip:
+		return strings.ToValidUTF8(value, ""), 0, true
+	default:
+		return strings.ToValidUTF8(value, string(utf8.RuneError)), 0, true
+	}
+}
+
+// newDecodingReader wraps r so that it yields UTF-8 regardless of the source encoding.
+// A leading byte order mark is consumed. Returns the encoding actually used.
+func newDecodingReader(r io.Reader, encoding string) (io.Reader, string, error) {
+	normalized, err := NormalizeEncoding(encoding)
+	if err != nil {
+		return nil, "", err
+	}
+
+	br := bufio.NewReaderSize(r, detectionSampleSize)
+	sample, err := br.Peek(detectionSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", fmt.Errorf("failed to read file sample: %w", err)
+	}
+
+	// A BOM is authoritative for auto and utf-16, and is stripped for utf-8
+	bomEncoding, bomLen := detectBOM(sample)
+	switch {
+	case normalized == EncodingAuto && bomEncoding != "":
+		normalized = bomEncoding
+	case normalized == EncodingAuto:
+		normalized = detectFromStatistics(sample)
+	case strings.EqualFold(strings.TrimSpace(encoding), "utf-16") && bomEncoding != "":
+		normalized = bomEncoding
+	}
+	if bomLen > 0 && bomEncoding == normalized {
+		if _, err := br.Discard(bomLen); err != nil {
+			return nil, "", fmt.Errorf("failed to skip byte order mark: %w", err)
+		}
+	}
+
+	switch normalized {
+	case EncodingUTF8:
+		return br, normalized, nil
+	case EncodingUTF16LE:
+		return &utf16Reader{src: br, bigEndian: false}, normalized, nil
+	case EncodingUTF16BE:
+		return &utf16Reader{src: br, bigEndian: true}, normalized, nil
+	case EncodingLatin1:
+		return &singleByteReader{src: br, table: nil}, normalized, nil
+	case EncodingWindows1252:
+		return &singleByteReader{src: br, table: &windows1252High}, normalized, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported encoding: %s", normalized)
+	}
+}
+
+// detectBOM returns the encoding indicated by a byte order mark and its length
+func detectBOM(sample []byte) (string, int) {
+	switch {
+	case bytes.HasPrefix(sample, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8, 3
+	case bytes.HasPrefix(sample, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE, 2
+	case bytes.HasPrefix(sample, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE, 2
+	}
+	return "", 0
+}
+
+// detectFromStatistics guesses the encoding of BOM-less content from byte statistics
+func detectFromStatistics(sample []byte) string {
+	if len(sample) == 0 {
+		return EncodingUTF8
+	}
+
+	// UTF-16 text in Latin scripts has a NUL in every other byte
+	var evenZeros, oddZeros int
+	for i, b := range sample {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	pairs := len(sample) / 2
+	if pairs > 0 {
+		if oddZeros*10 >= pairs*3 && evenZeros*10 < pairs {
+			return EncodingUTF16LE
+		}
+		if evenZeros*10 >= pairs*3 && oddZeros*10 < pairs {
+			return EncodingUTF16BE
+		}
+	}
+
+	if utf8.Valid(sample) {
+		return EncodingUTF8
+	}
+	// A full sample may end mid-rune; tolerate an incomplete trailing sequence
+	if len(sample) == detectionSampleSize {
+		for cut := 1; cut < utf8.UTFMax; cut++ {
+			if utf8.RuneStart(sample[len(sample)-cut]) && utf8.Valid(sample[:len(sample)-cut]) {
+				return EncodingUTF8
+			}
+		}
+	}
+
+	// Not valid UTF-8: assume the common Western 8-bit code page (superset of printable Latin-1)
+	return EncodingWindows1252
+}
+
+// utf16Reader transcodes a UTF-16 byte stream to UTF-8
+type utf16Reader struct {
+	src       *bufio.Reader
+	bigEndian bool
+	pending   []byte
+	err       error
+}
+
+func (r *utf16Reader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+func (r *utf16Reader) fill() {
+	var buf [utf8.UTFMax]byte
+	for i := 0; i < 512 && r.err == nil; i++ {
+		unit, err := r.readUnit()
+		if err != nil {
+			r.err = err
+			return
+		}
+
+		codePoint := rune(unit)
+		if unit >= 0xD800 && unit < 0xDC00 {
+			low, err := r.readUnit()
+			if err != nil {
+				r.err = err
+				codePoint = utf8.RuneError
+			} else if low >= 0xDC00 && low < 0xE000 {
+				codePoint = (rune(unit)-0xD800)<<10 + (rune(low) - 0xDC00) + 0x10000
+			} else {
+				codePoint = utf8.RuneError
+			}
+		} else if unit >= 0xDC00 && unit < 0xE000 {
+			codePoint = utf8.RuneError
+		}
+
+		n := utf8.EncodeRune(buf[:], codePoint)
+		r.pending = append(r.pending, buf[:n]...)
+	}
+}
+
+func (r *utf16Reader) readUnit() (uint16, error) {
+	var pair [2]byte
+	if _, err := io.ReadFull(r.src, pair[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return 0, fmt.Errorf("truncated UTF-16 input: odd number of bytes")
+		}
+		return 0, err
+	}
+	if r.bigEndian {
+		return uint16(pair[0])<<8 | uint16(pair[1]), nil
+	}
+	return uint16(pair[1])<<8 | uint16(pair[0]), nil
+}
+
+// singleByteReader transcodes an 8-bit code page to UTF-8.
+// A nil table means ISO-8859-1 (every byte maps to the same code point).
+type singleByteReader struct {
+	src     *bufio.Reader
+	table   *[32]rune
+	pending []byte
+}
+
+func (r *singleByteReader) Read(p []byte) (int, error) {
+	if len(r.pending) == 0 {
+		chunk := make([]byte, 1024)
+		n, err := r.src.Read(chunk)
+		if n == 0 {
+			return 0, err
+		}
+		var buf [utf8.UTFMax]byte
+		for _, b := range chunk[:n] {
+			if b < utf8.RuneSelf {
+				r.pending = append(r.pending, b)
+				continue
+			}
+			codePoint := rune(b)
+			if r.table != nil && b >= 0x80 && b < 0xA0 {
+				codePoint = r.table[b-0x80]
+			}
+			size := utf8.EncodeRune(buf[:], codePoint)
+			r.pending = append(r.pending, buf[:size]...)
+		}
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// windows1252High maps bytes 0x80-0x9F, the only range where Windows-1252 differs from ISO-8859-1
+var windows1252High = [32]rune{
+	0x20AC, 0xFFFD, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0xFFFD, 0x017D, 0xFFFD,
+	0xFFFD, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0xFFFD, 0x017E, 0x0178,
+}
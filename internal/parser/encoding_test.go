@@ -0,0 +1,194 @@
+package parser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+
+	"csv2json/internal/failure"
+)
+
+// writeTestFile writes raw bytes to a temporary file and returns its path
+func writeTestFile(t *testing.T, name string, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+// encodeUTF16 encodes s as UTF-16 with an optional byte order mark
+func encodeUTF16(s string, bigEndian, bom bool) []byte {
+	var out []byte
+	if bom {
+		if bigEndian {
+			out = append(out, 0xFE, 0xFF)
+		} else {
+			out = append(out, 0xFF, 0xFE)
+		}
+	}
+	for _, unit := range utf16.Encode([]rune(s)) {
+		if bigEndian {
+			out = append(out, byte(unit>>8), byte(unit))
+		} else {
+			out = append(out, byte(unit), byte(unit>>8))
+		}
+	}
+	return out
+}
+
+// TestParseAutoEncodingDetection validates ENCODING=auto across BOM and BOM-less inputs
+func TestParseAutoEncodingDetection(t *testing.T) {
+	csvText := "name,city\nJosé,Zürich\n"
+
+	testCases := []struct {
+		name     string
+		content  []byte
+		expected string
+	}{
+		{"utf-8 plain", []byte(csvText), EncodingUTF8},
+		{"utf-8 with BOM", append([]byte{0xEF, 0xBB, 0xBF}, csvText...), EncodingUTF8},
+		{"utf-16le with BOM", encodeUTF16(csvText, false, true), EncodingUTF16LE},
+		{"utf-16be with BOM", encodeUTF16(csvText, true, true), EncodingUTF16BE},
+		{"utf-16le without BOM", encodeUTF16(csvText, false, false), EncodingUTF16LE},
+		{"utf-16be without BOM", encodeUTF16(csvText, true, false), EncodingUTF16BE},
+		{"windows-1252", []byte("name,city\nJos\xe9,Z\xfcrich\n"), EncodingWindows1252},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeTestFile(t, "auto.csv", tc.content)
+			p := NewWithOptions(',', '"', true, Options{Encoding: "auto"})
+
+			result, err := p.ParseWithOrder(path)
+			if err != nil {
+				t.Fatalf("Expected successful parse, got error: %v", err)
+			}
+
+			if result.Encoding != tc.expected {
+				t.Errorf("Expected detected encoding '%s', got '%s'", tc.expected, result.Encoding)
+			}
+			if result.Headers[0] != "name" {
+				t.Errorf("Expected first header 'name' (BOM stripped), got %q", result.Headers[0])
+			}
+			if result.Rows[0].Values["name"] != "José" {
+				t.Errorf("Expected name 'José', got %q", result.Rows[0].Values["name"])
+			}
+			if result.Rows[0].Values["city"] != "Zürich" {
+				t.Errorf("Expected city 'Zürich', got %q", result.Rows[0].Values["city"])
+			}
+		})
+	}
+}
+
+// TestParseExplicitEncoding validates decoding with a configured (non-auto) encoding
+func TestParseExplicitEncoding(t *testing.T) {
+	// 0x80 is the euro sign in Windows-1252 but a C1 control in ISO-8859-1
+	content := []byte("item,price\nwidget,\x8010\n")
+
+	p := NewWithOptions(',', '"', true, Options{Encoding: "cp1252"})
+	result, err := p.ParseWithOrder(writeTestFile(t, "cp1252.csv", content))
+	if err != nil {
+		t.Fatalf("Expected successful parse, got error: %v", err)
+	}
+	if result.Rows[0].Values["price"] != "€10" {
+		t.Errorf("Expected price '€10', got %q", result.Rows[0].Values["price"])
+	}
+
+	p = NewWithOptions(',', '"', true, Options{Encoding: "latin1"})
+	result, err = p.ParseWithOrder(writeTestFile(t, "latin1.csv", content))
+	if err != nil {
+		t.Fatalf("Expected successful parse, got error: %v", err)
+	}
+	if result.Rows[0].Values["price"] != "\u008010" {
+		t.Errorf("Expected price '\\u008010', got %q", result.Rows[0].Values["price"])
+	}
+}
+
+// TestNormalizeEncoding validates accepted encoding names and aliases
+func TestNormalizeEncoding(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expected    string
+		shouldError bool
+	}{
+		{"", EncodingUTF8, false},
+		{"UTF-8", EncodingUTF8, false},
+		{"utf8", EncodingUTF8, false},
+		{"auto", EncodingAuto, false},
+		{"UTF-16", EncodingUTF16LE, false},
+		{"Latin1", EncodingLatin1, false},
+		{"cp1252", EncodingWindows1252, false},
+		{"ebcdic", "", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := NormalizeEncoding(tc.input)
+			if tc.shouldError && err == nil {
+				t.Errorf("Expected error for encoding %q, got success", tc.input)
+			}
+			if !tc.shouldError && got != tc.expected {
+				t.Errorf("Expected %q, got %q (err: %v)", tc.expected, got, err)
+			}
+		})
+	}
+}
+
+// TestParseInvalidUTF8Policy validates fail, replace and strip handling of invalid byte sequences
+func TestParseInvalidUTF8Policy(t *testing.T) {
+	content := []byte("name,city\nJohn,Lo\xffndon\n")
+
+	testCases := []struct {
+		policy   string
+		expected string
+	}{
+		{"", "Lo�ndon"},
+		{InvalidUTF8Replace, "Lo�ndon"},
+		{InvalidUTF8Strip, "London"},
+	}
+
+	for _, tc := range testCases {
+		t.Run("policy "+tc.policy, func(t *testing.T) {
+			p := NewWithOptions(',', '"', true, Options{InvalidUTF8Policy: tc.policy})
+			result, err := p.ParseWithOrder(writeTestFile(t, "invalid.csv", content))
+			if err != nil {
+				t.Fatalf("Expected successful parse, got error: %v", err)
+			}
+			if result.Rows[0].Values["city"] != tc.expected {
+				t.Errorf("Expected city %q, got %q", tc.expected, result.Rows[0].Values["city"])
+			}
+		})
+	}
+
+	t.Run("policy fail", func(t *testing.T) {
+		p := NewWithOptions(',', '"', true, Options{InvalidUTF8Policy: InvalidUTF8Fail})
+		_, err := p.ParseWithOrder(writeTestFile(t, "invalid.csv", content))
+		if err == nil {
+			t.Fatal("Expected error for invalid UTF-8 with fail policy, got success")
+		}
+		expected := "invalid UTF-8 byte sequence at row 2, column 2 (city), byte offset 2"
+		if err.Error() != expected {
+			t.Errorf("Expected error %q, got %q", expected, err.Error())
+		}
+		var parseErr *failure.Error
+		if !errors.As(err, &parseErr) || parseErr.Row != 2 || parseErr.Column != "2 (city)" {
+			t.Errorf("Expected row 2 and column 2 (city) in the failure, got %#v", err)
+		}
+	})
+}
+
+// TestValidateInvalidUTF8Policy validates accepted policy names
+func TestValidateInvalidUTF8Policy(t *testing.T) {
+	for _, policy := range []string{"", "fail", "replace", "strip"} {
+		if err := ValidateInvalidUTF8Policy(policy); err != nil {
+			t.Errorf("Expected policy %q to be valid, got error: %v", policy, err)
+		}
+	}
+	if err := ValidateInvalidUTF8Policy("ignore"); err == nil {
+		t.Error("Expected error for unsupported policy 'ignore', got success")
+	}
+}
@@ -0,0 +1,238 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"csv2json/internal/failure"
+)
+
+// Record roles of the multi-record format
+const (
+	RecordHeader  = "header"  // Starts a document; its fields become the output object's fields
+	RecordDetail  = "detail"  // Appended to the current document's nested array
+	RecordTrailer = "trailer" // Appended to the current document's nested array and closes the document
+)
+
+// Default nested array keys of detail and trailer records
+const (
+	defaultDetailKey  = "details"
+	defaultTrailerKey = "trailer"
+)
+
+// RecordLayout is the fixed-width layout of one record type of the multi-record format,
+// identified by the prefix its lines start with (e.g. "H", "D", "T" or "E1EDK01")
+type RecordLayout struct {
+	Prefix  string             `json:"prefix"`        // Leading characters identifying the record type; the layout starts after them
+	Role    string             `json:"role"`          // header, detail, or trailer
+	Key     string             `json:"key,omitempty"` // Nested array field of detail/trailer records (default: details / trailer)
+	Columns []FixedWidthColumn `json:"columns"`       // Named fields following the prefix
+}
+
+// nestedKey returns the field a detail or trailer record is nested under
+func (l RecordLayout) nestedKey() string {
+	if l.Key != "" {
+		return l.Key
+	}
+	if l.Role == RecordTrailer {
+		return defaultTrailerKey
+	}
+	return defaultDetailKey
+}
+
+// ParseRecordLayouts parses record layouts such as
+// "H:header=doc:10,date:8;D:detail:items=sku:8,qty:5;T:trailer=count:6": record types
+// separated by semicolons, each prefix:role[:key]=columns with columns as in
+// ParseFixedWidthColumns
+func ParseRecordLayouts(spec string) ([]RecordLayout, error) {
+	var layouts []RecordLayout
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		typeSpec, columnSpec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid record layout %q: expected prefix:role[:key]=columns", entry)
+		}
+		parts := strings.Split(typeSpec, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("invalid record layout %q: expected prefix:role[:key]=columns", entry)
+		}
+		layout := RecordLayout{Prefix: strings.TrimSpace(parts[0]), Role: strings.TrimSpace(parts[1])}
+		if len(parts) == 3 {
+			layout.Key = strings.TrimSpace(parts[2])
+		}
+		columns, err := ParseFixedWidthColumns(columnSpec)
+		if err != nil {
+			return nil, fmt.Errorf("record type %q: %w", layout.Prefix, err)
+		}
+		layout.Columns = columns
+		layouts = append(layouts, layout)
+	}
+	return layouts, nil
+}
+
+// ValidateRecordLayouts checks the layouts are usable: exactly one header record type,
+// at least one detail or trailer type, distinct non-empty prefixes, named columns, and
+// nested keys that do not collide with the header's fields
+func ValidateRecordLayouts(layouts []RecordLayout) error {
+	headers := 0
+	prefixes := make(map[string]bool)
+	var header *RecordLayout
+	for i, layout := range layouts {
+		if layout.Prefix == "" {
+			return fmt.Errorf("record type %d has no prefix", i+1)
+		}
+		if prefixes[layout.Prefix] {
+			return fmt.Errorf("record type %q is defined more than once", layout.Prefix)
+		}
+		prefixes[layout.Prefix] = true
+		switch layout.Role {
+		case RecordHeader:
+			headers++
+			header = &layouts[i]
+		case RecordDetail, RecordTrailer:
+		default:
+			return fmt.Errorf("record type %q has unsupported role: %s (supported: header, detail, trailer)", layout.Prefix, layout.Role)
+		}
+		if err := ValidateFixedWidthColumns(layout.Columns); err != nil {
+			return fmt.Errorf("record type %q: %w", layout.Prefix, err)
+		}
+		if columnNames(layout.Columns) == nil {
+			return fmt.Errorf("record type %q: columns must be named", layout.Prefix)
+		}
+	}
+	if headers != 1 {
+		return fmt.Errorf("exactly one header record type is required, got %d", headers)
+	}
+	if len(layouts) < 2 {
+		return fmt.Errorf("at least one detail or trailer record type is required")
+	}
+	for _, layout := range layouts {
+		if layout.Role == RecordHeader {
+			continue
+		}
+		for _, name := range columnNames(header.Columns) {
+			if name == layout.nestedKey() {
+				return fmt.Errorf("record type %q: nested key %q is also a header field", layout.Prefix, name)
+			}
+		}
+	}
+	return nil
+}
+
+// multiRecordReader reads the lines of a multi-record file, slicing each with the
+// layout of the record type its prefix identifies
+type multiRecordReader struct {
+	lines   *lineReader
+	layouts []RecordLayout // Longest prefixes first, so "D1" is matched before "D"
+	layout  *RecordLayout  // Layout of the record read last
+}
+
+func newMultiRecordReader(r io.Reader, layouts []RecordLayout) *multiRecordReader {
+	sorted := append([]RecordLayout(nil), layouts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return len(sorted[i].Prefix) > len(sorted[j].Prefix) })
+	return &multiRecordReader{lines: newLineReader(r), layouts: sorted}
+}
+
+func (m *multiRecordReader) Read() ([]string, error) {
+	line, err := m.lines.next()
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.layouts {
+		if strings.HasPrefix(line, m.layouts[i].Prefix) {
+			m.layout = &m.layouts[i]
+			return sliceFixedWidth(line[len(m.layout.Prefix):], m.layout.Columns, m.lines.line)
+		}
+	}
+	return nil, &failure.Error{Kind: failure.ErrParse, Row: m.lines.line, Err: fmt.Errorf("line %d has no known record type prefix", m.lines.line)}
+}
+
+// multiRecordRows returns the headers of a multi-record file and a function reading
+// its documents one at a time: a row per header record, with the detail and trailer
+// records following it nested under their keys
+func (p *Parser) multiRecordRows(r io.Reader) ([]string, func() (OrderedMap, bool, error), error) {
+	var header RecordLayout
+	var nestedKeys []string
+	for _, layout := range p.layouts {
+		if layout.Role == RecordHeader {
+			header = layout
+		} else if !slices.Contains(nestedKeys, layout.nestedKey()) {
+			nestedKeys = append(nestedKeys, layout.nestedKey())
+		}
+	}
+	fields, err := p.withRawField(columnNames(header.Columns))
+	if err != nil {
+		return nil, nil, err
+	}
+	headers := append(fields[:len(fields):len(fields)], nestedKeys...)
+
+	reader := newMultiRecordReader(r, p.layouts)
+	var current *OrderedMap
+	closed := false // The current document's trailer was read; only a header may follow
+
+	read := func() (OrderedMap, bool, error) {
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				// The last document ends with the file
+				if current == nil {
+					return OrderedMap{}, false, nil
+				}
+				document := *current
+				current = nil
+				return document, true, nil
+			}
+			if err != nil {
+				var parseErr *failure.Error
+				if errors.As(err, &parseErr) {
+					return OrderedMap{}, false, err
+				}
+				return OrderedMap{}, false, &failure.Error{Kind: failure.ErrParse, Row: reader.lines.line, Err: fmt.Errorf("failed to read line %d: %w", reader.lines.line, err)}
+			}
+
+			line, layout := reader.lines.line, reader.layout
+			names := columnNames(layout.Columns)
+			if err := p.sanitizeRecord(record, names, line-1); err != nil {
+				return OrderedMap{}, false, err
+			}
+			names, err = p.withRawField(names)
+			if err != nil {
+				return OrderedMap{}, false, err
+			}
+			row := p.newRow(names, record, reader)
+
+			// A header record starts a new document, completing the current one
+			if layout.Role == RecordHeader {
+				row.Keys = headers
+				row.Nested = make(map[string][]OrderedMap, len(nestedKeys))
+				for _, key := range nestedKeys {
+					row.Nested[key] = []OrderedMap{}
+				}
+				previous := current
+				current, closed = &row, false
+				if previous != nil {
+					return *previous, true, nil
+				}
+				continue
+			}
+
+			if current == nil {
+				return OrderedMap{}, false, &failure.Error{Kind: failure.ErrParse, Row: line, Err: fmt.Errorf("line %d: %s record %q before the first header record", line, layout.Role, layout.Prefix)}
+			}
+			if closed {
+				return OrderedMap{}, false, &failure.Error{Kind: failure.ErrParse, Row: line, Err: fmt.Errorf("line %d: %s record %q after the document's trailer", line, layout.Role, layout.Prefix)}
+			}
+			key := layout.nestedKey()
+			current.Nested[key] = append(current.Nested[key], row)
+			closed = layout.Role == RecordTrailer
+		}
+	}
+	return headers, read, nil
+}
@@ -0,0 +1,202 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"unicode/utf8"
+)
+
+// parallelMinChunk is the smallest share of a file worth handing to its own worker
+const parallelMinChunk = 1 << 20
+
+// parallelEligible reports whether a file is large enough to be parsed in parallel
+func (p *Parser) parallelEligible(filename string) bool {
+	if p.workers <= 1 || p.format == FormatMulti || p.delimiter >= utf8.RuneSelf || p.reassembleLines || p.raggedRowPolicy == RaggedRowSkip || p.raggedRowPolicy == RaggedRowPad || p.columnSample > 0 {
+		return false
+	}
+	info, err := os.Stat(filename)
+	return err == nil && info.Size() >= p.parallelMinSize && info.Size() >= 2*parallelMinChunk
+}
+
+// parseParallel decodes a file into memory, splits it on record boundaries and parses
+// the parts concurrently, concatenating the rows in file order. Any error is returned
+// without detail: the caller re-parses sequentially to report it with its row number.
+func (p *Parser) parseParallel(filename string) (*ParseResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoded, encoding, err := newDecodingReader(file, p.encoding)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first record is parsed up front so every part shares the headers
+	headerEnd := p.newBoundaryScanner(data).recordEnd(0)
+	firstReader, _ := p.newRecordReader(bytes.NewReader(data[:headerEnd]))
+	first, err := firstReader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.sanitizeRecord(first, nil, 0); err != nil {
+		return nil, err
+	}
+	headers := columnNames(p.columns)
+	switch {
+	case p.hasHeader && headers == nil:
+		headers = first
+	case !p.hasHeader:
+		if headers == nil {
+			for i := range first {
+				headers = append(headers, fmt.Sprintf("col_%d", i))
+			}
+		}
+		headerEnd = 0 // The first record is data
+	}
+	if headers, err = p.withRawField(headers); err != nil {
+		return nil, err
+	}
+
+	parts := p.split(data[headerEnd:])
+	rows := make([][]OrderedMap, len(parts))
+	errs := make([]error, len(parts))
+	var wg sync.WaitGroup
+	for i, part := range parts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rows[i], errs[i] = p.parseRecords(part, headers)
+		}()
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range parts {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		total += len(rows[i])
+	}
+	if total == 0 {
+		return nil, ErrNoDataRows
+	}
+
+	records := make([]OrderedMap, 0, total)
+	for _, part := range rows {
+		records = append(records, part...)
+	}
+	return &ParseResult{Headers: headers, Rows: records, Encoding: encoding}, nil
+}
+
+// parseRecords parses one part of a file into rows with the given headers
+func (p *Parser) parseRecords(data []byte, headers []string) ([]OrderedMap, error) {
+	reader, _ := p.newRecordReader(bytes.NewReader(data))
+	var records []OrderedMap
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := p.sanitizeRecord(record, headers, 0); err != nil {
+			return nil, err
+		}
+		if expected := len(headers) - p.rawColumns(); len(record) != expected {
+			return nil, fmt.Errorf("record has %d columns, expected %d", len(record), expected)
+		}
+		records = append(records, p.newRow(headers, record, reader))
+	}
+}
+
+// split cuts data into one part per worker, each ending on a record boundary
+func (p *Parser) split(data []byte) [][]byte {
+	size := len(data) / p.workers
+	if size < parallelMinChunk {
+		size = parallelMinChunk
+	}
+
+	scanner := p.newBoundaryScanner(data)
+	var parts [][]byte
+	for start := 0; start < len(data); {
+		end := len(data)
+		if start+size < len(data) {
+			end = scanner.recordEnd(start + size)
+		}
+		parts = append(parts, data[start:end])
+		start = end
+	}
+	return parts
+}
+
+// boundaryScanner finds record boundaries in decoded input in a single forward pass.
+// Delimited input is scanned from the start so that line breaks inside quoted fields
+// are skipped, following the reader's lazy quote rules: a quote only opens a field at
+// its start, and only closes it before a delimiter or line break.
+type boundaryScanner struct {
+	data       []byte
+	delimited  bool
+	delimiter  byte
+	pos        int
+	fieldStart bool
+	quoted     bool
+}
+
+func (p *Parser) newBoundaryScanner(data []byte) *boundaryScanner {
+	delimited := p.format != FormatWhitespace && p.format != FormatFixedWidth && p.format != FormatTSV
+	return &boundaryScanner{data: data, delimited: delimited, delimiter: byte(p.delimiter), fieldStart: true}
+}
+
+// recordEnd returns the offset just past the first line break at or after from that
+// ends a record, or len(data). Offsets must not decrease between calls.
+func (s *boundaryScanner) recordEnd(from int) int {
+	if !s.delimited {
+		if i := bytes.IndexByte(s.data[from:], '\n'); i >= 0 {
+			return from + i + 1
+		}
+		return len(s.data)
+	}
+
+	for ; s.pos < len(s.data); s.pos++ {
+		c := s.data[s.pos]
+		if s.quoted {
+			if c == '"' {
+				next := s.pos + 1
+				if next < len(s.data) && s.data[next] == '"' {
+					s.pos++ // Escaped quote
+				} else if next == len(s.data) || s.data[next] == s.delimiter || s.data[next] == '\n' || s.data[next] == '\r' {
+					s.quoted = false
+				}
+			}
+			continue
+		}
+
+		switch {
+		case c == '\n':
+			s.fieldStart = true
+			if s.pos >= from {
+				s.pos++
+				return s.pos
+			}
+		case c == s.delimiter:
+			s.fieldStart = true
+		case c == '"' && s.fieldStart:
+			s.quoted, s.fieldStart = true, false
+		case c == ' ' || c == '\t':
+			// Leading space is trimmed, so a quote may still open the field
+		default:
+			s.fieldStart = false
+		}
+	}
+	return len(s.data)
+}
@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseParallelMatchesSequential validates parallel parsing returns the same rows in
+// the same order as sequential parsing, including quoted line breaks and lazy quotes
+func TestParseParallelMatchesSequential(t *testing.T) {
+	var csv, text strings.Builder
+	csv.WriteString("id,note,size\r\n")
+	for i := 0; csv.Len() < 3*parallelMinChunk; i++ {
+		switch i % 4 {
+		case 0:
+			fmt.Fprintf(&csv, "%d,\"multi\nline, \"\"quoted\"\"\",%d\r\n", i, i)
+		case 1:
+			fmt.Fprintf(&csv, "%d, \"leading space\n\",12\" pipe\r\n", i)
+		default:
+			fmt.Fprintf(&csv, "%d,plain,%d\r\n", i, i)
+		}
+		fmt.Fprintf(&text, "%d  row-%d   %d\n", i, i, i%7)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		header  bool
+	}{
+		{"delimited", csv.String(), Options{}, true},
+		{"no header", csv.String(), Options{}, false},
+		{"whitespace", text.String(), Options{Format: FormatWhitespace}, false},
+		{"raw field", csv.String(), Options{RawField: "_raw"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "large.csv")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			want, err := NewWithOptions(',', '"', tt.header, tt.opts).ParseWithOrder(path)
+			if err != nil {
+				t.Fatalf("Sequential parse failed: %v", err)
+			}
+
+			tt.opts.Workers = 4
+			p := NewWithOptions(',', '"', tt.header, tt.opts)
+			if !p.parallelEligible(path) || len(p.split([]byte(tt.content))) < 2 {
+				t.Fatal("Expected the test file to be parsed in several parts")
+			}
+			got, err := p.parseParallel(path)
+			if err != nil {
+				t.Fatalf("Parallel parse failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Expected parallel parse to match sequential parse (%d rows), got %d rows", len(want.Rows), len(got.Rows))
+			}
+		})
+	}
+}
+
+// TestParseParallelErrorFallback validates errors are reported as by sequential parsing
+func TestParseParallelErrorFallback(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("id,name\n")
+	for i := 0; content.Len() < 3*parallelMinChunk; i++ {
+		fmt.Fprintf(&content, "%d,name-%d\n", i, i)
+	}
+	content.WriteString("broken\n")
+	path := filepath.Join(t.TempDir(), "broken.csv")
+	if err := os.WriteFile(path, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	_, want := New(',', '"', true).ParseWithOrder(path)
+	_, got := NewWithOptions(',', '"', true, Options{Workers: 4}).ParseWithOrder(path)
+	if want == nil || got == nil || got.Error() != want.Error() {
+		t.Errorf("Expected error %v, got %v", want, got)
+	}
+}
@@ -2,107 +2,260 @@ package parser
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
+
+	"csv2json/internal/failure"
 )
 
+// ErrNoDataRows is returned for files that are empty or contain only a header row
+var ErrNoDataRows = errors.New("no data rows found in file")
+
 // OrderedMap represents a map that preserves insertion order
 type OrderedMap struct {
 	Keys   []string
 	Values map[string]string
+	Nested map[string][]OrderedMap // Optional nested arrays by key (e.g. group-by child rows); nil for flat rows
 }
 
 // ParseResult contains the headers and data rows
 type ParseResult struct {
-	Headers []string
-	Rows    []OrderedMap
+	Headers    []string
+	Rows       []OrderedMap
+	Encoding   string // Source encoding the file was decoded from (detected when ENCODING=auto)
+	RaggedRows int    // Rows skipped or padded by the ragged row policy
+	Duplicates int    // Rows removed by deduplication (DEDUP_KEYS)
+}
+
+// HasNested reports whether any row carries nested arrays (e.g. after group-by)
+func (r *ParseResult) HasNested() bool {
+	for _, row := range r.Rows {
+		if len(row.Nested) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Options holds optional parsing behaviour beyond the basic CSV dialect
+type Options struct {
+	Encoding          string             // Source file encoding: utf-8 (default), utf-16le, utf-16be, iso-8859-1, windows-1252, or auto
+	InvalidUTF8Policy string             // Handling of invalid UTF-8: fail, replace (default), or strip
+	Format            string             // Input format: delimited (default), tsv, whitespace, fixed-width, or multi-record
+	FixedWidthColumns []FixedWidthColumn // Field layout for the fixed-width format
+	RecordLayouts     []RecordLayout     // Record type layouts for the multi-record format
+	Workers           int                // Parse files of at least ParallelMinSize bytes with this many goroutines (<= 1 = sequential)
+	ParallelMinSize   int64              // Smallest file parsed in parallel
+	RawField          string             // Column holding each record's raw source text, appended after the parsed columns ("" = none)
+	ReassembleLines   bool               // Join delimited or TSV records split by unquoted line breaks until they have the expected column count
+	RaggedRowPolicy   string             // Data rows whose column count differs from the header's: fail (default), skip, or pad
+	ColumnSample      int                // Headerless files take the most common column count of this many leading records (0 = the first record's)
 }
 
 type Parser struct {
-	delimiter rune
-	quoteChar rune
-	hasHeader bool
+	delimiter         rune
+	quoteChar         rune
+	hasHeader         bool
+	encoding          string
+	invalidUTF8Policy string
+	format            string
+	columns           []FixedWidthColumn
+	layouts           []RecordLayout
+	workers           int
+	parallelMinSize   int64
+	rawField          string
+	reassembleLines   bool
+	raggedRowPolicy   string
+	columnSample      int
 }
 
 func New(delimiter, quoteChar rune, hasHeader bool) *Parser {
+	return NewWithOptions(delimiter, quoteChar, hasHeader, Options{})
+}
+
+// NewWithOptions creates a parser with additional parsing options
+func NewWithOptions(delimiter, quoteChar rune, hasHeader bool, opts Options) *Parser {
+	if opts.Format == FormatTSV {
+		delimiter = '\t'
+	}
 	return &Parser{
-		delimiter: delimiter,
-		quoteChar: quoteChar,
-		hasHeader: hasHeader,
+		delimiter:         delimiter,
+		quoteChar:         quoteChar,
+		hasHeader:         hasHeader,
+		encoding:          opts.Encoding,
+		invalidUTF8Policy: opts.InvalidUTF8Policy,
+		format:            opts.Format,
+		columns:           opts.FixedWidthColumns,
+		layouts:           opts.RecordLayouts,
+		workers:           opts.Workers,
+		parallelMinSize:   opts.ParallelMinSize,
+		rawField:          opts.RawField,
+		reassembleLines:   opts.ReassembleLines,
+		raggedRowPolicy:   opts.RaggedRowPolicy,
+		columnSample:      opts.ColumnSample,
 	}
 }
 
 // Parse reads a CSV file and returns headers and ordered data rows
 func (p *Parser) ParseWithOrder(filename string) (*ParseResult, error) {
-	file, err := os.Open(filename)
+	if p.parallelEligible(filename) {
+		if result, err := p.parseParallel(filename); err == nil {
+			return result, nil
+		}
+		// Re-parse sequentially so the error is reported with its row number
+	}
+
+	var result *ParseResult
+	err := p.ParseChunks(filename, 0, func(chunk *ParseResult) error {
+		result = chunk
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	return result, nil
+}
 
-	reader := csv.NewReader(file)
-	reader.Comma = p.delimiter
-	reader.LazyQuotes = true
-	reader.TrimLeadingSpace = true
+// ParseChunks reads a file and passes its rows to fn in chunks of at most chunkRows
+// rows (chunkRows <= 0 passes every row in one chunk), so only one chunk is held in
+// memory at a time. Every chunk carries the file's headers and encoding.
+func (p *Parser) ParseChunks(filename string, chunkRows int, fn func(chunk *ParseResult) error) error {
+	stream, err := p.ParseStream(filename)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
 
-	var headers []string
 	var records []OrderedMap
+	ragged := 0 // Ragged rows counted by earlier chunks
 
-	rowNum := 0
-	for {
-		record, err := reader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to read CSV record at row %d: %w", rowNum, err)
-		}
+	// flush hands the buffered rows to fn and starts a new chunk
+	flush := func() error {
+		chunk := &ParseResult{Headers: stream.Headers(), Rows: records, Encoding: stream.Encoding(), RaggedRows: stream.RaggedRows() - ragged}
+		records, ragged = nil, stream.RaggedRows()
+		return fn(chunk)
+	}
 
-		// First row handling
-		if rowNum == 0 {
-			if p.hasHeader {
-				headers = record
-			} else {
-				// Generate column names: col_0, col_1, etc.
-				for i := range record {
-					headers = append(headers, fmt.Sprintf("col_%d", i))
-				}
-				// Process this row as data
-				row := OrderedMap{
-					Keys:   headers,
-					Values: make(map[string]string),
-				}
-				for i, value := range record {
-					row.Values[headers[i]] = value
-				}
-				records = append(records, row)
-			}
-		} else {
-			// Subsequent rows
-			if len(record) != len(headers) {
-				return nil, fmt.Errorf("row %d has %d columns, expected %d", rowNum, len(record), len(headers))
+	for stream.Next() {
+		records = append(records, stream.Row())
+		if chunkRows > 0 && len(records) == chunkRows {
+			if err := flush(); err != nil {
+				return err
 			}
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return err
+	}
+	if len(records) > 0 {
+		return flush()
+	}
+	return nil
+}
 
-			row := OrderedMap{
-				Keys:   headers,
-				Values: make(map[string]string),
-			}
-			for i, value := range record {
-				row.Values[headers[i]] = value
-			}
-			records = append(records, row)
+// newRow maps a record's fields to headers, adding its raw text when RawField is set
+func (p *Parser) newRow(headers, record []string, reader recordReader) OrderedMap {
+	row := OrderedMap{
+		Keys:   headers,
+		Values: make(map[string]string, len(headers)),
+	}
+	for i, value := range record {
+		row.Values[headers[i]] = value
+	}
+	if p.rawField != "" {
+		raw, _, _ := sanitizeUTF8(reader.(rawReader).raw(), p.invalidUTF8Policy)
+		row.Values[p.rawField] = raw
+	}
+	return row
+}
+
+// withRawField returns the headers of a file with the given columns: the columns,
+// followed by RawField when set
+func (p *Parser) withRawField(columns []string) ([]string, error) {
+	if p.rawField == "" {
+		return columns, nil
+	}
+	for _, column := range columns {
+		if column == p.rawField {
+			return nil, fmt.Errorf("raw line field '%s' is also a column of the file", p.rawField)
 		}
+	}
+	return append(columns[:len(columns):len(columns)], p.rawField), nil
+}
 
-		rowNum++
+// rawColumns returns the number of headers not taken from the record (the raw field)
+func (p *Parser) rawColumns() int {
+	if p.rawField == "" {
+		return 0
+	}
+	return 1
+}
+
+// newRecordReader returns the record reader for the configured input format, with the
+// column count sampled from a headerless file's leading records (0 = not sampled)
+func (p *Parser) newRecordReader(r io.Reader) (recordReader, int) {
+	switch p.format {
+	case FormatWhitespace:
+		return p.sample(&whitespaceReader{lines: newLineReader(r)})
+	case FormatFixedWidth:
+		return &fixedWidthReader{lines: newLineReader(r), columns: p.columns}, 0
+	case FormatTSV:
+		records, sampled := p.sample(&tsvReader{lines: newLineReader(r)})
+		if p.reassembleLines {
+			return &reassemblingReader{reader: records, expected: sampled}, sampled
+		}
+		return records, sampled
+	default:
+		var raw *rawCSVReader
+		if p.rawField != "" {
+			raw = &rawCSVReader{}
+			r = io.TeeReader(r, &raw.pending)
+		}
+		reader := csv.NewReader(r)
+		reader.Comma = p.delimiter
+		reader.LazyQuotes = true
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1 // Column counts are checked against the headers by the ragged row policy
+		var records recordReader = reader
+		if raw != nil {
+			raw.reader = reader
+			records = raw
+		}
+		records, sampled := p.sample(records)
+		if p.reassembleLines {
+			return &reassemblingReader{reader: records, expected: sampled}, sampled
+		}
+		return records, sampled
 	}
+}
 
-	if len(records) == 0 {
-		return nil, fmt.Errorf("no data rows found in file")
+// sample samples the column count of headerless files when ColumnSample is set
+func (p *Parser) sample(reader recordReader) (recordReader, int) {
+	if p.hasHeader || p.columnSample <= 0 {
+		return reader, 0
 	}
+	return sampleColumns(reader, p.columnSample)
+}
 
-	return &ParseResult{Headers: headers, Rows: records}, nil
+// sanitizeRecord applies the invalid UTF-8 policy to every field of a record in place.
+// rowNum is 0-based; errors report 1-based row and column numbers.
+func (p *Parser) sanitizeRecord(record, headers []string, rowNum int) error {
+	for i, value := range record {
+		sanitized, offset, ok := sanitizeUTF8(value, p.invalidUTF8Policy)
+		if !ok {
+			column := fmt.Sprintf("%d", i+1)
+			if i < len(headers) {
+				column = fmt.Sprintf("%d (%s)", i+1, headers[i])
+			}
+			return &failure.Error{Kind: failure.ErrParse, Row: rowNum + 1, Column: column,
+				Err: fmt.Errorf("invalid UTF-8 byte sequence at row %d, column %s, byte offset %d", rowNum+1, column, offset)}
+		}
+		record[i] = sanitized
+	}
+	return nil
 }
 
 // Parse maintains backward compatibility with old signature
@@ -127,13 +280,27 @@ func (p *Parser) Validate(filename string) error {
 	}
 	defer file.Close()
 
-	// Read first 4KB to validate content
+	decoded, _, err := newDecodingReader(file, p.encoding)
+	if err != nil {
+		return err
+	}
+
+	// Read first 4KB (decoded) to validate content
 	buf := make([]byte, 4096)
-	n, err := file.Read(buf)
-	if err != nil && err != io.EOF {
+	n, err := io.ReadFull(decoded, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return fmt.Errorf("cannot read file: %w", err)
 	}
 
+	if n == 0 {
+		return fmt.Errorf("file is empty: %w", ErrNoDataRows)
+	}
+
+	// Plain-text formats have no delimiter to look for
+	if p.format == FormatWhitespace || p.format == FormatFixedWidth || p.format == FormatMulti {
+		return nil
+	}
+
 	content := string(buf[:n])
 	if !strings.Contains(content, string(p.delimiter)) {
 		return fmt.Errorf("file does not appear to contain delimiter '%c'", p.delimiter)
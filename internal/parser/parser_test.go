@@ -1,8 +1,14 @@
 package parser
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+
+	"csv2json/internal/failure"
 )
 
 // TestParseValidBasicCSV validates basic CSV parsing functionality
@@ -123,6 +129,13 @@ func TestParseInvalidEmpty(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for empty file, got success")
 	}
+	if !errors.Is(err, ErrNoDataRows) {
+		t.Errorf("Expected ErrNoDataRows for empty file, got: %v", err)
+	}
+
+	if err := p.Validate("../../testdata/invalid_empty.csv"); !errors.Is(err, ErrNoDataRows) {
+		t.Errorf("Expected Validate to return ErrNoDataRows for empty file, got: %v", err)
+	}
 }
 
 // TestParseInvalidHeaderOnly validates header-only file handling
@@ -134,6 +147,9 @@ func TestParseInvalidHeaderOnly(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for header-only file, got success")
 	}
+	if !errors.Is(err, ErrNoDataRows) {
+		t.Errorf("Expected ErrNoDataRows for header-only file, got: %v", err)
+	}
 }
 
 // TestParseInvalidMismatchedColumns validates strict column count enforcement
@@ -145,6 +161,10 @@ func TestParseInvalidMismatchedColumns(t *testing.T) {
 	if err == nil {
 		t.Fatal("Expected error for mismatched columns, got success")
 	}
+	var parseErr *failure.Error
+	if !errors.Is(err, failure.ErrParse) || !errors.As(err, &parseErr) || parseErr.Row == 0 {
+		t.Errorf("Expected a parse error carrying the row, got %#v", err)
+	}
 }
 
 // TestParseInvalidNoHeader validates no-header file handling when header expected
@@ -177,6 +197,246 @@ func TestParseNonexistentFile(t *testing.T) {
 	}
 }
 
+// TestParseChunks validates rows are passed on in chunks that keep file order and headers
+func TestParseChunks(t *testing.T) {
+	path := t.TempDir() + "/chunks.csv"
+	if err := os.WriteFile(path, []byte("id,name\n1,a\n2,b\n3,c\n4,d\n5,e\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		chunkRows int
+		want      []int
+	}{
+		{2, []int{2, 2, 1}},
+		{5, []int{5}},
+		{0, []int{5}},
+	}
+
+	p := New(',', '"', true)
+	for _, tt := range tests {
+		var sizes []int
+		var ids []string
+		err := p.ParseChunks(path, tt.chunkRows, func(chunk *ParseResult) error {
+			if len(chunk.Headers) != 2 || chunk.Encoding == "" {
+				t.Errorf("Expected headers and encoding on every chunk, got %v %q", chunk.Headers, chunk.Encoding)
+			}
+			sizes = append(sizes, len(chunk.Rows))
+			for _, row := range chunk.Rows {
+				ids = append(ids, row.Values["id"])
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ParseChunks(%d) failed: %v", tt.chunkRows, err)
+		}
+		if fmt.Sprint(sizes) != fmt.Sprint(tt.want) || strings.Join(ids, "") != "12345" {
+			t.Errorf("ParseChunks(%d): expected chunks %v of rows 12345, got %v of %v", tt.chunkRows, tt.want, sizes, ids)
+		}
+	}
+
+	// Errors from fn stop parsing
+	stop := errors.New("stop")
+	calls := 0
+	err := p.ParseChunks(path, 1, func(*ParseResult) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("Expected parsing to stop at the first error, got %v after %d call(s)", err, calls)
+	}
+}
+
+// TestParseStream validates rows are read one at a time in file order
+func TestParseStream(t *testing.T) {
+	dir := t.TempDir()
+	path := dir + "/stream.csv"
+	if err := os.WriteFile(path, []byte("id,name\n1,a\n2,b,extra\n3,c\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	p := NewWithOptions(',', '"', true, Options{RaggedRowPolicy: "skip"})
+	stream, err := p.ParseStream(path)
+	if err != nil {
+		t.Fatalf("ParseStream failed: %v", err)
+	}
+	defer stream.Close()
+
+	var ids []string
+	for stream.Next() {
+		ids = append(ids, stream.Row().Values["id"])
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Expected the stream to end cleanly, got %v", err)
+	}
+	if strings.Join(ids, "") != "13" {
+		t.Errorf("Expected rows 1 and 3, got %v", ids)
+	}
+	if strings.Join(stream.Headers(), ",") != "id,name" || stream.RaggedRows() != 1 || stream.Encoding() == "" {
+		t.Errorf("Expected headers id,name, 1 ragged row and an encoding, got %v, %d, %q", stream.Headers(), stream.RaggedRows(), stream.Encoding())
+	}
+	if stream.Next() {
+		t.Error("Expected Next to stay false after the end of the file")
+	}
+
+	// A header-only file ends with ErrNoDataRows
+	headerOnly := dir + "/header.csv"
+	if err := os.WriteFile(headerOnly, []byte("id,name\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	empty, err := p.ParseStream(headerOnly)
+	if err != nil {
+		t.Fatalf("ParseStream failed: %v", err)
+	}
+	defer empty.Close()
+	if empty.Next() || !errors.Is(empty.Err(), ErrNoDataRows) {
+		t.Errorf("Expected ErrNoDataRows, got %v", empty.Err())
+	}
+
+	if _, err := p.ParseStream(dir + "/missing.csv"); err == nil {
+		t.Error("Expected error for nonexistent file, got success")
+	}
+}
+
+// TestParseRawField validates each record's source text is kept under RawField,
+// including quoted line breaks, for every input format
+func TestParseRawField(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    []string
+	}{
+		{"delimited", "id,note\r\n1, \"a \"\"b\"\"\"\r\n\r\n2,\"multi\r\nline\"\r\n3,last", Options{},
+			[]string{`1, "a ""b"""`, "2,\"multi\r\nline\"", "3,last"}},
+		{"whitespace", "id note\n1   a\r\n\n2\tb\n", Options{Format: FormatWhitespace},
+			[]string{"1   a", "2\tb"}},
+		{"fixed-width", "1  a\n22 bb\n", Options{Format: FormatFixedWidth, FixedWidthColumns: []FixedWidthColumn{{Name: "id", Width: 3}, {Name: "note", Width: 2}}},
+			[]string{"1  a", "22 bb"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/raw.csv"
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			tt.opts.RawField = "_raw"
+			header := tt.opts.Format != FormatFixedWidth
+			result, err := NewWithOptions(',', '"', header, tt.opts).ParseWithOrder(path)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			if strings.Join(result.Headers, ",") != "id,note,_raw" {
+				t.Errorf("Expected the raw field after the parsed columns, got %v", result.Headers)
+			}
+			var raw []string
+			for _, row := range result.Rows {
+				raw = append(raw, row.Values["_raw"])
+			}
+			if !reflect.DeepEqual(raw, tt.want) {
+				t.Errorf("Expected raw lines %q, got %q", tt.want, raw)
+			}
+		})
+	}
+
+	// The raw field may not shadow a column of the file
+	path := t.TempDir() + "/clash.csv"
+	if err := os.WriteFile(path, []byte("id,_raw\n1,a\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if _, err := NewWithOptions(',', '"', true, Options{RawField: "_raw"}).ParseWithOrder(path); err == nil {
+		t.Error("Expected an error for a column named like the raw field")
+	}
+}
+
+// TestParseReassembleLines validates records split by unquoted line breaks are joined
+// back to the header's column count, keeping the line break in the split field
+func TestParseReassembleLines(t *testing.T) {
+	path := t.TempDir() + "/broken.csv"
+	content := "id,note,amount\n1,first line\nsecond line,10\n2,one\ntwo\nthree,20\n3,\"quoted\nbreak\",30\n4,short\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// Without reassembly the split record is rejected
+	if _, err := New(',', '"', true).ParseWithOrder(path); err == nil {
+		t.Fatal("Expected an error for split records")
+	}
+
+	p := NewWithOptions(',', '"', true, Options{ReassembleLines: true, RawField: "_raw"})
+	_, err := p.ParseWithOrder(path)
+	if err == nil || !strings.Contains(err.Error(), "row 4 has 2 columns, expected 3") {
+		t.Fatalf("Expected the trailing short record to be rejected, got: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(strings.TrimSuffix(content, "4,short\n")), 0644); err != nil {
+		t.Fatalf("Failed to rewrite test file: %v", err)
+	}
+	result, err := p.ParseWithOrder(path)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	var notes, raw []string
+	for _, row := range result.Rows {
+		notes = append(notes, row.Values["note"]+"|"+row.Values["amount"])
+		raw = append(raw, row.Values["_raw"])
+	}
+	if want := []string{"first line\nsecond line|10", "one\ntwo\nthree|20", "quoted\nbreak|30"}; !reflect.DeepEqual(notes, want) {
+		t.Errorf("Expected notes %q, got %q", want, notes)
+	}
+	if raw[1] != "2,one\ntwo\nthree,20" {
+		t.Errorf("Expected the raw text of the joined lines, got %q", raw[1])
+	}
+}
+
+// TestParseRaggedRows validates the ragged row policies, relative to the column count
+// sampled from a headerless file's leading records rather than its first line
+func TestParseRaggedRows(t *testing.T) {
+	path := t.TempDir() + "/ragged.csv"
+	content := "EXPORT 2024-05-01\n1,a,10\n2,b,20\n3,c\n4,d,40\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	tests := []struct {
+		policy string
+		sample int
+		want   []string // col_0 of each row
+		ragged int
+	}{
+		{RaggedRowSkip, 0, []string{"EXPORT 2024-05-01"}, 4},
+		{RaggedRowSkip, 3, []string{"1", "2", "4"}, 2},
+		{RaggedRowPad, 3, []string{"EXPORT 2024-05-01", "1", "2", "3", "4"}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s_%d", tt.policy, tt.sample), func(t *testing.T) {
+			p := NewWithOptions(',', '"', false, Options{RaggedRowPolicy: tt.policy, ColumnSample: tt.sample})
+			result, err := p.ParseWithOrder(path)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			var got []string
+			for _, row := range result.Rows {
+				got = append(got, row.Values["col_0"])
+			}
+			if !reflect.DeepEqual(got, tt.want) || result.RaggedRows != tt.ragged {
+				t.Errorf("Expected rows %q with %d ragged, got %q with %d", tt.want, tt.ragged, got, result.RaggedRows)
+			}
+			if tt.sample > 0 && len(result.Headers) != 3 {
+				t.Errorf("Expected 3 sampled columns, got %v", result.Headers)
+			}
+		})
+	}
+
+	// The default policy rejects the first line that does not match the sampled count
+	_, err := NewWithOptions(',', '"', false, Options{ColumnSample: 3}).ParseWithOrder(path)
+	if err == nil || !strings.Contains(err.Error(), "row 0 has 1 columns, expected 3") {
+		t.Errorf("Expected the garbage line to be rejected, got: %v", err)
+	}
+}
+
 // TestParserConfigValidation validates parser configuration
 func TestParserConfigValidation(t *testing.T) {
 	// Test different delimiters
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"fmt"
+
+	"csv2json/internal/failure"
+)
+
+// Ragged row policies, applied to data rows whose column count differs from the header's
+const (
+	RaggedRowFail = "fail" // Reject the file (default)
+	RaggedRowSkip = "skip" // Drop the row
+	RaggedRowPad  = "pad"  // Pad short rows with empty values; longer rows are rejected
+)
+
+// ValidateRaggedRowPolicy returns an error if policy is not a supported ragged row policy.
+// An empty policy is valid and means the default (fail).
+func ValidateRaggedRowPolicy(policy string) error {
+	switch policy {
+	case "", RaggedRowFail, RaggedRowSkip, RaggedRowPad:
+		return nil
+	default:
+		return fmt.Errorf("unsupported ragged row policy: %s (supported: fail, skip, pad)", policy)
+	}
+}
+
+// fitRecord applies the ragged row policy to a data record, returning the record to
+// use (nil = skipped) and whether it was ragged
+func (p *Parser) fitRecord(record []string, expected, rowNum int) ([]string, bool, error) {
+	if len(record) == expected {
+		return record, false, nil
+	}
+	switch {
+	case p.raggedRowPolicy == RaggedRowSkip:
+		return nil, true, nil
+	case p.raggedRowPolicy == RaggedRowPad && len(record) < expected:
+		return append(record, make([]string, expected-len(record))...), true, nil
+	}
+	return nil, true, &failure.Error{Kind: failure.ErrParse, Row: rowNum + 1, Err: fmt.Errorf("row %d has %d columns, expected %d", rowNum, len(record), expected)}
+}
+
+// sampledReader replays the records read ahead to sample a headerless file's column
+// count (Options.ColumnSample), then reads on
+type sampledReader struct {
+	reader  recordReader
+	records [][]string
+	raws    []string
+	err     error // Error that ended the sample, returned once the records are replayed
+	last    string
+}
+
+// sampleColumns reads up to n records ahead and returns a reader replaying them with
+// the dominant column count among them: the most common, ties going to the count seen
+// first (0 = no records)
+func sampleColumns(reader recordReader, n int) (*sampledReader, int) {
+	s := &sampledReader{reader: reader}
+	counts := make(map[int]int)
+	var order []int
+	for len(s.records) < n {
+		record, err := reader.Read()
+		if err != nil {
+			s.err = err
+			break
+		}
+		s.records = append(s.records, record)
+		if raw, ok := reader.(rawReader); ok {
+			s.raws = append(s.raws, raw.raw())
+		}
+		if counts[len(record)] == 0 {
+			order = append(order, len(record))
+		}
+		counts[len(record)]++
+	}
+
+	dominant := 0
+	for _, count := range order {
+		if counts[count] > counts[dominant] {
+			dominant = count
+		}
+	}
+	return s, dominant
+}
+
+func (s *sampledReader) Read() ([]string, error) {
+	if len(s.records) > 0 {
+		record := s.records[0]
+		s.records = s.records[1:]
+		if len(s.raws) > 0 {
+			s.last, s.raws = s.raws[0], s.raws[1:]
+		}
+		return record, nil
+	}
+	if s.err != nil {
+		return nil, s.err
+	}
+	record, err := s.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	if raw, ok := s.reader.(rawReader); ok {
+		s.last = raw.raw()
+	}
+	return record, nil
+}
+
+func (s *sampledReader) raw() string {
+	return s.last
+}
@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+// rawReader is a recordReader that also returns the source text of the record it
+// read last, without its line ending (Options.RawField)
+type rawReader interface {
+	recordReader
+	raw() string
+}
+
+// rawCSVReader reads delimited records and keeps the decoded input of each. The csv
+// reader's input is teed into pending; the record's end offset attributes the text
+// read since the previous record to it.
+type rawCSVReader struct {
+	reader  *csv.Reader
+	pending bytes.Buffer // Input read but not yet attributed to a record
+	offset  int64        // Input offset of the start of pending
+	last    string
+}
+
+func (r *rawCSVReader) Read() ([]string, error) {
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	end := r.reader.InputOffset()
+	text := string(r.pending.Next(int(end - r.offset)))
+	r.offset = end
+
+	// Blank lines before the record are skipped by the reader
+	r.last = strings.TrimRight(strings.TrimLeft(text, "\r\n"), "\r\n")
+	return record, nil
+}
+
+func (r *rawCSVReader) raw() string {
+	return r.last
+}
+
+func (w *whitespaceReader) raw() string {
+	return w.lines.last
+}
+
+func (t *tsvReader) raw() string {
+	return t.lines.last
+}
+
+func (f *fixedWidthReader) raw() string {
+	return f.lines.last
+}
+
+func (m *multiRecordReader) raw() string {
+	return m.lines.last
+}
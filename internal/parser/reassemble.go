@@ -0,0 +1,65 @@
+package parser
+
+// reassemblingReader joins delimited or TSV records that broken exports split with
+// unquoted line breaks (Options.ReassembleLines). The first record (the header, if any)
+// sets the expected column count, unless it was sampled (Options.ColumnSample). A
+// shorter record is joined with the records after it, keeping the line break in the
+// field it split, as long as the joined record does not exceed the expected count.
+type reassemblingReader struct {
+	reader   recordReader
+	expected int // Expected column count (0 = taken from the first record)
+
+	// One record read ahead that did not fit the record before it
+	ahead    []string
+	aheadRaw string
+	aheadErr error
+	hasAhead bool
+
+	last string // Source text of the record returned last
+}
+
+func (r *reassemblingReader) Read() ([]string, error) {
+	record, raw, err := r.read()
+	if err != nil {
+		return nil, err
+	}
+	if r.expected == 0 {
+		r.expected = len(record)
+	}
+
+	for len(record) < r.expected {
+		next, nextRaw, err := r.read()
+		if err != nil || len(record)+len(next)-1 > r.expected {
+			r.ahead, r.aheadRaw, r.aheadErr, r.hasAhead = next, nextRaw, err, true
+			break
+		}
+		joined := make([]string, 0, len(record)+len(next)-1)
+		joined = append(joined, record[:len(record)-1]...)
+		joined = append(joined, record[len(record)-1]+"\n"+next[0])
+		record = append(joined, next[1:]...)
+		raw += "\n" + nextRaw
+	}
+
+	r.last = raw
+	return record, nil
+}
+
+// read returns the record read ahead, else the next record with its source text
+func (r *reassemblingReader) read() ([]string, string, error) {
+	if r.hasAhead {
+		r.hasAhead = false
+		return r.ahead, r.aheadRaw, r.aheadErr
+	}
+	record, err := r.reader.Read()
+	if err != nil {
+		return nil, "", err
+	}
+	if raw, ok := r.reader.(rawReader); ok {
+		return record, raw.raw(), nil
+	}
+	return record, "", nil
+}
+
+func (r *reassemblingReader) raw() string {
+	return r.last
+}
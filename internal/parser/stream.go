@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"csv2json/internal/failure"
+)
+
+// RowStream reads a file's data rows one at a time, so a file of any size is parsed
+// in constant memory. Iterate with Next and Row, then check Err:
+//
+//	for stream.Next() {
+//		row := stream.Row()
+//	}
+//	if err := stream.Err(); err != nil { ... }
+type RowStream struct {
+	file     *os.File
+	read     func() (OrderedMap, bool, error) // Reads the next row; false at the end of the file
+	row      OrderedMap
+	headers  []string
+	encoding string
+	ragged   int
+	rows     int
+	err      error
+}
+
+// ParseStream opens a file for reading row by row. The caller must Close the stream.
+func (p *Parser) ParseStream(filename string) (*RowStream, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	decoded, encoding, err := newDecodingReader(file, p.encoding)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	stream := &RowStream{file: file, encoding: encoding}
+	if p.format == FormatMulti {
+		if stream.headers, stream.read, err = p.multiRecordRows(decoded); err != nil {
+			file.Close()
+			return nil, err
+		}
+		return stream, nil
+	}
+	stream.read = p.delimitedRows(decoded, stream)
+	return stream, nil
+}
+
+// Next advances to the next data row, returning false at the end of the file or on
+// an error. A file without data rows ends with ErrNoDataRows.
+func (s *RowStream) Next() bool {
+	if s.err != nil || s.read == nil {
+		return false
+	}
+	row, ok, err := s.read()
+	if err != nil {
+		s.err = err
+		return false
+	}
+	if !ok {
+		s.read = nil
+		if s.rows == 0 {
+			s.err = ErrNoDataRows
+		}
+		return false
+	}
+	s.row = row
+	s.rows++
+	return true
+}
+
+// Row returns the row read by the last call to Next
+func (s *RowStream) Row() OrderedMap {
+	return s.row
+}
+
+// Headers returns the file's column names, known once Next has been called
+func (s *RowStream) Headers() []string {
+	return s.headers
+}
+
+// Encoding returns the source encoding the file is decoded from
+func (s *RowStream) Encoding() string {
+	return s.encoding
+}
+
+// RaggedRows returns the number of rows skipped or padded by the ragged row policy so far
+func (s *RowStream) RaggedRows() int {
+	return s.ragged
+}
+
+// Err returns the error that ended the stream, or nil
+func (s *RowStream) Err() error {
+	return s.err
+}
+
+// Close closes the file
+func (s *RowStream) Close() error {
+	return s.file.Close()
+}
+
+// delimitedRows returns a function reading the data rows of the delimited and plain
+// text formats one at a time, setting the stream's headers from the first record
+func (p *Parser) delimitedRows(r io.Reader, stream *RowStream) func() (OrderedMap, bool, error) {
+	reader, sampled := p.newRecordReader(r)
+	rowNum := 0
+	return func() (OrderedMap, bool, error) {
+		for ; ; rowNum++ {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return OrderedMap{}, false, nil
+			}
+			if err != nil {
+				return OrderedMap{}, false, &failure.Error{Kind: failure.ErrParse, Row: rowNum + 1, Err: fmt.Errorf("failed to read record at row %d: %w", rowNum, err)}
+			}
+
+			if err := p.sanitizeRecord(record, stream.headers, rowNum); err != nil {
+				return OrderedMap{}, false, err
+			}
+
+			// First row handling
+			if rowNum == 0 {
+				// Named layout columns replace the header line
+				columns := columnNames(p.columns)
+				if p.hasHeader {
+					if columns == nil {
+						columns = record
+					}
+				} else if columns == nil {
+					// Generate column names: col_0, col_1, etc., as many as the sampled column count
+					count := len(record)
+					if sampled > 0 {
+						count = sampled
+					}
+					for i := 0; i < count; i++ {
+						columns = append(columns, fmt.Sprintf("col_%d", i))
+					}
+				}
+				if stream.headers, err = p.withRawField(columns); err != nil {
+					return OrderedMap{}, false, err
+				}
+				if p.hasHeader {
+					continue
+				}
+			}
+
+			// Data rows (a headerless file's first row included)
+			fitted, isRagged, err := p.fitRecord(record, len(stream.headers)-p.rawColumns(), rowNum)
+			if err != nil {
+				return OrderedMap{}, false, err
+			}
+			if isRagged {
+				stream.ragged++
+			}
+			if fitted != nil {
+				row := p.newRow(stream.headers, fitted, reader)
+				rowNum++
+				return row, true, nil
+			}
+		}
+	}
+}
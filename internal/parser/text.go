@@ -0,0 +1,187 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"csv2json/internal/failure"
+)
+
+// Input formats
+const (
+	FormatDelimited  = "delimited"    // Delimiter-separated values with optional quoting (default)
+	FormatWhitespace = "whitespace"   // Fields separated by runs of spaces or tabs
+	FormatFixedWidth = "fixed-width"  // Fields at fixed character positions
+	FormatTSV        = "tsv"          // Tab-separated values, one record per line, quotes taken literally
+	FormatMulti      = "multi-record" // Fixed-width records whose layout is chosen by a record type prefix, nested under header records
+)
+
+// FixedWidthColumn is one field of a fixed-width layout
+type FixedWidthColumn struct {
+	Name  string `json:"name,omitempty"` // Column name ("" = taken from the header line or generated)
+	Width int    `json:"width"`          // Width in characters
+}
+
+// ValidateFormat returns an error if format is not a supported input format.
+// An empty format is valid and means the default (delimited).
+func ValidateFormat(format string) error {
+	switch format {
+	case "", FormatDelimited, FormatWhitespace, FormatFixedWidth, FormatTSV, FormatMulti:
+		return nil
+	default:
+		return fmt.Errorf("unsupported input format: %s (supported: delimited, tsv, whitespace, fixed-width, multi-record)", format)
+	}
+}
+
+// ParseFixedWidthColumns parses a layout such as "id:6,name:20,amount:10" (or
+// unnamed widths "6,20,10") into fixed-width columns
+func ParseFixedWidthColumns(spec string) ([]FixedWidthColumn, error) {
+	var columns []FixedWidthColumn
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		name, width := "", field
+		if i := strings.LastIndex(field, ":"); i >= 0 {
+			name, width = strings.TrimSpace(field[:i]), strings.TrimSpace(field[i+1:])
+		}
+		n, err := strconv.Atoi(width)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid width in %q: must be a positive integer", field)
+		}
+		columns = append(columns, FixedWidthColumn{Name: name, Width: n})
+	}
+	return columns, nil
+}
+
+// ValidateFixedWidthColumns checks a layout is usable: at least one column, positive
+// widths, and either every column named or none
+func ValidateFixedWidthColumns(columns []FixedWidthColumn) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("fixed-width format requires at least one column")
+	}
+	named := 0
+	for _, column := range columns {
+		if column.Width <= 0 {
+			return fmt.Errorf("column %q has invalid width %d", column.Name, column.Width)
+		}
+		if column.Name != "" {
+			named++
+		}
+	}
+	if named != 0 && named != len(columns) {
+		return fmt.Errorf("either all fixed-width columns must be named or none")
+	}
+	return nil
+}
+
+// recordReader yields one record per call and io.EOF at the end (implemented by csv.Reader)
+type recordReader interface {
+	Read() ([]string, error)
+}
+
+// lineReader reads non-blank lines, stripping line endings
+type lineReader struct {
+	scanner *bufio.Scanner
+	line    int
+	last    string // The line most recently returned, without its line ending
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	return &lineReader{scanner: scanner}
+}
+
+func (l *lineReader) next() (string, error) {
+	for l.scanner.Scan() {
+		l.line++
+		line := strings.TrimRight(l.scanner.Text(), "\r")
+		if strings.TrimSpace(line) != "" {
+			l.last = line
+			return line, nil
+		}
+	}
+	if err := l.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// whitespaceReader splits each line on runs of spaces and tabs
+type whitespaceReader struct {
+	lines *lineReader
+}
+
+func (w *whitespaceReader) Read() ([]string, error) {
+	line, err := w.lines.next()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(line), nil
+}
+
+// tsvReader splits each line on tabs. Fields cannot contain tabs or line breaks, so
+// quotes are ordinary characters and values are kept exactly as written.
+type tsvReader struct {
+	lines *lineReader
+}
+
+func (t *tsvReader) Read() ([]string, error) {
+	line, err := t.lines.next()
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(line, "\t"), nil
+}
+
+// fixedWidthReader slices each line at the layout's character positions and trims
+// padding. Short lines yield empty trailing fields; text beyond the layout is an error.
+type fixedWidthReader struct {
+	lines   *lineReader
+	columns []FixedWidthColumn
+}
+
+func (f *fixedWidthReader) Read() ([]string, error) {
+	line, err := f.lines.next()
+	if err != nil {
+		return nil, err
+	}
+	return sliceFixedWidth(line, f.columns, f.lines.line)
+}
+
+// sliceFixedWidth slices line number lineNum at the layout's character positions
+func sliceFixedWidth(line string, columns []FixedWidthColumn, lineNum int) ([]string, error) {
+	record := make([]string, len(columns))
+	pos := 0 // Byte offset; widths count characters (an invalid byte counts as one)
+	for i, column := range columns {
+		start := pos
+		for n := 0; n < column.Width && pos < len(line); n++ {
+			_, size := utf8.DecodeRuneInString(line[pos:])
+			pos += size
+		}
+		record[i] = strings.TrimSpace(line[start:pos])
+	}
+	if rest := strings.TrimSpace(line[pos:]); rest != "" {
+		return nil, &failure.Error{Kind: failure.ErrParse, Row: lineNum,
+			Err: fmt.Errorf("line %d is longer than the fixed-width layout (extra text %q)", lineNum, rest)}
+	}
+	return record, nil
+}
+
+// columnNames returns the names given by a fixed-width layout, or nil if unnamed
+func columnNames(columns []FixedWidthColumn) []string {
+	if len(columns) == 0 || columns[0].Name == "" {
+		return nil
+	}
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return names
+}
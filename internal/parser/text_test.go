@@ -0,0 +1,228 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemp writes content to a temporary file and returns its path
+func writeTemp(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	return path
+}
+
+// TestParseWhitespace validates runs of spaces and tabs separate fields and blank lines are skipped
+func TestParseWhitespace(t *testing.T) {
+	path := writeTemp(t, "id   name\tqty\n1  widget   3\n\n2\tgadget 10\r\n")
+	p := NewWithOptions(',', '"', true, Options{Format: FormatWhitespace})
+
+	if err := p.Validate(path); err != nil {
+		t.Fatalf("Expected whitespace file to validate without a delimiter, got: %v", err)
+	}
+	result, err := p.ParseWithOrder(path)
+	if err != nil {
+		t.Fatalf("Expected successful parse, got error: %v", err)
+	}
+	if strings.Join(result.Headers, ",") != "id,name,qty" || len(result.Rows) != 2 {
+		t.Fatalf("Unexpected result: headers %v, %d rows", result.Headers, len(result.Rows))
+	}
+	if result.Rows[1].Values["name"] != "gadget" || result.Rows[1].Values["qty"] != "10" {
+		t.Errorf("Unexpected second row: %v", result.Rows[1].Values)
+	}
+}
+
+// TestParseTSV validates tab-separated input: the tab delimiter whatever the given one,
+// literal quotes, and values kept exactly as written
+func TestParseTSV(t *testing.T) {
+	path := writeTemp(t, "id\tname\tnote\n1\t\"Big\" widget\t  indented, with comma\n\n2\tgadget\t\r\n")
+	p := NewWithOptions(',', '"', true, Options{Format: FormatTSV})
+
+	if err := p.Validate(path); err != nil {
+		t.Fatalf("Expected TSV file to validate, got: %v", err)
+	}
+	result, err := p.ParseWithOrder(path)
+	if err != nil {
+		t.Fatalf("Expected successful parse, got error: %v", err)
+	}
+	if strings.Join(result.Headers, ",") != "id,name,note" || len(result.Rows) != 2 {
+		t.Fatalf("Unexpected result: headers %v, %d rows", result.Headers, len(result.Rows))
+	}
+	if got := result.Rows[0].Values; got["name"] != `"Big" widget` || got["note"] != "  indented, with comma" {
+		t.Errorf("Unexpected first row: %q", got)
+	}
+	if got := result.Rows[1].Values; got["name"] != "gadget" || got["note"] != "" {
+		t.Errorf("Unexpected second row: %q", got)
+	}
+}
+
+// TestParseFixedWidth validates fixed-width slicing with named and unnamed layouts
+func TestParseFixedWidth(t *testing.T) {
+	content := "ID    NAME      AMOUNT\n" +
+		"000001Café      12.50\n" +
+		"000002Gadget\n"
+
+	tests := []struct {
+		name      string
+		layout    string
+		hasHeader bool
+		wantKeys  string
+		wantRows  int
+	}{
+		{"named layout replaces header line", "id:6,name:10,amount:6", true, "id,name,amount", 2},
+		{"unnamed layout uses header line", "6,10,6", true, "ID,NAME,AMOUNT", 2},
+		{"unnamed without header", "6,10,6", false, "col_0,col_1,col_2", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns, err := ParseFixedWidthColumns(tt.layout)
+			if err != nil {
+				t.Fatalf("Failed to parse layout: %v", err)
+			}
+			p := NewWithOptions(',', '"', tt.hasHeader, Options{Format: FormatFixedWidth, FixedWidthColumns: columns})
+			result, err := p.ParseWithOrder(writeTemp(t, content))
+			if err != nil {
+				t.Fatalf("Expected successful parse, got error: %v", err)
+			}
+			if strings.Join(result.Headers, ",") != tt.wantKeys || len(result.Rows) != tt.wantRows {
+				t.Fatalf("Expected headers %s and %d rows, got %v and %d", tt.wantKeys, tt.wantRows, result.Headers, len(result.Rows))
+			}
+
+			// Widths count characters, not bytes; short lines leave trailing fields empty
+			first := result.Rows[len(result.Rows)-2]
+			last := result.Rows[len(result.Rows)-1]
+			if first.Values[result.Headers[1]] != "Café" || first.Values[result.Headers[2]] != "12.50" {
+				t.Errorf("Unexpected row: %v", first.Values)
+			}
+			if last.Values[result.Headers[0]] != "000002" || last.Values[result.Headers[2]] != "" {
+				t.Errorf("Unexpected short row: %v", last.Values)
+			}
+		})
+	}
+}
+
+// TestParseFixedWidthOverflow validates text beyond the layout is rejected rather than dropped
+func TestParseFixedWidthOverflow(t *testing.T) {
+	p := NewWithOptions(',', '"', false, Options{Format: FormatFixedWidth, FixedWidthColumns: []FixedWidthColumn{{Width: 3}}})
+	_, err := p.ParseWithOrder(writeTemp(t, "abcdef\n"))
+	if err == nil || !strings.Contains(err.Error(), "longer than the fixed-width layout") {
+		t.Errorf("Expected overflow error, got: %v", err)
+	}
+}
+
+// TestFixedWidthColumnsValidation validates layout parsing and naming rules
+func TestFixedWidthColumnsValidation(t *testing.T) {
+	if _, err := ParseFixedWidthColumns("id:six"); err == nil {
+		t.Error("Expected error for non-numeric width, got success")
+	}
+	columns, err := ParseFixedWidthColumns("id:6, 4")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := ValidateFixedWidthColumns(columns); err == nil {
+		t.Error("Expected error for partially named layout, got success")
+	}
+	if err := ValidateFixedWidthColumns(nil); err == nil {
+		t.Error("Expected error for empty layout, got success")
+	}
+	if err := ValidateFormat("xml"); err == nil {
+		t.Error("Expected error for unsupported format, got success")
+	}
+}
+
+// TestParseMultiRecord validates header, detail and trailer records are sliced with the
+// layout their prefix selects and nested under the header record before them
+func TestParseMultiRecord(t *testing.T) {
+	layouts, err := ParseRecordLayouts("H:header=doc:6,date:8;D1:detail:items=sku:6,qty:4;D2:detail:notes=text:10;T:trailer=count:3")
+	if err != nil {
+		t.Fatalf("Expected layouts to parse, got: %v", err)
+	}
+	if err := ValidateRecordLayouts(layouts); err != nil {
+		t.Fatalf("Expected layouts to be valid, got: %v", err)
+	}
+	path := writeTemp(t, "H000001202601015\nD1ABC123   2\nD1XYZ999  10\nD2urgent\nT003\n\nH00000220260102\nD1QQQ001   1\n")
+	p := NewWithOptions(',', '"', true, Options{Format: FormatMulti, RecordLayouts: layouts, RawField: "_raw"})
+
+	if err := p.Validate(path); err != nil {
+		t.Fatalf("Expected multi-record file to validate without a delimiter, got: %v", err)
+	}
+	result, err := p.ParseWithOrder(path)
+	if err == nil {
+		t.Fatal("Expected the header overflowing its layout to fail the file")
+	}
+
+	path = writeTemp(t, "H00000120260101\nD1ABC123   2\nD1XYZ999  10\nD2urgent\nT003\n\nH00000220260102\nD1QQQ001   1\n")
+	result, err = p.ParseWithOrder(path)
+	if err != nil {
+		t.Fatalf("Expected successful parse, got error: %v", err)
+	}
+	if strings.Join(result.Headers, ",") != "doc,date,_raw,items,notes,trailer" || len(result.Rows) != 2 {
+		t.Fatalf("Unexpected result: headers %v, %d rows", result.Headers, len(result.Rows))
+	}
+	first := result.Rows[0]
+	if first.Values["doc"] != "000001" || first.Values["date"] != "20260101" || first.Values["_raw"] != "H00000120260101" {
+		t.Errorf("Unexpected first header: %v", first.Values)
+	}
+	if items := first.Nested["items"]; len(items) != 2 || items[1].Values["sku"] != "XYZ999" || items[1].Values["qty"] != "10" {
+		t.Errorf("Unexpected first items: %v", items)
+	}
+	if notes := first.Nested["notes"]; len(notes) != 1 || notes[0].Values["text"] != "urgent" {
+		t.Errorf("Unexpected first notes: %v", notes)
+	}
+	if trailer := first.Nested["trailer"]; len(trailer) != 1 || trailer[0].Values["count"] != "003" {
+		t.Errorf("Unexpected first trailer: %v", trailer)
+	}
+	second := result.Rows[1]
+	if len(second.Nested["items"]) != 1 || second.Nested["notes"] == nil || len(second.Nested["notes"]) != 0 {
+		t.Errorf("Unexpected second document: %v", second.Nested)
+	}
+
+	// Chunks split between documents
+	var chunks []int
+	if err := p.ParseChunks(path, 1, func(chunk *ParseResult) error {
+		chunks = append(chunks, len(chunk.Rows))
+		return nil
+	}); err != nil || len(chunks) != 2 {
+		t.Errorf("Expected one document per chunk, got %v (%v)", chunks, err)
+	}
+
+	for name, content := range map[string]string{
+		"detail before header":  "D1ABC123   2\nH00000120260101\n",
+		"detail after trailer":  "H00000120260101\nT001\nD1ABC123   2\n",
+		"unknown record prefix": "H00000120260101\nX1\n",
+	} {
+		if _, err := p.ParseWithOrder(writeTemp(t, content)); err == nil {
+			t.Errorf("%s: expected parse error, got success", name)
+		}
+	}
+}
+
+// TestRecordLayoutsValidation validates unusable record layouts are rejected
+func TestRecordLayoutsValidation(t *testing.T) {
+	for name, spec := range map[string]string{
+		"no header":           "D:detail=sku:6",
+		"two headers":         "H:header=doc:6;G:header=doc:6;D:detail=sku:6",
+		"header only":         "H:header=doc:6",
+		"duplicate prefix":    "H:header=doc:6;H:detail=sku:6",
+		"unknown role":        "H:header=doc:6;D:line=sku:6",
+		"unnamed columns":     "H:header=6;D:detail=sku:6",
+		"key is header field": "H:header=doc:6,details:2;D:detail=sku:6",
+	} {
+		layouts, err := ParseRecordLayouts(spec)
+		if err == nil {
+			err = ValidateRecordLayouts(layouts)
+		}
+		if err == nil {
+			t.Errorf("%s: expected error for %q, got success", name, spec)
+		}
+	}
+	if _, err := ParseRecordLayouts("H=doc:6"); err == nil {
+		t.Error("Expected error for a layout without a role, got success")
+	}
+}
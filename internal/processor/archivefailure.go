@@ -0,0 +1,203 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/metrics"
+	"csv2json/internal/output"
+)
+
+const metricArchiveFailures = "csv2json_archive_failures_total"
+
+func init() {
+	metrics.Register(metricArchiveFailures, metrics.Counter, "Files sent to the output that could not be archived as processed, by archive failure policy")
+}
+
+// quarantineFolder is the default quarantine folder, inside the input folder. Monitors
+// skip subfolders, so quarantined files are never picked up again.
+const quarantineFolder = ".quarantine"
+
+// publishedTracker remembers files that were sent to the output but could not be
+// archived (ARCHIVE_FAILURE_POLICY=mark), so the next scan archives them without
+// sending them again. Files are identified by name and content hash, and the state
+// survives restarts in .state/published.json.
+type publishedTracker struct {
+	mu    sync.Mutex
+	path  string
+	files map[string]string // Filename -> content hash
+}
+
+func newPublishedTracker(inputFolder string) *publishedTracker {
+	t := &publishedTracker{path: filepath.Join(inputFolder, stateFolder, "published.json"), files: make(map[string]string)}
+	content, err := os.ReadFile(t.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to read published files %s: %v", t.path, err)
+		}
+		return t
+	}
+	if err := json.Unmarshal(content, &t.files); err != nil {
+		log.Printf("Warning: ignoring invalid published files %s", t.path)
+		t.files = make(map[string]string)
+	}
+	return t
+}
+
+// published reports whether filename with content hash was already sent
+func (t *publishedTracker) published(filename, hash string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return hash != "" && t.files[filename] == hash
+}
+
+// mark records filename with content hash as sent
+func (t *publishedTracker) mark(filename, hash string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.files[filename] = hash
+	return t.save()
+}
+
+// clear forgets filename once it has been archived
+func (t *publishedTracker) clear(filename string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.files[filename]; !ok {
+		return
+	}
+	delete(t.files, filename)
+	if err := t.save(); err != nil {
+		log.Printf("Warning: failed to update published files %s: %v", t.path, err)
+	}
+}
+
+func (t *publishedTracker) save() error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t.files, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// archivePublished archives a file whose output was sent as processed. In exactly-once
+// mode the delivery is recorded first, so a file that is not archived is never sent
+// again. Otherwise, if archiving fails, the archive failure policy decides how the file
+// is kept from being sent again.
+func (p *Processor) archivePublished(filePath, filename, hash string) error {
+	recorded := false
+	if p.delivered != nil && hash != "" {
+		if err := p.delivered.delivered(hash, filename, output.IdempotencyKey(p.routeName, hash, "")); err != nil {
+			log.Printf("ALERT: failed to record delivery of %s: %v", filename, err)
+		} else {
+			recorded = true
+		}
+	}
+
+	err := p.archiver.Archive(filePath, archiver.CategoryProcessed, "")
+	if err == nil {
+		p.archivedPublished(filename, hash)
+		return nil
+	}
+
+	policy := p.config.ArchiveFailurePolicy
+	if policy == "" {
+		policy = config.ArchiveFailureReprocess
+	}
+	log.Printf("Failed to archive file: %v", err)
+	labels := p.routeLabels()
+	labels["policy"] = policy
+	metrics.Add(metricArchiveFailures, labels, 1)
+
+	switch policy {
+	case config.ArchiveFailureRetry:
+		delay := p.config.ArchiveRetryDelay
+		for attempt := 1; attempt <= p.config.ArchiveRetries; attempt++ {
+			log.Printf("Retrying archive of %s in %v (%d/%d)", filename, delay, attempt, p.config.ArchiveRetries)
+			time.Sleep(delay)
+			delay *= 2
+			if err = p.archiver.Archive(filePath, archiver.CategoryProcessed, ""); err == nil {
+				return nil
+			}
+			log.Printf("Failed to archive file: %v", err)
+		}
+
+	case config.ArchiveFailureQuarantine:
+		quarantined, qErr := p.quarantine(filePath)
+		if qErr == nil {
+			log.Printf("ALERT: %s was sent but could not be archived; quarantined as %s", filename, quarantined)
+			p.archivedPublished(filename, hash)
+			return nil
+		}
+		log.Printf("Failed to quarantine %s: %v", filename, qErr)
+
+	case config.ArchiveFailureMark:
+		if p.published == nil || recorded {
+			break
+		}
+		if markErr := p.published.mark(filename, hash); markErr != nil {
+			log.Printf("Failed to record %s as published: %v", filename, markErr)
+			break
+		}
+		log.Printf("ALERT: %s was sent but could not be archived; the next scan archives it without sending it again", filename)
+		return err
+	}
+
+	if recorded {
+		log.Printf("ALERT: %s was sent but could not be archived; the next scan archives it without sending it again", filename)
+		return err
+	}
+	log.Printf("ALERT: %s was sent but could not be archived; it will be processed and sent again", filename)
+	return err
+}
+
+// archivedPublished forgets that a sent file is waiting to be archived
+func (p *Processor) archivedPublished(filename, hash string) {
+	if p.published != nil {
+		p.published.clear(filename)
+	}
+	if p.delivered != nil && hash != "" {
+		if err := p.delivered.archived(hash); err != nil {
+			log.Printf("Warning: failed to record %s as archived: %v", filename, err)
+		}
+	}
+}
+
+// quarantine moves a file to the quarantine folder, returning its quarantined path
+func (p *Processor) quarantine(filePath string) (string, error) {
+	folder := p.config.ArchiveQuarantine
+	if folder == "" {
+		folder = filepath.Join(p.config.InputFolder, quarantineFolder)
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create quarantine folder: %w", err)
+	}
+	filename := filepath.Base(filePath)
+	ext := filepath.Ext(filename)
+	quarantined := filepath.Join(folder, fmt.Sprintf("%s_%s%s", filename[:len(filename)-len(ext)], p.archiver.Now().Format("20060102_150405"), ext))
+	if err := archiver.MoveFile(filePath, quarantined); err != nil {
+		return "", err
+	}
+	return quarantined, nil
+}
+
+// archiveUnarchived archives a file that was already sent before it could be archived
+// (ARCHIVE_FAILURE_POLICY=mark or EXACTLY_ONCE), without sending it again
+func (p *Processor) archiveUnarchived(filePath, filename, hash string) error {
+	log.Printf("%s was already sent, archiving it without sending it again", filename)
+	return p.archivePublished(filePath, filename, hash)
+}
@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestArchiveFailurePolicy validates how each policy handles a file that was sent but
+// could not be archived as processed
+func TestArchiveFailurePolicy(t *testing.T) {
+	tests := []struct {
+		policy      string
+		wantErr     bool
+		wantInInput bool
+	}{
+		{config.ArchiveFailureReprocess, true, true},
+		{config.ArchiveFailureRetry, true, true},
+		{config.ArchiveFailureQuarantine, false, false},
+		{config.ArchiveFailureMark, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dir := t.TempDir()
+			input := filepath.Join(dir, "input")
+			outputFolder := filepath.Join(dir, "output")
+			for _, folder := range []string{input, outputFolder} {
+				if err := os.MkdirAll(folder, 0755); err != nil {
+					t.Fatalf("Failed to create folder: %v", err)
+				}
+			}
+			// A file where the processed archive folder should be makes archiving fail
+			processed := filepath.Join(dir, "processed")
+			if err := os.WriteFile(processed, nil, 0644); err != nil {
+				t.Fatalf("Failed to block the archive folder: %v", err)
+			}
+
+			cfg := &config.Config{
+				InputFolder:          input,
+				ArchiveFailurePolicy: tt.policy,
+				ArchiveRetries:       2,
+				ArchiveRetryDelay:    time.Millisecond,
+				ArchiveProcessed:     processed,
+			}
+			p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+			if tt.policy == config.ArchiveFailureMark {
+				p.published = newPublishedTracker(input)
+			}
+
+			file := filepath.Join(input, "orders.csv")
+			if err := os.WriteFile(file, []byte("id,name\n1,widget\n"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			err := p.processFile(file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got: %v", tt.wantErr, err)
+			}
+			if _, err := os.Stat(file); (err == nil) != tt.wantInInput {
+				t.Errorf("Expected file in the input folder: %v, got stat error: %v", tt.wantInInput, err)
+			}
+
+			switch tt.policy {
+			case config.ArchiveFailureQuarantine:
+				quarantined, _ := filepath.Glob(filepath.Join(input, quarantineFolder, "orders_*.csv"))
+				if len(quarantined) != 1 {
+					t.Errorf("Expected the file in the quarantine folder, got %v", quarantined)
+				}
+			case config.ArchiveFailureMark:
+				// Once archiving works again, the next scan archives the file without sending it
+				os.Remove(processed)
+				os.Remove(filepath.Join(outputFolder, "orders.json"))
+				if err := p.processFile(file); err != nil {
+					t.Fatalf("Expected the marked file to be archived, got: %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(processed, "orders.csv")); err != nil {
+					t.Errorf("Expected the file archived as processed: %v", err)
+				}
+				if _, err := os.Stat(filepath.Join(outputFolder, "orders.json")); !os.IsNotExist(err) {
+					t.Error("Expected the marked file not to be sent again")
+				}
+				if len(newPublishedTracker(input).files) != 0 {
+					t.Error("Expected the mark to be cleared once archived")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"csv2json/internal/alert"
+	"csv2json/internal/config"
+	"csv2json/internal/metrics"
+	"csv2json/internal/sla"
+)
+
+// Arrival SLA metrics
+const (
+	metricSLAMissed   = "csv2json_sla_missed_total"
+	metricSLABreached = "csv2json_sla_breached"
+	metricLastArrival = "csv2json_last_arrival_timestamp_seconds"
+)
+
+func init() {
+	metrics.Register(metricSLAMissed, metrics.Counter, "Arrival deadlines passed without a file arriving")
+	metrics.Register(metricSLABreached, metrics.Gauge, "Whether the route missed its last arrival deadline and no file has arrived since (1) or not (0)")
+	metrics.Register(metricLastArrival, metrics.Gauge, "Unix time the route last received a file")
+}
+
+// arrivalState is the last arrival persisted in .state/arrival.json
+type arrivalState struct {
+	LastArrival time.Time `json:"lastArrival"`
+	Filename    string    `json:"filename"`
+}
+
+// arrivalTracker records when files arrive and reports deadlines of the route's
+// expected arrival schedule that pass without one. The last arrival survives
+// restarts so a restart after an on-time delivery is not reported as missed.
+type arrivalTracker struct {
+	schedule *sla.Schedule
+	path     string
+
+	mu       sync.Mutex
+	state    arrivalState
+	breached bool // A deadline was missed and no file has arrived since
+}
+
+func newArrivalTracker(inputFolder string, schedule *sla.Schedule) *arrivalTracker {
+	t := &arrivalTracker{schedule: schedule, path: filepath.Join(inputFolder, stateFolder, "arrival.json")}
+	content, err := os.ReadFile(t.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to read last arrival %s: %v", t.path, err)
+		}
+		return t
+	}
+	if err := json.Unmarshal(content, &t.state); err != nil {
+		log.Printf("Warning: ignoring invalid last arrival %s", t.path)
+	}
+	return t
+}
+
+// arrived records a file arriving at the given time. It reports whether the
+// route had missed a deadline and is now back on schedule.
+func (t *arrivalTracker) arrived(filename string, at time.Time) (recovered bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.state = arrivalState{LastArrival: at.UTC(), Filename: filename}
+	if err := t.save(); err != nil {
+		log.Printf("Warning: failed to persist last arrival: %v", err)
+	}
+	recovered, t.breached = t.breached, false
+	return recovered
+}
+
+// missed reports whether no file arrived in the period closed by deadline,
+// returning the start of that period
+func (t *arrivalTracker) missed(deadline time.Time) (bool, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	start := t.schedule.PeriodStart(deadline)
+	if !t.state.LastArrival.Before(start) {
+		return false, start
+	}
+	t.breached = true
+	return true, start
+}
+
+// lastArrival returns the time of the last recorded arrival (zero if none)
+func (t *arrivalTracker) lastArrival() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.state.LastArrival
+}
+
+// save writes the state file atomically
+func (t *arrivalTracker) save() error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// watchArrivals checks each deadline of the expected arrival schedule until the
+// processor stops
+func (p *Processor) watchArrivals() {
+	if last := p.arrivals.lastArrival(); !last.IsZero() {
+		metrics.Set(metricLastArrival, p.routeLabels(), float64(last.Unix()))
+	}
+	metrics.Set(metricSLABreached, p.routeLabels(), 0)
+
+	for {
+		deadline := p.arrivals.schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(deadline))
+		select {
+		case <-p.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		missed, start := p.arrivals.missed(deadline)
+		if !missed {
+			continue
+		}
+		metrics.Add(metricSLAMissed, p.routeLabels(), 1)
+		metrics.Set(metricSLABreached, p.routeLabels(), 1)
+		message := fmt.Sprintf("no file arrived in %s between %s and the %s deadline (expected arrival: %s)",
+			p.config.InputFolder, start.Format(time.RFC3339), deadline.Format(time.RFC3339), p.arrivals.schedule)
+		log.Printf("WARNING: Route %s missed its arrival deadline: %s", p.routeLabels()["route"], message)
+		alert.Send(config.AlertEventSLAMissed, p.routeName, message)
+	}
+}
+
+// recordArrival notes that a file arrived for the route's expected arrival schedule
+func (p *Processor) recordArrival(filename string) {
+	now := time.Now()
+	metrics.Set(metricLastArrival, p.routeLabels(), float64(now.Unix()))
+	if p.arrivals.arrived(filename, now) {
+		metrics.Set(metricSLABreached, p.routeLabels(), 0)
+		log.Printf("Route %s is back on its arrival schedule: received %s", p.routeLabels()["route"], filename)
+	}
+}
@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"testing"
+	"time"
+
+	"csv2json/internal/sla"
+)
+
+// TestArrivalTracker validates missed deadlines, recovery, and that the last arrival survives restarts
+func TestArrivalTracker(t *testing.T) {
+	schedule, err := sla.Parse("daily by 06:00 UTC")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	input := t.TempDir()
+	at := func(day, hour int) time.Time { return time.Date(2026, 1, day, hour, 0, 0, 0, time.UTC) }
+
+	tracker := newArrivalTracker(input, schedule)
+	if missed, _ := tracker.missed(at(7, 6)); !missed {
+		t.Error("Expected a deadline without any arrival to be missed")
+	}
+	if !tracker.arrived("late.csv", at(7, 9)) {
+		t.Error("Expected the late arrival to end the breach")
+	}
+
+	// The late file counts towards the 7th only; the 8th still needs its own file
+	if missed, start := tracker.missed(at(8, 6)); !missed || !start.Equal(at(8, 0)) {
+		t.Errorf("Expected the 8th to be missed from midnight, got missed=%t start=%v", missed, start)
+	}
+
+	tracker.arrived("on-time.csv", at(9, 5))
+	tracker = newArrivalTracker(input, schedule)
+	if missed, _ := tracker.missed(at(9, 6)); missed {
+		t.Error("Expected the persisted on-time arrival to satisfy the deadline after a restart")
+	}
+	if tracker.arrived("next.csv", at(10, 5)) {
+		t.Error("Expected no recovery without a prior breach")
+	}
+}
@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"csv2json/internal/events"
+	"csv2json/internal/failure"
+	"csv2json/internal/parser"
+)
+
+// Provenance fields of each file entry in a batched payload
+const (
+	batchSourceKey   = "sourceFile"
+	batchRowCountKey = "rowCount"
+	batchDataKey     = "data"
+)
+
+// batchEntry is a parsed file waiting to be emitted as part of a batch
+type batchEntry struct {
+	filePath string
+	filename string
+	result   *parser.ParseResult
+	hash     string // Content hash recorded once archived as processed (SKIP_DUPLICATE_FILES)
+}
+
+// batcher accumulates parsed files and flushes them together when the window
+// elapses or the file count is reached, whichever happens first
+type batcher struct {
+	window   time.Duration
+	maxFiles int
+	flush    func([]batchEntry)
+	files    sync.Locker // Held while a file is processed, so flushes never run alongside one
+
+	mu      sync.Mutex
+	pending []batchEntry
+	timer   *time.Timer
+	seq     atomic.Uint64 // Batches flushed, numbering batch identifiers
+}
+
+func newBatcher(window time.Duration, maxFiles int, files sync.Locker, flush func([]batchEntry)) *batcher {
+	return &batcher{window: window, maxFiles: maxFiles, files: files, flush: flush}
+}
+
+// add queues a file, flushing immediately if the batch is full. Callers hold files,
+// as add is called while a file is processed.
+func (b *batcher) add(entry batchEntry) {
+	b.mu.Lock()
+	b.pending = append(b.pending, entry)
+	if b.maxFiles > 0 && len(b.pending) >= b.maxFiles {
+		entries := b.take()
+		b.mu.Unlock()
+		b.run(entries)
+		return
+	}
+	if b.timer == nil && b.window > 0 {
+		b.timer = time.AfterFunc(b.window, b.flushPending)
+	}
+	b.mu.Unlock()
+}
+
+// flushPending emits whatever is queued (window expiry and shutdown), once the file
+// being processed, if any, is done
+func (b *batcher) flushPending() {
+	b.files.Lock()
+	defer b.files.Unlock()
+	b.mu.Lock()
+	entries := b.take()
+	b.mu.Unlock()
+	b.run(entries)
+}
+
+// take removes and returns queued entries; callers must hold mu
+func (b *batcher) take() []batchEntry {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	entries := b.pending
+	b.pending = nil
+	return entries
+}
+
+// run flushes entries; callers hold files
+func (b *batcher) run(entries []batchEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	b.flush(entries)
+}
+
+// identifier names a batch uniquely, even for batches of the same size flushed in the
+// same second
+func (b *batcher) identifier() string {
+	return fmt.Sprintf("batch_%s_%d", time.Now().UTC().Format("20060102T150405.000000000Z"), b.seq.Add(1))
+}
+
+// buildBatchResult combines files into one result with an object per file holding
+// its provenance and rows
+func buildBatchResult(entries []batchEntry) *parser.ParseResult {
+	keys := []string{batchSourceKey, batchRowCountKey, batchDataKey}
+	combined := &parser.ParseResult{Headers: keys}
+	for _, entry := range entries {
+		combined.Rows = append(combined.Rows, parser.OrderedMap{
+			Keys: keys,
+			Values: map[string]string{
+				batchSourceKey:   entry.filename,
+				batchRowCountKey: strconv.Itoa(len(entry.result.Rows)),
+			},
+			Nested: map[string][]parser.OrderedMap{batchDataKey: entry.result.Rows},
+		})
+	}
+	return combined
+}
+
+// flushBatch emits queued files as one payload and archives each according to the outcome
+func (p *Processor) flushBatch(entries []batchEntry) {
+	identifier := p.batch.identifier()
+	p.setEnvelopeSource("") // A batch has no single source file
+
+	err := p.output.SendOrdered(buildBatchResult(entries), identifier)
+	if err != nil {
+		log.Printf("Batch output failed (%d files): %v", len(entries), err)
+		p.alertOutputFailed(err)
+	} else {
+		log.Printf("Emitted batch %s with %d files", identifier, len(entries))
+	}
+
+	for _, entry := range entries {
+		var archiveErr error
+		if err != nil {
+			archiveErr = p.fail(entry.filePath, entry.filename, failure.Publish(err))
+		} else {
+			p.emit(events.Event{Type: events.PublishConfirmed, File: entry.filename, Rows: len(entry.result.Rows)})
+			archiveErr = p.archivePublished(entry.filePath, entry.filename, entry.hash)
+		}
+		if archiveErr != nil {
+			log.Printf("Failed to archive file %s: %v", entry.filename, archiveErr)
+		} else if err == nil {
+			p.ignored.markProcessed(entry.filename, entry.hash)
+		}
+	}
+}
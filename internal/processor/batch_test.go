@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"csv2json/internal/parser"
+)
+
+// TestBatcherMaxFiles validates a batch is flushed as soon as it reaches the file limit
+func TestBatcherMaxFiles(t *testing.T) {
+	var flushed [][]batchEntry
+	b := newBatcher(0, 2, &sync.Mutex{}, func(entries []batchEntry) { flushed = append(flushed, entries) })
+
+	b.add(batchEntry{filename: "a.csv"})
+	if len(flushed) != 0 {
+		t.Fatalf("Expected no flush after 1 file, got %d", len(flushed))
+	}
+	b.add(batchEntry{filename: "b.csv"})
+	b.add(batchEntry{filename: "c.csv"})
+
+	if len(flushed) != 1 || len(flushed[0]) != 2 {
+		t.Fatalf("Expected one flush of 2 files, got %v", flushed)
+	}
+
+	b.flushPending()
+	if len(flushed) != 2 || flushed[1][0].filename != "c.csv" {
+		t.Errorf("Expected remaining file c.csv flushed on shutdown, got %v", flushed)
+	}
+}
+
+// TestBatcherWindow validates a batch is flushed when the time window elapses
+func TestBatcherWindow(t *testing.T) {
+	done := make(chan int, 1)
+	b := newBatcher(50*time.Millisecond, 0, &sync.Mutex{}, func(entries []batchEntry) { done <- len(entries) })
+
+	b.add(batchEntry{filename: "a.csv"})
+	b.add(batchEntry{filename: "b.csv"})
+
+	select {
+	case n := <-done:
+		if n != 2 {
+			t.Errorf("Expected 2 files in window flush, got %d", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected window flush, timed out")
+	}
+}
+
+// TestBatcherWindowWaitsForFile validates a window flush waits until the file being
+// processed is done
+func TestBatcherWindowWaitsForFile(t *testing.T) {
+	var files sync.Mutex
+	done := make(chan int, 1)
+	b := newBatcher(10*time.Millisecond, 0, &files, func(entries []batchEntry) { done <- len(entries) })
+
+	files.Lock()
+	b.add(batchEntry{filename: "a.csv"})
+	select {
+	case <-done:
+		t.Fatal("Expected no flush while a file is processed")
+	case <-time.After(100 * time.Millisecond):
+	}
+	files.Unlock()
+
+	select {
+	case n := <-done:
+		if n != 1 {
+			t.Errorf("Expected 1 file in window flush, got %d", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected window flush once the file was done, timed out")
+	}
+}
+
+// TestBatcherIdentifier validates batch identifiers are unique within the same second
+func TestBatcherIdentifier(t *testing.T) {
+	b := newBatcher(0, 1, &sync.Mutex{}, func([]batchEntry) {})
+	seen := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		id := b.identifier()
+		if seen[id] {
+			t.Fatalf("Duplicate batch identifier %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+// TestBuildBatchResult validates per-file provenance in the combined payload
+func TestBuildBatchResult(t *testing.T) {
+	rows := []parser.OrderedMap{{Keys: []string{"id"}, Values: map[string]string{"id": "1"}}}
+	combined := buildBatchResult([]batchEntry{
+		{filename: "a.csv", result: &parser.ParseResult{Headers: []string{"id"}, Rows: rows}},
+		{filename: "b.csv", result: &parser.ParseResult{}},
+	})
+
+	if len(combined.Rows) != 2 {
+		t.Fatalf("Expected 2 file entries, got %d", len(combined.Rows))
+	}
+	first := combined.Rows[0]
+	if first.Values[batchSourceKey] != "a.csv" || first.Values[batchRowCountKey] != "1" {
+		t.Errorf("Unexpected provenance: %v", first.Values)
+	}
+	if len(first.Nested[batchDataKey]) != 1 {
+		t.Errorf("Expected 1 nested row, got %d", len(first.Nested[batchDataKey]))
+	}
+	if combined.Rows[1].Values[batchRowCountKey] != "0" {
+		t.Errorf("Expected rowCount 0 for empty file, got %s", combined.Rows[1].Values[batchRowCountKey])
+	}
+}
@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/businessdate"
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestBusinessDatePropagation validates the business date names the output and
+// archive folders, and that a file without one fails validation
+func TestBusinessDatePropagation(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output", businessdate.Placeholder)
+	extractor, err := businessdate.New(businessdate.Spec{From: businessdate.FromColumn, Column: "as_of"})
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+	cfg := &config.Config{OutputType: "file", OutputFolder: outputFolder, BusinessDate: extractor, ArchiveByDate: true, ArchiveProcessed: filepath.Join(dir, "processed"), ArchiveFailed: filepath.Join(dir, "failed")}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+
+	file := filepath.Join(dir, "orders.csv")
+	if err := os.WriteFile(file, []byte("id,as_of\n1,2026-03-31\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "output", "2026-03-31", "orders.json")); err != nil {
+		t.Errorf("Expected output in the business date folder: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "processed", "2026-03-31", "orders.csv")); err != nil {
+		t.Errorf("Expected the file archived in the business date folder: %v", err)
+	}
+	files, err := p.archiver.ArchivedFiles(archiver.CategoryProcessed)
+	if err != nil || len(files) != 1 || files[0].BusinessDate != "2026-03-31" {
+		t.Errorf("Expected one archived file dated 2026-03-31, got %+v (%v)", files, err)
+	}
+
+	// A file whose business date cannot be derived fails, outside any date folder
+	bad := filepath.Join(dir, "returns.csv")
+	if err := os.WriteFile(bad, []byte("id,as_of\n1,not a date\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(bad); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "failed", "returns.csv")); err != nil {
+		t.Errorf("Expected the undated file archived as failed: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"csv2json/internal/config"
+)
+
+// checksumSidecars are the companion files carrying a data file's checksum, in the
+// order they are looked for (orders.csv.sha256, then orders.csv.md5)
+var checksumSidecars = []struct {
+	suffix  string
+	newHash func() hash.Hash
+}{
+	{".sha256", sha256.New},
+	{".md5", md5.New},
+}
+
+// checksumSuffixes returns the suffixes of checksum sidecars
+func checksumSuffixes() []string {
+	suffixes := make([]string, len(checksumSidecars))
+	for i, sidecar := range checksumSidecars {
+		suffixes[i] = sidecar.suffix
+	}
+	return suffixes
+}
+
+// isChecksumSidecar reports whether filename is a checksum sidecar
+func isChecksumSidecar(filename string) bool {
+	for _, sidecar := range checksumSidecars {
+		if strings.HasSuffix(filename, sidecar.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// checksumsEnabled reports whether checksum sidecars are verified (CHECKSUM_POLICY)
+func (p *Processor) checksumsEnabled() bool {
+	return p.config.ChecksumPolicy != "" && p.config.ChecksumPolicy != config.ChecksumPolicyOff
+}
+
+// checksumSidecar returns the sidecar of filename in the input folder and its hash
+// ("" when the file has none)
+func (p *Processor) checksumSidecar(filename string) (string, func() hash.Hash) {
+	for _, sidecar := range checksumSidecars {
+		path := filepath.Join(p.config.InputFolder, filename+sidecar.suffix)
+		if _, err := os.Stat(path); err == nil {
+			return path, sidecar.newHash
+		}
+	}
+	return "", nil
+}
+
+// processChecksumSidecar processes the data file a sidecar belongs to, or leaves the
+// sidecar in place until the data file arrives. Sidecars are archived with their data file.
+func (p *Processor) processChecksumSidecar(sidecarName string) error {
+	dataName := strings.TrimSuffix(sidecarName, filepath.Ext(sidecarName))
+	dataPath := filepath.Join(p.config.InputFolder, dataName)
+	if _, err := os.Stat(dataPath); err != nil {
+		log.Printf("Holding %s until %s arrives", sidecarName, dataName)
+		return nil
+	}
+	return p.processFile(dataPath)
+}
+
+// verifyChecksum checks a data file against its checksum sidecar. Files without a
+// sidecar pass unless CHECKSUM_POLICY=require.
+func (p *Processor) verifyChecksum(filePath, filename string) error {
+	if !p.checksumsEnabled() {
+		return nil
+	}
+	sidecar, newHash := p.checksumSidecar(filename)
+	if sidecar == "" {
+		if p.config.ChecksumPolicy == config.ChecksumPolicyRequire {
+			return fmt.Errorf("no checksum file for %s", filename)
+		}
+		return nil
+	}
+
+	expected, err := readChecksum(sidecar, newHash().Size())
+	if err != nil {
+		return fmt.Errorf("invalid checksum file %s: %w", filepath.Base(sidecar), err)
+	}
+	actual, err := hashFile(filePath, newHash)
+	if err != nil {
+		return fmt.Errorf("failed to checksum %s: %w", filename, err)
+	}
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch: %s expects %s, %s has %s", filepath.Base(sidecar), expected, filename, actual)
+	}
+	log.Printf("Verified %s against %s", filename, filepath.Base(sidecar))
+	return nil
+}
+
+// readChecksum reads the hex digest from a sidecar, either bare or in md5sum/sha256sum
+// format ("<digest>  <filename>")
+func readChecksum(path string, size int) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("file is empty")
+	}
+	digest := strings.ToLower(fields[0])
+	if decoded, err := hex.DecodeString(digest); err != nil || len(decoded) != size {
+		return "", fmt.Errorf("expected a %d-character hex digest, got %q", size*2, fields[0])
+	}
+	return digest, nil
+}
@@ -0,0 +1,121 @@
+package processor
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestChecksumSidecars validates verification against sidecars and archiving them with their data file
+func TestChecksumSidecars(t *testing.T) {
+	dir := t.TempDir()
+	inputDir := filepath.Join(dir, "input")
+	outputDir := filepath.Join(dir, "output")
+	processed := filepath.Join(dir, "processed")
+	failed := filepath.Join(dir, "failed")
+	for _, folder := range []string{inputDir, outputDir} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		InputFolder:      inputDir,
+		OutputType:       "file",
+		OutputFolder:     outputDir,
+		ChecksumPolicy:   config.ChecksumPolicyRequire,
+		EmptyFilePolicy:  config.EmptyFilePolicyFail,
+		ArchiveProcessed: processed,
+		ArchiveFailed:    failed,
+	}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputDir))
+	p.archiver.SetCompanions(inputDir, checksumSuffixes())
+
+	content := []byte("id,name\n1,widget\n")
+	sha := sha256.Sum256(content)
+	md := md5.Sum([]byte("something else"))
+	write := func(name string, data []byte) string {
+		path := filepath.Join(inputDir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+
+	// Data files wait for their sidecar, which then triggers processing
+	good := write("good.csv", content)
+	if err := p.processFile(good); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(good); err != nil {
+		t.Fatalf("Expected good.csv held until its checksum file arrives: %v", err)
+	}
+	sidecar := write("good.csv.sha256", []byte(hex.EncodeToString(sha[:])+"  good.csv\n"))
+	if err := p.processFile(sidecar); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	for _, name := range []string{"good.csv", "good.csv.sha256"} {
+		if _, err := os.Stat(filepath.Join(processed, name)); err != nil {
+			t.Errorf("Expected %s archived as processed: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "good.json")); err != nil {
+		t.Errorf("Expected output for verified file: %v", err)
+	}
+
+	// A mismatch fails the file and its sidecar without output
+	bad := write("bad.csv", content)
+	write("bad.csv.md5", []byte(hex.EncodeToString(md[:])))
+	if err := p.processFile(bad); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	for _, name := range []string{"bad.csv", "bad.csv.md5"} {
+		if _, err := os.Stat(filepath.Join(failed, name)); err != nil {
+			t.Errorf("Expected %s archived as failed: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "bad.json")); err == nil {
+		t.Error("Expected no output for a file failing verification")
+	}
+}
+
+// TestReadChecksum validates bare and md5sum-style digests and rejects malformed ones
+func TestReadChecksum(t *testing.T) {
+	dir := t.TempDir()
+	digest := "D41D8CD98F00B204E9800998ECF8427E"
+	testCases := []struct {
+		name    string
+		content string
+		valid   bool
+	}{
+		{"bare", digest + "\n", true},
+		{"md5sum", digest + " *orders.csv\n", true},
+		{"empty", "\n", false},
+		{"short", "d41d8cd9\n", false},
+		{"not hex", "zz1d8cd98f00b204e9800998ecf8427e\n", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, tc.name+".md5")
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("Failed to write sidecar: %v", err)
+			}
+			got, err := readChecksum(path, md5.Size)
+			if !tc.valid {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil || got != "d41d8cd98f00b204e9800998ecf8427e" {
+				t.Errorf("Expected lowercase digest, got %q (%v)", got, err)
+			}
+		})
+	}
+}
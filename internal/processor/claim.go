@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// claimFolder is the input subfolder holding files claimed by each instance
+const claimFolder = ".claimed"
+
+// errClaimLost is returned when another instance claimed the file first
+var errClaimLost = errors.New("file claimed by another instance")
+
+// claimer coordinates service instances sharing an input folder. A file is claimed by
+// atomically renaming it into .claimed/<instanceID>/; only the instance whose rename
+// succeeds processes it. Claims are refreshed while held, and claims older than the
+// TTL (an instance that died mid-file) are released back into the input folder.
+type claimer struct {
+	inputFolder string
+	instanceID  string
+	ttl         time.Duration
+	stop        chan struct{}
+	stopOnce    sync.Once
+}
+
+func newClaimer(inputFolder, instanceID string, ttl time.Duration) *claimer {
+	return &claimer{
+		inputFolder: inputFolder,
+		instanceID:  instanceID,
+		ttl:         ttl,
+		stop:        make(chan struct{}),
+	}
+}
+
+// dir returns the claim folder of an instance
+func (c *claimer) dir(instanceID string) string {
+	return filepath.Join(c.inputFolder, claimFolder, instanceID)
+}
+
+// claim moves filePath into this instance's claim folder and returns the claimed path
+func (c *claimer) claim(filePath string) (string, error) {
+	dir := c.dir(c.instanceID)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create claim folder: %w", err)
+	}
+
+	claimed := filepath.Join(dir, filepath.Base(filePath))
+	if err := os.Rename(filePath, claimed); err != nil {
+		if os.IsNotExist(err) {
+			return "", errClaimLost
+		}
+		return "", fmt.Errorf("failed to claim file: %w", err)
+	}
+
+	// The modification time records when the claim was last refreshed
+	now := time.Now()
+	if err := os.Chtimes(claimed, now, now); err != nil {
+		log.Printf("Warning: failed to timestamp claim %s: %v", claimed, err)
+	}
+	return claimed, nil
+}
+
+// run releases leftovers from a previous run of this instance, then periodically
+// refreshes held claims and releases stale claims of other instances
+func (c *claimer) run() {
+	c.releaseOwn()
+
+	ticker := time.NewTicker(c.ttl / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.refreshOwn()
+			c.releaseStale()
+		}
+	}
+}
+
+// close stops the background claim maintenance
+func (c *claimer) close() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// releaseOwn returns files left in this instance's claim folder (after a crash) to the input folder
+func (c *claimer) releaseOwn() {
+	for _, path := range claimedFiles(c.dir(c.instanceID)) {
+		c.release(path)
+	}
+}
+
+// refreshOwn touches held claims so other instances do not consider them stale
+func (c *claimer) refreshOwn() {
+	now := time.Now()
+	for _, path := range claimedFiles(c.dir(c.instanceID)) {
+		os.Chtimes(path, now, now)
+	}
+}
+
+// releaseStale returns files whose claim has not been refreshed within the TTL
+func (c *claimer) releaseStale() {
+	instances, err := os.ReadDir(filepath.Join(c.inputFolder, claimFolder))
+	if err != nil {
+		return
+	}
+	for _, instance := range instances {
+		if !instance.IsDir() || instance.Name() == c.instanceID {
+			continue
+		}
+		for _, path := range claimedFiles(c.dir(instance.Name())) {
+			info, err := os.Stat(path)
+			if err == nil && time.Since(info.ModTime()) > c.ttl {
+				c.release(path)
+			}
+		}
+	}
+}
+
+// release moves a claimed file back into the input folder unless a file of that name is waiting
+func (c *claimer) release(path string) {
+	target := filepath.Join(c.inputFolder, filepath.Base(path))
+	if _, err := os.Stat(target); err == nil {
+		log.Printf("Warning: cannot release claim %s: %s already exists", path, target)
+		return
+	}
+	if err := os.Rename(path, target); err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to release claim %s: %v", path, err)
+		}
+		return
+	}
+	log.Printf("Released stale claim: %s", filepath.Base(path))
+}
+
+// claimedFiles lists the files in a claim folder
+func claimedFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return paths
+}
@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestClaimExclusive validates only one instance can claim a file
+func TestClaimExclusive(t *testing.T) {
+	input := t.TempDir()
+	file := filepath.Join(input, "orders.csv")
+	if err := os.WriteFile(file, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	a := newClaimer(input, "node-a", time.Minute)
+	b := newClaimer(input, "node-b", time.Minute)
+
+	claimed, err := a.claim(file)
+	if err != nil {
+		t.Fatalf("Expected node-a to claim the file, got: %v", err)
+	}
+	if claimed != filepath.Join(input, claimFolder, "node-a", "orders.csv") {
+		t.Errorf("Unexpected claimed path: %s", claimed)
+	}
+	if _, err := b.claim(file); !errors.Is(err, errClaimLost) {
+		t.Errorf("Expected errClaimLost for node-b, got: %v", err)
+	}
+}
+
+// TestClaimRelease validates stale claims of other instances and leftover own claims are released
+func TestClaimRelease(t *testing.T) {
+	input := t.TempDir()
+	a := newClaimer(input, "node-a", 3*time.Second)
+	b := newClaimer(input, "node-b", 3*time.Second)
+
+	for _, name := range []string{"fresh.csv", "stale.csv"} {
+		if err := os.WriteFile(filepath.Join(input, name), []byte("id\n1\n"), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if _, err := a.claim(filepath.Join(input, name)); err != nil {
+			t.Fatalf("Failed to claim %s: %v", name, err)
+		}
+	}
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(filepath.Join(a.dir("node-a"), "stale.csv"), old, old)
+
+	// node-b only takes over the claim that node-a stopped refreshing
+	b.releaseStale()
+	if _, err := os.Stat(filepath.Join(input, "stale.csv")); err != nil {
+		t.Errorf("Expected stale claim released to input folder: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(input, "fresh.csv")); !os.IsNotExist(err) {
+		t.Error("Expected fresh claim to stay claimed")
+	}
+
+	// node-a restarting releases everything it still held
+	a.releaseOwn()
+	if _, err := os.Stat(filepath.Join(input, "fresh.csv")); err != nil {
+		t.Errorf("Expected own leftover claim released on restart: %v", err)
+	}
+}
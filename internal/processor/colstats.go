@@ -0,0 +1,104 @@
+package processor
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"unicode/utf8"
+
+	"csv2json/internal/parser"
+)
+
+// distinctLimit caps the distinct values counted per column; beyond it the count is a
+// lower bound and the column is marked as sampled
+const distinctLimit = 10000
+
+// columnStats profiles the values of one source column
+type columnStats struct {
+	Name      string `json:"name"`
+	Empty     int    `json:"empty"`     // Values that are empty or whitespace only
+	Null      int    `json:"null"`      // Null markers: NULL (any case) or \N
+	MinLength int    `json:"minLength"` // Shortest non-null value, in characters
+	MaxLength int    `json:"maxLength"` // Longest non-null value, in characters
+	Distinct  int    `json:"distinct"`  // Distinct non-null values, up to distinctLimit
+	Sampled   bool   `json:"distinctSampled,omitempty"`
+
+	measured int // Non-null values seen
+	values   map[string]struct{}
+}
+
+// fileStats is the column statistics sidecar of one file (COLUMN_STATS)
+type fileStats struct {
+	File    string         `json:"file"`
+	Route   string         `json:"route,omitempty"`
+	Rows    int            `json:"rows"`
+	Columns []*columnStats `json:"columns"`
+
+	byName map[string]*columnStats
+}
+
+func newFileStats(file, route string) *fileStats {
+	return &fileStats{File: file, Route: route, Columns: []*columnStats{}, byName: make(map[string]*columnStats)}
+}
+
+// add profiles the rows of a parsed file or chunk
+func (s *fileStats) add(result *parser.ParseResult) {
+	for _, header := range result.Headers {
+		if _, ok := s.byName[header]; !ok {
+			column := &columnStats{Name: header, values: make(map[string]struct{})}
+			s.byName[header] = column
+			s.Columns = append(s.Columns, column)
+		}
+	}
+	for _, row := range result.Rows {
+		for _, header := range result.Headers {
+			s.byName[header].add(row.Values[header])
+		}
+	}
+	s.Rows += len(result.Rows)
+}
+
+// add profiles one value
+func (c *columnStats) add(value string) {
+	if isNullMarker(value) {
+		c.Null++
+		return
+	}
+	if strings.TrimSpace(value) == "" {
+		c.Empty++
+	}
+
+	length := utf8.RuneCountInString(value)
+	if c.measured == 0 || length < c.MinLength {
+		c.MinLength = length
+	}
+	if length > c.MaxLength {
+		c.MaxLength = length
+	}
+	c.measured++
+
+	if _, seen := c.values[value]; !seen {
+		if len(c.values) == distinctLimit {
+			c.Sampled = true
+			return
+		}
+		c.values[value] = struct{}{}
+		c.Distinct = len(c.values)
+	}
+}
+
+// isNullMarker reports whether value is a null marker written by database exports
+func isNullMarker(value string) bool {
+	return strings.EqualFold(value, "null") || value == `\N`
+}
+
+// attachColumnStats hands a file's column statistics to the archiver, which writes
+// them next to the archived file
+func (p *Processor) attachColumnStats(filename string, stats *fileStats) {
+	content, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		log.Printf("Warning: failed to encode column statistics for %s: %v", filename, err)
+		return
+	}
+	p.archiver.AttachStats(filename, content)
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+)
+
+// TestFileStats validates null, empty, length and distinct counts per column
+func TestFileStats(t *testing.T) {
+	stats := newFileStats("orders.csv", "orders")
+	row := func(id, name string) parser.OrderedMap {
+		return parser.OrderedMap{Keys: []string{"id", "name"}, Values: map[string]string{"id": id, "name": name}}
+	}
+	stats.add(&parser.ParseResult{Headers: []string{"id", "name"}, Rows: []parser.OrderedMap{
+		row("1", "widget"),
+		row("22", ""),
+		row("333", "NULL"),
+	}})
+	// Chunks of a spilled file accumulate
+	stats.add(&parser.ParseResult{Headers: []string{"id", "name"}, Rows: []parser.OrderedMap{
+		row("1", `\N`),
+		row("4444", "  "),
+	}})
+
+	tests := []struct {
+		column                                      int
+		name                                        string
+		empty, null, minLength, maxLength, distinct int
+	}{
+		{column: 0, name: "id", minLength: 1, maxLength: 4, distinct: 4},
+		{column: 1, name: "name", empty: 2, null: 2, minLength: 0, maxLength: 6, distinct: 3},
+	}
+
+	if stats.Rows != 5 || len(stats.Columns) != 2 {
+		t.Fatalf("Expected 5 rows and 2 columns, got %d rows and %d columns", stats.Rows, len(stats.Columns))
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := stats.Columns[tt.column]
+			if c.Name != tt.name || c.Empty != tt.empty || c.Null != tt.null || c.MinLength != tt.minLength || c.MaxLength != tt.maxLength || c.Distinct != tt.distinct {
+				t.Errorf("Unexpected stats %+v", *c)
+			}
+		})
+	}
+}
+
+// TestFileStatsDistinctLimit validates distinct counting stops at the limit and marks the count as sampled
+func TestFileStatsDistinctLimit(t *testing.T) {
+	c := &columnStats{values: make(map[string]struct{})}
+	for i := 0; i < distinctLimit+10; i++ {
+		c.add(strconv.Itoa(i))
+	}
+	if c.Distinct != distinctLimit || !c.Sampled {
+		t.Errorf("Expected %d distinct values marked as sampled, got %d (sampled %v)", distinctLimit, c.Distinct, c.Sampled)
+	}
+}
+
+// TestProcessFileColumnStats validates a stats sidecar is written next to the archived file
+func TestProcessFileColumnStats(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "output"), 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	cfg := &config.Config{ColumnStats: true, ArchiveProcessed: filepath.Join(dir, "processed")}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(filepath.Join(dir, "output")))
+
+	file := filepath.Join(dir, "orders.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,widget\n2,\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "processed", "orders.csv.stats.json"))
+	if err != nil {
+		t.Fatalf("Expected stats sidecar: %v", err)
+	}
+	var stats fileStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatalf("Invalid stats sidecar: %v", err)
+	}
+	if stats.File != "orders.csv" || stats.Rows != 2 || len(stats.Columns) != 2 || stats.Columns[1].Empty != 1 {
+		t.Errorf("Unexpected stats sidecar: %s", data)
+	}
+}
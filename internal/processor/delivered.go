@@ -0,0 +1,204 @@
+package processor
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Delivery journal events
+const (
+	deliveryDelivered = "delivered" // The file's output was sent
+	deliveryArchived  = "archived"  // The delivered file was archived as processed
+)
+
+// deliveryEntry is one line of the delivery journal
+type deliveryEntry struct {
+	Event          string    `json:"event"`
+	Hash           string    `json:"sha256"`
+	File           string    `json:"file,omitempty"`
+	IdempotencyKey string    `json:"idempotencyKey,omitempty"`
+	At             time.Time `json:"at"`
+}
+
+// deliveryRecord is the delivery state of one content hash
+type deliveryRecord struct {
+	File           string
+	IdempotencyKey string
+	DeliveredAt    time.Time
+	Archived       bool
+}
+
+// deliveryStore records the content hashes delivered by a route (EXACTLY_ONCE), so
+// content is published once whatever name it arrives under. Deliveries are appended
+// to .state/delivered.log and synced before the file is archived; a second entry
+// marks the file archived. A delivery that was never marked archived (the process
+// stopped or archiving failed in between) is archived on the next scan without
+// being sent again.
+type deliveryStore struct {
+	mu      sync.Mutex
+	path    string
+	records map[string]*deliveryRecord // Content hash -> delivery
+}
+
+// newDeliveryStore loads the delivery journal, forgetting archived deliveries older
+// than retention (0 = kept forever)
+func newDeliveryStore(inputFolder string, retention time.Duration) *deliveryStore {
+	s := &deliveryStore{path: filepath.Join(inputFolder, stateFolder, "delivered.log"), records: make(map[string]*deliveryRecord)}
+	file, err := os.Open(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to read delivery journal %s: %v", s.path, err)
+		}
+		return s
+	}
+	defer file.Close()
+
+	compact := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry deliveryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Hash == "" {
+			// A torn last line from a crash mid-append; the file it describes was not archived
+			log.Printf("Warning: ignoring invalid delivery journal entry in %s", s.path)
+			compact = true
+			continue
+		}
+		switch entry.Event {
+		case deliveryDelivered:
+			s.records[entry.Hash] = &deliveryRecord{File: entry.File, IdempotencyKey: entry.IdempotencyKey, DeliveredAt: entry.At}
+		case deliveryArchived:
+			if record, ok := s.records[entry.Hash]; ok {
+				record.Archived = true
+			}
+			compact = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Printf("Warning: failed to read delivery journal %s: %v", s.path, err)
+	}
+
+	if retention > 0 {
+		cutoff := time.Now().Add(-retention)
+		for hash, record := range s.records {
+			if record.Archived && record.DeliveredAt.Before(cutoff) {
+				delete(s.records, hash)
+			}
+		}
+	}
+	if compact {
+		if err := s.rewrite(); err != nil {
+			log.Printf("Warning: failed to compact delivery journal %s: %v", s.path, err)
+		}
+	}
+	return s
+}
+
+// lookup returns the delivery of content hash, if it was delivered
+func (s *deliveryStore) lookup(hash string) (deliveryRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[hash]
+	if !ok {
+		return deliveryRecord{}, false
+	}
+	return *record, true
+}
+
+// delivered durably records that filename with content hash was sent
+func (s *deliveryStore) delivered(hash, filename, idempotencyKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[hash]; ok && record.File == filename {
+		return nil
+	}
+	now := time.Now().UTC()
+	if err := s.append(deliveryEntry{Event: deliveryDelivered, Hash: hash, File: filename, IdempotencyKey: idempotencyKey, At: now}); err != nil {
+		return err
+	}
+	s.records[hash] = &deliveryRecord{File: filename, IdempotencyKey: idempotencyKey, DeliveredAt: now}
+	return nil
+}
+
+// archived records that the delivered file with content hash was archived
+func (s *deliveryStore) archived(hash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[hash]
+	if !ok || record.Archived {
+		return nil
+	}
+	if err := s.append(deliveryEntry{Event: deliveryArchived, Hash: hash, At: time.Now().UTC()}); err != nil {
+		return err
+	}
+	record.Archived = true
+	return nil
+}
+
+// append writes one entry to the journal and syncs it to disk
+func (s *deliveryStore) append(entry deliveryEntry) error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// rewrite replaces the journal with one delivered entry per record, followed by an
+// archived entry for archived records
+func (s *deliveryStore) rewrite() error {
+	tmp := s.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for hash, record := range s.records {
+		if err := encoder.Encode(deliveryEntry{Event: deliveryDelivered, Hash: hash, File: record.File, IdempotencyKey: record.IdempotencyKey, At: record.DeliveredAt}); err != nil {
+			file.Close()
+			return err
+		}
+		if record.Archived {
+			if err := encoder.Encode(deliveryEntry{Event: deliveryArchived, Hash: hash, At: record.DeliveredAt}); err != nil {
+				file.Close()
+				return err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to replace delivery journal: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestExactlyOnce validates content is published once: a delivered file that could not
+// be archived is archived without being sent again, and the same content under another
+// name is ignored, also after a restart
+func TestExactlyOnce(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input")
+	outputFolder := filepath.Join(dir, "output")
+	for _, folder := range []string{input, outputFolder} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+	// A file where the processed archive folder should be makes archiving fail
+	processed := filepath.Join(dir, "processed")
+	if err := os.WriteFile(processed, nil, 0644); err != nil {
+		t.Fatalf("Failed to block the archive folder: %v", err)
+	}
+
+	cfg := &config.Config{InputFolder: input, ExactlyOnce: true, ArchiveProcessed: processed, ArchiveIgnored: filepath.Join(dir, "ignored")}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+	p.delivered = newDeliveryStore(input, 0)
+
+	content := []byte("id,name\n1,widget\n")
+	file := filepath.Join(input, "orders.csv")
+	if err := os.WriteFile(file, content, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	hash, _ := fileHash(file)
+	if err := p.processFile(file); err == nil {
+		t.Fatal("Expected the archive failure to be reported")
+	}
+	if _, err := os.Stat(filepath.Join(outputFolder, "orders.json")); err != nil {
+		t.Fatalf("Expected the file to be sent: %v", err)
+	}
+
+	// After a restart, with archiving working again, the file is archived without being sent
+	os.Remove(processed)
+	os.Remove(filepath.Join(outputFolder, "orders.json"))
+	p.delivered = newDeliveryStore(input, 0)
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("Expected the delivered file to be archived, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(processed, "orders.csv")); err != nil {
+		t.Errorf("Expected the file archived as processed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputFolder, "orders.json")); !os.IsNotExist(err) {
+		t.Error("Expected the delivered file not to be sent again")
+	}
+
+	// The same content under another name is a duplicate
+	p.delivered = newDeliveryStore(input, 0)
+	copied := filepath.Join(input, "orders_resent.csv")
+	if err := os.WriteFile(copied, content, 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(copied); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputFolder, "orders_resent.json")); !os.IsNotExist(err) {
+		t.Error("Expected the resent content not to be published")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ignored", "orders_resent.csv")); err != nil {
+		t.Errorf("Expected the resent file archived as ignored: %v", err)
+	}
+
+	if record, ok := p.delivered.lookup(hash); !ok || !record.Archived || record.File != "orders.csv" || record.IdempotencyKey == "" {
+		t.Errorf("Unexpected delivery record: %+v", record)
+	}
+}
@@ -0,0 +1,103 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// diskGuard pauses intake while the output or archive filesystems are low on space,
+// so files are not failed mid-way with ENOSPC and partial outputs left behind
+type diskGuard struct {
+	paths    []string
+	minFree  uint64
+	interval time.Duration
+	free     func(path string) (uint64, error)
+	paused   bool
+}
+
+func newDiskGuard(paths []string, minFreeMB int, interval time.Duration) *diskGuard {
+	return &diskGuard{
+		paths:    paths,
+		minFree:  uint64(minFreeMB) * 1024 * 1024,
+		interval: interval,
+		free:     freeBytes,
+	}
+}
+
+// check returns an error describing the first path below the free space threshold
+func (g *diskGuard) check() error {
+	for _, path := range g.paths {
+		available, err := g.free(existingAncestor(path))
+		if err != nil {
+			log.Printf("Warning: cannot check free space for %s: %v", path, err)
+			continue
+		}
+		if available < g.minFree {
+			return fmt.Errorf("%s has %d MB free (minimum %d MB)", path, available/(1024*1024), g.minFree/(1024*1024))
+		}
+	}
+	return nil
+}
+
+// wait blocks until every path has enough free space, rechecking at the configured
+// interval. It returns an error if done is closed while intake is paused.
+func (g *diskGuard) wait(done <-chan struct{}) error {
+	for {
+		err := g.check()
+		if err == nil {
+			if g.paused {
+				log.Printf("Disk space recovered, resuming intake")
+				g.paused = false
+			}
+			return nil
+		}
+		if !g.paused {
+			log.Printf("ALERT: low disk space, pausing intake: %v", err)
+			g.paused = true
+		}
+
+		select {
+		case <-done:
+			return fmt.Errorf("stopped while intake was paused for disk space")
+		case <-time.After(g.interval):
+		}
+	}
+}
+
+// guardedPaths returns the output and archive folders that must have free space.
+// Templated folders are checked at their fixed prefix.
+func guardedPaths(outputType, outputFolder string, archives ...string) []string {
+	candidates := archives
+	if outputType == "file" || outputType == "both" {
+		candidates = append([]string{outputFolder}, archives...)
+	}
+
+	var paths []string
+	for _, path := range candidates {
+		if i := strings.Index(path, "{"); i >= 0 {
+			path = path[:i]
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// existingAncestor returns path, or its closest existing parent directory
+func existingAncestor(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
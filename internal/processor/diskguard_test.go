@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestDiskGuardPausesUntilSpaceRecovers validates intake waits while free space is below the threshold
+func TestDiskGuardPausesUntilSpaceRecovers(t *testing.T) {
+	g := newDiskGuard([]string{t.TempDir()}, 100, time.Millisecond)
+	checks := 0
+	g.free = func(string) (uint64, error) {
+		checks++
+		if checks < 3 {
+			return 50 * 1024 * 1024, nil
+		}
+		return 200 * 1024 * 1024, nil
+	}
+
+	if err := g.wait(make(chan struct{})); err != nil {
+		t.Fatalf("Expected wait to return once space recovered, got: %v", err)
+	}
+	if checks != 3 {
+		t.Errorf("Expected 3 checks, got %d", checks)
+	}
+	if g.paused {
+		t.Error("Expected guard to resume after space recovered")
+	}
+}
+
+// TestDiskGuardStop validates a paused guard returns an error when the processor stops
+func TestDiskGuardStop(t *testing.T) {
+	g := newDiskGuard([]string{t.TempDir()}, 100, time.Hour)
+	g.free = func(string) (uint64, error) { return 0, nil }
+
+	done := make(chan struct{})
+	close(done)
+	if err := g.wait(done); err == nil {
+		t.Error("Expected error when stopped while paused, got success")
+	}
+}
+
+// TestGuardedPaths validates which folders are checked and that templates are cut at the placeholder
+func TestGuardedPaths(t *testing.T) {
+	paths := guardedPaths("file", "./out/{partition}/json", "./archive/processed", "./archive/failed")
+	expected := []string{"./out/", "./archive/processed", "./archive/failed"}
+	if len(paths) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, paths)
+	}
+	for i := range expected {
+		if paths[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, paths)
+		}
+	}
+
+	if paths := guardedPaths("queue", "./out", "./archive"); len(paths) != 1 {
+		t.Errorf("Expected only the archive folder for queue output, got %v", paths)
+	}
+}
+
+// TestFreeBytes validates free space can be read for an existing ancestor of a missing path
+func TestFreeBytes(t *testing.T) {
+	free, err := freeBytes(existingAncestor(filepath.Join(t.TempDir(), "missing", "dir")))
+	if err != nil {
+		t.Fatalf("freeBytes failed: %v", err)
+	}
+	if free == 0 {
+		t.Error("Expected non-zero free space for temp dir")
+	}
+}
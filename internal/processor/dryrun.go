@@ -0,0 +1,92 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+)
+
+// NewConverter creates a processor that converts files with a route's parser,
+// transforms and contract, and has no output (csv2json convert). It does not monitor,
+// archive, claim or record state, and publishes no events.
+func NewConverter(cfg *config.Config) (*Processor, error) {
+	if cfg.ReverseConversion {
+		return nil, errors.New("the route converts JSON to CSV; use csv2json reverse")
+	}
+	transforms, err := buildTransforms(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure transforms: %w", err)
+	}
+	return &Processor{
+		config:     cfg,
+		parser:     newParser(cfg),
+		transforms: transforms,
+		ignored:    newIgnoreTracker(),
+	}, nil
+}
+
+// NewDryRun creates a converter that writes the output it would send to w instead
+// (csv2json test-route)
+func NewDryRun(cfg *config.Config, w io.Writer) (*Processor, error) {
+	p, err := NewConverter(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if p.output, err = output.NewPreviewHandler(w, cfg.OutputType, cfg.OutputFolder, cfg.QueueType, cfg.QueueHost, cfg.QueuePort, cfg.QueueName, outputOptions(cfg)); err != nil {
+		return nil, fmt.Errorf("failed to create output handler: %w", err)
+	}
+	return p, nil
+}
+
+// Convert converts one file as the route would, applying the empty file policy. The
+// file is left where it is. Returns nil if the policy drops the file without output,
+// or the error the file would be archived as failed with.
+func (p *Processor) Convert(filePath string) (*parser.ParseResult, error) {
+	filename := filepath.Base(filePath)
+	err := p.parser.Validate(filePath)
+	var result *parser.ParseResult
+	if err == nil {
+		result, err = p.convert(filePath, filename)
+	}
+	if errors.Is(err, parser.ErrNoDataRows) {
+		switch p.config.EmptyFilePolicy {
+		case config.EmptyFilePolicyEmitEmptyArray:
+			return &parser.ParseResult{}, nil
+		case config.EmptyFilePolicyIgnore:
+			log.Printf("No data rows in %s, no output would be sent (EMPTY_FILE_POLICY=%s)", filename, p.config.EmptyFilePolicy)
+			return nil, nil
+		}
+	}
+	return result, err
+}
+
+// DryRun converts one file as the route would and sends the result to the processor's
+// output. The file is left where it is. Returns the converted result, or the error the
+// file would be archived as failed or ignored with.
+func (p *Processor) DryRun(filePath string) (*parser.ParseResult, error) {
+	filename := filepath.Base(filePath)
+	if reason, detail := p.config.IgnoreReason(filename); reason != "" {
+		return nil, fmt.Errorf("file would be ignored by the route (%s: %s)", reason, detail)
+	}
+
+	p.setEnvelopeSource(filePath)
+
+	result, err := p.Convert(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return &parser.ParseResult{}, nil
+	}
+
+	if err := p.send(result, filename); err != nil {
+		return nil, fmt.Errorf("output failed: %w", err)
+	}
+	return result, nil
+}
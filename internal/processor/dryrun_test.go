@@ -0,0 +1,161 @@
+package processor
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestDryRun validates that a dry run prints the output a route would send and leaves
+// the file, output folder and archives untouched
+func TestDryRun(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "orders.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,widget\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	outputFolder := filepath.Join(dir, "output")
+
+	testCases := []struct {
+		name     string
+		cfg      config.Config
+		expected []string
+	}{
+		{
+			name:     "file output",
+			cfg:      config.Config{OutputType: "file", OutputFolder: outputFolder},
+			expected: []string{"--- file " + filepath.ToSlash(filepath.Join(outputFolder, "orders.json")), `"name": "widget"`},
+		},
+		{
+			name:     "queue output",
+			cfg:      config.Config{OutputType: "queue", QueueType: "rabbitmq", QueueHost: "localhost", QueuePort: 5672, QueueName: "orders-in"},
+			expected: []string{"--- queue orders-in", `"route": "orders"`, `"name": "widget"`},
+		},
+		{
+			name:     "partitioned",
+			cfg:      config.Config{OutputType: "file", OutputFolder: outputFolder, PartitionBy: "name"},
+			expected: []string{"orders_widget.json"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tc.cfg.Delimiter, tc.cfg.QuoteChar, tc.cfg.HasHeader = ',', '"', true
+			p, err := NewDryRun(&tc.cfg, &out)
+			if err != nil {
+				t.Fatalf("NewDryRun failed: %v", err)
+			}
+			p.SetEnvelopeContext("orders", "orders.v1", output.PayloadEnvelope)
+
+			result, err := p.DryRun(file)
+			if err != nil {
+				t.Fatalf("DryRun failed: %v", err)
+			}
+			if len(result.Rows) != 1 {
+				t.Errorf("Expected 1 row, got %d", len(result.Rows))
+			}
+			for _, want := range tc.expected {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, out.String())
+				}
+			}
+		})
+	}
+
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("Expected the file to stay in place: %v", err)
+	}
+	if _, err := os.Stat(outputFolder); !os.IsNotExist(err) {
+		t.Errorf("Expected no output to be written, got %v", err)
+	}
+}
+
+// TestDryRunFailures validates that a dry run reports why a file would be ignored or fail
+func TestDryRunFailures(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		return path
+	}
+
+	testCases := []struct {
+		name     string
+		cfg      config.Config
+		file     string
+		expected string
+	}{
+		{
+			name:     "ignored by suffix",
+			cfg:      config.Config{OutputType: "file", FileSuffixFilter: []string{".csv"}},
+			file:     write("orders.txt", "id\n1\n"),
+			expected: "would be ignored",
+		},
+		{
+			name:     "empty file",
+			cfg:      config.Config{OutputType: "file"},
+			file:     write("empty.csv", "id,name\n"),
+			expected: "no data rows",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cfg.Delimiter, tc.cfg.QuoteChar, tc.cfg.HasHeader = ',', '"', true
+			p, err := NewDryRun(&tc.cfg, &bytes.Buffer{})
+			if err != nil {
+				t.Fatalf("NewDryRun failed: %v", err)
+			}
+			_, err = p.DryRun(tc.file)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected error containing %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+// TestConvertEmptyFilePolicy validates that Convert applies the empty file policy
+func TestConvertEmptyFilePolicy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "empty.csv")
+	if err := os.WriteFile(file, []byte("id,name\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	testCases := []struct {
+		policy  string
+		rows    int // Rows of the result (-1 = no result)
+		wantErr bool
+	}{
+		{config.EmptyFilePolicyFail, -1, true},
+		{config.EmptyFilePolicyEmitEmptyArray, 0, false},
+		{config.EmptyFilePolicyIgnore, -1, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			p, err := NewConverter(&config.Config{Delimiter: ',', QuoteChar: '"', HasHeader: true, EmptyFilePolicy: tc.policy})
+			if err != nil {
+				t.Fatalf("NewConverter failed: %v", err)
+			}
+			result, err := p.Convert(file)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %t, got %v", tc.wantErr, err)
+			}
+			rows := -1
+			if result != nil {
+				rows = len(result.Rows)
+			}
+			if rows != tc.rows {
+				t.Errorf("Expected %d rows, got %d", tc.rows, rows)
+			}
+		})
+	}
+}
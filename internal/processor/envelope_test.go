@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// contextRecorder is a file handler that records the route context it is given
+type contextRecorder struct {
+	*output.FileHandler
+	routeName     string
+	contract      string
+	payloadFormat string
+	sources       []string
+}
+
+func (r *contextRecorder) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
+	r.routeName, r.contract, r.payloadFormat = routeName, ingestionContract, payloadFormat
+}
+
+func (r *contextRecorder) SetSourceFile(sourceFilePath string) {
+	r.sources = append(r.sources, sourceFilePath)
+}
+
+// TestEnvelopeContextWiring validates the route context is set once and each file only
+// updates the source path, so a route's legacy payload format survives processing
+func TestEnvelopeContextWiring(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	recorder := &contextRecorder{FileHandler: output.NewFileHandler(outputFolder)}
+	p := newTestProcessor(t, &config.Config{OutputType: "file", OutputFolder: outputFolder}, recorder)
+	p.SetEnvelopeContext("orders", "orders.csv.v1", output.PayloadLegacy)
+
+	file := filepath.Join(dir, "orders.csv")
+	if err := os.WriteFile(file, []byte("id\n1\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+
+	if recorder.routeName != "orders" || recorder.contract != "orders.csv.v1" || recorder.payloadFormat != output.PayloadLegacy {
+		t.Errorf("Unexpected route context: route=%q contract=%q payloadFormat=%q", recorder.routeName, recorder.contract, recorder.payloadFormat)
+	}
+	if len(recorder.sources) != 1 || recorder.sources[0] != file {
+		t.Errorf("Expected source file %s, got %v", file, recorder.sources)
+	}
+}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+
+	"csv2json/internal/alert"
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/events"
+	"csv2json/internal/metrics"
+)
+
+// Pipeline metrics, counted from the route's events
+const (
+	metricFilesArchived = "csv2json_files_total"
+	metricRowsPublished = "csv2json_rows_published_total"
+	metricRowsRejected  = "csv2json_rows_rejected_total"
+	metricRowsDeduped   = "csv2json_rows_deduplicated_total"
+)
+
+func init() {
+	metrics.Register(metricFilesArchived, metrics.Counter, "Files archived, by archive category")
+	metrics.Register(metricRowsPublished, metrics.Counter, "Rows delivered to the output")
+	metrics.Register(metricRowsRejected, metrics.Counter, "Parsed rows rejected by schema checks, transforms or the ingestion contract")
+	metrics.Register(metricRowsDeduped, metrics.Counter, "Duplicate rows removed by deduplication (DEDUP_KEYS)")
+}
+
+// subscribe creates the route's event bus and subscribes logging, metrics, alerts and
+// processing reports to it. Events are forwarded to the process-wide bus.
+func (p *Processor) subscribe() {
+	p.events = events.NewBus()
+	p.events.Subscribe(logEvent)
+	p.events.Subscribe(p.countEvent, events.FileArchived, events.PublishConfirmed, events.RowsRejected, events.RowsDeduplicated)
+	p.events.Subscribe(p.alertFailed, events.FileArchived)
+	p.events.Subscribe(p.reportEvent)
+	p.events.Subscribe(events.Publish)
+}
+
+// emit publishes a pipeline event for this route
+func (p *Processor) emit(event events.Event) {
+	event.Route = p.routeLabels()["route"]
+	p.events.Publish(event)
+}
+
+// archived publishes the archiving of a file (archiver callback)
+func (p *Processor) archived(filename string, category archiver.Category, reason string) {
+	p.emit(events.Event{Type: events.FileArchived, File: filename, Status: string(category), Reason: reason})
+}
+
+// logEvent logs the pipeline steps that are not logged where they happen
+func logEvent(e events.Event) {
+	switch e.Type {
+	case events.FileDetected:
+		log.Printf("Processing file: %s", e.File)
+	case events.FileParsed:
+		log.Printf("Parsed %d rows from %s (%s)", e.Rows, e.File, e.Detail)
+	case events.RowsRejected:
+		log.Printf("Rejected %d rows of %s: %s", e.Rows, e.File, e.Reason)
+	case events.FileArchived:
+		if e.Status == string(archiver.CategoryProcessed) {
+			log.Printf("Successfully processed: %s", e.File)
+		}
+	}
+}
+
+// countEvent updates the pipeline metrics
+func (p *Processor) countEvent(e events.Event) {
+	switch e.Type {
+	case events.FileArchived:
+		labels := p.routeLabels()
+		labels["status"] = e.Status
+		metrics.Add(metricFilesArchived, labels, 1)
+	case events.PublishConfirmed:
+		metrics.Add(metricRowsPublished, p.routeLabels(), float64(e.Rows))
+	case events.RowsRejected:
+		metrics.Add(metricRowsRejected, p.routeLabels(), float64(e.Rows))
+	case events.RowsDeduplicated:
+		metrics.Add(metricRowsDeduped, p.routeLabels(), float64(e.Rows))
+	}
+}
+
+// alertFailed alerts operators about files archived as failed
+func (p *Processor) alertFailed(e events.Event) {
+	if e.Status == string(archiver.CategoryFailed) {
+		alert.Send(config.AlertEventFileFailed, p.routeName, fmt.Sprintf("%s archived as failed: %s", e.File, e.Reason))
+	}
+}
+
+// reportEvent feeds the processing report of each file and publishes it once archived
+// (REPORT_DESTINATION)
+func (p *Processor) reportEvent(e events.Event) {
+	if p.reports == nil {
+		return
+	}
+	switch e.Type {
+	case events.FileDetected:
+		p.reports.started(e.File)
+	case events.FileParsed:
+		p.reports.parsed(e.File, e.Rows)
+	case events.RowsDeduplicated:
+		p.reports.deduplicated(e.File, e.Rows)
+	case events.PublishConfirmed:
+		p.reports.delivered(e.File, e.Rows)
+	case events.FileArchived:
+		p.sendReport(e.File, archiver.Category(e.Status), e.Reason)
+	}
+}
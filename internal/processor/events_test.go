@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/events"
+	"csv2json/internal/output"
+	"csv2json/internal/transform"
+)
+
+// TestProcessFileEvents validates the pipeline events published for delivered, deduplicated and
+// rejected files
+func TestProcessFileEvents(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	cfg := &config.Config{OutputType: "file", OutputFolder: outputFolder, SchemaDriftPolicy: config.SchemaDriftPolicyFail}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+	p.schema = newSchemaTracker(dir)
+	p.routeName = "orders"
+	dedup, err := transform.NewDeduplicator([]string{"id"}, transform.DedupKeepFirst)
+	if err != nil {
+		t.Fatalf("NewDeduplicator failed: %v", err)
+	}
+	p.transforms = transform.NewPipeline(dedup)
+	p.subscribe()
+	p.archiver.OnArchived(p.archived)
+
+	var got []events.Event
+	p.events.Subscribe(func(e events.Event) { got = append(got, e) })
+
+	files := map[string]string{"good.csv": "id,name\n1,widget\n2,gadget\n2,gadget\n", "drift.csv": "id,name,extra\n3,gizmo,x\n"}
+	for _, name := range []string{"good.csv", "drift.csv"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(files[name]), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := p.processFile(file); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	type step struct {
+		Type   events.Type
+		File   string
+		Rows   int
+		Status string
+	}
+	var steps []step
+	for _, e := range got {
+		if e.Route != "orders" {
+			t.Errorf("Expected events of route orders, got %q", e.Route)
+		}
+		steps = append(steps, step{e.Type, e.File, e.Rows, e.Status})
+	}
+	want := []step{
+		{events.FileDetected, "good.csv", 0, ""},
+		{events.ParseStarted, "good.csv", 0, ""},
+		{events.FileParsed, "good.csv", 3, ""},
+		{events.RowsDeduplicated, "good.csv", 1, ""},
+		{events.PublishConfirmed, "good.csv", 2, ""},
+		{events.FileArchived, "good.csv", 0, "processed"},
+		{events.FileDetected, "drift.csv", 0, ""},
+		{events.ParseStarted, "drift.csv", 0, ""},
+		{events.FileParsed, "drift.csv", 1, ""},
+		{events.RowsRejected, "drift.csv", 1, ""},
+		{events.FileArchived, "drift.csv", 0, "failed"},
+	}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("Expected events %v, got %v", want, steps)
+	}
+}
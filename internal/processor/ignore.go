@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"csv2json/internal/metrics"
+)
+
+// ignoreTracker counts ignored files by reason code and remembers the content
+// of processed files so identical re-deliveries can be ignored as duplicates
+type ignoreTracker struct {
+	mu     sync.Mutex
+	counts map[string]int
+	seen   map[string]string // Filename -> content hash of the last processed file with that name
+}
+
+func newIgnoreTracker() *ignoreTracker {
+	return &ignoreTracker{
+		counts: make(map[string]int),
+		seen:   make(map[string]string),
+	}
+}
+
+// count increments the ignored counter for reason
+func (t *ignoreTracker) count(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts[reason]++
+}
+
+// snapshot returns a copy of the ignored counters
+func (t *ignoreTracker) snapshot() map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	counts := make(map[string]int, len(t.counts))
+	for reason, n := range t.counts {
+		counts[reason] = n
+	}
+	return counts
+}
+
+// isDuplicate reports whether filename was already processed with identical content
+func (t *ignoreTracker) isDuplicate(filename, hash string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return hash != "" && t.seen[filename] == hash
+}
+
+// markProcessed records the content hash of a successfully processed file
+func (t *ignoreTracker) markProcessed(filename, hash string) {
+	if hash == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.seen[filename] = hash
+}
+
+// summary formats the ignored counters as "reason=n" pairs sorted by reason
+func (t *ignoreTracker) summary() string {
+	counts := t.snapshot()
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+
+	pairs := make([]string, len(reasons))
+	for i, reason := range reasons {
+		pairs[i] = fmt.Sprintf("%s=%d", reason, counts[reason])
+	}
+	return strings.Join(pairs, " ")
+}
+
+// IgnoredCounts returns the number of files ignored by this processor, keyed by reason code
+func (p *Processor) IgnoredCounts() map[string]int {
+	return p.ignored.snapshot()
+}
+
+// ignore archives a file as ignored with its reason code and updates the counters
+func (p *Processor) ignore(filePath, filename, reason, detail string) error {
+	log.Printf("Ignoring %s (reason: %s, %s)", filename, reason, detail)
+	p.ignored.count(reason)
+	labels := p.routeLabels()
+	labels["reason"] = reason
+	metrics.Add(metricFilesIgnored, labels, 1)
+	return p.archiver.ArchiveIgnored(filePath, reason, detail)
+}
+
+// skip leaves a file that does not match the filters in the input folder and
+// updates the counters; the monitor does not offer it again while it stays there
+func (p *Processor) skip(filename, reason, detail string) {
+	log.Printf("Skipping %s, left in place (reason: %s, %s)", filename, reason, detail)
+	p.ignored.count(reason)
+	labels := p.routeLabels()
+	labels["reason"] = reason
+	metrics.Add(metricFilesIgnored, labels, 1)
+}
+
+// fileHash returns the hex SHA-256 of a file's content
+func fileHash(filePath string) (string, error) {
+	return hashFile(filePath, sha256.New)
+}
+
+// hashFile returns the hex digest of a file's content using the given hash
+func hashFile(filePath string, newHash func() hash.Hash) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := newHash()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+)
+
+// TestIgnoreTracker validates ignored counters and duplicate detection by name and content
+func TestIgnoreTracker(t *testing.T) {
+	tracker := newIgnoreTracker()
+	tracker.count("pattern_mismatch")
+	tracker.count("pattern_mismatch")
+	tracker.count("duplicate")
+
+	counts := tracker.snapshot()
+	if counts["pattern_mismatch"] != 2 || counts["duplicate"] != 1 {
+		t.Errorf("Unexpected counts: %v", counts)
+	}
+	if summary := tracker.summary(); summary != "duplicate=1 pattern_mismatch=2" {
+		t.Errorf("Expected sorted summary, got %q", summary)
+	}
+
+	tracker.markProcessed("a.csv", "abc")
+	if !tracker.isDuplicate("a.csv", "abc") {
+		t.Error("Expected same name and content to be a duplicate")
+	}
+	if tracker.isDuplicate("a.csv", "def") {
+		t.Error("Expected changed content not to be a duplicate")
+	}
+	if tracker.isDuplicate("b.csv", "abc") {
+		t.Error("Expected a different filename not to be a duplicate")
+	}
+}
+
+// TestFileHash validates content hashing used for duplicate detection
+func TestFileHash(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.csv")
+	second := filepath.Join(dir, "second.csv")
+	os.WriteFile(first, []byte("id\n1\n"), 0644)
+	os.WriteFile(second, []byte("id\n1\n"), 0644)
+
+	h1, err := fileHash(first)
+	if err != nil {
+		t.Fatalf("fileHash failed: %v", err)
+	}
+	h2, _ := fileHash(second)
+	if h1 != h2 || len(h1) != 64 {
+		t.Errorf("Expected equal 64-char hashes, got %q and %q", h1, h2)
+	}
+}
+
+// TestProcessFileUnmatchedPolicy validates that unmatched files are archived or left in place
+func TestProcessFileUnmatchedPolicy(t *testing.T) {
+	tests := []struct {
+		policy     string
+		wantInput  bool
+		wantIgnore bool
+	}{
+		{config.UnmatchedFileArchive, false, true},
+		{config.UnmatchedFileSkip, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dir := t.TempDir()
+			ignoredDir := filepath.Join(dir, "ignored")
+			p := newTestProcessor(t, &config.Config{FileSuffixFilter: []string{".csv"}, UnmatchedPolicy: tt.policy, ArchiveIgnored: ignoredDir}, nil)
+			file := filepath.Join(dir, "notes.txt")
+			if err := os.WriteFile(file, []byte("not for us"), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			if err := p.processFile(file); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+			if _, err := os.Stat(file); (err == nil) != tt.wantInput {
+				t.Errorf("Expected file left in input folder: %v, stat error: %v", tt.wantInput, err)
+			}
+			if _, err := os.Stat(filepath.Join(ignoredDir, "notes.txt")); (err == nil) != tt.wantIgnore {
+				t.Errorf("Expected file in ignored archive: %v, stat error: %v", tt.wantIgnore, err)
+			}
+			if counts := p.IgnoredCounts(); counts[config.IgnoreReasonSuffixMismatch] != 1 {
+				t.Errorf("Expected one suffix_mismatch, got %v", counts)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lockFolder is the input subfolder holding the instance lock file. Monitors skip
+// subfolders, so the lock file is never picked up as input.
+const lockFolder = ".lock"
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("locked by another process")
+
+// instanceLock is an exclusive OS file lock on an input folder. The lock is released
+// by the OS when the process exits, so a crashed instance never leaves a stale lock.
+type instanceLock struct {
+	file *os.File
+}
+
+// acquireInstanceLock locks inputFolder for this process, failing if another
+// instance on this host already watches it
+func acquireInstanceLock(inputFolder string) (*instanceLock, error) {
+	dir := filepath.Join(inputFolder, lockFolder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock folder: %w", err)
+	}
+
+	path := filepath.Join(dir, "csv2json.pid")
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, errLocked) {
+			return nil, fmt.Errorf("input folder %s is already watched by another csv2json instance%s (lock file %s)", inputFolder, lockHolder(path), path)
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// Record the holder for operators; the lock itself is the OS file lock
+	if err := file.Truncate(0); err == nil {
+		file.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	}
+	return &instanceLock{file: file}, nil
+}
+
+// release unlocks the folder. The lock file is left in place: removing it could let a
+// starting instance lock a file that is about to disappear.
+func (l *instanceLock) release() {
+	if l != nil {
+		l.file.Close()
+	}
+}
+
+// lockHolder describes the process recorded in a lock file, or "" if unreadable
+func lockHolder(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	if pid := strings.TrimSpace(string(content)); pid != "" {
+		return " (pid " + pid + ")"
+	}
+	return ""
+}
@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestInstanceLock validates a locked input folder cannot be locked again until released
+func TestInstanceLock(t *testing.T) {
+	input := t.TempDir()
+
+	lock, err := acquireInstanceLock(input)
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(input, lockFolder, "csv2json.pid"))
+	if err != nil || strings.TrimSpace(string(content)) != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Expected lock file to record pid %d, got %q (%v)", os.Getpid(), content, err)
+	}
+
+	if _, err := acquireInstanceLock(input); err == nil || !strings.Contains(err.Error(), "already watched") {
+		t.Fatalf("Expected second lock to fail, got: %v", err)
+	}
+
+	lock.release()
+	relock, err := acquireInstanceLock(input)
+	if err != nil {
+		t.Fatalf("Expected lock to be available after release, got: %v", err)
+	}
+	relock.release()
+}
@@ -0,0 +1,247 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// heldFolder holds files of completed business dates under the hold policy until they
+// are released, inside the input folder. Monitors skip subfolders, so held files are
+// never picked up again.
+const heldFolder = ".held"
+
+// businessDateTracker remembers the latest business date a route processed. A date is
+// completed once a file for a later date has been processed, so further files for the
+// latest date are still part of it, while files for earlier dates are handled by the
+// late business date policy. The date survives restarts in .state/business-dates.json;
+// held files released with csv2json release are recorded in .state/released.json,
+// which the release command writes while the service runs.
+type businessDateTracker struct {
+	mu           sync.Mutex
+	path         string
+	releasedPath string
+	latest       string // Latest processed business date (YYYY-MM-DD, "" = none)
+}
+
+// businessDateState is the state persisted in .state/business-dates.json
+type businessDateState struct {
+	Latest string `json:"latest"`
+}
+
+func newBusinessDateTracker(inputFolder string) *businessDateTracker {
+	t := &businessDateTracker{
+		path:         filepath.Join(inputFolder, stateFolder, "business-dates.json"),
+		releasedPath: releasedPath(inputFolder),
+	}
+	content, err := os.ReadFile(t.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to read business dates %s: %v", t.path, err)
+		}
+		return t
+	}
+	var state businessDateState
+	if err := json.Unmarshal(content, &state); err != nil {
+		log.Printf("Warning: ignoring invalid business dates %s", t.path)
+		return t
+	}
+	t.latest = state.Latest
+	return t
+}
+
+// releasedPath returns the file recording the held files released in inputFolder
+func releasedPath(inputFolder string) string {
+	return filepath.Join(inputFolder, stateFolder, "released.json")
+}
+
+// late reports whether date was completed, i.e. is before the latest processed date
+func (t *businessDateTracker) late(date string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.latest != "" && date < t.latest
+}
+
+// processed records that a file for date was processed, completing earlier dates if
+// it is later than the latest processed date
+func (t *businessDateTracker) processed(date string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if date <= t.latest {
+		return
+	}
+	t.latest = date
+	if err := writeJSON(t.path, businessDateState{Latest: date}); err != nil {
+		log.Printf("Warning: failed to persist latest business date: %v", err)
+	}
+}
+
+// released reports whether filename with content hash was released from the held
+// folder, and forgets the release so a later copy of the file is held again
+func (t *businessDateTracker) released(filename, hash string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	releases, err := readReleases(t.releasedPath)
+	if err != nil {
+		log.Printf("Warning: failed to read released files %s: %v", t.releasedPath, err)
+		return false
+	}
+	if hash == "" || releases[filename] != hash {
+		return false
+	}
+	delete(releases, filename)
+	if err := writeJSON(t.releasedPath, releases); err != nil {
+		log.Printf("Warning: failed to update released files %s: %v", t.releasedPath, err)
+	}
+	return true
+}
+
+// readReleases reads the released files (filename -> content hash) recorded at path
+func readReleases(path string) (map[string]string, error) {
+	releases := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return releases, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(content, &releases); err != nil {
+		return nil, fmt.Errorf("invalid released files: %w", err)
+	}
+	return releases, nil
+}
+
+// writeJSON atomically replaces path with v as indented JSON
+func writeJSON(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// applyLateDatePolicy handles a file whose business date the route already completed.
+// It returns true if the file was held and must not be sent.
+func (p *Processor) applyLateDatePolicy(filePath, filename string) (bool, error) {
+	if p.dates == nil || p.businessDate == "" || !p.dates.late(p.businessDate) {
+		return false, nil
+	}
+
+	switch p.config.LateDatePolicy {
+	case config.LateBusinessDateCorrections:
+		log.Printf("%s restates completed business date %s, publishing it to corrections queue %s", filename, p.businessDate, p.config.CorrectionsQueue)
+		if cs, ok := p.output.(output.CorrectionSetter); ok {
+			cs.SetCorrection(true)
+		}
+
+	case config.LateBusinessDateHold:
+		hash, err := fileHash(filePath)
+		if err != nil {
+			return false, err
+		}
+		if p.dates.released(filename, hash) {
+			log.Printf("%s restates completed business date %s and was released, processing it", filename, p.businessDate)
+			return false, nil
+		}
+		held, err := p.hold(filePath)
+		if err != nil {
+			return false, err
+		}
+		log.Printf("ALERT: %s restates completed business date %s; held as %s until released with csv2json release", filename, p.businessDate, held)
+		return true, nil
+
+	default:
+		log.Printf("%s restates completed business date %s", filename, p.businessDate)
+	}
+	return false, nil
+}
+
+// hold moves a file to the held folder under its name, returning its held path
+func (p *Processor) hold(filePath string) (string, error) {
+	folder := filepath.Join(p.config.InputFolder, heldFolder)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create held folder: %w", err)
+	}
+	held := filepath.Join(folder, filepath.Base(filePath))
+	if _, err := os.Stat(held); err == nil {
+		return "", fmt.Errorf("a file named %s is already held", filepath.Base(filePath))
+	}
+	if err := archiver.MoveFile(filePath, held); err != nil {
+		return "", err
+	}
+	return held, nil
+}
+
+// ReleaseHeld moves the files held in inputFolder whose name matches pattern ("" = all)
+// back into it and records them as released, so the route processes them despite their
+// completed business date. Returns the names released (or that would be, for a dry run).
+func ReleaseHeld(inputFolder, pattern string, dryRun bool) ([]string, error) {
+	folder := filepath.Join(inputFolder, heldFolder)
+	entries, err := os.ReadDir(folder)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read held files: %w", err)
+	}
+
+	path := releasedPath(inputFolder)
+	releases, err := readReleases(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var released []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() {
+			continue
+		}
+		if pattern != "" {
+			if ok, _ := filepath.Match(pattern, name); !ok {
+				continue
+			}
+		}
+		if dryRun {
+			released = append(released, name)
+			continue
+		}
+
+		held, target := filepath.Join(folder, name), filepath.Join(inputFolder, name)
+		if _, err := os.Stat(target); err == nil {
+			log.Printf("Failed to release %s: input file already exists", name)
+			continue
+		}
+		hash, err := fileHash(held)
+		if err != nil {
+			log.Printf("Failed to release %s: %v", name, err)
+			continue
+		}
+		// Recorded before the file is moved, so the route never sees it unreleased
+		releases[name] = hash
+		if err := writeJSON(path, releases); err != nil {
+			return released, fmt.Errorf("failed to record released files: %w", err)
+		}
+		if err := archiver.MoveFile(held, target); err != nil {
+			log.Printf("Failed to release %s: %v", name, err)
+			continue
+		}
+		released = append(released, name)
+	}
+	return released, nil
+}
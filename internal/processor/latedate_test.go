@@ -0,0 +1,134 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/businessdate"
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+)
+
+// correctionRecorder is a file handler that records whether each file was sent as a correction
+type correctionRecorder struct {
+	*output.FileHandler
+	correction  bool
+	corrections []bool
+}
+
+func (r *correctionRecorder) SetCorrection(correction bool) {
+	r.correction = correction
+}
+
+func (r *correctionRecorder) SendOrdered(result *parser.ParseResult, identifier string) error {
+	r.corrections = append(r.corrections, r.correction)
+	return r.FileHandler.SendOrdered(result, identifier)
+}
+
+func (r *correctionRecorder) SendSpill(spill *output.Spill, identifier string) error {
+	r.corrections = append(r.corrections, r.correction)
+	return r.FileHandler.SendSpill(spill, identifier)
+}
+
+// newLateDateProcessor returns a processor deriving business dates from filenames
+// with the given late business date policy
+func newLateDateProcessor(t *testing.T, dir, policy string, out output.Handler) *Processor {
+	t.Helper()
+	extractor, err := businessdate.New(businessdate.Spec{From: businessdate.FromFilename})
+	if err != nil {
+		t.Fatalf("Failed to create extractor: %v", err)
+	}
+	cfg := &config.Config{InputFolder: dir, OutputType: "file", BusinessDate: extractor, LateDatePolicy: policy, CorrectionsQueue: "orders.corrections"}
+	p := newTestProcessor(t, cfg, out)
+	p.dates = newBusinessDateTracker(dir)
+	return p
+}
+
+// processDated writes and processes a file named name in dir
+func processDated(t *testing.T, p *Processor, dir, name string) {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := os.WriteFile(file, []byte("id,qty\n1,2\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+}
+
+// TestLateDateHold validates files of completed business dates are held until
+// released, and that the completed date survives restarts
+func TestLateDateHold(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	p := newLateDateProcessor(t, dir, config.LateBusinessDateHold, output.NewFileHandler(outputFolder))
+
+	// Files for the same date belong to it until a later date arrives
+	processDated(t, p, dir, "orders_20260331.csv")
+	processDated(t, p, dir, "orders_20260331_part2.csv")
+	if _, err := os.Stat(filepath.Join(outputFolder, "orders_20260331_part2.json")); err != nil {
+		t.Fatalf("Expected the second file of the latest date to be processed: %v", err)
+	}
+
+	processDated(t, p, dir, "orders_20260330.csv")
+	if _, err := os.Stat(filepath.Join(dir, heldFolder, "orders_20260330.csv")); err != nil {
+		t.Fatalf("Expected the late file to be held: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputFolder, "orders_20260330.json")); !os.IsNotExist(err) {
+		t.Error("Expected no output for the held file")
+	}
+
+	// A restarted route still knows the latest date
+	if tracker := newBusinessDateTracker(dir); !tracker.late("2026-03-30") || tracker.late("2026-03-31") {
+		t.Errorf("Expected 2026-03-30 to be completed after a restart, latest %q", tracker.latest)
+	}
+
+	released, err := ReleaseHeld(dir, "orders_*.csv", false)
+	if err != nil || len(released) != 1 {
+		t.Fatalf("Expected one released file, got %v (%v)", released, err)
+	}
+	if err := p.processFile(filepath.Join(dir, "orders_20260330.csv")); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputFolder, "orders_20260330.json")); err != nil {
+		t.Errorf("Expected output for the released file: %v", err)
+	}
+
+	// The release is used up: the same file arriving again is held again
+	processDated(t, p, dir, "orders_20260330.csv")
+	if _, err := os.Stat(filepath.Join(dir, heldFolder, "orders_20260330.csv")); err != nil {
+		t.Errorf("Expected the file to be held again: %v", err)
+	}
+}
+
+// TestLateDateCorrections validates only files of completed business dates are flagged
+// as corrections: a date is completed once a later date arrives
+func TestLateDateCorrections(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	recorder := &correctionRecorder{FileHandler: output.NewFileHandler(outputFolder)}
+	p := newLateDateProcessor(t, dir, config.LateBusinessDateCorrections, recorder)
+
+	processDated(t, p, dir, "orders_20260331.csv")
+	processDated(t, p, dir, "orders_20260331_v2.csv")
+	processDated(t, p, dir, "orders_20260401.csv")
+	processDated(t, p, dir, "orders_20260331_v3.csv")
+
+	want := []bool{false, false, false, true}
+	if len(recorder.corrections) != len(want) {
+		t.Fatalf("Expected corrections %v, got %v", want, recorder.corrections)
+	}
+	for i := range want {
+		if recorder.corrections[i] != want[i] {
+			t.Fatalf("Expected corrections %v, got %v", want, recorder.corrections)
+		}
+	}
+}
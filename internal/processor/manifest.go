@@ -0,0 +1,240 @@
+package processor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/events"
+	"csv2json/internal/parser"
+)
+
+// manifestSuffix marks batch manifests listing the members of a transactional batch
+const manifestSuffix = ".manifest"
+
+// Fields of the batch-complete record emitted once every member was delivered
+const (
+	manifestBatchKey     = "batch"
+	manifestStatusKey    = "status"
+	manifestFileCountKey = "fileCount"
+	manifestFilesKey     = "files"
+	manifestComplete     = "complete"
+)
+
+// manifestMember is a file listed in a batch manifest
+type manifestMember struct {
+	filePath string
+	filename string
+	result   *parser.ParseResult // nil when the member is archived without output (EMPTY_FILE_POLICY=ignore)
+}
+
+// isManifest reports whether filename is a batch manifest
+func isManifest(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), manifestSuffix)
+}
+
+// readManifest returns the member filenames listed in a manifest, one per line.
+// Blank lines and lines starting with # are skipped.
+func readManifest(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var members []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		if name != filepath.Base(name) || name == "." || name == ".." || isManifest(name) {
+			return nil, fmt.Errorf("entry %q must name a data file in the input folder", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("entry %q is listed twice", name)
+		}
+		seen[name] = true
+		members = append(members, name)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("manifest lists no files")
+	}
+	return members, nil
+}
+
+// processManifest delivers the files listed in a manifest as one transactional batch.
+// Every member is converted before anything is sent. Only when all members were
+// delivered is a batch-complete record emitted and the batch archived as processed;
+// otherwise the manifest and all its members are archived as failed together.
+func (p *Processor) processManifest(manifestPath, manifestName string) error {
+	names, err := readManifest(manifestPath)
+	if err != nil {
+		return p.archiveBatch(manifestPath, manifestName, nil, fmt.Errorf("invalid batch manifest: %w", err))
+	}
+	log.Printf("Batch manifest %s lists %d files", manifestName, len(names))
+
+	// Members must be in place when the manifest arrives, so producers write it last
+	members := make([]manifestMember, 0, len(names))
+	var missing []string
+	for _, name := range names {
+		memberPath := filepath.Join(p.config.InputFolder, name)
+		if _, err := os.Stat(memberPath); err != nil {
+			missing = append(missing, name)
+			continue
+		}
+		members = append(members, manifestMember{filePath: memberPath, filename: name})
+	}
+	if len(missing) > 0 {
+		return p.archiveBatch(manifestPath, manifestName, members, fmt.Errorf("batch member(s) not found: %s", strings.Join(missing, ", ")))
+	}
+
+	// Members are taken into the working directory with their manifest
+	if p.work != nil {
+		for i := range members {
+			working, err := p.work.take(members[i].filePath)
+			if err != nil {
+				return p.archiveBatch(manifestPath, manifestName, members, fmt.Errorf("batch member %s: %w", members[i].filename, err))
+			}
+			members[i].filePath = working
+		}
+	}
+
+	for i := range members {
+		member := &members[i]
+		p.emit(events.Event{Type: events.FileDetected, File: member.filename})
+		result, err := p.convertMember(member.filePath, member.filename)
+		if err != nil {
+			return p.archiveBatch(manifestPath, manifestName, members, fmt.Errorf("batch member %s: %w", member.filename, err))
+		}
+		member.result = result
+	}
+
+	for _, member := range members {
+		if member.result == nil {
+			continue
+		}
+		p.setEnvelopeSource(member.filePath)
+		if err := p.send(member.result, member.filename); err != nil {
+			log.Printf("Output failed: %v", err)
+			p.alertOutputFailed(err)
+			return p.archiveBatch(manifestPath, manifestName, members, fmt.Errorf("batch member %s: %w", member.filename, err))
+		}
+	}
+
+	p.setEnvelopeSource(manifestPath)
+	if err := p.output.SendOrdered(buildBatchComplete(manifestName, members), manifestName); err != nil {
+		log.Printf("Output failed: %v", err)
+		p.alertOutputFailed(err)
+		return p.archiveBatch(manifestPath, manifestName, members, fmt.Errorf("batch-complete record: %w", err))
+	}
+	log.Printf("Emitted batch-complete record for %s (%d files)", manifestName, len(members))
+
+	return p.archiveBatch(manifestPath, manifestName, members, nil)
+}
+
+// convertMember validates and converts one batch member, applying the empty file policy.
+// A nil result means the member is archived without output.
+func (p *Processor) convertMember(filePath, filename string) (*parser.ParseResult, error) {
+	if err := p.verifyChecksum(filePath, filename); err != nil {
+		return nil, err
+	}
+	err := p.parser.Validate(filePath)
+	if err == nil {
+		if exceeds, _ := p.exceedsMemoryLimit(filePath); exceeds {
+			return nil, fmt.Errorf("file exceeds MEMORY_LIMIT_MB (%d); batch members are converted in memory", p.config.MemoryLimitMB)
+		}
+		var result *parser.ParseResult
+		if result, err = p.convert(filePath, filename); err == nil {
+			return result, nil
+		}
+	}
+	if !errors.Is(err, parser.ErrNoDataRows) {
+		return nil, err
+	}
+
+	switch p.config.EmptyFilePolicy {
+	case config.EmptyFilePolicyEmitEmptyArray:
+		log.Printf("No data rows in %s, emitting empty payload (EMPTY_FILE_POLICY=%s)", filename, p.config.EmptyFilePolicy)
+		return &parser.ParseResult{}, nil
+	case config.EmptyFilePolicyIgnore:
+		log.Printf("No data rows in %s, archiving with its batch without output (EMPTY_FILE_POLICY=%s)", filename, p.config.EmptyFilePolicy)
+		return nil, nil
+	}
+	return nil, err
+}
+
+// archiveBatch archives a manifest and its members together: as processed when batchErr
+// is nil, otherwise as failed with the batch error as the reason for every file
+func (p *Processor) archiveBatch(manifestPath, manifestName string, members []manifestMember, batchErr error) error {
+	category, reason := archiver.CategoryProcessed, ""
+	if batchErr != nil {
+		log.Printf("Batch %s failed: %v", manifestName, batchErr)
+		category, reason = archiver.CategoryFailed, batchErr.Error()
+	}
+
+	var errs []error
+	for _, member := range members {
+		if batchErr == nil && member.result != nil {
+			p.emit(events.Event{Type: events.PublishConfirmed, File: member.filename, Rows: len(member.result.Rows)})
+		}
+		if err := p.archiver.Archive(member.filePath, category, reason); err != nil {
+			log.Printf("Failed to archive file %s: %v", member.filename, err)
+			errs = append(errs, err)
+		}
+	}
+	if err := p.archiver.Archive(manifestPath, category, reason); err != nil {
+		log.Printf("Failed to archive file %s: %v", manifestName, err)
+		errs = append(errs, err)
+	}
+
+	if batchErr == nil && len(errs) == 0 {
+		log.Printf("Successfully processed batch: %s", manifestName)
+	}
+	return errors.Join(errs...)
+}
+
+// buildBatchComplete builds the record announcing that every member of a batch was delivered
+func buildBatchComplete(manifestName string, members []manifestMember) *parser.ParseResult {
+	fileKeys := []string{batchSourceKey, batchRowCountKey}
+	files := make([]parser.OrderedMap, 0, len(members))
+	total := 0
+	for _, member := range members {
+		rows := 0
+		if member.result != nil {
+			rows = len(member.result.Rows)
+		}
+		total += rows
+		files = append(files, parser.OrderedMap{
+			Keys:   fileKeys,
+			Values: map[string]string{batchSourceKey: member.filename, batchRowCountKey: strconv.Itoa(rows)},
+		})
+	}
+
+	keys := []string{manifestBatchKey, manifestStatusKey, manifestFileCountKey, batchRowCountKey, manifestFilesKey}
+	return &parser.ParseResult{
+		Headers: keys,
+		Rows: []parser.OrderedMap{{
+			Keys: keys,
+			Values: map[string]string{
+				manifestBatchKey:     strings.TrimSuffix(manifestName, filepath.Ext(manifestName)),
+				manifestStatusKey:    manifestComplete,
+				manifestFileCountKey: strconv.Itoa(len(members)),
+				batchRowCountKey:     strconv.Itoa(total),
+			},
+			Nested: map[string][]parser.OrderedMap{manifestFilesKey: files},
+		}},
+	}
+}
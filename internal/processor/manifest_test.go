@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestProcessManifest validates that manifest members are delivered and archived as a unit
+func TestProcessManifest(t *testing.T) {
+	dir := t.TempDir()
+	inputDir := filepath.Join(dir, "input")
+	outputDir := filepath.Join(dir, "output")
+	processed := filepath.Join(dir, "processed")
+	failed := filepath.Join(dir, "failed")
+	for _, folder := range []string{inputDir, outputDir} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		InputFolder:      inputDir,
+		OutputType:       "file",
+		OutputFolder:     outputDir,
+		BatchManifests:   true,
+		EmptyFilePolicy:  config.EmptyFilePolicyFail,
+		ArchiveProcessed: processed,
+		ArchiveFailed:    failed,
+	}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputDir))
+
+	files := map[string]string{
+		"a.csv":            "id,name\n1,widget\n",
+		"b.csv":            "id,name\n2,gadget\n3,gizmo\n",
+		"c.csv":            "id,name\n",
+		"batch_1.manifest": "# nightly orders\na.csv\n\nb.csv\n",
+		"batch_2.manifest": "c.csv\nmissing.csv\n",
+		"batch_3.manifest": "c.csv\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	// Members wait in the input folder until a manifest lists them
+	if err := p.processFile(filepath.Join(inputDir, "a.csv")); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(inputDir, "a.csv")); err != nil {
+		t.Fatalf("Expected a.csv held in the input folder: %v", err)
+	}
+
+	if err := p.processFile(filepath.Join(inputDir, "batch_1.manifest")); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	for _, name := range []string{"a.csv", "b.csv", "batch_1.manifest"} {
+		if _, err := os.Stat(filepath.Join(processed, name)); err != nil {
+			t.Errorf("Expected %s archived as processed: %v", name, err)
+		}
+	}
+	content, err := os.ReadFile(filepath.Join(outputDir, "batch_1.json"))
+	if err != nil {
+		t.Fatalf("Expected batch-complete record: %v", err)
+	}
+	var complete []map[string]any
+	if err := json.Unmarshal(content, &complete); err != nil {
+		t.Fatalf("Invalid batch-complete record: %v", err)
+	}
+	if len(complete) != 1 || complete[0]["status"] != "complete" || complete[0]["fileCount"] != "2" || complete[0]["rowCount"] != "3" {
+		t.Errorf("Unexpected batch-complete record: %s", content)
+	}
+
+	// A missing member fails the batch without output
+	if err := p.processFile(filepath.Join(inputDir, "batch_2.manifest")); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	for _, name := range []string{"c.csv", "batch_2.manifest"} {
+		if _, err := os.Stat(filepath.Join(failed, name)); err != nil {
+			t.Errorf("Expected %s archived as failed: %v", name, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "batch_2.json")); err == nil {
+		t.Error("Expected no batch-complete record for a failed batch")
+	}
+
+	// A member that fails conversion fails the batch
+	if err := os.WriteFile(filepath.Join(inputDir, "c.csv"), []byte(files["c.csv"]), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(filepath.Join(inputDir, "batch_3.manifest")); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(failed, "batch_3.manifest")); err != nil {
+		t.Errorf("Expected batch_3.manifest archived as failed: %v", err)
+	}
+}
+
+// TestReadManifest validates manifest entries are plain, unique file names
+func TestReadManifest(t *testing.T) {
+	dir := t.TempDir()
+	invalid := map[string]string{
+		"empty":     "# nothing\n\n",
+		"path":      "../orders.csv\n",
+		"duplicate": "a.csv\na.csv\n",
+		"manifest":  "other.manifest\n",
+	}
+	for name, content := range invalid {
+		path := filepath.Join(dir, name+".manifest")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write manifest: %v", err)
+		}
+		if _, err := readManifest(path); err == nil {
+			t.Errorf("%s: expected error, got success", name)
+		}
+	}
+}
@@ -1,30 +1,77 @@
 package processor
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
 	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"csv2json/internal/alert"
 	"csv2json/internal/archiver"
 	"csv2json/internal/config"
+	"csv2json/internal/contract"
+	"csv2json/internal/converter"
+	"csv2json/internal/events"
+	"csv2json/internal/failure"
 	"csv2json/internal/monitor"
 	"csv2json/internal/output"
 	"csv2json/internal/parser"
+	"csv2json/internal/transform"
 )
 
 type Processor struct {
 	config            *config.Config
 	parser            *parser.Parser
+	transforms        *transform.Pipeline
+	batch             *batcher // Non-nil when merge window batching is enabled
+	ignored           *ignoreTracker
+	scheduler         *Scheduler    // Optional processing budget shared with other routes
+	claims            *claimer      // Non-nil when files are claimed before processing (CLAIM_FILES)
+	claimsOnce        sync.Once     // Claim maintenance runs once across supervised restarts
+	disk              *diskGuard    // Non-nil when intake pauses on low disk space (MIN_FREE_DISK_MB)
+	throttle          *byteThrottle // Non-nil when intake is limited by volume (BYTES_PER_INTERVAL)
+	done              chan struct{}
+	schema            *schemaTracker       // Non-nil when columns are compared with the established schema (SCHEMA_DRIFT_POLICY)
+	arrivals          *arrivalTracker      // Non-nil when files are expected on a schedule (EXPECTED_ARRIVAL)
+	arrivalsOnce      sync.Once            // Deadline checks run once across supervised restarts
+	reports           *reportTracker       // Non-nil when a report is published after each file (REPORT_DESTINATION)
+	events            *events.Bus          // Pipeline events of this route, forwarded to the process-wide bus
+	lock              *instanceLock        // Non-nil when the input folder is locked against other instances (INSTANCE_LOCK)
+	work              *workDir             // Non-nil when files are processed in a working directory (WORK_DIR)
+	published         *publishedTracker    // Non-nil when sent files that could not be archived are recorded (ARCHIVE_FAILURE_POLICY=mark)
+	delivered         *deliveryStore       // Non-nil when content is published only once (EXACTLY_ONCE)
+	dates             *businessDateTracker // Non-nil when late business dates are corrected or held (LATE_BUSINESS_DATE_POLICY)
+	businessDate      string               // Business date of the file being processed ("" = none)
+	restarting        atomic.Bool          // Set while the supervisor waits to restart the monitor
+	waiting           atomic.Int32         // Files held back by low disk space or the shared scheduler
+	processing        atomic.Int32         // Files being processed; the monitor loop does not turn meanwhile
+	files             sync.Mutex           // Held while a file is processed; batch window flushes wait for it
+	priority          int                  // Route priority when waiting for a scheduler slot
 	archiver          *archiver.Archiver
 	output            output.Handler
 	monitor           monitor.FileMonitor // Changed from *monitor.Monitor to interface
 	routeName         string              // Optional route name for multi-ingress mode
 	ingestionContract string              // Schema/contract identifier (ADR-006)
+	contract          *contract.Schema    // Registry schema output must satisfy (nil = contract is a label only)
 }
 
 func New(cfg *config.Config) (*Processor, error) {
+	// Lock the input folder before anything starts watching or connecting
+	var lock *instanceLock
+	if cfg.InstanceLock {
+		var err error
+		if lock, err = acquireInstanceLock(cfg.InputFolder); err != nil {
+			return nil, err
+		}
+	}
+
 	// Initialize components
-	p := parser.New(cfg.Delimiter, cfg.QuoteChar, cfg.HasHeader)
+	p := newParser(cfg)
 
 	arch := archiver.New(
 		cfg.ArchiveProcessed,
@@ -32,8 +79,9 @@ func New(cfg *config.Config) (*Processor, error) {
 		cfg.ArchiveFailed,
 		cfg.ArchiveTimestamp,
 	)
+	arch.SetLocation(cfg.Location())
 
-	out, err := output.CreateHandler(
+	out, err := output.CreateHandlerWithOptions(
 		cfg.OutputType,
 		cfg.OutputFolder,
 		cfg.QueueType,
@@ -43,107 +91,844 @@ func New(cfg *config.Config) (*Processor, error) {
 		cfg.QueueUsername,
 		cfg.QueuePassword,
 		cfg.LogQueueMessages,
+		outputOptions(cfg),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create output handler: %w", err)
 	}
 
 	// Create appropriate monitor based on watch mode
+	var backfillOrder monitor.BackfillOrder
+	if cfg.ProcessExisting {
+		backfillOrder = monitor.BackfillOrder(cfg.ProcessExistingOrder)
+	}
 	mon, err := monitor.NewMonitor(
 		monitor.WatchMode(cfg.WatchMode),
 		cfg.InputFolder,
 		cfg.PollInterval,
 		cfg.HybridPollInterval,
+		cfg.StabilityWindow,
 		cfg.MaxFilesPerPoll,
+		backfillOrder,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file monitor: %w", err)
 	}
 
-	return &Processor{
+	transforms, err := buildTransforms(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure transforms: %w", err)
+	}
+
+	proc := &Processor{
 		config:            cfg,
 		parser:            p,
+		transforms:        transforms,
 		archiver:          arch,
 		output:            out,
 		monitor:           mon,
+		ignored:           newIgnoreTracker(),
+		done:              make(chan struct{}),
+		lock:              lock,
 		routeName:         "", // Empty for legacy mode
 		ingestionContract: "", // Empty for legacy mode
-	}, nil
+	}
+	proc.subscribe()
+	arch.OnArchived(proc.archived)
+	if w, ok := mon.(monitor.WatchReporter); ok {
+		w.SetWatchReporter(proc.watchEvent)
+	}
+	if proc.checksumsEnabled() {
+		arch.SetCompanions(cfg.InputFolder, checksumSuffixes())
+	}
+
+	if cfg.WorkDir != "" {
+		work, err := newWorkDir(cfg.WorkDir, cfg.InputFolder)
+		if err != nil {
+			out.Close()
+			lock.release()
+			return nil, err
+		}
+		work.recoverOrphans(arch)
+		proc.work = work
+	}
+
+	if cfg.MinFreeDiskMB > 0 {
+		paths := guardedPaths(cfg.OutputType, cfg.OutputFolder, cfg.ArchiveProcessed, cfg.ArchiveIgnored, cfg.ArchiveFailed)
+		proc.disk = newDiskGuard(paths, cfg.MinFreeDiskMB, cfg.DiskCheckInterval)
+	}
+
+	if cfg.BytesPerInterval > 0 {
+		proc.throttle = newByteThrottle(cfg.BytesPerInterval, cfg.ThrottleInterval)
+	}
+
+	if (cfg.SchemaDriftPolicy == config.SchemaDriftPolicyWarn || cfg.SchemaDriftPolicy == config.SchemaDriftPolicyFail) && !cfg.ReverseConversion {
+		proc.schema = newSchemaTracker(cfg.InputFolder)
+	}
+
+	if cfg.ExpectedArrival != nil {
+		proc.arrivals = newArrivalTracker(cfg.InputFolder, cfg.ExpectedArrival)
+	}
+
+	if cfg.ArchiveFailurePolicy == config.ArchiveFailureMark {
+		proc.published = newPublishedTracker(cfg.InputFolder)
+	}
+
+	if cfg.ExactlyOnce {
+		proc.delivered = newDeliveryStore(cfg.InputFolder, cfg.ExactlyOnceRetention)
+	}
+
+	if cfg.LateDatePolicy == config.LateBusinessDateCorrections || cfg.LateDatePolicy == config.LateBusinessDateHold {
+		proc.dates = newBusinessDateTracker(cfg.InputFolder)
+	}
+
+	if cfg.ReportDestination != "" {
+		reporter, err := output.NewReporter(cfg.ReportDestination, cfg.QueueHost, cfg.QueuePort, cfg.QueueVHost, cfg.QueueUsername, cfg.QueuePassword)
+		if err != nil {
+			out.Close()
+			lock.release()
+			return nil, err
+		}
+		proc.reports = newReportTracker(reporter)
+	}
+
+	if cfg.ClaimFiles {
+		proc.claims = newClaimer(cfg.InputFolder, cfg.InstanceID, cfg.ClaimTTL)
+	}
+
+	if cfg.BatchWindow > 0 || cfg.BatchMaxFiles > 0 {
+		proc.batch = newBatcher(cfg.BatchWindow, cfg.BatchMaxFiles, &proc.files, proc.flushBatch)
+	}
+
+	if cfg.MemoryLimitMB > 0 && !cfg.ReverseConversion {
+		if features := proc.wholeFileFeatures(); len(features) > 0 {
+			log.Printf("Warning: %s need the whole file in memory; files over MEMORY_LIMIT_MB (%d) will be archived as failed",
+				strings.Join(features, ", "), cfg.MemoryLimitMB)
+		}
+	}
+
+	return proc, nil
+}
+
+// newParser creates the parser configured for a route
+func newParser(cfg *config.Config) *parser.Parser {
+	return parser.NewWithOptions(cfg.Delimiter, cfg.QuoteChar, cfg.HasHeader, parser.Options{
+		Encoding:          cfg.Encoding,
+		InvalidUTF8Policy: cfg.InvalidUTF8Policy,
+		Format:            cfg.InputFormat,
+		FixedWidthColumns: cfg.FixedWidthColumns,
+		RecordLayouts:     cfg.RecordLayouts,
+		Workers:           cfg.ParseWorkers,
+		ParallelMinSize:   cfg.ParallelParseMin,
+		RawField:          cfg.RawLineField,
+		ReassembleLines:   cfg.ReassembleLines,
+		RaggedRowPolicy:   cfg.RaggedRowPolicy,
+		ColumnSample:      cfg.ColumnSampleRows,
+	})
+}
+
+// outputOptions returns the optional output behaviour configured for a route
+func outputOptions(cfg *config.Config) output.Options {
+	return output.Options{
+		ASCIISafe:       cfg.ASCIISafeOutput,
+		Shape:           cfg.OutputShape,
+		InferTypes:      cfg.TypeInference,
+		ColumnTypes:     cfg.ConverterOptions().ColumnTypes,
+		Tenant:          cfg.Tenant,
+		Location:        cfg.Location(),
+		PipePath:        cfg.OutputPipe,
+		FileWrapper:     fileWrapper(cfg),
+		ContentType:     cfg.MessageContentType,
+		Schema:          cfg.MessageSchema,
+		KafkaMessageKey: cfg.KafkaMessageKey,
+		KafkaPartition:  cfg.KafkaPartition,
+
+		LegacyIdentifier: cfg.LegacyIdentifier,
+
+		VHost:                cfg.QueueVHost,
+		Credentials:          config.QueueCredentials,
+		LazyConnect:          cfg.QueueLazyConnect,
+		SharedConnection:     cfg.QueueSharedConnection,
+		Heartbeat:            cfg.QueueHeartbeat,
+		ConnectRetries:       cfg.QueueConnectRetries,
+		ConnectRetryDelay:    cfg.QueueConnectRetryDelay,
+		RabbitMQExchange:     cfg.RabbitMQExchange,
+		RabbitMQExchangeType: cfg.RabbitMQExchangeType,
+		RabbitMQRoutingKey:   cfg.RabbitMQRoutingKey,
+		RabbitMQBindingKey:   cfg.RabbitMQBindingKey,
+
+		ReceiptLog:        cfg.ReceiptLog,
+		PublisherConfirms: cfg.PublisherConfirms,
+		ConfirmTimeout:    cfg.PublishConfirmTimeout,
+
+		DownstreamAck:        cfg.DownstreamAck,
+		DownstreamAckQueue:   cfg.DownstreamAckQueue,
+		DownstreamAckTimeout: cfg.DownstreamAckTimeout,
+
+		CorrectionsQueue: cfg.CorrectionsQueue,
+
+		QueueShards: cfg.QueueShards,
+		ShardColumn: shardColumn(cfg),
+
+		EncryptionKey:   cfg.PayloadEncryptionKey,
+		EncryptionKeyID: cfg.PayloadEncryptionKeyID,
+
+		SigningAlgorithm: cfg.SigningAlgorithm,
+		SigningKey:       cfg.SigningKey,
+		SigningKeyID:     cfg.SigningKeyID,
+	}
+}
+
+// shardColumn returns the column hashed to choose a queue shard ("" = round-robin)
+func shardColumn(cfg *config.Config) string {
+	if cfg.ShardStrategy == config.ShardStrategyHash {
+		return cfg.ShardColumn
+	}
+	return ""
+}
+
+// fileWrapper returns the wrapper object output files are wrapped in (nil = bare JSON)
+func fileWrapper(cfg *config.Config) *output.FileWrapper {
+	if cfg.FileWrapper == nil {
+		return nil
+	}
+	return &output.FileWrapper{File: cfg.FileWrapper.File, GeneratedAt: cfg.FileWrapper.GeneratedAt, Records: cfg.FileWrapper.Records}
+}
+
+// buildTransforms assembles the transform pipeline from configuration, in a fixed order
+func buildTransforms(cfg *config.Config) (*transform.Pipeline, error) {
+	// Pipeline order: sample, dedup, enrich, schema, sort, mask, hash, sanitize, group
+	var transforms []transform.Transform
+
+	// Sampling runs first so a sample reflects the file as delivered
+	if cfg.SampleRows > 0 {
+		sampler, err := transform.NewSampler(cfg.SampleRows, cfg.SampleMode)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, sampler)
+	}
+
+	// Deduplication runs on the delivered rows, before enrichment adds columns the key
+	// could match on
+	if len(cfg.DedupKeys) > 0 {
+		dedup, err := transform.NewDeduplicator(cfg.DedupKeys, cfg.DedupKeep)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, dedup)
+	}
+
+	// Enrichment runs before value transforms so joins see original (unmasked, unhashed) keys
+	if len(cfg.Lookups) > 0 {
+		tables := make([]transform.LookupTable, 0, len(cfg.Lookups))
+		for _, l := range cfg.Lookups {
+			table := transform.LookupTable{Path: l.Path, Key: l.Key, LookupKey: l.LookupKey, Columns: l.Columns}
+			if l.Delimiter != "" {
+				table.Delimiter = rune(l.Delimiter[0])
+			}
+			tables = append(tables, table)
+		}
+		enricher, err := transform.NewEnricher(tables)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, enricher)
+	}
+
+	// The output schema runs after enrichment so joined columns can be declared, and before
+	// value transforms so defaults are sorted, masked and hashed like delivered values
+	if len(cfg.OutputSchema) > 0 {
+		fields := make([]transform.SchemaField, 0, len(cfg.OutputSchema))
+		for _, f := range cfg.OutputSchema {
+			fields = append(fields, transform.SchemaField{Name: f.Name, Default: f.Default})
+		}
+		schema, err := transform.NewOutputSchema(fields, cfg.ExtraColumns == config.ExtraColumnsFlag, cfg.StrictColumns)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, schema)
+	}
+
+	// Sorting runs before masking and hashing so it orders on original values
+	if len(cfg.SortBy) > 0 {
+		keys := make([]transform.SortKey, 0, len(cfg.SortBy))
+		for _, k := range cfg.SortBy {
+			descending, err := transform.ParseSortOrder(k.Order)
+			if err != nil {
+				return nil, fmt.Errorf("sort column '%s': %w", k.Column, err)
+			}
+			numeric, err := transform.ParseSortType(k.Type)
+			if err != nil {
+				return nil, fmt.Errorf("sort column '%s': %w", k.Column, err)
+			}
+			keys = append(keys, transform.SortKey{Column: k.Column, Descending: descending, Numeric: numeric})
+		}
+		sorter, err := transform.NewSorter(keys, cfg.SortMemoryRows)
+		if err != nil {
+			return nil, err
+		}
+		sorter.SetTempDir(workTempDir(cfg.WorkDir))
+		transforms = append(transforms, sorter)
+	}
+
+	// Masking and hashing run after sorting and before sanitization
+	if len(cfg.MaskRules) > 0 {
+		rules := make([]transform.MaskRule, 0, len(cfg.MaskRules))
+		for _, r := range cfg.MaskRules {
+			maskChar, err := transform.ParseMaskChar(r.MaskChar)
+			if err != nil {
+				return nil, fmt.Errorf("mask rule for column '%s': %w", r.Column, err)
+			}
+			rules = append(rules, transform.MaskRule{Column: r.Column, Mode: r.Mode, Keep: r.Keep, MaskChar: maskChar})
+		}
+		masker, err := transform.NewMasker(rules)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, masker)
+	}
+
+	if len(cfg.HashColumns) > 0 {
+		hasher, err := transform.NewColumnHasher(cfg.HashColumns, cfg.HashSalt)
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, hasher)
+	}
+
+	// Output sanitization runs after value transforms so it sees final values
+	if cfg.SanitizeFormulas {
+		transforms = append(transforms, transform.NewFormulaSanitizer(cfg.SanitizePrefix))
+	}
+
+	// Grouping changes the row structure, so it runs after all value-level transforms
+	if len(cfg.GroupBy) > 0 {
+		grouper, err := transform.NewGrouper(transform.GroupSpec{
+			By:            cfg.GroupBy,
+			ParentColumns: cfg.GroupParentCols,
+			ChildKey:      cfg.GroupChildKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		transforms = append(transforms, grouper)
+	}
+
+	return transform.NewPipeline(transforms...), nil
 }
 
 // SetEnvelopeContext configures message envelope metadata for multi-ingress mode (ADR-006)
-func (p *Processor) SetEnvelopeContext(routeName, ingestionContract string, includeEnvelope bool) {
+func (p *Processor) SetEnvelopeContext(routeName, ingestionContract, payloadFormat string) {
 	p.routeName = routeName
 	p.ingestionContract = ingestionContract
-	// If output is a queue handler, configure envelope context
-	if qh, ok := p.output.(*output.QueueHandler); ok {
-		qh.SetEnvelopeContext(routeName, ingestionContract, "", includeEnvelope) // sourceFilePath set per file
-	} else if bh, ok := p.output.(*output.BothHandler); ok {
-		// For BothHandler, configure the queue handler inside it
-		bh.SetEnvelopeContext(routeName, ingestionContract, "", includeEnvelope)
+	// Queue envelopes carry the full context; file handlers record the route in receipts
+	if rc, ok := p.output.(output.RouteContextSetter); ok {
+		rc.SetEnvelopeContext(routeName, ingestionContract, payloadFormat)
 	}
 }
 
 func (p *Processor) Start() error {
-	return p.monitor.Start(p.processFile)
+	if p.claims != nil {
+		p.claimsOnce.Do(func() { go p.claims.run() })
+	}
+	if p.arrivals != nil {
+		p.arrivalsOnce.Do(func() { go p.watchArrivals() })
+	}
+	return p.monitor.Start(p.scheduledProcessFile)
+}
+
+// TriggerNow forces the monitor to rescan the input folder now rather than at the
+// next poll
+func (p *Processor) TriggerNow() {
+	if p.monitor != nil {
+		p.monitor.TriggerNow()
+	}
+}
+
+// SetScheduler shares a processing budget with other route processors. Files from
+// higher-priority routes are processed first when routes compete for a slot.
+func (p *Processor) SetScheduler(scheduler *Scheduler, priority int) {
+	p.scheduler = scheduler
+	p.priority = priority
+}
+
+// SetContract enforces a registry schema: output that violates it is archived as failed,
+// and queue envelopes record the schema version the data was validated against
+func (p *Processor) SetContract(schema *contract.Schema) {
+	p.contract = schema
+	if cv, ok := p.output.(output.ContractVersionSetter); ok {
+		cv.SetContractVersion(schema.ResolvedVersion)
+	}
+}
+
+// scheduledProcessFile processes a file once there is enough disk space, the intake
+// throttle has budget and the shared scheduler grants a slot. While intake is paused,
+// the file is left in the input folder.
+func (p *Processor) scheduledProcessFile(filePath string) error {
+	if p.disk != nil || p.throttle != nil || p.scheduler != nil {
+		// Deliberate waits keep the monitor loop busy; they are not a stall
+		p.waiting.Add(1)
+		err := p.acquire(filePath)
+		p.waiting.Add(-1)
+		if err != nil {
+			return err
+		}
+		if p.scheduler != nil {
+			defer p.scheduler.Release()
+		}
+	}
+	// However long a file takes, the monitor loop is busy with it rather than stalled
+	p.processing.Add(1)
+	defer p.processing.Add(-1)
+	p.files.Lock()
+	defer p.files.Unlock()
+	return p.processFile(filePath)
+}
+
+// acquire waits for enough disk space, intake budget for filePath and a shared
+// scheduler slot
+func (p *Processor) acquire(filePath string) error {
+	if p.disk != nil {
+		if err := p.disk.wait(p.done); err != nil {
+			return err
+		}
+	}
+	if p.throttle != nil {
+		if err := p.throttle.wait(filePath, p.done); err != nil {
+			return err
+		}
+	}
+	if p.scheduler != nil {
+		p.scheduler.Acquire(p.priority)
+	}
+	return nil
 }
 
 func (p *Processor) Stop() {
+	close(p.done)
 	p.monitor.Stop()
+	if p.claims != nil {
+		p.claims.close()
+	}
+	if p.batch != nil {
+		p.batch.flushPending()
+	}
 	if err := p.output.Close(); err != nil {
 		log.Printf("Error closing output handler: %v", err)
 	}
+	if p.reports != nil {
+		if err := p.reports.reporter.Close(); err != nil {
+			log.Printf("Error closing report queue: %v", err)
+		}
+	}
+	if summary := p.ignored.summary(); summary != "" {
+		log.Printf("Ignored files by reason: %s", summary)
+	}
+	p.lock.release()
+}
+
+// ProcessFile processes a single file immediately, bypassing the monitor, disk space
+// guard and shared scheduler (used by the selftest subcommand)
+func (p *Processor) ProcessFile(filePath string) error {
+	p.files.Lock()
+	defer p.files.Unlock()
+	return p.processFile(filePath)
 }
 
-func (p *Processor) processFile(filePath string) error {
+func (p *Processor) processFile(filePath string) (err error) {
 	filename := filepath.Base(filePath)
-	log.Printf("Processing file: %s", filename)
+
+	// A panic fails this file only, never the monitor or other routes
+	defer func() {
+		if r := recover(); r != nil {
+			err = p.recoverFile(filePath, filename, r)
+		}
+	}()
+
+	// Checksum sidecars are processed with their data file
+	if p.checksumsEnabled() && isChecksumSidecar(filename) {
+		return p.processChecksumSidecar(filename)
+	}
+
+	// Batch manifests bypass the filters, while their members wait in the input folder
+	// until a manifest lists them
+	manifest := p.config.BatchManifests && isManifest(filename)
+	var reason, detail string
+	if !manifest {
+		reason, detail = p.config.IgnoreReason(filename)
+	}
+	// Filters run before the file is claimed, so a route that skips unmatched files
+	// never moves them
+	if reason != "" && p.config.UnmatchedPolicy == config.UnmatchedFileSkip {
+		p.skip(filename, reason, detail)
+		return nil
+	}
+	if p.config.BatchManifests && !manifest && reason == "" {
+		log.Printf("Holding %s until a batch manifest lists it", filename)
+		return nil
+	}
+	if p.config.ChecksumPolicy == config.ChecksumPolicyRequire && !manifest && reason == "" {
+		if sidecar, _ := p.checksumSidecar(filename); sidecar == "" {
+			log.Printf("Holding %s until its checksum file arrives", filename)
+			return nil
+		}
+	}
+
+	// Claim the file so that only one instance sharing the input folder processes it
+	if p.claims != nil {
+		claimed, err := p.claims.claim(filePath)
+		if errors.Is(err, errClaimLost) {
+			log.Printf("Skipping %s: %v", filename, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		filePath = claimed
+	}
+
+	// Take the file out of the producer's reach for the rest of processing
+	if p.work != nil {
+		working, err := p.work.take(filePath)
+		if errors.Is(err, errWorkGone) {
+			log.Printf("Skipping %s: %v", filename, err)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		filePath = working
+	}
+
+	p.emit(events.Event{Type: events.FileDetected, File: filename})
 
 	// Update source file path in queue handler for envelope metadata
-	if qh, ok := p.output.(*output.QueueHandler); ok {
-		qh.SetEnvelopeContext(p.routeName, p.ingestionContract, filePath, true)
-	} else if bh, ok := p.output.(*output.BothHandler); ok {
-		bh.SetEnvelopeContext(p.routeName, p.ingestionContract, filePath, true)
+	p.setEnvelopeSource(filePath)
+	p.setBusinessDate("")
+
+	if manifest {
+		return p.processManifest(filePath, filename)
 	}
 
-	// Check if file should be processed based on filters
-	if !p.config.ShouldProcessFile(filename) {
-		log.Printf("File does not match filters, ignoring: %s", filename)
-		return p.archiver.Archive(filePath, archiver.CategoryIgnored, "")
+	// Verify the file against its checksum sidecar before reading it
+	if err := p.verifyChecksum(filePath, filename); err != nil {
+		log.Printf("Checksum verification failed: %v", err)
+		return p.fail(filePath, filename, failure.Validation(err))
+	}
+
+	// Archive files that do not match the filters
+	if reason != "" {
+		return p.ignore(filePath, filename, reason, detail)
+	}
+
+	// Skip re-deliveries of a file that was already processed with identical content
+	var hash string
+	if p.config.SkipDuplicateFiles || p.published != nil || p.delivered != nil {
+		var err error
+		if hash, err = fileHash(filePath); err != nil {
+			log.Printf("Failed to hash file: %v", err)
+			return p.fail(filePath, filename, failure.Parse(err))
+		}
+		if p.published != nil && p.published.published(filename, hash) {
+			return p.archiveUnarchived(filePath, filename, hash)
+		}
+		if p.delivered != nil {
+			if record, ok := p.delivered.lookup(hash); ok {
+				if !record.Archived && record.File == filename {
+					return p.archiveUnarchived(filePath, filename, hash)
+				}
+				return p.ignore(filePath, filename, config.IgnoreReasonDuplicate, fmt.Sprintf("content sha256 %s already delivered from %s at %s", hash, record.File, record.DeliveredAt.Format(time.RFC3339)))
+			}
+		}
+		if p.config.SkipDuplicateFiles && p.ignored.isDuplicate(filename, hash) {
+			return p.ignore(filePath, filename, config.IgnoreReasonDuplicate, fmt.Sprintf("content sha256 %s already processed", hash))
+		}
+	}
+
+	// Any file passing the filters counts towards the expected arrival schedule
+	if p.arrivals != nil {
+		p.recordArrival(filename)
+	}
+
+	// Reverse routes turn JSON back into CSV
+	if p.config.ReverseConversion {
+		return p.processReverse(filePath, filename, hash)
 	}
 
 	// Validate file content
 	if err := p.parser.Validate(filePath); err != nil {
+		if errors.Is(err, parser.ErrNoDataRows) {
+			return p.handleEmptyFile(filePath, filename, err)
+		}
 		log.Printf("File validation failed: %v", err)
-		return p.archiver.Archive(filePath, archiver.CategoryFailed, err.Error())
+		return p.fail(filePath, filename, failure.Parse(err))
+	}
+
+	// Files too large to convert in memory are converted chunk by chunk through a spill file
+	if exceeds, estimate := p.exceedsMemoryLimit(filePath); exceeds {
+		return p.processSpilled(filePath, filename, hash, estimate)
+	}
+
+	result, err := p.convert(filePath, filename)
+	if errors.Is(err, parser.ErrNoDataRows) {
+		return p.handleEmptyFile(filePath, filename, err)
+	}
+	if err != nil {
+		return p.fail(filePath, filename, err)
+	}
+
+	// Files restating a completed business date are corrected or held
+	if held, err := p.applyLateDatePolicy(filePath, filename); held || err != nil {
+		if err != nil {
+			log.Printf("Late business date handling failed: %v", err)
+			return p.fail(filePath, filename, failure.Validation(err))
+		}
+		return nil
 	}
 
+	// Merge window batching: output and archiving happen when the batch is flushed
+	if p.batch != nil {
+		log.Printf("Queued %s for batched output", filename)
+		p.batch.add(batchEntry{filePath: filePath, filename: filename, result: result, hash: hash})
+		return nil
+	}
+
+	// Send output with ordered fields
+	if err := p.send(result, filename); err != nil {
+		log.Printf("Output failed: %v", err)
+		p.alertOutputFailed(err)
+		return p.fail(filePath, filename, failure.Publish(err))
+	}
+
+	return p.finishProcessed(filePath, filename, hash, len(result.Rows))
+}
+
+// convert parses a validated file and applies schema checks, transforms and the
+// ingestion contract, returning the result as it will be sent
+func (p *Processor) convert(filePath, filename string) (*parser.ParseResult, error) {
 	// Parse file (preserves CSV column order per ADR-003)
+	p.emit(events.Event{Type: events.ParseStarted, File: filename})
 	result, err := p.parser.ParseWithOrder(filePath)
 	if err != nil {
-		log.Printf("Parsing failed: %v", err)
-		return p.archiver.Archive(filePath, archiver.CategoryFailed, err.Error())
+		// Empty files are logged by the empty file policy
+		if !errors.Is(err, parser.ErrNoDataRows) {
+			log.Printf("Parsing failed: %v", err)
+		}
+		return nil, failure.Parse(err)
 	}
 
 	if len(result.Rows) == 0 {
 		log.Printf("No data parsed from file: %s", filename)
-		return p.archiver.Archive(filePath, archiver.CategoryFailed, "No data parsed")
+		return nil, failure.Parse(errors.New("No data parsed"))
 	}
 
-	log.Printf("Parsed %d rows from %s", len(result.Rows), filename)
+	if result.RaggedRows > 0 {
+		log.Printf("%d ragged row(s) in %s handled by ragged row policy %s", result.RaggedRows, filename, p.config.RaggedRowPolicy)
+	}
 
-	// Send output with ordered fields
-	if err := p.output.SendOrdered(result, filename); err != nil {
-		log.Printf("Output failed: %v", err)
-		return p.archiver.Archive(filePath, archiver.CategoryFailed, err.Error())
+	// Derive the business date from the source columns before transforms reshape them
+	if err := p.deriveBusinessDate(filePath, filename, result.Rows[0].Values); err != nil {
+		return nil, err
+	}
+
+	parsed := len(result.Rows)
+	p.emit(events.Event{Type: events.FileParsed, File: filename, Rows: parsed, Detail: "encoding: " + result.Encoding})
+
+	// Profile the source columns before schema checks and transforms change them
+	if p.config.ColumnStats {
+		stats := newFileStats(filename, p.routeName)
+		stats.add(result)
+		p.attachColumnStats(filename, stats)
+	}
+
+	// Compare columns with the route's established schema before transforms reshape them
+	if p.schema != nil {
+		if reason := p.checkSchema(filename, result.Headers); reason != "" {
+			p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: reason})
+			return nil, failure.Validation(errors.New(reason))
+		}
+	}
+
+	// Evaluate data quality expectations against the source columns
+	if p.config.Quality != nil {
+		run := p.config.Quality.Start()
+		run.Add(result)
+		if reason := p.checkQuality(filename, run); reason != "" {
+			p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: reason})
+			return nil, failure.Validation(errors.New(reason))
+		}
+	}
+
+	// Apply configured transforms before output
+	if err := p.transforms.Apply(result); err != nil {
+		log.Printf("Transform failed: %v", err)
+		p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: err.Error()})
+		return nil, failure.Validation(err)
+	}
+	if result.Duplicates > 0 {
+		p.emit(events.Event{Type: events.RowsDeduplicated, File: filename, Rows: result.Duplicates})
+	}
+
+	// Enforce the ingestion contract on the output as it will be sent
+	if p.contract != nil {
+		if err := p.contract.Validate(result); err != nil {
+			log.Printf("Contract validation failed: %v", err)
+			p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: err.Error()})
+			return nil, failure.Validation(err)
+		}
+	}
+
+	// Values must match their columns' declared types to be rendered
+	if err := p.checkColumnTypes(result.Rows); err != nil {
+		p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: err.Error()})
+		return nil, failure.Validation(err)
+	}
+
+	// Object output renders the file's single row; anything else would be truncated
+	if p.config.OutputShape == converter.ShapeObject && len(result.Rows) != 1 {
+		reason := fmt.Sprintf("OUTPUT_SHAPE=object requires exactly one data row, got %d", len(result.Rows))
+		p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: reason})
+		return nil, failure.Validation(errors.New(reason))
+	}
+
+	return result, nil
+}
+
+// checkColumnTypes returns an error for the first value that does not match its
+// column's declared type (COLUMN_TYPES)
+func (p *Processor) checkColumnTypes(rows []parser.OrderedMap) error {
+	if len(p.config.ColumnTypes) == 0 {
+		return nil
+	}
+	return converter.NewWithOptions(p.config.ConverterOptions()).CheckTypes(rows)
+}
+
+// finishProcessed archives a file whose rows were delivered to the output
+func (p *Processor) finishProcessed(filePath, filename, hash string, rows int) error {
+	p.emit(events.Event{Type: events.PublishConfirmed, File: filename, Rows: rows})
+	if p.dates != nil && p.businessDate != "" {
+		p.dates.processed(p.businessDate)
 	}
 
 	// Archive as processed
-	if err := p.archiver.Archive(filePath, archiver.CategoryProcessed, ""); err != nil {
-		log.Printf("Failed to archive file: %v", err)
+	if err := p.archivePublished(filePath, filename, hash); err != nil {
+		return err
+	}
+	p.ignored.markProcessed(filename, hash)
+	return nil
+}
+
+// fail archives a file as failed, recording the route and file in err's context for
+// the error sidecar
+func (p *Processor) fail(filePath, filename string, err error) error {
+	return p.archiver.ArchiveFailed(filePath, failure.WithContext(err, p.routeName, filename))
+}
+
+// setEnvelopeSource updates the source file path reported in queue message envelopes
+func (p *Processor) setEnvelopeSource(filePath string) {
+	if rc, ok := p.output.(output.RouteContextSetter); ok {
+		rc.SetSourceFile(filePath)
+	}
+}
+
+// deriveBusinessDate derives the file's business date, if the route has a source for
+// it, and carries it into envelopes, output folders and archive folders
+func (p *Processor) deriveBusinessDate(filePath, filename string, firstRow map[string]string) error {
+	if p.config.BusinessDate == nil {
+		return nil
+	}
+	var modTime time.Time
+	if info, err := os.Stat(filePath); err == nil {
+		modTime = info.ModTime()
+	}
+	date, err := p.config.BusinessDate.Extract(filename, firstRow, modTime, p.config.Location())
+	if err != nil {
+		log.Printf("Business date of %s could not be derived: %v", filename, err)
+		return failure.Validation(err)
+	}
+	log.Printf("Business date of %s: %s (from %s)", filename, date, p.config.BusinessDate.From())
+	p.setBusinessDate(date)
+	return nil
+}
+
+// setBusinessDate sets the business date of the current file ("" = none) on the
+// output and, when archiving by business date, the archiver. Any correction of the
+// previous file is cleared.
+func (p *Processor) setBusinessDate(date string) {
+	if p.config.BusinessDate == nil {
+		return
+	}
+	p.businessDate = date
+	if bd, ok := p.output.(output.BusinessDateSetter); ok {
+		bd.SetBusinessDate(date)
+	}
+	if cs, ok := p.output.(output.CorrectionSetter); ok {
+		cs.SetCorrection(false)
+	}
+	if p.config.ArchiveByDate {
+		p.archiver.SetDateFolder(date)
+	}
+}
+
+// alertOutputFailed raises a broker outage alert when publishing to a queue fails.
+// Errors other than publish errors (e.g. unsupported partitioning) raise no alert.
+func (p *Processor) alertOutputFailed(err error) {
+	if !errors.Is(err, failure.ErrPublish) {
+		return
+	}
+	if p.config.OutputType == "queue" || p.config.OutputType == "both" {
+		alert.Send(config.AlertEventBrokerOutage, p.routeName, fmt.Sprintf("publishing to %s %s failed: %v", p.config.QueueType, p.config.QueueName, err))
+	}
+}
+
+// send emits result as a single output, or one output per partition when PARTITION_BY is set
+func (p *Processor) send(result *parser.ParseResult, filename string) error {
+	if p.config.PartitionBy == "" {
+		return p.output.SendOrdered(result, filename)
+	}
+
+	sender, ok := p.output.(output.PartitionSender)
+	if !ok {
+		return fmt.Errorf("output type %s does not support partitioning", p.config.OutputType)
+	}
+
+	partitions, err := transform.SplitByColumn(result, p.config.PartitionBy)
+	if err != nil {
 		return err
 	}
+	log.Printf("Split %s into %d partitions by %s", filename, len(partitions), p.config.PartitionBy)
 
-	log.Printf("Successfully processed: %s", filename)
+	for _, part := range partitions {
+		if err := sender.SendPartition(part.Result, filename, part.Value); err != nil {
+			return fmt.Errorf("partition %s=%q: %w", p.config.PartitionBy, part.Value, err)
+		}
+	}
 	return nil
 }
+
+// handleEmptyFile applies the configured empty file policy to empty and header-only files
+func (p *Processor) handleEmptyFile(filePath, filename string, cause error) error {
+	switch p.config.EmptyFilePolicy {
+	case config.EmptyFilePolicyEmitEmptyArray:
+		log.Printf("No data rows in %s, emitting empty payload (EMPTY_FILE_POLICY=%s)", filename, p.config.EmptyFilePolicy)
+		if p.batch != nil {
+			p.batch.add(batchEntry{filePath: filePath, filename: filename, result: &parser.ParseResult{}})
+			return nil
+		}
+		if err := p.output.SendOrdered(&parser.ParseResult{}, filename); err != nil {
+			log.Printf("Output failed: %v", err)
+			p.alertOutputFailed(err)
+			return p.fail(filePath, filename, failure.Publish(err))
+		}
+		return p.archivePublished(filePath, filename, "")
+
+	case config.EmptyFilePolicyIgnore:
+		log.Printf("No data rows in %s, archiving as processed without output (EMPTY_FILE_POLICY=%s)", filename, p.config.EmptyFilePolicy)
+		return p.archiver.Archive(filePath, archiver.CategoryProcessed, "")
+
+	default:
+		log.Printf("Parsing failed: %v", cause)
+		return p.fail(filePath, filename, failure.Parse(cause))
+	}
+}
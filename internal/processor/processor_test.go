@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+	"csv2json/internal/transform"
+)
+
+// newTestProcessor creates a processor for cfg that sends to handler, without a monitor.
+// Files are parsed as comma-separated with a header and archived to cfg's archive
+// folders; unset folders default to a temporary directory.
+func newTestProcessor(t *testing.T, cfg *config.Config, handler output.Handler) *Processor {
+	t.Helper()
+	dir := t.TempDir()
+	processed, ignored, failed := cfg.ArchiveProcessed, cfg.ArchiveIgnored, cfg.ArchiveFailed
+	if processed == "" {
+		processed = filepath.Join(dir, "processed")
+	}
+	if ignored == "" {
+		ignored = filepath.Join(dir, "ignored")
+	}
+	if failed == "" {
+		failed = filepath.Join(dir, "failed")
+	}
+	return &Processor{
+		config:     cfg,
+		parser:     parser.New(',', '"', true),
+		transforms: transform.NewPipeline(),
+		archiver:   archiver.New(processed, ignored, failed, cfg.ArchiveTimestamp),
+		output:     handler,
+		ignored:    newIgnoreTracker(),
+	}
+}
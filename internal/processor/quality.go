@@ -0,0 +1,44 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"csv2json/internal/config"
+	"csv2json/internal/metrics"
+	"csv2json/internal/quality"
+)
+
+// metricQualityUnmet counts data quality expectations files did not meet
+const metricQualityUnmet = "csv2json_quality_unmet_total"
+
+func init() {
+	metrics.Register(metricQualityUnmet, metrics.Counter, "Data quality expectations not met by a file, by expectation")
+}
+
+// checkQuality applies the data quality policy to the results of a file's
+// expectations. It returns a failure reason when the file must be archived as
+// failed, or "" to continue processing.
+func (p *Processor) checkQuality(filename string, run *quality.Run) string {
+	unmet := quality.Unmet(run.Results())
+	if len(unmet) == 0 {
+		return ""
+	}
+
+	descriptions := make([]string, len(unmet))
+	for i, result := range unmet {
+		labels := p.routeLabels()
+		labels["expectation"] = result.Name()
+		metrics.Add(metricQualityUnmet, labels, 1)
+		descriptions[i] = result.String()
+	}
+	reason := fmt.Sprintf("data quality expectations not met in %s: %s", filename, strings.Join(descriptions, "; "))
+
+	if p.config.QualityPolicy == config.QualityPolicyWarn {
+		log.Printf("WARNING: %s", reason)
+		return ""
+	}
+	log.Printf("ERROR: %s", reason)
+	return reason
+}
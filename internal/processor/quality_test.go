@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+	"csv2json/internal/quality"
+)
+
+// TestProcessFileQuality validates warn processes files with unmet expectations and fail archives them as failed
+func TestProcessFileQuality(t *testing.T) {
+	suite, err := quality.NewSuite([]quality.Expectation{
+		{Column: "email", Type: quality.NotEmpty, MinPercent: 90},
+		{Column: "id", Type: quality.Unique},
+	})
+	if err != nil {
+		t.Fatalf("NewSuite failed: %v", err)
+	}
+
+	tests := []struct {
+		policy     string
+		content    string
+		wantFolder string
+	}{
+		{policy: config.QualityPolicyFail, content: "id,email\n1,a@example.com\n2,b@example.com\n", wantFolder: "processed"},
+		{policy: config.QualityPolicyFail, content: "id,email\n1,a@example.com\n1,\n", wantFolder: "failed"},
+		{policy: config.QualityPolicyWarn, content: "id,email\n1,a@example.com\n1,\n", wantFolder: "processed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy+" "+tt.wantFolder, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "output"), 0755); err != nil {
+				t.Fatalf("Failed to create output dir: %v", err)
+			}
+			cfg := &config.Config{Quality: suite, QualityPolicy: tt.policy, ArchiveProcessed: filepath.Join(dir, "processed"), ArchiveFailed: filepath.Join(dir, "failed")}
+			p := newTestProcessor(t, cfg, output.NewFileHandler(filepath.Join(dir, "output")))
+
+			file := filepath.Join(dir, "contacts.csv")
+			if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if err := p.processFile(file); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, tt.wantFolder, "contacts.csv")); err != nil {
+				t.Errorf("Expected file archived to %s: %v", tt.wantFolder, err)
+			}
+			if tt.wantFolder == "failed" {
+				content, err := os.ReadFile(filepath.Join(dir, "failed", "contacts.csv.error"))
+				if err != nil || !strings.Contains(string(content), "Category: validation\nSource: contacts.csv\n") {
+					t.Errorf("Expected the error log to record a validation error, got %q (%v)", content, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/output"
+)
+
+// fileReport accumulates the processing report of one file until it is archived
+type fileReport struct {
+	started   time.Time
+	parsed    int // Rows parsed from the file
+	deduped   int // Duplicate rows removed by deduplication
+	delivered int // Rows sent to the output
+}
+
+// reportTracker follows files from pickup to archiving and publishes one report per
+// file. Reports are keyed by filename, so batched files are reported when their
+// batch is flushed.
+type reportTracker struct {
+	reporter *output.Reporter
+	mu       sync.Mutex
+	files    map[string]*fileReport
+}
+
+func newReportTracker(reporter *output.Reporter) *reportTracker {
+	return &reportTracker{reporter: reporter, files: make(map[string]*fileReport)}
+}
+
+// file returns the report for filename, starting one if the file is new
+func (t *reportTracker) file(filename string) *fileReport {
+	report, ok := t.files[filename]
+	if !ok {
+		report = &fileReport{started: time.Now()}
+		t.files[filename] = report
+	}
+	return report
+}
+
+// started records that a file was picked up
+func (t *reportTracker) started(filename string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.files[filename] = &fileReport{started: time.Now()}
+}
+
+// parsed records the rows parsed from a file
+func (t *reportTracker) parsed(filename string, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.file(filename).parsed = rows
+}
+
+// deduplicated records the duplicate rows removed from a file
+func (t *reportTracker) deduplicated(filename string, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.file(filename).deduped = rows
+}
+
+// delivered records the rows sent to the output for a file
+func (t *reportTracker) delivered(filename string, rows int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.file(filename).delivered = rows
+}
+
+// finish removes and returns the report of an archived file
+func (t *reportTracker) finish(filename string) *fileReport {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	report := t.file(filename)
+	delete(t.files, filename)
+	return report
+}
+
+// sendReport publishes the report of a file that was just archived
+func (p *Processor) sendReport(filename string, category archiver.Category, reason string) {
+	file := p.reports.finish(filename)
+	report := output.Report{
+		Route:       p.routeLabels()["route"],
+		File:        filename,
+		Status:      string(category),
+		Rows:        file.delivered,
+		Duplicates:  file.deduped,
+		DurationMs:  time.Since(file.started).Milliseconds(),
+		Destination: p.outputDestination(),
+	}
+	if category == archiver.CategoryFailed {
+		// Files fail as a whole, so every parsed row is rejected
+		report.Rows, report.Rejects, report.Error = 0, file.parsed, reason
+	}
+	if err := p.reports.reporter.Send(report); err != nil {
+		log.Printf("Warning: failed to send processing report for %s: %v", filename, err)
+	}
+}
+
+// outputDestination describes where the route's data is sent
+func (p *Processor) outputDestination() string {
+	var destinations []string
+	if p.config.OutputType == "file" || p.config.OutputType == "both" {
+		destinations = append(destinations, p.config.OutputFolder)
+	}
+	if p.config.OutputType == "queue" || p.config.OutputType == "both" {
+		destinations = append(destinations, p.config.QueueType+"://"+p.config.QueueName)
+	}
+	if p.config.OutputType == "stdout" {
+		destinations = append(destinations, output.StdoutDestination)
+	}
+	if p.config.OutputType == "pipe" {
+		destinations = append(destinations, p.config.OutputPipe)
+	}
+	return strings.Join(destinations, ", ")
+}
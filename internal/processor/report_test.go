@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+	"csv2json/internal/transform"
+)
+
+// TestProcessFileReports validates one report is written per file with its rows, rejects, duplicates and status
+func TestProcessFileReports(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	reporter, err := output.NewReporter(filepath.Join(dir, "reports"), "", 0, "", "", "")
+	if err != nil {
+		t.Fatalf("NewReporter failed: %v", err)
+	}
+	cfg := &config.Config{OutputType: "file", OutputFolder: outputFolder, SchemaDriftPolicy: config.SchemaDriftPolicyFail}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+	p.schema = newSchemaTracker(dir)
+	p.reports = newReportTracker(reporter)
+	dedup, err := transform.NewDeduplicator([]string{"id"}, transform.DedupKeepFirst)
+	if err != nil {
+		t.Fatalf("NewDeduplicator failed: %v", err)
+	}
+	p.transforms = transform.NewPipeline(dedup)
+	p.subscribe()
+	p.archiver.OnArchived(p.archived)
+
+	files := map[string]string{"good.csv": "id,name\n1,widget\n2,gadget\n1,widget\n", "drift.csv": "id,name,extra\n3,gizmo,x\n"}
+	for _, name := range []string{"good.csv", "drift.csv"} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(files[name]), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := p.processFile(file); err != nil {
+			t.Fatalf("processFile failed: %v", err)
+		}
+	}
+
+	reports := map[string]output.Report{}
+	entries, err := os.ReadDir(filepath.Join(dir, "reports"))
+	if err != nil {
+		t.Fatalf("Failed to read reports: %v", err)
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".report.json") {
+			t.Errorf("Unexpected report file name: %s", entry.Name())
+		}
+		content, err := os.ReadFile(filepath.Join(dir, "reports", entry.Name()))
+		if err != nil {
+			t.Fatalf("Failed to read report: %v", err)
+		}
+		var report output.Report
+		if err := json.Unmarshal(content, &report); err != nil {
+			t.Fatalf("Invalid report JSON %s: %v", content, err)
+		}
+		reports[report.File] = report
+	}
+
+	tests := []struct {
+		file       string
+		status     string
+		rows       int
+		rejects    int
+		duplicates int
+	}{
+		{"good.csv", "processed", 2, 0, 1},
+		{"drift.csv", "failed", 0, 1, 0},
+	}
+	for _, tt := range tests {
+		report, ok := reports[tt.file]
+		if !ok {
+			t.Errorf("Expected a report for %s, got %v", tt.file, reports)
+			continue
+		}
+		if report.Status != tt.status || report.Rows != tt.rows || report.Rejects != tt.rejects || report.Duplicates != tt.duplicates {
+			t.Errorf("%s: expected %s with %d rows, %d rejects and %d duplicates, got %+v", tt.file, tt.status, tt.rows, tt.rejects, tt.duplicates, report)
+		}
+		if report.Destination != outputFolder {
+			t.Errorf("%s: expected destination %s, got %s", tt.file, outputFolder, report.Destination)
+		}
+	}
+	if reports["drift.csv"].Error == "" {
+		t.Error("Expected the failed file's report to include the error")
+	}
+	if len(p.reports.files) != 0 {
+		t.Errorf("Expected finished reports to be released, %d remain", len(p.reports.files))
+	}
+}
@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+	"csv2json/internal/events"
+	"csv2json/internal/failure"
+)
+
+// processReverse flattens a JSON array of objects into a CSV file in the output
+// folder (reverse routes). Transforms apply to the flattened rows as usual.
+func (p *Processor) processReverse(filePath, filename, hash string) error {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Printf("Failed to open file: %v", err)
+		return p.fail(filePath, filename, failure.Parse(err))
+	}
+	result, err := converter.FromJSON(file)
+	file.Close()
+	if err != nil {
+		log.Printf("JSON parsing failed: %v", err)
+		return p.fail(filePath, filename, failure.Parse(err))
+	}
+
+	if len(result.Rows) == 0 {
+		switch p.config.EmptyFilePolicy {
+		case config.EmptyFilePolicyIgnore:
+			log.Printf("No rows in %s, archiving as processed without output (EMPTY_FILE_POLICY=%s)", filename, p.config.EmptyFilePolicy)
+			return p.archiver.Archive(filePath, archiver.CategoryProcessed, "")
+		case config.EmptyFilePolicyFail:
+			log.Printf("No rows in %s", filename)
+			return p.archiver.Archive(filePath, archiver.CategoryFailed, "No data parsed")
+		}
+	}
+
+	log.Printf("Parsed %d JSON objects from %s", len(result.Rows), filename)
+
+	if err := p.transforms.Apply(result); err != nil {
+		log.Printf("Transform failed: %v", err)
+		return p.fail(filePath, filename, failure.Validation(err))
+	}
+
+	var buf bytes.Buffer
+	if err := converter.WriteCSV(&buf, result, p.config.Delimiter); err != nil {
+		log.Printf("CSV rendering failed: %v", err)
+		return p.fail(filePath, filename, failure.Publish(err))
+	}
+
+	outputPath := filepath.Join(p.config.OutputFolder, converter.GetCSVFilename(filename))
+	if err := writeFile(outputPath, buf.Bytes()); err != nil {
+		log.Printf("Output failed: %v", err)
+		return p.fail(filePath, filename, failure.Publish(err))
+	}
+	log.Printf("Wrote %d rows to %s", len(result.Rows), outputPath)
+	p.emit(events.Event{Type: events.PublishConfirmed, File: filename, Rows: len(result.Rows)})
+
+	if err := p.archivePublished(filePath, filename, hash); err != nil {
+		return err
+	}
+	p.ignored.markProcessed(filename, hash)
+	return nil
+}
+
+// writeFile writes data to path, creating its folder if needed
+func writeFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+)
+
+// TestProcessFileReverse validates reverse routes write CSV and archive the JSON input
+func TestProcessFileReverse(t *testing.T) {
+	dir := t.TempDir()
+	outputDir := filepath.Join(dir, "output")
+	processed := filepath.Join(dir, "processed")
+	failed := filepath.Join(dir, "failed")
+
+	cfg := &config.Config{
+		ReverseConversion: true,
+		OutputFolder:      outputDir,
+		Delimiter:         ';',
+		EmptyFilePolicy:   config.EmptyFilePolicyFail,
+		ArchiveProcessed:  processed,
+		ArchiveFailed:     failed,
+	}
+	p := newTestProcessor(t, cfg, nil)
+
+	tests := []struct {
+		name       string
+		filename   string
+		content    string
+		wantCSV    string // "" = no output expected
+		wantFolder string
+	}{
+		{"objects", "a.json", `[{"id":"1","name":"widget"},{"id":"2","qty":3}]`, "id;name;qty\n1;widget;\n2;;3\n", processed},
+		{"empty array", "b.json", `[]`, "", failed},
+		{"not json", "c.json", `id,name`, "", failed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := filepath.Join(dir, tt.filename)
+			if err := os.WriteFile(file, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+
+			if err := p.processFile(file); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(tt.wantFolder, tt.filename)); err != nil {
+				t.Errorf("Expected %s archived to %s: %v", tt.filename, tt.wantFolder, err)
+			}
+
+			csvPath := filepath.Join(outputDir, converter.GetCSVFilename(tt.filename))
+			content, err := os.ReadFile(csvPath)
+			if tt.wantCSV == "" {
+				if err == nil {
+					t.Errorf("Expected no CSV output, got %q", content)
+				}
+				return
+			}
+			if err != nil || string(content) != tt.wantCSV {
+				t.Errorf("Expected CSV %q, got %q (%v)", tt.wantCSV, content, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package processor
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// Scheduler shares a fixed file-processing budget between route processors.
+// When routes are waiting for a slot, the highest-priority route is served
+// first; routes of equal priority are served in arrival order.
+type Scheduler struct {
+	mu      sync.Mutex
+	slots   int
+	inUse   int
+	seq     uint64
+	waiting waitQueue
+}
+
+// NewScheduler creates a scheduler allowing at most slots files to be processed concurrently
+func NewScheduler(slots int) *Scheduler {
+	if slots < 1 {
+		slots = 1
+	}
+	return &Scheduler{slots: slots}
+}
+
+// Acquire blocks until a processing slot is granted to a caller of the given priority
+func (s *Scheduler) Acquire(priority int) {
+	s.mu.Lock()
+	if s.inUse < s.slots && len(s.waiting) == 0 {
+		s.inUse++
+		s.mu.Unlock()
+		return
+	}
+	s.seq++
+	w := &waiter{priority: priority, seq: s.seq, ready: make(chan struct{})}
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	<-w.ready
+}
+
+// Release returns a slot, handing it directly to the highest-priority waiter if any
+func (s *Scheduler) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) > 0 {
+		w := heap.Pop(&s.waiting).(*waiter)
+		close(w.ready)
+		return
+	}
+	if s.inUse > 0 {
+		s.inUse--
+	}
+}
+
+// waiter is a caller blocked in Acquire
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+}
+
+// waitQueue is a max-heap on priority, FIFO within a priority
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+func (q waitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *waitQueue) Push(x any)   { *q = append(*q, x.(*waiter)) }
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return w
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSchedulerPriorityOrder validates waiting routes are served by priority, then arrival order
+func TestSchedulerPriorityOrder(t *testing.T) {
+	s := NewScheduler(1)
+	s.Acquire(0) // Occupy the only slot so every other caller waits
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	enqueue := func(name string, priority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Acquire(priority)
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			s.Release()
+		}()
+		// Wait until the caller is queued so arrival order is deterministic
+		for {
+			s.mu.Lock()
+			n := len(s.waiting)
+			s.mu.Unlock()
+			if n == queued {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	enqueue("bulk-1", 0, 1)     // First to arrive
+	enqueue("trading-1", 10, 2) // Higher priority
+	enqueue("bulk-2", 0, 3)
+	enqueue("trading-2", 10, 4)
+
+	s.Release()
+	wg.Wait()
+
+	expected := []string{"trading-1", "trading-2", "bulk-1", "bulk-2"}
+	for i, name := range expected {
+		if order[i] != name {
+			t.Fatalf("Expected order %v, got %v", expected, order)
+		}
+	}
+}
+
+// TestSchedulerBudget validates no more than the configured number of slots are held at once
+func TestSchedulerBudget(t *testing.T) {
+	s := NewScheduler(2)
+	var mu sync.Mutex
+	active, peak := 0, 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(priority int) {
+			defer wg.Done()
+			s.Acquire(priority)
+			mu.Lock()
+			active++
+			if active > peak {
+				peak = active
+			}
+			mu.Unlock()
+			time.Sleep(2 * time.Millisecond)
+			mu.Lock()
+			active--
+			mu.Unlock()
+			s.Release()
+		}(i % 3)
+	}
+	wg.Wait()
+
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent holders, got %d", peak)
+	}
+	if s.inUse != 0 {
+		t.Errorf("Expected all slots released, got %d in use", s.inUse)
+	}
+}
@@ -0,0 +1,140 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"csv2json/internal/config"
+	"csv2json/internal/metrics"
+)
+
+const metricSchemaDrift = "csv2json_schema_drift_total"
+
+func init() {
+	metrics.Register(metricSchemaDrift, metrics.Counter, "Files whose columns differ from the route's established schema")
+}
+
+// stateFolder is the input subfolder holding per-route state shared by instances
+// watching the folder. Monitors skip subfolders, so state is never picked up as input.
+const stateFolder = ".state"
+
+// schemaState is the established schema persisted in .state/schema.json
+type schemaState struct {
+	Columns         []string  `json:"columns"`
+	EstablishedFrom string    `json:"establishedFrom"`
+	EstablishedAt   time.Time `json:"establishedAt"`
+}
+
+// schemaTracker remembers the column set of the first file a route parsed and reports
+// how later files differ from it. The schema survives restarts; deleting the state
+// file re-establishes it from the next file.
+type schemaTracker struct {
+	mu    sync.Mutex
+	path  string
+	state *schemaState // nil until established
+}
+
+func newSchemaTracker(inputFolder string) *schemaTracker {
+	t := &schemaTracker{path: filepath.Join(inputFolder, stateFolder, "schema.json")}
+	content, err := os.ReadFile(t.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Warning: failed to read established schema %s: %v", t.path, err)
+		}
+		return t
+	}
+	var state schemaState
+	if err := json.Unmarshal(content, &state); err != nil || len(state.Columns) == 0 {
+		log.Printf("Warning: ignoring invalid established schema %s; the next file establishes a new one", t.path)
+		return t
+	}
+	t.state = &state
+	return t
+}
+
+// check compares headers with the established schema, establishing it from this file if
+// there is none yet. It returns the columns added and removed relative to the schema and
+// the file the schema was established from.
+func (t *schemaTracker) check(filename string, headers []string) (added, removed []string, establishedFrom string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	columns := append([]string(nil), headers...)
+	sort.Strings(columns)
+
+	if t.state == nil {
+		t.state = &schemaState{Columns: columns, EstablishedFrom: filename, EstablishedAt: time.Now().UTC()}
+		if err := t.save(); err != nil {
+			log.Printf("Warning: failed to persist established schema: %v", err)
+		}
+		log.Printf("Established schema from %s: %d column(s)", filename, len(columns))
+		return nil, nil, filename
+	}
+
+	known := make(map[string]bool, len(t.state.Columns))
+	for _, column := range t.state.Columns {
+		known[column] = true
+	}
+	seen := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		seen[column] = true
+		if !known[column] {
+			added = append(added, column)
+		}
+	}
+	for _, column := range t.state.Columns {
+		if !seen[column] {
+			removed = append(removed, column)
+		}
+	}
+	return added, removed, t.state.EstablishedFrom
+}
+
+// save writes the state file atomically so a crash never leaves a truncated schema
+func (t *schemaTracker) save() error {
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(t.state, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, t.path)
+}
+
+// checkSchema applies the schema drift policy to a parsed file. It returns a failure
+// reason when the file must be archived as failed, or "" to continue processing.
+func (p *Processor) checkSchema(filename string, headers []string) string {
+	added, removed, establishedFrom := p.schema.check(filename, headers)
+	if len(added) == 0 && len(removed) == 0 {
+		return ""
+	}
+
+	metrics.Add(metricSchemaDrift, p.routeLabels(), 1)
+	var changes []string
+	if len(added) > 0 {
+		changes = append(changes, "added "+strings.Join(added, ", "))
+	}
+	if len(removed) > 0 {
+		changes = append(changes, "removed "+strings.Join(removed, ", "))
+	}
+	drift := fmt.Sprintf("schema drift in %s: %s (established from %s)", filename, strings.Join(changes, "; "), establishedFrom)
+
+	if p.config.SchemaDriftPolicy == config.SchemaDriftPolicyFail {
+		log.Printf("ERROR: %s", drift)
+		return drift
+	}
+	log.Printf("WARNING: %s", drift)
+	return ""
+}
@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestSchemaTracker validates the first file establishes a persisted schema and later files report drift
+func TestSchemaTracker(t *testing.T) {
+	input := t.TempDir()
+
+	tracker := newSchemaTracker(input)
+	if added, removed, _ := tracker.check("a.csv", []string{"id", "name", "qty"}); added != nil || removed != nil {
+		t.Fatalf("Expected the first file to establish the schema, got added %v removed %v", added, removed)
+	}
+
+	// A restarted tracker keeps the schema; column order does not matter
+	tracker = newSchemaTracker(input)
+	if added, removed, _ := tracker.check("b.csv", []string{"qty", "id", "name"}); added != nil || removed != nil {
+		t.Errorf("Expected reordered columns to match, got added %v removed %v", added, removed)
+	}
+	added, removed, from := tracker.check("c.csv", []string{"id", "name", "price"})
+	if strings.Join(added, ",") != "price" || strings.Join(removed, ",") != "qty" || from != "a.csv" {
+		t.Errorf("Expected added [price], removed [qty] from a.csv, got %v %v %s", added, removed, from)
+	}
+}
+
+// TestProcessFileSchemaDrift validates warn processes drifted files and fail archives them as failed
+func TestProcessFileSchemaDrift(t *testing.T) {
+	tests := []struct {
+		policy     string
+		wantFolder string
+	}{
+		{config.SchemaDriftPolicyWarn, "processed"},
+		{config.SchemaDriftPolicyFail, "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(dir, "output"), 0755); err != nil {
+				t.Fatalf("Failed to create output dir: %v", err)
+			}
+			cfg := &config.Config{SchemaDriftPolicy: tt.policy, ArchiveProcessed: filepath.Join(dir, "processed"), ArchiveFailed: filepath.Join(dir, "failed")}
+			p := newTestProcessor(t, cfg, output.NewFileHandler(filepath.Join(dir, "output")))
+			p.schema = newSchemaTracker(dir)
+
+			files := map[string]string{"first.csv": "id,name\n1,widget\n", "second.csv": "id,name,extra\n2,gadget,x\n"}
+			for _, name := range []string{"first.csv", "second.csv"} {
+				file := filepath.Join(dir, name)
+				if err := os.WriteFile(file, []byte(files[name]), 0644); err != nil {
+					t.Fatalf("Failed to create test file: %v", err)
+				}
+				if err := p.processFile(file); err != nil {
+					t.Fatalf("processFile failed: %v", err)
+				}
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "processed", "first.csv")); err != nil {
+				t.Errorf("Expected first file processed: %v", err)
+			}
+			if _, err := os.Stat(filepath.Join(dir, tt.wantFolder, "second.csv")); err != nil {
+				t.Errorf("Expected drifted file archived to %s: %v", tt.wantFolder, err)
+			}
+		})
+	}
+}
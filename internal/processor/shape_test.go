@@ -0,0 +1,59 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+	"csv2json/internal/output"
+)
+
+// TestObjectShape validates single-row files are written as one JSON object, and that
+// files with any other row count fail instead of being truncated
+func TestObjectShape(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	failedFolder := filepath.Join(dir, "failed")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	handler, err := output.CreateHandlerWithOptions("file", outputFolder, "", "", 0, "", "", "", false, output.Options{Shape: converter.ShapeObject})
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	cfg := &config.Config{InputFolder: dir, OutputType: "file", OutputShape: converter.ShapeObject, ArchiveFailed: failedFolder}
+	p := newTestProcessor(t, cfg, handler)
+
+	for name, content := range map[string]string{
+		"status.csv": "id,state\n1,ready\n",
+		"multi.csv":  "id,state\n1,ready\n2,done\n",
+	} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := p.processFile(file); err != nil {
+			t.Fatalf("processFile(%s) failed: %v", name, err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputFolder, "status.json"))
+	if err != nil {
+		t.Fatalf("Expected output for the single-row file: %v", err)
+	}
+	var object map[string]string
+	if err := json.Unmarshal(content, &object); err != nil || object["state"] != "ready" {
+		t.Errorf("Expected a single object, got %s (%v)", content, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(outputFolder, "multi.json")); !os.IsNotExist(err) {
+		t.Error("Expected no output for the multi-row file")
+	}
+	entries, err := os.ReadDir(failedFolder)
+	if err != nil || len(entries) == 0 {
+		t.Errorf("Expected the multi-row file to be archived as failed, got %v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,258 @@
+package processor
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/converter"
+	"csv2json/internal/events"
+	"csv2json/internal/failure"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+	"csv2json/internal/quality"
+	"csv2json/internal/transform"
+)
+
+// spillChunkRows is the number of rows parsed, transformed and rendered at a time
+// when a file is converted through a spill file
+const spillChunkRows = 10000
+
+// payloadExpansion estimates the memory a file takes once parsed into rows and
+// rendered as JSON, as a multiple of its size on disk
+const payloadExpansion = 8
+
+// wholeFileTransforms need every row of a file at once and cannot run chunk by chunk.
+// Sorting can: spilled files are sorted externally.
+var wholeFileTransforms = map[string]bool{"sample": true, "dedup": true, "groupBy": true}
+
+// exceedsMemoryLimit reports whether a file's estimated parsed payload exceeds
+// MEMORY_LIMIT_MB, returning the estimate in bytes
+func (p *Processor) exceedsMemoryLimit(filePath string) (bool, int64) {
+	if p.config.MemoryLimitMB <= 0 {
+		return false, 0
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return false, 0 // Parsing reports the error
+	}
+	estimate := info.Size() * payloadExpansion
+	return estimate > p.memoryLimit(), estimate
+}
+
+// memoryLimit returns MEMORY_LIMIT_MB in bytes
+func (p *Processor) memoryLimit() int64 {
+	return int64(p.config.MemoryLimitMB) << 20
+}
+
+// wholeFileFeatures lists the configured features that prevent chunked conversion
+func (p *Processor) wholeFileFeatures() []string {
+	var features []string
+	for _, name := range p.transforms.Names() {
+		if wholeFileTransforms[name] {
+			features = append(features, "transform "+name)
+		}
+	}
+	if p.config.PartitionBy != "" {
+		features = append(features, "PARTITION_BY")
+	}
+	if p.batch != nil {
+		features = append(features, "batching")
+	}
+	if p.config.OutputShape == converter.ShapeObject {
+		features = append(features, "OUTPUT_SHAPE=object")
+	}
+	return features
+}
+
+// processSpilled converts a file too large to hold in memory chunk by chunk into a
+// temporary spill file, then sends the spilled output. Only one chunk of parsed
+// rows, or one run of an external sort, is in memory at a time; queue and stream
+// outputs still hold the rendered payload.
+func (p *Processor) processSpilled(filePath, filename, hash string, estimate int64) error {
+	log.Printf("Estimated payload of %s (%d MB) exceeds MEMORY_LIMIT_MB (%d), converting through a spill file",
+		filename, estimate>>20, p.config.MemoryLimitMB)
+
+	if features := p.wholeFileFeatures(); len(features) > 0 {
+		reason := fmt.Sprintf("estimated payload %d MB exceeds MEMORY_LIMIT_MB (%d) and %s need the whole file in memory",
+			estimate>>20, p.config.MemoryLimitMB, strings.Join(features, ", "))
+		log.Printf("ERROR: %s: %s", filename, reason)
+		return p.archiver.Archive(filePath, archiver.CategoryFailed, reason)
+	}
+
+	sender, ok := p.output.(output.SpillSender)
+	if !ok {
+		return p.archiver.Archive(filePath, archiver.CategoryFailed, fmt.Sprintf("output type %s does not support spilled output", p.config.OutputType))
+	}
+
+	p.emit(events.Event{Type: events.ParseStarted, File: filename})
+	spill, parsed, encoding, err := p.spill(filePath, filename)
+	if spill != nil {
+		defer os.Remove(spill.Path)
+	}
+	if errors.Is(err, parser.ErrNoDataRows) {
+		return p.handleEmptyFile(filePath, filename, err)
+	}
+	if err != nil {
+		log.Printf("Spilled conversion failed: %v", err)
+		if errors.Is(err, failure.ErrValidation) {
+			p.emit(events.Event{Type: events.RowsRejected, File: filename, Rows: parsed, Reason: err.Error()})
+		}
+		return p.fail(filePath, filename, failure.Parse(err))
+	}
+
+	p.emit(events.Event{Type: events.FileParsed, File: filename, Rows: parsed, Detail: fmt.Sprintf("encoding: %s, %d MB spill file", encoding, spill.Bytes>>20)})
+
+	// Files restating a completed business date are corrected or held
+	if held, err := p.applyLateDatePolicy(filePath, filename); held || err != nil {
+		if err != nil {
+			log.Printf("Late business date handling failed: %v", err)
+			return p.fail(filePath, filename, failure.Validation(err))
+		}
+		return nil
+	}
+
+	// Queue messages and stream records are written whole, so the rendered payload itself must fit
+	if p.config.OutputType != "file" && spill.Bytes > p.memoryLimit() {
+		reason := fmt.Sprintf("rendered payload %d MB exceeds MEMORY_LIMIT_MB (%d) and %s output is written whole",
+			spill.Bytes>>20, p.config.MemoryLimitMB, p.config.OutputType)
+		log.Printf("ERROR: %s: %s", filename, reason)
+		return p.archiver.Archive(filePath, archiver.CategoryFailed, reason)
+	}
+
+	if err := sender.SendSpill(spill, filename); err != nil {
+		log.Printf("Output failed: %v", err)
+		p.alertOutputFailed(err)
+		return p.fail(filePath, filename, failure.Publish(err))
+	}
+
+	return p.finishProcessed(filePath, filename, hash, spill.Rows)
+}
+
+// spill parses, transforms and renders a file chunk by chunk into a temporary file,
+// returning the spill, the number of rows parsed and the detected source encoding
+func (p *Processor) spill(filePath, filename string) (*output.Spill, int, string, error) {
+	f, err := os.CreateTemp(workTempDir(p.config.WorkDir), "csv2json-spill-*.json")
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to create spill file: %w", err)
+	}
+	defer f.Close()
+
+	spill := &output.Spill{Path: f.Name()}
+	w := bufio.NewWriter(f)
+	array := converter.NewWithOptions(p.config.ConverterOptions()).NewArrayWriter(w)
+
+	var stats *fileStats
+	if p.config.ColumnStats {
+		stats = newFileStats(filename, p.routeName)
+		defer p.attachColumnStats(filename, stats)
+	}
+
+	var run *quality.Run
+	if p.config.Quality != nil {
+		run = p.config.Quality.Start()
+	}
+
+	// Transforms after a sort run on the merged rows of the external sort
+	transforms, sortTransform, sorted := p.transforms.Split("sort")
+	var external *transform.ExternalSort
+	if sortTransform != nil {
+		external = sortTransform.(*transform.Sorter).NewExternalSort()
+		defer external.Close()
+	}
+
+	written := 0
+	var headers []string
+	write := func(chunk *parser.ParseResult) error {
+		first := written + 1
+		written += len(chunk.Rows)
+		if err := sorted.Apply(chunk); err != nil {
+			return failure.Validation(err)
+		}
+		if p.contract != nil {
+			if err := p.contract.Validate(chunk); err != nil {
+				return failure.Validation(fmt.Errorf("rows %d-%d: %w", first, written, err))
+			}
+		}
+		if err := p.checkColumnTypes(chunk.Rows); err != nil {
+			return failure.Validation(fmt.Errorf("rows %d-%d: %w", first, written, err))
+		}
+		if spill.FirstRow == nil && len(chunk.Rows) > 0 {
+			spill.FirstRow = chunk.Rows[0].Values
+		}
+		return array.Write(chunk.Rows)
+	}
+
+	parsed, ragged := 0, 0
+	var encoding string
+	err = p.parser.ParseChunks(filePath, spillChunkRows, func(chunk *parser.ParseResult) error {
+		first := parsed + 1
+		parsed += len(chunk.Rows)
+		ragged += chunk.RaggedRows
+		if stats != nil {
+			stats.add(chunk)
+		}
+		if run != nil {
+			run.Add(chunk)
+		}
+
+		// The business date is derived from the first data row
+		if first == 1 && len(chunk.Rows) > 0 {
+			if err := p.deriveBusinessDate(filePath, filename, chunk.Rows[0].Values); err != nil {
+				return err
+			}
+		}
+		if first == 1 {
+			encoding = chunk.Encoding
+			// Compare columns with the route's established schema once, before transforms reshape them
+			if p.schema != nil {
+				if reason := p.checkSchema(filename, chunk.Headers); reason != "" {
+					return failure.Validation(errors.New(reason))
+				}
+			}
+		}
+
+		if err := transforms.Apply(chunk); err != nil {
+			return failure.Validation(err)
+		}
+		if external != nil {
+			headers = chunk.Headers
+			if err := external.Add(chunk); err != nil {
+				return failure.Validation(fmt.Errorf("transform sort failed: %w", err))
+			}
+			return nil
+		}
+		return write(chunk)
+	})
+	if err == nil && external != nil {
+		err = external.Merge(spillChunkRows, func(rows []parser.OrderedMap) error {
+			return write(&parser.ParseResult{Headers: headers, Rows: rows})
+		})
+	}
+	if err != nil {
+		return spill, parsed, encoding, err
+	}
+	if ragged > 0 {
+		log.Printf("%d ragged row(s) in %s handled by ragged row policy %s", ragged, filename, p.config.RaggedRowPolicy)
+	}
+
+	// Expectations are evaluated once every chunk was seen
+	if run != nil {
+		if reason := p.checkQuality(filename, run); reason != "" {
+			return spill, parsed, encoding, failure.Validation(errors.New(reason))
+		}
+	}
+
+	if err := array.Close(); err != nil {
+		return spill, parsed, encoding, fmt.Errorf("failed to write spill file: %w", err)
+	}
+	if err := w.Flush(); err != nil {
+		return spill, parsed, encoding, fmt.Errorf("failed to write spill file: %w", err)
+	}
+	spill.Rows, spill.Bytes = array.Rows(), array.Bytes()
+	return spill, parsed, encoding, nil
+}
@@ -0,0 +1,256 @@
+package processor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+	"csv2json/internal/output"
+	"csv2json/internal/parser"
+	"csv2json/internal/transform"
+)
+
+// TestProcessFileSpilled validates files over MEMORY_LIMIT_MB are converted through a spill
+// file with the same output as in memory, sorted externally, unless a transform needs
+// the whole file
+func TestProcessFileSpilled(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("id,name,city\n")
+	for i := 0; i < 2*spillChunkRows+17; i++ {
+		fmt.Fprintf(&content, "%d,name-%d,Zürich\n", i, i)
+	}
+
+	// Runs smaller than a chunk, so the sort spills several runs
+	sorter, err := transform.NewSorter([]transform.SortKey{{Column: "id", Descending: true, Numeric: true}}, spillChunkRows/3)
+	if err != nil {
+		t.Fatalf("NewSorter failed: %v", err)
+	}
+	dedup, err := transform.NewDeduplicator([]string{"id"}, transform.DedupKeepFirst)
+	if err != nil {
+		t.Fatalf("NewDeduplicator failed: %v", err)
+	}
+	tests := []struct {
+		name       string
+		transforms *transform.Pipeline
+		wantFolder string
+	}{
+		{"chunked", transform.NewPipeline(), "processed"},
+		{"sorted", transform.NewPipeline(sorter, transform.NewFormulaSanitizer("'")), "processed"},
+		{"whole-file transform", transform.NewPipeline(dedup), "failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outputFolder := filepath.Join(dir, "output")
+			if err := os.MkdirAll(outputFolder, 0755); err != nil {
+				t.Fatalf("Failed to create output dir: %v", err)
+			}
+			cfg := &config.Config{OutputType: "file", MemoryLimitMB: 1, ArchiveProcessed: filepath.Join(dir, "processed"), ArchiveFailed: filepath.Join(dir, "failed")}
+			p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+			p.transforms = tt.transforms
+
+			file := filepath.Join(dir, "large.csv")
+			if err := os.WriteFile(file, []byte(content.String()), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if exceeds, _ := p.exceedsMemoryLimit(file); !exceeds {
+				t.Fatal("Expected the test file to exceed the memory limit")
+			}
+			if err := p.processFile(file); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+
+			archived := filepath.Join(dir, tt.wantFolder, "large.csv")
+			if _, err := os.Stat(archived); err != nil {
+				t.Fatalf("Expected file archived to %s: %v", tt.wantFolder, err)
+			}
+			if tt.wantFolder != "processed" {
+				return
+			}
+
+			result, err := p.parser.ParseWithOrder(archived)
+			if err != nil {
+				t.Fatalf("ParseWithOrder failed: %v", err)
+			}
+			if err := tt.transforms.Apply(result); err != nil {
+				t.Fatalf("Transforms failed: %v", err)
+			}
+			want, err := converter.New().ToJSONOrdered(result)
+			if err != nil {
+				t.Fatalf("ToJSONOrdered failed: %v", err)
+			}
+			got, err := os.ReadFile(filepath.Join(outputFolder, "large.json"))
+			if err != nil {
+				t.Fatalf("Failed to read output: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("Expected spilled output to match in-memory output (%d bytes), got %d bytes", len(want), len(got))
+			}
+		})
+	}
+}
+
+// chunkRecorder is a transform recording the number of rows it is applied to at a time
+type chunkRecorder struct {
+	chunks []int
+}
+
+func (r *chunkRecorder) Name() string { return "chunkRecorder" }
+func (r *chunkRecorder) Apply(result *parser.ParseResult) error {
+	r.chunks = append(r.chunks, len(result.Rows))
+	return nil
+}
+
+// TestProcessFileChunked validates files over MEMORY_LIMIT_MB are converted with at most
+// one chunk of rows in flight, while smaller files are converted in memory
+func TestProcessFileChunked(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("id,name,city\n")
+	rows := 2*spillChunkRows + 17
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&content, "%d,name-%d,Zürich\n", i, i)
+	}
+
+	tests := []struct {
+		name        string
+		memoryLimit int
+		wantChunks  []int
+	}{
+		{"over memory limit", 1, []int{spillChunkRows, spillChunkRows, 17}},
+		{"under memory limit", 64, []int{rows}},
+		{"no memory limit", 0, []int{rows}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			outputFolder := filepath.Join(dir, "output")
+			cfg := &config.Config{OutputType: "file", OutputFolder: outputFolder, MemoryLimitMB: tt.memoryLimit}
+			p := newTestProcessor(t, cfg, output.NewFileHandler(outputFolder))
+			recorder := &chunkRecorder{}
+			p.transforms = transform.NewPipeline(recorder)
+
+			file := filepath.Join(dir, "orders.csv")
+			if err := os.WriteFile(file, []byte(content.String()), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if err := p.processFile(file); err != nil {
+				t.Fatalf("processFile failed: %v", err)
+			}
+
+			if fmt.Sprint(recorder.chunks) != fmt.Sprint(tt.wantChunks) {
+				t.Errorf("Expected rows in flight %v, got %v", tt.wantChunks, recorder.chunks)
+			}
+		})
+	}
+}
+
+// TestProcessFileSpilledStream validates spilled files are written to stdout and named
+// pipes as one stream record
+func TestProcessFileSpilledStream(t *testing.T) {
+	var content strings.Builder
+	content.WriteString("id,name,city\n")
+	// Over the memory limit estimate, while the rendered record itself fits
+	rows := spillChunkRows + 17
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&content, "%d,name-%d,Zürich\n", i, i)
+	}
+
+	tests := []struct {
+		name string
+		// open returns the stream the handler writes to and restores any state
+		open func(t *testing.T, dir string) (opts output.Options, stream func() (*os.File, error), restore func())
+	}{
+		{"stdout", func(t *testing.T, dir string) (output.Options, func() (*os.File, error), func()) {
+			reader, writer, err := os.Pipe()
+			if err != nil {
+				t.Fatalf("Failed to create pipe: %v", err)
+			}
+			stdout := os.Stdout
+			os.Stdout = writer
+			return output.Options{}, func() (*os.File, error) { return reader, nil }, func() {
+				os.Stdout = stdout
+				writer.Close()
+			}
+		}},
+		{"pipe", func(t *testing.T, dir string) (output.Options, func() (*os.File, error), func()) {
+			if runtime.GOOS == "windows" {
+				t.Skip("named pipes are not created on Windows")
+			}
+			path := filepath.Join(dir, "out.fifo")
+			return output.Options{PipePath: path}, func() (*os.File, error) { return os.Open(path) }, func() {}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts, stream, restore := tt.open(t, dir)
+			handler, err := output.CreateHandlerWithOptions(tt.name, "", "", "", 0, "", "", "", false, opts)
+			if err != nil {
+				restore()
+				t.Fatalf("CreateHandlerWithOptions failed: %v", err)
+			}
+
+			// Read the stream concurrently, as the record is larger than a pipe buffer
+			lines := make(chan []byte, 1)
+			go func() {
+				defer close(lines)
+				reader, err := stream()
+				if err != nil {
+					return
+				}
+				defer reader.Close()
+				scanner := bufio.NewScanner(reader)
+				scanner.Buffer(nil, 16<<20)
+				for scanner.Scan() {
+					lines <- append([]byte(nil), scanner.Bytes()...)
+				}
+			}()
+
+			cfg := &config.Config{OutputType: tt.name, MemoryLimitMB: 1, ArchiveProcessed: filepath.Join(dir, "processed"), ArchiveFailed: filepath.Join(dir, "failed")}
+			p := newTestProcessor(t, cfg, handler)
+			file := filepath.Join(dir, "large.csv")
+			if err := os.WriteFile(file, []byte(content.String()), 0644); err != nil {
+				t.Fatalf("Failed to create test file: %v", err)
+			}
+			if exceeds, _ := p.exceedsMemoryLimit(file); !exceeds {
+				t.Fatal("Expected the test file to exceed the memory limit")
+			}
+			processErr := p.processFile(file)
+			handler.Close()
+			restore()
+			if processErr != nil {
+				t.Fatalf("processFile failed: %v", processErr)
+			}
+
+			if _, err := os.Stat(filepath.Join(dir, "processed", "large.csv")); err != nil {
+				t.Fatalf("Expected file archived to processed: %v", err)
+			}
+			line, ok := <-lines
+			if !ok {
+				t.Fatal("Expected a stream record")
+			}
+			var record output.StreamRecord
+			if err := json.Unmarshal(line, &record); err != nil {
+				t.Fatalf("Line is not a JSON record: %v", err)
+			}
+			var data []map[string]string
+			if err := json.Unmarshal(record.Data, &data); err != nil {
+				t.Fatalf("Record data is not a JSON array: %v", err)
+			}
+			if record.Identifier != "large.csv" || len(data) != rows {
+				t.Errorf("Expected %d rows for large.csv, got %d for %q", rows, len(data), record.Identifier)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"time"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/metrics"
+	"csv2json/internal/monitor"
+)
+
+// Route health metrics
+const (
+	metricRouteUp       = "csv2json_route_up"
+	metricRouteDegraded = "csv2json_route_degraded"
+	metricRouteRestarts = "csv2json_route_restarts_total"
+	metricRoutePanics   = "csv2json_route_panics_total"
+	metricFilesIgnored  = "csv2json_files_ignored_total"
+)
+
+func init() {
+	metrics.Register(metricRouteUp, metrics.Gauge, "Whether the route processor is running (1) or restarting (0)")
+	metrics.Register(metricRouteDegraded, metrics.Gauge, "Whether the route is configured but waiting to start (1), e.g. for its input folder to be mounted")
+	metrics.Register(metricRouteRestarts, metrics.Counter, "Route processor restarts after a failure or panic")
+	metrics.Register(metricRoutePanics, metrics.Counter, "Panics recovered while processing files or running the monitor")
+	metrics.Register(metricFilesIgnored, metrics.Counter, "Files archived as ignored, by reason code")
+}
+
+// Restart backoff for supervised processors
+const (
+	minRestartBackoff = time.Second
+	maxRestartBackoff = time.Minute
+)
+
+// routeLabels returns the metric labels identifying this processor's route
+func (p *Processor) routeLabels() metrics.Labels {
+	route := p.routeName
+	if route == "" {
+		route = "default"
+	}
+	return metrics.Labels{"route": route}
+}
+
+// MarkRouteDown reports a route that could not be started (e.g. skipped as invalid at startup)
+func MarkRouteDown(route string) {
+	if route == "" {
+		return
+	}
+	metrics.Set(metricRouteUp, metrics.Labels{"route": route}, 0)
+}
+
+// MarkRouteDegraded reports a route that is configured but cannot start yet, such as
+// one waiting for its input folder to appear, and clears the report once it can
+func MarkRouteDegraded(route string, degraded bool) {
+	if route == "" {
+		return
+	}
+	value := 0.0
+	if degraded {
+		value = 1
+	}
+	metrics.Set(metricRouteDegraded, metrics.Labels{"route": route}, value)
+}
+
+// Run starts the processor and restarts it with exponential backoff whenever the
+// monitor fails or panics, until Stop is called. A panic in one route therefore
+// never takes down other routes or the process.
+func (p *Processor) Run() {
+	backoff := minRestartBackoff
+	for {
+		started := time.Now()
+		p.restarting.Store(false)
+		metrics.Set(metricRouteUp, p.routeLabels(), 1)
+		err := p.startRecovered()
+		metrics.Set(metricRouteUp, p.routeLabels(), 0)
+		p.restarting.Store(true)
+
+		select {
+		case <-p.done:
+			return
+		default:
+		}
+
+		// A processor that ran for a while before failing starts over with a short backoff
+		if time.Since(started) > maxRestartBackoff {
+			backoff = minRestartBackoff
+		}
+		log.Printf("ERROR: Route '%s' processor stopped unexpectedly: %v (restarting in %v)", p.routeLabels()["route"], err, backoff)
+		metrics.Add(metricRouteRestarts, p.routeLabels(), 1)
+
+		select {
+		case <-p.done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+// Ready returns a channel that is closed once the route's monitor is watching its
+// folder. Monitors that do not report readiness are treated as ready immediately.
+func (p *Processor) Ready() <-chan struct{} {
+	if l, ok := p.monitor.(monitor.Liveness); ok {
+		return l.Ready()
+	}
+	ready := make(chan struct{})
+	close(ready)
+	return ready
+}
+
+// Stalled reports whether the route's monitor loop has not turned for longer than
+// maxAge. A route restarting under supervision, holding a file back for disk space or
+// a scheduler slot, or processing a file, however large, is not stalled.
+func (p *Processor) Stalled(maxAge time.Duration) bool {
+	l, ok := p.monitor.(monitor.Liveness)
+	if !ok || p.restarting.Load() || p.waiting.Load() > 0 || p.processing.Load() > 0 {
+		return false
+	}
+	last := l.LastBeat()
+	return !last.IsZero() && time.Since(last) > maxAge
+}
+
+// startRecovered runs Start, converting a panic into an error
+func (p *Processor) startRecovered() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("PANIC in route '%s' monitor: %v\n%s", p.routeLabels()["route"], r, debug.Stack())
+			metrics.Add(metricRoutePanics, p.routeLabels(), 1)
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if err := p.Start(); err != nil {
+		return err
+	}
+	return fmt.Errorf("monitor exited")
+}
+
+// recoverFile handles a panic raised while processing a file: the file is archived
+// as failed (if it has not been moved already) so it is not retried in a loop
+func (p *Processor) recoverFile(filePath, filename string, r any) error {
+	log.Printf("PANIC processing %s: %v\n%s", filename, r, debug.Stack())
+	metrics.Add(metricRoutePanics, p.routeLabels(), 1)
+
+	if _, err := os.Stat(filePath); err != nil {
+		return fmt.Errorf("panic processing %s: %v", filename, r)
+	}
+	return p.archiver.Archive(filePath, archiver.CategoryFailed, fmt.Sprintf("panic: %v", r))
+}
@@ -0,0 +1,151 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"csv2json/internal/config"
+	"csv2json/internal/metrics"
+	"csv2json/internal/monitor"
+	"csv2json/internal/parser"
+	"csv2json/internal/transform"
+)
+
+// flakyMonitor panics on its first start, then runs until stopped
+type flakyMonitor struct {
+	starts int
+	stop   chan struct{}
+}
+
+func (m *flakyMonitor) Start(callback monitor.FileCallback) error {
+	m.starts++
+	if m.starts == 1 {
+		panic("watcher exploded")
+	}
+	<-m.stop
+	return nil
+}
+
+func (m *flakyMonitor) Stop()       {}
+func (m *flakyMonitor) TriggerNow() {}
+
+// TestRunRestartsAfterPanic validates a panicking monitor is restarted instead of killing the process
+func TestRunRestartsAfterPanic(t *testing.T) {
+	mon := &flakyMonitor{stop: make(chan struct{})}
+	p := &Processor{monitor: mon, routeName: "supervise-test", done: make(chan struct{})}
+	labels := metrics.Labels{"route": "supervise-test"}
+
+	finished := make(chan struct{})
+	go func() {
+		p.Run()
+		close(finished)
+	}()
+
+	deadline := time.After(5 * time.Second)
+	for metrics.Default.Value(metricRouteRestarts, labels) < 1 || metrics.Default.Value(metricRouteUp, labels) != 1 {
+		select {
+		case <-deadline:
+			t.Fatal("Route was not restarted after panic")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	if metrics.Default.Value(metricRoutePanics, labels) != 1 {
+		t.Errorf("Expected 1 recorded panic, got %v", metrics.Default.Value(metricRoutePanics, labels))
+	}
+
+	close(p.done)
+	close(mon.stop)
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after stop")
+	}
+	if mon.starts != 2 {
+		t.Errorf("Expected 2 starts, got %d", mon.starts)
+	}
+}
+
+// panicTransform panics when applied
+type panicTransform struct{}
+
+func (panicTransform) Name() string                    { return "panic" }
+func (panicTransform) Apply(*parser.ParseResult) error { panic("bad row") }
+
+// TestProcessFileRecoversPanic validates a panic while processing fails only that file
+func TestProcessFileRecoversPanic(t *testing.T) {
+	dir := t.TempDir()
+	failed := filepath.Join(dir, "failed")
+	file := filepath.Join(dir, "orders.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,widget\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	p := newTestProcessor(t, &config.Config{ArchiveFailed: failed}, nil)
+	p.transforms = transform.NewPipeline(panicTransform{})
+
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("Expected panic to be recovered and file archived, got: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(failed, "orders.csv")); err != nil {
+		t.Errorf("Expected file archived as failed: %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(failed, "orders.csv.error"))
+	if err != nil || !strings.Contains(string(content), "panic: bad row") {
+		t.Errorf("Expected error log mentioning the panic, got %q (%v)", content, err)
+	}
+}
+
+// beatMonitor is a monitor reporting a fixed last heartbeat
+type beatMonitor struct {
+	flakyMonitor
+	last time.Time
+}
+
+func (m *beatMonitor) Ready() <-chan struct{} { return make(chan struct{}) }
+func (m *beatMonitor) LastBeat() time.Time    { return m.last }
+
+// TestStalled validates stall detection ignores supervised restarts, deliberate waits
+// and files in progress
+func TestStalled(t *testing.T) {
+	old := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name       string
+		last       time.Time
+		restarting bool
+		waiting    bool
+		processing bool
+		want       bool
+	}{
+		{"recent beat", time.Now(), false, false, false, false},
+		{"not started", time.Time{}, false, false, false, false},
+		{"stale beat", old, false, false, false, true},
+		{"restarting", old, true, false, false, false},
+		{"waiting for slot", old, false, true, false, false},
+		{"processing a long file", old, false, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Processor{monitor: &beatMonitor{last: tt.last}}
+			p.restarting.Store(tt.restarting)
+			if tt.waiting {
+				p.waiting.Add(1)
+			}
+			if tt.processing {
+				p.processing.Add(1)
+			}
+			if got := p.Stalled(10 * time.Second); got != tt.want {
+				t.Errorf("Expected stalled=%t, got %t", tt.want, got)
+			}
+		})
+	}
+
+	select {
+	case <-(&Processor{monitor: &flakyMonitor{}}).Ready():
+	default:
+		t.Error("Expected monitors without liveness reporting to be ready immediately")
+	}
+}
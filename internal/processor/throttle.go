@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// byteThrottle limits intake to a volume of input per interval (BYTES_PER_INTERVAL),
+// so a burst of large files cannot swamp downstream consumers. It is a token bucket
+// holding at most one interval's budget: a file is admitted while any budget is left
+// and may overdraw it, and intake then waits until the overdraft is paid back.
+type byteThrottle struct {
+	limit    float64 // Bytes per interval, and the most budget that can accumulate
+	interval time.Duration
+	budget   float64
+	last     time.Time
+	now      func() time.Time
+	paused   bool
+}
+
+func newByteThrottle(bytesPerInterval int64, interval time.Duration) *byteThrottle {
+	t := &byteThrottle{
+		limit:    float64(bytesPerInterval),
+		interval: interval,
+		budget:   float64(bytesPerInterval),
+		now:      time.Now,
+	}
+	t.last = t.now()
+	return t
+}
+
+// refill adds the budget accrued since the last refill
+func (t *byteThrottle) refill() {
+	now := t.now()
+	t.budget += t.limit * float64(now.Sub(t.last)) / float64(t.interval)
+	if t.budget > t.limit {
+		t.budget = t.limit
+	}
+	t.last = now
+}
+
+// delay returns how long until budget is available again (0 = now)
+func (t *byteThrottle) delay() time.Duration {
+	t.refill()
+	if t.budget > 0 {
+		return 0
+	}
+	// Round up so the wait ends with budget available
+	return time.Duration((-t.budget+1)/t.limit*float64(t.interval)) + time.Millisecond
+}
+
+// wait blocks until there is budget left, then charges the size of filePath to it.
+// It returns an error if done is closed while intake is throttled.
+func (t *byteThrottle) wait(filePath string, done <-chan struct{}) error {
+	for {
+		d := t.delay()
+		if d == 0 {
+			break
+		}
+		if !t.paused {
+			log.Printf("Intake throttled: BYTES_PER_INTERVAL budget used, resuming in %s", d.Round(time.Second))
+			t.paused = true
+		}
+		select {
+		case <-done:
+			return fmt.Errorf("stopped while intake was throttled")
+		case <-time.After(d):
+		}
+	}
+	if t.paused {
+		log.Printf("Intake throttle budget available, resuming intake")
+		t.paused = false
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		t.budget -= float64(info.Size())
+	}
+	return nil
+}
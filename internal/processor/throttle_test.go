@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestByteThrottleBudget validates files are admitted while budget is left and intake then waits for the overdraft to be repaid
+func TestByteThrottleBudget(t *testing.T) {
+	dir := t.TempDir()
+	file := func(name string, size int) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatalf("Failed to create file: %v", err)
+		}
+		return path
+	}
+
+	clock := time.Unix(0, 0)
+	th := newByteThrottle(1000, time.Minute)
+	th.now = func() time.Time { return clock }
+	th.last = clock
+	done := make(chan struct{})
+
+	tests := []struct {
+		name        string
+		path        string
+		advance     time.Duration
+		expectDelay time.Duration // Delay before the file is admitted, to the second
+	}{
+		{name: "within budget", path: file("small.csv", 400)},
+		{name: "overdraws remaining budget", path: file("huge.csv", 2500)},
+		{name: "waits for overdraft", path: file("next.csv", 10), expectDelay: 114 * time.Second},
+		{name: "admitted after repayment", path: file("later.csv", 10), advance: 2 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock = clock.Add(tt.advance)
+			if d := th.delay().Round(time.Second); d != tt.expectDelay {
+				t.Fatalf("Expected delay %s, got %s", tt.expectDelay, d)
+			}
+			if tt.expectDelay > 0 {
+				return
+			}
+			if err := th.wait(tt.path, done); err != nil {
+				t.Fatalf("Expected file to be admitted, got: %v", err)
+			}
+		})
+	}
+}
+
+// TestByteThrottleStop validates a throttled wait returns an error when the processor stops
+func TestByteThrottleStop(t *testing.T) {
+	th := newByteThrottle(1000, time.Hour)
+	th.budget = -5000
+
+	done := make(chan struct{})
+	close(done)
+	if err := th.wait(filepath.Join(t.TempDir(), "any.csv"), done); err == nil {
+		t.Error("Expected error when stopped while throttled, got success")
+	}
+}
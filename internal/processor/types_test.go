@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/config"
+	"csv2json/internal/converter"
+	"csv2json/internal/output"
+)
+
+// TestTypeInference validates inferred and declared value types reach the output, and
+// that files with values not matching their column's type fail instead of being sent
+func TestTypeInference(t *testing.T) {
+	dir := t.TempDir()
+	outputFolder := filepath.Join(dir, "output")
+	failedFolder := filepath.Join(dir, "failed")
+	if err := os.MkdirAll(outputFolder, 0755); err != nil {
+		t.Fatalf("Failed to create output dir: %v", err)
+	}
+	cfg := &config.Config{InputFolder: dir, OutputType: "file", ArchiveFailed: failedFolder, TypeInference: true,
+		ColumnTypes: []config.ColumnType{{Column: "account", Type: converter.TypeString}, {Column: "age", Type: converter.TypeInteger}}}
+	handler, err := output.CreateHandlerWithOptions("file", outputFolder, "", "", 0, "", "", "", false, outputOptions(cfg))
+	if err != nil {
+		t.Fatalf("Failed to create handler: %v", err)
+	}
+	p := newTestProcessor(t, cfg, handler)
+
+	for name, content := range map[string]string{
+		"people.csv":  "account,age,active,score\n30,30,true,\n",
+		"invalid.csv": "account,age,active,score\n31,thirty,false,1.5\n",
+	} {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+		if err := p.processFile(file); err != nil {
+			t.Fatalf("processFile(%s) failed: %v", name, err)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(outputFolder, "people.json"))
+	if err != nil {
+		t.Fatalf("Expected output for the valid file: %v", err)
+	}
+	var rows []map[string]any
+	if err := json.Unmarshal(content, &rows); err != nil {
+		t.Fatalf("Expected a JSON array, got %s (%v)", content, err)
+	}
+	if rows[0]["account"] != "30" || rows[0]["age"] != float64(30) || rows[0]["active"] != true || rows[0]["score"] != nil {
+		t.Errorf("Expected typed values with account kept a string, got %v", rows[0])
+	}
+
+	if _, err := os.Stat(filepath.Join(outputFolder, "invalid.json")); !os.IsNotExist(err) {
+		t.Error("Expected no output for the file with an invalid integer")
+	}
+	entries, err := os.ReadDir(failedFolder)
+	if err != nil || len(entries) == 0 {
+		t.Errorf("Expected the invalid file to be archived as failed, got %v (%v)", entries, err)
+	}
+}
@@ -0,0 +1,39 @@
+package processor
+
+import (
+	"csv2json/internal/metrics"
+	"csv2json/internal/monitor"
+)
+
+// File system watcher metrics
+const (
+	metricWatcherEvents    = "csv2json_watcher_events_total"
+	metricWatcherCoalesced = "csv2json_watcher_events_coalesced_total"
+	metricWatcherErrors    = "csv2json_watcher_errors_total"
+	metricWatcherOverflows = "csv2json_watcher_overflows_total"
+	metricWatcherMissed    = "csv2json_watcher_missed_files_total"
+)
+
+func init() {
+	metrics.Register(metricWatcherEvents, metrics.Counter, "Create and Write events received from the file system watcher")
+	metrics.Register(metricWatcherCoalesced, metrics.Counter, "Watcher events merged into a pending readiness check of the same file")
+	metrics.Register(metricWatcherErrors, metrics.Counter, "Errors reported by the file system watcher")
+	metrics.Register(metricWatcherOverflows, metrics.Counter, "Watcher event queue overflows; events were dropped and the folder rescanned")
+	metrics.Register(metricWatcherMissed, metrics.Counter, "Files found by a rescan or backup poll that no watcher event had delivered")
+}
+
+// watcherMetrics maps watcher events to the counters they increment
+var watcherMetrics = map[monitor.WatchEvent]string{
+	monitor.WatchEventReceived:  metricWatcherEvents,
+	monitor.WatchEventCoalesced: metricWatcherCoalesced,
+	monitor.WatchEventError:     metricWatcherErrors,
+	monitor.WatchEventOverflow:  metricWatcherOverflows,
+	monitor.WatchEventMissed:    metricWatcherMissed,
+}
+
+// watchEvent counts an event of the route's file system watcher
+func (p *Processor) watchEvent(event monitor.WatchEvent) {
+	if name, ok := watcherMetrics[event]; ok {
+		metrics.Add(name, p.routeLabels(), 1)
+	}
+}
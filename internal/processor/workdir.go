@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"csv2json/internal/archiver"
+)
+
+// workTempFolder is the working directory subfolder holding spill files and sort runs
+const workTempFolder = "tmp"
+
+// errWorkGone is returned when a file left the input folder before it could be taken
+var errWorkGone = errors.New("file no longer in the input folder")
+
+// workDir isolates files from producers while they are processed: each file is moved
+// into the route's working directory before it is read, so a producer rewriting or
+// deleting it cannot affect the conversion. Files left there by a crash are returned
+// to the input folder on startup.
+type workDir struct {
+	dir         string
+	inputFolder string
+}
+
+func newWorkDir(dir, inputFolder string) (*workDir, error) {
+	if err := os.MkdirAll(filepath.Join(dir, workTempFolder), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	return &workDir{dir: dir, inputFolder: inputFolder}, nil
+}
+
+// workTempDir returns the folder for temporary files of a route with working directory
+// dir ("" = system temp folder)
+func workTempDir(dir string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, workTempFolder)
+}
+
+// take moves filePath into the working directory and returns its working path. On the
+// same filesystem the move is an atomic rename; across devices the file is copied first.
+func (w *workDir) take(filePath string) (string, error) {
+	working := filepath.Join(w.dir, filepath.Base(filePath))
+	if err := archiver.MoveFile(filePath, working); err != nil {
+		if _, statErr := os.Stat(filePath); os.IsNotExist(statErr) {
+			return "", errWorkGone
+		}
+		return "", fmt.Errorf("failed to move file to working directory: %w", err)
+	}
+	return working, nil
+}
+
+// recoverOrphans removes temporary files and returns files left in the working directory by a
+// crash to the input folder. An orphan whose name is waiting in the input folder again
+// is archived as failed rather than overwriting the newer delivery.
+func (w *workDir) recoverOrphans(arch *archiver.Archiver) {
+	temp := workTempDir(w.dir)
+	if entries, err := os.ReadDir(temp); err == nil {
+		for _, entry := range entries {
+			if err := os.RemoveAll(filepath.Join(temp, entry.Name())); err != nil {
+				log.Printf("Warning: failed to remove temporary file %s: %v", entry.Name(), err)
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		log.Printf("Warning: failed to read working directory: %v", err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(w.dir, entry.Name())
+		target := filepath.Join(w.inputFolder, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			log.Printf("Warning: orphaned %s not returned to the input folder: a file of that name is waiting", entry.Name())
+			if err := arch.Archive(path, archiver.CategoryFailed, "orphaned in the working directory after a crash; a newer file of the same name was waiting"); err != nil {
+				log.Printf("Failed to archive file %s: %v", entry.Name(), err)
+			}
+			continue
+		}
+		if err := archiver.MoveFile(path, target); err != nil {
+			log.Printf("Warning: failed to return orphaned %s to the input folder: %v", entry.Name(), err)
+			continue
+		}
+		log.Printf("Returned orphaned file %s from the working directory to the input folder", entry.Name())
+	}
+}
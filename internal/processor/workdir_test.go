@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"csv2json/internal/archiver"
+	"csv2json/internal/config"
+	"csv2json/internal/output"
+)
+
+// TestWorkDirRecoverOrphans validates crash leftovers are returned to the input folder and temp files removed
+func TestWorkDirRecoverOrphans(t *testing.T) {
+	dir := t.TempDir()
+	inputDir := filepath.Join(dir, "input")
+	work := filepath.Join(dir, "work")
+	failed := filepath.Join(dir, "failed")
+	if err := os.MkdirAll(filepath.Join(work, workTempFolder), 0755); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+	if err := os.MkdirAll(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input folder: %v", err)
+	}
+	for path, content := range map[string]string{
+		filepath.Join(work, "orphan.csv"):                   "id\n1\n",
+		filepath.Join(work, "redelivered.csv"):              "id\n2\n",
+		filepath.Join(inputDir, "redelivered.csv"):          "id\n3\n",
+		filepath.Join(work, workTempFolder, "spill-1.json"): "[",
+		filepath.Join(work, workTempFolder, "sort-1.jsonl"): "{}",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create test file: %v", err)
+		}
+	}
+
+	w, err := newWorkDir(work, inputDir)
+	if err != nil {
+		t.Fatalf("newWorkDir failed: %v", err)
+	}
+	w.recoverOrphans(archiver.New(filepath.Join(dir, "processed"), filepath.Join(dir, "ignored"), failed, false))
+
+	if _, err := os.Stat(filepath.Join(inputDir, "orphan.csv")); err != nil {
+		t.Errorf("Expected orphan.csv returned to the input folder: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(inputDir, "redelivered.csv")); string(content) != "id\n3\n" {
+		t.Errorf("Expected the waiting redelivery untouched, got %q", content)
+	}
+	if _, err := os.Stat(filepath.Join(failed, "redelivered.csv")); err != nil {
+		t.Errorf("Expected conflicting orphan archived as failed: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(work, workTempFolder)); len(entries) != 0 {
+		t.Errorf("Expected temporary files removed, found %d", len(entries))
+	}
+}
+
+// TestProcessFileWorkDir validates files are processed from the working directory
+func TestProcessFileWorkDir(t *testing.T) {
+	dir := t.TempDir()
+	inputDir := filepath.Join(dir, "input")
+	outputDir := filepath.Join(dir, "output")
+	processed := filepath.Join(dir, "processed")
+	for _, folder := range []string{inputDir, outputDir} {
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+	}
+	work, err := newWorkDir(filepath.Join(dir, "work"), inputDir)
+	if err != nil {
+		t.Fatalf("newWorkDir failed: %v", err)
+	}
+
+	cfg := &config.Config{InputFolder: inputDir, OutputType: "file", OutputFolder: outputDir, ArchiveProcessed: processed}
+	p := newTestProcessor(t, cfg, output.NewFileHandler(outputDir))
+	p.work = work
+
+	file := filepath.Join(inputDir, "orders.csv")
+	if err := os.WriteFile(file, []byte("id,name\n1,widget\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	if err := p.processFile(file); err != nil {
+		t.Fatalf("processFile failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(processed, "orders.csv")); err != nil {
+		t.Errorf("Expected orders.csv archived as processed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "orders.json")); err != nil {
+		t.Errorf("Expected output for orders.csv: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "work", "orders.csv")); err == nil {
+		t.Error("Expected orders.csv to leave the working directory once archived")
+	}
+
+	// A file that vanished before it could be taken is skipped
+	if err := p.processFile(filepath.Join(inputDir, "gone.csv")); err != nil {
+		t.Errorf("Expected vanished file to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+// Package quality evaluates data quality expectations declared for a route against
+// the rows of each file, in the style of Great Expectations: each expectation checks
+// one column and passes when a minimum share of rows satisfies it.
+package quality
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"csv2json/internal/parser"
+)
+
+// Expectation types
+const (
+	NotEmpty = "notEmpty" // Values are not empty or whitespace only
+	Unique   = "unique"   // Values do not repeat an earlier row's value
+	Pattern  = "pattern"  // Values match a regular expression
+)
+
+// Expectation declares one check on a column
+type Expectation struct {
+	Column     string
+	Type       string  // NotEmpty, Unique or Pattern
+	Pattern    string  // Regular expression values must match (Pattern type)
+	MinPercent float64 // Share of rows that must pass, 0-100 (0 = 100)
+}
+
+// Name identifies the expectation in logs and metrics, e.g. "email:notEmpty"
+func (e Expectation) Name() string {
+	return e.Column + ":" + e.Type
+}
+
+// threshold returns the share of rows that must pass
+func (e Expectation) threshold() float64 {
+	if e.MinPercent == 0 {
+		return 100
+	}
+	return e.MinPercent
+}
+
+// Suite is a compiled set of expectations
+type Suite struct {
+	expectations []Expectation
+	patterns     []*regexp.Regexp
+}
+
+// NewSuite checks and compiles expectations
+func NewSuite(expectations []Expectation) (*Suite, error) {
+	suite := &Suite{expectations: expectations, patterns: make([]*regexp.Regexp, len(expectations))}
+	for i, e := range expectations {
+		if e.Column == "" {
+			return nil, fmt.Errorf("expectation %d: column must be set", i+1)
+		}
+		if e.MinPercent < 0 || e.MinPercent > 100 {
+			return nil, fmt.Errorf("expectation %s: minPercent must be between 0 and 100, got %g", e.Name(), e.MinPercent)
+		}
+		switch e.Type {
+		case NotEmpty, Unique:
+			if e.Pattern != "" {
+				return nil, fmt.Errorf("expectation %s: pattern only applies to the %s type", e.Name(), Pattern)
+			}
+		case Pattern:
+			if e.Pattern == "" {
+				return nil, fmt.Errorf("expectation %s: pattern must be set", e.Name())
+			}
+			compiled, err := regexp.Compile(e.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("expectation %s: invalid pattern: %w", e.Name(), err)
+			}
+			suite.patterns[i] = compiled
+		default:
+			return nil, fmt.Errorf("expectation on column '%s': unsupported type: %s (supported: %s, %s, %s)", e.Column, e.Type, NotEmpty, Unique, Pattern)
+		}
+	}
+	return suite, nil
+}
+
+// Run evaluates a suite over the rows of one file, which may be added in chunks
+type Run struct {
+	suite   *Suite
+	rows    int
+	passed  []int
+	missing []bool                // Column absent from the file
+	seen    []map[string]struct{} // Values seen so far (Unique type)
+}
+
+// Start begins evaluating the suite against a new file
+func (s *Suite) Start() *Run {
+	run := &Run{
+		suite:   s,
+		passed:  make([]int, len(s.expectations)),
+		missing: make([]bool, len(s.expectations)),
+		seen:    make([]map[string]struct{}, len(s.expectations)),
+	}
+	for i, e := range s.expectations {
+		if e.Type == Unique {
+			run.seen[i] = make(map[string]struct{})
+		}
+	}
+	return run
+}
+
+// Add evaluates the rows of a parsed file or chunk
+func (r *Run) Add(result *parser.ParseResult) {
+	columns := make(map[string]bool, len(result.Headers))
+	for _, header := range result.Headers {
+		columns[header] = true
+	}
+
+	for i, e := range r.suite.expectations {
+		if !columns[e.Column] {
+			r.missing[i] = true
+			continue
+		}
+		for _, row := range result.Rows {
+			if r.passes(i, row.Values[e.Column]) {
+				r.passed[i]++
+			}
+		}
+	}
+	r.rows += len(result.Rows)
+}
+
+// passes reports whether value satisfies expectation i
+func (r *Run) passes(i int, value string) bool {
+	switch r.suite.expectations[i].Type {
+	case NotEmpty:
+		return strings.TrimSpace(value) != ""
+	case Unique:
+		if _, seen := r.seen[i][value]; seen {
+			return false
+		}
+		r.seen[i][value] = struct{}{}
+		return true
+	default:
+		return r.suite.patterns[i].MatchString(value)
+	}
+}
+
+// Result is the outcome of one expectation for a file
+type Result struct {
+	Expectation
+	Rows    int     // Rows evaluated
+	Passed  int     // Rows satisfying the expectation
+	Percent float64 // Passed as a share of Rows (100 for a file without rows)
+	Missing bool    // The column is not in the file
+	Met     bool
+}
+
+// String describes the outcome, e.g. "email:notEmpty 97.5% of 400 rows (expected >= 99%)"
+func (r Result) String() string {
+	if r.Missing {
+		return fmt.Sprintf("%s column not in file", r.Name())
+	}
+	return fmt.Sprintf("%s %.4g%% of %d rows (expected >= %g%%)", r.Name(), r.Percent, r.Rows, r.threshold())
+}
+
+// Results returns the outcome of every expectation, in declaration order
+func (r *Run) Results() []Result {
+	results := make([]Result, len(r.suite.expectations))
+	for i, e := range r.suite.expectations {
+		result := Result{Expectation: e, Rows: r.rows, Passed: r.passed[i], Percent: 100, Missing: r.missing[i]}
+		if r.rows > 0 {
+			result.Percent = 100 * float64(r.passed[i]) / float64(r.rows)
+		}
+		result.Met = !result.Missing && result.Percent >= e.threshold()
+		results[i] = result
+	}
+	return results
+}
+
+// Unmet returns the results of the expectations that were not met
+func Unmet(results []Result) []Result {
+	var unmet []Result
+	for _, result := range results {
+		if !result.Met {
+			unmet = append(unmet, result)
+		}
+	}
+	return unmet
+}
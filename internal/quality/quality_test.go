@@ -0,0 +1,99 @@
+package quality
+
+import (
+	"testing"
+
+	"csv2json/internal/parser"
+)
+
+// rows builds a parse result with one column
+func rows(column string, values ...string) *parser.ParseResult {
+	result := &parser.ParseResult{Headers: []string{column}}
+	for _, value := range values {
+		result.Rows = append(result.Rows, parser.OrderedMap{Keys: []string{column}, Values: map[string]string{column: value}})
+	}
+	return result
+}
+
+// TestSuiteResults validates each expectation type against its threshold
+func TestSuiteResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectation Expectation
+		values      []string
+		wantPercent float64
+		wantMet     bool
+	}{
+		{name: "not empty", expectation: Expectation{Column: "c", Type: NotEmpty}, values: []string{"a", "b"}, wantPercent: 100, wantMet: true},
+		{name: "whitespace is empty", expectation: Expectation{Column: "c", Type: NotEmpty}, values: []string{"a", " "}, wantPercent: 50},
+		{name: "below threshold", expectation: Expectation{Column: "c", Type: NotEmpty, MinPercent: 75}, values: []string{"a", "b", "", ""}, wantPercent: 50},
+		{name: "within threshold", expectation: Expectation{Column: "c", Type: NotEmpty, MinPercent: 75}, values: []string{"a", "b", "c", ""}, wantPercent: 75, wantMet: true},
+		{name: "unique", expectation: Expectation{Column: "c", Type: Unique}, values: []string{"1", "2", "3"}, wantPercent: 100, wantMet: true},
+		{name: "repeated value", expectation: Expectation{Column: "c", Type: Unique}, values: []string{"1", "2", "1", "1"}, wantPercent: 50},
+		{name: "pattern", expectation: Expectation{Column: "c", Type: Pattern, Pattern: `^[A-Z]{3}$`}, values: []string{"ABC", "abc"}, wantPercent: 50},
+		{name: "no rows", expectation: Expectation{Column: "c", Type: Unique}, wantPercent: 100, wantMet: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suite, err := NewSuite([]Expectation{tt.expectation})
+			if err != nil {
+				t.Fatalf("NewSuite failed: %v", err)
+			}
+			run := suite.Start()
+			run.Add(rows("c", tt.values...))
+			result := run.Results()[0]
+			if result.Percent != tt.wantPercent || result.Met != tt.wantMet {
+				t.Errorf("Expected %g%% met=%v, got %g%% met=%v", tt.wantPercent, tt.wantMet, result.Percent, result.Met)
+			}
+		})
+	}
+}
+
+// TestRunChunks validates uniqueness and counts carry across chunks, and a missing column is unmet
+func TestRunChunks(t *testing.T) {
+	suite, err := NewSuite([]Expectation{
+		{Column: "id", Type: Unique},
+		{Column: "email", Type: NotEmpty},
+	})
+	if err != nil {
+		t.Fatalf("NewSuite failed: %v", err)
+	}
+	run := suite.Start()
+	run.Add(rows("id", "1", "2"))
+	run.Add(rows("id", "2", "3"))
+
+	unmet := Unmet(run.Results())
+	if len(unmet) != 2 {
+		t.Fatalf("Expected 2 unmet expectations, got %v", unmet)
+	}
+	if unmet[0].Passed != 3 || unmet[0].Rows != 4 {
+		t.Errorf("Expected 3 of 4 unique ids, got %d of %d", unmet[0].Passed, unmet[0].Rows)
+	}
+	if !unmet[1].Missing || unmet[1].String() != "email:notEmpty column not in file" {
+		t.Errorf("Expected missing email column, got %s", unmet[1])
+	}
+}
+
+// TestNewSuiteInvalid validates malformed expectations are rejected
+func TestNewSuiteInvalid(t *testing.T) {
+	tests := []struct {
+		name        string
+		expectation Expectation
+	}{
+		{name: "no column", expectation: Expectation{Type: NotEmpty}},
+		{name: "unknown type", expectation: Expectation{Column: "c", Type: "positive"}},
+		{name: "threshold over 100", expectation: Expectation{Column: "c", Type: NotEmpty, MinPercent: 101}},
+		{name: "pattern missing", expectation: Expectation{Column: "c", Type: Pattern}},
+		{name: "invalid pattern", expectation: Expectation{Column: "c", Type: Pattern, Pattern: "("}},
+		{name: "pattern on other type", expectation: Expectation{Column: "c", Type: Unique, Pattern: "x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewSuite([]Expectation{tt.expectation}); err == nil {
+				t.Errorf("Expected error for %+v", tt.expectation)
+			}
+		})
+	}
+}
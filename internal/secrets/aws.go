@@ -0,0 +1,136 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+const awsService = "secretsmanager"
+
+// awsSecrets reads an AWS Secrets Manager secret whose SecretString is a JSON object.
+// Requests are signed with Signature Version 4 using static or session credentials.
+type awsSecrets struct {
+	client       *http.Client
+	endpoint     string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	secretID     string
+	now          func() time.Time
+}
+
+func newAWS(cfg *config.SecretsConfig, client *http.Client) *awsSecrets {
+	endpoint := cfg.AWSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.%s.amazonaws.com", awsService, cfg.AWSRegion)
+	}
+	return &awsSecrets{
+		client:       client,
+		endpoint:     strings.TrimRight(endpoint, "/"),
+		region:       cfg.AWSRegion,
+		accessKey:    cfg.AWSAccessKeyID,
+		secretKey:    cfg.AWSSecretAccessKey,
+		sessionToken: cfg.AWSSessionToken,
+		secretID:     cfg.Path,
+		now:          time.Now,
+	}
+}
+
+func (a *awsSecrets) Name() string { return "aws" }
+
+// Fetch calls GetSecretValue for the current version of the secret
+func (a *awsSecrets) Fetch(ctx context.Context) (map[string]string, error) {
+	body, err := json.Marshal(map[string]string{"SecretId": a.secretID})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(req, body)
+
+	var resp struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := doJSON(a.client, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.SecretString == "" {
+		return nil, fmt.Errorf("secret %s has no SecretString", a.secretID)
+	}
+	return parseSecretJSON([]byte(resp.SecretString))
+}
+
+// sign adds Signature Version 4 headers to req
+func (a *awsSecrets) sign(req *http.Request, body []byte) {
+	now := a.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if a.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.sessionToken)
+	}
+
+	// Canonical headers: host plus every header set above, sorted by lowercase name
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := sortedKeys(headers)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashHex(body),
+	}, "\n")
+
+	scope := strings.Join([]string{date, a.region, awsService, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+a.secretKey), date)
+	key = hmacSHA256(key, a.region)
+	key = hmacSHA256(key, awsService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.accessKey, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
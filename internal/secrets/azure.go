@@ -0,0 +1,142 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+const (
+	azureVaultResource = "https://vault.azure.net"
+	azureAPIVersion    = "7.4"
+)
+
+// Token endpoints, overridable in tests
+var (
+	azureLoginURL = "https://login.microsoftonline.com"
+	azureIMDSURL  = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// azureSecrets reads an Azure Key Vault secret whose value is a JSON object. It
+// authenticates with a service principal when client credentials are configured,
+// otherwise with the managed identity of the host.
+type azureSecrets struct {
+	client       *http.Client
+	vaultURL     string
+	secretName   string
+	tenantID     string
+	clientID     string
+	clientSecret string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+func newAzure(cfg *config.SecretsConfig, client *http.Client) *azureSecrets {
+	return &azureSecrets{
+		client:       client,
+		vaultURL:     strings.TrimRight(cfg.AzureVaultURL, "/"),
+		secretName:   cfg.Path,
+		tenantID:     cfg.AzureTenantID,
+		clientID:     cfg.AzureClientID,
+		clientSecret: cfg.AzureClientSecret,
+	}
+}
+
+func (a *azureSecrets) Name() string { return "azure" }
+
+// Fetch reads the current version of the secret
+func (a *azureSecrets) Fetch(ctx context.Context) (map[string]string, error) {
+	token, err := a.accessToken(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire access token: %w", err)
+	}
+
+	secretURL := fmt.Sprintf("%s/secrets/%s?api-version=%s", a.vaultURL, url.PathEscape(a.secretName), azureAPIVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var resp struct {
+		Value string `json:"value"`
+	}
+	if err := doJSON(a.client, req, &resp); err != nil {
+		return nil, err
+	}
+	return parseSecretJSON([]byte(resp.Value))
+}
+
+// Renew drops a token close to expiry so the next fetch acquires a fresh one
+func (a *azureSecrets) Renew(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if time.Until(a.expires) < 5*time.Minute {
+		a.token = ""
+	}
+	return nil
+}
+
+// accessToken returns a cached Key Vault access token, acquiring one when needed
+func (a *azureSecrets) accessToken(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && time.Now().Before(a.expires) {
+		return a.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	if a.clientSecret != "" {
+		form := url.Values{
+			"grant_type":    {"client_credentials"},
+			"client_id":     {a.clientID},
+			"client_secret": {a.clientSecret},
+			"scope":         {azureVaultResource + "/.default"},
+		}
+		tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", azureLoginURL, url.PathEscape(a.tenantID))
+		req, err = http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		query := url.Values{"api-version": {"2018-02-01"}, "resource": {azureVaultResource}}
+		if a.clientID != "" {
+			query.Set("client_id", a.clientID) // User-assigned managed identity
+		}
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, azureIMDSURL+"?"+query.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+	}
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   any    `json:"expires_in"` // Number (Entra ID) or string (IMDS)
+	}
+	if err := doJSON(a.client, req, &resp); err != nil {
+		return "", err
+	}
+	if resp.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access_token")
+	}
+
+	lifetime := time.Hour
+	var seconds int
+	if _, err := fmt.Sscan(fmt.Sprint(resp.ExpiresIn), &seconds); err == nil && seconds > 0 {
+		lifetime = time.Duration(seconds) * time.Second
+	}
+	a.token = resp.AccessToken
+	a.expires = time.Now().Add(lifetime - time.Minute)
+	return a.token, nil
+}
@@ -0,0 +1,162 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+// Provider fetches the configured secret as environment-variable-named key/value pairs
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) (map[string]string, error)
+}
+
+// renewer is implemented by providers whose credentials expire unless renewed
+type renewer interface {
+	Renew(ctx context.Context) error
+}
+
+// New creates the provider selected by cfg
+func New(cfg *config.SecretsConfig) (Provider, error) {
+	client := &http.Client{Timeout: cfg.Timeout}
+	switch cfg.Provider {
+	case config.SecretsProviderVault:
+		return newVault(cfg, client), nil
+	case config.SecretsProviderAWS:
+		return newAWS(cfg, client), nil
+	case config.SecretsProviderAzure:
+		return newAzure(cfg, client), nil
+	default:
+		return nil, fmt.Errorf("unsupported secrets provider: %s", cfg.Provider)
+	}
+}
+
+// Load fetches the secret and exports its values to the process environment,
+// where the rest of the configuration picks them up
+func Load(p Provider, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	values, err := p.Fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch secrets from %s: %w", p.Name(), err)
+	}
+	Apply(values)
+	log.Printf("Loaded %d secret(s) from %s: %v", len(values), p.Name(), sortedKeys(values))
+	return nil
+}
+
+// Watch re-fetches the secret every interval until done is closed, renewing
+// provider credentials first. Rotated values replace the environment; queue handlers
+// read the broker credentials from it before each dial, so a reconnect uses them.
+func Watch(p Provider, interval, timeout time.Duration, done <-chan struct{}) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			refresh(p, timeout)
+		}
+	}
+}
+
+// refresh renews provider credentials and re-applies the fetched secret
+func refresh(p Provider, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if r, ok := p.(renewer); ok {
+		if err := r.Renew(ctx); err != nil {
+			log.Printf("Warning: failed to renew %s credentials: %v", p.Name(), err)
+		}
+	}
+	values, err := p.Fetch(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to refresh secrets from %s (keeping previous values): %v", p.Name(), err)
+		return
+	}
+	if changed := Apply(values); len(changed) > 0 {
+		log.Printf("Secrets rotated in %s: %v", p.Name(), changed)
+	}
+}
+
+// Apply sets each value in the process environment as the secrets configuration layer,
+// leaving keys overridden by a flag alone, and returns the keys that changed
+func Apply(values map[string]string) []string {
+	changed, err := config.ApplySecrets(values)
+	if err != nil {
+		log.Printf("Warning: failed to set secrets: %v", err)
+	}
+	return changed
+}
+
+// parseSecretJSON decodes a secret stored as a JSON object of string values
+func parseSecretJSON(data []byte) (map[string]string, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("secret is not a JSON object: %w", err)
+	}
+	values := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if s, ok := value.(string); ok {
+			values[key] = s
+		} else {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+	return values, nil
+}
+
+// doJSON performs req and decodes a successful JSON response into out
+func doJSON(client *http.Client, req *http.Request, out any) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: HTTP %d: %s", req.Method, req.URL.Path, resp.StatusCode, truncate(string(body), 200))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of values in sorted order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,158 @@
+package secrets
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"csv2json/internal/config"
+)
+
+// TestVaultFetchAndRenew validates KV v2 reads and token renewal use the Vault token
+func TestVaultFetchAndRenew(t *testing.T) {
+	var renewed bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/csv2json":
+			w.Write([]byte(`{"data":{"data":{"QUEUE_USERNAME":"svc","QUEUE_PASSWORD":"p@ss"},"metadata":{"version":3}}}`))
+		case "/v1/auth/token/renew-self":
+			renewed = r.Method == http.MethodPost
+			w.Write([]byte(`{"auth":{"renewable":true}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(&config.SecretsConfig{
+		Provider:   config.SecretsProviderVault,
+		Path:       "/secret/data/csv2json",
+		Timeout:    time.Second,
+		VaultAddr:  server.URL + "/",
+		VaultToken: "root-token",
+	})
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	values, err := p.Fetch(t.Context())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if values["QUEUE_USERNAME"] != "svc" || values["QUEUE_PASSWORD"] != "p@ss" {
+		t.Errorf("Unexpected secret values: %v", values)
+	}
+
+	if err := p.(renewer).Renew(t.Context()); err != nil || !renewed {
+		t.Errorf("Expected token renewal, got renewed=%t err=%v", renewed, err)
+	}
+
+	p.(*vault).token = "wrong"
+	if _, err := p.Fetch(t.Context()); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Expected HTTP 403 error, got: %v", err)
+	}
+}
+
+// TestAWSFetchSigned validates GetSecretValue requests are SigV4 signed and the JSON SecretString is parsed
+func TestAWSFetchSigned(t *testing.T) {
+	var auth, target, token, secretID string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		target = r.Header.Get("X-Amz-Target")
+		token = r.Header.Get("X-Amz-Security-Token")
+		var body map[string]string
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, &body)
+		secretID = body["SecretId"]
+		w.Write([]byte(`{"Name":"prod/csv2json","SecretString":"{\"HASH_SALT\":\"pepper\",\"QUEUE_PORT\":5671}"}`))
+	}))
+	defer server.Close()
+
+	p := newAWS(&config.SecretsConfig{
+		Path:               "prod/csv2json",
+		AWSRegion:          "eu-west-1",
+		AWSAccessKeyID:     "AKIDEXAMPLE",
+		AWSSecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		AWSSessionToken:    "session",
+		AWSEndpoint:        server.URL,
+	}, server.Client())
+	p.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	values, err := p.Fetch(t.Context())
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	if values["HASH_SALT"] != "pepper" || values["QUEUE_PORT"] != "5671" {
+		t.Errorf("Unexpected secret values: %v", values)
+	}
+	if secretID != "prod/csv2json" || target != "secretsmanager.GetSecretValue" || token != "session" {
+		t.Errorf("Unexpected request: SecretId=%q target=%q token=%q", secretID, target, token)
+	}
+
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240501/eu-west-1/secretsmanager/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target, Signature="
+	if !strings.HasPrefix(auth, wantPrefix) || len(auth) != len(wantPrefix)+64 {
+		t.Errorf("Unexpected Authorization header: %s", auth)
+	}
+}
+
+// TestAzureFetchManagedIdentity validates the managed identity token is used to read the secret
+func TestAzureFetchManagedIdentity(t *testing.T) {
+	var tokenRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/identity":
+			tokenRequests++
+			if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("resource") != azureVaultResource {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.Write([]byte(`{"access_token":"mi-token","expires_in":"3599"}`))
+		case r.URL.Path == "/secrets/csv2json" && r.Header.Get("Authorization") == "Bearer mi-token":
+			w.Write([]byte(`{"value":"{\"QUEUE_PASSWORD\":\"rotated\"}"}`))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	defer func(old string) { azureIMDSURL = old }(azureIMDSURL)
+	azureIMDSURL = server.URL + "/identity"
+
+	p := newAzure(&config.SecretsConfig{Path: "csv2json", AzureVaultURL: server.URL}, server.Client())
+	for i := 0; i < 2; i++ {
+		values, err := p.Fetch(t.Context())
+		if err != nil {
+			t.Fatalf("Fetch failed: %v", err)
+		}
+		if values["QUEUE_PASSWORD"] != "rotated" {
+			t.Errorf("Unexpected secret values: %v", values)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("Expected the access token to be cached, got %d token requests", tokenRequests)
+	}
+}
+
+// TestApply validates secrets are exported to the environment and only changed keys are reported
+func TestApply(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("QUEUE_USERNAME", "svc")
+
+	changed := Apply(map[string]string{"QUEUE_USERNAME": "svc", "QUEUE_PASSWORD": "new"})
+	if len(changed) != 1 || changed[0] != "QUEUE_PASSWORD" {
+		t.Errorf("Expected only QUEUE_PASSWORD to change, got %v", changed)
+	}
+	if os.Getenv("QUEUE_PASSWORD") != "new" {
+		t.Errorf("Expected QUEUE_PASSWORD to be exported, got %q", os.Getenv("QUEUE_PASSWORD"))
+	}
+}
@@ -0,0 +1,73 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"csv2json/internal/config"
+)
+
+// vault reads a HashiCorp Vault KV v2 secret using a token
+type vault struct {
+	client *http.Client
+	addr   string
+	token  string
+	path   string // KV v2 API path, e.g. "secret/data/csv2json"
+}
+
+func newVault(cfg *config.SecretsConfig, client *http.Client) *vault {
+	return &vault{
+		client: client,
+		addr:   strings.TrimRight(cfg.VaultAddr, "/"),
+		token:  cfg.VaultToken,
+		path:   strings.Trim(cfg.Path, "/"),
+	}
+}
+
+func (v *vault) Name() string { return "vault" }
+
+// Fetch reads the latest version of the KV v2 secret
+func (v *vault) Fetch(ctx context.Context) (map[string]string, error) {
+	req, err := v.request(ctx, http.MethodGet, "/v1/"+v.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := doJSON(v.client, req, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Data == nil {
+		return nil, fmt.Errorf("no KV v2 data at %s", v.path)
+	}
+
+	values := make(map[string]string, len(resp.Data.Data))
+	for key, value := range resp.Data.Data {
+		values[key] = fmt.Sprint(value)
+	}
+	return values, nil
+}
+
+// Renew extends the lease of the Vault token
+func (v *vault) Renew(ctx context.Context) error {
+	req, err := v.request(ctx, http.MethodPost, "/v1/auth/token/renew-self")
+	if err != nil {
+		return err
+	}
+	return doJSON(v.client, req, nil)
+}
+
+func (v *vault) request(ctx context.Context, method, path string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, v.addr+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	return req, nil
+}
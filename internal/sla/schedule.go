@@ -0,0 +1,123 @@
+package sla
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Schedule timezones resolve on hosts without a zoneinfo database (e.g. Windows)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Schedule is an expected arrival schedule: at least one file must arrive in each
+// period before its deadline. Specs have the form "<days> by HH:MM [timezone]" or
+// "hourly by :MM [timezone]", where days is daily, weekdays, weekends, or a comma
+// list such as mon,wed,fri. For example "daily by 06:00" expects a file between
+// midnight and 06:00 every day; the timezone defaults to the local time zone.
+type Schedule struct {
+	spec   string
+	hourly bool
+	days   [7]bool // Indexed by time.Weekday
+	hour   int
+	minute int
+	loc    *time.Location
+}
+
+// Parse parses an expected arrival schedule
+func Parse(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) < 3 || len(fields) > 4 || fields[1] != "by" {
+		return nil, fmt.Errorf("invalid schedule %q (expected \"<days> by HH:MM [timezone]\" or \"hourly by :MM [timezone]\")", spec)
+	}
+	s := &Schedule{spec: spec, loc: time.Local}
+
+	if len(fields) == 4 {
+		loc, err := time.LoadLocation(fields[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule timezone %q: %w", fields[3], err)
+		}
+		s.loc = loc
+	}
+
+	if strings.ToLower(fields[0]) == "hourly" {
+		s.hourly = true
+		minute, err := strconv.Atoi(strings.TrimPrefix(fields[2], ":"))
+		if err != nil || !strings.HasPrefix(fields[2], ":") || minute < 0 || minute > 59 {
+			return nil, fmt.Errorf("invalid hourly deadline %q (expected :MM)", fields[2])
+		}
+		s.minute = minute
+		return s, nil
+	}
+
+	if err := s.parseDays(strings.ToLower(fields[0])); err != nil {
+		return nil, err
+	}
+	deadline, err := time.Parse("15:04", fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid deadline %q (expected HH:MM)", fields[2])
+	}
+	s.hour, s.minute = deadline.Hour(), deadline.Minute()
+	return s, nil
+}
+
+// parseDays sets the days with a deadline
+func (s *Schedule) parseDays(days string) error {
+	switch days {
+	case "daily":
+		for day := range s.days {
+			s.days[day] = true
+		}
+	case "weekdays":
+		for day := time.Monday; day <= time.Friday; day++ {
+			s.days[day] = true
+		}
+	case "weekends":
+		s.days[time.Saturday], s.days[time.Sunday] = true, true
+	default:
+		for _, name := range strings.Split(days, ",") {
+			day, ok := weekdays[name]
+			if !ok {
+				return fmt.Errorf("invalid schedule days %q (expected daily, weekdays, weekends, or a list such as mon,wed,fri)", days)
+			}
+			s.days[day] = true
+		}
+	}
+	return nil
+}
+
+// String returns the schedule as it was specified
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first deadline after t
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.In(s.loc)
+	if s.hourly {
+		deadline := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), s.minute, 0, 0, s.loc)
+		if !deadline.After(t) {
+			deadline = deadline.Add(time.Hour)
+		}
+		return deadline
+	}
+	for i := 0; ; i++ {
+		deadline := time.Date(t.Year(), t.Month(), t.Day()+i, s.hour, s.minute, 0, 0, s.loc)
+		if deadline.After(t) && s.days[deadline.Weekday()] {
+			return deadline
+		}
+	}
+}
+
+// PeriodStart returns the start of the period a deadline closes: the top of the hour
+// for hourly schedules, otherwise midnight of the deadline's day
+func (s *Schedule) PeriodStart(deadline time.Time) time.Time {
+	deadline = deadline.In(s.loc)
+	if s.hourly {
+		return time.Date(deadline.Year(), deadline.Month(), deadline.Day(), deadline.Hour(), 0, 0, 0, s.loc)
+	}
+	return time.Date(deadline.Year(), deadline.Month(), deadline.Day(), 0, 0, 0, 0, s.loc)
+}
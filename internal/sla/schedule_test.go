@@ -0,0 +1,73 @@
+package sla
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParse validates accepted and rejected schedule specs
+func TestParse(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"daily by 06:00", false},
+		{"weekdays by 17:30 Europe/London", false},
+		{"mon,wed,fri by 09:15", false},
+		{"weekends by 23:59 UTC", false},
+		{"hourly by :15", false},
+		{"daily at 06:00", true},
+		{"daily by 6am", true},
+		{"someday by 06:00", true},
+		{"hourly by 15", true},
+		{"hourly by :60", true},
+		{"daily by 06:00 Mars/Olympus", true},
+		{"", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse(%q) error = %v, wantErr %t", tt.spec, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNext validates deadlines and the periods they close
+func TestNext(t *testing.T) {
+	// 2026-01-07 is a Wednesday
+	at := func(day, hour, minute int) time.Time { return time.Date(2026, 1, day, hour, minute, 0, 0, time.UTC) }
+
+	tests := []struct {
+		spec      string
+		now       time.Time
+		deadline  time.Time
+		periodFor time.Time
+	}{
+		{"daily by 06:00 UTC", at(7, 5, 0), at(7, 6, 0), at(7, 0, 0)},
+		{"daily by 06:00 UTC", at(7, 6, 0), at(8, 6, 0), at(8, 0, 0)},
+		{"weekdays by 06:00 UTC", at(9, 7, 0), at(12, 6, 0), at(12, 0, 0)}, // Friday after the deadline -> Monday
+		{"mon,wed by 12:00 UTC", at(7, 13, 0), at(12, 12, 0), at(12, 0, 0)},
+		{"weekends by 08:00 UTC", at(7, 13, 0), at(10, 8, 0), at(10, 0, 0)},
+		{"hourly by :15 UTC", at(7, 5, 10), at(7, 5, 15), at(7, 5, 0)},
+		{"hourly by :15 UTC", at(7, 5, 20), at(7, 6, 15), at(7, 6, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec+" at "+tt.now.Format(time.Kitchen), func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse failed: %v", err)
+			}
+			deadline := s.Next(tt.now)
+			if !deadline.Equal(tt.deadline) {
+				t.Errorf("Expected deadline %v, got %v", tt.deadline, deadline)
+			}
+			if start := s.PeriodStart(deadline); !start.Equal(tt.periodFor) {
+				t.Errorf("Expected period start %v, got %v", tt.periodFor, start)
+			}
+		})
+	}
+}
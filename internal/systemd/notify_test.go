@@ -0,0 +1,64 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestNotify validates states are delivered to NOTIFY_SOCKET and skipped without it
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(StateReady); sent || err != nil {
+		t.Fatalf("Expected no-op without NOTIFY_SOCKET, got sent=%t err=%v", sent, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if sent, err := Notify(StateReady); !sent || err != nil {
+		t.Fatalf("Expected notification to be sent, got sent=%t err=%v", sent, err)
+	}
+	buf := make([]byte, 64)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != StateReady {
+		t.Errorf("Expected %q, got %q (%v)", StateReady, buf[:n], err)
+	}
+}
+
+// TestWatchdogInterval validates WATCHDOG_USEC and WATCHDOG_PID handling
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		usec    string
+		pid     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"disabled", "", "", 0, false},
+		{"enabled", "30000000", "", 30 * time.Second, false},
+		{"this process", "2000000", strconv.Itoa(os.Getpid()), 2 * time.Second, false},
+		{"other process", "2000000", "1", 0, false},
+		{"invalid", "soon", "", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			got, err := WatchdogInterval()
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("Expected %v (error %t), got %v (%v)", tt.want, tt.wantErr, got, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package transform
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"csv2json/internal/parser"
+)
+
+// Duplicate retention modes
+const (
+	DedupKeepFirst = "first"
+	DedupKeepLast  = "last"
+)
+
+// Deduplicator collapses rows sharing the same key column combination within one file
+type Deduplicator struct {
+	keys []string
+	keep string
+}
+
+// NewDeduplicator creates a deduplicator on keys, keeping the first or last occurrence
+func NewDeduplicator(keys []string, keep string) (*Deduplicator, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one dedup key column is required")
+	}
+	if keep == "" {
+		keep = DedupKeepFirst
+	}
+	if keep != DedupKeepFirst && keep != DedupKeepLast {
+		return nil, fmt.Errorf("unsupported dedup keep mode '%s' (supported: first, last)", keep)
+	}
+	return &Deduplicator{keys: keys, keep: keep}, nil
+}
+
+// Name identifies the transform in logs and errors
+func (d *Deduplicator) Name() string {
+	return "dedup"
+}
+
+// Apply removes duplicate rows, preserving the relative order of retained rows, and
+// counts them in the result's Duplicates
+func (d *Deduplicator) Apply(result *parser.ParseResult) error {
+	for _, key := range d.keys {
+		if len(result.Rows) > 0 && !containsKey(result.Headers, key) {
+			return fmt.Errorf("dedup key column '%s' not found", key)
+		}
+	}
+
+	// Record which row index survives for each key combination
+	survivor := make(map[string]int, len(result.Rows))
+	for i, row := range result.Rows {
+		k := d.rowKey(row)
+		if _, seen := survivor[k]; !seen || d.keep == DedupKeepLast {
+			survivor[k] = i
+		}
+	}
+
+	duplicates := len(result.Rows) - len(survivor)
+	if duplicates == 0 {
+		return nil
+	}
+
+	kept := make([]parser.OrderedMap, 0, len(survivor))
+	for i, row := range result.Rows {
+		if survivor[d.rowKey(row)] == i {
+			kept = append(kept, row)
+		}
+	}
+	log.Printf("Deduplicated %d rows to %d (%d duplicates removed, keys: %s, keep: %s)",
+		len(result.Rows), len(kept), duplicates, strings.Join(d.keys, ","), d.keep)
+	result.Rows = kept
+	result.Duplicates += duplicates
+	return nil
+}
+
+// rowKey builds an unambiguous composite key from the key column values
+func (d *Deduplicator) rowKey(row parser.OrderedMap) string {
+	var sb strings.Builder
+	for _, key := range d.keys {
+		value := row.Values[key]
+		fmt.Fprintf(&sb, "%d:%s|", len(value), value)
+	}
+	return sb.String()
+}
@@ -0,0 +1,78 @@
+package transform
+
+import (
+	"testing"
+)
+
+// TestDeduplicatorKeepFirstAndLast validates both retention modes and order preservation
+func TestDeduplicatorKeepFirstAndLast(t *testing.T) {
+	testCases := []struct {
+		keep     string
+		expected []string
+	}{
+		{DedupKeepFirst, []string{"1", "2", "4"}},
+		{DedupKeepLast, []string{"2", "3", "4"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.keep, func(t *testing.T) {
+			result := newTestResult([]string{"id", "region", "seq"},
+				[]string{"A", "N", "1"},
+				[]string{"B", "N", "2"},
+				[]string{"A", "N", "3"},
+				[]string{"A", "S", "4"},
+			)
+
+			d, err := NewDeduplicator([]string{"id", "region"}, tc.keep)
+			if err != nil {
+				t.Fatalf("NewDeduplicator failed: %v", err)
+			}
+			if err := d.Apply(result); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+
+			if len(result.Rows) != len(tc.expected) {
+				t.Fatalf("Expected %d rows, got %d", len(tc.expected), len(result.Rows))
+			}
+			if result.Duplicates != 1 {
+				t.Errorf("Expected 1 duplicate counted, got %d", result.Duplicates)
+			}
+			for i, seq := range tc.expected {
+				if result.Rows[i].Values["seq"] != seq {
+					t.Errorf("Row %d: expected seq %s, got %s", i, seq, result.Rows[i].Values["seq"])
+				}
+			}
+		})
+	}
+}
+
+// TestDeduplicatorCompositeKeyIsUnambiguous validates key values containing separators do not collide
+func TestDeduplicatorCompositeKeyIsUnambiguous(t *testing.T) {
+	result := newTestResult([]string{"a", "b"},
+		[]string{"x|", "y"},
+		[]string{"x", "|y"},
+	)
+
+	d, _ := NewDeduplicator([]string{"a", "b"}, "")
+	if err := d.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if len(result.Rows) != 2 {
+		t.Errorf("Expected 2 distinct rows, got %d", len(result.Rows))
+	}
+}
+
+// TestDeduplicatorValidation validates configuration and missing key errors
+func TestDeduplicatorValidation(t *testing.T) {
+	if _, err := NewDeduplicator(nil, ""); err == nil {
+		t.Error("Expected error for empty key list, got success")
+	}
+	if _, err := NewDeduplicator([]string{"id"}, "middle"); err == nil {
+		t.Error("Expected error for unsupported keep mode, got success")
+	}
+
+	d, _ := NewDeduplicator([]string{"missing"}, "")
+	if err := d.Apply(newTestResult([]string{"id"}, []string{"1"})); err == nil {
+		t.Error("Expected error for missing key column, got success")
+	}
+}
@@ -0,0 +1,164 @@
+package transform
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"csv2json/internal/parser"
+)
+
+// LookupTable describes a reference CSV joined into each record by key
+type LookupTable struct {
+	Path      string   // Reference CSV file (must have a header row)
+	Key       string   // Column in the input records used for the join
+	LookupKey string   // Column in the reference CSV matched against Key (defaults to Key)
+	Columns   []string // Reference columns added to each record (defaults to all except LookupKey)
+	Delimiter rune     // Reference CSV delimiter (defaults to ',')
+}
+
+// loadedTable is a reference CSV indexed by its lookup key
+type loadedTable struct {
+	spec    LookupTable
+	columns []string
+	index   map[string]map[string]string
+	modTime time.Time
+	size    int64
+}
+
+// Enricher joins reference CSV columns into each record. Reference files are loaded at
+// startup and reloaded automatically when their modification time or size changes.
+// Columns already present in the input are never overwritten; unmatched keys get "".
+type Enricher struct {
+	tables []*loadedTable
+}
+
+// NewEnricher loads every lookup table, failing fast on unreadable or invalid references
+func NewEnricher(tables []LookupTable) (*Enricher, error) {
+	e := &Enricher{}
+	for _, spec := range tables {
+		if spec.Path == "" || spec.Key == "" {
+			return nil, fmt.Errorf("lookup table requires path and key")
+		}
+		if spec.LookupKey == "" {
+			spec.LookupKey = spec.Key
+		}
+		if spec.Delimiter == 0 {
+			spec.Delimiter = ','
+		}
+		table := &loadedTable{spec: spec}
+		if err := table.load(); err != nil {
+			return nil, err
+		}
+		e.tables = append(e.tables, table)
+	}
+	return e, nil
+}
+
+// Name identifies the transform in logs and errors
+func (e *Enricher) Name() string {
+	return "lookup"
+}
+
+// Apply adds reference columns to every row, reloading changed reference files first
+func (e *Enricher) Apply(result *parser.ParseResult) error {
+	for _, table := range e.tables {
+		if err := table.reloadIfChanged(); err != nil {
+			// Keep serving the last good copy rather than failing every file
+			log.Printf("Warning: failed to reload lookup table %s, using previous version: %v", table.spec.Path, err)
+		}
+
+		added := make([]string, 0, len(table.columns))
+		for _, column := range table.columns {
+			if !containsKey(result.Headers, column) {
+				added = append(added, column)
+			}
+		}
+		if len(added) == 0 {
+			continue
+		}
+
+		keys := append(append([]string{}, result.Headers...), added...)
+		result.Headers = keys
+		for i := range result.Rows {
+			row := &result.Rows[i]
+			match := table.index[row.Values[table.spec.Key]]
+			for _, column := range added {
+				row.Values[column] = match[column] // "" when unmatched (ADR-003)
+			}
+			row.Keys = append(append([]string{}, row.Keys...), added...)
+		}
+	}
+	return nil
+}
+
+// reloadIfChanged reloads the reference file when its modification time or size changed
+func (t *loadedTable) reloadIfChanged() error {
+	info, err := os.Stat(t.spec.Path)
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(t.modTime) && info.Size() == t.size {
+		return nil
+	}
+	if err := t.load(); err != nil {
+		return err
+	}
+	log.Printf("Reloaded lookup table %s (%d keys)", t.spec.Path, len(t.index))
+	return nil
+}
+
+// load reads and indexes the reference CSV
+func (t *loadedTable) load() error {
+	info, err := os.Stat(t.spec.Path)
+	if err != nil {
+		return fmt.Errorf("lookup table %s: %w", t.spec.Path, err)
+	}
+
+	result, err := parser.New(t.spec.Delimiter, '"', true).ParseWithOrder(t.spec.Path)
+	if err != nil {
+		return fmt.Errorf("lookup table %s: %w", t.spec.Path, err)
+	}
+	if !containsKey(result.Headers, t.spec.LookupKey) {
+		return fmt.Errorf("lookup table %s: key column '%s' not found", t.spec.Path, t.spec.LookupKey)
+	}
+
+	columns := t.spec.Columns
+	if len(columns) == 0 {
+		for _, header := range result.Headers {
+			if header != t.spec.LookupKey {
+				columns = append(columns, header)
+			}
+		}
+	}
+	for _, column := range columns {
+		if !containsKey(result.Headers, column) {
+			return fmt.Errorf("lookup table %s: column '%s' not found", t.spec.Path, column)
+		}
+	}
+
+	index := make(map[string]map[string]string, len(result.Rows))
+	for _, row := range result.Rows {
+		key := row.Values[t.spec.LookupKey]
+		if _, exists := index[key]; !exists { // First occurrence wins
+			index[key] = row.Values
+		}
+	}
+
+	t.columns = columns
+	t.index = index
+	t.modTime = info.ModTime()
+	t.size = info.Size()
+	return nil
+}
+
+// containsKey reports whether keys contains key
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package transform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeReference writes a reference CSV and returns its path
+func writeReference(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "stores.csv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write reference file: %v", err)
+	}
+	return path
+}
+
+// TestEnricherApply validates joining reference columns by key
+func TestEnricherApply(t *testing.T) {
+	path := writeReference(t, t.TempDir(), "store_id,region,manager\nS1,North,Ann\nS2,South,Ben\n")
+
+	enricher, err := NewEnricher([]LookupTable{{Path: path, Key: "store", LookupKey: "store_id", Columns: []string{"region"}}})
+	if err != nil {
+		t.Fatalf("NewEnricher failed: %v", err)
+	}
+
+	result := newTestResult([]string{"store", "sales"},
+		[]string{"S1", "100"},
+		[]string{"S9", "5"},
+	)
+	if err := enricher.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if result.Rows[0].Values["region"] != "North" {
+		t.Errorf("Expected region 'North', got %q", result.Rows[0].Values["region"])
+	}
+	if v, ok := result.Rows[1].Values["region"]; !ok || v != "" {
+		t.Errorf("Expected empty region for unmatched key, got %q (present: %t)", v, ok)
+	}
+	if _, ok := result.Rows[0].Values["manager"]; ok {
+		t.Error("Expected only configured columns to be added")
+	}
+	if len(result.Headers) != 3 || result.Headers[2] != "region" {
+		t.Errorf("Expected region appended to headers, got %v", result.Headers)
+	}
+	if len(result.Rows[0].Keys) != 3 || result.Rows[0].Keys[2] != "region" {
+		t.Errorf("Expected region appended to row keys, got %v", result.Rows[0].Keys)
+	}
+}
+
+// TestEnricherReloadOnChange validates reference files are reloaded when modified
+func TestEnricherReloadOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := writeReference(t, dir, "store_id,region\nS1,North\n")
+
+	enricher, err := NewEnricher([]LookupTable{{Path: path, Key: "store_id"}})
+	if err != nil {
+		t.Fatalf("NewEnricher failed: %v", err)
+	}
+
+	writeReference(t, dir, "store_id,region\nS1,Northeast\n")
+	// Ensure the modification time differs even on coarse-grained file systems
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Failed to update modification time: %v", err)
+	}
+
+	result := newTestResult([]string{"store_id"}, []string{"S1"})
+	if err := enricher.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Rows[0].Values["region"] != "Northeast" {
+		t.Errorf("Expected reloaded region 'Northeast', got %q", result.Rows[0].Values["region"])
+	}
+}
+
+// TestEnricherDoesNotOverwriteInput validates input columns take precedence
+func TestEnricherDoesNotOverwriteInput(t *testing.T) {
+	path := writeReference(t, t.TempDir(), "store_id,region\nS1,North\n")
+
+	enricher, err := NewEnricher([]LookupTable{{Path: path, Key: "store_id"}})
+	if err != nil {
+		t.Fatalf("NewEnricher failed: %v", err)
+	}
+
+	result := newTestResult([]string{"store_id", "region"}, []string{"S1", "Local"})
+	if err := enricher.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if result.Rows[0].Values["region"] != "Local" {
+		t.Errorf("Expected input value 'Local' preserved, got %q", result.Rows[0].Values["region"])
+	}
+	if len(result.Headers) != 2 {
+		t.Errorf("Expected no duplicate headers, got %v", result.Headers)
+	}
+}
+
+// TestNewEnricherValidation validates fail-fast loading errors
+func TestNewEnricherValidation(t *testing.T) {
+	dir := t.TempDir()
+	path := writeReference(t, dir, "store_id,region\nS1,North\n")
+
+	testCases := []struct {
+		name  string
+		table LookupTable
+	}{
+		{"missing file", LookupTable{Path: filepath.Join(dir, "missing.csv"), Key: "store_id"}},
+		{"missing key column", LookupTable{Path: path, Key: "store"}},
+		{"missing output column", LookupTable{Path: path, Key: "store_id", Columns: []string{"country"}}},
+		{"missing path", LookupTable{Key: "store_id"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewEnricher([]LookupTable{tc.table}); err == nil {
+				t.Error("Expected error, got success")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package transform
+
+import (
+	"fmt"
+
+	"csv2json/internal/parser"
+)
+
+// DefaultGroupChildKey is the field holding nested child rows when none is configured
+const DefaultGroupChildKey = "items"
+
+// GroupSpec describes how flat parent/child rows are nested
+type GroupSpec struct {
+	By            []string // Columns identifying a parent (e.g. order_id)
+	ParentColumns []string // Columns kept on the parent object (defaults to By)
+	ChildKey      string   // Field name of the nested child array (defaults to DefaultGroupChildKey)
+}
+
+// Grouper nests rows sharing the same By values into a single parent object with an
+// array of child objects, e.g. an order header with its line items. Parent values are
+// taken from the first row of each group; children hold the remaining columns.
+type Grouper struct {
+	spec GroupSpec
+}
+
+// NewGrouper validates spec and creates a grouper
+func NewGrouper(spec GroupSpec) (*Grouper, error) {
+	if len(spec.By) == 0 {
+		return nil, fmt.Errorf("at least one group-by column is required")
+	}
+	if len(spec.ParentColumns) == 0 {
+		spec.ParentColumns = spec.By
+	}
+	for _, column := range spec.By {
+		if !containsKey(spec.ParentColumns, column) {
+			return nil, fmt.Errorf("group-by column '%s' must be included in parent columns", column)
+		}
+	}
+	if spec.ChildKey == "" {
+		spec.ChildKey = DefaultGroupChildKey
+	}
+	if containsKey(spec.ParentColumns, spec.ChildKey) {
+		return nil, fmt.Errorf("child key '%s' conflicts with a parent column", spec.ChildKey)
+	}
+	return &Grouper{spec: spec}, nil
+}
+
+// Name identifies the transform in logs and errors
+func (g *Grouper) Name() string {
+	return "groupBy"
+}
+
+// Apply replaces the flat rows with one nested parent row per group, in first-seen order
+func (g *Grouper) Apply(result *parser.ParseResult) error {
+	if len(result.Rows) == 0 {
+		return nil
+	}
+	for _, column := range g.spec.ParentColumns {
+		if !containsKey(result.Headers, column) {
+			return fmt.Errorf("group column '%s' not found", column)
+		}
+	}
+
+	childKeys := make([]string, 0, len(result.Headers))
+	for _, header := range result.Headers {
+		if !containsKey(g.spec.ParentColumns, header) {
+			childKeys = append(childKeys, header)
+		}
+	}
+	parentKeys := append(append([]string{}, g.spec.ParentColumns...), g.spec.ChildKey)
+
+	dedup := &Deduplicator{keys: g.spec.By}
+	groupIndex := make(map[string]int)
+	var parents []parser.OrderedMap
+
+	for _, row := range result.Rows {
+		key := dedup.rowKey(row)
+		idx, exists := groupIndex[key]
+		if !exists {
+			parent := parser.OrderedMap{
+				Keys:   parentKeys,
+				Values: make(map[string]string, len(g.spec.ParentColumns)),
+				Nested: map[string][]parser.OrderedMap{g.spec.ChildKey: {}},
+			}
+			for _, column := range g.spec.ParentColumns {
+				parent.Values[column] = row.Values[column]
+			}
+			idx = len(parents)
+			groupIndex[key] = idx
+			parents = append(parents, parent)
+		}
+
+		child := parser.OrderedMap{Keys: childKeys, Values: make(map[string]string, len(childKeys))}
+		for _, column := range childKeys {
+			child.Values[column] = row.Values[column]
+		}
+		parents[idx].Nested[g.spec.ChildKey] = append(parents[idx].Nested[g.spec.ChildKey], child)
+	}
+
+	result.Headers = parentKeys
+	result.Rows = parents
+	return nil
+}
@@ -0,0 +1,104 @@
+package transform
+
+import (
+	"encoding/json"
+	"testing"
+
+	"csv2json/internal/converter"
+)
+
+// TestGrouperApply validates nesting order lines under order headers
+func TestGrouperApply(t *testing.T) {
+	result := newTestResult([]string{"order_id", "customer", "sku", "qty"},
+		[]string{"O1", "Alice", "A", "1"},
+		[]string{"O2", "Bob", "B", "2"},
+		[]string{"O1", "Alice", "C", "3"},
+	)
+
+	g, err := NewGrouper(GroupSpec{By: []string{"order_id"}, ParentColumns: []string{"order_id", "customer"}, ChildKey: "lines"})
+	if err != nil {
+		t.Fatalf("NewGrouper failed: %v", err)
+	}
+	if err := g.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if len(result.Rows) != 2 {
+		t.Fatalf("Expected 2 groups, got %d", len(result.Rows))
+	}
+	if !result.HasNested() {
+		t.Error("Expected result to report nested rows")
+	}
+
+	first := result.Rows[0]
+	if first.Values["order_id"] != "O1" || first.Values["customer"] != "Alice" {
+		t.Errorf("Unexpected parent values: %v", first.Values)
+	}
+	lines := first.Nested["lines"]
+	if len(lines) != 2 || lines[1].Values["sku"] != "C" {
+		t.Fatalf("Expected 2 child lines with second sku 'C', got %+v", lines)
+	}
+	if len(lines[0].Keys) != 2 || lines[0].Keys[0] != "sku" {
+		t.Errorf("Expected child keys [sku qty], got %v", lines[0].Keys)
+	}
+}
+
+// TestGrouperRendersNestedJSON validates the converter renders grouped output in order
+func TestGrouperRendersNestedJSON(t *testing.T) {
+	result := newTestResult([]string{"order_id", "sku"},
+		[]string{"O1", "A"},
+		[]string{"O1", "B"},
+	)
+
+	g, _ := NewGrouper(GroupSpec{By: []string{"order_id"}})
+	if err := g.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	jsonBytes, err := converter.New().ToJSONOrdered(result)
+	if err != nil {
+		t.Fatalf("ToJSONOrdered failed: %v", err)
+	}
+
+	expected := `[
+  {
+    "order_id": "O1",
+    "items": [
+      {
+        "sku": "A"
+      },
+      {
+        "sku": "B"
+      }
+    ]
+  }
+]`
+	if string(jsonBytes) != expected {
+		t.Errorf("Unexpected nested JSON:\n%s\nexpected:\n%s", jsonBytes, expected)
+	}
+
+	var decoded []map[string]interface{}
+	if err := json.Unmarshal(jsonBytes, &decoded); err != nil {
+		t.Fatalf("Generated JSON is invalid: %v", err)
+	}
+}
+
+// TestNewGrouperValidation validates grouping spec errors
+func TestNewGrouperValidation(t *testing.T) {
+	testCases := []struct {
+		name string
+		spec GroupSpec
+	}{
+		{"no group columns", GroupSpec{}},
+		{"group column not in parent", GroupSpec{By: []string{"id"}, ParentColumns: []string{"name"}}},
+		{"child key conflict", GroupSpec{By: []string{"id"}, ChildKey: "id"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewGrouper(tc.spec); err == nil {
+				t.Error("Expected error, got success")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package transform
+
+import (
+	"testing"
+)
+
+// TestColumnHasher validates salted hashing is deterministic, salted and skips empty values
+func TestColumnHasher(t *testing.T) {
+	result := newTestResult([]string{"customer_id", "email", "amount"},
+		[]string{"C001", "alice@example.com", "10"},
+		[]string{"C001", "", "20"},
+	)
+
+	hasher, err := NewColumnHasher([]string{"customer_id", "email"}, "pepper")
+	if err != nil {
+		t.Fatalf("NewColumnHasher failed: %v", err)
+	}
+	if err := hasher.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	expected := hasher.Hash("C001")
+	if len(expected) != 64 {
+		t.Fatalf("Expected 64-character hex digest, got %q", expected)
+	}
+
+	if result.Rows[0].Values["customer_id"] != expected || result.Rows[1].Values["customer_id"] != expected {
+		t.Errorf("Expected identical hashes for identical values (joinable), got %q and %q",
+			result.Rows[0].Values["customer_id"], result.Rows[1].Values["customer_id"])
+	}
+	if result.Rows[1].Values["email"] != "" {
+		t.Errorf("Expected empty value to stay empty, got %q", result.Rows[1].Values["email"])
+	}
+	if result.Rows[0].Values["amount"] != "10" {
+		t.Errorf("Expected unconfigured column untouched, got %q", result.Rows[0].Values["amount"])
+	}
+
+	resalted, _ := NewColumnHasher([]string{"customer_id"}, "salt")
+	if resalted.Hash("C001") == expected {
+		t.Error("Expected salt to change the hash")
+	}
+}
+
+// TestColumnHasherKnownDigest validates the digest against a known HMAC-SHA256 value
+func TestColumnHasherKnownDigest(t *testing.T) {
+	hasher, _ := NewColumnHasher([]string{"id"}, "key")
+	// HMAC-SHA256("key", "The quick brown fox jumps over the lazy dog")
+	if got := hasher.Hash("The quick brown fox jumps over the lazy dog"); got != "f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8" {
+		t.Errorf("Unexpected digest: %s", got)
+	}
+}
+
+// TestNewColumnHasherValidation validates rejection of empty column lists and salts
+func TestNewColumnHasherValidation(t *testing.T) {
+	if _, err := NewColumnHasher(nil, "salt"); err == nil {
+		t.Error("Expected error for empty column list, got success")
+	}
+	if _, err := NewColumnHasher([]string{""}, "salt"); err == nil {
+		t.Error("Expected error for empty column name, got success")
+	}
+	if _, err := NewColumnHasher([]string{"id"}, ""); err == nil {
+		t.Error("Expected error for empty salt, got success")
+	}
+}
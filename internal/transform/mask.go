@@ -0,0 +1,150 @@
+package transform
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+
+	"csv2json/internal/parser"
+)
+
+// Masking modes
+const (
+	MaskModeFull    = "full"    // Replace every character with the mask character
+	MaskModePartial = "partial" // Mask all but the last Keep characters (e.g. card last 4)
+	MaskModeFormat  = "format"  // Preserve format: digits -> 0, letters -> X/x, separators kept; last Keep alphanumerics visible
+	MaskModeDrop    = "drop"    // Remove the column from the output entirely
+)
+
+// DefaultMaskChar is used when a rule does not specify a mask character
+const DefaultMaskChar = '*'
+
+// MaskRule describes how a single column is masked
+type MaskRule struct {
+	Column   string
+	Mode     string
+	Keep     int  // Trailing characters left visible (partial and format modes)
+	MaskChar rune // Mask character for full/partial modes (DefaultMaskChar if zero)
+}
+
+// Masker applies column-level masking and anonymization rules
+type Masker struct {
+	rules []MaskRule
+}
+
+// NewMasker validates rules and creates a masker. Defaults are applied to a copy, so
+// the caller's rules are left unchanged.
+func NewMasker(rules []MaskRule) (*Masker, error) {
+	rules = append([]MaskRule(nil), rules...)
+	for i, rule := range rules {
+		if rule.Column == "" {
+			return nil, fmt.Errorf("mask rule %d: column is required", i)
+		}
+		switch rule.Mode {
+		case MaskModeFull, MaskModePartial, MaskModeFormat, MaskModeDrop:
+		default:
+			return nil, fmt.Errorf("mask rule for column '%s': unsupported mode '%s' (supported: full, partial, format, drop)", rule.Column, rule.Mode)
+		}
+		if rule.Keep < 0 {
+			return nil, fmt.Errorf("mask rule for column '%s': keep must be >= 0, got %d", rule.Column, rule.Keep)
+		}
+		if rule.Mode == MaskModePartial && rule.Keep == 0 {
+			rules[i].Keep = 4
+		}
+		if rule.MaskChar == 0 {
+			rules[i].MaskChar = DefaultMaskChar
+		}
+	}
+	return &Masker{rules: rules}, nil
+}
+
+// Name identifies the transform in logs and errors
+func (m *Masker) Name() string {
+	return "mask"
+}
+
+// Apply masks or drops the configured columns in every row
+func (m *Masker) Apply(result *parser.ParseResult) error {
+	dropped := make(map[string]bool)
+	for _, rule := range m.rules {
+		if rule.Mode == MaskModeDrop {
+			dropped[rule.Column] = true
+			continue
+		}
+		for _, row := range result.Rows {
+			if value, ok := row.Values[rule.Column]; ok {
+				row.Values[rule.Column] = rule.mask(value)
+			}
+		}
+	}
+
+	if len(dropped) > 0 {
+		result.Headers = removeKeys(result.Headers, dropped)
+		for i := range result.Rows {
+			result.Rows[i].Keys = removeKeys(result.Rows[i].Keys, dropped)
+			for column := range dropped {
+				delete(result.Rows[i].Values, column)
+			}
+		}
+	}
+	return nil
+}
+
+// mask applies a single non-drop rule to value
+func (r MaskRule) mask(value string) string {
+	runes := []rune(value)
+	switch r.Mode {
+	case MaskModeFull:
+		for i := range runes {
+			runes[i] = r.MaskChar
+		}
+	case MaskModePartial:
+		for i := 0; i < len(runes)-r.Keep; i++ {
+			runes[i] = r.MaskChar
+		}
+	case MaskModeFormat:
+		visible := r.Keep
+		for i := len(runes) - 1; i >= 0; i-- {
+			c := runes[i]
+			if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+				continue
+			}
+			if visible > 0 {
+				visible--
+				continue
+			}
+			switch {
+			case unicode.IsDigit(c):
+				runes[i] = '0'
+			case unicode.IsUpper(c):
+				runes[i] = 'X'
+			default:
+				runes[i] = 'x'
+			}
+		}
+	}
+	return string(runes)
+}
+
+// removeKeys returns a copy of keys without the excluded entries (rows may share key slices)
+func removeKeys(keys []string, excluded map[string]bool) []string {
+	kept := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if !excluded[key] {
+			kept = append(kept, key)
+		}
+	}
+	return kept
+}
+
+// ParseMaskChar converts a configured mask character string to a rune (zero if empty)
+func ParseMaskChar(s string) (rune, error) {
+	if s == "" {
+		return 0, nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) {
+		return 0, fmt.Errorf("mask character must be a single character, got %q", s)
+	}
+	return r, nil
+}
@@ -0,0 +1,100 @@
+package transform
+
+import (
+	"testing"
+)
+
+// TestMaskRuleModes validates each masking mode on representative values
+func TestMaskRuleModes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		rule     MaskRule
+		value    string
+		expected string
+	}{
+		{"full", MaskRule{Mode: MaskModeFull, MaskChar: '*'}, "123-45-6789", "***********"},
+		{"partial last 4", MaskRule{Mode: MaskModePartial, Keep: 4, MaskChar: '*'}, "4111111111111111", "************1111"},
+		{"partial shorter than keep", MaskRule{Mode: MaskModePartial, Keep: 4, MaskChar: '*'}, "12", "12"},
+		{"format ssn", MaskRule{Mode: MaskModeFormat, Keep: 4}, "123-45-6789", "000-00-6789"},
+		{"format mixed case", MaskRule{Mode: MaskModeFormat}, "Ab-12", "Xx-00"},
+		{"unicode", MaskRule{Mode: MaskModeFull, MaskChar: '#'}, "Zoë", "###"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.rule.mask(tc.value); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestMaskerApply validates masking and dropping columns across rows
+func TestMaskerApply(t *testing.T) {
+	result := newTestResult([]string{"name", "ssn", "card", "notes"},
+		[]string{"Alice", "123-45-6789", "4111111111111111", "vip"},
+		[]string{"Bob", "987-65-4321", "5500000000000004", ""},
+	)
+
+	masker, err := NewMasker([]MaskRule{
+		{Column: "ssn", Mode: MaskModeFormat, Keep: 4},
+		{Column: "card", Mode: MaskModePartial},
+		{Column: "notes", Mode: MaskModeDrop},
+		{Column: "missing", Mode: MaskModeFull},
+	})
+	if err != nil {
+		t.Fatalf("NewMasker failed: %v", err)
+	}
+
+	if err := masker.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if result.Rows[0].Values["ssn"] != "000-00-6789" {
+		t.Errorf("Expected masked ssn, got %q", result.Rows[0].Values["ssn"])
+	}
+	if result.Rows[1].Values["card"] != "************0004" {
+		t.Errorf("Expected card with last 4 visible (default keep), got %q", result.Rows[1].Values["card"])
+	}
+	if len(result.Headers) != 3 || result.Headers[2] != "card" {
+		t.Errorf("Expected 'notes' dropped from headers, got %v", result.Headers)
+	}
+	for i, row := range result.Rows {
+		if _, exists := row.Values["notes"]; exists {
+			t.Errorf("Row %d: expected 'notes' to be dropped", i)
+		}
+		if len(row.Keys) != 3 {
+			t.Errorf("Row %d: expected 3 keys, got %v", i, row.Keys)
+		}
+	}
+}
+
+// TestNewMaskerValidation validates rejection of invalid rules
+func TestNewMaskerValidation(t *testing.T) {
+	testCases := []struct {
+		name string
+		rule MaskRule
+	}{
+		{"missing column", MaskRule{Mode: MaskModeFull}},
+		{"unknown mode", MaskRule{Column: "ssn", Mode: "scramble"}},
+		{"negative keep", MaskRule{Column: "ssn", Mode: MaskModePartial, Keep: -1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewMasker([]MaskRule{tc.rule}); err == nil {
+				t.Error("Expected error, got success")
+			}
+		})
+	}
+}
+
+func TestNewMaskerLeavesRulesUnchanged(t *testing.T) {
+	rules := []MaskRule{{Column: "card", Mode: MaskModePartial}}
+	if _, err := NewMasker(rules); err != nil {
+		t.Fatalf("NewMasker failed: %v", err)
+	}
+	if rules[0].Keep != 0 || rules[0].MaskChar != 0 {
+		t.Errorf("Expected the caller's rules to keep their zero values, got %+v", rules[0])
+	}
+}
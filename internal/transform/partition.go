@@ -0,0 +1,38 @@
+package transform
+
+import (
+	"fmt"
+
+	"csv2json/internal/parser"
+)
+
+// Partition is the subset of a parse result sharing one partition column value
+type Partition struct {
+	Value  string
+	Result *parser.ParseResult
+}
+
+// SplitByColumn splits result into one partition per distinct value of column.
+// Partitions are returned in order of first appearance; row order within each is preserved.
+func SplitByColumn(result *parser.ParseResult, column string) ([]Partition, error) {
+	if len(result.Rows) > 0 && !containsKey(result.Headers, column) {
+		return nil, fmt.Errorf("partition column '%s' not found", column)
+	}
+
+	index := make(map[string]int)
+	var partitions []Partition
+	for _, row := range result.Rows {
+		value := row.Values[column]
+		i, exists := index[value]
+		if !exists {
+			i = len(partitions)
+			index[value] = i
+			partitions = append(partitions, Partition{
+				Value:  value,
+				Result: &parser.ParseResult{Headers: result.Headers, Encoding: result.Encoding},
+			})
+		}
+		partitions[i].Result.Rows = append(partitions[i].Result.Rows, row)
+	}
+	return partitions, nil
+}
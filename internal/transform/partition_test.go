@@ -0,0 +1,54 @@
+package transform
+
+import (
+	"testing"
+)
+
+// TestSplitByColumn validates partitions follow first-seen order and keep row order
+func TestSplitByColumn(t *testing.T) {
+	result := newTestResult([]string{"id", "country"},
+		[]string{"1", "DE"},
+		[]string{"2", "FR"},
+		[]string{"3", "DE"},
+		[]string{"4", ""},
+	)
+
+	partitions, err := SplitByColumn(result, "country")
+	if err != nil {
+		t.Fatalf("SplitByColumn failed: %v", err)
+	}
+
+	expected := []struct {
+		value string
+		ids   []string
+	}{
+		{"DE", []string{"1", "3"}},
+		{"FR", []string{"2"}},
+		{"", []string{"4"}},
+	}
+	if len(partitions) != len(expected) {
+		t.Fatalf("Expected %d partitions, got %d", len(expected), len(partitions))
+	}
+	for i, exp := range expected {
+		part := partitions[i]
+		if part.Value != exp.value {
+			t.Errorf("Partition %d: expected value %q, got %q", i, exp.value, part.Value)
+		}
+		if len(part.Result.Rows) != len(exp.ids) {
+			t.Fatalf("Partition %q: expected %d rows, got %d", exp.value, len(exp.ids), len(part.Result.Rows))
+		}
+		for j, id := range exp.ids {
+			if part.Result.Rows[j].Values["id"] != id {
+				t.Errorf("Partition %q row %d: expected id %s, got %s", exp.value, j, id, part.Result.Rows[j].Values["id"])
+			}
+		}
+	}
+}
+
+// TestSplitByColumnMissing validates an error for an unknown partition column
+func TestSplitByColumnMissing(t *testing.T) {
+	result := newTestResult([]string{"id"}, []string{"1"})
+	if _, err := SplitByColumn(result, "country"); err == nil {
+		t.Error("Expected error for missing partition column, got success")
+	}
+}
@@ -0,0 +1,77 @@
+package transform
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"time"
+
+	"csv2json/internal/parser"
+)
+
+// Sampling modes
+const (
+	SampleModeHead   = "head"   // First N rows of the file
+	SampleModeRandom = "random" // Uniform random sample of N rows, kept in file order
+)
+
+// Sampler limits output to a sample of rows, for onboarding new feeds without
+// flooding downstream systems. The source file is still archived in full.
+type Sampler struct {
+	rows int
+	mode string
+	rng  *rand.Rand
+}
+
+// NewSampler creates a sampler emitting at most rows rows using mode (default head)
+func NewSampler(rows int, mode string) (*Sampler, error) {
+	if rows <= 0 {
+		return nil, fmt.Errorf("sample size must be > 0, got %d", rows)
+	}
+	if mode == "" {
+		mode = SampleModeHead
+	}
+	if mode != SampleModeHead && mode != SampleModeRandom {
+		return nil, fmt.Errorf("unsupported sample mode '%s' (supported: head, random)", mode)
+	}
+	return &Sampler{rows: rows, mode: mode, rng: rand.New(rand.NewSource(time.Now().UnixNano()))}, nil
+}
+
+// Name identifies the transform in logs and errors
+func (s *Sampler) Name() string {
+	return "sample"
+}
+
+// Apply reduces the rows to the configured sample
+func (s *Sampler) Apply(result *parser.ParseResult) error {
+	total := len(result.Rows)
+	if total <= s.rows {
+		return nil
+	}
+
+	if s.mode == SampleModeHead {
+		result.Rows = result.Rows[:s.rows]
+	} else {
+		// Reservoir sampling over row indices, then restore file order
+		picked := make([]int, s.rows)
+		for i := range picked {
+			picked[i] = i
+		}
+		for i := s.rows; i < total; i++ {
+			if j := s.rng.Intn(i + 1); j < s.rows {
+				picked[j] = i
+			}
+		}
+		sort.Ints(picked)
+
+		sampled := make([]parser.OrderedMap, len(picked))
+		for i, idx := range picked {
+			sampled[i] = result.Rows[idx]
+		}
+		result.Rows = sampled
+	}
+
+	log.Printf("Sampled %d of %d rows (mode: %s)", len(result.Rows), total, s.mode)
+	return nil
+}
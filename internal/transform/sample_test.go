@@ -0,0 +1,69 @@
+package transform
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+)
+
+// TestSamplerHead validates head sampling keeps the first N rows
+func TestSamplerHead(t *testing.T) {
+	result := newTestResult([]string{"id"}, []string{"1"}, []string{"2"}, []string{"3"})
+
+	s, err := NewSampler(2, "")
+	if err != nil {
+		t.Fatalf("NewSampler failed: %v", err)
+	}
+	if err := s.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if len(result.Rows) != 2 || result.Rows[1].Values["id"] != "2" {
+		t.Errorf("Expected first 2 rows, got %+v", result.Rows)
+	}
+}
+
+// TestSamplerRandom validates random sampling size, uniqueness and file order
+func TestSamplerRandom(t *testing.T) {
+	var rows [][]string
+	for i := 0; i < 100; i++ {
+		rows = append(rows, []string{fmt.Sprintf("%d", i)})
+	}
+	result := newTestResult([]string{"id"}, rows...)
+
+	s, err := NewSampler(10, SampleModeRandom)
+	if err != nil {
+		t.Fatalf("NewSampler failed: %v", err)
+	}
+	if err := s.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if len(result.Rows) != 10 {
+		t.Fatalf("Expected 10 sampled rows, got %d", len(result.Rows))
+	}
+	previous := -1
+	for _, row := range result.Rows {
+		id, _ := strconv.Atoi(row.Values["id"])
+		if id <= previous {
+			t.Errorf("Expected sampled rows in file order without repeats, got %d after %d", id, previous)
+		}
+		previous = id
+	}
+}
+
+// TestSamplerValidation validates sampling spec errors and small files
+func TestSamplerValidation(t *testing.T) {
+	if _, err := NewSampler(0, ""); err == nil {
+		t.Error("Expected error for zero sample size, got success")
+	}
+	if _, err := NewSampler(5, "tail"); err == nil {
+		t.Error("Expected error for unsupported mode, got success")
+	}
+
+	result := newTestResult([]string{"id"}, []string{"1"})
+	s, _ := NewSampler(5, SampleModeRandom)
+	if err := s.Apply(result); err != nil || len(result.Rows) != 1 {
+		t.Errorf("Expected file smaller than sample to pass through unchanged, got %d rows (err: %v)", len(result.Rows), err)
+	}
+}
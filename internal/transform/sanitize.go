@@ -0,0 +1,55 @@
+package transform
+
+import (
+	"strconv"
+	"strings"
+
+	"csv2json/internal/parser"
+)
+
+// DefaultFormulaPrefix is prepended to values that a spreadsheet would evaluate as a formula
+const DefaultFormulaPrefix = "'"
+
+// formulaTriggers are leading characters that spreadsheet applications interpret as formulas
+const formulaTriggers = "=+-@\t\r"
+
+// FormulaSanitizer neutralizes CSV/formula injection by prefixing values that start with a
+// formula trigger character (=, +, -, @, tab, carriage return). Plain numbers such as "-42"
+// are left untouched so signed numeric data survives unchanged.
+type FormulaSanitizer struct {
+	prefix string
+}
+
+// NewFormulaSanitizer creates a sanitizer using prefix (DefaultFormulaPrefix if empty)
+func NewFormulaSanitizer(prefix string) *FormulaSanitizer {
+	if prefix == "" {
+		prefix = DefaultFormulaPrefix
+	}
+	return &FormulaSanitizer{prefix: prefix}
+}
+
+// Name identifies the transform in logs and errors
+func (s *FormulaSanitizer) Name() string {
+	return "sanitizeFormulas"
+}
+
+// Apply prefixes every value that could be evaluated as a spreadsheet formula
+func (s *FormulaSanitizer) Apply(result *parser.ParseResult) error {
+	for _, row := range result.Rows {
+		for key, value := range row.Values {
+			row.Values[key] = s.Sanitize(value)
+		}
+	}
+	return nil
+}
+
+// Sanitize returns value with the configured prefix if it starts with a formula trigger
+func (s *FormulaSanitizer) Sanitize(value string) string {
+	if value == "" || !strings.ContainsRune(formulaTriggers, rune(value[0])) {
+		return value
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return value
+	}
+	return s.prefix + value
+}
@@ -0,0 +1,67 @@
+package transform
+
+import (
+	"testing"
+
+	"csv2json/internal/parser"
+)
+
+// newTestResult builds a ParseResult from headers and row values
+func newTestResult(headers []string, rows ...[]string) *parser.ParseResult {
+	result := &parser.ParseResult{Headers: headers}
+	for _, values := range rows {
+		row := parser.OrderedMap{Keys: headers, Values: make(map[string]string)}
+		for i, h := range headers {
+			row.Values[h] = values[i]
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result
+}
+
+// TestFormulaSanitizer validates neutralization of formula trigger characters
+func TestFormulaSanitizer(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"equals", "=SUM(A1:A9)", "'=SUM(A1:A9)"},
+		{"plus", "+1+2", "'+1+2"},
+		{"minus formula", "-2+3+cmd|' /C calc'!A0", "'-2+3+cmd|' /C calc'!A0"},
+		{"at", "@SUM(1)", "'@SUM(1)"},
+		{"tab", "\t=1", "'\t=1"},
+		{"negative number", "-42.5", "-42.5"},
+		{"positive number", "+7", "+7"},
+		{"plain text", "hello", "hello"},
+		{"empty", "", ""},
+	}
+
+	s := NewFormulaSanitizer("")
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := s.Sanitize(tc.value); got != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+// TestFormulaSanitizerApply validates sanitization across rows with a custom prefix
+func TestFormulaSanitizerApply(t *testing.T) {
+	result := newTestResult([]string{"name", "note"},
+		[]string{"Alice", "=HYPERLINK(\"http://evil\")"},
+		[]string{"Bob", "fine"},
+	)
+
+	if err := NewPipeline(NewFormulaSanitizer(" ")).Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	if result.Rows[0].Values["note"] != " =HYPERLINK(\"http://evil\")" {
+		t.Errorf("Expected prefixed formula, got %q", result.Rows[0].Values["note"])
+	}
+	if result.Rows[1].Values["note"] != "fine" {
+		t.Errorf("Expected untouched value, got %q", result.Rows[1].Values["note"])
+	}
+}
@@ -0,0 +1,94 @@
+package transform
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"csv2json/internal/parser"
+)
+
+// SchemaField is one declared output field and the value emitted when a file lacks it
+type SchemaField struct {
+	Name    string
+	Default string // Emitted for a missing column ("" per ADR-003 when not set)
+}
+
+// OutputSchema emits every record with exactly the declared fields, in declared order,
+// insulating consumers from upstream column changes. Columns missing from a file get
+// their default; columns present but empty keep their empty value. Columns not in the
+// schema are dropped, with a warning when flagExtra is set, or fail the file when strict
+// is set so accidental format changes are caught at the edge.
+type OutputSchema struct {
+	fields    []SchemaField
+	flagExtra bool
+	strict    bool
+}
+
+// NewOutputSchema creates an output schema, rejecting unnamed and duplicate fields
+func NewOutputSchema(fields []SchemaField, flagExtra, strict bool) (*OutputSchema, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("output schema requires at least one field")
+	}
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field.Name == "" {
+			return nil, fmt.Errorf("output schema field name must not be empty")
+		}
+		if seen[field.Name] {
+			return nil, fmt.Errorf("output schema field '%s' is declared twice", field.Name)
+		}
+		seen[field.Name] = true
+	}
+	return &OutputSchema{fields: fields, flagExtra: flagExtra, strict: strict}, nil
+}
+
+// Name identifies the transform in logs and errors
+func (s *OutputSchema) Name() string {
+	return "outputSchema"
+}
+
+// Apply rebuilds every row with the declared fields
+func (s *OutputSchema) Apply(result *parser.ParseResult) error {
+	names := make([]string, len(s.fields))
+	var missing []string
+	for i, field := range s.fields {
+		names[i] = field.Name
+		if !containsKey(result.Headers, field.Name) {
+			missing = append(missing, field.Name)
+		}
+	}
+	var extra []string
+	for _, header := range result.Headers {
+		if !containsKey(names, header) {
+			extra = append(extra, header)
+		}
+	}
+	if len(extra) > 0 {
+		if s.strict {
+			return fmt.Errorf("column(s) not declared in the output schema: %s", strings.Join(extra, ", "))
+		}
+		if s.flagExtra {
+			log.Printf("Warning: output schema dropped column(s) not in the schema: %s", strings.Join(extra, ", "))
+		}
+	}
+	if len(missing) > 0 {
+		log.Printf("Output schema: emitting default values for missing column(s) %s", strings.Join(missing, ", "))
+	}
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		values := make(map[string]string, len(s.fields))
+		for _, field := range s.fields {
+			value, ok := row.Values[field.Name]
+			if !ok {
+				value = field.Default
+			}
+			values[field.Name] = value
+		}
+		row.Keys = names
+		row.Values = values
+	}
+	result.Headers = names
+	return nil
+}
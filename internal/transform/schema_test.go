@@ -0,0 +1,85 @@
+package transform
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestOutputSchema validates declared field order, defaults for missing columns and dropped extras
+func TestOutputSchema(t *testing.T) {
+	result := newTestResult([]string{"city", "id", "legacy"},
+		[]string{"Berlin", "1", "x"},
+		[]string{"", "2", "y"},
+	)
+	schema, err := NewOutputSchema([]SchemaField{
+		{Name: "id"},
+		{Name: "city"},
+		{Name: "country", Default: "US"},
+	}, true, false)
+	if err != nil {
+		t.Fatalf("NewOutputSchema failed: %v", err)
+	}
+	if err := schema.Apply(result); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+
+	expectedKeys := []string{"id", "city", "country"}
+	if !reflect.DeepEqual(result.Headers, expectedKeys) {
+		t.Errorf("Expected headers %v, got %v", expectedKeys, result.Headers)
+	}
+	expected := []map[string]string{
+		{"id": "1", "city": "Berlin", "country": "US"},
+		{"id": "2", "city": "", "country": "US"},
+	}
+	for i, row := range result.Rows {
+		if !reflect.DeepEqual(row.Keys, expectedKeys) {
+			t.Errorf("Row %d: expected keys %v, got %v", i, expectedKeys, row.Keys)
+		}
+		if !reflect.DeepEqual(row.Values, expected[i]) {
+			t.Errorf("Row %d: expected %v, got %v", i, expected[i], row.Values)
+		}
+	}
+}
+
+// TestNewOutputSchemaErrors validates rejection of invalid schema declarations
+func TestNewOutputSchemaErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		fields []SchemaField
+	}{
+		{"no fields", nil},
+		{"empty name", []SchemaField{{Name: "id"}, {Name: ""}}},
+		{"duplicate", []SchemaField{{Name: "id"}, {Name: "id", Default: "0"}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewOutputSchema(tc.fields, false, false); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
+
+// TestOutputSchemaStrictColumns validates that strict mode fails files with undeclared columns
+func TestOutputSchemaStrictColumns(t *testing.T) {
+	schema, err := NewOutputSchema([]SchemaField{{Name: "id"}, {Name: "country", Default: "US"}}, false, true)
+	if err != nil {
+		t.Fatalf("NewOutputSchema failed: %v", err)
+	}
+
+	// Missing columns are still defaulted in strict mode
+	result := newTestResult([]string{"id"}, []string{"1"})
+	if err := schema.Apply(result); err != nil {
+		t.Fatalf("Expected missing column to be defaulted, got error: %v", err)
+	}
+	if result.Rows[0].Values["country"] != "US" {
+		t.Errorf("Expected default country, got %q", result.Rows[0].Values["country"])
+	}
+
+	result = newTestResult([]string{"id", "country", "region"}, []string{"1", "DE", "EU"})
+	err = schema.Apply(result)
+	if err == nil || !strings.Contains(err.Error(), "region") {
+		t.Errorf("Expected error naming the undeclared column, got %v", err)
+	}
+}
@@ -0,0 +1,322 @@
+package transform
+
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+
+	"csv2json/internal/parser"
+)
+
+// Sort orders and comparison types
+const (
+	SortAsc        = "asc"
+	SortDesc       = "desc"
+	SortTypeString = "string"
+	SortTypeNumber = "number"
+)
+
+// DefaultSortMemoryRows is the default number of rows in each run of an external sort
+const DefaultSortMemoryRows = 100000
+
+// SortKey describes one column of a sort specification
+type SortKey struct {
+	Column     string
+	Descending bool
+	Numeric    bool // Compare as numbers; non-numeric values sort after numbers
+}
+
+// Sorter orders rows by one or more columns. Apply sorts a file held in memory; files
+// converted chunk by chunk are sorted with an ExternalSort, in bounded runs spilled to
+// temporary files and merged back.
+type Sorter struct {
+	keys       []SortKey
+	memoryRows int    // Rows in each external sort run
+	tempDir    string // Folder for external sort runs ("" = system temp folder)
+}
+
+// NewSorter creates a sorter; memoryRows <= 0 uses DefaultSortMemoryRows
+func NewSorter(keys []SortKey, memoryRows int) (*Sorter, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one sort column is required")
+	}
+	for i, key := range keys {
+		if key.Column == "" {
+			return nil, fmt.Errorf("sort key %d: column is required", i)
+		}
+	}
+	if memoryRows <= 0 {
+		memoryRows = DefaultSortMemoryRows
+	}
+	return &Sorter{keys: keys, memoryRows: memoryRows}, nil
+}
+
+// SetTempDir writes external sort runs to dir instead of the system temp folder
+func (s *Sorter) SetTempDir(dir string) {
+	s.tempDir = dir
+}
+
+// ParseSortOrder converts "asc"/"desc" (empty means asc) into a descending flag
+func ParseSortOrder(order string) (bool, error) {
+	switch order {
+	case "", SortAsc:
+		return false, nil
+	case SortDesc:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported sort order '%s' (supported: asc, desc)", order)
+	}
+}
+
+// ParseSortType converts "string"/"number" (empty means string) into a numeric flag
+func ParseSortType(sortType string) (bool, error) {
+	switch sortType {
+	case "", SortTypeString:
+		return false, nil
+	case SortTypeNumber:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unsupported sort type '%s' (supported: string, number)", sortType)
+	}
+}
+
+// Name identifies the transform in logs and errors
+func (s *Sorter) Name() string {
+	return "sort"
+}
+
+// Apply sorts the rows in place; the sort is stable so equal rows keep file order
+func (s *Sorter) Apply(result *parser.ParseResult) error {
+	if len(result.Rows) == 0 {
+		return nil
+	}
+	if err := s.checkColumns(result.Headers); err != nil {
+		return err
+	}
+	s.sortRows(result.Rows)
+	return nil
+}
+
+// checkColumns returns an error if a sort column is not among headers
+func (s *Sorter) checkColumns(headers []string) error {
+	for _, key := range s.keys {
+		if !containsKey(headers, key.Column) {
+			return fmt.Errorf("sort column '%s' not found", key.Column)
+		}
+	}
+	return nil
+}
+
+func (s *Sorter) sortRows(rows []parser.OrderedMap) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		return s.compare(rows[i].Values, rows[j].Values) < 0
+	})
+}
+
+// compare returns -1, 0 or 1 comparing two rows on the sort keys
+func (s *Sorter) compare(a, b map[string]string) int {
+	for _, key := range s.keys {
+		c := compareValues(a[key.Column], b[key.Column], key.Numeric)
+		if key.Descending {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareValues(a, b string, numeric bool) int {
+	if numeric {
+		fa, errA := strconv.ParseFloat(a, 64)
+		fb, errB := strconv.ParseFloat(b, 64)
+		switch {
+		case errA == nil && errB == nil:
+			if fa < fb {
+				return -1
+			} else if fa > fb {
+				return 1
+			}
+			return 0
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		}
+	}
+	if a < b {
+		return -1
+	} else if a > b {
+		return 1
+	}
+	return 0
+}
+
+// ExternalSort sorts the rows of a file added chunk by chunk while holding at most one
+// run of rows in memory: full runs are sorted and spilled to temporary files as JSON
+// lines of row values, then Merge k-way merges them. Close removes the runs.
+type ExternalSort struct {
+	sorter *Sorter
+	keys   []string            // Column keys of the rows
+	buffer []parser.OrderedMap // Rows of the run being collected
+	runs   []*os.File
+	rows   int
+}
+
+// NewExternalSort starts an external sort of a file's rows
+func (s *Sorter) NewExternalSort() *ExternalSort {
+	return &ExternalSort{sorter: s}
+}
+
+// Add adds a chunk of rows, spilling a sorted run whenever a run is full
+func (e *ExternalSort) Add(chunk *parser.ParseResult) error {
+	if len(chunk.Rows) == 0 {
+		return nil
+	}
+	if e.keys == nil {
+		if err := e.sorter.checkColumns(chunk.Headers); err != nil {
+			return err
+		}
+		e.keys = chunk.Rows[0].Keys
+	}
+	for _, row := range chunk.Rows {
+		e.buffer = append(e.buffer, row)
+		e.rows++
+		if len(e.buffer) >= e.sorter.memoryRows {
+			if err := e.spill(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// spill sorts the buffered rows and writes them to a new run
+func (e *ExternalSort) spill() error {
+	e.sorter.sortRows(e.buffer)
+	f, err := os.CreateTemp(e.sorter.tempDir, "csv2json-sort-*.jsonl")
+	if err != nil {
+		return fmt.Errorf("failed to create sort run: %w", err)
+	}
+	e.runs = append(e.runs, f)
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, row := range e.buffer {
+		if err := enc.Encode(row.Values); err != nil {
+			return fmt.Errorf("failed to write sort run: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write sort run: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to rewind sort run: %w", err)
+	}
+	e.buffer = nil
+	return nil
+}
+
+// Merge passes the sorted rows to fn in chunks of up to chunkRows rows. Ties are
+// broken by run order to keep the sort stable.
+func (e *ExternalSort) Merge(chunkRows int, fn func(rows []parser.OrderedMap) error) error {
+	if len(e.buffer) > 0 {
+		if err := e.spill(); err != nil {
+			return err
+		}
+	}
+	log.Printf("Sorting %d rows externally in %d runs of up to %d rows", e.rows, len(e.runs), e.sorter.memoryRows)
+
+	h := &runHeap{sorter: e.sorter}
+	for i, f := range e.runs {
+		r := &sortRun{index: i, dec: json.NewDecoder(bufio.NewReader(f))}
+		if err := r.next(); err != nil {
+			return err
+		}
+		if r.current != nil {
+			h.runs = append(h.runs, r)
+		}
+	}
+	heap.Init(h)
+
+	chunk := make([]parser.OrderedMap, 0, chunkRows)
+	for h.Len() > 0 {
+		r := h.runs[0]
+		chunk = append(chunk, parser.OrderedMap{Keys: e.keys, Values: r.current})
+		if err := r.next(); err != nil {
+			return err
+		}
+		if r.current == nil {
+			heap.Pop(h)
+		} else {
+			heap.Fix(h, 0)
+		}
+		if len(chunk) == chunkRows {
+			if err := fn(chunk); err != nil {
+				return err
+			}
+			chunk = make([]parser.OrderedMap, 0, chunkRows)
+		}
+	}
+	if len(chunk) > 0 {
+		return fn(chunk)
+	}
+	return nil
+}
+
+// Close removes the spilled runs
+func (e *ExternalSort) Close() {
+	for _, f := range e.runs {
+		f.Close()
+		os.Remove(f.Name())
+	}
+	e.runs = nil
+}
+
+// sortRun reads rows back from one spilled run
+type sortRun struct {
+	index   int
+	dec     *json.Decoder
+	current map[string]string // nil once the run is exhausted
+}
+
+func (r *sortRun) next() error {
+	var values map[string]string
+	if err := r.dec.Decode(&values); err != nil {
+		if err == io.EOF {
+			r.current = nil
+			return nil
+		}
+		return fmt.Errorf("failed to read sort run: %w", err)
+	}
+	r.current = values
+	return nil
+}
+
+// runHeap orders runs by their current row for the k-way merge
+type runHeap struct {
+	runs   []*sortRun
+	sorter *Sorter
+}
+
+func (h *runHeap) Len() int { return len(h.runs) }
+func (h *runHeap) Less(i, j int) bool {
+	if c := h.sorter.compare(h.runs[i].current, h.runs[j].current); c != 0 {
+		return c < 0
+	}
+	return h.runs[i].index < h.runs[j].index
+}
+func (h *runHeap) Swap(i, j int)      { h.runs[i], h.runs[j] = h.runs[j], h.runs[i] }
+func (h *runHeap) Push(x interface{}) { h.runs = append(h.runs, x.(*sortRun)) }
+func (h *runHeap) Pop() interface{} {
+	last := h.runs[len(h.runs)-1]
+	h.runs = h.runs[:len(h.runs)-1]
+	return last
+}
@@ -0,0 +1,122 @@
+package transform
+
+import (
+	"fmt"
+	"testing"
+
+	"csv2json/internal/parser"
+)
+
+// TestSorterApply validates multi-column ascending/descending and numeric ordering
+func TestSorterApply(t *testing.T) {
+	testCases := []struct {
+		name     string
+		keys     []SortKey
+		expected []string
+	}{
+		{"string ascending", []SortKey{{Column: "amount"}}, []string{"c", "a", "d", "b"}},
+		{"numeric ascending", []SortKey{{Column: "amount", Numeric: true}}, []string{"a", "d", "c", "b"}},
+		{"region then amount descending", []SortKey{{Column: "region"}, {Column: "amount", Descending: true, Numeric: true}}, []string{"c", "a", "b", "d"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := newTestResult([]string{"id", "region", "amount"},
+				[]string{"a", "EU", "9"},
+				[]string{"b", "US", "n/a"},
+				[]string{"c", "EU", "10"},
+				[]string{"d", "US", "9"},
+			)
+
+			s, err := NewSorter(tc.keys, 0)
+			if err != nil {
+				t.Fatalf("NewSorter failed: %v", err)
+			}
+			if err := s.Apply(result); err != nil {
+				t.Fatalf("Apply failed: %v", err)
+			}
+
+			for i, id := range tc.expected {
+				if result.Rows[i].Values["id"] != id {
+					t.Errorf("Row %d: expected id %s, got %s", i, id, result.Rows[i].Values["id"])
+				}
+			}
+		})
+	}
+}
+
+// TestSorterExternal validates rows added in chunks merge from spilled runs into the
+// same stable order as an in-memory sort, in chunks of the requested size
+func TestSorterExternal(t *testing.T) {
+	var rows [][]string
+	for i := 0; i < 250; i++ {
+		rows = append(rows, []string{fmt.Sprintf("%03d", i), fmt.Sprintf("%d", (i*37)%10)})
+	}
+	inMemory := newTestResult([]string{"id", "bucket"}, rows...)
+
+	keys := []SortKey{{Column: "bucket", Numeric: true}}
+	sorter, _ := NewSorter(keys, 40)
+	if err := sorter.Apply(inMemory); err != nil {
+		t.Fatalf("In-memory sort failed: %v", err)
+	}
+
+	external := sorter.NewExternalSort()
+	defer external.Close()
+	for start := 0; start < len(rows); start += 30 {
+		end := min(start+30, len(rows))
+		if err := external.Add(newTestResult([]string{"id", "bucket"}, rows[start:end]...)); err != nil {
+			t.Fatalf("Add failed: %v", err)
+		}
+	}
+	if len(external.runs) != 6 {
+		t.Errorf("Expected 6 spilled runs of up to 40 rows, got %d", len(external.runs))
+	}
+
+	var merged []string
+	err := external.Merge(100, func(chunk []parser.OrderedMap) error {
+		if len(chunk) > 100 {
+			t.Errorf("Expected chunks of up to 100 rows, got %d", len(chunk))
+		}
+		if len(chunk[0].Keys) != 2 {
+			t.Errorf("Expected merged rows to keep column keys, got %v", chunk[0].Keys)
+		}
+		for _, row := range chunk {
+			merged = append(merged, row.Values["id"])
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Merge failed: %v", err)
+	}
+
+	if len(merged) != len(inMemory.Rows) {
+		t.Fatalf("Expected %d rows, got %d", len(inMemory.Rows), len(merged))
+	}
+	for i, row := range inMemory.Rows {
+		if merged[i] != row.Values["id"] {
+			t.Fatalf("Row %d: expected id %s, got %s", i, row.Values["id"], merged[i])
+		}
+	}
+
+	if err := sorter.NewExternalSort().Add(newTestResult([]string{"id"}, []string{"1"})); err == nil {
+		t.Error("Expected error for a missing sort column, got success")
+	}
+}
+
+// TestSorterValidation validates sort spec errors
+func TestSorterValidation(t *testing.T) {
+	if _, err := NewSorter(nil, 0); err == nil {
+		t.Error("Expected error for empty sort spec, got success")
+	}
+	if _, err := ParseSortOrder("up"); err == nil {
+		t.Error("Expected error for unsupported sort order, got success")
+	}
+	if _, err := ParseSortType("date"); err == nil {
+		t.Error("Expected error for unsupported sort type, got success")
+	}
+
+	s, _ := NewSorter([]SortKey{{Column: "missing"}}, 0)
+	if err := s.Apply(newTestResult([]string{"id"}, []string{"1"})); err == nil {
+		t.Error("Expected error for missing sort column, got success")
+	}
+}
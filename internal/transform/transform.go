@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"fmt"
+
+	"csv2json/internal/parser"
+)
+
+// Transform modifies parsed rows between parsing and output
+type Transform interface {
+	Name() string
+	Apply(result *parser.ParseResult) error
+}
+
+// Pipeline applies a sequence of transforms in configuration order
+type Pipeline struct {
+	transforms []Transform
+}
+
+// NewPipeline creates a pipeline from the given transforms
+func NewPipeline(transforms ...Transform) *Pipeline {
+	return &Pipeline{transforms: transforms}
+}
+
+// Len returns the number of transforms in the pipeline
+func (p *Pipeline) Len() int {
+	return len(p.transforms)
+}
+
+// Names returns the transform names in application order
+func (p *Pipeline) Names() []string {
+	names := make([]string, len(p.transforms))
+	for i, t := range p.transforms {
+		names[i] = t.Name()
+	}
+	return names
+}
+
+// Split returns the transforms before the first one named name, that transform and
+// the transforms after it. The transform is nil, and after empty, when none is named name.
+func (p *Pipeline) Split(name string) (*Pipeline, Transform, *Pipeline) {
+	for i, t := range p.transforms {
+		if t.Name() == name {
+			return NewPipeline(p.transforms[:i]...), t, NewPipeline(p.transforms[i+1:]...)
+		}
+	}
+	return p, nil, NewPipeline()
+}
+
+// Apply runs every transform against result in order, stopping at the first error
+func (p *Pipeline) Apply(result *parser.ParseResult) error {
+	for _, t := range p.transforms {
+		if err := t.Apply(result); err != nil {
+			return fmt.Errorf("transform %s failed: %w", t.Name(), err)
+		}
+	}
+	return nil
+}
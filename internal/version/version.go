@@ -1,45 +1,75 @@
 package version
 
 import (
-	"os"
+	"encoding/json"
+	"runtime"
+	"runtime/debug"
 	"strings"
+
+	"csv2json"
 )
 
-// Version is the fallback version if VERSION file cannot be read
-// In normal operation, version is read from VERSION file via GetVersion()
+// Version is the fallback version if no version was embedded at build time
+// In normal operation, the VERSION file is embedded into the binary via go:embed
 // This constant should remain "unknown" - do not hardcode versions here
 const Version = "unknown"
 
-// BuildDate is set at compile time via -ldflags
+// BuildDate is set at compile time via -ldflags (default: VCS commit time from build info)
 var BuildDate = "unknown"
 
-// GitCommit is set at compile time via -ldflags
+// GitCommit is set at compile time via -ldflags (default: VCS revision from build info)
 var GitCommit = "unknown"
 
-// ReadVersionFromFile reads the VERSION file from project root
-// Falls back to const Version if file cannot be read
-func ReadVersionFromFile() string {
-	// Try multiple paths to find VERSION file
-	paths := []string{
-		"VERSION",
-		"../VERSION",
-		"../../VERSION",
-		"../../../VERSION",
-	}
+// Info is the version and build metadata of the running binary
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+	Modified  bool   `json:"modified,omitempty"` // Built from a working tree with uncommitted changes
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
 
-	for _, path := range paths {
-		if content, err := os.ReadFile(path); err == nil {
-			return strings.TrimSpace(string(content))
-		}
+// GetVersion returns the embedded VERSION, falling back to the module version
+// recorded by `go install` and finally to const Version. It does not depend on
+// the working directory.
+func GetVersion() string {
+	if v := csv2json.Version(); v != "" {
+		return v
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+		return strings.TrimPrefix(bi.Main.Version, "v")
 	}
-
-	// Fallback to const if file not found
 	return Version
 }
 
-// GetVersion returns the version, preferring VERSION file over const
-func GetVersion() string {
-	return ReadVersionFromFile()
+// GetInfo returns version and build metadata. Values set via -ldflags take
+// precedence over the VCS details Go records in the binary's build info.
+func GetInfo() Info {
+	info := Info{
+		Version:   GetVersion(),
+		GitCommit: GitCommit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.GitCommit == "unknown" && len(setting.Value) >= 7 {
+					info.GitCommit = setting.Value[:7]
+				}
+			case "vcs.time":
+				if info.BuildDate == "unknown" {
+					info.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				info.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return info
 }
 
 // GetVersionInfo returns formatted version information
@@ -49,12 +79,23 @@ func GetVersionInfo() string {
 
 // GetFullVersionInfo returns detailed version information including build metadata
 func GetFullVersionInfo() string {
-	info := "csv2json v" + GetVersion()
-	if GitCommit != "unknown" {
-		info += " (commit: " + GitCommit + ")"
+	info := GetInfo()
+	text := "csv2json v" + info.Version
+	if info.GitCommit != "unknown" {
+		text += " (commit: " + info.GitCommit
+		if info.Modified {
+			text += ", modified"
+		}
+		text += ")"
 	}
-	if BuildDate != "unknown" {
-		info += " (built: " + BuildDate + ")"
+	if info.BuildDate != "unknown" {
+		text += " (built: " + info.BuildDate + ")"
 	}
-	return info
+	return text + " " + info.GoVersion + " " + info.Platform
+}
+
+// GetJSONVersionInfo returns version and build metadata as indented JSON
+func GetJSONVersionInfo() string {
+	data, _ := json.MarshalIndent(GetInfo(), "", "  ")
+	return string(data)
 }
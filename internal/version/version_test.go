@@ -0,0 +1,32 @@
+package version
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestGetVersion validates the embedded VERSION is reported regardless of the working directory
+func TestGetVersion(t *testing.T) {
+	content, err := os.ReadFile("../../VERSION")
+	if err != nil {
+		t.Fatalf("Failed to read VERSION: %v", err)
+	}
+	t.Chdir(t.TempDir())
+
+	if got := GetVersion(); got != strings.TrimSpace(string(content)) {
+		t.Errorf("Expected version %q, got %q", strings.TrimSpace(string(content)), got)
+	}
+}
+
+// TestGetJSONVersionInfo validates the machine-readable version output
+func TestGetJSONVersionInfo(t *testing.T) {
+	var info Info
+	if err := json.Unmarshal([]byte(GetJSONVersionInfo()), &info); err != nil {
+		t.Fatalf("Expected valid JSON, got error: %v", err)
+	}
+	if info.Version != GetVersion() || info.GoVersion == "" || info.Platform == "" {
+		t.Errorf("Unexpected version info: %+v", info)
+	}
+}
@@ -0,0 +1,15 @@
+// Package csv2json embeds release metadata kept at the repository root.
+package csv2json
+
+import (
+	_ "embed"
+	"strings"
+)
+
+//go:embed VERSION
+var versionFile string
+
+// Version returns the contents of the VERSION file the binary was built from
+func Version() string {
+	return strings.TrimSpace(versionFile)
+}